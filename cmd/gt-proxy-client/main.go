@@ -2,6 +2,13 @@
 // When GT_PROXY_URL, GT_PROXY_CERT, GT_PROXY_KEY, and GT_PROXY_CA are all set, it forwards
 // os.Args[1:] to the proxy server over mTLS and proxies the response.
 // Otherwise it execs the real binary at /usr/local/bin/gt.real (or the path in GT_REAL_BIN).
+//
+// Invoked as gt-sandbox-init it is instead the init process of a gt sandbox: it relays
+// the proxy's unix socket into the sandbox and runs the agent (see sandbox_init_unix.go).
+//
+// By default the command runs over the streaming endpoint (/v1/exec/stream), which relays
+// stdin and streams stdout/stderr as they are produced. Against an older proxy without that
+// endpoint, or with GT_PROXY_MODE=buffered, it uses the request/response /v1/exec instead.
 package main
 
 import (
@@ -9,6 +16,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +24,8 @@ import (
 	"path/filepath"
 	"syscall"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/proxy/execstream"
 )
 
 type execRequest struct {
@@ -28,7 +38,15 @@ type execResponse struct {
 	ExitCode int    `json:"exitCode"`
 }
 
+// sandboxInitName is the argv[0] under which gt-proxy-client runs as the init
+// process of a gt sandbox (see internal/sandbox).
+const sandboxInitName = "gt-sandbox-init"
+
 func main() {
+	if toolNameFromArg0(os.Args[0]) == sandboxInitName {
+		os.Exit(sandboxInit(os.Args[1:]))
+	}
+
 	// Required environment variables:
 	//   GT_PROXY_URL  — proxy base URL (e.g. https://172.17.0.1:9876)
 	//   GT_PROXY_CERT — path to PEM client cert (issued by proxy CA)
@@ -36,6 +54,7 @@ func main() {
 	//   GT_PROXY_CA   — path to PEM proxy CA cert (used to verify server cert)
 	// Optional:
 	//   GT_REAL_BIN   — fallback binary path (default /usr/local/bin/gt.real)
+	//   GT_PROXY_MODE — "buffered" to skip the streaming endpoint
 	proxyURL := os.Getenv("GT_PROXY_URL")
 	certFile := os.Getenv("GT_PROXY_CERT")
 	keyFile := os.Getenv("GT_PROXY_KEY")
@@ -74,17 +93,31 @@ func main() {
 		RootCAs:      pool,
 	}
 
-	httpClient := &http.Client{
-		Timeout:   5 * time.Minute,
-		Transport: &http.Transport{TLSClientConfig: tlsCfg},
-	}
-
 	// Determine argv: prepend the binary name so the server knows which tool we are.
 	argv := os.Args // os.Args[0] is the binary path; the server needs the tool name as argv[0].
 	// Replace argv[0] with the tool name (gt or bd) based on the binary name.
 	toolName := toolNameFromArg0(os.Args[0])
 	argv = append([]string{toolName}, os.Args[1:]...)
 
+	if os.Getenv("GT_PROXY_MODE") != "buffered" {
+		// No overall timeout: the command may legitimately run (and read stdin)
+		// for as long as the server's exec timeout allows.
+		streamClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:       tlsCfg,
+				ResponseHeaderTimeout: time.Minute,
+			},
+		}
+		if exitCode, ok := execStream(streamClient, proxyURL, argv); ok {
+			os.Exit(exitCode)
+		}
+	}
+
+	httpClient := &http.Client{
+		Timeout:   5 * time.Minute,
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+	}
+
 	body, err := json.Marshal(execRequest{Argv: argv})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "gt-proxy-client: encode request: %v\n", err)
@@ -119,6 +152,96 @@ func main() {
 	os.Exit(result.ExitCode)
 }
 
+// execStream runs argv over /v1/exec/stream, relaying stdin when it is not a
+// terminal and copying output frames to stdout/stderr as they arrive. It
+// returns ok == false if the server does not have the streaming endpoint;
+// any other failure exits the process.
+func execStream(client *http.Client, proxyURL string, argv []string) (exitCode int, ok bool) {
+	sendStdin := stdinIsPiped()
+	pr, pw := io.Pipe()
+	go func() {
+		fw := execstream.NewWriter(pw, nil)
+		if err := fw.JSON(execstream.Start, execstream.StartRequest{Argv: argv, Stdin: sendStdin}); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if sendStdin {
+			if _, err := io.Copy(fw.Stream(execstream.Stdin), os.Stdin); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if err := fw.Frame(execstream.StdinEOF, nil); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close() //nolint:errcheck // io.PipeWriter.Close never fails
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, proxyURL+"/v1/exec/stream", pr) //nolint:gosec // proxyURL is from trusted env var GT_PROXY_URL
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt-proxy-client: build request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Set("Content-Type", execstream.ContentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt-proxy-client: proxy request failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort close on response body
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		// Proxy predates the streaming endpoint.
+		return 0, false
+	default:
+		msg, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "gt-proxy-client: server error %d: %s\n", resp.StatusCode, msg)
+		os.Exit(1)
+	}
+
+	for {
+		t, payload, err := execstream.ReadFrame(resp.Body)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			fmt.Fprintf(os.Stderr, "gt-proxy-client: read stream: %v\n", err)
+			os.Exit(1)
+		}
+		switch t {
+		case execstream.Stdout:
+			_, _ = os.Stdout.Write(payload)
+		case execstream.Stderr:
+			_, _ = os.Stderr.Write(payload)
+		case execstream.Exit:
+			var status execstream.ExitStatus
+			if err := json.Unmarshal(payload, &status); err != nil {
+				fmt.Fprintf(os.Stderr, "gt-proxy-client: decode exit status: %v\n", err)
+				os.Exit(1)
+			}
+			if status.Error != "" {
+				fmt.Fprintf(os.Stderr, "gt-proxy-client: %s\n", status.Error)
+			}
+			return status.ExitCode, true
+		}
+	}
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file worth forwarding.
+// Terminals and /dev/null (character devices) are not forwarded, so an
+// interactive shell never blocks waiting for input the command won't read.
+func stdinIsPiped() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
+}
+
 // toolNameFromArg0 extracts "gt" or "bd" from the argv[0] binary path.
 func toolNameFromArg0(arg0 string) string {
 	return filepath.Base(arg0)
@@ -0,0 +1,124 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// sandboxInit runs argv as the sandbox's agent and returns its exit code.
+//
+// When GT_SANDBOX_SOCKET is set the sandbox has no network of its own, so the
+// init relays TCP connections on GT_PROXY_URL's address (inside the sandbox)
+// to the proxy's unix socket. The TLS session is end to end; the relay only
+// copies bytes.
+//
+// As PID 1 of the sandbox's PID namespace the init also reaps orphaned
+// processes and relays SIGTERM and SIGHUP to the agent, exiting when the agent
+// does. Terminal interrupts already reach the agent through its process group
+// and are not relayed again.
+func sandboxInit(argv []string) int {
+	if len(argv) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: usage: %s <command> [args...]\n", sandboxInitName, sandboxInitName)
+		return 2
+	}
+
+	if sock := os.Getenv("GT_SANDBOX_SOCKET"); sock != "" {
+		ln, err := listenForProxy(os.Getenv("GT_PROXY_URL"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sandboxInitName, err)
+			return 1
+		}
+		go relayToSocket(ln, sock)
+	}
+
+	sigs := make(chan os.Signal, 8)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGCHLD)
+
+	cmd := exec.Command(argv[0], argv[1:]...) //nolint:gosec // argv is the agent command from the startup command
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", sandboxInitName, err)
+		return 127
+	}
+	agent := cmd.Process.Pid
+
+	for sig := range sigs {
+		switch sig {
+		case syscall.SIGTERM, syscall.SIGHUP:
+			_ = cmd.Process.Signal(sig)
+		case syscall.SIGCHLD:
+			if code, done := reap(agent); done {
+				return code
+			}
+		}
+	}
+	return 1
+}
+
+// reap collects every exited child. It reports the agent's exit code once
+// the agent itself has exited.
+func reap(agent int) (code int, done bool) {
+	for {
+		var ws syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return 0, false
+		}
+		if pid == agent {
+			if ws.Signaled() {
+				return 128 + int(ws.Signal()), true
+			}
+			return ws.ExitStatus(), true
+		}
+	}
+}
+
+// listenForProxy listens on the host:port of proxyURL.
+func listenForProxy(proxyURL string) (net.Listener, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GT_PROXY_URL %q", proxyURL)
+	}
+	ln, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("proxy relay: %w", err)
+	}
+	return ln, nil
+}
+
+// relayToSocket accepts connections on ln and pipes each to the unix socket.
+func relayToSocket(ln net.Listener, sock string) {
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer c.Close() //nolint:errcheck // best-effort close
+			up, err := net.Dial("unix", sock)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: proxy socket: %v\n", sandboxInitName, err)
+				return
+			}
+			defer up.Close() //nolint:errcheck // best-effort close
+			go func() {
+				_, _ = io.Copy(up, c)
+				if uc, ok := up.(*net.UnixConn); ok {
+					_ = uc.CloseWrite()
+				}
+			}()
+			_, _ = io.Copy(c, up)
+		}()
+	}
+}
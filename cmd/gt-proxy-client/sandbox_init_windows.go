@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// sandboxInit is unsupported on Windows; gt sandboxes are Linux-only.
+func sandboxInit([]string) int {
+	fmt.Fprintf(os.Stderr, "%s: sandboxes require Linux\n", sandboxInitName)
+	return 1
+}
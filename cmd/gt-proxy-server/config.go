@@ -56,6 +56,19 @@ type ProxyConfig struct {
 	//   - A split-horizon DNS entry that resolves to the proxy IP
 	//   - A mDNS name (e.g. "macbook.local")
 	ExtraSANHosts []string `json:"extra_san_hosts"`
+
+	// PolicyFile is the per-identity exec policy (see proxy.Policy).
+	// Defaults to <town_root>/.runtime/proxy/policy.json if empty.
+	PolicyFile string `json:"policy_file"`
+
+	// AuditFile is the hash-chained audit journal (see proxy.AuditJournal).
+	// Defaults to <town_root>/.runtime/proxy/audit.jsonl if empty.
+	AuditFile string `json:"audit_file"`
+
+	// SocketFile is the unix socket sandboxed polecats reach the proxy
+	// through. Defaults to <town_root>/.runtime/proxy/proxy.sock if empty;
+	// "none" disables it.
+	SocketFile string `json:"socket_file"`
 }
 
 // loadConfig reads the config file at path and returns a ProxyConfig.
@@ -24,6 +24,9 @@ func TestLoadConfig(t *testing.T) {
 			},
 			ExtraSANIPs:   []string{"170.170.170.170", "10.8.0.1"},
 			ExtraSANHosts: []string{"proxy.mycompany.com", "gt-proxy.local"},
+			PolicyFile:    "/tmp/gt/policy.json",
+			AuditFile:     "/tmp/gt/audit.jsonl",
+			SocketFile:    "/tmp/gt/proxy.sock",
 		}
 		data, err := json.Marshal(cfg)
 		require.NoError(t, err)
@@ -42,6 +45,9 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, []string{"create", "show"}, got.AllowedSubcommands["bd"])
 		assert.Equal(t, []string{"170.170.170.170", "10.8.0.1"}, got.ExtraSANIPs)
 		assert.Equal(t, []string{"proxy.mycompany.com", "gt-proxy.local"}, got.ExtraSANHosts)
+		assert.Equal(t, "/tmp/gt/policy.json", got.PolicyFile)
+		assert.Equal(t, "/tmp/gt/audit.jsonl", got.AuditFile)
+		assert.Equal(t, "/tmp/gt/proxy.sock", got.SocketFile)
 	})
 }
 
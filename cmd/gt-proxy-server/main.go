@@ -34,7 +34,10 @@ func main() {
 		allowedCmds    = flag.String("allowed-cmds", "gt,bd", "comma-separated list of allowed commands")
 		allowedSubcmds = flag.String("allowed-subcmds", discoverAllowedSubcmds(),
 			`semicolon-separated list of "cmd:sub1,sub2,..." subcommand allowlists`)
-		townRoot = flag.String("town-root", "", "Gas Town root directory (default: $GT_TOWN or ~/gt)")
+		townRoot   = flag.String("town-root", "", "Gas Town root directory (default: $GT_TOWN or ~/gt)")
+		policyFile = flag.String("policy", "", "per-identity exec policy file (default: <town-root>/.runtime/proxy/policy.json)")
+		auditFile  = flag.String("audit", "", "hash-chained audit journal (default: <town-root>/.runtime/proxy/audit.jsonl)")
+		socketFile = flag.String("socket", "", `unix socket for sandboxed polecats (default: <town-root>/.runtime/proxy/proxy.sock; "none" disables)`)
 	)
 	flag.Parse()
 
@@ -72,6 +75,15 @@ func main() {
 	if !explicitFlags["town-root"] && fileCfg.TownRoot != "" {
 		*townRoot = fileCfg.TownRoot
 	}
+	if !explicitFlags["policy"] && fileCfg.PolicyFile != "" {
+		*policyFile = fileCfg.PolicyFile
+	}
+	if !explicitFlags["audit"] && fileCfg.AuditFile != "" {
+		*auditFile = fileCfg.AuditFile
+	}
+	if !explicitFlags["socket"] && fileCfg.SocketFile != "" {
+		*socketFile = fileCfg.SocketFile
+	}
 	if !explicitFlags["allowed-cmds"] && len(fileCfg.AllowedCommands) > 0 {
 		*allowedCmds = strings.Join(fileCfg.AllowedCommands, ",")
 	}
@@ -91,6 +103,13 @@ func main() {
 		}
 	}
 
+	switch *socketFile {
+	case "":
+		*socketFile = proxy.SocketPath(*townRoot)
+	case "none":
+		*socketFile = ""
+	}
+
 	ca, err := proxy.LoadOrGenerateCA(*caDir)
 	if err != nil {
 		slog.Error("CA setup failed", "err", err)
@@ -135,6 +154,9 @@ func main() {
 		TownRoot:           *townRoot,
 		ExtraSANIPs:        extraSANIPs,
 		ExtraSANHosts:      extraSANHosts,
+		PolicyPath:         *policyFile,
+		AuditPath:          *auditFile,
+		SocketPath:         *socketFile,
 	}
 
 	srv, err := proxy.New(cfg, ca)
@@ -522,6 +522,10 @@ type CreateOptions struct {
 	Actor       string // Who is creating this issue (populates created_by)
 	Ephemeral   bool   // Create as ephemeral (wisp) - not synced to git
 	Rig         string // Target rig database (e.g., "gantry"). When set, binds create to the rig's .beads directory.
+
+	// AllowFlagTitle skips the flag-like title guard for callers whose title
+	// is user-supplied text rather than parsed argv (e.g. the dashboard).
+	AllowFlagTitle bool
 }
 
 // UpdateOptions specifies options for updating an issue.
@@ -1707,7 +1711,7 @@ func (b *Beads) Blocked() ([]*Issue, error) {
 // This ensures created_by is populated for issue provenance tracking.
 func (b *Beads) Create(opts CreateOptions) (*Issue, error) {
 	// Guard against flag-like titles (gt-e0kx5: --help garbage beads)
-	if IsFlagLikeTitle(opts.Title) {
+	if !opts.AllowFlagTitle && IsFlagLikeTitle(opts.Title) {
 		return nil, fmt.Errorf("refusing to create bead: %w (got %q)", ErrFlagTitle, opts.Title)
 	}
 
@@ -1782,7 +1786,7 @@ func (b *Beads) Create(opts CreateOptions) (*Issue, error) {
 // deterministic IDs rather than auto-generated ones.
 func (b *Beads) CreateWithID(id string, opts CreateOptions) (*Issue, error) {
 	// Guard against flag-like titles (gt-e0kx5: --help garbage beads)
-	if IsFlagLikeTitle(opts.Title) {
+	if !opts.AllowFlagTitle && IsFlagLikeTitle(opts.Title) {
 		return nil, fmt.Errorf("refusing to create bead: %w (got %q)", ErrFlagTitle, opts.Title)
 	}
 
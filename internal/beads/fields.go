@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -1129,3 +1130,393 @@ func ExpandRolePattern(pattern, townRoot, rig, name, role, prefix string) string
 	result = strings.ReplaceAll(result, "{prefix}", prefix)
 	return result
 }
+
+// StepFields holds the workflow step metadata gt writes on step beads created
+// from a formula (gt formula run). These fields are stored as
+// "workflow_<key>: value" lines in the issue description, alongside
+// workflow_target.
+type StepFields struct {
+	Step          string // Formula step ID (e.g., "migrate-db")
+	When          string // Condition (vars already bound); the step is skipped when false
+	RepeatUntil   string // Loop condition checked each time the step finishes
+	MaxIterations int    // Loop bound for RepeatUntil
+	Iteration     int    // 1-based run number of the current iteration
+	Skipped       string // Reason the step was skipped, set when its When was false
+	Workflow      string // Workflow root bead (hq-wf-*) that tracks this step
+
+	// OutputTypes maps each declared output name to its type
+	// (string, json, path); Outputs holds the values recorded so far.
+	OutputTypes map[string]string
+	Outputs     map[string]string
+
+	// Agent, Model, and MinCapability are the step's agent constraints;
+	// RanAgent and RanModel record the agent preset and model it was
+	// dispatched to.
+	Agent         string
+	Model         string
+	MinCapability string
+	RanAgent      string
+	RanModel      string
+
+	// Failure policy (formula timeout, retries, retry_backoff, on_failure)
+	// and its runtime state. StartedAt (RFC3339) is set each time the step
+	// is dispatched; Attempt counts dispatches of the current iteration;
+	// RetryAt (RFC3339) is when a failed step is due to be re-dispatched;
+	// Failure describes the last failure and how it was handled.
+	Timeout      string
+	Retries      int
+	RetryBackoff string
+	OnFailure    string
+	StartedAt    string
+	Attempt      int
+	RetryAt      string
+	Failure      string
+}
+
+// stepOutputKeyPrefix prefixes the description key of each recorded output
+// (workflow_output.<name>: "<quoted value>").
+const stepOutputKeyPrefix = "workflow_output."
+
+var stepFieldKeys = map[string]bool{
+	"workflow_step":           true,
+	"workflow_when":           true,
+	"workflow_repeat_until":   true,
+	"workflow_max_iterations": true,
+	"workflow_iteration":      true,
+	"workflow_skipped":        true,
+	"workflow_root":           true,
+	"workflow_outputs":        true,
+	"workflow_agent":          true,
+	"workflow_model":          true,
+	"workflow_min_capability": true,
+	"workflow_ran_agent":      true,
+	"workflow_ran_model":      true,
+	"workflow_timeout":        true,
+	"workflow_retries":        true,
+	"workflow_retry_backoff":  true,
+	"workflow_on_failure":     true,
+	"workflow_started_at":     true,
+	"workflow_attempt":        true,
+	"workflow_retry_at":       true,
+	"workflow_failure":        true,
+}
+
+func isStepFieldKey(key string) bool {
+	return stepFieldKeys[key] || strings.HasPrefix(key, stepOutputKeyPrefix)
+}
+
+// ParseStepFields extracts workflow step fields from an issue's description.
+// Returns nil if no step fields are found.
+func ParseStepFields(issue *Issue) *StepFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &StepFields{}
+	hasFields := false
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		rawKey := strings.TrimSpace(key)
+		key = strings.ToLower(rawKey)
+		value = strings.TrimSpace(value)
+		if !isStepFieldKey(key) || value == "" {
+			continue
+		}
+		if strings.HasPrefix(key, stepOutputKeyPrefix) {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if fields.Outputs == nil {
+				fields.Outputs = make(map[string]string)
+			}
+			fields.Outputs[rawKey[len(stepOutputKeyPrefix):]] = value
+			hasFields = true
+			continue
+		}
+		switch key {
+		case "workflow_step":
+			fields.Step = value
+		case "workflow_when":
+			fields.When = value
+		case "workflow_repeat_until":
+			fields.RepeatUntil = value
+		case "workflow_max_iterations":
+			fields.MaxIterations, _ = parseIntField(value)
+		case "workflow_iteration":
+			fields.Iteration, _ = parseIntField(value)
+		case "workflow_skipped":
+			fields.Skipped = value
+		case "workflow_root":
+			fields.Workflow = value
+		case "workflow_agent":
+			fields.Agent = value
+		case "workflow_model":
+			fields.Model = value
+		case "workflow_min_capability":
+			fields.MinCapability = value
+		case "workflow_ran_agent":
+			fields.RanAgent = value
+		case "workflow_ran_model":
+			fields.RanModel = value
+		case "workflow_timeout":
+			fields.Timeout = value
+		case "workflow_retries":
+			fields.Retries, _ = parseIntField(value)
+		case "workflow_retry_backoff":
+			fields.RetryBackoff = value
+		case "workflow_on_failure":
+			fields.OnFailure = value
+		case "workflow_started_at":
+			fields.StartedAt = value
+		case "workflow_attempt":
+			fields.Attempt, _ = parseIntField(value)
+		case "workflow_retry_at":
+			fields.RetryAt = value
+		case "workflow_failure":
+			fields.Failure = value
+		case "workflow_outputs":
+			fields.OutputTypes = make(map[string]string)
+			for _, decl := range strings.Split(value, ",") {
+				name, typ, _ := strings.Cut(strings.TrimSpace(decl), ":")
+				if name != "" {
+					fields.OutputTypes[name] = typ
+				}
+			}
+		}
+		hasFields = true
+	}
+
+	if !hasFields {
+		return nil
+	}
+	return fields
+}
+
+// FormatStepFields formats StepFields as description lines.
+// Only non-empty fields are included.
+func FormatStepFields(fields *StepFields) string {
+	if fields == nil {
+		return ""
+	}
+
+	var lines []string
+	if fields.Step != "" {
+		lines = append(lines, "workflow_step: "+fields.Step)
+	}
+	if fields.When != "" {
+		lines = append(lines, "workflow_when: "+fields.When)
+	}
+	if fields.RepeatUntil != "" {
+		lines = append(lines, "workflow_repeat_until: "+fields.RepeatUntil)
+	}
+	if fields.MaxIterations > 0 {
+		lines = append(lines, fmt.Sprintf("workflow_max_iterations: %d", fields.MaxIterations))
+	}
+	if fields.Iteration > 0 {
+		lines = append(lines, fmt.Sprintf("workflow_iteration: %d", fields.Iteration))
+	}
+	if fields.Skipped != "" {
+		lines = append(lines, "workflow_skipped: "+fields.Skipped)
+	}
+	if fields.Workflow != "" {
+		lines = append(lines, "workflow_root: "+fields.Workflow)
+	}
+	if fields.Agent != "" {
+		lines = append(lines, "workflow_agent: "+fields.Agent)
+	}
+	if fields.Model != "" {
+		lines = append(lines, "workflow_model: "+fields.Model)
+	}
+	if fields.MinCapability != "" {
+		lines = append(lines, "workflow_min_capability: "+fields.MinCapability)
+	}
+	if fields.RanAgent != "" {
+		lines = append(lines, "workflow_ran_agent: "+fields.RanAgent)
+	}
+	if fields.RanModel != "" {
+		lines = append(lines, "workflow_ran_model: "+fields.RanModel)
+	}
+	if fields.Timeout != "" {
+		lines = append(lines, "workflow_timeout: "+fields.Timeout)
+	}
+	if fields.Retries > 0 {
+		lines = append(lines, fmt.Sprintf("workflow_retries: %d", fields.Retries))
+	}
+	if fields.RetryBackoff != "" {
+		lines = append(lines, "workflow_retry_backoff: "+fields.RetryBackoff)
+	}
+	if fields.OnFailure != "" {
+		lines = append(lines, "workflow_on_failure: "+fields.OnFailure)
+	}
+	if fields.StartedAt != "" {
+		lines = append(lines, "workflow_started_at: "+fields.StartedAt)
+	}
+	if fields.Attempt > 0 {
+		lines = append(lines, fmt.Sprintf("workflow_attempt: %d", fields.Attempt))
+	}
+	if fields.RetryAt != "" {
+		lines = append(lines, "workflow_retry_at: "+fields.RetryAt)
+	}
+	if fields.Failure != "" {
+		lines = append(lines, "workflow_failure: "+fields.Failure)
+	}
+	if len(fields.OutputTypes) > 0 {
+		decls := make([]string, 0, len(fields.OutputTypes))
+		for _, name := range sortedKeys(fields.OutputTypes) {
+			decls = append(decls, name+":"+fields.OutputTypes[name])
+		}
+		lines = append(lines, "workflow_outputs: "+strings.Join(decls, ", "))
+	}
+	for _, name := range sortedKeys(fields.Outputs) {
+		lines = append(lines, stepOutputKeyPrefix+name+": "+strconv.Quote(fields.Outputs[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetStepFields updates an issue's description with the given step fields.
+// Existing step field lines are replaced; other content is preserved.
+// Returns the new description string.
+func SetStepFields(issue *Issue, fields *StepFields) string {
+	formatted := FormatStepFields(fields)
+	if issue == nil {
+		return formatted
+	}
+
+	var otherLines []string
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && isStepFieldKey(strings.ToLower(strings.TrimSpace(key))) {
+			continue
+		}
+		otherLines = append(otherLines, line)
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[0]) == "" {
+		otherLines = otherLines[1:]
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[len(otherLines)-1]) == "" {
+		otherLines = otherLines[:len(otherLines)-1]
+	}
+
+	if formatted == "" {
+		return strings.Join(otherLines, "\n")
+	}
+	if len(otherLines) == 0 {
+		return formatted
+	}
+	return formatted + "\n\n" + strings.Join(otherLines, "\n")
+}
+
+// TemplateFields holds the fields gt writes on issues instantiated from a
+// convoy template (gt convoy template run), and the sling formula a batch
+// sling records on beads it stages. These fields are stored as key: value
+// lines in the issue description.
+type TemplateFields struct {
+	Template     string   // Convoy template the issue was instantiated from
+	SlingFormula string   // Formula gt sling applies when none is given
+	SlingVars    []string // Variables for SlingFormula (key=value)
+}
+
+// ParseTemplateFields extracts convoy template fields from an issue's
+// description. Returns nil if no template fields are found.
+func ParseTemplateFields(issue *Issue) *TemplateFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &TemplateFields{}
+	hasFields := false
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "convoy_template":
+			fields.Template = value
+		case "sling_formula":
+			fields.SlingFormula = value
+		case "sling_vars":
+			fields.SlingVars = parseAttachedVars(value)
+		default:
+			continue
+		}
+		hasFields = true
+	}
+
+	if !hasFields {
+		return nil
+	}
+	return fields
+}
+
+// FormatTemplateFields formats TemplateFields as description lines.
+// Only non-empty fields are included.
+func FormatTemplateFields(fields *TemplateFields) string {
+	if fields == nil {
+		return ""
+	}
+
+	var lines []string
+	if fields.Template != "" {
+		lines = append(lines, "convoy_template: "+fields.Template)
+	}
+	if fields.SlingFormula != "" {
+		lines = append(lines, "sling_formula: "+fields.SlingFormula)
+	}
+	if len(fields.SlingVars) > 0 {
+		lines = append(lines, "sling_vars: "+formatAttachedVars(fields.SlingVars))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetTemplateFields updates an issue's description with the given template
+// fields. Existing template field lines are replaced; other content is
+// preserved. Returns the new description string.
+func SetTemplateFields(issue *Issue, fields *TemplateFields) string {
+	formatted := FormatTemplateFields(fields)
+	if issue == nil {
+		return formatted
+	}
+
+	var otherLines []string
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok {
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "convoy_template", "sling_formula", "sling_vars":
+				continue
+			}
+		}
+		otherLines = append(otherLines, line)
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[0]) == "" {
+		otherLines = otherLines[1:]
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[len(otherLines)-1]) == "" {
+		otherLines = otherLines[:len(otherLines)-1]
+	}
+
+	if formatted == "" {
+		return strings.Join(otherLines, "\n")
+	}
+	if len(otherLines) == 0 {
+		return formatted
+	}
+	return strings.Join(otherLines, "\n") + "\n\n" + formatted
+}
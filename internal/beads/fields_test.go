@@ -1,6 +1,7 @@
 package beads
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -768,3 +769,55 @@ func TestSetConvoyFieldsPreservesWatchers(t *testing.T) {
 		t.Errorf("lost prose, got:\n%s", got)
 	}
 }
+
+func TestStepFieldsRoundTrip(t *testing.T) {
+	issue := &Issue{Description: "workflow_target: gastown/crew/max\n\nRun the migration."}
+	fields := &StepFields{
+		Step:          "review",
+		RepeatUntil:   `steps.review.outputs.verdict == "approve"`,
+		MaxIterations: 3,
+		Iteration:     1,
+		Workflow:      "hq-wf-abc12",
+		OutputTypes:   map[string]string{"verdict": "string", "report": "json"},
+		Outputs:       map[string]string{"verdict": "changes\nrequested", "report": `{"ok":false}`},
+		MinCapability: "advanced",
+		RanAgent:      "claude",
+		RanModel:      "opus",
+		Timeout:       "30m",
+		Retries:       2,
+		OnFailure:     "goto:plan",
+		StartedAt:     "2026-01-10T12:00:00Z",
+		Attempt:       1,
+	}
+	issue.Description = SetStepFields(issue, fields)
+	if got := ParseStepFields(issue); got == nil || !reflect.DeepEqual(got, fields) {
+		t.Fatalf("ParseStepFields = %+v, want %+v", got, fields)
+	}
+
+	fields.Iteration = 2
+	issue.Description = SetStepFields(issue, fields)
+	if strings.Count(issue.Description, "workflow_iteration:") != 1 {
+		t.Errorf("iteration line duplicated:\n%s", issue.Description)
+	}
+	if !strings.Contains(issue.Description, "workflow_target: gastown/crew/max") || !strings.Contains(issue.Description, "Run the migration.") {
+		t.Errorf("other content lost:\n%s", issue.Description)
+	}
+	if ParseStepFields(&Issue{Description: "plain prose"}) != nil {
+		t.Error("ParseStepFields on prose should be nil")
+	}
+}
+
+func TestTemplateFieldsRoundTrip(t *testing.T) {
+	fields := &TemplateFields{
+		Template:     "deps-weekly",
+		SlingFormula: "mol-dep-update",
+		SlingVars:    []string{"ecosystem=go", "note=a, b"},
+	}
+	issue := &Issue{Description: "Bump dependencies.\n\n" + FormatTemplateFields(fields)}
+	if got := ParseTemplateFields(issue); !reflect.DeepEqual(got, fields) {
+		t.Fatalf("ParseTemplateFields = %+v, want %+v", got, fields)
+	}
+	if ParseTemplateFields(&Issue{Description: "plain prose"}) != nil {
+		t.Error("ParseTemplateFields on prose should be nil")
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// loadCapabilityProfiles returns the town's declared and learned capability
+// profiles. A corrupt history file degrades to declared skills only.
+func loadCapabilityProfiles(townRoot string) *capacity.CapabilityProfiles {
+	history, err := capacity.LoadCapabilityHistory(townRoot)
+	if err != nil {
+		style.PrintWarning("could not load capability history: %v", err)
+		history = nil
+	}
+	return capacity.NewCapabilityProfiles(loadSchedulerConfig(townRoot), history)
+}
+
+// findIdlePolecatForSling picks the idle polecat to reuse for a bead.
+// Without capability needs this is FindIdlePolecat (first idle polecat).
+// With needs, every idle polecat is ranked by its capability profile; when
+// none has the required capabilities the best partial match is still reused,
+// so capability labels never leave work stranded behind a busy specialist.
+func findIdlePolecatForSling(polecatMgr *polecat.Manager, profiles *capacity.CapabilityProfiles, rigName string, needs capacity.CapabilityNeeds) (*polecat.Polecat, error) {
+	if needs.Empty() || profiles == nil {
+		return polecatMgr.FindIdlePolecat()
+	}
+	idle, err := polecatMgr.FindIdlePolecats()
+	if err != nil || len(idle) == 0 {
+		return nil, err
+	}
+	candidates := make([]capacity.CapabilityCandidate, len(idle))
+	for i, p := range idle {
+		candidates[i] = capacity.CapabilityCandidate{Name: p.Name, Skills: profiles.Polecat(rigName, p.Name)}
+	}
+	best, satisfied := capacity.BestCandidate(needs, candidates)
+	if satisfied && len(needs.Required) > 0 {
+		fmt.Printf("  Capability match: %s has %s\n", idle[best].Name, strings.Join(needs.Required, ", "))
+	}
+	return idle[best], nil
+}
+
+// routeAgentForCapabilities chooses an agent preset for a bead whose required
+// capabilities the polecat itself lacks. An explicit agent (--agent or the
+// sling context) always wins. Returns the agent to use ("" = role default).
+func routeAgentForCapabilities(profiles *capacity.CapabilityProfiles, needs capacity.CapabilityNeeds, polecatSkills []string, agent string) string {
+	if needs.Empty() || profiles == nil {
+		return agent
+	}
+	missing := needs.Missing(append(append([]string(nil), polecatSkills...), profiles.Agent(agent)...))
+	if len(missing) == 0 {
+		return agent
+	}
+	if agent == "" {
+		var candidates []capacity.CapabilityCandidate
+		for _, name := range profiles.Agents() {
+			candidates = append(candidates, capacity.CapabilityCandidate{Name: name, Skills: profiles.Agent(name)})
+		}
+		remaining := capacity.CapabilityNeeds{Required: missing, Preferred: needs.Preferred}
+		if best, ok := capacity.BestCandidate(remaining, candidates); best >= 0 && ok {
+			fmt.Printf("  Capability match: agent %s has %s\n", candidates[best].Name, strings.Join(missing, ", "))
+			return candidates[best].Name
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  %s no idle polecat or agent has %s; dispatching anyway\n",
+		style.Warning.Render("⚠"), formatCapabilityLabels(missing))
+	return agent
+}
+
+func formatCapabilityLabels(caps []string) string {
+	labels := make([]string, len(caps))
+	for i, c := range caps {
+		labels[i] = capacity.LabelCapabilityPrefix + c
+	}
+	return strings.Join(labels, ", ")
+}
+
+// recordCapabilityCompletion credits a polecat (and the agent preset it ran,
+// from GT_AGENT) with the capability labels of a bead it completed. Best-effort:
+// history only improves routing, so failures are reported and ignored.
+func recordCapabilityCompletion(townRoot, sender, beadID string) {
+	if beadID == "" {
+		return
+	}
+	id, err := session.ParseAddress(sender)
+	if err != nil || id.Role != session.RolePolecat {
+		return
+	}
+	info, err := getBeadInfoFromTownRoot(townRoot, beadID)
+	if err != nil {
+		return
+	}
+	caps := capacity.CapabilityNeedsFromLabels(info.Labels).All()
+	if len(caps) == 0 {
+		return
+	}
+	polecatID := id.Rig + "/" + id.Name
+	if err := capacity.RecordCapabilityCompletion(townRoot, polecatID, os.Getenv("GT_AGENT"), caps); err != nil {
+		style.PrintWarning("could not record capability history: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
+)
+
+func TestRouteAgentForCapabilities(t *testing.T) {
+	profiles := capacity.NewCapabilityProfiles(&capacity.SchedulerConfig{
+		Capabilities: &capacity.CapabilityConfig{
+			Agents: map[string][]string{
+				"codex":  {"migrations"},
+				"gemini": {"frontend", "css"},
+			},
+		},
+	}, nil)
+	frontend := capacity.CapabilityNeeds{Required: []string{"frontend"}}
+
+	tests := []struct {
+		name          string
+		needs         capacity.CapabilityNeeds
+		polecatSkills []string
+		agent         string
+		want          string
+	}{
+		{"no needs keeps default", capacity.CapabilityNeeds{}, nil, "", ""},
+		{"polecat already capable", frontend, []string{"frontend"}, "", ""},
+		{"agent fills missing capability", frontend, nil, "", "gemini"},
+		{"explicit agent wins", frontend, nil, "codex", "codex"},
+		{"no match falls back to default", capacity.CapabilityNeeds{Required: []string{"rust"}}, nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routeAgentForCapabilities(profiles, tt.needs, tt.polecatSkills, tt.agent); got != tt.want {
+				t.Errorf("routeAgentForCapabilities = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
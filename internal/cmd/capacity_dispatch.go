@@ -7,7 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -69,12 +71,90 @@ var fireCrossRigEscalation = func(rig, prefix, beadID string) {
 	}
 }
 
+// slaRiskEscalationDebounce is the minimum interval between SLA-risk
+// escalations for the same work bead. The risk is re-evaluated every dispatch
+// tick; without debounce a bead stuck behind full capacity would page on
+// every heartbeat until it dispatches or its context is cleared.
+const slaRiskEscalationDebounce = 30 * time.Minute
+
+// fireSLARiskEscalation invokes `gt escalate` with HIGH severity. Best effort —
+// escalation failure is logged but does not block the dispatch path.
+var fireSLARiskEscalation = func(risk capacity.SLARisk) {
+	msg := fmt.Sprintf("scheduler SLA at risk: bead=%s rig=%s due=%s slack=%s — capacity exhausted",
+		risk.Bead.WorkBeadID, risk.Bead.TargetRig, risk.DueAt.UTC().Format(time.RFC3339), risk.Slack.Round(time.Second))
+	cmd := exec.Command("gt", "escalate", "--severity", "high", "--reason", "sla-risk",
+		"--fingerprint", "sla-risk:"+risk.Bead.WorkBeadID, msg)
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s SLA-risk escalation failed: %v\n", style.Warning.Render("⚠"), err)
+	}
+}
+
+// reportSLARisks emits a scheduler_sla_risk event and a debounced escalation
+// for each queued bead whose deadline is threatened by lack of capacity.
+// A risk is printed when its escalation fires or the at-risk set changes,
+// not on every tick it persists. Escalation times and the reported set are
+// kept in capacity.SLARiskState, since each dispatch tick is a new process.
+func reportSLARisks(townRoot, actor string, risks []capacity.SLARisk, now time.Time) {
+	state, err := capacity.LoadSLARiskState(townRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s loading SLA-risk state: %v\n", style.Warning.Render("⚠"), err)
+		state = &capacity.SLARiskState{Escalated: map[string]time.Time{}}
+	}
+
+	ids := make([]string, 0, len(risks))
+	atRisk := make(map[string]bool, len(risks))
+	for _, risk := range risks {
+		ids = append(ids, risk.Bead.WorkBeadID)
+		atRisk[risk.Bead.WorkBeadID] = true
+	}
+	sort.Strings(ids)
+	changed := !slices.Equal(ids, state.Reported)
+	dirty := changed
+	state.Reported = ids
+
+	for _, risk := range risks {
+		beadID := risk.Bead.WorkBeadID
+		last, escalated := state.Escalated[beadID]
+		fire := !escalated || now.Sub(last) >= slaRiskEscalationDebounce
+		if fire || changed {
+			fmt.Fprintf(os.Stderr, "%s sla_risk bead=%s target_rig=%s due=%s slack=%s\n",
+				style.Warning.Render("⚠"), beadID, risk.Bead.TargetRig,
+				risk.DueAt.UTC().Format(time.RFC3339), risk.Slack.Round(time.Second))
+		}
+		if !fire {
+			continue
+		}
+		state.Escalated[beadID] = now
+		dirty = true
+		_ = events.LogFeed(events.TypeSchedulerSLARisk, actor,
+			events.SchedulerSLARiskPayload(beadID, risk.Bead.TargetRig,
+				risk.DueAt.UTC().Format(time.RFC3339), risk.Slack.Round(time.Second).String()))
+		fireSLARiskEscalation(risk)
+	}
+
+	// Forget escalations of beads no longer at risk once their debounce has
+	// passed, so the state file does not grow without bound.
+	for beadID, last := range state.Escalated {
+		if !atRisk[beadID] && now.Sub(last) >= slaRiskEscalationDebounce {
+			delete(state.Escalated, beadID)
+			dirty = true
+		}
+	}
+
+	if dirty {
+		if err := capacity.SaveSLARiskState(townRoot, state); err != nil {
+			fmt.Fprintf(os.Stderr, "%s saving SLA-risk state: %v\n", style.Warning.Render("⚠"), err)
+		}
+	}
+}
+
 // maxDispatchFailures is the maximum number of consecutive dispatch failures
 // before a sling context is closed as circuit-broken.
 const maxDispatchFailures = 3
 
 type schedulerDispatchPlan struct {
 	State       *capacity.SchedulerState
+	Config      *capacity.SchedulerConfig
 	MaxPolecats int
 	BatchSize   int
 	SpawnDelay  time.Duration
@@ -82,6 +162,16 @@ type schedulerDispatchPlan struct {
 	Scheduled   []scheduledBeadInfo
 	Ready       []capacity.PendingBead
 	Plan        capacity.DispatchPlan
+	SLARisks    []capacity.SLARisk
+	Windows     *capacity.DispatchWindows
+	WindowHeld  []capacity.PendingBead // Ready beads whose rig is outside its dispatch window
+}
+
+// queuesWork reports whether scheduled beads are dispatched by the scheduler:
+// always in deferred mode, and in direct mode when dispatch windows are
+// configured (beads slung outside a window are queued until it opens).
+func (p *schedulerDispatchPlan) queuesWork() bool {
+	return p.MaxPolecats > 0 || p.Config.HasDispatchWindows()
 }
 
 func buildSchedulerDispatchPlan(townRoot string, batchOverride int, cleanup bool) (*schedulerDispatchPlan, error) {
@@ -106,8 +196,12 @@ func buildSchedulerDispatchPlan(townRoot string, batchOverride int, cleanup bool
 		batchSize = batchOverride
 	}
 	spawnDelay := schedulerCfg.GetSpawnDelay()
+	windows, err := capacity.CompileDispatchWindows(schedulerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dispatch windows: %w", err)
+	}
 
-	if cleanup && !state.Paused && maxPolecats > 0 {
+	if cleanup && !state.Paused && (maxPolecats > 0 || schedulerCfg.HasDispatchWindows()) {
 		if err := cleanupStaleContexts(townRoot); err != nil {
 			return nil, fmt.Errorf("cleaning stale scheduler contexts: %w", err)
 		}
@@ -126,26 +220,52 @@ func buildSchedulerDispatchPlan(townRoot string, batchOverride int, cleanup bool
 		return nil, fmt.Errorf("loading polecat capacity: %w", err)
 	}
 
-	ready := readySlingContextsFromAssessments(assessments)
-	dispatchPlan := capacity.PlanDispatch(snapshot.Free, batchSize, ready)
+	// Deadline/SLA-aware ordering: least slack first, then priority, then FIFO.
+	// PlanDispatch takes a prefix of this slice, so urgent work preempts
+	// lower-priority beads that were queued earlier.
+	now := time.Now()
+	ready := capacity.OrderBySlack(readySlingContextsFromAssessments(assessments), schedulerCfg, now)
+	// Dispatch windows: beads whose rig is outside its window stay queued.
+	inWindow, held := windows.FilterOpen(ready, now)
+	free := snapshot.Free
+	if maxPolecats <= 0 && schedulerCfg.HasDispatchWindows() {
+		// Direct mode has no capacity limit; the scheduler only holds
+		// window-queued beads until their window opens.
+		free = len(inWindow)
+	}
+	dispatchPlan := capacity.PlanDispatch(free, batchSize, inWindow)
+	if len(inWindow) == 0 && len(held) > 0 {
+		dispatchPlan = capacity.DispatchPlan{Skipped: len(held), Reason: "window"}
+	} else {
+		dispatchPlan.Skipped += len(held)
+	}
+	var slaRisks []capacity.SLARisk
+	if strings.HasPrefix(dispatchPlan.Reason, "capacity") {
+		slaRisks = capacity.FindSLARisks(dispatchPlan.Deferred, schedulerCfg, now)
+	}
 	if len(ready) > 0 {
 		switch {
 		case state.Paused:
 			dispatchPlan = capacity.DispatchPlan{Skipped: len(ready), Reason: "paused"}
-		case maxPolecats <= 0:
+		case maxPolecats <= 0 && !schedulerCfg.HasDispatchWindows():
 			dispatchPlan = capacity.DispatchPlan{Skipped: len(ready), Reason: "direct-mode"}
+			slaRisks = nil
 		}
 	}
 
 	return &schedulerDispatchPlan{
 		State:       state,
+		Config:      schedulerCfg,
 		MaxPolecats: maxPolecats,
 		BatchSize:   batchSize,
 		SpawnDelay:  spawnDelay,
 		Capacity:    snapshot,
-		Scheduled:   scheduledBeadInfosFromAssessments(assessments),
+		Scheduled:   scheduledBeadInfosFromAssessments(assessments, schedulerCfg, now),
 		Ready:       ready,
 		Plan:        dispatchPlan,
+		SLARisks:    slaRisks,
+		Windows:     windows,
+		WindowHeld:  held,
 	}, nil
 }
 
@@ -192,8 +312,9 @@ func dispatchScheduledWork(townRoot, actor string, batchOverride int, dryRun boo
 		return 0, nil
 	}
 
-	// Nothing to dispatch when scheduler is in direct dispatch or disabled mode.
-	if dispatchPlan.MaxPolecats <= 0 {
+	// Nothing to dispatch when scheduler is in direct dispatch or disabled mode
+	// (unless dispatch windows are holding beads slung outside a window).
+	if !dispatchPlan.queuesWork() {
 		if !isDaemonDispatch() {
 			if len(dispatchPlan.Scheduled) > 0 {
 				fmt.Printf("%s %d context bead(s) still open from a previous deferred mode\n",
@@ -207,6 +328,8 @@ func dispatchScheduledWork(townRoot, actor string, batchOverride int, dryRun boo
 		return 0, nil
 	}
 
+	reportSLARisks(townRoot, actor, dispatchPlan.SLARisks, time.Now())
+
 	// Wire up the DispatchCycle
 	successfulRigs := make(map[string]bool)
 	// Track polecat names from dispatch results, keyed by context bead ID.
@@ -228,7 +351,7 @@ func dispatchScheduledWork(townRoot, actor string, batchOverride int, dryRun boo
 				successfulRigs[b.TargetRig] = true
 			}
 			_ = events.LogFeed(events.TypeSchedulerDispatch, actor,
-				events.SchedulerDispatchPayload(b.WorkBeadID, b.TargetRig, polecatNames[b.ID]))
+				events.SchedulerDispatchPayload(b.WorkBeadID, b.TargetRig, polecatNames[b.ID], dispatchPlan.MaxPolecats))
 			return nil
 		},
 		OnSuccess: func(b capacity.PendingBead) error {
@@ -254,7 +377,7 @@ func dispatchScheduledWork(townRoot, actor string, batchOverride int, dryRun boo
 					// Last-resort close succeeded — context is now closed.
 					// Log feed event so dashboards can detect bead DB degradation.
 					_ = events.LogFeed(events.TypeSchedulerCloseRetry, actor,
-						events.SchedulerDispatchPayload(b.WorkBeadID, b.TargetRig, polecatNames[b.ID]))
+						events.SchedulerDispatchPayload(b.WorkBeadID, b.TargetRig, polecatNames[b.ID], dispatchPlan.MaxPolecats))
 					// Skip recordDispatchFailure to avoid writing to a closed context.
 					return
 				}
@@ -315,7 +438,7 @@ func printSchedulerDryRunPlan(dispatchPlan *schedulerDispatchPlan) {
 			dispatchPlan.State.PausedBy, len(dispatchPlan.Ready))
 		return
 	}
-	if dispatchPlan.MaxPolecats <= 0 {
+	if !dispatchPlan.queuesWork() {
 		if len(dispatchPlan.Scheduled) == 0 {
 			fmt.Println("No ready beads scheduled for dispatch")
 			return
@@ -325,6 +448,34 @@ func printSchedulerDryRunPlan(dispatchPlan *schedulerDispatchPlan) {
 		return
 	}
 	printDryRunPlan(dispatchPlan.Plan, dispatchPlan.Capacity, dispatchPlan.BatchSize)
+	printWindowHeld(dispatchPlan.WindowHeld, dispatchPlan.Windows)
+	printSLARisks(dispatchPlan.SLARisks)
+}
+
+// printWindowHeld lists ready beads held back because their rig is outside
+// its dispatch window, with the next opening.
+func printWindowHeld(held []capacity.PendingBead, windows *capacity.DispatchWindows) {
+	if len(held) == 0 {
+		return
+	}
+	fmt.Printf("\n%s %d ready bead(s) outside dispatch window:\n", style.Dim.Render("⏸"), len(held))
+	now := time.Now()
+	for _, b := range held {
+		fmt.Printf("  %s → %s  (%s)\n", b.WorkBeadID, b.TargetRig, formatNextWindow(windows, b.TargetRig, now))
+	}
+}
+
+// formatNextWindow describes when dispatch to rig next becomes possible.
+func formatNextWindow(windows *capacity.DispatchWindows, rig string, now time.Time) string {
+	next, ok := windows.NextOpen(rig, now)
+	switch {
+	case !ok:
+		return "no upcoming window"
+	case !next.After(now):
+		return "open"
+	default:
+		return "opens " + next.Local().Format("Mon 2006-01-02 15:04 MST")
+	}
 }
 
 func printDispatchNoOp(report capacity.DispatchReport, snapshot polecatCapacitySnapshot) {
@@ -334,6 +485,9 @@ func printDispatchNoOp(report capacity.DispatchReport, snapshot polecatCapacityS
 	case "capacity":
 		fmt.Printf("\n%s No capacity: %d ready bead(s) waiting (working: %d recovery_blocked: %d reservations: %d reusable_idle: %d pending_mr: %d)\n",
 			style.Dim.Render("○"), report.Skipped, snapshot.Working, snapshot.RecoveryBlocked, snapshot.Reservations, snapshot.ReusableIdle, snapshot.PendingMR)
+	case "window":
+		fmt.Printf("\n%s Outside dispatch window: %d ready bead(s) held\n",
+			style.Dim.Render("⏸"), report.Skipped)
 	default:
 		fmt.Printf("\n%s No dispatchable beads (reason: %s, skipped: %d)\n",
 			style.Dim.Render("○"), report.Reason, report.Skipped)
@@ -360,6 +514,8 @@ func printDryRunPlan(plan capacity.DispatchPlan, snapshot polecatCapacitySnapsho
 			fmt.Printf("No capacity: %s, %d ready bead(s) waiting\n", capStr, totalReady)
 		case "validation":
 			fmt.Printf("No dispatchable beads: validation failed for %d candidate(s)\n", totalReady)
+		case "window":
+			fmt.Printf("Outside dispatch window: %d ready bead(s) held\n", totalReady)
 		default:
 			fmt.Printf("No dispatchable beads: reason=%s, %d candidate(s) skipped\n", plan.Reason, totalReady)
 		}
@@ -373,6 +529,26 @@ func printDryRunPlan(plan capacity.DispatchPlan, snapshot polecatCapacitySnapsho
 	}
 }
 
+// printSLARisks lists queued beads whose deadline is threatened by capacity.
+func printSLARisks(risks []capacity.SLARisk) {
+	if len(risks) == 0 {
+		return
+	}
+	fmt.Printf("\n%s %d bead(s) at SLA risk (capacity exhausted):\n", style.Warning.Render("⚠"), len(risks))
+	for _, r := range risks {
+		fmt.Printf("  %s → %s  due %s (%s)\n", r.Bead.WorkBeadID, r.Bead.TargetRig,
+			r.DueAt.Local().Format(time.RFC3339), formatSlack(r.Slack))
+	}
+}
+
+// formatSlack renders slack as "12m left" or "3m overdue".
+func formatSlack(slack time.Duration) string {
+	if slack < 0 {
+		return (-slack).Round(time.Second).String() + " overdue"
+	}
+	return slack.Round(time.Second).String() + " left"
+}
+
 // beadsForContext returns a Beads instance that can operate on a sling context
 // bead. Sling contexts live in the target rig's beads dir (GH#3468), so we
 // resolve the dir from the context's TargetRig field. Falls back to HQ if
@@ -473,11 +649,12 @@ func cleanupStaleContexts(townRoot string) error {
 	return nil
 }
 
-// beadStatusInfo holds batch-fetched bead status, title, and labels.
+// beadStatusInfo holds batch-fetched bead status, title, labels, and priority.
 type beadStatusInfo struct {
-	Status string
-	Title  string
-	Labels []string
+	Status   string
+	Title    string
+	Labels   []string
+	Priority int
 }
 
 func beadStatusInfoFromBeadInfo(info *beadInfo) beadStatusInfo {
@@ -485,9 +662,10 @@ func beadStatusInfoFromBeadInfo(info *beadInfo) beadStatusInfo {
 		return beadStatusInfo{}
 	}
 	return beadStatusInfo{
-		Status: info.Status,
-		Title:  info.Title,
-		Labels: info.Labels,
+		Status:   info.Status,
+		Title:    info.Title,
+		Labels:   info.Labels,
+		Priority: info.Priority,
 	}
 }
 
@@ -515,19 +693,21 @@ func batchFetchBeadInfoByIDs(townRoot string, ids []string) map[string]beadStatu
 			continue
 		}
 		var items []struct {
-			ID     string   `json:"id"`
-			Status string   `json:"status"`
-			Title  string   `json:"title"`
-			Labels []string `json:"labels"`
+			ID       string   `json:"id"`
+			Status   string   `json:"status"`
+			Title    string   `json:"title"`
+			Labels   []string `json:"labels"`
+			Priority int      `json:"priority"`
 		}
 		if err := json.Unmarshal(out, &items); err != nil {
 			continue
 		}
 		for _, item := range items {
 			result[item.ID] = beadStatusInfo{
-				Status: item.Status,
-				Title:  item.Title,
-				Labels: item.Labels,
+				Status:   item.Status,
+				Title:    item.Title,
+				Labels:   item.Labels,
+				Priority: item.Priority,
 			}
 		}
 	}
@@ -670,6 +850,7 @@ func readySlingContextsFromAssessments(assessments []scheduledContextAssessment)
 			TargetRig:       assessment.fields.TargetRig,
 			Description:     assessment.context.issue.Description,
 			Labels:          workLabels,
+			Priority:        assessment.info.Priority,
 			Context:         assessment.fields,
 			ContextWorkDir:  assessment.context.workDir,
 			ContextBeadsDir: assessment.context.beadsDir,
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -53,6 +55,173 @@ func TestShouldFireCrossRigEscalation_KeyedByRigAndPrefix(t *testing.T) {
 	}
 }
 
+func TestReportSLARisks_EscalatesOncePerDebounceWindow(t *testing.T) {
+	// Keep feed events out of any enclosing workspace.
+	townRoot := t.TempDir()
+	t.Chdir(townRoot)
+
+	var fired []string
+	orig := fireSLARiskEscalation
+	fireSLARiskEscalation = func(risk capacity.SLARisk) {
+		fired = append(fired, risk.Bead.WorkBeadID)
+	}
+	t.Cleanup(func() { fireSLARiskEscalation = orig })
+
+	now := time.Date(2026, 4, 30, 12, 0, 0, 0, time.UTC)
+	risks := []capacity.SLARisk{
+		{Bead: capacity.PendingBead{WorkBeadID: "gt-p0", TargetRig: "gastown"}, DueAt: now.Add(2 * time.Minute), Slack: 2 * time.Minute},
+		{Bead: capacity.PendingBead{WorkBeadID: "gt-late", TargetRig: "gastown"}, DueAt: now.Add(-time.Minute), Slack: -time.Minute},
+	}
+
+	reportSLARisks(townRoot, "test", risks, now)
+	if len(fired) != 2 {
+		t.Fatalf("first report fired %d escalations, want 2", len(fired))
+	}
+	reportSLARisks(townRoot, "test", risks, now.Add(time.Minute))
+	if len(fired) != 2 {
+		t.Fatalf("repeat inside debounce window fired %d escalations total, want 2", len(fired))
+	}
+	reportSLARisks(townRoot, "test", risks[:1], now.Add(slaRiskEscalationDebounce+time.Minute))
+	if len(fired) != 3 || fired[2] != "gt-p0" {
+		t.Fatalf("after debounce window fired = %v, want gt-p0 re-escalated", fired)
+	}
+}
+
+// TestReportSLARisks_DebouncesAcrossInvocations runs the report in two
+// separate gt processes, as the daemon does on consecutive heartbeats, and
+// expects a single escalation.
+func TestReportSLARisks_DebouncesAcrossInvocations(t *testing.T) {
+	if os.Getenv("GT_TEST_SLA_RISK_CHILD") == "1" {
+		townRoot := os.Getenv("GT_TEST_SLA_RISK_TOWN")
+		fireSLARiskEscalation = func(risk capacity.SLARisk) {
+			f, err := os.OpenFile(filepath.Join(townRoot, "escalations.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			fmt.Fprintln(f, risk.Bead.WorkBeadID)
+		}
+		now := time.Now()
+		reportSLARisks(townRoot, "test", []capacity.SLARisk{
+			{Bead: capacity.PendingBead{WorkBeadID: "gt-p0", TargetRig: "gastown"}, DueAt: now.Add(2 * time.Minute), Slack: 2 * time.Minute},
+		}, now)
+		return
+	}
+
+	townRoot := t.TempDir()
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestReportSLARisks_DebouncesAcrossInvocations$")
+		cmd.Dir = townRoot
+		cmd.Env = append(os.Environ(), "GT_TEST_SLA_RISK_CHILD=1", "GT_TEST_SLA_RISK_TOWN="+townRoot)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("invocation %d: %v\n%s", i+1, err, out)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(townRoot, "escalations.log"))
+	if err != nil {
+		t.Fatalf("reading escalations: %v", err)
+	}
+	if got := strings.Fields(string(data)); len(got) != 1 || got[0] != "gt-p0" {
+		t.Errorf("escalations across two invocations = %v, want one for gt-p0", got)
+	}
+}
+
+func TestReportSLARisks_PrintsOnlyOnEscalationOrChange(t *testing.T) {
+	townRoot := t.TempDir()
+	t.Chdir(townRoot)
+
+	orig := fireSLARiskEscalation
+	fireSLARiskEscalation = func(capacity.SLARisk) {}
+	t.Cleanup(func() { fireSLARiskEscalation = orig })
+
+	now := time.Date(2026, 4, 30, 12, 0, 0, 0, time.UTC)
+	p0 := capacity.SLARisk{Bead: capacity.PendingBead{WorkBeadID: "gt-p0", TargetRig: "gastown"}, DueAt: now.Add(2 * time.Minute), Slack: 2 * time.Minute}
+	late := capacity.SLARisk{Bead: capacity.PendingBead{WorkBeadID: "gt-late", TargetRig: "gastown"}, DueAt: now.Add(-time.Minute), Slack: -time.Minute}
+
+	report := func(risks []capacity.SLARisk, at time.Time) string {
+		return captureStderr(t, func() { reportSLARisks(townRoot, "test", risks, at) })
+	}
+
+	if out := report([]capacity.SLARisk{p0}, now); !strings.Contains(out, "sla_risk bead=gt-p0") {
+		t.Fatalf("first report should print gt-p0, got %q", out)
+	}
+	if out := report([]capacity.SLARisk{p0}, now.Add(time.Minute)); strings.Contains(out, "sla_risk") {
+		t.Fatalf("unchanged set inside debounce window should not print, got %q", out)
+	}
+	if out := report([]capacity.SLARisk{p0, late}, now.Add(2*time.Minute)); !strings.Contains(out, "bead=gt-p0") || !strings.Contains(out, "bead=gt-late") {
+		t.Fatalf("changed set should print every at-risk bead, got %q", out)
+	}
+	if out := report([]capacity.SLARisk{p0, late}, now.Add(3*time.Minute)); strings.Contains(out, "sla_risk") {
+		t.Fatalf("unchanged set should not print, got %q", out)
+	}
+	if out := report([]capacity.SLARisk{p0, late}, now.Add(slaRiskEscalationDebounce+time.Minute)); !strings.Contains(out, "bead=gt-p0") || strings.Contains(out, "bead=gt-late") {
+		t.Fatalf("re-escalation should print only gt-p0, got %q", out)
+	}
+}
+
+func TestScheduledBeadInfosFromAssessmentsAnnotatesSLA(t *testing.T) {
+	now := time.Date(2026, 4, 30, 12, 0, 0, 0, time.UTC)
+	cfg := &capacity.SchedulerConfig{SLAs: map[string]string{"P0": "15m"}}
+	assessment := func(id string, priority int) scheduledContextAssessment {
+		return scheduledContextAssessment{
+			context: slingContextRecord{issue: &beads.Issue{ID: "ctx-" + id, Title: id}},
+			fields: &capacity.SlingContextFields{
+				WorkBeadID: id,
+				TargetRig:  "gastown",
+				EnqueuedAt: now.Add(-12 * time.Minute).Format(time.RFC3339),
+			},
+			info:  beadStatusInfo{Status: "open", Title: id, Priority: priority},
+			found: true,
+			ready: true,
+		}
+	}
+
+	infos := scheduledBeadInfosFromAssessments([]scheduledContextAssessment{
+		assessment("gt-urgent", 0),
+		assessment("gt-normal", 2),
+	}, cfg, now)
+	if len(infos) != 2 {
+		t.Fatalf("got %d infos, want 2", len(infos))
+	}
+	if infos[0].DueAt != "2026-04-30T12:03:00Z" || infos[0].Slack != "3m0s" || !infos[0].SLARisk {
+		t.Errorf("P0 info = %+v, want due 12:03 slack 3m0s at risk", infos[0])
+	}
+	if infos[1].DueAt != "" || infos[1].SLARisk {
+		t.Errorf("P2 info = %+v, want no deadline", infos[1])
+	}
+}
+
+func TestSchedulerWindowStatus(t *testing.T) {
+	if st := schedulerWindowStatus(&capacity.SchedulerConfig{}, time.Now()); st != nil {
+		t.Fatalf("no windows configured: got %+v, want nil", st)
+	}
+
+	cfg := &capacity.SchedulerConfig{
+		Windows: &capacity.DispatchSchedule{
+			Timezone: "UTC",
+			Windows:  []capacity.DispatchWindow{{Cron: "0 9 * * 1-5", Duration: "8h"}},
+		},
+		RigWindows: map[string]*capacity.DispatchSchedule{
+			"batch": {Timezone: "UTC", Windows: []capacity.DispatchWindow{{Cron: "0 16 * * *", Duration: "1h"}}},
+		},
+	}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC) // Monday
+	st := schedulerWindowStatus(cfg, now)
+	if st == nil || !st.Open {
+		t.Fatalf("town window at 10:00 Monday: got %+v, want open", st)
+	}
+	batch := st.Rigs["batch"]
+	if batch.Open || batch.NextOpen != "2026-03-02T16:00:00Z" {
+		t.Errorf("batch rig window = %+v, want closed until 16:00", batch)
+	}
+
+	cfg.Windows.Timezone = "Mars/Olympus"
+	if st := schedulerWindowStatus(cfg, now); st == nil || st.Error == "" {
+		t.Errorf("invalid timezone: got %+v, want error", st)
+	}
+}
+
 func TestDispatchSingleBeadRawReviewOnlyHookFailureClearsMetadata(t *testing.T) {
 	townRoot, _, descPath := setupMutableBDRawSlingTest(t, "Keep this body.")
 
@@ -213,6 +213,7 @@ COMMANDS:
   land      Land an owned convoy (cleanup worktrees, close convoy)
   status    Show convoy progress, tracked issues, and active workers
   list      List convoys (the dashboard view)
+  template  Manage recurring and templated convoys
   watch     Subscribe to convoy completion notifications
   unwatch   Unsubscribe from convoy completion notifications`,
 }
@@ -257,7 +258,12 @@ var convoyStatusCmd = &cobra.Command{
 	Long: `Show detailed status for a convoy.
 
 Displays convoy metadata, tracked issues, and completion progress.
-Without an ID, shows status of all active convoys.`,
+Without an ID, shows status of all active convoys.
+
+Open convoys also get a forecast from the last 30 days of history (sling to
+done, and done to merged) under the scheduler's capacity: the expected
+completion with its p10–p90 band, the critical path through the tracked
+issues' dependencies, and the issues blocking the most downstream work.`,
 	Args:         cobra.MaximumNArgs(1),
 	SilenceUsage: true,
 	RunE:         runConvoyStatus,
@@ -2003,21 +2009,29 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Forecast completion of open convoys from history and capacity.
+	now := time.Now()
+	var forecast *convoyops.Forecast
+	if normalizeConvoyStatus(convoy.Status) != convoyStatusClosed && len(tracked) > 0 {
+		forecast = forecastTrackedIssues(townBeads, tracked, now)
+	}
+
 	if convoyStatusJSON {
 		lifecycle := "system-managed"
 		if isOwned {
 			lifecycle = "caller-managed"
 		}
 		type jsonStatus struct {
-			ID            string             `json:"id"`
-			Title         string             `json:"title"`
-			Status        string             `json:"status"`
-			Owned         bool               `json:"owned"`
-			Lifecycle     string             `json:"lifecycle"`
-			MergeStrategy string             `json:"merge_strategy,omitempty"`
-			Tracked       []trackedIssueInfo `json:"tracked"`
-			Completed     int                `json:"completed"`
-			Total         int                `json:"total"`
+			ID            string              `json:"id"`
+			Title         string              `json:"title"`
+			Status        string              `json:"status"`
+			Owned         bool                `json:"owned"`
+			Lifecycle     string              `json:"lifecycle"`
+			MergeStrategy string              `json:"merge_strategy,omitempty"`
+			Tracked       []trackedIssueInfo  `json:"tracked"`
+			Completed     int                 `json:"completed"`
+			Total         int                 `json:"total"`
+			Forecast      *convoyops.Forecast `json:"forecast,omitempty"`
 		}
 		out := jsonStatus{
 			ID:            convoy.ID,
@@ -2029,6 +2043,7 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 			Tracked:       tracked,
 			Completed:     completed,
 			Total:         len(tracked),
+			Forecast:      forecast,
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -2049,6 +2064,9 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Merge:     %s\n", merge)
 	}
 	fmt.Printf("  Progress:  %d/%d completed\n", completed, len(tracked))
+	if forecast != nil && forecast.Remaining > 0 {
+		fmt.Printf("  ETA:       %s\n", formatForecastETA(forecast, now))
+	}
 	fmt.Printf("  Created:   %s\n", convoy.CreatedAt)
 	if convoy.ClosedAt != "" {
 		fmt.Printf("  Closed:    %s\n", convoy.ClosedAt)
@@ -2089,6 +2107,11 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if forecast != nil && forecast.Remaining > 0 {
+		fmt.Println()
+		printForecastDetails(forecast, "  ")
+	}
+
 	// Hint for owned convoys when all issues are complete
 	if isOwned && completed == len(tracked) && len(tracked) > 0 && normalizeConvoyStatus(convoy.Status) == convoyStatusOpen {
 		fmt.Printf("\n  %s\n", style.Dim.Render("All issues complete. Land with: gt convoy land "+convoyID))
@@ -2402,6 +2425,7 @@ type trackedIssueInfo struct {
 	Labels    []string `json:"labels,omitempty"`     // Bead labels (propagated from trackedDependency)
 	Worker    string   `json:"worker,omitempty"`     // Worker currently assigned (e.g., gastown/nux)
 	WorkerAge string   `json:"worker_age,omitempty"` // How long worker has been on this issue
+	BlockedBy []string `json:"blocked_by,omitempty"` // Issues this one waits on (blocking dependencies)
 }
 
 // trackedDependency is dep-list data enriched with fresh issue details.
@@ -2414,6 +2438,7 @@ type trackedDependency struct {
 	DependencyType string   `json:"dependency_type"`
 	Labels         []string `json:"labels"`
 	Blocked        bool     `json:"-"`
+	BlockedBy      []string `json:"-"`
 }
 
 func applyFreshIssueDetails(dep *trackedDependency, details *issueDetails) {
@@ -2438,6 +2463,12 @@ func applyFreshIssueDetails(dep *trackedDependency, details *issueDetails) {
 	// labels are empty clears stale queue labels that would otherwise
 	// suppress stranded issue detection.
 	dep.Labels = details.Labels
+	dep.BlockedBy = nil
+	for _, d := range details.Dependencies {
+		if convoyops.IsBlockingDepType(d.DependencyType) {
+			dep.BlockedBy = append(dep.BlockedBy, beads.ExtractIssueID(d.ID))
+		}
+	}
 }
 
 // getTrackedIssues gets issues tracked by a convoy with fresh cross-rig details.
@@ -2516,6 +2547,7 @@ func getTrackedIssues(townBeads, convoyID string) ([]trackedIssueInfo, error) {
 			Blocked:   dep.Blocked,
 			Assignee:  dep.Assignee,
 			Labels:    dep.Labels,
+			BlockedBy: dep.BlockedBy,
 		}
 
 		// Add worker info if available
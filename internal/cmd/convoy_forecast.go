@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// forecastTrackedIssues forecasts the completion of a convoy's tracked
+// issues from the town's history and scheduler capacity.
+func forecastTrackedIssues(townRoot string, tracked []trackedIssueInfo, now time.Time) *convoyops.Forecast {
+	issues := make([]convoyops.ForecastIssue, 0, len(tracked))
+	for _, t := range tracked {
+		issues = append(issues, convoyops.ForecastIssue{ID: t.ID, Status: t.Status, BlockedBy: t.BlockedBy})
+	}
+	return forecastIssues(townRoot, issues, now)
+}
+
+// forecastIssues annotates issues with their dispatch history and forecasts
+// their completion.
+func forecastIssues(townRoot string, issues []convoyops.ForecastIssue, now time.Time) *convoyops.Forecast {
+	history := convoyops.LoadForecastHistory(townRoot, now)
+	for i := range issues {
+		history.Annotate(&issues[i])
+	}
+	return convoyops.ComputeForecast(issues, history, convoyops.ForecastCapacity(townRoot), now)
+}
+
+// formatForecastETA renders a forecast's expected completion for status
+// output, e.g. "~3h (2h–5h)".
+func formatForecastETA(f *convoyops.Forecast, now time.Time) string {
+	switch {
+	case f == nil:
+		return ""
+	case f.Remaining == 0:
+		return "done"
+	case !f.Estimated():
+		return "unknown (no completed work in the last 30 days)"
+	}
+	return convoyops.FormatForecastETA(f, now)
+}
+
+// printForecastDetails prints a forecast's critical path and top blockers.
+// Lines are prefixed with indent.
+func printForecastDetails(f *convoyops.Forecast, indent string) {
+	if f == nil || f.Remaining == 0 {
+		return
+	}
+	basis := fmt.Sprintf("%d issues remaining, %d completed in history", f.Remaining, f.Samples)
+	if f.Capacity > 0 {
+		basis += fmt.Sprintf(", %d polecats", f.Capacity)
+	}
+	fmt.Printf("%s%s\n", indent, style.Dim.Render("Forecast: "+basis))
+	if len(f.CriticalPath) > 1 || f.PathDuration > 0 {
+		path := strings.Join(f.CriticalPath, " → ")
+		if f.PathDuration > 0 {
+			path += style.Dim.Render(" (" + convoyops.FormatForecastDuration(f.PathDuration) + ")")
+		}
+		fmt.Printf("%sCritical path: %s\n", indent, path)
+	}
+	if len(f.Blockers) > 0 {
+		parts := make([]string, 0, len(f.Blockers))
+		for _, b := range f.Blockers {
+			parts = append(parts, fmt.Sprintf("%s (%d waiting)", b.ID, b.Downstream))
+		}
+		fmt.Printf("%sTop blockers:  %s\n", indent, strings.Join(parts, ", "))
+	}
+}
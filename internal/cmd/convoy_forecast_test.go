@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+)
+
+func TestForecastTrackedIssues(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	ts := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	events := strings.Join([]string{
+		`{"ts":"` + ts(5*time.Hour) + `","type":"sling","payload":{"bead":"gt-old"}}`,
+		`{"ts":"` + ts(4*time.Hour) + `","type":"done","payload":{"bead":"gt-old"}}`,
+		`{"ts":"` + ts(30*time.Minute) + `","type":"sling","payload":{"bead":"gt-a"}}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(townRoot, ".events.jsonl"), []byte(events), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracked := []trackedIssueInfo{
+		{ID: "gt-a", Status: "hooked"},
+		{ID: "gt-b", Status: "open", BlockedBy: []string{"gt-a"}},
+		{ID: "gt-old", Status: "closed"},
+	}
+	f := forecastTrackedIssues(townRoot, tracked, now)
+
+	if f.Remaining != 2 || f.Samples != 1 {
+		t.Fatalf("Remaining/Samples = %d/%d, want 2/1", f.Remaining, f.Samples)
+	}
+	if want := []string{"gt-a", "gt-b"}; !reflect.DeepEqual(f.CriticalPath, want) {
+		t.Errorf("CriticalPath = %v, want %v", f.CriticalPath, want)
+	}
+	// gt-a has 30m of its 1h left, then gt-b takes 1h.
+	if got := f.ETA.Sub(now); got != 90*time.Minute {
+		t.Errorf("ETA in %v, want 1h30m", got)
+	}
+	if got := formatForecastETA(f, now); got != "~1h30m (1h30m–1h30m)" {
+		t.Errorf("formatForecastETA = %q", got)
+	}
+}
+
+func TestFormatForecastETA(t *testing.T) {
+	now := time.Now()
+	if got := formatForecastETA(&convoyops.Forecast{}, now); got != "done" {
+		t.Errorf("complete forecast = %q, want done", got)
+	}
+	if got := formatForecastETA(&convoyops.Forecast{Remaining: 2}, now); !strings.HasPrefix(got, "unknown") {
+		t.Errorf("forecast without history = %q, want unknown", got)
+	}
+	if got := formatForecastETA(nil, now); got != "" {
+		t.Errorf("nil forecast = %q, want empty", got)
+	}
+}
@@ -0,0 +1,529 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	convoyTemplateAddForce bool
+	convoyTemplateListJSON bool
+	convoyTemplateRunVars  []string
+	convoyTemplateRunDue   bool
+	convoyTemplateRunForce bool
+	convoyTemplateRunDry   bool
+)
+
+var convoyTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage recurring and templated convoys",
+	Long: `Manage convoy templates - issue skeletons that instantiate a fresh convoy
+on demand or on a cron schedule.
+
+A template is a TOML file stored in settings/convoy-templates/<name>.toml.
+It declares the convoy title, variables, and issues (with rigs, labels,
+formulas, and dependencies between them). Text fields may use {{var}}
+placeholders for declared vars and the built-ins date, week, month, year,
+and template.
+
+Templates with a schedule are run by the daemon's convoy_templates patrol.
+A scheduled run is skipped while the template's previous convoy is still open,
+or while its status can't be checked. A previous convoy that no longer exists
+counts as finished.
+
+Example template:
+
+  name = "deps-weekly"
+  title = "Dependency updates {{week}}"
+  schedule = "0 9 * * 1"        # Mondays at 09:00
+
+  [vars.ecosystem]
+  default = "go"
+
+  [[issues]]
+  id = "bump"
+  rig = "gastown"
+  title = "Bump {{ecosystem}} dependencies"
+  formula = "mol-dep-update"
+  vars = { ecosystem = "{{ecosystem}}" }
+
+  [[issues]]
+  id = "verify"
+  rig = "gastown"
+  title = "Run the full test matrix"
+  needs = ["bump"]`,
+	RunE: requireSubcommand,
+}
+
+var convoyTemplateAddCmd = &cobra.Command{
+	Use:   "add <file>",
+	Short: "Validate and install a convoy template",
+	Long: `Validate a convoy template and install it into the town.
+
+The template's cron schedule, variables, issues, and dependencies are
+checked before it is copied to settings/convoy-templates/<name>.toml. A
+scheduled template first runs at its next scheduled time after it is added.
+
+Examples:
+  gt convoy template add deps-weekly.toml
+  gt convoy template add deps-weekly.toml --force   # Replace existing`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvoyTemplateAdd,
+}
+
+var convoyTemplateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List convoy templates and their schedules",
+	Args:  cobra.NoArgs,
+	RunE:  runConvoyTemplateList,
+}
+
+var convoyTemplateRunCmd = &cobra.Command{
+	Use:   "run [name]",
+	Short: "Instantiate a convoy from a template",
+	Long: `Create the template's issues and a convoy tracking them.
+
+Issues are created in their rigs' beads with blocking dependencies between
+them. Issues that declare a formula carry it to gt sling, which applies it
+(with the template's formula vars) when the issue is dispatched.
+
+A run is skipped while the template's previous convoy is still open; use
+--force to instantiate anyway. With --due, every scheduled template whose
+schedule has come up is run (this is what the daemon invokes).
+
+Examples:
+  gt convoy template run deps-weekly
+  gt convoy template run deps-weekly --var ecosystem=npm
+  gt convoy template run deps-weekly --dry-run
+  gt convoy template run --due`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConvoyTemplateRun,
+}
+
+func init() {
+	convoyTemplateAddCmd.Flags().BoolVarP(&convoyTemplateAddForce, "force", "f", false, "Replace an existing template with the same name")
+
+	convoyTemplateListCmd.Flags().BoolVar(&convoyTemplateListJSON, "json", false, "Output as JSON")
+
+	convoyTemplateRunCmd.Flags().StringArrayVar(&convoyTemplateRunVars, "var", nil, "Template variable (key=value), can be repeated")
+	convoyTemplateRunCmd.Flags().BoolVar(&convoyTemplateRunDue, "due", false, "Run every scheduled template that is due")
+	convoyTemplateRunCmd.Flags().BoolVarP(&convoyTemplateRunForce, "force", "f", false, "Run even if the previous convoy is still open")
+	convoyTemplateRunCmd.Flags().BoolVar(&convoyTemplateRunDry, "dry-run", false, "Show the issues that would be created")
+
+	convoyTemplateCmd.AddCommand(convoyTemplateAddCmd)
+	convoyTemplateCmd.AddCommand(convoyTemplateListCmd)
+	convoyTemplateCmd.AddCommand(convoyTemplateRunCmd)
+	convoyCmd.AddCommand(convoyTemplateCmd)
+}
+
+func runConvoyTemplateAdd(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+	t, err := convoyops.ParseTemplate(data)
+	if err != nil {
+		return fmt.Errorf("invalid convoy template %s: %w", args[0], err)
+	}
+
+	townRoot, err := getTownBeadsDir()
+	if err != nil {
+		return err
+	}
+	path := convoyops.TemplatePath(townRoot, t.Name)
+	if _, err := os.Stat(path); err == nil && !convoyTemplateAddForce {
+		return fmt.Errorf("convoy template %q already exists (use --force to replace)", t.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating templates directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // G306: templates are shared town settings
+		return fmt.Errorf("writing template: %w", err)
+	}
+
+	// Start the schedule from now so adding a template doesn't run it at once.
+	now := time.Now()
+	state, err := convoyops.LoadTemplateState(townRoot)
+	if err != nil {
+		return err
+	}
+	run := state[t.Name]
+	if run == nil {
+		run = &convoyops.TemplateRun{}
+		state[t.Name] = run
+	}
+	run.LastCheck = now
+	if err := convoyops.SaveTemplateState(townRoot, state); err != nil {
+		return fmt.Errorf("saving template state: %w", err)
+	}
+
+	fmt.Printf("%s Added convoy template %s\n", style.Bold.Render("✓"), t.Name)
+	fmt.Printf("  Issues:   %d\n", len(t.Issues))
+	if t.Schedule != "" {
+		fmt.Printf("  Schedule: %s\n", t.Schedule)
+		if next := t.NextRun(now); !next.IsZero() {
+			fmt.Printf("  Next run: %s\n", next.Format("2006-01-02 15:04 MST"))
+		}
+		fmt.Printf("  %s\n", style.Dim.Render("Scheduled runs need the daemon's convoy_templates patrol enabled"))
+	} else {
+		fmt.Printf("  %s\n", style.Dim.Render("No schedule: run with gt convoy template run "+t.Name))
+	}
+	return nil
+}
+
+// convoyTemplateListItem is the JSON shape of gt convoy template list.
+type convoyTemplateListItem struct {
+	Name       string    `json:"name"`
+	Title      string    `json:"title"`
+	Schedule   string    `json:"schedule,omitempty"`
+	NextRun    time.Time `json:"next_run,omitzero"`
+	Issues     int       `json:"issues"`
+	LastRun    time.Time `json:"last_run,omitzero"`
+	LastConvoy string    `json:"last_convoy,omitempty"`
+	LastStatus string    `json:"last_status,omitempty"`
+	LastSkip   string    `json:"last_skip,omitempty"`
+}
+
+func runConvoyTemplateList(cmd *cobra.Command, args []string) error {
+	townRoot, err := getTownBeadsDir()
+	if err != nil {
+		return err
+	}
+	templates, errs := convoyops.ListTemplates(townRoot)
+	for _, err := range errs {
+		style.PrintWarning("%v", err)
+	}
+	state, err := convoyops.LoadTemplateState(townRoot)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	items := make([]convoyTemplateListItem, 0, len(templates))
+	for _, t := range templates {
+		item := convoyTemplateListItem{
+			Name:     t.Name,
+			Title:    t.Title,
+			Schedule: t.Schedule,
+			NextRun:  t.NextRun(now),
+			Issues:   len(t.Issues),
+		}
+		if run := state[t.Name]; run != nil {
+			item.LastRun = run.LastRun
+			item.LastConvoy = run.LastConvoy
+			item.LastSkip = run.LastSkip
+			if run.LastConvoy != "" {
+				if result, err := bdShow(run.LastConvoy); err == nil {
+					item.LastStatus = result.Status
+				}
+			}
+		}
+		items = append(items, item)
+	}
+
+	if convoyTemplateListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+
+	if len(items) == 0 {
+		fmt.Printf("No convoy templates. Add one with: gt convoy template add <file>\n")
+		return nil
+	}
+	fmt.Printf("%s\n\n", style.Bold.Render("Convoy Templates"))
+	for _, item := range items {
+		fmt.Printf("  %s  %s\n", style.Bold.Render(item.Name), item.Title)
+		schedule := "manual"
+		if item.Schedule != "" {
+			schedule = item.Schedule
+			if !item.NextRun.IsZero() {
+				schedule += style.Dim.Render(" (next " + item.NextRun.Format("2006-01-02 15:04") + ")")
+			}
+		}
+		fmt.Printf("    Schedule: %s\n", schedule)
+		fmt.Printf("    Issues:   %d\n", item.Issues)
+		if item.LastConvoy != "" {
+			last := fmt.Sprintf("%s %s", item.LastRun.Format("2006-01-02 15:04"), item.LastConvoy)
+			if item.LastStatus != "" {
+				last += " (" + item.LastStatus + ")"
+			}
+			fmt.Printf("    Last run: %s\n", last)
+		}
+		if item.LastSkip != "" {
+			fmt.Printf("    %s\n", style.Dim.Render("Skipped: "+item.LastSkip))
+		}
+	}
+	return nil
+}
+
+func runConvoyTemplateRun(cmd *cobra.Command, args []string) error {
+	if convoyTemplateRunDue == (len(args) == 1) {
+		return fmt.Errorf("specify a template name or --due")
+	}
+	if convoyTemplateRunDue && len(convoyTemplateRunVars) > 0 {
+		return fmt.Errorf("--var cannot be combined with --due: scheduled runs use template defaults")
+	}
+	overrides, err := parseTemplateVars(convoyTemplateRunVars)
+	if err != nil {
+		return err
+	}
+	townRoot, err := getTownBeadsDir()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var templates []*convoyops.Template
+	if convoyTemplateRunDue {
+		due, err := convoyops.DueTemplates(townRoot, now)
+		if err != nil {
+			style.PrintWarning("%v", err)
+		}
+		if len(due) == 0 {
+			fmt.Println("No convoy templates due")
+			return nil
+		}
+		templates = due
+	} else {
+		t, err := convoyops.LoadTemplate(townRoot, args[0])
+		if err != nil {
+			return err
+		}
+		templates = []*convoyops.Template{t}
+	}
+
+	var failed []string
+	for _, t := range templates {
+		if err := runConvoyTemplate(townRoot, t, overrides, now); err != nil {
+			if !convoyTemplateRunDue {
+				return err
+			}
+			style.PrintWarning("convoy template %s: %v", t.Name, err)
+			failed = append(failed, t.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("convoy templates failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runConvoyTemplate instantiates one template, skipping it while its
+// previous convoy is still open, and records the outcome in the template
+// state.
+func runConvoyTemplate(townRoot string, t *convoyops.Template, overrides map[string]string, now time.Time) error {
+	vars, err := t.ResolveVars(overrides, now)
+	if err != nil {
+		return err
+	}
+	rendered, err := t.Render(vars)
+	if err != nil {
+		return err
+	}
+	if convoyTemplateRunDry {
+		printTemplatePlan(t, rendered)
+		return nil
+	}
+
+	state, err := convoyops.LoadTemplateState(townRoot)
+	if err != nil {
+		return err
+	}
+	run := state[t.Name]
+	if run == nil {
+		run = &convoyops.TemplateRun{}
+		state[t.Name] = run
+	}
+
+	if run.LastConvoy != "" && !convoyTemplateRunForce {
+		result, err := bdShow(run.LastConvoy)
+		switch {
+		case err != nil && isBeadNotFound(err):
+			// A deleted previous convoy cannot still be running.
+			fmt.Printf("%s Previous convoy %s of %s no longer exists; treating it as finished\n",
+				style.Dim.Render("○"), run.LastConvoy, t.Name)
+		case err != nil:
+			// Without the previous convoy's status (e.g. Dolt is down) a run
+			// could duplicate an open convoy. Skip without advancing
+			// LastCheck, so the next patrol tries again.
+			run.LastSkip = fmt.Sprintf("%s: could not check previous convoy %s: %v", now.Format("2006-01-02 15:04"), run.LastConvoy, err)
+			style.PrintWarning("skipping %s: could not check previous convoy %s: %v", t.Name, run.LastConvoy, err)
+			return convoyops.SaveTemplateState(townRoot, state)
+		case result.Status != "closed":
+			run.LastCheck = now
+			run.LastSkip = fmt.Sprintf("%s: previous convoy %s still %s", now.Format("2006-01-02 15:04"), run.LastConvoy, result.Status)
+			fmt.Printf("%s Skipping %s: previous convoy %s is still %s\n",
+				style.Dim.Render("○"), t.Name, run.LastConvoy, result.Status)
+			return convoyops.SaveTemplateState(townRoot, state)
+		}
+	}
+
+	convoyID, err := instantiateConvoyTemplate(townRoot, t, rendered)
+	run.LastCheck = now
+	if err != nil {
+		run.LastSkip = fmt.Sprintf("%s: %v", now.Format("2006-01-02 15:04"), err)
+		if saveErr := convoyops.SaveTemplateState(townRoot, state); saveErr != nil {
+			style.PrintWarning("saving template state: %v", saveErr)
+		}
+		return err
+	}
+	run.LastRun = now
+	run.LastConvoy = convoyID
+	run.LastSkip = ""
+	return convoyops.SaveTemplateState(townRoot, state)
+}
+
+// instantiateConvoyTemplate creates a rendered template's issues, their
+// blocking dependencies, and a convoy tracking them. Returns the convoy ID.
+// If the run fails partway, the issues already created are closed so they
+// are not left orphaned; any that could not be closed are named in the error.
+func instantiateConvoyTemplate(townRoot string, t *convoyops.Template, rendered *convoyops.RenderedTemplate) (string, error) {
+	resolvedBeads := beads.ResolveBeadsDir(townRoot)
+	if err := beads.EnsureCustomTypes(resolvedBeads); err != nil {
+		return "", fmt.Errorf("ensuring custom types: %w", err)
+	}
+	if err := beads.EnsureCustomStatuses(resolvedBeads); err != nil {
+		return "", fmt.Errorf("ensuring custom statuses: %w", err)
+	}
+
+	// Issues are in dependency order, so every need is created first.
+	bd := beads.New(townRoot)
+	ids := make(map[string]string, len(rendered.Issues))
+	var created []string
+	for _, issue := range rendered.Issues {
+		description := strings.TrimSpace(issue.Description)
+		fields := beads.FormatTemplateFields(&beads.TemplateFields{
+			Template:     t.Name,
+			SlingFormula: issue.Formula,
+			SlingVars:    issue.FormulaVars,
+		})
+		if description != "" {
+			description += "\n\n"
+		}
+		description += fields
+
+		bead, err := bd.Create(beads.CreateOptions{
+			Title:       issue.Title,
+			Labels:      append([]string{"gt:" + issue.Type}, issue.Labels...),
+			Priority:    issue.Priority,
+			Description: description,
+			Rig:         issue.Rig,
+		})
+		if err != nil {
+			return "", rollbackTemplateIssues(bd, t.Name, created,
+				fmt.Errorf("creating issue %q in %s: %w", issue.ID, issue.Rig, err))
+		}
+		ids[issue.ID] = bead.ID
+		created = append(created, bead.ID)
+		fmt.Printf("  %s Created %s: %s\n", style.Dim.Render("○"), bead.ID, issue.Title)
+
+		for _, need := range issue.Needs {
+			if err := BdCmd("dep", "add", bead.ID, ids[need]).
+				WithAutoCommit().
+				Dir(resolveBeadDirFromTownRoot(townRoot, bead.ID)).
+				Run(); err != nil {
+				return "", rollbackTemplateIssues(bd, t.Name, created,
+					fmt.Errorf("adding dependency %s → %s: %w", bead.ID, ids[need], err))
+			}
+		}
+	}
+
+	owner := t.Owner
+	if owner == "" {
+		owner = detectSender()
+	}
+	description := strings.TrimSpace(rendered.Description)
+	if description == "" {
+		description = fmt.Sprintf("Convoy tracking %d issues", len(created))
+	}
+	description += "\n\n" + beads.FormatTemplateFields(&beads.TemplateFields{Template: t.Name})
+	description = beads.SetConvoyFields(&beads.Issue{Description: description}, &beads.ConvoyFields{
+		Owner: owner,
+		Merge: t.Merge,
+	})
+
+	convoyID := fmt.Sprintf("hq-cv-%s", generateShortID())
+	createArgs := []string{
+		"create",
+		"--type=task",
+		"--id=" + convoyID,
+		"--title=" + rendered.Title,
+		"--description=" + description,
+		"--labels=" + convoyLabels(false),
+		"--json",
+	}
+	if beads.NeedsForceForID(convoyID) {
+		createArgs = append(createArgs, "--force")
+	}
+	var stderr bytes.Buffer
+	if err := BdCmd(createArgs...).
+		WithAutoCommit().
+		Dir(townRoot).
+		Stderr(&stderr).
+		Run(); err != nil {
+		return "", rollbackTemplateIssues(bd, t.Name, created,
+			fmt.Errorf("creating convoy: %w (%s)", err, strings.TrimSpace(stderr.String())))
+	}
+	for _, id := range created {
+		if err := addTrackingRelationFn(townRoot, convoyID, id); err != nil {
+			style.PrintWarning("couldn't track %s: %s", id, err)
+		}
+	}
+
+	fmt.Printf("%s Created convoy 🚚 %s from template %s\n", style.Bold.Render("✓"), convoyID, t.Name)
+	fmt.Printf("  Name:     %s\n", rendered.Title)
+	fmt.Printf("  Tracking: %d issues\n", len(created))
+	return convoyID, nil
+}
+
+// rollbackTemplateIssues closes the issues a failed template run created and
+// returns cause, naming any issues that are still open.
+func rollbackTemplateIssues(bd *beads.Beads, template string, created []string, cause error) error {
+	if len(created) == 0 {
+		return cause
+	}
+	if err := bd.ForceCloseWithReason("convoy template "+template+" run failed", created...); err != nil {
+		return fmt.Errorf("%w (rollback failed, left open: %s: %v)", cause, strings.Join(created, ", "), err)
+	}
+	fmt.Printf("  %s Rolled back %s\n", style.Dim.Render("○"), strings.Join(created, ", "))
+	return cause
+}
+
+// printTemplatePlan prints what a template run would create.
+func printTemplatePlan(t *convoyops.Template, rendered *convoyops.RenderedTemplate) {
+	fmt.Printf("Would create convoy %q from template %s:\n", rendered.Title, t.Name)
+	for _, issue := range rendered.Issues {
+		line := fmt.Sprintf("  %s [%s] %s", issue.ID, issue.Rig, issue.Title)
+		if issue.Formula != "" {
+			line += style.Dim.Render(" (formula " + issue.Formula + ")")
+		}
+		fmt.Println(line)
+		if len(issue.Needs) > 0 {
+			fmt.Printf("    %s\n", style.Dim.Render("needs "+strings.Join(issue.Needs, ", ")))
+		}
+	}
+}
+
+// parseTemplateVars parses --var key=value flags.
+func parseTemplateVars(args []string) (map[string]string, error) {
+	vars := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", arg)
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
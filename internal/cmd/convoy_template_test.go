@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+)
+
+func TestParseTemplateVars(t *testing.T) {
+	got, err := parseTemplateVars([]string{"ecosystem=npm", "note=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseTemplateVars: %v", err)
+	}
+	want := map[string]string{"ecosystem": "npm", "note": "a=b", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTemplateVars = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"ecosystem", "=npm"} {
+		if _, err := parseTemplateVars([]string{bad}); err == nil {
+			t.Errorf("parseTemplateVars(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRunConvoyTemplate_SkipsWhenPreviousConvoyUnknown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX bd stub")
+	}
+	townRoot := t.TempDir()
+	logPath := filepath.Join(townRoot, "bd.log")
+	binDir := t.TempDir()
+	writeBDStub(t, binDir, `#!/usr/bin/env sh
+echo "$*" >> "`+logPath+`"
+case "$*" in
+  *show*) echo "dolt server unreachable" >&2; exit 1 ;;
+esac
+exit 0
+`, "")
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmpl, err := convoyops.ParseTemplate([]byte(`
+name = "weekly"
+title = "Weekly"
+[[issues]]
+id = "a"
+rig = "gastown"
+title = "Do it"
+`))
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	lastCheck := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := convoyops.SaveTemplateState(townRoot, convoyops.TemplateState{
+		"weekly": {LastCheck: lastCheck, LastConvoy: "hq-cv-prev"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := lastCheck.Add(7 * 24 * time.Hour)
+	if err := runConvoyTemplate(townRoot, tmpl, nil, now); err != nil {
+		t.Fatalf("runConvoyTemplate: %v", err)
+	}
+
+	state, err := convoyops.LoadTemplateState(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := state["weekly"]
+	if !strings.Contains(run.LastSkip, "could not check previous convoy hq-cv-prev") {
+		t.Errorf("LastSkip = %q", run.LastSkip)
+	}
+	if !run.LastCheck.Equal(lastCheck) || run.LastConvoy != "hq-cv-prev" {
+		t.Errorf("skip must not advance the schedule or replace the convoy: %+v", run)
+	}
+	if data, _ := os.ReadFile(logPath); strings.Contains(string(data), "create") {
+		t.Errorf("nothing should be created when the previous convoy is unknown, bd calls:\n%s", data)
+	}
+}
+
+func TestRunConvoyTemplate_RunsWhenPreviousConvoyMissing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX bd stub")
+	}
+	townRoot := t.TempDir()
+	logPath := filepath.Join(townRoot, "bd.log")
+	binDir := t.TempDir()
+	writeBDStub(t, binDir, `#!/usr/bin/env sh
+echo "$*" >> "`+logPath+`"
+case "$*" in
+  *show*) echo "Error: no issue found matching hq-cv-prev" >&2; exit 1 ;;
+  *create*) exit 1 ;;
+esac
+exit 0
+`, "")
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmpl, err := convoyops.ParseTemplate([]byte(`
+name = "weekly"
+title = "Weekly"
+[[issues]]
+id = "a"
+rig = "gastown"
+title = "Do it"
+`))
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	lastCheck := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := convoyops.SaveTemplateState(townRoot, convoyops.TemplateState{
+		"weekly": {LastCheck: lastCheck, LastConvoy: "hq-cv-prev"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stubbed town cannot create issues; what matters is that the run
+	// was attempted rather than skipped.
+	now := lastCheck.Add(7 * 24 * time.Hour)
+	_ = runConvoyTemplate(townRoot, tmpl, nil, now)
+
+	state, err := convoyops.LoadTemplateState(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run := state["weekly"]; !run.LastCheck.Equal(now) || strings.Contains(run.LastSkip, "could not check") {
+		t.Errorf("run should advance past the missing convoy: %+v", run)
+	}
+}
+
+func TestInstantiateConvoyTemplate_RollsBackOnDependencyFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX bd stub")
+	}
+	townRoot := t.TempDir()
+	beadsDir := filepath.Join(townRoot, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(beadsDir, ".gt-types-configured"), []byte(beads.TypeConfigSentinelValue()), 0644)
+	_ = os.WriteFile(filepath.Join(beadsDir, ".gt-statuses-configured"), []byte(strings.Join(constants.BeadsCustomStatusesList(), ",")), 0644)
+	if err := os.MkdirAll(filepath.Join(townRoot, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(townRoot, "mayor", "town.json"), []byte(`{"type":"town","name":"test"}`), 0644)
+	logPath := filepath.Join(townRoot, "bd.log")
+	countPath := filepath.Join(townRoot, "count")
+	binDir := t.TempDir()
+	writeBDStub(t, binDir, `#!/usr/bin/env sh
+echo "$*" >> "`+logPath+`"
+case "$*" in
+  *"create --json"*)
+    n=$(cat "`+countPath+`" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "`+countPath+`"
+    echo "{\"id\":\"gt-t$n\",\"title\":\"t\"}"
+    ;;
+  *"dep add"*) echo "dependency refused" >&2; exit 1 ;;
+esac
+exit 0
+`, "")
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tmpl, err := convoyops.ParseTemplate([]byte(`
+name = "weekly"
+title = "Weekly"
+[[issues]]
+id = "a"
+rig = "hq"
+title = "First"
+[[issues]]
+id = "b"
+rig = "hq"
+title = "Second"
+needs = ["a"]
+`))
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	rendered, err := tmpl.Render(nil)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	_, err = instantiateConvoyTemplate(townRoot, tmpl, rendered)
+	if err == nil || !strings.Contains(err.Error(), "adding dependency gt-t2 → gt-t1") {
+		t.Fatalf("err = %v, want dependency failure", err)
+	}
+	data, _ := os.ReadFile(logPath)
+	log := string(data)
+	if !strings.Contains(log, "close") || !strings.Contains(log, "gt-t1") || !strings.Contains(log, "gt-t2") {
+		t.Errorf("created issues should be rolled back, bd calls:\n%s", log)
+	}
+	if strings.Contains(log, "hq-cv-") {
+		t.Errorf("no convoy should be created after a dependency failure, bd calls:\n%s", log)
+	}
+}
+
+func TestRollbackTemplateIssues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX bd stub")
+	}
+	townRoot := t.TempDir()
+	logPath := filepath.Join(townRoot, "bd.log")
+	binDir := t.TempDir()
+	writeBDStub(t, binDir, `#!/usr/bin/env sh
+echo "$*" >> "`+logPath+`"
+[ -n "$BD_FAIL_CLOSE" ] && case "$*" in *close*) exit 1 ;; esac
+exit 0
+`, "")
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	cause := errors.New("creating convoy: boom")
+
+	err := rollbackTemplateIssues(beads.NewIsolated(townRoot), "weekly", []string{"gt-a1", "gt-b2"}, cause)
+	if !errors.Is(err, cause) || strings.Contains(err.Error(), "left open") {
+		t.Errorf("rollback error = %v, want the cause alone", err)
+	}
+	data, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(data), "close") || !strings.Contains(string(data), "gt-a1") || !strings.Contains(string(data), "gt-b2") {
+		t.Errorf("created issues not closed, bd calls:\n%s", data)
+	}
+
+	t.Setenv("BD_FAIL_CLOSE", "1")
+	err = rollbackTemplateIssues(beads.NewIsolated(townRoot), "weekly", []string{"gt-a1"}, cause)
+	if !errors.Is(err, cause) || !strings.Contains(err.Error(), "left open: gt-a1") {
+		t.Errorf("failed rollback error = %v, want the open issues named", err)
+	}
+}
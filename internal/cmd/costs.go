@@ -20,6 +20,7 @@ import (
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/util"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -928,12 +929,6 @@ type CostLogEntry struct {
 	WorkItem  string    `json:"work_item,omitempty"`
 }
 
-// getCostsLogPath returns the path to the costs log file.
-// Location: $GT_HOME/.gt/costs.jsonl when GT_HOME is set, otherwise ~/.gt/.
-func getCostsLogPath() string {
-	return filepath.Join(gtDataDir(), "costs.jsonl")
-}
-
 // runCostsRecord captures the final cost from a session and appends it to a local log file.
 // This is called by the Claude Code Stop hook. It's designed to never fail due to
 // database availability - it's a simple file append operation.
@@ -1007,7 +1002,7 @@ func runCostsRecord(cmd *cobra.Command, args []string) error {
 	}
 
 	// Append to log file
-	logPath := getCostsLogPath()
+	logPath := util.CostsLogPath()
 
 	// Ensure directory exists
 	logDir := filepath.Dir(logPath)
@@ -1222,7 +1217,7 @@ func runCostsDigest(cmd *cobra.Command, args []string) error {
 
 // querySessionCostEntries reads session cost entries from the local log file for a target date.
 func querySessionCostEntries(targetDate time.Time) ([]CostEntry, error) {
-	logPath := getCostsLogPath()
+	logPath := util.CostsLogPath()
 
 	// Read log file
 	data, err := os.ReadFile(logPath)
@@ -1349,7 +1344,7 @@ func createCostDigestBead(digest CostDigest) (string, error) {
 // deleteSessionCostEntries removes entries for a target date from the costs log file.
 // It rewrites the file without the entries for that date.
 func deleteSessionCostEntries(targetDate time.Time) (int, error) {
-	logPath := getCostsLogPath()
+	logPath := util.CostsLogPath()
 
 	// Read log file
 	data, err := os.ReadFile(logPath)
@@ -36,6 +36,11 @@ The dashboard shows real-time convoy status with:
 - Last activity indicator (green/yellow/red)
 - Auto-refresh every 30 seconds via htmx
 
+Before binding to a non-loopback address, configure authentication with
+'gt dashboard user add' (browser sign-in) or 'gt dashboard token add'
+(API clients), or set web_auth.trusted_proxy in settings/config.json.
+Every mutating API call is recorded in the events log ('gt audit').
+
 Example:
   gt dashboard                    # Start on default port 8080
   gt dashboard --port 3000        # Start on port 3000
@@ -60,6 +65,7 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	var handler http.Handler
 	var err error
 	webCfg := config.DefaultWebTimeoutsConfig()
+	var authCfg *config.WebAuthConfig
 
 	townRoot, wsErr := workspace.FindFromCwdOrError()
 	if wsErr != nil {
@@ -86,14 +92,18 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 			if ts.WebTimeouts != nil {
 				webCfg = ts.WebTimeouts
 			}
+			authCfg = ts.WebAuth
 		} else {
 			fmt.Fprintf(cmd.ErrOrStderr(), "warning: loading town settings: %v (using defaults)\n", loadErr)
 		}
 
-		handler, err = web.NewDashboardMux(fetcher, webCfg)
+		handler, err = web.NewDashboardMux(fetcher, webCfg, authCfg)
 		if err != nil {
 			return fmt.Errorf("creating dashboard handler: %w", err)
 		}
+		if !authCfg.Enabled() && !isLoopbackBind(dashboardBind) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: dashboard is listening on %s without authentication; anyone who can reach it can run commands (see 'gt dashboard token add')\n", dashboardBind)
+		}
 	}
 
 	// Build the listen address and display URL
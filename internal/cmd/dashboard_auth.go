@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/web"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	dashboardAuthRole          string
+	dashboardUserPasswordStdin bool
+)
+
+var dashboardTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage dashboard bearer tokens",
+	Long: `Manage static bearer tokens for the dashboard API.
+
+Tokens are stored as SHA-256 hashes in settings/config.json under web_auth.
+The plaintext is printed once when the token is created.
+
+Roles:
+  viewer    Read every page and run read-only commands
+  operator  Also create/close issues, send mail, and run action commands
+  admin     Also add rigs and start/stop agents
+
+Examples:
+  gt dashboard token add ci --role operator
+  gt dashboard token list
+  gt dashboard token revoke ci
+  curl -H "Authorization: Bearer gtd_..." http://host:8080/api/v1/ready`,
+	RunE: requireSubcommand,
+}
+
+var dashboardTokenAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a bearer token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDashboardTokenAdd,
+}
+
+var dashboardTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List bearer tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runDashboardTokenList,
+}
+
+var dashboardTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a bearer token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDashboardTokenRevoke,
+}
+
+var dashboardUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage dashboard user accounts",
+	Long: `Manage local dashboard accounts.
+
+Browsers sign in with HTTP basic auth. Passwords are stored as bcrypt
+hashes in settings/config.json under web_auth. Accounts without a password
+only assign a role to users asserted by a trusted reverse proxy
+(web_auth.trusted_proxy).
+
+Examples:
+  gt dashboard user add alice --role admin
+  echo "$PW" | gt dashboard user add bot --role viewer --password-stdin
+  gt dashboard user list
+  gt dashboard user remove alice`,
+	RunE: requireSubcommand,
+}
+
+var dashboardUserAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create or update a user account",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDashboardUserAdd,
+}
+
+var dashboardUserListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List user accounts",
+	Args:  cobra.NoArgs,
+	RunE:  runDashboardUserList,
+}
+
+var dashboardUserRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a user account",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDashboardUserRemove,
+}
+
+func init() {
+	dashboardTokenAddCmd.Flags().StringVar(&dashboardAuthRole, "role", "viewer", "Role: viewer, operator, or admin")
+	dashboardUserAddCmd.Flags().StringVar(&dashboardAuthRole, "role", "viewer", "Role: viewer, operator, or admin")
+	dashboardUserAddCmd.Flags().BoolVar(&dashboardUserPasswordStdin, "password-stdin", false, "Read the password from stdin")
+
+	dashboardTokenCmd.AddCommand(dashboardTokenAddCmd, dashboardTokenListCmd, dashboardTokenRevokeCmd)
+	dashboardUserCmd.AddCommand(dashboardUserAddCmd, dashboardUserListCmd, dashboardUserRemoveCmd)
+	dashboardCmd.AddCommand(dashboardTokenCmd, dashboardUserCmd)
+}
+
+// loadDashboardAuth loads town settings for editing web_auth.
+func loadDashboardAuth() (string, *config.TownSettings, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	settingsPath := config.TownSettingsPath(townRoot)
+	ts, err := config.LoadOrCreateTownSettings(settingsPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("loading town settings: %w", err)
+	}
+	if ts.WebAuth == nil {
+		ts.WebAuth = &config.WebAuthConfig{}
+	}
+	return settingsPath, ts, nil
+}
+
+func runDashboardTokenAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	role, err := web.ParseRole(dashboardAuthRole)
+	if err != nil {
+		return err
+	}
+	settingsPath, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	for _, t := range ts.WebAuth.Tokens {
+		if t.Name == name {
+			return fmt.Errorf("token %q already exists (revoke it first)", name)
+		}
+	}
+
+	token, err := web.GenerateToken()
+	if err != nil {
+		return err
+	}
+	ts.WebAuth.Tokens = append(ts.WebAuth.Tokens, config.WebAuthToken{
+		Name:      name,
+		Role:      role.String(),
+		Hash:      web.HashToken(token),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err := config.SaveTownSettings(settingsPath, ts); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+
+	fmt.Printf("%s Created %s token %s\n", style.Success.Render("✓"), role, style.Bold.Render(name))
+	fmt.Printf("\n  %s\n\n", token)
+	fmt.Println(style.Dim.Render("This is the only time the token is shown. Restart gt dashboard to apply."))
+	return nil
+}
+
+func runDashboardTokenList(cmd *cobra.Command, args []string) error {
+	_, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	if len(ts.WebAuth.Tokens) == 0 {
+		fmt.Println("No dashboard tokens.")
+		return nil
+	}
+	for _, t := range ts.WebAuth.Tokens {
+		fmt.Printf("  %-20s %-9s %s\n", t.Name, t.Role, style.Dim.Render(t.CreatedAt))
+	}
+	return nil
+}
+
+func runDashboardTokenRevoke(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	settingsPath, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	kept := ts.WebAuth.Tokens[:0]
+	for _, t := range ts.WebAuth.Tokens {
+		if t.Name != name {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(ts.WebAuth.Tokens) {
+		return fmt.Errorf("token %q not found", name)
+	}
+	ts.WebAuth.Tokens = kept
+	if err := config.SaveTownSettings(settingsPath, ts); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+	fmt.Printf("%s Revoked token %s (restart gt dashboard to apply)\n", style.Success.Render("✓"), style.Bold.Render(name))
+	return nil
+}
+
+func runDashboardUserAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if strings.ContainsAny(name, ": \t") {
+		return fmt.Errorf("invalid user name %q: must not contain colons or whitespace", name)
+	}
+	role, err := web.ParseRole(dashboardAuthRole)
+	if err != nil {
+		return err
+	}
+	password, err := readDashboardPassword(cmd)
+	if err != nil {
+		return err
+	}
+	var hash string
+	if password != "" {
+		if hash, err = web.HashPassword(password); err != nil {
+			return err
+		}
+	}
+
+	settingsPath, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	user := config.WebAuthUser{Name: name, Role: role.String(), PasswordHash: hash}
+	replaced := false
+	for i, u := range ts.WebAuth.Users {
+		if u.Name == name {
+			ts.WebAuth.Users[i] = user
+			replaced = true
+		}
+	}
+	if !replaced {
+		ts.WebAuth.Users = append(ts.WebAuth.Users, user)
+	}
+	if err := config.SaveTownSettings(settingsPath, ts); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+
+	verb := "Added"
+	if replaced {
+		verb = "Updated"
+	}
+	fmt.Printf("%s %s %s user %s (restart gt dashboard to apply)\n", style.Success.Render("✓"), verb, role, style.Bold.Render(name))
+	if hash == "" {
+		fmt.Println(style.Dim.Render("No password set: the account only applies to trusted-proxy sign-ins."))
+	}
+	return nil
+}
+
+// readDashboardPassword reads a password from stdin or an interactive prompt.
+// An empty password creates a proxy-only account.
+func readDashboardPassword(cmd *cobra.Command) (string, error) {
+	if dashboardUserPasswordStdin {
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("stdin is not a terminal; use --password-stdin")
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), "Password (empty for proxy-only): ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	if len(first) == 0 {
+		return "", nil
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), "Confirm password: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	if string(first) != string(second) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return string(first), nil
+}
+
+func runDashboardUserList(cmd *cobra.Command, args []string) error {
+	_, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	if len(ts.WebAuth.Users) == 0 {
+		fmt.Println("No dashboard users.")
+		return nil
+	}
+	for _, u := range ts.WebAuth.Users {
+		kind := "password"
+		if u.PasswordHash == "" {
+			kind = "proxy-only"
+		}
+		fmt.Printf("  %-20s %-9s %s\n", u.Name, u.Role, style.Dim.Render(kind))
+	}
+	return nil
+}
+
+func runDashboardUserRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	settingsPath, ts, err := loadDashboardAuth()
+	if err != nil {
+		return err
+	}
+	kept := ts.WebAuth.Users[:0]
+	for _, u := range ts.WebAuth.Users {
+		if u.Name != name {
+			kept = append(kept, u)
+		}
+	}
+	if len(kept) == len(ts.WebAuth.Users) {
+		return fmt.Errorf("user %q not found", name)
+	}
+	ts.WebAuth.Users = kept
+	if err := config.SaveTownSettings(settingsPath, ts); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+	fmt.Printf("%s Removed user %s (restart gt dashboard to apply)\n", style.Success.Render("✓"), style.Bold.Render(name))
+	return nil
+}
+
+// isLoopbackBind reports whether a --bind address only accepts local connections.
+func isLoopbackBind(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bind)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var stepTimeoutsDryRun bool
+
+var deaconStepTimeoutsCmd = &cobra.Command{
+	Use:   "step-timeouts",
+	Short: "Enforce workflow step timeouts, retries, and failure policies",
+	Long: `Enforce the timeout, retries, and on_failure policies of workflow steps
+(gt formula run) in open workflows.
+
+A step that has been hooked or in progress longer than its timeout, measured
+from the workflow_started_at stamp gt sling records on the step, is failed
+and the polecat holding it is stopped.
+A failed step (timed out, or ended with gt done --status ESCALATED) is
+retried after its backoff while retries remain, then handled by on_failure:
+
+  fail-molecule  park the step as blocked and note the failure on the workflow
+  skip           close the step as skipped so its dependents proceed
+  escalate       park the step and escalate to the Mayor
+  goto:<step>    re-run <step>, then this step
+
+This command also re-dispatches failed steps whose retry time has come.
+Outcomes are posted to the feed (step_failed) and shown by gt mol status.
+
+This is called by the Deacon during patrol. Run manually for debugging.
+
+Examples:
+  gt deacon step-timeouts            # Enforce policies
+  gt deacon step-timeouts --dry-run  # Show what would be done`,
+	RunE: runDeaconStepTimeouts,
+}
+
+func init() {
+	deaconStepTimeoutsCmd.Flags().BoolVar(&stepTimeoutsDryRun, "dry-run", false, "Show what would be done without doing it")
+	deaconCmd.AddCommand(deaconStepTimeoutsCmd)
+}
+
+func runDeaconStepTimeouts(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	b := beads.New(townRoot)
+	workflows, err := b.List(beads.ListOptions{Status: "open", Label: "gt:workflow"})
+	if err != nil {
+		return fmt.Errorf("listing workflows: %w", err)
+	}
+
+	now := time.Now()
+	acted := 0
+	for _, wf := range workflows {
+		tracked, err := getTrackedIssues(filepath.Join(townRoot, ".beads"), wf.ID)
+		if err != nil {
+			style.PrintWarning("%v", err)
+			continue
+		}
+		for _, t := range tracked {
+			if t.Status == "closed" {
+				continue
+			}
+			issue, err := b.Show(t.ID)
+			if err != nil {
+				continue
+			}
+			fields := beads.ParseStepFields(issue)
+			if fields == nil {
+				continue
+			}
+			check, reason := stepPolicyDue(fields, issue.Status, now)
+			switch check {
+			case stepTimedOut:
+				acted++
+				if stepTimeoutsDryRun {
+					fmt.Printf("  %s %s (%s) %s: would fail\n", style.Bold.Render("?"), fields.Step, issue.ID, reason)
+					continue
+				}
+				if _, err := timeOutWorkflowStep(townRoot, b, issue, fields, reason, now); err != nil {
+					style.PrintWarning("%v", err)
+				}
+			case stepRetryDue:
+				acted++
+				if stepTimeoutsDryRun {
+					fmt.Printf("  %s %s (%s): would retry (attempt %d)\n", style.Bold.Render("?"), fields.Step, issue.ID, fields.Attempt)
+					continue
+				}
+				if err := retryWorkflowStep(townRoot, b, issue); err != nil {
+					style.PrintWarning("%v", err)
+				}
+			}
+		}
+	}
+	if acted == 0 {
+		fmt.Printf("%s No workflow steps need attention\n", style.Dim.Render("○"))
+	}
+	return nil
+}
+
+// retryWorkflowStep reopens a failed step whose retry time has come and
+// dispatches it again. A step that is somehow still assigned is released
+// from its holder first, so the retry is the only worker on it.
+func retryWorkflowStep(townRoot string, b *beads.Beads, issue *beads.Issue) error {
+	status := "open"
+	opts := beads.UpdateOptions{Status: &status}
+	if issue.Assignee != "" {
+		releaseStepHolderFn(townRoot, issue.Assignee)
+		unassigned := ""
+		opts.Assignee = &unassigned
+	}
+	if err := b.Update(issue.ID, opts); err != nil {
+		return fmt.Errorf("reopening %s for retry: %w", issue.ID, err)
+	}
+	fmt.Printf("%s Retrying step %s\n", style.Bold.Render("↻"), issue.ID)
+	return slingWorkflowStep(townRoot, issue.ID)
+}
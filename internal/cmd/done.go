@@ -1920,6 +1920,9 @@ notifyWitness:
 	if err := events.LogFeed(events.TypeDone, sender, events.DonePayload(issueID, branch)); err != nil {
 		style.PrintWarning("could not log feed event: %v", err)
 	}
+	if exitType == ExitCompleted && !pushFailed && !mrFailed {
+		recordCapabilityCompletion(townRoot, sender, issueID)
+	}
 
 	// Update agent bead state (ZFC: self-report completion). If push/MR failed,
 	// keep the hook intact so Witness can recover the still-open work.
@@ -2385,8 +2388,27 @@ func updateAgentStateOnDone(cwd, townRoot, exitType, issueID string) error {
 				}
 			}
 
+			// Failure policy: an ESCALATED workflow step with retries or
+			// on_failure is retried or handled by its policy instead of closing.
+			if exitType == ExitEscalated && isWorkflowStep {
+				if handled, err := failWorkflowStepOnEscalate(townRoot, hookBd, hookedBead, detectSender()); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				} else if handled {
+					goto doneStateUpdate
+				}
+			}
+
+			// repeat_until loop: a workflow step whose loop condition is still
+			// false is reopened for its next iteration instead of closing.
+			repeated, repeatErr := repeatWorkflowStep(hookBd, hookedBead)
+			if repeatErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", repeatErr)
+			}
+
 			// Acceptance criteria gate: skip close if criteria are unchecked.
-			if unchecked := beads.HasUncheckedCriteria(hookedBead); unchecked > 0 {
+			if repeated {
+				fmt.Fprintf(os.Stderr, "  %s stays open and will be dispatched again.\n", hookedBeadID)
+			} else if unchecked := beads.HasUncheckedCriteria(hookedBead); unchecked > 0 {
 				style.PrintWarning("hooked bead %s has %d unchecked acceptance criteria — skipping close", hookedBeadID, unchecked)
 				fmt.Fprintf(os.Stderr, "  The bead will remain open for witness/mayor review.\n")
 			} else if err := hookBd.Close(hookedBeadID); err != nil {
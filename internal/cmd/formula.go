@@ -5,11 +5,13 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/base32"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -26,15 +28,16 @@ import (
 
 // Formula command flags
 var (
-	formulaListJSON   bool
-	formulaShowJSON   bool
-	formulaRunPR      int
-	formulaRunRig     string
-	formulaRunDryRun  bool
-	formulaRunAgent   string
-	formulaRunFiles   []string
-	formulaRunSet     []string
-	formulaCreateType string
+	formulaListJSON     bool
+	formulaShowJSON     bool
+	formulaShowResolved bool
+	formulaRunPR        int
+	formulaRunRig       string
+	formulaRunDryRun    bool
+	formulaRunAgent     string
+	formulaRunFiles     []string
+	formulaRunSet       []string
+	formulaCreateType   string
 )
 
 var formulaCmd = &cobra.Command{
@@ -54,6 +57,14 @@ Commands:
   show    Display formula details (steps, variables, composition)
   run     Execute a formula (pour and dispatch)
   create  Create a new formula template
+  schema  Print the JSON Schema for a formula's inputs
+  test    Simulate a formula against scripted scenarios
+
+Registry commands:
+  search   Search a formula registry
+  install  Install formulas (optionally pinned to a version)
+  update   Update unpinned installed formulas
+  publish  Publish a formula to a directory registry
 
 Search paths (in order):
   1. .beads/formulas/ (project)
@@ -94,9 +105,15 @@ Shows:
   - Steps with dependencies
   - Composition rules (extends, aspects)
 
+With --resolved, the formula is resolved locally instead: parents named in
+extends are merged, compose.expand rules are applied, and advice from every
+aspect in compose.aspects is woven in. The resulting steps are printed with
+their dependencies, which is exactly what "gt formula run" executes.
+
 Examples:
   gt formula show shiny
-  gt formula show rule-of-five --json`,
+  gt formula show rule-of-five --json
+  gt formula show shiny-secure --resolved`,
 	Args: cobra.ExactArgs(1),
 	RunE: runFormulaShow,
 }
@@ -166,6 +183,7 @@ func init() {
 
 	// Show flags
 	formulaShowCmd.Flags().BoolVar(&formulaShowJSON, "json", false, "Output as JSON")
+	formulaShowCmd.Flags().BoolVar(&formulaShowResolved, "resolved", false, "Show steps after extends, expand, and aspect composition")
 
 	// Run flags
 	formulaRunCmd.Flags().IntVar(&formulaRunPR, "pr", 0, "GitHub PR number to run formula on")
@@ -200,9 +218,12 @@ func runFormulaList(cmd *cobra.Command, args []string) error {
 	return bdCmd.Run()
 }
 
-// runFormulaShow delegates to bd formula show
+// runFormulaShow delegates to bd formula show, or resolves locally with --resolved.
 func runFormulaShow(cmd *cobra.Command, args []string) error {
 	formulaName := args[0]
+	if formulaShowResolved {
+		return showResolvedFormula(formulaName)
+	}
 	bdArgs := []string{"formula", "show", formulaName}
 	if formulaShowJSON {
 		bdArgs = append(bdArgs, "--json")
@@ -282,6 +303,27 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("parsing formula: %w", err)
 	}
 
+	// Apply extends/compose (including aspect weaving)
+	f, err = formula.Resolve(f, formulaSearchPaths())
+	if err != nil {
+		return fmt.Errorf("resolving formula: %w", err)
+	}
+
+	// Check typed inputs; prompt for missing required ones on a TTY
+	inputVars := append([]string(nil), formulaRunSet...)
+	if formulaRunPR > 0 {
+		inputVars = append(inputVars, fmt.Sprintf("pr=%d", formulaRunPR))
+	}
+	if len(formulaRunFiles) > 0 {
+		inputVars = append(inputVars, "files="+strings.Join(formulaRunFiles, ","))
+	}
+	townRoot, _ := workspace.FindFromCwd()
+	answers, err := checkFormulaInputs(f, inputVars, formulaInputChecker(townRoot, nil), !formulaRunDryRun)
+	if err != nil {
+		return err
+	}
+	formulaRunSet = append(formulaRunSet, answers...)
+
 	// Handle dry-run mode
 	if formulaRunDryRun {
 		return dryRunFormula(f, formulaName, targetRig)
@@ -772,11 +814,19 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 
 	// Step 2: Create step beads and wire dependencies
 	stepBeads := make(map[string]string) // step.ID -> bead ID
+	skippedSteps := make(map[string]bool)
 	setVars := parseSetVars(formulaRunSet)
+	varMap := buildFormulaVarMap(f, formulaRunSet)
 
 	for _, step := range f.Steps {
 		stepBeadID := fmt.Sprintf("%s-wfs-%s", rigPrefix, generateFormulaShortID())
 		stepDescription := workflowStepDescription(step, substituteFormulaVars(step.Description, setVars))
+		fields, run, err := workflowStepFields(step, varMap)
+		if err != nil {
+			return err
+		}
+		fields.Workflow = workflowID
+		stepDescription = beads.SetStepFields(&beads.Issue{Description: stepDescription}, fields)
 
 		// Use --body-file=- (stdin) for the description to avoid CLI arg
 		// length limits and quoting issues with large markdown descriptions.
@@ -825,6 +875,21 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 		if len(step.Needs) > 0 {
 			needsStr = fmt.Sprintf(" (needs: %s)", strings.Join(step.Needs, ", "))
 		}
+
+		// A when condition that is already false from vars alone skips the
+		// step now; closing it unblocks its dependents.
+		if !run {
+			if err := BdCmd("close", stepBeadID, "--reason=skipped: "+fields.Skipped).
+				WithAutoCommit().
+				Dir(rigBeadsDir).
+				Run(); err != nil {
+				fmt.Printf("%s Failed to close skipped step %s: %v\n",
+					style.Dim.Render("Warning:"), step.ID, err)
+			}
+			skippedSteps[step.ID] = true
+			fmt.Printf("  %s %s: %s%s (skipped: %s)\n", style.Dim.Render("⊘"), step.ID, stepBeadID, needsStr, fields.Skipped)
+			continue
+		}
 		fmt.Printf("  %s %s: %s%s\n", style.Dim.Render("○"), step.ID, stepBeadID, needsStr)
 	}
 
@@ -845,7 +910,7 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 	slingCount := 0
 	interactiveCount := 0
 	for _, step := range f.Steps {
-		if len(step.Needs) > 0 {
+		if len(step.Needs) > 0 || skippedSteps[step.ID] {
 			continue // has unmet dependencies — will be auto-dispatched
 		}
 
@@ -872,9 +937,10 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 
 		// Non-interactive step: sling to the step's target, or to the rig's
 		// polecat pool by default.
-		// Agent precedence: CLI --agent > formula-level
+		// Agent precedence: CLI --agent > step agent/model constraints
+		// (routed by gt sling from the step bead) > formula-level
 		stepAgent := formulaRunAgent
-		if stepAgent == "" {
+		if stepAgent == "" && !step.HasAgentConstraints() {
 			stepAgent = f.Agent
 		}
 		stepTarget := workflowStepTarget(step, targetRig)
@@ -899,7 +965,7 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 	}
 
 	// Summary
-	blockedCount := len(f.Steps) - slingCount - interactiveCount
+	blockedCount := len(f.Steps) - slingCount - interactiveCount - len(skippedSteps)
 	fmt.Printf("\n%s Workflow dispatched!\n", style.Bold.Render("✓"))
 	fmt.Printf("  Workflow: %s\n", workflowID)
 	if interactiveCount > 0 {
@@ -912,6 +978,9 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 		fmt.Printf("  Steps:    %d total, %d dispatched, %d awaiting dependencies\n",
 			len(f.Steps), slingCount, blockedCount)
 	}
+	if len(skippedSteps) > 0 {
+		fmt.Printf("  Skipped:  %d (when condition false)\n", len(skippedSteps))
+	}
 	fmt.Printf("\n  Track progress: gt convoy status %s\n", workflowID)
 
 	return nil
@@ -920,6 +989,7 @@ func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string) e
 const workflowTargetField = "workflow_target"
 
 func workflowStepDescription(step formula.Step, description string) string {
+	description += workflowStepOutputsNote(step)
 	target := strings.TrimSpace(step.Target)
 	if target == "" {
 		return description
@@ -927,6 +997,29 @@ func workflowStepDescription(step formula.Step, description string) string {
 	return fmt.Sprintf("%s: %s\n\n%s", workflowTargetField, target, description)
 }
 
+// workflowStepOutputsNote tells the agent which outputs the step must record.
+func workflowStepOutputsNote(step formula.Step) string {
+	if len(step.Outputs) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(step.Outputs))
+	for name := range step.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("\n\n## Outputs\n\nRecord before finishing: gt mol step output set <name> <value>\n")
+	for _, name := range names {
+		out := step.Outputs[name]
+		fmt.Fprintf(&sb, "\n- %s (%s)", name, out.Kind())
+		if out.Description != "" {
+			sb.WriteString(": " + out.Description)
+		}
+	}
+	return sb.String()
+}
+
 func workflowStepTarget(step formula.Step, targetRig string) string {
 	target := strings.TrimSpace(step.Target)
 	if target == "" || target == "rig" {
@@ -1035,9 +1128,9 @@ func substituteFormulaVars(text string, vars map[string]interface{}) string {
 	})
 }
 
-// findFormulaFile searches for a formula file by name
-func findFormulaFile(name string) (string, error) {
-	// Search paths in order
+// formulaSearchPaths returns the directories searched for formula files,
+// in priority order.
+func formulaSearchPaths() []string {
 	searchPaths := []string{}
 
 	// 1. Project .beads/formulas/
@@ -1055,9 +1148,22 @@ func findFormulaFile(name string) (string, error) {
 		searchPaths = append(searchPaths, filepath.Join(home, ".beads", "formulas"))
 	}
 
+	return searchPaths
+}
+
+// errFormulaNotFound is returned (wrapped) by findFormulaFile when no search
+// path has the formula.
+var errFormulaNotFound = errors.New("not found in search paths")
+
+// findFormulaFile searches for a formula file by name
+func findFormulaFile(name string) (string, error) {
 	// Try each path with common extensions
 	extensions := []string{".formula.toml", ".formula.json"}
-	for _, basePath := range searchPaths {
+	for _, basePath := range formulaSearchPaths() {
+		// A version installed by gt formula install wins within its directory.
+		if path, err := formula.InstalledFormulaPath(basePath, name); err != nil || path != "" {
+			return path, err
+		}
 		for _, ext := range extensions {
 			path := filepath.Join(basePath, name+ext)
 			if _, err := os.Stat(path); err == nil {
@@ -1066,7 +1172,7 @@ func findFormulaFile(name string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("formula '%s' not found in search paths", name)
+	return "", fmt.Errorf("formula '%s' %w", name, errFormulaNotFound)
 }
 
 // parseFormulaFile parses a formula file using the formula package's TOML parser.
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"golang.org/x/term"
+)
+
+var formulaSchemaCmd = &cobra.Command{
+	Use:   "schema <name>",
+	Short: "Print the JSON Schema for a formula's inputs",
+	Long: `Print a JSON Schema (draft 2020-12) describing a formula's [vars] and
+[inputs], so a UI can render a form for them.
+
+Input types map to schema types: int -> integer, number -> number,
+bool -> boolean, enum -> string with enum, regex -> string with pattern.
+bead-id, rig-name, and file-path are strings with a matching "format".
+
+Examples:
+  gt formula schema shiny
+  gt formula schema code-review > code-review.schema.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaSchema,
+}
+
+func init() {
+	formulaCmd.AddCommand(formulaSchemaCmd)
+}
+
+func runFormulaSchema(cmd *cobra.Command, args []string) error {
+	f, err := loadResolvedFormula(args[0])
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f.InputSchema())
+}
+
+// autoFormulaVars are filled in by gt sling itself when it instantiates a
+// formula on a bead (see formulaVarsForBead, loadRigCommandVars, and
+// ensureFormulaRequiredVars), so they are never prompted for.
+var autoFormulaVars = []string{
+	"feature", "issue", "base_branch", "resume_branch",
+	"setup_command", "typecheck_command", "lint_command", "test_command", "build_command",
+}
+
+// formulaInputChecker returns an input checker that resolves rig names
+// against mayor/rigs.json and bead IDs against the town's beads.
+func formulaInputChecker(townRoot string, supplied []string) formula.InputChecker {
+	c := formula.InputChecker{
+		Supplied: supplied,
+		FileExists: func(path string) bool {
+			_, err := os.Stat(path)
+			return err == nil
+		},
+	}
+	if townRoot == "" {
+		return c
+	}
+	if rigs, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json")); err == nil {
+		c.RigExists = func(name string) bool {
+			_, ok := rigs.Rigs[name]
+			return ok
+		}
+	}
+	b := beads.New(townRoot)
+	c.BeadExists = func(id string) bool {
+		_, err := b.Show(id)
+		return err == nil
+	}
+	return c
+}
+
+// varsToMap parses key=value pairs; later pairs win.
+func varsToMap(vars []string) map[string]string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		if key, value, ok := strings.Cut(v, "="); ok && key != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// checkFormulaInputs validates key=value vars against a formula's typed
+// [vars] and [inputs]. When stdin is a terminal and prompt is set, missing
+// required inputs are asked for; the answers are returned as key=value pairs
+// for the caller to append to its vars.
+func checkFormulaInputs(f *formula.Formula, vars []string, c formula.InputChecker, prompt bool) ([]string, error) {
+	values := varsToMap(vars)
+	var answers []string
+	if missing := c.Missing(f, values); len(missing) > 0 && prompt && term.IsTerminal(int(os.Stdin.Fd())) {
+		var err error
+		answers, err = promptFormulaInputs(os.Stdin, os.Stdout, f.Name, missing, c)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range varsToMap(answers) {
+			values[k] = v
+		}
+	}
+	if err := c.Check(f, values); err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+// checkSlingFormulaInputs validates gt sling --var values for a formula gt
+// can load. Formulas gt cannot find are left to bd mol wisp; any other load
+// failure (bad TOML, broken extends) is returned.
+func checkSlingFormulaInputs(formulaName, townRoot string, vars, supplied []string, prompt bool) ([]string, error) {
+	f, err := loadResolvedFormula(formulaName)
+	if errors.Is(err, errFormulaNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return checkFormulaInputs(f, vars, formulaInputChecker(townRoot, supplied), prompt)
+}
+
+// promptFormulaInputs asks for each missing input in turn, re-asking until
+// the answer is valid (at most three times per input).
+func promptFormulaInputs(in io.Reader, out io.Writer, formulaName string, missing []formula.Param, c formula.InputChecker) ([]string, error) {
+	reader := bufio.NewReader(in)
+	fmt.Fprintf(out, "Formula %s needs %d more input(s):\n", formulaName, len(missing))
+	var answers []string
+	for _, p := range missing {
+		label := fmt.Sprintf("  %s (%s", p.Name, p.Kind())
+		if len(p.Values) > 0 {
+			label += ": " + strings.Join(p.Values, "|")
+		}
+		label += ")"
+		if desc := strings.TrimSpace(p.Description); desc != "" {
+			label += " " + desc
+		}
+
+		const maxAttempts = 3
+		for attempt := 1; ; attempt++ {
+			fmt.Fprintf(out, "%s: ", label)
+			line, err := reader.ReadString('\n')
+			value := strings.TrimSpace(line)
+			if err != nil && value == "" {
+				return nil, fmt.Errorf("reading %s: %w", p.Name, err)
+			}
+			verr := c.CheckValue(p, value)
+			if value == "" {
+				verr = fmt.Errorf("%s: required", p.Name)
+			}
+			if verr == nil {
+				answers = append(answers, p.Name+"="+value)
+				break
+			}
+			if attempt == maxAttempts {
+				return nil, verr
+			}
+			fmt.Fprintf(out, "  %v\n", verr)
+		}
+	}
+	return answers, nil
+}
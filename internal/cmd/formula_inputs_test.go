@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestPromptFormulaInputs(t *testing.T) {
+	missing := []formula.Param{
+		{Name: "env", Type: formula.ParamEnum, Values: []string{"staging", "prod"}, Description: "Target environment"},
+		{Name: "retries", Type: formula.ParamInt},
+	}
+	var out bytes.Buffer
+	answers, err := promptFormulaInputs(strings.NewReader("dev\nprod\n\nthree\n2\n"), &out, "deploy", missing, formula.InputChecker{})
+	if err != nil {
+		t.Fatalf("promptFormulaInputs: %v", err)
+	}
+	if want := []string{"env=prod", "retries=2"}; !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers = %v, want %v", answers, want)
+	}
+	for _, want := range []string{
+		"env (enum: staging|prod) Target environment: ",
+		`env: "dev" is not one of staging, prod`,
+		"retries: required",
+		`retries: "three" is not an integer`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	_, err = promptFormulaInputs(strings.NewReader("x\ny\nz\n"), &out, "deploy", missing[1:], formula.InputChecker{})
+	if err == nil || !strings.Contains(err.Error(), "not an integer") {
+		t.Errorf("after three bad answers err = %v, want integer error", err)
+	}
+	if _, err := promptFormulaInputs(strings.NewReader(""), &out, "deploy", missing[:1], formula.InputChecker{}); err == nil {
+		t.Error("EOF should fail")
+	}
+}
+
+func TestCheckFormulaInputsNonInteractive(t *testing.T) {
+	f, err := formula.Parse([]byte(`formula = "deploy"
+[vars.env]
+type = "enum"
+values = ["staging", "prod"]
+required = true
+[vars.issue]
+required = true
+[[steps]]
+id = "ship"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := formula.InputChecker{Supplied: autoFormulaVars}
+	if _, err := checkFormulaInputs(f, []string{"env=prod"}, c, false); err != nil {
+		t.Errorf("valid vars: %v", err)
+	}
+	_, err = checkFormulaInputs(f, []string{"env=dev", "env=qa"}, c, false)
+	if err == nil || !strings.Contains(err.Error(), `env: "qa" is not one of`) {
+		t.Errorf("last --var should win and be rejected, got %v", err)
+	}
+	if _, err := checkFormulaInputs(f, nil, c, true); err == nil || !strings.Contains(err.Error(), "env: required") {
+		t.Errorf("missing input without a TTY should fail, got %v", err)
+	}
+}
+
+func TestCheckSlingFormulaInputsLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", dir)
+	formulasDir := filepath.Join(dir, ".beads", "formulas")
+	if err := os.MkdirAll(formulasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(formulasDir, "broken.formula.toml"), []byte("formula = [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown to gt: left to bd mol wisp.
+	if answers, err := checkSlingFormulaInputs("bd-only-formula", "", nil, nil, false); err != nil || answers != nil {
+		t.Errorf("missing formula = (%v, %v), want (nil, nil)", answers, err)
+	}
+	// Found but unparseable: must not be silently skipped.
+	if _, err := checkSlingFormulaInputs("broken", "", nil, nil, false); err == nil || !strings.Contains(err.Error(), "parsing formula") {
+		t.Errorf("broken formula error = %v, want parsing error", err)
+	}
+}
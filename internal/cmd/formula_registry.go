@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Formula registry flags
+var (
+	formulaRegistry       string
+	formulaSearchJSON     bool
+	formulaPublishVersion string
+)
+
+var formulaInstallCmd = &cobra.Command{
+	Use:   "install <name>[@version]...",
+	Short: "Install formulas from a registry",
+	Long: `Install formulas from a formula registry into the town's .beads/formulas/.
+
+Without a version the latest release is installed and gt formula update may
+move it forward. With a version (exact, or a major/minor prefix such as @4 or
+@4.1) the install is pinned: update leaves it alone.
+
+Installed packages live in .beads/formulas/.installed/ and are recorded with
+their checksums in .beads/formulas/.lock.json. Installed versions take
+precedence over embedded formulas of the same name.
+
+A registry is a directory or a static HTTP site serving index.json (see
+gt formula publish). Set it with --registry or GT_FORMULA_REGISTRY.
+
+Examples:
+  gt formula install code-review --registry ~/formula-registry
+  gt formula install code-review@1.2.0
+  gt formula install mol-deploy@2 release-notes`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runFormulaInstall,
+}
+
+var formulaUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Update installed formulas to their latest versions",
+	Long: `Update installed formulas to the latest version in the registry they were
+installed from. Pinned installs are skipped; reinstall with an explicit
+version (gt formula install name@version) to move a pin.
+
+With no names, every installed formula is checked.`,
+	RunE: runFormulaUpdate,
+}
+
+var formulaSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search a formula registry",
+	Long: `List formulas in a registry whose name or description contains the query.
+With no query, every formula is listed.
+
+Examples:
+  gt formula search review
+  gt formula search --registry https://formulas.example.com --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFormulaSearch,
+}
+
+var formulaPublishCmd = &cobra.Command{
+	Use:   "publish <name|path> --version <semver>",
+	Short: "Publish a formula to a directory registry",
+	Long: `Validate a formula and add it to a directory registry as a versioned,
+checksummed package, updating the registry's index.json.
+
+The registry directory can then be served as-is by any static HTTP server.
+Published versions are immutable: publishing an existing version fails.
+
+Examples:
+  gt formula publish code-review --version 1.2.0 --registry ~/formula-registry
+  gt formula publish ./deploy.formula.toml --version 2.0.0-rc.1`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaPublish,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{formulaInstallCmd, formulaUpdateCmd, formulaSearchCmd, formulaPublishCmd} {
+		c.Flags().StringVar(&formulaRegistry, "registry", "", "Registry directory or URL (default: $GT_FORMULA_REGISTRY)")
+		formulaCmd.AddCommand(c)
+	}
+	formulaSearchCmd.Flags().BoolVar(&formulaSearchJSON, "json", false, "Output as JSON")
+	formulaPublishCmd.Flags().StringVar(&formulaPublishVersion, "version", "", "Semantic version to publish (required)")
+	_ = formulaPublishCmd.MarkFlagRequired("version")
+}
+
+// registryLocation returns the --registry flag, falling back to
+// GT_FORMULA_REGISTRY and then to fallback (e.g. the registry recorded in
+// the lockfile).
+func registryLocation(fallback string) string {
+	if formulaRegistry != "" {
+		return formulaRegistry
+	}
+	if env := os.Getenv("GT_FORMULA_REGISTRY"); env != "" {
+		return env
+	}
+	return fallback
+}
+
+// formulaInstallDir is the town-level formulas directory that holds the lockfile.
+func formulaInstallDir() (string, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(townRoot, ".beads", "formulas"), nil
+}
+
+func runFormulaInstall(cmd *cobra.Command, args []string) error {
+	dir, err := formulaInstallDir()
+	if err != nil {
+		return err
+	}
+	reg, err := formula.OpenRegistry(registryLocation(""))
+	if err != nil {
+		return err
+	}
+	idx, err := reg.Index(cmd.Context())
+	if err != nil {
+		return err
+	}
+	for _, ref := range args {
+		name, constraint := formula.ParseFormulaRef(ref)
+		if err := installFormulaVersion(cmd.Context(), dir, reg, idx, name, constraint, constraint != ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installFormulaVersion resolves, downloads, verifies, and records one formula.
+func installFormulaVersion(ctx context.Context, dir string, reg formula.Registry, idx *formula.RegistryIndex, name, constraint string, pinned bool) error {
+	v, err := idx.Resolve(name, constraint)
+	if err != nil {
+		return err
+	}
+	content, err := reg.Fetch(ctx, v)
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", name, v.Version, err)
+	}
+	if _, err := formula.Parse(content); err != nil {
+		return fmt.Errorf("%s@%s: invalid formula: %w", name, v.Version, err)
+	}
+	if err := formula.InstallFormula(dir, name, v, content, reg.Location(), pinned, time.Now()); err != nil {
+		return err
+	}
+	pin := ""
+	if pinned {
+		pin = style.Dim.Render(" [pinned]")
+	}
+	fmt.Printf("%s Installed %s@%s%s\n", style.SuccessPrefix, name, v.Version, pin)
+	return nil
+}
+
+func runFormulaUpdate(cmd *cobra.Command, args []string) error {
+	dir, err := formulaInstallDir()
+	if err != nil {
+		return err
+	}
+	lock, err := formula.ReadLockfile(dir)
+	if err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		for name := range lock.Formulas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		fmt.Println("No formulas installed.")
+		return nil
+	}
+
+	indexes := map[string]*formula.RegistryIndex{}
+	for _, name := range names {
+		locked, ok := lock.Formulas[name]
+		if !ok {
+			return fmt.Errorf("formula %q is not installed", name)
+		}
+		if locked.Pinned {
+			fmt.Printf("  %s %s@%s is pinned\n", style.Dim.Render("○"), name, locked.Version)
+			continue
+		}
+		reg, err := formula.OpenRegistry(registryLocation(locked.Registry))
+		if err != nil {
+			return err
+		}
+		idx, ok := indexes[reg.Location()]
+		if !ok {
+			if idx, err = reg.Index(cmd.Context()); err != nil {
+				return err
+			}
+			indexes[reg.Location()] = idx
+		}
+		latest, err := idx.Resolve(name, "")
+		if err != nil {
+			return err
+		}
+		if formula.CompareVersions(latest.Version, locked.Version) <= 0 {
+			fmt.Printf("  %s %s@%s is up to date\n", style.Dim.Render("○"), name, locked.Version)
+			continue
+		}
+		if err := installFormulaVersion(cmd.Context(), dir, reg, idx, name, latest.Version, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runFormulaSearch(cmd *cobra.Command, args []string) error {
+	reg, err := formula.OpenRegistry(registryLocation(""))
+	if err != nil {
+		return err
+	}
+	idx, err := reg.Index(cmd.Context())
+	if err != nil {
+		return err
+	}
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+	results := idx.Search(query)
+	if formulaSearchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No formulas matching %q in %s\n", query, reg.Location())
+		return nil
+	}
+
+	installed := map[string]string{}
+	if dir, err := formulaInstallDir(); err == nil {
+		if lock, err := formula.ReadLockfile(dir); err == nil {
+			for name, l := range lock.Formulas {
+				installed[name] = l.Version
+			}
+		}
+	}
+	for _, r := range results {
+		line := fmt.Sprintf("  %s %s", style.Bold.Render(r.Name), r.Latest)
+		if v, ok := installed[r.Name]; ok {
+			line += style.Dim.Render(" [installed " + v + "]")
+		}
+		fmt.Println(line)
+		if r.Description != "" {
+			fmt.Printf("    %s\n", style.Dim.Render(r.Description))
+		}
+	}
+	return nil
+}
+
+func runFormulaPublish(cmd *cobra.Command, args []string) error {
+	location := registryLocation("")
+	if location == "" {
+		return fmt.Errorf("no formula registry configured (use --registry or set GT_FORMULA_REGISTRY)")
+	}
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return fmt.Errorf("cannot publish to %s: HTTP registries are read-only; publish to the directory it serves", location)
+	}
+
+	path := args[0]
+	if _, err := os.Stat(path); err != nil {
+		if path, err = findFormulaFile(args[0]); err != nil {
+			return err
+		}
+	}
+	content, err := os.ReadFile(path) //nolint:gosec // G304: user-specified formula file
+	if err != nil {
+		return fmt.Errorf("reading formula: %w", err)
+	}
+	f, err := formula.Parse(content)
+	if err != nil {
+		return fmt.Errorf("invalid formula %s: %w", path, err)
+	}
+
+	root := strings.TrimPrefix(location, "file://")
+	v, err := formula.PublishToDir(root, f.Name, formulaPublishVersion, content, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Published %s@%s %s\n", style.SuccessPrefix, f.Name, v.Version, style.Dim.Render("sha256:"+v.SHA256))
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// resolvedStep is the JSON shape of a step in `gt formula show --resolved --json`.
+type resolvedStep struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title,omitempty"`
+	Needs []string `json:"needs,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// loadResolvedFormula finds a formula on disk (falling back to the embedded
+// set), then applies extends, compose.expand, and compose.aspects.
+func loadResolvedFormula(name string) (*formula.Formula, error) {
+	var f *formula.Formula
+	if path, err := findFormulaFile(name); err == nil {
+		if f, err = parseFormulaFile(path); err != nil {
+			return nil, fmt.Errorf("parsing formula: %w", err)
+		}
+	} else if !errors.Is(err, errFormulaNotFound) {
+		return nil, fmt.Errorf("finding formula: %w", err)
+	} else {
+		data, embErr := formula.GetEmbeddedFormulaContent(name)
+		if embErr != nil {
+			return nil, fmt.Errorf("finding formula: %w", err)
+		}
+		if f, err = formula.Parse(data); err != nil {
+			return nil, fmt.Errorf("parsing formula: %w", err)
+		}
+	}
+	resolved, err := formula.Resolve(f, formulaSearchPaths())
+	if err != nil {
+		return nil, fmt.Errorf("resolving formula: %w", err)
+	}
+	return resolved, nil
+}
+
+func showResolvedFormula(name string) error {
+	f, err := loadResolvedFormula(name)
+	if err != nil {
+		return err
+	}
+
+	steps := make([]resolvedStep, 0, len(f.Steps))
+	for _, s := range f.Steps {
+		steps = append(steps, resolvedStep{ID: s.ID, Title: s.Title, Needs: s.Needs, Tags: s.Tags})
+	}
+
+	if formulaShowJSON {
+		out := struct {
+			Name        string         `json:"name"`
+			Type        string         `json:"type"`
+			Description string         `json:"description,omitempty"`
+			Steps       []resolvedStep `json:"steps"`
+		}{f.Name, string(f.Type), f.Description, steps}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Printf("%s %s\n", style.Bold.Render(f.Name), style.Dim.Render("("+string(f.Type)+", resolved)"))
+	if f.Description != "" {
+		fmt.Printf("%s\n", strings.TrimSpace(f.Description))
+	}
+	if len(steps) == 0 {
+		fmt.Println("\nNo steps.")
+		return nil
+	}
+	fmt.Printf("\nSteps (%d):\n", len(steps))
+	for _, s := range steps {
+		line := fmt.Sprintf("  %-32s %s", s.ID, s.Title)
+		if len(s.Needs) > 0 {
+			line += style.Dim.Render("  ← " + strings.Join(s.Needs, ", "))
+		}
+		if len(s.Tags) > 0 {
+			line += style.Dim.Render("  [" + strings.Join(s.Tags, ", ") + "]")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	formulaTestRig     string
+	formulaTestVerbose bool
+)
+
+var formulaTestCmd = &cobra.Command{
+	Use:   "test <scenario.toml>...",
+	Short: "Run a formula against scripted scenarios",
+	Long: `Simulate a formula run against a scenario file and check the result,
+without agents, beads, or Dolt.
+
+The formula is resolved (extends, compose.expand, aspects) and overlaid
+exactly as for a real run. Steps are then dispatched in waves using the same
+scheduling as gt: skipped when their when condition is false, grouped by
+parallel, and repeated while repeat_until is false. Each [[events]] entry
+finishes one running step, optionally failing it or recording outputs.
+
+Scenario format:
+
+  formula = "mol-polecat-work"        # name, or path to a .formula.toml
+  overlay = "overlay.toml"            # optional; default: town/rig overlay
+
+  [vars]
+  issue = "gt-abc12"
+
+  [[events]]
+  step = "implement"
+  outputs = { pr_url = "https://example.com/pull/7" }
+
+  [[events]]
+  step = "review"
+  result = "fail"
+
+  [expect]
+  steps = ["implement", "review"]     # after expansion and overlays
+  order = ["implement", "review"]     # dispatch order
+  waves = [["implement"], ["review"]] # parallel groups
+  skipped = []
+  failed = ["review"]
+  status = "failed"                   # completed (default), failed, stalled
+  [expect.descriptions]
+  review = "https://example.com/pull/7"
+
+Rendered titles and descriptions must not contain unresolved {{...}}
+placeholders unless expect.allow_unresolved is set.
+
+Examples:
+  gt formula test formulas/tests/*.scenario.toml
+  gt formula test review.scenario.toml --rig gastown -v`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runFormulaTest,
+}
+
+func init() {
+	formulaTestCmd.Flags().StringVar(&formulaTestRig, "rig", "", "Apply this rig's formula overlay when the scenario names none")
+	formulaTestCmd.Flags().BoolVarP(&formulaTestVerbose, "verbose", "v", false, "Print the simulated waves")
+	formulaCmd.AddCommand(formulaTestCmd)
+}
+
+func runFormulaTest(cmd *cobra.Command, args []string) error {
+	failed := 0
+	for _, path := range args {
+		failures, err := runFormulaScenario(path)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("%s %s\n    %v\n", style.Bold.Render("FAIL"), path, err)
+		case len(failures) > 0:
+			failed++
+			fmt.Printf("%s %s\n", style.Bold.Render("FAIL"), path)
+			for _, f := range failures {
+				fmt.Printf("    %s\n", f)
+			}
+		default:
+			fmt.Printf("%s %s\n", style.SuccessPrefix, path)
+		}
+	}
+	if failed > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+// runFormulaScenario loads, simulates, and checks one scenario file.
+func runFormulaScenario(path string) ([]string, error) {
+	s, err := formula.ParseScenarioFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := loadScenarioFormula(s)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := s.LoadOverlay()
+	if err != nil {
+		return nil, err
+	}
+	if overlay == nil {
+		if townRoot, err := workspace.FindFromCwd(); err == nil && townRoot != "" {
+			if overlay, err = formula.LoadFormulaOverlay(f.Name, townRoot, formulaTestRig); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, w := range formula.ApplyOverlays(f, overlay) {
+		fmt.Printf("  %s %s\n", style.WarningPrefix, w)
+	}
+
+	sim, err := formula.Simulate(f, s.Vars, s.Events)
+	if err != nil {
+		return nil, err
+	}
+	if formulaTestVerbose {
+		for i, wave := range sim.Waves {
+			fmt.Printf("  wave %d: %s\n", i+1, strings.Join(wave, ", "))
+		}
+		if len(sim.Skipped) > 0 {
+			fmt.Printf("  skipped: %s\n", strings.Join(sim.Skipped, ", "))
+		}
+		fmt.Printf("  status: %s\n", sim.Status)
+	}
+	return s.Check(sim), nil
+}
+
+// loadScenarioFormula loads the scenario's formula by path or by name and
+// resolves its composition.
+func loadScenarioFormula(s *formula.Scenario) (*formula.Formula, error) {
+	file := s.FormulaFile()
+	if file == "" {
+		return loadResolvedFormula(s.Formula)
+	}
+	f, err := formula.ParseFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing formula: %w", err)
+	}
+	resolved, err := formula.Resolve(f, formulaSearchPaths())
+	if err != nil {
+		return nil, fmt.Errorf("resolving formula: %w", err)
+	}
+	return resolved, nil
+}
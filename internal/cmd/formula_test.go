@@ -493,3 +493,35 @@ func TestFormulaRunExamplesUseSetVars(t *testing.T) {
 		t.Fatal("design usage examples do not mention --set problem=")
 	}
 }
+
+func TestLoadResolvedFormulaWeavesProjectAspect(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	formulasDir := filepath.Join(dir, ".beads", "formulas")
+	if err := os.MkdirAll(formulasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"app": "formula = \"app\"\nextends = [\"shiny\"]\n[compose]\naspects = [\"review-gate\"]\n",
+		"review-gate": "formula = \"review-gate\"\ntype = \"aspect\"\n[[advice]]\ntarget = \"submit\"\n" +
+			"[advice.before]\nid = \"rule-of-five\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(formulasDir, name+".formula.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := loadResolvedFormula("app")
+	if err != nil {
+		t.Fatalf("loadResolvedFormula: %v", err)
+	}
+	var ids []string
+	for _, s := range f.Steps {
+		ids = append(ids, s.ID)
+	}
+	want := []string{"design", "implement", "review", "test", "rule-of-five", "submit"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("steps = %v, want %v", ids, want)
+	}
+}
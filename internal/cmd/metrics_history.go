@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/trends"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	metricsHistorySince  string
+	metricsHistoryStep   string
+	metricsHistorySeries []string
+	metricsHistoryJSON   bool
+)
+
+// historySparkWidth is the number of cells in a gt metrics history trend column.
+const historySparkWidth = 24
+
+func init() {
+	metricsHistoryCmd.Flags().StringVar(&metricsHistorySince, "since", "24h", "How far back to look (e.g. 6h, 7d, 30d)")
+	metricsHistoryCmd.Flags().StringVar(&metricsHistoryStep, "step", "", "Bucket size (default: chosen from --since)")
+	metricsHistoryCmd.Flags().StringSliceVar(&metricsHistorySeries, "series", nil, "Only show these series (repeatable or comma-separated)")
+	metricsHistoryCmd.Flags().BoolVar(&metricsHistoryJSON, "json", false, "Output bucketed points as JSON")
+
+	metricsCmd.AddCommand(metricsHistoryCmd)
+}
+
+var metricsHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show town gauge trends recorded by the daemon",
+	Long: `Show the history of town gauges the daemon samples each heartbeat:
+active polecats and agent sessions, scheduler and merge queue depth, the
+oldest merge request's wait, Dolt latency and connections, today's costs,
+and open escalations.
+
+History lives in <town>/.runtime/metrics. Recent samples are kept at full
+resolution, older ones as hourly and then daily min/max/avg buckets.
+
+Examples:
+  gt metrics history                  # last 24 hours
+  gt metrics history --since 7d
+  gt metrics history --since 30d --series mq.depth,dolt.latency_ms
+  gt metrics history --since 6h --step 15m --json`,
+	Args: cobra.NoArgs,
+	RunE: runMetricsHistory,
+}
+
+func runMetricsHistory(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	span, err := parseDuration(metricsHistorySince)
+	if err != nil || span <= 0 {
+		return fmt.Errorf("invalid --since %q: use a span like 6h or 7d", metricsHistorySince)
+	}
+	step := trends.AutoStep(span)
+	if metricsHistoryStep != "" {
+		step, err = parseDuration(metricsHistoryStep)
+		if err != nil || step <= 0 {
+			return fmt.Errorf("invalid --step %q: use a span like 15m or 1h", metricsHistoryStep)
+		}
+	}
+
+	to := time.Now()
+	series, err := trends.Open(townRoot).Query(to.Add(-span), to, step, metricsHistorySeries...)
+	if err != nil {
+		return fmt.Errorf("reading metrics history: %w", err)
+	}
+	sortHistorySeries(series)
+
+	if metricsHistoryJSON {
+		if series == nil {
+			series = []trends.Series{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(series)
+	}
+
+	if len(series) == 0 {
+		fmt.Println(style.Dim.Render("No metrics history yet (the daemon records a sample every heartbeat)"))
+		return nil
+	}
+
+	fmt.Printf("%s %s\n\n", style.Bold.Render("Metrics history"),
+		style.Dim.Render(fmt.Sprintf("(last %s, %s buckets)", metricsHistorySince, formatHistoryStep(step))))
+	fmt.Printf("  %-22s %9s %9s %9s %9s  %s\n", "SERIES", "LATEST", "MIN", "MAX", "AVG", "TREND")
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		lo, hi := math.Inf(1), math.Inf(-1)
+		var sum float64
+		var n int
+		avgs := make([]float64, len(s.Points))
+		for i, p := range s.Points {
+			lo = math.Min(lo, p.Min)
+			hi = math.Max(hi, p.Max)
+			sum += p.Avg * float64(p.N)
+			n += p.N
+			avgs[i] = p.Avg
+		}
+		fmt.Printf("  %-22s %9s %9s %9s %9s  %s\n", s.Name,
+			formatHistoryValue(s.Points[len(s.Points)-1].Avg),
+			formatHistoryValue(lo), formatHistoryValue(hi),
+			formatHistoryValue(sum/float64(n)),
+			sparkline(avgs, historySparkWidth))
+	}
+	return nil
+}
+
+// sortHistorySeries orders series as trends.Names lists them, with any
+// unknown series after, by name.
+func sortHistorySeries(series []trends.Series) {
+	rank := func(name string) int {
+		if i := slices.Index(trends.Names, name); i >= 0 {
+			return i
+		}
+		return len(trends.Names)
+	}
+	slices.SortStableFunc(series, func(a, b trends.Series) int {
+		if d := rank(a.Name) - rank(b.Name); d != 0 {
+			return d
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// sparkline renders values as block characters, averaging adjacent values
+// down to at most width cells.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		cells := make([]float64, width)
+		for i := range cells {
+			lo, hi := i*len(values)/width, (i+1)*len(values)/width
+			var sum float64
+			for _, v := range values[lo:hi] {
+				sum += v
+			}
+			cells[i] = sum / float64(hi-lo)
+		}
+		values = cells
+	}
+
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	lo, hi := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		b.WriteRune(blocks[i])
+	}
+	return b.String()
+}
+
+func formatHistoryValue(v float64) string {
+	if math.Abs(v-math.Round(v)) < 0.005 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+func formatHistoryStep(step time.Duration) string {
+	if step >= 24*time.Hour && step%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", step/(24*time.Hour))
+	}
+	s := step.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/trends"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 7, 3.5, 7}, 10); got != "▁█▄█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]float64{2, 2, 2}, 10); got != "▁▁▁" {
+		t.Errorf("flat sparkline = %q", got)
+	}
+	if got := []rune(sparkline(make([]float64, 100), 24)); len(got) != 24 {
+		t.Errorf("downsampled sparkline has %d cells, want 24", len(got))
+	}
+}
+
+func TestFormatHistoryStep(t *testing.T) {
+	for step, want := range map[time.Duration]string{
+		5 * time.Minute:    "5m",
+		10 * time.Minute:   "10m",
+		time.Hour:          "1h",
+		90 * time.Minute:   "1h30m",
+		24 * time.Hour:     "1d",
+		7 * 24 * time.Hour: "7d",
+		30 * time.Second:   "30s",
+		6*time.Hour + 1e9:  "6h0m1s",
+	} {
+		if got := formatHistoryStep(step); got != want {
+			t.Errorf("formatHistoryStep(%v) = %q, want %q", step, got, want)
+		}
+	}
+}
+
+func TestSortHistorySeries(t *testing.T) {
+	series := []trends.Series{{Name: "zz.custom"}, {Name: trends.OpenEscalations}, {Name: trends.ActivePolecats}, {Name: "aa.custom"}}
+	sortHistorySeries(series)
+	want := []string{trends.ActivePolecats, trends.OpenEscalations, "aa.custom", "zz.custom"}
+	for i, s := range series {
+		if s.Name != want[i] {
+			t.Fatalf("order = %v, want %v", series, want)
+		}
+	}
+}
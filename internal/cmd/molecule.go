@@ -223,6 +223,9 @@ When a polecat is working on a molecule, it processes one step at a time:
 2. When done: gt mol step done <step-id>
 3. System auto-continues to next ready step
 
+Workflow steps that declare outputs record them with
+'gt mol step output set <name> <value>' before finishing.
+
 IMPORTANT: Always use 'gt mol step done' to complete steps. Do not manually
 close steps with 'bd close' - that skips the auto-continuation logic.`,
 }
@@ -252,6 +255,7 @@ func init() {
 
 	// Add step subcommand with its children
 	moleculeStepCmd.AddCommand(moleculeStepDoneCmd)
+	moleculeStepCmd.AddCommand(moleculeStepOutputCmd)
 	moleculeCmd.AddCommand(moleculeStepCmd)
 
 	// Add subcommands (agent-specific operations only)
@@ -22,6 +22,12 @@ type DAGNode struct {
 	Dependents   []string   `json:"dependents,omitempty"`
 	Tier         int        `json:"tier"` // Execution tier (0 = root, higher = later)
 	Children     []*DAGNode `json:"children,omitempty"`
+
+	// Conditional and looping workflow steps (formula when / repeat_until).
+	When          string `json:"when,omitempty"`
+	RepeatUntil   string `json:"repeat_until,omitempty"`
+	Iteration     int    `json:"iteration,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty"`
 }
 
 // DAGInfo contains the full DAG information for a molecule.
@@ -45,6 +51,10 @@ Shows the dependency structure with execution tiers and status:
   ⧖ in_progress - Step being worked on
   ○ ready       - Step ready to execute (all deps met)
   ◌ blocked     - Step waiting on dependencies
+  ⊘ skipped     - Step's when condition was false
+
+Conditional steps are marked "?" and repeat_until loops show their
+iteration ("↻ 2/3").
 
 Examples:
   gt mol dag gs-wisp-abc     # Show DAG for molecule
@@ -170,6 +180,16 @@ func buildDAG(b *beads.Beads, root *beads.Issue, children []*beads.Issue) (*DAGI
 			node.Parallel = true
 		}
 
+		if fields := beads.ParseStepFields(step); fields != nil {
+			node.When = fields.When
+			node.RepeatUntil = fields.RepeatUntil
+			node.Iteration = fields.Iteration
+			node.MaxIterations = fields.MaxIterations
+			if child.Status == "closed" && fields.Skipped != "" {
+				node.Status = "skipped"
+			}
+		}
+
 		// Compute ready status for open steps
 		if child.Status == "open" {
 			allDepsClosed := true
@@ -329,8 +349,8 @@ func outputDAGTree(dag *DAGInfo) error {
 
 	// Legend
 	fmt.Println()
-	fmt.Printf("   %s done  %s in_progress  %s ready  %s blocked\n",
-		style.Bold.Render("✓"), style.Bold.Render("⧖"), style.Bold.Render("○"), style.Dim.Render("◌"))
+	fmt.Printf("   %s done  %s in_progress  %s ready  %s blocked  %s skipped\n",
+		style.Bold.Render("✓"), style.Bold.Render("⧖"), style.Bold.Render("○"), style.Dim.Render("◌"), style.Dim.Render("⊘"))
 
 	return nil
 }
@@ -353,19 +373,6 @@ func printNode(dag *DAGInfo, id, prefix string, isLast bool, visited map[string]
 		connector = "└─"
 	}
 
-	// Status icon
-	var icon string
-	switch node.Status {
-	case "closed":
-		icon = style.Bold.Render("✓")
-	case "in_progress":
-		icon = style.Bold.Render("⧖")
-	case "ready":
-		icon = style.Bold.Render("○")
-	default:
-		icon = style.Dim.Render("◌")
-	}
-
 	// Parallel marker
 	parallelMark := ""
 	if node.Parallel {
@@ -373,7 +380,7 @@ func printNode(dag *DAGInfo, id, prefix string, isLast bool, visited map[string]
 	}
 
 	// Print node
-	fmt.Printf("%s%s %s %s%s\n", prefix, connector, icon, node.ID, parallelMark)
+	fmt.Printf("%s%s %s %s%s%s\n", prefix, connector, dagStatusIcon(node), node.ID, parallelMark, dagConditionMarks(node))
 
 	// Child prefix
 	childPrefix := prefix
@@ -412,19 +419,6 @@ func outputDAGTiers(dag *DAGInfo) error {
 				continue
 			}
 
-			// Status icon
-			var icon string
-			switch node.Status {
-			case "closed":
-				icon = style.Bold.Render("✓")
-			case "in_progress":
-				icon = style.Bold.Render("⧖")
-			case "ready":
-				icon = style.Bold.Render("○")
-			default:
-				icon = style.Dim.Render("◌")
-			}
-
 			// Parallel marker
 			parallelMark := ""
 			if node.Parallel {
@@ -437,7 +431,7 @@ func outputDAGTiers(dag *DAGInfo) error {
 				depStr = fmt.Sprintf(" ← %s", strings.Join(node.Dependencies, ", "))
 			}
 
-			fmt.Printf("       %s %s%s%s\n", icon, id, parallelMark, depStr)
+			fmt.Printf("       %s %s%s%s%s\n", dagStatusIcon(node), id, parallelMark, dagConditionMarks(node), depStr)
 		}
 		fmt.Println()
 	}
@@ -449,8 +443,36 @@ func outputDAGTiers(dag *DAGInfo) error {
 
 	// Legend
 	fmt.Println()
-	fmt.Printf("   %s done  %s in_progress  %s ready  %s blocked\n",
-		style.Bold.Render("✓"), style.Bold.Render("⧖"), style.Bold.Render("○"), style.Dim.Render("◌"))
+	fmt.Printf("   %s done  %s in_progress  %s ready  %s blocked  %s skipped\n",
+		style.Bold.Render("✓"), style.Bold.Render("⧖"), style.Bold.Render("○"), style.Dim.Render("◌"), style.Dim.Render("⊘"))
 
 	return nil
 }
+
+// dagStatusIcon returns the display icon for a node's status.
+func dagStatusIcon(node *DAGNode) string {
+	switch node.Status {
+	case "closed":
+		return style.Bold.Render("✓")
+	case "in_progress":
+		return style.Bold.Render("⧖")
+	case "ready":
+		return style.Bold.Render("○")
+	case "skipped":
+		return style.Dim.Render("⊘")
+	default:
+		return style.Dim.Render("◌")
+	}
+}
+
+// dagConditionMarks returns markers for conditional and looping steps.
+func dagConditionMarks(node *DAGNode) string {
+	var marks string
+	if node.When != "" && node.Status != "skipped" {
+		marks += style.Dim.Render(" ?")
+	}
+	if node.RepeatUntil != "" {
+		marks += fmt.Sprintf(" ↻ %d/%d", node.Iteration, node.MaxIterations)
+	}
+	return marks
+}
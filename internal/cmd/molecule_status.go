@@ -116,6 +116,95 @@ type MoleculeProgressInfo struct {
 	BlockedSteps []string `json:"blocked_steps"`
 	Percent      int      `json:"percent_complete"`
 	Complete     bool     `json:"complete"`
+
+	// Conditional and looping workflow steps (formula when / repeat_until).
+	SkippedSteps []string       `json:"skipped_steps,omitempty"` // Closed because their when condition was false (counted in DoneSteps)
+	Loops        []StepLoopInfo `json:"loops,omitempty"`         // Open repeat_until steps and their current iteration
+
+	// StepAgents lists the agent and model each dispatched step ran on.
+	StepAgents []StepAgentInfo `json:"step_agents,omitempty"`
+
+	// StepFailures lists steps that failed or timed out and how their
+	// failure policy handled them (formula retries / on_failure).
+	StepFailures []StepFailureInfo `json:"step_failures,omitempty"`
+}
+
+// StepFailureInfo describes the last failure of a workflow step.
+type StepFailureInfo struct {
+	ID      string `json:"id"`
+	Step    string `json:"step,omitempty"`
+	Failure string `json:"failure"`
+	Attempt int    `json:"attempt,omitempty"`
+	RetryAt string `json:"retry_at,omitempty"`
+}
+
+// StepAgentInfo records which agent preset and model ran a workflow step.
+type StepAgentInfo struct {
+	ID    string `json:"id"`
+	Step  string `json:"step,omitempty"`
+	Agent string `json:"agent"`
+	Model string `json:"model,omitempty"`
+}
+
+// StepLoopInfo describes an in-flight repeat_until step.
+type StepLoopInfo struct {
+	ID            string `json:"id"`
+	Iteration     int    `json:"iteration"`
+	MaxIterations int    `json:"max_iterations"`
+}
+
+// noteStepConditions records skip and loop state from a step's workflow fields.
+func (p *MoleculeProgressInfo) noteStepConditions(step *beads.Issue) {
+	fields := beads.ParseStepFields(step)
+	if fields == nil {
+		return
+	}
+	if step.Status == "closed" && fields.Skipped != "" {
+		p.SkippedSteps = append(p.SkippedSteps, step.ID)
+	}
+	if step.Status != "closed" && fields.RepeatUntil != "" {
+		p.Loops = append(p.Loops, StepLoopInfo{ID: step.ID, Iteration: fields.Iteration, MaxIterations: fields.MaxIterations})
+	}
+	if fields.RanAgent != "" {
+		p.StepAgents = append(p.StepAgents, StepAgentInfo{ID: step.ID, Step: fields.Step, Agent: fields.RanAgent, Model: fields.RanModel})
+	}
+	if fields.Failure != "" {
+		p.StepFailures = append(p.StepFailures, StepFailureInfo{ID: step.ID, Step: fields.Step, Failure: fields.Failure, Attempt: fields.Attempt, RetryAt: fields.RetryAt})
+	}
+}
+
+// printStepConditions prints skipped and looping steps, if any.
+func printStepConditions(p *MoleculeProgressInfo) {
+	if len(p.SkippedSteps) > 0 {
+		fmt.Printf("  Skipped:     %d (%s)\n", len(p.SkippedSteps), strings.Join(p.SkippedSteps, ", "))
+	}
+	for _, loop := range p.Loops {
+		fmt.Printf("  Looping:     %s (iteration %d/%d)\n", loop.ID, loop.Iteration, loop.MaxIterations)
+	}
+	if len(p.StepAgents) > 0 {
+		fmt.Printf("  Models:\n")
+		for _, a := range p.StepAgents {
+			name := a.ID
+			if a.Step != "" {
+				name = a.Step + " " + style.Dim.Render("("+a.ID+")")
+			}
+			ran := a.Agent
+			if a.Model != "" {
+				ran += "/" + a.Model
+			}
+			fmt.Printf("    %s: %s\n", name, ran)
+		}
+	}
+	if len(p.StepFailures) > 0 {
+		fmt.Printf("  Failures:\n")
+		for _, f := range p.StepFailures {
+			name := f.ID
+			if f.Step != "" {
+				name = f.Step + " " + style.Dim.Render("("+f.ID+")")
+			}
+			fmt.Printf("    %s %s: %s\n", style.Warning.Render("✗"), name, f.Failure)
+		}
+	}
 }
 
 // MoleculeStatusInfo contains status information for an agent's work.
@@ -218,6 +307,7 @@ func runMoleculeProgress(cmd *cobra.Command, args []string) error {
 	// Categorize steps
 	for _, child := range children {
 		progress.TotalSteps++
+		progress.noteStepConditions(child)
 
 		switch child.Status {
 		case "closed":
@@ -295,6 +385,7 @@ func runMoleculeProgress(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 	fmt.Printf("  Blocked:     %d\n", len(progress.BlockedSteps))
+	printStepConditions(&progress)
 
 	if progress.Complete {
 		fmt.Printf("\n  %s\n", style.Bold.Render("✓ Molecule complete!"))
@@ -606,6 +697,7 @@ func getMoleculeProgressInfo(b *beads.Beads, moleculeRootID string) (*MoleculePr
 	// Categorize steps
 	for _, child := range children {
 		progress.TotalSteps++
+		progress.noteStepConditions(child)
 
 		switch child.Status {
 		case "closed":
@@ -773,6 +865,7 @@ func outputMoleculeStatus(status MoleculeStatusInfo) {
 		}
 		fmt.Println()
 		fmt.Printf("  Blocked:     %d\n", len(status.Progress.BlockedSteps))
+		printStepConditions(status.Progress)
 
 		if status.Progress.Complete {
 			fmt.Printf("\n%s\n", style.Bold.Render("✓ Molecule complete!"))
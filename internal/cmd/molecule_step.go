@@ -23,9 +23,11 @@ var moleculeStepDoneCmd = &cobra.Command{
 
 This command handles the step-to-step transition for polecats:
 
-1. Closes the completed step (bd close <step-id>)
+1. Closes the completed step (bd close <step-id>), or reopens it when it is a
+   repeat_until loop whose condition is still false and under its bound
 2. Extracts the molecule ID from the step
-3. Finds the next ready step (dependency-aware)
+3. Finds the next ready step (dependency-aware), closing steps whose when
+   condition is false as skipped
 4. If next step exists:
    - Updates the hook to point to the next step
    - Respawns the pane for a fresh session
@@ -61,7 +63,7 @@ type StepDoneResult struct {
 	NextStepTitle string   `json:"next_step_title,omitempty"`
 	ParallelSteps []string `json:"parallel_steps,omitempty"` // Multiple ready steps for fan-out
 	Complete      bool     `json:"complete"`
-	Action        string   `json:"action"` // "continue", "parallel", "done", "no_more_ready"
+	Action        string   `json:"action"` // "continue", "repeat", "parallel", "done", "no_more_ready"
 }
 
 func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
@@ -112,10 +114,22 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		MoleculeID: moleculeID,
 	}
 
-	// Step 3: Close the step
+	// Step 3: Close the step (or reopen it for another repeat_until iteration)
 	if moleculeStepDryRun {
 		fmt.Printf("[dry-run] Would close step: %s\n", stepID)
 		result.StepClosed = true
+	} else if repeated, err := repeatWorkflowStep(b, step); err != nil {
+		return err
+	} else if repeated {
+		result.NextStepID = step.ID
+		result.NextStepTitle = step.Title
+		result.Action = "repeat"
+		if moleculeJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		return handleStepContinue(cwd, townRoot, step, false)
 	} else {
 		if err := b.Close(stepID); err != nil {
 			return fmt.Errorf("closing step: %w", err)
@@ -124,11 +138,30 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s Closed step %s: %s\n", style.Bold.Render("✓"), stepID, step.Title)
 	}
 
-	// Step 4: Find all ready steps (supports fan-out pattern)
+	// Step 4: Find all ready steps (supports fan-out pattern). Ready steps
+	// whose when condition is false are skipped, which may ready others.
 	readySteps, allComplete, err := findAllReadySteps(b, moleculeID)
 	if err != nil {
 		return fmt.Errorf("finding next steps: %w", err)
 	}
+	for !moleculeStepDryRun && !allComplete {
+		var kept []*beads.Issue
+		for _, s := range readySteps {
+			skipped, err := skipWorkflowStepIfFalse(b, s)
+			if err != nil {
+				return err
+			}
+			if !skipped {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == len(readySteps) {
+			break
+		}
+		if readySteps, allComplete, err = findAllReadySteps(b, moleculeID); err != nil {
+			return fmt.Errorf("finding next steps: %w", err)
+		}
+	}
 
 	if allComplete {
 		result.Complete = true
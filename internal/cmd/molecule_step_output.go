@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var moleculeStepOutputCmd = &cobra.Command{
+	Use:   "output",
+	Short: "Record named outputs of a workflow step",
+	RunE:  requireSubcommand,
+	Long: `Commands for workflow step outputs.
+
+Formula steps can declare named outputs:
+
+  [[steps]]
+  id = "open-pr"
+  [steps.outputs]
+  pr_url = "string"
+  report = { type = "json", description = "Test summary" }
+
+The agent working the step records each value with 'gt mol step output set'.
+Later steps reference it as {{steps.open-pr.outputs.pr_url}} in their
+description (filled in when the step is dispatched), or as
+steps.open-pr.outputs.pr_url in when/repeat_until conditions.`,
+}
+
+var moleculeStepOutputSetCmd = &cobra.Command{
+	Use:   "set <name> <value>",
+	Short: "Record an output of the current workflow step",
+	Long: `Record a declared output on a workflow step bead.
+
+The value is checked against the declared type:
+  string   Any text
+  json     Must parse as JSON; stored compacted
+  path     A file path; relative paths are made absolute
+
+Use "-" as the value to read it from stdin. The step defaults to the bead on
+your hook; use --step to name another.
+
+Examples:
+  gt mol step output set pr_url https://github.com/org/repo/pull/42
+  go test -json ./... | jq -s '{failed: map(select(.Action=="fail"))|length}' | gt mol step output set report -
+  gt mol step output set verdict approve --step gt-wfs-abc12`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMoleculeStepOutputSet,
+}
+
+var moleculeStepOutputStep string
+
+func init() {
+	moleculeStepOutputSetCmd.Flags().StringVar(&moleculeStepOutputStep, "step", "", "Workflow step bead (default: the bead on your hook)")
+	moleculeStepOutputCmd.AddCommand(moleculeStepOutputSetCmd)
+}
+
+func runMoleculeStepOutputSet(cmd *cobra.Command, args []string) error {
+	name, value := args[0], args[1]
+	if value == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading value from stdin: %w", err)
+		}
+		value = strings.TrimRight(string(data), "\n")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting current directory: %w", err)
+	}
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil {
+		return fmt.Errorf("finding workspace: %w", err)
+	}
+	if townRoot == "" {
+		return fmt.Errorf("not in a Gas Town workspace")
+	}
+
+	b := beads.New(townRoot)
+	stepID := moleculeStepOutputStep
+	if stepID == "" {
+		if stepID, err = hookedStepForOutput(cwd, townRoot); err != nil {
+			return err
+		}
+	}
+
+	issue, err := b.Show(stepID)
+	if err != nil {
+		return fmt.Errorf("step not found: %w", err)
+	}
+	fields, err := recordStepOutput(issue, name, value)
+	if err != nil {
+		return err
+	}
+	desc := beads.SetStepFields(issue, fields)
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return fmt.Errorf("recording output on %s: %w", issue.ID, err)
+	}
+
+	fmt.Printf("%s Recorded %s.outputs.%s on %s\n", style.Bold.Render("✓"), fields.Step, name, issue.ID)
+	return nil
+}
+
+// hookedStepForOutput returns the bead on the current agent's hook.
+func hookedStepForOutput(cwd, townRoot string) (string, error) {
+	roleInfo, err := GetRoleWithContext(cwd, townRoot)
+	if err != nil {
+		return "", fmt.Errorf("detecting role (use --step): %w", err)
+	}
+	beadsPath := townRoot
+	if roleInfo.Rig != "" && roleInfo.Role != RoleMayor && roleInfo.Role != RoleDeacon {
+		beadsPath = filepath.Join(townRoot, roleInfo.Rig)
+	}
+	stepID := findHookedBeadForAgent(beads.New(beadsPath), roleInfo.ActorString())
+	if stepID == "" {
+		return "", fmt.Errorf("nothing on your hook (use --step)")
+	}
+	return stepID, nil
+}
@@ -7,8 +7,10 @@ import (
 	"os/exec"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -458,6 +460,17 @@ func showMountainDetail(townBeads, inputID string) error {
 
 	total := len(completed) + len(active) + len(ready) + len(skipped) + len(blocked)
 
+	// Forecast the remaining (non-skipped) slingable beads.
+	now := time.Now()
+	var forecastInput []convoyops.ForecastIssue
+	for _, group := range [][]string{completed, active, ready, blocked} {
+		for _, id := range group {
+			node := dag.Nodes[id]
+			forecastInput = append(forecastInput, convoyops.ForecastIssue{ID: id, Status: node.Status, BlockedBy: node.BlockedBy})
+		}
+	}
+	forecast := forecastIssues(townBeads, forecastInput, now)
+
 	if mountainJSON {
 		jsonOut := map[string]interface{}{
 			"convoy_id": convoyID,
@@ -470,6 +483,7 @@ func showMountainDetail(townBeads, inputID string) error {
 			"skipped":   len(skipped),
 			"blocked":   len(blocked),
 			"waves":     len(waves),
+			"forecast":  forecast,
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -485,6 +499,9 @@ func showMountainDetail(townBeads, inputID string) error {
 	fmt.Printf("Mountain: %s %q\n", convoyID, cv.Title)
 	fmt.Printf("\nProgress: %d/%d closed (%d%%)\n", len(completed), total, pct)
 	fmt.Printf("Wave: %d total\n", len(waves))
+	if forecast.Remaining > 0 {
+		fmt.Printf("ETA: %s\n", formatForecastETA(forecast, now))
+	}
 
 	if len(completed) > 0 {
 		sort.Strings(completed)
@@ -558,6 +575,11 @@ func showMountainDetail(townBeads, inputID string) error {
 		}
 	}
 
+	if forecast.Remaining > 0 {
+		fmt.Println()
+		printForecastDetails(forecast, "")
+	}
+
 	return nil
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -60,6 +61,10 @@ type SlingSpawnOptions struct {
 	BaseBranch    string // Override base branch for polecat worktree (e.g., "develop", "release/v2")
 	ResumeBranch  string // Resume an existing branch (e.g. PR head) instead of creating polecat/<name>/<bead>+<ts>
 	SkipAdmission bool   // Caller already holds a polecat admission reservation
+
+	// Capabilities are the bead's cap:/cap-pref: labels. When set, the idle
+	// polecat and agent preset are chosen by capability profile.
+	Capabilities capacity.CapabilityNeeds
 }
 
 func effectivePolecatDirCap(configured int) int {
@@ -183,7 +188,16 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 	// Persistent polecat model (gt-4ac): try to reuse an idle polecat first.
 	// Idle polecats have completed their work but kept their sandbox (worktree).
 	// Reusing avoids the overhead of creating a new worktree.
-	idlePolecat, findErr := polecatMgr.FindIdlePolecat()
+	var profiles *capacity.CapabilityProfiles
+	if !opts.Capabilities.Empty() {
+		profiles = loadCapabilityProfiles(townRoot)
+	}
+	idlePolecat, findErr := findIdlePolecatForSling(polecatMgr, profiles, rigName, opts.Capabilities)
+	var polecatSkills []string
+	if findErr == nil && idlePolecat != nil && profiles != nil {
+		polecatSkills = profiles.Polecat(rigName, idlePolecat.Name)
+	}
+	opts.Agent = routeAgentForCapabilities(profiles, opts.Capabilities, polecatSkills, opts.Agent)
 	if findErr == nil && idlePolecat != nil {
 		polecatName := idlePolecat.Name
 		fmt.Printf("Reusing idle polecat: %s\n", polecatName)
@@ -201,6 +201,10 @@ func renderFormulaStepsFullParsed(formulaName string, f *formula.Formula, varMap
 	for i, step := range f.Steps {
 		title := applyFormulaVars(step.Title, varMap)
 		fmt.Fprintf(&sb, "### Step %d: %s\n\n", i+1, title)
+		if note := formulaStepConditionNote(step, varMap); note != "" {
+			sb.WriteString(note)
+			sb.WriteString("\n\n")
+		}
 		if step.Description != "" {
 			sb.WriteString(applyFormulaVars(step.Description, varMap))
 			sb.WriteString("\n\n")
@@ -209,6 +213,38 @@ func renderFormulaStepsFullParsed(formulaName string, f *formula.Formula, varMap
 	return sb.String()
 }
 
+// formulaStepConditionNote describes a step's when / repeat_until for the
+// inline checklist. A when condition that vars already decide is resolved
+// here; one that depends on step outputs is left for the agent to check.
+func formulaStepConditionNote(step formula.Step, varMap map[string]string) string {
+	var notes []string
+	if step.When != "" {
+		fields, run, err := workflowStepFields(step, varMap)
+		switch {
+		case err != nil:
+			notes = append(notes, fmt.Sprintf("_Only if `%s` (invalid condition: %v)._", step.When, err))
+		case !run:
+			notes = append(notes, fmt.Sprintf("_**Skip this step**: `%s` is false._", step.When))
+		case countConditionRefs(fields.When) < countConditionRefs(step.When):
+			notes = append(notes, fmt.Sprintf("_Only if `%s` (with vars: `%s`)._", step.When, fields.When))
+		default:
+			notes = append(notes, fmt.Sprintf("_Only if `%s`._", step.When))
+		}
+	}
+	if step.RepeatUntil != "" {
+		notes = append(notes, fmt.Sprintf("_Repeat until `%s`, at most %d times._", step.RepeatUntil, step.IterationLimit()))
+	}
+	return strings.Join(notes, "\n")
+}
+
+func countConditionRefs(src string) int {
+	cond, err := formula.ParseCondition(src)
+	if err != nil {
+		return 0
+	}
+	return len(cond.Refs())
+}
+
 // buildFormulaVarMap builds a map of variable name → value for substitution.
 // Formula defaults are applied first; extraVars (key=value strings) override them.
 func buildFormulaVarMap(f *formula.Formula, extraVars []string) map[string]string {
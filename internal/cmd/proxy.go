@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultProxyAdminAddr matches gt-proxy-server's --admin-listen default.
+const defaultProxyAdminAddr = "127.0.0.1:9877"
+
+var proxyAdminAddr string
+
+var proxyCmd = &cobra.Command{
+	Use:     "proxy",
+	GroupID: GroupServices,
+	Short:   "Manage the sandbox proxy (gt-proxy-server)",
+	RunE:    requireSubcommand,
+	Long: `Manage the mTLS proxy that sandboxed polecats use to reach gt, bd and git.
+
+Subcommands talk to the proxy's local admin API (default 127.0.0.1:9877,
+override with --admin or GT_PROXY_ADMIN) and read the town's proxy state
+under .runtime/proxy.`,
+}
+
+func init() {
+	def := os.Getenv("GT_PROXY_ADMIN")
+	if def == "" {
+		def = defaultProxyAdminAddr
+	}
+	proxyCmd.PersistentFlags().StringVar(&proxyAdminAddr, "admin", def, "Proxy admin API address (host:port)")
+
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// proxyAdminUnreachableError reports that the admin API could not be reached
+// at all, as opposed to answering with an error.
+type proxyAdminUnreachableError struct{ err error }
+
+func (e *proxyAdminUnreachableError) Error() string {
+	return fmt.Sprintf("proxy admin API at %s unreachable: %v", proxyAdminAddr, e.err)
+}
+
+func (e *proxyAdminUnreachableError) Unwrap() error { return e.err }
+
+// proxyAdminPost sends body as JSON to the admin API and decodes a JSON reply
+// into out when out is non-nil.
+func proxyAdminPost(path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post("http://"+proxyAdminAddr+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return &proxyAdminUnreachableError{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("proxy admin API: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
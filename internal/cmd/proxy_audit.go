@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/proxy"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	proxyAuditJournal string
+	proxyAuditCA      string
+	proxyAuditJSON    bool
+)
+
+var proxyAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check the proxy's tamper-evident audit journal",
+	RunE:  requireSubcommand,
+	Long: `Work with the proxy audit journal (.runtime/proxy/audit.jsonl).
+
+The proxy appends every exec (identity, argv, exit code, duration, output
+hashes) and every git push (refs with old/new SHAs) to the journal. Entries
+are chained by hash and periodically sealed by checkpoints signed with the
+proxy CA key; copies of the checkpoints go to audit-checkpoints.jsonl.`,
+}
+
+var proxyAuditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Detect gaps, edits and truncation in the audit journal",
+	Long: `Verify the proxy audit journal offline: every entry's hash, the hash
+chain and sequence numbers (catching edited, removed or reordered entries),
+each checkpoint and its CA signature, and the separate checkpoint file
+(catching a journal truncated after a checkpoint).
+
+Entries after the last checkpoint are protected only by the hash chain.
+Exits 1 when any problem is found.
+
+Examples:
+  gt proxy audit verify
+  gt proxy audit verify --json
+  gt proxy audit verify --journal ./audit.jsonl --ca ./ca.crt`,
+	RunE: runProxyAuditVerify,
+}
+
+func init() {
+	proxyAuditVerifyCmd.Flags().StringVar(&proxyAuditJournal, "journal", "", "Audit journal (default: <town>/.runtime/proxy/audit.jsonl)")
+	proxyAuditVerifyCmd.Flags().StringVar(&proxyAuditCA, "ca", "", "CA certificate for checkpoint signatures (default: <town>/.runtime/ca/ca.crt)")
+	proxyAuditVerifyCmd.Flags().BoolVar(&proxyAuditJSON, "json", false, "Output the report as JSON")
+
+	proxyAuditCmd.AddCommand(proxyAuditVerifyCmd)
+	proxyCmd.AddCommand(proxyAuditCmd)
+}
+
+func runProxyAuditVerify(cmd *cobra.Command, _ []string) error {
+	townRoot, townErr := workspace.FindFromCwdOrError()
+	journal := proxyAuditJournal
+	if journal == "" {
+		if townErr != nil {
+			return fmt.Errorf("not in a Gas Town workspace (use --journal): %w", townErr)
+		}
+		journal = proxy.AuditJournalPath(townRoot)
+	}
+	if _, err := os.Stat(journal); err != nil {
+		return fmt.Errorf("audit journal: %w", err)
+	}
+
+	// The town CA is optional: without it signatures go unchecked, but an
+	// explicit --ca must load.
+	caPath := proxyAuditCA
+	if caPath == "" && townErr == nil {
+		caPath = filepath.Join(townRoot, ".runtime", "ca", "ca.crt")
+	}
+	var caCert *x509.Certificate
+	if caPath != "" {
+		cert, err := loadCACert(caPath)
+		switch {
+		case err == nil:
+			caCert = cert
+		case proxyAuditCA != "" || !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+
+	rep, err := proxy.VerifyAuditJournal(journal, caCert)
+	if err != nil {
+		return err
+	}
+	if proxyAuditJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rep); err != nil {
+			return err
+		}
+	} else {
+		printProxyAuditReport(rep, caCert != nil)
+	}
+	if !rep.OK() {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+func printProxyAuditReport(rep *proxy.AuditReport, checkedSignatures bool) {
+	if !rep.OK() {
+		fmt.Printf("%s %s: %d problem(s)\n", style.Error.Render("✗"), rep.Journal, len(rep.Problems))
+		for _, p := range rep.Problems {
+			where := "checkpoint file"
+			if p.Line > 0 {
+				where = fmt.Sprintf("line %d", p.Line)
+			}
+			fmt.Printf("  %s %s\n", style.Dim.Render(where+":"), p.Message)
+		}
+		return
+	}
+	fmt.Printf("%s %s: %d entries through seq %d, chain intact\n",
+		style.Success.Render("✓"), rep.Journal, rep.Entries, rep.LastSeq)
+	signed := "signatures not checked (no CA)"
+	if checkedSignatures {
+		signed = fmt.Sprintf("%d signed", rep.Signed)
+	}
+	fmt.Printf("  %d checkpoint(s), %s, %d in checkpoint file\n", rep.Checkpoints, signed, rep.ExternalCheckpoints)
+	if rep.Unsealed > 0 {
+		fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("%d entries after the last checkpoint (seq %d)", rep.Unsealed, rep.LastCheckpointSeq)))
+	}
+}
+
+// loadCACert reads a PEM CA certificate.
+func loadCACert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // operator-supplied certificate path
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/proxy"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	proxyCertsJSON     bool
+	proxyCertsState    string
+	proxyRevokeReason  string
+	proxyRevokeExpires string
+)
+
+var proxyCertsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "List, revoke and inspect polecat client certificates",
+	RunE:  requireSubcommand,
+	Long: `Manage the polecat client certificates issued by the proxy CA.
+
+The town's cert ledger (.runtime/proxy/certs.json) records every cert the
+proxy issues and every revocation, with who revoked it and why. Revocations
+survive proxy restarts and are kept until the cert's own expiry, after which
+the TLS handshake rejects it anyway and the entry is pruned.`,
+}
+
+var proxyCertsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List outstanding and revoked certificates",
+	Args:  cobra.NoArgs,
+	RunE:  runProxyCertsList,
+}
+
+var proxyCertsRevokeCmd = &cobra.Command{
+	Use:   "revoke <serial|cn|cert.pem>...",
+	Short: "Revoke certificates by serial, polecat CN, or PEM file",
+	Long: `Revoke one or more polecat client certificates.
+
+Each argument is a hex serial, a polecat CN (gt-<rig>-<name>, revoking all
+of its outstanding certs), or a path to the PEM certificate.
+
+The revocation is sent to the running proxy, which rejects the cert at the
+TLS handshake immediately and records it in the ledger. If the proxy's admin
+API is unreachable, the revocation is written to the ledger directly; a
+running proxy picks it up within a minute, and a stopped one on start.
+
+Examples:
+  gt proxy certs revoke 3f9a0c... --reason "leaked in logs"
+  gt proxy certs revoke gt-gastown-nux --reason "polecat nuked"
+  gt proxy certs revoke ./client.crt --reason "lost container"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runProxyCertsRevoke,
+}
+
+var proxyCertsInspectCmd = &cobra.Command{
+	Use:   "inspect <serial|cert.pem>",
+	Short: "Show a certificate's details and ledger state",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProxyCertsInspect,
+}
+
+func init() {
+	proxyCertsListCmd.Flags().BoolVar(&proxyCertsJSON, "json", false, "Output as JSON")
+	proxyCertsListCmd.Flags().StringVar(&proxyCertsState, "state", "", "Only show certs in this state (active, revoked)")
+	proxyCertsRevokeCmd.Flags().StringVar(&proxyRevokeReason, "reason", "", "Why the cert is being revoked (required)")
+	proxyCertsRevokeCmd.Flags().StringVar(&proxyRevokeExpires, "not-after", "",
+		"Expiry (RFC 3339) of a cert the ledger doesn't know, to keep its revocation until then")
+	proxyCertsInspectCmd.Flags().BoolVar(&proxyCertsJSON, "json", false, "Output as JSON")
+
+	proxyCertsCmd.AddCommand(proxyCertsListCmd, proxyCertsRevokeCmd, proxyCertsInspectCmd)
+	proxyCmd.AddCommand(proxyCertsCmd)
+}
+
+func proxyCertLedger() (*proxy.CertLedger, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	return proxy.OpenCertLedger(proxy.CertLedgerPath(townRoot)), nil
+}
+
+func runProxyCertsList(cmd *cobra.Command, args []string) error {
+	switch proxyCertsState {
+	case "", "active", "revoked":
+	default:
+		return fmt.Errorf("invalid --state %q: use active or revoked", proxyCertsState)
+	}
+	ledger, err := proxyCertLedger()
+	if err != nil {
+		return err
+	}
+	records, err := ledger.Records()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	filtered := make([]proxy.CertRecord, 0, len(records))
+	for _, r := range records {
+		if proxyCertsState == "" || r.State(now) == proxyCertsState {
+			filtered = append(filtered, r)
+		}
+	}
+
+	if proxyCertsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(filtered)
+	}
+	if len(filtered) == 0 {
+		fmt.Println(style.Dim.Render("No certificates in the ledger"))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERIAL\tCN\tSTATE\tISSUED\tEXPIRES\tREVOKED BY\tREASON")
+	for _, r := range filtered {
+		state := r.State(now)
+		if state == "revoked" {
+			state = style.Error.Render(state)
+		}
+		by, reason := "", ""
+		if r.Revoked != nil {
+			by, reason = r.Revoked.By, r.Revoked.Reason
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Serial, orDash(r.CN), state, formatCertTime(r.IssuedAt), formatCertTime(r.NotAfter),
+			orDash(by), orDash(reason))
+	}
+	return tw.Flush()
+}
+
+func runProxyCertsRevoke(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(proxyRevokeReason) == "" {
+		return fmt.Errorf("--reason is required")
+	}
+	var explicitNotAfter time.Time
+	if proxyRevokeExpires != "" {
+		t, err := time.Parse(time.RFC3339, proxyRevokeExpires)
+		if err != nil {
+			return fmt.Errorf("invalid --not-after: %w", err)
+		}
+		explicitNotAfter = t
+	}
+	ledger, err := proxyCertLedger()
+	if err != nil {
+		return err
+	}
+
+	targets, err := resolveCertTargets(ledger, args)
+	if err != nil {
+		return err
+	}
+	by := detectSender()
+	for _, target := range targets {
+		notAfter := target.NotAfter
+		if notAfter.IsZero() {
+			notAfter = explicitNotAfter
+		}
+		req := map[string]string{
+			"serial":     target.Serial,
+			"reason":     proxyRevokeReason,
+			"revoked_by": by,
+		}
+		if !notAfter.IsZero() {
+			req["not_after"] = notAfter.UTC().Format(time.RFC3339)
+		}
+
+		err := proxyAdminPost("/v1/admin/deny-cert", req, nil)
+		var unreachable *proxyAdminUnreachableError
+		switch {
+		case err == nil:
+			fmt.Printf("%s Revoked %s %s\n", style.Success.Render("✓"), target.Serial, style.Dim.Render(orDash(target.CN)))
+		case errors.As(err, &unreachable):
+			if _, lerr := ledger.Revoke(target.Serial, proxy.Revocation{At: time.Now(), By: by, Reason: proxyRevokeReason}, notAfter); lerr != nil {
+				return fmt.Errorf("%v; recording in ledger: %w", err, lerr)
+			}
+			fmt.Printf("%s Revoked %s in the ledger %s\n", style.Warning.Render("!"), target.Serial,
+				style.Dim.Render("(proxy unreachable: a running proxy applies it within a minute)"))
+		default:
+			return fmt.Errorf("revoking %s: %w", target.Serial, err)
+		}
+	}
+	return nil
+}
+
+// certTarget is a certificate named on the command line.
+type certTarget struct {
+	Serial   string
+	CN       string
+	NotAfter time.Time // zero when only the serial is known
+	Cert     *x509.Certificate
+}
+
+// resolveCertTargets turns serials, CNs, and PEM paths into certificates.
+// A CN expands to every unrevoked cert the ledger holds for it.
+func resolveCertTargets(ledger *proxy.CertLedger, args []string) ([]certTarget, error) {
+	var out []certTarget
+	for _, arg := range args {
+		if t, ok, err := certTargetFromFile(arg); err != nil {
+			return nil, err
+		} else if ok {
+			out = append(out, t)
+			continue
+		}
+		if serial, ok := proxy.ParseSerial(arg); ok {
+			t := certTarget{Serial: serial.Text(16)}
+			if rec, found, err := ledger.Lookup(t.Serial); err != nil {
+				return nil, err
+			} else if found {
+				t.CN, t.NotAfter = rec.CN, rec.NotAfter
+			}
+			out = append(out, t)
+			continue
+		}
+		if !strings.HasPrefix(arg, "gt-") {
+			return nil, fmt.Errorf("%q is not a serial, a gt-<rig>-<name> CN, or a certificate file", arg)
+		}
+		records, err := ledger.Records()
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for _, r := range records {
+			if r.CN == arg && r.Revoked == nil {
+				out = append(out, certTarget{Serial: r.Serial, CN: r.CN, NotAfter: r.NotAfter})
+				n++
+			}
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("no outstanding certificates for %s in the ledger", arg)
+		}
+	}
+	return out, nil
+}
+
+// certTargetFromFile reads a PEM certificate from path. It reports false
+// without error when path is not an existing file.
+func certTargetFromFile(path string) (certTarget, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return certTarget{}, false, nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // operator-supplied certificate path
+	if err != nil {
+		return certTarget{}, false, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return certTarget{}, false, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return certTarget{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return certTarget{
+		Serial:   cert.SerialNumber.Text(16),
+		CN:       cert.Subject.CommonName,
+		NotAfter: cert.NotAfter,
+		Cert:     cert,
+	}, true, nil
+}
+
+// certInspection is the JSON output of gt proxy certs inspect.
+type certInspection struct {
+	Serial    string            `json:"serial"`
+	State     string            `json:"state"`
+	Ledger    *proxy.CertRecord `json:"ledger,omitempty"`
+	CN        string            `json:"cn,omitempty"`
+	Issuer    string            `json:"issuer,omitempty"`
+	NotBefore *time.Time        `json:"not_before,omitempty"`
+	NotAfter  *time.Time        `json:"not_after,omitempty"`
+}
+
+func runProxyCertsInspect(cmd *cobra.Command, args []string) error {
+	ledger, err := proxyCertLedger()
+	if err != nil {
+		return err
+	}
+	target, fromFile, err := certTargetFromFile(args[0])
+	if err != nil {
+		return err
+	}
+	if !fromFile {
+		serial, ok := proxy.ParseSerial(args[0])
+		if !ok {
+			return fmt.Errorf("%q is not a serial or a certificate file", args[0])
+		}
+		target = certTarget{Serial: serial.Text(16)}
+	}
+
+	now := time.Now()
+	out := certInspection{Serial: target.Serial, State: "unknown"}
+	rec, found, err := ledger.Lookup(target.Serial)
+	if err != nil {
+		return err
+	}
+	if found {
+		out.Ledger = &rec
+		out.State = rec.State(now)
+		out.CN = rec.CN
+		out.NotAfter = &rec.NotAfter
+	}
+	if c := target.Cert; c != nil {
+		out.CN = c.Subject.CommonName
+		out.Issuer = c.Issuer.CommonName
+		out.NotBefore, out.NotAfter = &c.NotBefore, &c.NotAfter
+		if !found {
+			out.State = "active"
+			if c.NotAfter.Before(now) {
+				out.State = "expired"
+			}
+		}
+	}
+	if !found && target.Cert == nil {
+		return fmt.Errorf("serial %s is not in the cert ledger (it may have expired and been pruned)", target.Serial)
+	}
+
+	if proxyCertsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Printf("%s %s\n", style.Bold.Render("Certificate"), out.Serial)
+	fmt.Printf("  CN:        %s\n", orDash(out.CN))
+	fmt.Printf("  State:     %s\n", out.State)
+	if out.Issuer != "" {
+		fmt.Printf("  Issuer:    %s\n", out.Issuer)
+	}
+	if out.NotBefore != nil {
+		fmt.Printf("  Valid:     %s → %s\n", formatCertTime(*out.NotBefore), formatCertTime(*out.NotAfter))
+	} else if out.NotAfter != nil {
+		fmt.Printf("  Expires:   %s\n", formatCertTime(*out.NotAfter))
+	}
+	if !found {
+		fmt.Printf("  Ledger:    %s\n", style.Dim.Render("not recorded (issued outside the proxy admin API)"))
+		return nil
+	}
+	if !rec.IssuedAt.IsZero() {
+		fmt.Printf("  Issued:    %s\n", formatCertTime(rec.IssuedAt))
+	}
+	if r := rec.Revoked; r != nil {
+		fmt.Printf("  Revoked:   %s by %s\n", formatCertTime(r.At), orDash(r.By))
+		fmt.Printf("  Reason:    %s\n", orDash(r.Reason))
+	}
+	return nil
+}
+
+func formatCertTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
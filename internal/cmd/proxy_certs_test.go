@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/proxy"
+)
+
+func TestResolveCertTargets(t *testing.T) {
+	ca, err := proxy.GenerateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	ledger := proxy.OpenCertLedger(filepath.Join(dir, "certs.json"))
+
+	issue := func(cn string) (*x509.Certificate, []byte) {
+		certPEM, _, err := ca.IssuePolecat(cn, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(certPEM)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, certPEM
+	}
+	nux1, _ := issue("gt-gastown-nux")
+	nux2, _ := issue("gt-gastown-nux")
+	other, otherPEM := issue("gt-gastown-max")
+	for _, c := range []*x509.Certificate{nux1, nux2} {
+		if err := ledger.RecordIssued(c, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ledger.Revoke(nux2.SerialNumber.Text(16), proxy.Revocation{At: time.Now()}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	pemPath := filepath.Join(dir, "max.crt")
+	if err := os.WriteFile(pemPath, otherPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := resolveCertTargets(ledger, []string{"gt-gastown-nux", pemPath, "0xABCDEF"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("targets = %+v", targets)
+	}
+	if targets[0].Serial != nux1.SerialNumber.Text(16) || targets[0].NotAfter.IsZero() {
+		t.Errorf("CN target = %+v, want only the unrevoked cert", targets[0])
+	}
+	if targets[1].Serial != other.SerialNumber.Text(16) || targets[1].CN != "gt-gastown-max" || targets[1].NotAfter.IsZero() {
+		t.Errorf("file target = %+v", targets[1])
+	}
+	if targets[2].Serial != "abcdef" || !targets[2].NotAfter.IsZero() {
+		t.Errorf("serial target = %+v", targets[2])
+	}
+
+	for _, bad := range []string{"gt-gastown-ghost", "polecat-nux"} {
+		if _, err := resolveCertTargets(ledger, []string{bad}); err == nil {
+			t.Errorf("resolveCertTargets(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/proxy"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	proxyPolicyFile     string
+	proxyPolicyIdentity string
+	proxyPolicyCN       string
+	proxyPolicyJSON     bool
+)
+
+var proxyPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check the proxy's per-identity exec policy",
+	RunE:  requireSubcommand,
+	Long: `Work with the per-identity exec policy (.runtime/proxy/policy.json).
+
+The policy is evaluated for every proxied gt/bd command after the command
+and subcommand allowlists, against the identity in the caller's client cert.
+Rules can match argv patterns, restrict arguments ("only beads in my rig",
+"only mail to my witness"), assign rate classes, and deny with a reason.
+The proxy reloads the file when it changes. See docs/proxy-server.md.`,
+}
+
+var proxyPolicyTestCmd = &cobra.Command{
+	Use:   "test [-- <command> [args...]]",
+	Short: "Validate the policy and evaluate a command against it offline",
+	Long: `Validate the exec policy and, given a command, show how the proxy would
+decide it for an identity: allow or deny, the deciding rule, the reason and
+the rate class. Nothing is executed and the proxy need not be running.
+
+Only the policy is evaluated; the proxy's command and subcommand allowlists
+are not. Exits 1 when the command is denied.
+
+Examples:
+  gt proxy policy test
+  gt proxy policy test --identity gastown/nux -- gt mail send mayor/ -s hi
+  gt proxy policy test --cn gt-gastown-nux -- bd close bd-123
+  gt proxy policy test --policy ./policy.json --identity gastown/nux -- bd show gt-abc`,
+	RunE: runProxyPolicyTest,
+}
+
+func init() {
+	proxyPolicyTestCmd.Flags().StringVar(&proxyPolicyFile, "policy", "", "Policy file (default: <town>/.runtime/proxy/policy.json)")
+	proxyPolicyTestCmd.Flags().StringVar(&proxyPolicyIdentity, "identity", "", "Caller identity as <rig>/<name>")
+	proxyPolicyTestCmd.Flags().StringVar(&proxyPolicyCN, "cn", "", "Caller cert CN (gt-<rig>-<name>), instead of --identity")
+	proxyPolicyTestCmd.Flags().BoolVar(&proxyPolicyJSON, "json", false, "Output the decision as JSON")
+
+	proxyPolicyCmd.AddCommand(proxyPolicyTestCmd)
+	proxyCmd.AddCommand(proxyPolicyCmd)
+}
+
+// proxyPolicyTestResult is the --json output of gt proxy policy test.
+type proxyPolicyTestResult struct {
+	Policy   string                `json:"policy"`
+	Identity string                `json:"identity,omitempty"`
+	Argv     []string              `json:"argv,omitempty"`
+	Decision *proxy.PolicyDecision `json:"decision,omitempty"`
+}
+
+func runProxyPolicyTest(cmd *cobra.Command, args []string) error {
+	if proxyPolicyIdentity != "" && proxyPolicyCN != "" {
+		return fmt.Errorf("use --identity or --cn, not both")
+	}
+	identity := proxyPolicyIdentity
+	if proxyPolicyCN != "" {
+		identity = proxy.IdentityFromCN(proxyPolicyCN)
+		if identity == "" {
+			return fmt.Errorf("--cn %q is not a polecat CN (gt-<rig>-<name>)", proxyPolicyCN)
+		}
+	}
+	if identity != "" {
+		if rig, name, ok := strings.Cut(identity, "/"); !ok || rig == "" || name == "" {
+			return fmt.Errorf("--identity %q: want <rig>/<name>", identity)
+		}
+	}
+
+	// The town supplies the default policy path and the bead routes behind
+	// {prefix} and bead_prefixes; an explicit --policy works outside a town.
+	townRoot, townErr := workspace.FindFromCwdOrError()
+	path := proxyPolicyFile
+	if path == "" {
+		if townErr != nil {
+			return fmt.Errorf("not in a Gas Town workspace (use --policy): %w", townErr)
+		}
+		path = proxy.PolicyPath(townRoot)
+	}
+
+	policy, err := proxy.LoadPolicy(path)
+	if err != nil {
+		return err
+	}
+	result := proxyPolicyTestResult{Policy: path, Identity: identity, Argv: args}
+
+	if len(args) == 0 {
+		if proxyPolicyJSON {
+			return printProxyPolicyJSON(result)
+		}
+		if policy == nil {
+			fmt.Printf("%s No policy at %s — every allowlisted command is allowed\n", style.Dim.Render("○"), path)
+			return nil
+		}
+		fmt.Printf("%s Policy %s is valid: %d rule(s), %d rate class(es), default %s\n",
+			style.Success.Render("✓"), path, len(policy.Rules), len(policy.RateClasses), orDefault(policy.Default, proxy.PolicyAllow))
+		return nil
+	}
+
+	decision := policy.Evaluate(townRoot, identity, args)
+	result.Decision = &decision
+	if proxyPolicyJSON {
+		if err := printProxyPolicyJSON(result); err != nil {
+			return err
+		}
+	} else {
+		printProxyPolicyDecision(policy, decision)
+	}
+	if !decision.Allow {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+func printProxyPolicyJSON(result proxyPolicyTestResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func printProxyPolicyDecision(policy *proxy.Policy, d proxy.PolicyDecision) {
+	rule := "default"
+	if d.Rule != "" {
+		rule = "rule " + d.Rule
+	}
+	if policy == nil {
+		rule = "no policy"
+	}
+	if !d.Allow {
+		fmt.Printf("%s (%s): %s\n", style.Error.Render("DENY"), rule, d.Reason)
+		return
+	}
+	fmt.Printf("%s (%s)", style.Success.Render("ALLOW"), rule)
+	if d.RateClass != "" {
+		rc := policy.RateClasses[d.RateClass]
+		fmt.Printf(" rate class %s: %g/s, burst %d", style.Bold.Render(d.RateClass), rc.Rate, rc.Burst)
+	}
+	fmt.Println()
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/sandbox"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	sandboxExecRig     string
+	sandboxExecPolecat string
+	sandboxInitSpec    string
+)
+
+var sandboxCmd = &cobra.Command{
+	Use:     "sandbox",
+	GroupID: GroupAgents,
+	Short:   "Run polecats in a Linux namespace sandbox",
+	RunE:    requireSubcommand,
+	Long: `Run a rig's polecats isolated on the local Linux host, without Docker.
+
+Enable it per rig in <rig>/settings/config.json:
+
+  "sandbox": {"mode": "namespace"}
+
+Polecats in the rig then start inside user, mount, PID and network
+namespaces (via bubblewrap when installed, else built into gt). They see
+the system directories read-only, their own worktree, a private home and
+the gt-proxy-server socket — nothing else of the town. gt and bd inside
+are gt-proxy-client, using a certificate issued from the proxy CA on
+spawn, so every command and git push goes through the proxy's allowlist,
+policy and audit journal.`,
+	// Sandbox commands run in a polecat's startup path and inside the new
+	// namespaces; skip the root pre-run checks.
+	PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+}
+
+var sandboxExecCmd = &cobra.Command{
+	Use:   "exec --rig <rig> --polecat <name> -- <command> [args...]",
+	Short: "Run an agent command in a polecat's sandbox",
+	Long: `Prepare a polecat's sandbox and run the agent command inside it, with
+the current directory (the polecat's worktree) as its working directory.
+
+Polecat startup commands in sandboxed rigs call this automatically; it is
+rarely run by hand. Each run installs gt-proxy-client into the sandbox,
+issues a fresh client certificate (CN gt-<rig>-<polecat>) and records it in
+the proxy's cert ledger. State lives in .runtime/sandbox/<rig>/<polecat>.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSandboxExec,
+}
+
+var sandboxCheckCmd = &cobra.Command{
+	Use:   "check <rig>",
+	Short: "Check that a rig's polecats can start sandboxed",
+	Long: `Check the prerequisites of a rig's sandbox without starting anything:
+Linux, the proxy CA, the proxy socket (unless the sandbox shares the host
+network), gt-proxy-client, and bubblewrap or unprivileged user namespaces.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSandboxCheck,
+}
+
+var sandboxInitCmd = &cobra.Command{
+	Use:    "init --spec <file> -- <command> [args...]",
+	Short:  "Build the sandbox filesystem and start the agent (internal)",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return sandbox.Init(sandboxInitSpec, args)
+	},
+}
+
+func init() {
+	sandboxExecCmd.Flags().StringVar(&sandboxExecRig, "rig", "", "Rig name (required)")
+	sandboxExecCmd.Flags().StringVar(&sandboxExecPolecat, "polecat", "", "Polecat name (required)")
+	_ = sandboxExecCmd.MarkFlagRequired("rig")
+	_ = sandboxExecCmd.MarkFlagRequired("polecat")
+	sandboxInitCmd.Flags().StringVar(&sandboxInitSpec, "spec", "", "Sandbox spec written by gt sandbox exec")
+	_ = sandboxInitCmd.MarkFlagRequired("spec")
+
+	sandboxCmd.AddCommand(sandboxExecCmd, sandboxCheckCmd, sandboxInitCmd)
+	rootCmd.AddCommand(sandboxCmd)
+}
+
+// loadRigSandbox returns the rig's sandbox settings, failing if the rig does
+// not enable the sandbox.
+func loadRigSandbox(townRoot, rig string) (*config.SandboxConfig, error) {
+	settings, err := config.LoadRigSettings(config.RigSettingsPath(filepath.Join(townRoot, rig)))
+	if err != nil {
+		return nil, fmt.Errorf("loading rig settings: %w", err)
+	}
+	if !settings.Sandbox.Enabled() {
+		return nil, fmt.Errorf("rig %s does not enable sandbox mode (settings: sandbox.mode)", rig)
+	}
+	return settings.Sandbox, nil
+}
+
+func runSandboxExec(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadRigSandbox(townRoot, sandboxExecRig)
+	if err != nil {
+		return err
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	spec, err := sandbox.Prepare(sandbox.Options{
+		TownRoot:  townRoot,
+		Rig:       sandboxExecRig,
+		Polecat:   sandboxExecPolecat,
+		WorkDir:   workDir,
+		Config:    cfg,
+		Agent:     args[0],
+		ConfigDir: os.Getenv("CLAUDE_CONFIG_DIR"),
+		PATH:      os.Getenv("PATH"),
+	})
+	if err != nil {
+		return fmt.Errorf("preparing sandbox: %w", err)
+	}
+	code, err := sandbox.Run(spec, args)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return NewSilentExit(code)
+	}
+	return nil
+}
+
+func runSandboxCheck(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadRigSandbox(townRoot, args[0])
+	if err == nil {
+		err = sandbox.Check(townRoot, cfg)
+	}
+	if err != nil {
+		fmt.Printf("%s %s: %v\n", style.Error.Render("✗"), args[0], err)
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return NewSilentExit(1)
+	}
+	fmt.Printf("%s %s: polecats will start sandboxed\n", style.Success.Render("✓"), args[0])
+	return nil
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
@@ -37,10 +40,27 @@ Subcommands:
   gt scheduler pause     # Pause dispatch
   gt scheduler resume    # Resume dispatch
   gt scheduler clear     # Remove beads from scheduler
+  gt scheduler simulate  # Replay history under an alternate config
+  gt scheduler capabilities  # Show capability routing profiles
 
 Config:
   gt config set scheduler.max_polecats 5    # Enable deferred dispatch
-  gt config set scheduler.max_polecats -1   # Direct dispatch (default)`,
+  gt config set scheduler.max_polecats -1   # Direct dispatch (default)
+
+Deadlines and SLAs:
+  Ready beads dispatch in order of least slack (time left before their
+  deadline), then priority, then enqueue time. A bead's deadline comes from
+  gt sling --deadline/--sla, or from scheduler.slas in settings/config.json
+  keyed by priority (e.g. {"P0": "15m"}). When capacity is exhausted and a
+  queued bead's slack drops below scheduler.sla_risk_window (default 5m),
+  the scheduler emits a scheduler_sla_risk event and escalates.
+
+Dispatch windows:
+  scheduler.windows (town) and scheduler.rig_windows.<rig> in
+  settings/config.json restrict when new polecats start: cron-style windows
+  with a duration, an IANA timezone, and YYYY-MM-DD blackout dates. Beads
+  slung to a rig outside its window are queued, even in direct dispatch mode,
+  and dispatched by the daemon once the window opens.`,
 	RunE: requireSubcommand,
 }
 
@@ -124,6 +144,63 @@ type scheduledBeadInfo struct {
 	Status    string `json:"status"`
 	TargetRig string `json:"target_rig"`
 	Blocked   bool   `json:"blocked,omitempty"`
+	Priority  int    `json:"priority"`
+	DueAt     string `json:"due_at,omitempty"`
+	Slack     string `json:"slack,omitempty"`
+	SLARisk   bool   `json:"sla_risk,omitempty"`
+}
+
+// dispatchWindowStatus describes whether a dispatch window is open and when
+// it next opens. Rigs holds per-rig windows (combined with the town window).
+type dispatchWindowStatus struct {
+	Open     bool                            `json:"open"`
+	NextOpen string                          `json:"next_open,omitempty"`
+	Error    string                          `json:"error,omitempty"`
+	Rigs     map[string]dispatchWindowStatus `json:"rigs,omitempty"`
+}
+
+// schedulerWindowStatus evaluates configured dispatch windows at now.
+// Returns nil when no windows are configured.
+func schedulerWindowStatus(cfg *capacity.SchedulerConfig, now time.Time) *dispatchWindowStatus {
+	if !cfg.HasDispatchWindows() {
+		return nil
+	}
+	windows, err := capacity.CompileDispatchWindows(cfg)
+	if err != nil {
+		return &dispatchWindowStatus{Error: err.Error()}
+	}
+	status := func(rig string) dispatchWindowStatus {
+		st := dispatchWindowStatus{Open: windows.IsOpen(rig, now)}
+		if !st.Open {
+			if next, ok := windows.NextOpen(rig, now); ok {
+				st.NextOpen = next.UTC().Format(time.RFC3339)
+			}
+		}
+		return st
+	}
+	town := status("")
+	if len(cfg.RigWindows) > 0 {
+		town.Rigs = make(map[string]dispatchWindowStatus, len(cfg.RigWindows))
+		for rig := range cfg.RigWindows {
+			town.Rigs[rig] = status(rig)
+		}
+	}
+	return &town
+}
+
+// formatWindowStatus renders a window status line for gt scheduler status.
+func formatWindowStatus(st dispatchWindowStatus) string {
+	if st.Open {
+		return "open"
+	}
+	if st.NextOpen == "" {
+		return style.Warning.Render("closed") + " (no upcoming window)"
+	}
+	next, err := time.Parse(time.RFC3339, st.NextOpen)
+	if err != nil {
+		return style.Warning.Render("closed") + " (next opening: " + st.NextOpen + ")"
+	}
+	return style.Warning.Render("closed") + " (next opening: " + next.Local().Format("Mon 2006-01-02 15:04 MST") + ")"
 }
 
 func runSchedulerStatus(cmd *cobra.Command, args []string) error {
@@ -147,6 +224,8 @@ func runSchedulerStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading polecat capacity: %w", err)
 	}
 
+	windowStatus := schedulerWindowStatus(loadSchedulerConfig(townRoot), time.Now())
+
 	if schedulerStatusJSON {
 		out := struct {
 			Paused         bool                    `json:"paused"`
@@ -156,6 +235,8 @@ func runSchedulerStatus(cmd *cobra.Command, args []string) error {
 			ActivePolecats int                     `json:"active_polecats"`
 			Capacity       polecatCapacitySnapshot `json:"capacity"`
 			LastDispatchAt string                  `json:"last_dispatch_at,omitempty"`
+			SLAAtRisk      int                     `json:"sla_at_risk"`
+			DispatchWindow *dispatchWindowStatus   `json:"dispatch_window,omitempty"`
 			Beads          []scheduledBeadInfo     `json:"beads"`
 		}{
 			Paused:         state.Paused,
@@ -164,12 +245,16 @@ func runSchedulerStatus(cmd *cobra.Command, args []string) error {
 			ActivePolecats: capacitySnapshot.ActiveSessions,
 			Capacity:       capacitySnapshot,
 			LastDispatchAt: state.LastDispatchAt,
+			DispatchWindow: windowStatus,
 			Beads:          scheduled,
 		}
 		for _, b := range scheduled {
 			if !b.Blocked {
 				out.ScheduledReady++
 			}
+			if b.SLARisk {
+				out.SLAAtRisk++
+			}
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -177,10 +262,14 @@ func runSchedulerStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	readyCount := 0
+	var atRisk []scheduledBeadInfo
 	for _, b := range scheduled {
 		if !b.Blocked {
 			readyCount++
 		}
+		if b.SLARisk {
+			atRisk = append(atRisk, b)
+		}
 	}
 
 	fmt.Printf("%s\n\n", style.Bold.Render("Scheduler Status"))
@@ -207,6 +296,27 @@ func runSchedulerStatus(cmd *cobra.Command, args []string) error {
 	if state.LastDispatchAt != "" {
 		fmt.Printf("  Last dispatch: %s (%d beads)\n", state.LastDispatchAt, state.LastDispatchCount)
 	}
+	if windowStatus != nil {
+		if windowStatus.Error != "" {
+			fmt.Printf("  Window:    %s %s\n", style.Warning.Render("invalid:"), windowStatus.Error)
+		} else {
+			fmt.Printf("  Window:    %s\n", formatWindowStatus(*windowStatus))
+			rigs := make([]string, 0, len(windowStatus.Rigs))
+			for rig := range windowStatus.Rigs {
+				rigs = append(rigs, rig)
+			}
+			sort.Strings(rigs)
+			for _, rig := range rigs {
+				fmt.Printf("    %-10s %s\n", rig+":", formatWindowStatus(windowStatus.Rigs[rig]))
+			}
+		}
+	}
+	if len(atRisk) > 0 {
+		fmt.Printf("  SLA risk:  %s\n", style.Warning.Render(fmt.Sprintf("%d bead(s)", len(atRisk))))
+		for _, b := range atRisk {
+			fmt.Printf("    %s → %s  due %s (slack %s)\n", b.ID, b.TargetRig, b.DueAt, b.Slack)
+		}
+	}
 
 	return nil
 }
@@ -247,7 +357,14 @@ func runSchedulerList(cmd *cobra.Command, args []string) error {
 			if b.Blocked {
 				indicator = "⏸"
 			}
-			fmt.Printf("    %s %s: %s\n", indicator, b.ID, b.Title)
+			due := ""
+			if b.DueAt != "" {
+				due = style.Dim.Render(fmt.Sprintf("  [P%d due %s, slack %s]", b.Priority, b.DueAt, b.Slack))
+				if b.SLARisk {
+					due = style.Warning.Render(fmt.Sprintf("  [P%d due %s, slack %s — at risk]", b.Priority, b.DueAt, b.Slack))
+				}
+			}
+			fmt.Printf("    %s %s: %s%s\n", indicator, b.ID, b.Title, due)
 		}
 		fmt.Println()
 	}
@@ -384,16 +501,34 @@ func listScheduledBeads(townRoot string) ([]scheduledBeadInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return scheduledBeadInfosFromAssessments(assessments), nil
+	return scheduledBeadInfosFromAssessments(assessments, loadSchedulerConfig(townRoot), time.Now()), nil
 }
 
-func scheduledBeadInfosFromAssessments(assessments []scheduledContextAssessment) []scheduledBeadInfo {
+// loadSchedulerConfig returns the town scheduler config, or defaults when
+// settings are missing or unreadable. Display paths only — dispatch loads
+// settings strictly in buildSchedulerDispatchPlan.
+func loadSchedulerConfig(townRoot string) *capacity.SchedulerConfig {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.Scheduler == nil {
+		return capacity.DefaultSchedulerConfig()
+	}
+	return settings.Scheduler
+}
+
+func scheduledBeadInfosFromAssessments(assessments []scheduledContextAssessment, cfg *capacity.SchedulerConfig, now time.Time) []scheduledBeadInfo {
 	var result []scheduledBeadInfo
 	for _, assessment := range assessments {
 		bead, ok := scheduledBeadInfoFromWork(assessment.context.issue.Title, assessment.fields, assessment.info, assessment.found, assessment.ready)
 		if !ok {
 			continue
 		}
+		pending := capacity.PendingBead{Priority: assessment.info.Priority, Context: assessment.fields}
+		if slack, ok := capacity.Slack(pending, cfg, now); ok {
+			due, _ := capacity.DueAt(pending, cfg)
+			bead.DueAt = due.UTC().Format(time.RFC3339)
+			bead.Slack = slack.Round(time.Second).String()
+			bead.SLARisk = slack <= cfg.GetSLARiskWindow()
+		}
 		result = append(result, bead)
 	}
 
@@ -419,6 +554,7 @@ func scheduledBeadInfoFromWork(ctxTitle string, fields *capacity.SlingContextFie
 		Status:    status,
 		TargetRig: fields.TargetRig,
 		Blocked:   !ready,
+		Priority:  info.Priority,
 	}, true
 }
 
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var schedulerCapabilitiesJSON bool
+
+var schedulerCapabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Show polecat and agent capability profiles used for routing",
+	Long: `Show the capability profiles used to route beads to polecats.
+
+Beads labeled cap:<name> require a capability; cap-pref:<name> prefers one.
+When dispatching such a bead, the idle polecat whose profile best matches is
+reused, and if it lacks a required capability an agent preset that has it is
+chosen (unless --agent was given). If nothing matches, the bead is dispatched
+anyway to the best available polecat.
+
+Profiles combine:
+  - Declared skills: scheduler.capabilities.polecats ("<rig>/<name>" or
+    "<name>") and scheduler.capabilities.agents in settings/config.json
+  - Learned skills: capability labels on beads a polecat or agent completed
+    at least scheduler.capabilities.min_completions times (default 2)
+
+Examples:
+  gt scheduler capabilities
+  gt scheduler capabilities --json`,
+	RunE: runSchedulerCapabilities,
+}
+
+func init() {
+	schedulerCapabilitiesCmd.Flags().BoolVar(&schedulerCapabilitiesJSON, "json", false, "Output as JSON")
+	schedulerCmd.AddCommand(schedulerCapabilitiesCmd)
+}
+
+func runSchedulerCapabilities(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return err
+	}
+	profiles := loadCapabilityProfiles(townRoot)
+
+	out := struct {
+		Polecats map[string][]string `json:"polecats"`
+		Agents   map[string][]string `json:"agents"`
+	}{
+		Polecats: make(map[string][]string),
+		Agents:   make(map[string][]string),
+	}
+	polecatIDs := profiles.Polecats()
+	for _, id := range polecatIDs {
+		out.Polecats[id] = profiles.Profile(id)
+	}
+	agents := profiles.Agents()
+	for _, a := range agents {
+		out.Agents[a] = profiles.Agent(a)
+	}
+
+	if schedulerCapabilitiesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(polecatIDs) == 0 && len(agents) == 0 {
+		fmt.Println("No capability profiles (declare scheduler.capabilities or label beads cap:<name>)")
+		return nil
+	}
+	fmt.Printf("%s\n\n", style.Bold.Render("Capability Profiles"))
+	if len(polecatIDs) > 0 {
+		fmt.Println("  Polecats:")
+		for _, id := range polecatIDs {
+			fmt.Printf("    %-24s %s\n", id, strings.Join(out.Polecats[id], ", "))
+		}
+	}
+	if len(agents) > 0 {
+		fmt.Println("  Agents:")
+		for _, a := range agents {
+			fmt.Printf("    %-24s %s\n", a, strings.Join(out.Agents[a], ", "))
+		}
+	}
+	return nil
+}
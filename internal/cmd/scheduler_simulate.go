@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	schedulerSimSince       string
+	schedulerSimMaxPolecats int
+	schedulerSimBatchSize   int
+	schedulerSimSpawnDelay  string
+	schedulerSimTick        time.Duration
+	schedulerSimJSON        bool
+)
+
+var schedulerSimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Replay scheduler history under an alternate config",
+	Long: `Replay historical scheduler activity under an alternate config.
+
+Reads scheduler_enqueue, scheduler_dispatch, and done events from
+.events.jsonl, then re-runs the same capacity planning the daemon uses
+(dispatch windows, PlanDispatch, spawn delay) against the recorded enqueue
+times. Each polecat runs for as long as it actually did, or for the median
+recorded run time if it never finished within the log.
+
+Prints queue wait, utilization, and throughput for what actually happened
+next to the simulated result. Flags override the current scheduler config;
+windows and SLAs always come from settings/config.json.
+
+Only scheduled beads are replayed: directly slung polecats never appear in
+the enqueue events, so their load on capacity is not modeled.
+
+  gt scheduler simulate --max-polecats 8
+  gt scheduler simulate --since 7d --batch-size 3 --spawn-delay 10s
+  gt scheduler simulate --max-polecats 4 --json`,
+	RunE: runSchedulerSimulate,
+}
+
+func init() {
+	schedulerSimulateCmd.Flags().StringVar(&schedulerSimSince, "since", "7d", "Replay events since duration (e.g., 24h, 7d)")
+	schedulerSimulateCmd.Flags().IntVar(&schedulerSimMaxPolecats, "max-polecats", 0, "Simulated scheduler.max_polecats (default: current config)")
+	schedulerSimulateCmd.Flags().IntVar(&schedulerSimBatchSize, "batch-size", 0, "Simulated scheduler.batch_size (default: current config)")
+	schedulerSimulateCmd.Flags().StringVar(&schedulerSimSpawnDelay, "spawn-delay", "", "Simulated scheduler.spawn_delay (default: current config)")
+	schedulerSimulateCmd.Flags().DurationVar(&schedulerSimTick, "tick", 3*time.Minute, "Simulated daemon heartbeat interval")
+	schedulerSimulateCmd.Flags().BoolVar(&schedulerSimJSON, "json", false, "Output as JSON")
+
+	schedulerCmd.AddCommand(schedulerSimulateCmd)
+}
+
+func runSchedulerSimulate(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if schedulerSimSince != "" {
+		d, err := parseDuration(schedulerSimSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = time.Now().Add(-d)
+	}
+
+	current := loadSchedulerConfig(townRoot)
+	alt := *current
+	if cmd.Flags().Changed("max-polecats") {
+		alt.MaxPolecats = &schedulerSimMaxPolecats
+	}
+	if cmd.Flags().Changed("batch-size") {
+		if schedulerSimBatchSize < 1 {
+			return fmt.Errorf("--batch-size must be at least 1")
+		}
+		alt.BatchSize = &schedulerSimBatchSize
+	}
+	if cmd.Flags().Changed("spawn-delay") {
+		if _, err := time.ParseDuration(schedulerSimSpawnDelay); err != nil {
+			return fmt.Errorf("invalid --spawn-delay: %w", err)
+		}
+		alt.SpawnDelay = schedulerSimSpawnDelay
+	}
+
+	jobs, err := loadSchedulerSimJobs(filepath.Join(townRoot, events.EventsFile), since)
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	actual := capacity.ActualStats(jobs, current.GetMaxPolecats())
+	simulated, err := capacity.Simulate(jobs, &alt, schedulerSimTick)
+	if err != nil {
+		return fmt.Errorf("invalid scheduler config: %w", err)
+	}
+
+	if schedulerSimJSON {
+		out := struct {
+			Since     string             `json:"since,omitempty"`
+			Current   schedulerSimParams `json:"current"`
+			Simulated schedulerSimParams `json:"simulated"`
+			Actual    capacity.SimStats  `json:"actual_stats"`
+			Result    capacity.SimStats  `json:"simulated_stats"`
+		}{
+			Current:   schedulerSimParamsFor(current),
+			Simulated: schedulerSimParamsFor(&alt),
+			Actual:    actual,
+			Result:    simulated,
+		}
+		if !since.IsZero() {
+			out.Since = since.UTC().Format(time.RFC3339)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(jobs) == 0 {
+		fmt.Printf("No scheduler enqueue events since %s\n", schedulerSimSince)
+		return nil
+	}
+
+	fmt.Printf("%s\n\n", style.Bold.Render("Scheduler Simulation"))
+	fmt.Printf("  Replayed:  %d scheduled beads since %s\n", len(jobs), schedulerSimSince)
+	fmt.Printf("  Actual:    %s\n", schedulerSimParamsFor(current))
+	fmt.Printf("  Simulated: %s\n\n", schedulerSimParamsFor(&alt))
+	printSchedulerSimTable(actual, simulated)
+	return nil
+}
+
+// schedulerSimParams is the capacity config a simulation run used.
+type schedulerSimParams struct {
+	MaxPolecats int    `json:"max_polecats"`
+	BatchSize   int    `json:"batch_size"`
+	SpawnDelay  string `json:"spawn_delay"`
+}
+
+func schedulerSimParamsFor(cfg *capacity.SchedulerConfig) schedulerSimParams {
+	return schedulerSimParams{
+		MaxPolecats: cfg.GetMaxPolecats(),
+		BatchSize:   cfg.GetBatchSize(),
+		SpawnDelay:  cfg.GetSpawnDelay().String(),
+	}
+}
+
+func (p schedulerSimParams) String() string {
+	if p.MaxPolecats <= 0 {
+		return "direct dispatch"
+	}
+	return fmt.Sprintf("max_polecats=%d batch_size=%d spawn_delay=%s", p.MaxPolecats, p.BatchSize, p.SpawnDelay)
+}
+
+func printSchedulerSimTable(actual, simulated capacity.SimStats) {
+	dur := func(d time.Duration) string { return d.Round(time.Second).String() }
+	rows := []struct {
+		label     string
+		actual    string
+		simulated string
+	}{
+		{"Dispatched", fmt.Sprintf("%d", actual.Dispatched), fmt.Sprintf("%d", simulated.Dispatched)},
+		{"Completed", fmt.Sprintf("%d", actual.Completed), fmt.Sprintf("%d", simulated.Completed)},
+		{"Wait (mean)", dur(actual.MeanWait), dur(simulated.MeanWait)},
+		{"Wait (p50)", dur(actual.P50Wait), dur(simulated.P50Wait)},
+		{"Wait (p90)", dur(actual.P90Wait), dur(simulated.P90Wait)},
+		{"Wait (max)", dur(actual.MaxWait), dur(simulated.MaxWait)},
+		{"Makespan", dur(actual.Span), dur(simulated.Span)},
+		{"Throughput", fmt.Sprintf("%.2f/h", actual.ThroughputPerHr), fmt.Sprintf("%.2f/h", simulated.ThroughputPerHr)},
+		{"Concurrency", fmt.Sprintf("%.1f avg, %d peak", actual.AvgConcurrency, actual.PeakConcurrency),
+			fmt.Sprintf("%.1f avg, %d peak", simulated.AvgConcurrency, simulated.PeakConcurrency)},
+		{"Utilization", formatSimUtilization(actual.Utilization), formatSimUtilization(simulated.Utilization)},
+	}
+	if actual.UtilizationBasis == capacity.UtilizationCurrentCapacity && actual.Utilization != 0 {
+		rows[len(rows)-1].actual += " *"
+	}
+	fmt.Printf("  %-13s %-20s %s\n", "", style.Bold.Render("Actual"), style.Bold.Render("Simulated"))
+	for _, r := range rows {
+		fmt.Printf("  %-13s %-20s %s\n", r.label, r.actual, r.simulated)
+	}
+	if actual.UtilizationBasis == capacity.UtilizationCurrentCapacity && actual.Utilization != 0 {
+		fmt.Printf("\n  %s\n", style.Dim.Render("* vs current capacity: the dispatch events do not record max_polecats"))
+	}
+}
+
+func formatSimUtilization(u float64) string {
+	if u == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", u*100)
+}
+
+// loadSchedulerSimJobs rebuilds scheduled bead timelines from the events log.
+// A bead is included when its first scheduler_enqueue falls at or after since;
+// its dispatch is the first scheduler_dispatch after that enqueue, and its
+// completion the first done event after dispatch. A missing file yields no jobs.
+func loadSchedulerSimJobs(path string, since time.Time) ([]capacity.SimJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	byBead := make(map[string]*capacity.SimJob)
+	var order []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Skip malformed lines
+		}
+		bead, _ := e.Payload["bead"].(string)
+		if bead == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		switch e.Type {
+		case events.TypeSchedulerEnqueue:
+			if ts.Before(since) || byBead[bead] != nil {
+				continue
+			}
+			rig, _ := e.Payload["rig"].(string)
+			byBead[bead] = &capacity.SimJob{ID: bead, Rig: rig, EnqueuedAt: ts}
+			order = append(order, bead)
+		case events.TypeSchedulerDispatch:
+			if job := byBead[bead]; job != nil && job.DispatchedAt.IsZero() && !ts.Before(job.EnqueuedAt) {
+				job.DispatchedAt = ts
+				if v, ok := e.Payload["max_polecats"].(float64); ok {
+					maxPolecats := int(v)
+					job.MaxPolecats = &maxPolecats
+				}
+			}
+		case events.TypeDone:
+			if job := byBead[bead]; job != nil && !job.DispatchedAt.IsZero() && job.DoneAt.IsZero() && !ts.Before(job.DispatchedAt) {
+				job.DoneAt = ts
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]capacity.SimJob, 0, len(order))
+	for _, id := range order {
+		jobs = append(jobs, *byBead[id])
+	}
+	sort.SliceStable(jobs, func(i, k int) bool { return jobs[i].EnqueuedAt.Before(jobs[k].EnqueuedAt) })
+	return jobs, nil
+}
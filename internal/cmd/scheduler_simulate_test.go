@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSchedulerSimJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	lines := []string{
+		`{"ts":"2026-03-01T08:00:00Z","type":"scheduler_enqueue","payload":{"bead":"gt-old","rig":"gastown"}}`,
+		`{"ts":"2026-03-02T09:00:00Z","type":"scheduler_enqueue","payload":{"bead":"gt-a","rig":"gastown"}}`,
+		`{"ts":"2026-03-02T09:01:00Z","type":"scheduler_enqueue","payload":{"bead":"gt-b","rig":"beads"}}`,
+		`not json`,
+		`{"ts":"2026-03-02T09:03:00Z","type":"scheduler_dispatch","payload":{"bead":"gt-a","rig":"gastown","polecat":"toast","max_polecats":6}}`,
+		`{"ts":"2026-03-02T09:04:00Z","type":"done","payload":{"bead":"gt-unscheduled","branch":"x"}}`,
+		`{"ts":"2026-03-02T09:06:00Z","type":"scheduler_dispatch","payload":{"bead":"gt-b","rig":"beads","polecat":"nux"}}`,
+		`{"ts":"2026-03-02T09:30:00Z","type":"done","payload":{"bead":"gt-a","branch":"polecat/toast"}}`,
+		`{"ts":"2026-03-02T09:45:00Z","type":"scheduler_enqueue","payload":{"bead":"gt-a","rig":"gastown"}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := loadSchedulerSimJobs(path, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("got %d jobs, want 2 (since filter and re-enqueue dedup): %+v", len(jobs), jobs)
+	}
+	a, b := jobs[0], jobs[1]
+	if a.ID != "gt-a" || a.Rig != "gastown" {
+		t.Errorf("job[0] = %+v, want gt-a on gastown", a)
+	}
+	if a.DispatchedAt.Minute() != 3 || a.DoneAt.Minute() != 30 {
+		t.Errorf("gt-a dispatched %v done %v, want 09:03 and 09:30", a.DispatchedAt, a.DoneAt)
+	}
+	if b.ID != "gt-b" || b.DispatchedAt.Minute() != 6 || !b.DoneAt.IsZero() {
+		t.Errorf("job[1] = %+v, want gt-b dispatched 09:06, not done", b)
+	}
+	// Older dispatch events carry no max_polecats.
+	if a.MaxPolecats == nil || *a.MaxPolecats != 6 || b.MaxPolecats != nil {
+		t.Errorf("recorded max_polecats a=%v b=%v, want 6 and unrecorded", a.MaxPolecats, b.MaxPolecats)
+	}
+}
+
+func TestLoadSchedulerSimJobsMissingFile(t *testing.T) {
+	jobs, err := loadSchedulerSimJobs(filepath.Join(t.TempDir(), "missing.jsonl"), time.Time{})
+	if err != nil || len(jobs) != 0 {
+		t.Errorf("missing file: jobs=%v err=%v, want none", jobs, err)
+	}
+}
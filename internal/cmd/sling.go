@@ -16,6 +16,7 @@ import (
 	"github.com/steveyegge/gastown/internal/lock"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/nudge"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -104,7 +105,14 @@ Batch Slinging:
 
   When multiple beads are provided with a rig target, each bead gets its own
   polecat. This parallelizes work dispatch without running gt sling N times.
-  Use --max-concurrent to throttle spawn rate and prevent Dolt server overload.`,
+  Use --max-concurrent to throttle spawn rate and prevent Dolt server overload.
+
+  Blocking deps (blocks, waits-for, ...) order the dispatch, across rigs too.
+  Only beads with no open blockers are slung immediately; the rest are
+  staged in one batch convoy, and the daemon slings each as its blockers
+  close. A dependency cycle within the batch is an error.
+
+  gt sling gt-abc gt-def gt-ghi gastown --waves   # Show the wave plan only`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runSling,
 }
@@ -113,6 +121,7 @@ var (
 	slingSubject     string
 	slingMessage     string
 	slingDryRun      bool
+	slingWaves       bool     // --waves: print the batch dispatch plan without slinging
 	slingOnTarget    string   // --on flag: target bead when slinging a formula
 	slingVars        []string // --var flag: formula variables (key=value)
 	slingArgs        string   // --args flag: natural language instructions for executor
@@ -137,12 +146,15 @@ var (
 	slingFormula       string // --formula: override formula for dispatch (default: mol-polecat-work)
 	slingCrew          string // --crew: target a crew member in the specified rig
 	slingReviewOnly    bool   // --review-only: mark work as review-only (no merge/commit/push)
+	slingDeadline      string // --deadline: dispatch deadline for deferred dispatch (RFC3339 or duration from now)
+	slingSLA           string // --sla: dispatch SLA measured from enqueue time (deferred dispatch)
 )
 
 func init() {
 	slingCmd.Flags().StringVarP(&slingSubject, "subject", "s", "", "Context subject for the work")
 	slingCmd.Flags().StringVarP(&slingMessage, "message", "m", "", "Context message for the work")
 	slingCmd.Flags().BoolVarP(&slingDryRun, "dry-run", "n", false, "Show what would be done")
+	slingCmd.Flags().BoolVar(&slingWaves, "waves", false, "Batch sling: print the dependency-ordered dispatch waves and exit")
 	slingCmd.Flags().StringVar(&slingOnTarget, "on", "", "Apply formula to existing bead (implies wisp scaffolding)")
 	slingCmd.Flags().StringArrayVar(&slingVars, "var", nil, "Formula variable (key=value), can be repeated")
 	slingCmd.Flags().StringVarP(&slingArgs, "args", "a", "", "Natural language instructions for the executor (e.g., 'patch release')")
@@ -167,6 +179,8 @@ func init() {
 	slingCmd.Flags().StringVar(&slingFormula, "formula", "", "Formula to apply (default: mol-polecat-work for polecat targets)")
 	slingCmd.Flags().StringVar(&slingCrew, "crew", "", "Target a crew member in the specified rig (e.g., --crew mel with target gastown → gastown/crew/mel)")
 	slingCmd.Flags().BoolVar(&slingReviewOnly, "review-only", false, "Mark work as review-only: assignee evaluates and reports back, must NOT merge/commit/push")
+	slingCmd.Flags().StringVar(&slingDeadline, "deadline", "", "Dispatch deadline when scheduled (RFC3339 time or duration from now, e.g. '2h')")
+	slingCmd.Flags().StringVar(&slingSLA, "sla", "", "Dispatch SLA when scheduled, measured from enqueue (e.g. '15m')")
 
 	slingCmd.AddCommand(slingRespawnResetCmd)
 	rootCmd.AddCommand(slingCmd)
@@ -326,6 +340,12 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 		} else {
 			args = redirected
 		}
+		if skipped, err := prepareWorkflowStep(townRoot, args[0]); err != nil {
+			return err
+		} else if skipped {
+			return nil
+		}
+		slingAgent = startWorkflowStep(townRoot, args[0], args[1], slingAgent)
 	}
 
 	// Config-driven dispatch mode: check scheduler.max_polecats
@@ -333,6 +353,18 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 	if deferErr != nil {
 		return deferErr
 	}
+	// Dispatch windows: in direct mode, a rig target outside its window is
+	// queued for the scheduler rather than dispatched immediately. Batches
+	// whose rig is resolved from bead prefixes are checked once it is known
+	// (runAutoResolvedBatchSling).
+	if !deferred && len(args) >= 2 {
+		if rigName, isRig := IsRigName(args[len(args)-1]); isRig {
+			deferred, deferErr = outsideDispatchWindow(townRoot, rigName)
+			if deferErr != nil {
+				return deferErr
+			}
+		}
+	}
 
 	// Batch mode detection: multiple beads with optional rig target
 	// Pattern A (explicit rig):  gt sling gt-abc gt-def gt-ghi gastown
@@ -361,11 +393,7 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 		}
 		// No explicit rig -- try auto-resolving from bead prefixes
 		if allBeadIDs(args) {
-			rigName, err := resolveRigFromBeadIDs(args, filepath.Dir(townBeadsDir))
-			if err != nil {
-				return err
-			}
-			return runBatchSling(args, rigName, townBeadsDir)
+			return runAutoResolvedBatchSling(args, townRoot, townBeadsDir)
 		}
 	}
 
@@ -396,6 +424,8 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 				Agent:        slingAgent,
 				HookRawBead:  slingHookRawBead,
 				Ralph:        slingRalph,
+				Deadline:     slingDeadline,
+				SLA:          slingSLA,
 			})
 		}
 	}
@@ -437,6 +467,8 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 			Agent:        slingAgent,
 			HookRawBead:  slingHookRawBead,
 			Ralph:        slingRalph,
+			Deadline:     slingDeadline,
+			SLA:          slingSLA,
 		})
 	}
 
@@ -482,6 +514,8 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 				Agent:        slingAgent,
 				HookRawBead:  slingHookRawBead,
 				Ralph:        slingRalph,
+				Deadline:     slingDeadline,
+				SLA:          slingSLA,
 			})
 		}
 		// Dog targets (deacon/dogs, deacon/dogs/<name>, dog:, dog:<name>) fall through
@@ -552,11 +586,7 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 	// 2-bead auto-resolve: gt sling gt-abc gt-def
 	if len(args) == 2 && allBeadIDs(args) {
 		if _, isRig := IsRigName(args[1]); !isRig {
-			rigName, err := resolveRigFromBeadIDs(args, filepath.Dir(townBeadsDir))
-			if err != nil {
-				return err
-			}
-			return runBatchSling(args, rigName, townBeadsDir)
+			return runAutoResolvedBatchSling(args, townRoot, townBeadsDir)
 		}
 	}
 
@@ -576,6 +606,11 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 		if err := verifyFormulaExists(formulaName, beads.ResolveHookDir(townRoot, beadID, ""), townRoot); err != nil {
 			return err
 		}
+		answers, err := checkSlingFormulaInputs(formulaName, townRoot, slingVars, autoFormulaVars, !slingDryRun)
+		if err != nil {
+			return err
+		}
+		slingVars = append(slingVars, answers...)
 	} else {
 		// Could be bead mode or standalone formula mode
 		firstArg := args[0]
@@ -721,6 +756,7 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 		TownRoot:     townRoot,
 		BaseBranch:   slingBaseBranch,
 		ResumeBranch: slingResumeBranch,
+		Capabilities: capacity.CapabilityNeedsFromLabels(info.Labels),
 	})
 	if err != nil {
 		return err
@@ -884,8 +920,20 @@ func runSling(cmd *cobra.Command, args []string) (retErr error) {
 			targetRig = parts[0]
 		}
 		formulaName = resolveFormula(slingFormula, false, townRoot, targetRig)
+		// Beads instantiated from a convoy template, or staged by a batch
+		// sling, carry the formula (and its vars) to apply; an explicit
+		// --formula wins.
+		templateFields := beads.ParseTemplateFields(&beads.Issue{Description: info.Description})
 		if slingFormula != "" {
 			fmt.Printf("  Applying %s for polecat work...\n", formulaName)
+		} else if templateFields != nil && templateFields.SlingFormula != "" {
+			formulaName = templateFields.SlingFormula
+			slingVars = append(append([]string(nil), templateFields.SlingVars...), slingVars...)
+			if templateFields.Template != "" {
+				fmt.Printf("  Applying %s from convoy template %s...\n", formulaName, templateFields.Template)
+			} else {
+				fmt.Printf("  Applying %s recorded by batch sling...\n", formulaName)
+			}
 		} else {
 			fmt.Printf("  Auto-applying %s for polecat work...\n", formulaName)
 		}
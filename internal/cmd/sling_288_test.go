@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/formula"
 )
 
 // TestInstantiateFormulaOnBead verifies the helper function works correctly.
@@ -268,6 +271,51 @@ exit /b 0
 	}
 }
 
+// TestCookFormula_PinnedInstall verifies that a version installed with
+// gt formula install is cooked by path rather than by name.
+func TestCookFormula_PinnedInstall(t *testing.T) {
+	townRoot := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+
+	formulasDir := filepath.Join(townRoot, ".beads", "formulas")
+	content := []byte("formula = \"mol-review\"\ndescription = \"pinned\"\n[[steps]]\nid = \"a\"\n")
+	if err := formula.InstallFormula(formulasDir, "mol-review",
+		formula.RegistryVersion{Version: "1.2.0", SHA256: formula.Checksum(content)}, content, "test", true, time.Now()); err != nil {
+		t.Fatalf("InstallFormula: %v", err)
+	}
+	want := formula.InstalledFormulaFile(formulasDir, "mol-review", "1.2.0")
+
+	if got, err := installedFormulaRef("mol-review", t.TempDir(), townRoot); err != nil || got != want {
+		t.Errorf("installedFormulaRef = %q, %v; want %q", got, err, want)
+	}
+	if got, err := installedFormulaRef("mol-other", t.TempDir(), townRoot); err != nil || got != "mol-other" {
+		t.Errorf("installedFormulaRef(uninstalled) = %q, %v; want the bare name", got, err)
+	}
+
+	binDir := filepath.Join(townRoot, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("mkdir binDir: %v", err)
+	}
+	logPath := filepath.Join(townRoot, "bd.log")
+	_ = writeBDStub(t, binDir, `#!/bin/sh
+echo "CMD:$*" >> "${BD_LOG}"
+exit 0
+`, `@echo off
+echo CMD:%*>>"%BD_LOG%"
+exit /b 0
+`)
+	t.Setenv("BD_LOG", logPath)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := CookFormula("mol-review", townRoot, townRoot); err != nil {
+		t.Fatalf("CookFormula failed: %v", err)
+	}
+	logBytes, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(logBytes), "cook "+want) {
+		t.Errorf("expected cook of the pinned file %s, log:\n%s", want, logBytes)
+	}
+}
+
 // TestSlingHookRawBeadFlag verifies --hook-raw-bead flag exists.
 func TestSlingHookRawBeadFlag(t *testing.T) {
 	// Verify the flag variable exists and works
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -69,28 +70,96 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		}
 	}
 
+	// Order dispatch by blocking deps: only the roots are slung now. The rest
+	// are staged in the batch convoy, and the daemon's convoy feeder slings
+	// each one as its blockers close.
+	plan, err := planBatchDispatch(townRoot, beadIDs)
+	if err != nil {
+		return err
+	}
+	staged := plan.Staged()
+
+	if slingWaves {
+		fmt.Printf("%s Batch sling of %d beads to rig '%s'\n", style.Bold.Render("🎯"), len(beadIDs), rigName)
+		fmt.Print(renderDispatchPlan(plan))
+		return nil
+	}
+
+	dispatchIDs := beadIDs
+	if len(staged) > 0 {
+		dispatchIDs = plan.Roots()
+	}
+
 	// Issue #288: Auto-apply formula for batch sling (resolved via flags)
 	formulaName := resolveFormula(slingFormula, slingHookRawBead, filepath.Dir(townBeadsDir), rigName)
 
 	if slingDryRun {
 		fmt.Printf("%s Batch slinging %d beads to rig '%s':\n", style.Bold.Render("🎯"), len(beadIDs), rigName)
+		if len(staged) > 0 {
+			fmt.Print(renderDispatchPlan(plan))
+		}
 		if formulaName != "" {
 			fmt.Printf("  Would cook %s formula once\n", formulaName)
 		} else {
 			fmt.Printf("  Would hook raw beads (no formula)\n")
 		}
-		for _, beadID := range beadIDs {
+		for _, beadID := range dispatchIDs {
 			if formulaName != "" {
 				fmt.Printf("  Would spawn polecat and apply %s to: %s\n", formulaName, beadID)
 			} else {
 				fmt.Printf("  Would spawn polecat and hook raw: %s\n", beadID)
 			}
 		}
+		if len(staged) > 0 && !slingNoConvoy {
+			fmt.Printf("  Would stage in batch convoy: %s\n", strings.Join(staged, ", "))
+			if formulaName != "" && (slingFormula != "" || len(slingVars) > 0) {
+				fmt.Printf("  Would record %s on staged beads for the feeder\n", formulaName)
+			}
+		} else if len(staged) > 0 {
+			fmt.Printf("  Would hold back (--no-convoy): %s\n", strings.Join(staged, ", "))
+		}
 		return nil
 	}
 
 	fmt.Printf("%s Batch slinging %d beads to rig '%s'...\n", style.Bold.Render("🎯"), len(beadIDs), rigName)
 
+	if len(staged) > 0 {
+		fmt.Print(renderDispatchPlan(plan))
+		if slingNoConvoy {
+			fmt.Printf("  %s --no-convoy: %d blocked bead(s) will not be dispatched; sling them once their blockers close\n",
+				style.Warning.Render("⚠"), len(staged))
+		} else {
+			// The feeder slings staged beads without flags; record an
+			// explicit --formula/--var on them so the batch's formula
+			// still applies when they are fed.
+			if formulaName != "" && (slingFormula != "" || len(slingVars) > 0) {
+				for _, id := range staged {
+					if err := stageSlingFormula(townRoot, id, formulaName, slingVars); err != nil {
+						return fmt.Errorf("recording formula on staged bead %s: %w", id, err)
+					}
+				}
+			}
+			// Track the whole batch up front so executeSling reuses this
+			// convoy for the roots and the feeder can find the staged beads.
+			convoyID, tracked, err := createBatchConvoy(beadIDs, rigName, slingOwned, slingMerge, slingBaseBranch)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  %s Tracking batch in convoy %s; %d bead(s) staged until their blockers close\n",
+				style.Bold.Render("→"), convoyID, len(staged))
+			trackedSet := make(map[string]bool, len(tracked))
+			for _, id := range tracked {
+				trackedSet[id] = true
+			}
+			for _, id := range staged {
+				if !trackedSet[id] {
+					fmt.Printf("  %s %s is not tracked by %s and will not be fed; sling it once its blockers close\n",
+						style.Dim.Render("Warning:"), id, convoyID)
+				}
+			}
+		}
+	}
+
 	if slingMaxConcurrent > 0 {
 		fmt.Printf("  Spawn batch size: %d (spawns N, pauses, spawns N more)\n", slingMaxConcurrent)
 	}
@@ -116,7 +185,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		success bool
 		errMsg  string
 	}
-	results := make([]batchResult, 0, len(beadIDs))
+	results := make([]batchResult, 0, len(dispatchIDs))
 	activeCount := 0 // Track active spawns for --max-concurrent throttling
 
 	var slingMode string
@@ -125,7 +194,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 	}
 
 	// Dispatch each bead via executeSling
-	for i, beadID := range beadIDs {
+	for i, beadID := range dispatchIDs {
 		// Spawn-rate throttle: when --max-concurrent is set, pause between batches
 		// of N spawns. This does NOT limit total concurrent polecats — all spawned
 		// polecats remain running. It only slows down how fast they are created.
@@ -144,7 +213,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 			activeCount = 0
 		}
 
-		fmt.Printf("\n[%d/%d] Slinging %s...\n", i+1, len(beadIDs), beadID)
+		fmt.Printf("\n[%d/%d] Slinging %s...\n", i+1, len(dispatchIDs), beadID)
 
 		params := SlingParams{
 			BeadID:           beadID,
@@ -195,7 +264,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		// Delay between spawns to prevent Dolt lock contention — sequential
 		// spawns without delay cause database lock timeouts when multiple bd
 		// operations (agent bead creation, hook setting) overlap.
-		if i < len(beadIDs)-1 {
+		if i < len(dispatchIDs)-1 {
 			time.Sleep(2 * time.Second)
 		}
 	}
@@ -212,16 +281,19 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		}
 	}
 
-	fmt.Printf("\n%s Batch sling complete: %d/%d succeeded\n", style.Bold.Render("📊"), successCount, len(beadIDs))
-	if successCount < len(beadIDs) {
+	fmt.Printf("\n%s Batch sling complete: %d/%d succeeded\n", style.Bold.Render("📊"), successCount, len(dispatchIDs))
+	if len(staged) > 0 && !slingNoConvoy {
+		fmt.Printf("  %s %d staged: %s\n", style.Dim.Render("○"), len(staged), strings.Join(staged, ", "))
+	}
+	if successCount < len(dispatchIDs) {
 		for _, r := range results {
 			if !r.success {
 				fmt.Printf("  %s %s: %s\n", style.Dim.Render("✗"), r.beadID, r.errMsg)
 			}
 		}
 	}
-	if successCount == 0 && len(beadIDs) > 0 {
-		return fmt.Errorf("batch sling failed: 0/%d succeeded", len(beadIDs))
+	if successCount == 0 && len(dispatchIDs) > 0 {
+		return fmt.Errorf("batch sling failed: 0/%d succeeded", len(dispatchIDs))
 	}
 
 	return nil
@@ -230,6 +302,41 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 // cleanupSpawnedPolecat removes a polecat that was spawned but whose session/hook failed,
 // preventing orphaned polecats from accumulating. Cleans up worktree, agent bead, git branch,
 // and optionally the associated auto-convoy.
+// stageSlingFormula records formula and vars on a staged bead as
+// sling_formula/sling_vars, which gt sling applies when the convoy feeder
+// later slings the bead without a --formula.
+func stageSlingFormula(townRoot, beadID, formula string, vars []string) error {
+	out, err := bdShowBeadOutputFromTownRoot(townRoot, beadID)
+	if err != nil {
+		return fmt.Errorf("fetching bead: %w", err)
+	}
+	var issues []beads.Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return fmt.Errorf("parsing bead: %w", err)
+	}
+	if len(issues) == 0 {
+		return fmt.Errorf("bead not found")
+	}
+	issue := &issues[0]
+
+	fields := beads.ParseTemplateFields(issue)
+	if fields == nil {
+		fields = &beads.TemplateFields{}
+	}
+	fields.SlingFormula = formula
+	fields.SlingVars = append([]string(nil), vars...)
+
+	newDesc := beads.SetTemplateFields(issue, fields)
+	if err := BdCmd("update", beadID, "--description="+newDesc).
+		Dir(resolveBeadDirFromTownRoot(townRoot, beadID)).
+		StripBeadsDir().
+		WithAutoCommit().
+		Run(); err != nil {
+		return fmt.Errorf("updating bead description: %w", err)
+	}
+	return nil
+}
+
 func cleanupSpawnedPolecat(spawnInfo *SpawnedPolecatInfo, rigName, convoyID string) {
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
@@ -279,6 +386,24 @@ func allBeadIDs(args []string) bool {
 	return len(args) > 0
 }
 
+// runAutoResolvedBatchSling slings beads to the rig resolved from their
+// prefixes. The rig is only known here, so its dispatch window is checked
+// here too: outside the window the beads are scheduled instead.
+func runAutoResolvedBatchSling(beadIDs []string, townRoot, townBeadsDir string) error {
+	rigName, err := resolveRigFromBeadIDs(beadIDs, filepath.Dir(townBeadsDir))
+	if err != nil {
+		return err
+	}
+	outside, err := outsideDispatchWindow(townRoot, rigName)
+	if err != nil {
+		return err
+	}
+	if outside {
+		return runBatchSchedule(beadIDs, rigName, townRoot)
+	}
+	return runBatchSling(beadIDs, rigName, townBeadsDir)
+}
+
 // resolveRigFromBeadIDs resolves the target rig from bead prefixes.
 // All beads must resolve to the same rig. Returns an error with suggested
 // actions if any prefix cannot be resolved or if beads span multiple rigs.
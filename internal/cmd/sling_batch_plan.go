@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// planBatchDispatch orders the beads of a batch sling into dispatch waves by
+// their blocking deps. Deps are read through a StoreResolver over the town
+// and rig databases, so a blocker in another rig counts with its current
+// status. If no database can be opened, every bead is a root and the batch
+// dispatches unordered, as it did before dependency planning.
+func planBatchDispatch(townRoot string, beadIDs []string) (*convoyops.DispatchPlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stores := openTownBeadsStores(ctx, townRoot)
+	defer func() {
+		for _, store := range stores {
+			_ = store.Close()
+		}
+	}()
+	if len(stores) == 0 {
+		fmt.Printf("  %s Could not open beads databases; dispatching without dependency order\n",
+			style.Dim.Render("Warning:"))
+	}
+
+	plan, err := convoyops.PlanDispatch(ctx, convoyops.NewStoreResolver(townRoot, stores), beadIDs)
+	if err != nil {
+		return nil, fmt.Errorf("planning batch dispatch: %w", err)
+	}
+	return plan, nil
+}
+
+// openTownBeadsStores opens the town (hq) beads store and each registered
+// rig's store, keyed the way StoreResolver expects. Stores that fail to open
+// are skipped.
+func openTownBeadsStores(ctx context.Context, townRoot string) map[string]beadsdk.Storage {
+	stores := make(map[string]beadsdk.Storage)
+	if store, err := beadsdk.OpenFromConfig(ctx, filepath.Join(townRoot, ".beads")); err == nil {
+		stores["hq"] = store
+	}
+
+	rigsConfig, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json"))
+	if err != nil {
+		return stores
+	}
+	for rigName := range rigsConfig.Rigs {
+		beadsDir := doltserver.FindRigBeadsDir(townRoot, rigName)
+		if beadsDir == "" {
+			continue
+		}
+		if store, err := beadsdk.OpenFromConfig(ctx, beadsDir); err == nil {
+			stores[rigName] = store
+		}
+	}
+	return stores
+}
+
+// renderDispatchPlan formats the wave structure of a batch sling. Wave 1 is
+// dispatched now; later waves list what each bead is waiting on.
+func renderDispatchPlan(plan *convoyops.DispatchPlan) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Dispatch plan (%d wave", len(plan.Waves))
+	if len(plan.Waves) != 1 {
+		sb.WriteString("s")
+	}
+	sb.WriteString("):\n")
+
+	for i, wave := range plan.Waves {
+		label := "staged"
+		if i == 0 {
+			label = "dispatch now"
+		}
+		fmt.Fprintf(&sb, "  Wave %d (%s):\n", i+1, label)
+		if len(wave) == 0 {
+			sb.WriteString("    (none — every bead waits on a blocker outside the batch)\n")
+			continue
+		}
+		for _, id := range wave {
+			blockers := plan.Blockers[id]
+			if len(blockers) == 0 {
+				fmt.Fprintf(&sb, "    %s\n", id)
+				continue
+			}
+			external := make(map[string]bool)
+			for _, b := range plan.ExternalBlockers(id) {
+				external[b] = true
+			}
+			names := make([]string, len(blockers))
+			for j, b := range blockers {
+				names[j] = b
+				if external[b] {
+					names[j] += " (outside batch)"
+				}
+			}
+			fmt.Fprintf(&sb, "    %s  ← %s\n", id, strings.Join(names, ", "))
+		}
+	}
+	return sb.String()
+}
@@ -9,6 +9,11 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 )
 
 // TestCreateBatchConvoy_CreatesOneConvoyTrackingAllBeads verifies that
@@ -1959,3 +1964,131 @@ esac
 		t.Errorf("getConvoyInfoForIssue returned %+v, want nil for phantom convoy", got)
 	}
 }
+
+// TestRenderDispatchPlan verifies the wave listing printed by batch sling
+// and --waves: roots dispatch now, staged beads name their blockers, and
+// blockers outside the batch are marked.
+func TestRenderDispatchPlan(t *testing.T) {
+	plan := &convoyops.DispatchPlan{
+		Waves: [][]string{{"gt-aaa"}, {"gt-bbb", "gt-ccc"}},
+		Blockers: map[string][]string{
+			"gt-bbb": {"gt-aaa"},
+			"gt-ccc": {"bd-xyz"},
+		},
+	}
+	got := renderDispatchPlan(plan)
+	for _, want := range []string{
+		"Dispatch plan (2 waves):",
+		"Wave 1 (dispatch now):\n    gt-aaa\n",
+		"Wave 2 (staged):",
+		"gt-bbb  ← gt-aaa\n",
+		"gt-ccc  ← bd-xyz (outside batch)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderDispatchPlan missing %q:\n%s", want, got)
+		}
+	}
+
+	empty := renderDispatchPlan(&convoyops.DispatchPlan{Waves: [][]string{nil, {"gt-ccc"}}})
+	if !strings.Contains(empty, "(none") {
+		t.Errorf("empty first wave not explained:\n%s", empty)
+	}
+}
+
+func TestStageSlingFormula_RecordsFormulaAndVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows")
+	}
+
+	binDir := t.TempDir()
+	townRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(townRoot, ".beads"), 0755); err != nil {
+		t.Fatalf("mkdir .beads: %v", err)
+	}
+	updateLog := filepath.Join(townRoot, "bd-update.log")
+
+	bdScript := `#!/bin/sh
+cmd="$1"
+if [ "$cmd" = "--allow-stale" ]; then
+  shift || true
+  cmd="$1"
+fi
+shift || true
+case "$cmd" in
+  show)
+    cat <<'JSON'
+[{"id":"gt-b2","title":"Blocked","status":"open","description":"Do the thing.\nsling_formula: old-formula"}]
+JSON
+    ;;
+  update)
+    printf '%s\n' "$@" > "` + updateLog + `"
+    ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(bdScript), 0755); err != nil {
+		t.Fatalf("write bd stub: %v", err)
+	}
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	if err := stageSlingFormula(townRoot, "gt-b2", "mol-review", []string{"depth=2"}); err != nil {
+		t.Fatalf("stageSlingFormula: %v", err)
+	}
+
+	data, err := os.ReadFile(updateLog)
+	if err != nil {
+		t.Fatalf("update not called: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{"Do the thing.", "sling_formula: mol-review", "sling_vars:", "depth=2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("update description missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "old-formula") {
+		t.Errorf("update description kept the old formula:\n%s", got)
+	}
+}
+
+// TestRunAutoResolvedBatchSling_SchedulesOutsideWindow verifies that a batch
+// whose rig is resolved from bead prefixes honours that rig's dispatch window.
+func TestRunAutoResolvedBatchSling_SchedulesOutsideWindow(t *testing.T) {
+	townRoot := t.TempDir()
+	beadsDir := filepath.Join(townRoot, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	routesContent := `{"prefix":"gt-","path":"gastown/.beads"}` + "\n"
+	if err := os.WriteFile(filepath.Join(beadsDir, "routes.jsonl"), []byte(routesContent), 0644); err != nil {
+		t.Fatalf("write routes: %v", err)
+	}
+
+	// Black out today and tomorrow so the window stays closed across midnight.
+	now := time.Now().UTC()
+	settings := config.NewTownSettings()
+	settings.Scheduler = &capacity.SchedulerConfig{
+		RigWindows: map[string]*capacity.DispatchSchedule{
+			"gastown": {Timezone: "UTC", Blackouts: []string{
+				now.Format("2006-01-02"), now.AddDate(0, 0, 1).Format("2006-01-02"),
+			}},
+		},
+	}
+	if err := config.SaveTownSettings(config.TownSettingsPath(townRoot), settings); err != nil {
+		t.Fatalf("SaveTownSettings: %v", err)
+	}
+
+	prevDryRun := slingDryRun
+	slingDryRun = true
+	t.Cleanup(func() { slingDryRun = prevDryRun })
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runAutoResolvedBatchSling([]string{"gt-aaa", "gt-bbb"}, townRoot, beadsDir)
+	})
+	if err != nil {
+		t.Fatalf("runAutoResolvedBatchSling: %v", err)
+	}
+	if !strings.Contains(out, "Would schedule 2 beads to rig 'gastown'") {
+		t.Errorf("expected beads to be scheduled outside the window, got:\n%s", out)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/style"
 )
 
@@ -245,7 +246,8 @@ func executeSling(params SlingParams) (*SlingResult, error) {
 		// rig-targeted dispatch (batch sling + queue dispatch), where a fresh
 		// polecat must be spawned. The single-sling path (runSling) handles
 		// the --create flag for non-rig targets via resolveTarget.
-		Create: true,
+		Create:       true,
+		Capabilities: capacity.CapabilityNeedsFromLabels(info.Labels),
 	}
 	spawnInfo, err := spawnPolecatForSling(params.RigName, spawnOpts)
 	if err != nil {
@@ -295,6 +295,14 @@ func runSlingFormula(ctx context.Context, args []string) (err error) {
 	}
 	townBeadsDir := filepath.Join(townRoot, ".beads")
 
+	// Check typed --var values up front; prompt for missing required inputs
+	// on a TTY instead of failing inside bd mol wisp.
+	answers, err := checkSlingFormulaInputs(formulaName, townRoot, slingVars, nil, !slingDryRun)
+	if err != nil {
+		return err
+	}
+	slingVars = append(slingVars, answers...)
+
 	// Resolve target using shared dispatch logic
 	var target string
 	if len(args) > 1 {
@@ -458,9 +466,16 @@ func runSlingFormula(ctx context.Context, args []string) (err error) {
 		}
 	}
 
+	// A version pinned by gt formula install is cooked and instantiated by path.
+	formulaRef, err := installedFormulaRef(formulaName, formulaWorkDir, townRoot)
+	if err != nil {
+		rollbackSpawned("")
+		return err
+	}
+
 	// Step 1: Cook the formula (ensures proto exists)
 	fmt.Printf("  Cooking formula...\n")
-	if err := BdCmd("cook", formulaName).
+	if err := BdCmd("cook", formulaRef).
 		Dir(formulaWorkDir).
 		WithGTRoot(townRoot).
 		Run(); err != nil {
@@ -472,7 +487,7 @@ func runSlingFormula(ctx context.Context, args []string) (err error) {
 
 	// Step 2: Create wisp instance (ephemeral)
 	fmt.Printf("  Creating wisp...\n")
-	wispArgs := []string{"mol", "wisp", formulaName}
+	wispArgs := []string{"mol", "wisp", formulaRef}
 	for _, v := range slingVars {
 		wispArgs = append(wispArgs, "--var", v)
 	}
@@ -98,6 +98,7 @@ type beadInfo struct {
 	Labels       []string         `json:"labels,omitempty"`
 	Dependencies []beads.IssueDep `json:"dependencies,omitempty"`
 	IssueType    string           `json:"issue_type,omitempty"`
+	Priority     int              `json:"priority"`
 }
 
 // isDeferredBead checks whether a bead should be rejected from slinging because
@@ -1034,12 +1035,21 @@ func InstantiateFormulaOnBead(ctx context.Context, formulaName, beadID, title, h
 	// If cook fails, retry with the embedded formula extracted to a temp file.
 	// This handles non-gastown rigs that don't have formulas provisioned on disk.
 	// See gt-oir.
-	resolvedFormula := formulaName
+	// A version pinned by gt formula install is cooked and bonded by path.
+	resolvedFormula, err := installedFormulaRef(formulaName, formulaWorkDir, townRoot)
+	if err != nil {
+		return nil, err
+	}
+	pinned := resolvedFormula != formulaName
 	var formulaCleanup func()
 	if !skipCook {
-		if err := formulaBeadBdCmd(beadID, formulaWorkDir, townRoot, "cook", formulaName).
+		if err := formulaBeadBdCmd(beadID, formulaWorkDir, townRoot, "cook", resolvedFormula).
 			WithAutoCommit().
 			Run(); err != nil {
+			if pinned {
+				telemetry.RecordMolCook(ctx, formulaName, err)
+				return nil, fmt.Errorf("cooking formula %s: %w", formulaName, err)
+			}
 			// Retry with embedded formula
 			resolvedFormula, formulaCleanup = resolveFormulaToTempFile(formulaName)
 			if formulaCleanup != nil {
@@ -1193,7 +1203,18 @@ func ensureFormulaRequiredVars(formulaName string, vars []string) []string {
 // townRoot is required for GT_ROOT so bd can find town-level formulas.
 // Falls back to embedded formula extraction if bd can't find the formula on disk.
 func CookFormula(formulaName, workDir, townRoot string) error {
-	err := BdCmd("cook", formulaName).
+	pinned, err := installedFormulaRef(formulaName, workDir, townRoot)
+	if err != nil {
+		return err
+	}
+	if pinned != formulaName {
+		return BdCmd("cook", pinned).
+			Dir(workDir).
+			WithAutoCommit().
+			WithGTRoot(townRoot).
+			Run()
+	}
+	err = BdCmd("cook", formulaName).
 		Dir(workDir).
 		WithAutoCommit().
 		WithGTRoot(townRoot).
@@ -1216,6 +1237,35 @@ func CookFormula(formulaName, workDir, townRoot string) error {
 		Run()
 }
 
+// installedFormulaRef returns the file of the version of formulaName pinned
+// by gt formula install, or formulaName itself when none is installed. bd
+// resolves bare names against its own search paths and never reads the
+// lockfile, so a pinned install must be passed to bd cook and bd mol wisp
+// as a path. The search order matches findFormulaFile, rooted at workDir
+// instead of the current directory.
+func installedFormulaRef(formulaName, workDir, townRoot string) (string, error) {
+	var dirs []string
+	if workDir != "" {
+		dirs = append(dirs, filepath.Join(workDir, ".beads", "formulas"))
+	}
+	if townRoot != "" {
+		dirs = append(dirs, filepath.Join(townRoot, ".beads", "formulas"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".beads", "formulas"))
+	}
+	for _, dir := range dirs {
+		path, err := formula.InstalledFormulaPath(dir, formulaName)
+		if err != nil {
+			return "", err
+		}
+		if path != "" {
+			return path, nil
+		}
+	}
+	return formulaName, nil
+}
+
 // resolveFormulaToTempFile extracts an embedded formula to a temp file.
 // Returns the temp file path and a cleanup function, or the original name
 // if extraction fails. Used as a fallback when bd can't find the formula on disk.
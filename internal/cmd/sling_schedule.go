@@ -43,6 +43,32 @@ func shouldDeferDispatch() (bool, error) {
 	return false, nil // -1 or 0 = direct dispatch
 }
 
+// outsideDispatchWindow reports whether rigName is currently outside its
+// dispatch window (town or rig). Used in direct-dispatch mode: beads slung
+// outside a window are queued for the scheduler instead of spawning a polecat
+// immediately, and the daemon dispatches them once the window opens.
+// Returns (false, nil) when no windows are configured.
+func outsideDispatchWindow(townRoot, rigName string) (bool, error) {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return false, fmt.Errorf("loading town settings: %w", err)
+	}
+	if !settings.Scheduler.HasDispatchWindows() {
+		return false, nil
+	}
+	windows, err := capacity.CompileDispatchWindows(settings.Scheduler)
+	if err != nil {
+		return false, fmt.Errorf("invalid dispatch windows (dispatch blocked — fix scheduler.windows in settings/config.json): %w", err)
+	}
+	now := time.Now()
+	if windows.IsOpen(rigName, now) {
+		return false, nil
+	}
+	fmt.Printf("%s %s is outside its dispatch window (%s); scheduling instead of dispatching\n",
+		style.Dim.Render("⏸"), rigName, formatNextWindow(windows, rigName, now))
+	return true, nil
+}
+
 // ScheduleOptions holds options for scheduling a bead.
 type ScheduleOptions struct {
 	Formula      string   // Formula to apply at dispatch time (e.g., "mol-polecat-work")
@@ -61,6 +87,8 @@ type ScheduleOptions struct {
 	Agent        string   // Agent override (e.g., "gemini", "codex")
 	HookRawBead  bool     // Hook raw bead without default formula
 	Ralph        bool     // Ralph Wiggum loop mode
+	Deadline     string   // Dispatch deadline (RFC3339 or duration from now)
+	SLA          string   // Dispatch SLA measured from enqueue time (Go duration)
 }
 
 // scheduleBead schedules a bead for deferred dispatch via the capacity scheduler.
@@ -128,6 +156,17 @@ func scheduleBead(beadID, rigName string, opts ScheduleOptions) error {
 		return fmt.Errorf("bead %s is already %s to %s\nUse --force to override", beadID, info.Status, info.Assignee)
 	}
 
+	now := time.Now().UTC()
+	deadline, err := resolveDeadlineFlag(opts.Deadline, now)
+	if err != nil {
+		return err
+	}
+	if opts.SLA != "" {
+		if d, err := time.ParseDuration(opts.SLA); err != nil || d <= 0 {
+			return fmt.Errorf("invalid --sla %q: expected positive Go duration (e.g. 15m, 4h)", opts.SLA)
+		}
+	}
+
 	if opts.Formula != "" {
 		if err := verifyFormulaExists(opts.Formula, filepath.Dir(rigBeadsDir), townRoot); err != nil {
 			return fmt.Errorf("formula %q not found: %w", opts.Formula, err)
@@ -156,7 +195,9 @@ func scheduleBead(beadID, rigName string, opts ScheduleOptions) error {
 		Version:    1,
 		WorkBeadID: beadID,
 		TargetRig:  rigName,
-		EnqueuedAt: time.Now().UTC().Format(time.RFC3339),
+		EnqueuedAt: now.Format(time.RFC3339),
+		Deadline:   deadline,
+		SLA:        opts.SLA,
 	}
 	if opts.Formula != "" {
 		fields.Formula = opts.Formula
@@ -224,6 +265,22 @@ func scheduleBead(beadID, rigName string, opts ScheduleOptions) error {
 	return nil
 }
 
+// resolveDeadlineFlag normalizes a --deadline value to RFC3339 UTC.
+// Accepts an absolute RFC3339 time or a positive duration relative to now
+// (e.g. "2h"). Empty input means no deadline.
+func resolveDeadlineFlag(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d).UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("invalid --deadline %q: expected RFC3339 time (2026-01-02T15:04:05Z) or duration from now (e.g. 2h)", value)
+}
+
 // runBatchSchedule schedules multiple beads for deferred dispatch.
 // Returns error when all schedule attempts fail.
 func runBatchSchedule(beadIDs []string, rigName, townRoot string) error {
@@ -257,6 +314,8 @@ func runBatchSchedule(beadIDs []string, rigName, townRoot string) error {
 			Agent:        slingAgent,
 			HookRawBead:  slingHookRawBead,
 			Ralph:        slingRalph,
+			Deadline:     slingDeadline,
+			SLA:          slingSLA,
 		})
 		if err != nil {
 			fmt.Printf("  %s %s: %v\n", style.Dim.Render("✗"), beadID, err)
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -129,6 +130,10 @@ type ResolveTargetOptions struct {
 	BaseBranch           string // Override base branch for polecat worktree
 	ResumeBranch         string // Existing branch to resume (e.g. PR head); mutually exclusive with BaseBranch
 	SkipPolecatAdmission bool   // Caller already holds a capacity reservation
+
+	// Capabilities are the bead's cap:/cap-pref: labels, used to pick the
+	// polecat and agent preset when spawning into a rig.
+	Capabilities capacity.CapabilityNeeds
 }
 
 // ResolvedTarget holds the results of target resolution.
@@ -241,6 +246,7 @@ func resolveTarget(target string, opts ResolveTargetOptions) (*ResolvedTarget, e
 			BaseBranch:    opts.BaseBranch,
 			ResumeBranch:  opts.ResumeBranch,
 			SkipAdmission: opts.SkipPolecatAdmission,
+			Capabilities:  opts.Capabilities,
 		}
 		spawnInfo, err := spawnPolecatForSling(rigName, spawnOpts)
 		if err != nil {
@@ -283,6 +289,7 @@ func resolveTarget(target string, opts ResolveTargetOptions) (*ResolvedTarget, e
 				BaseBranch:    opts.BaseBranch,
 				ResumeBranch:  opts.ResumeBranch,
 				SkipAdmission: opts.SkipPolecatAdmission,
+				Capabilities:  opts.Capabilities,
 			}
 			spawnInfo, spawnErr := spawnPolecatForSling(rigName, spawnOpts)
 			if spawnErr != nil {
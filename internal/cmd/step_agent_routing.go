@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// stepAgentCandidate is an agent preset a workflow step could run on.
+type stepAgentCandidate struct {
+	Name  string
+	Model string // As passed to --model; "" when unknown
+}
+
+// runtimeConfigModel returns the model an agent config runs: its --model
+// (or -m) argument in either "--model X" or "--model=X" form, or else the
+// default_model of its agent preset.
+func runtimeConfigModel(rc *config.RuntimeConfig) string {
+	if rc == nil {
+		return ""
+	}
+	for i, arg := range rc.Args {
+		switch {
+		case (arg == "--model" || arg == "-m") && i+1 < len(rc.Args):
+			return rc.Args[i+1]
+		case strings.HasPrefix(arg, "--model="):
+			return strings.TrimPrefix(arg, "--model=")
+		case strings.HasPrefix(arg, "-m="):
+			return strings.TrimPrefix(arg, "-m=")
+		}
+	}
+	command := string(config.DefaultAgentPreset())
+	if rc.Command != "" {
+		command = filepath.Base(rc.Command)
+	}
+	// A custom agent without its own default falls back to the preset of
+	// the binary it wraps. Provider is not consulted: it defaults to claude
+	// for any command.
+	for _, name := range []string{rc.ResolvedAgent, command} {
+		if name == "" {
+			continue
+		}
+		if preset := config.GetAgentPresetByName(name); preset != nil && preset.DefaultModel != "" {
+			return preset.DefaultModel
+		}
+	}
+	return ""
+}
+
+// stepAgentSatisfies reports whether a candidate meets a step's model and
+// min_capability constraints.
+func stepAgentSatisfies(c stepAgentCandidate, model, minCapability string) bool {
+	if model != "" && formula.ModelFamily(c.Model) != formula.ModelFamily(model) {
+		return false
+	}
+	if minCapability != "" && formula.CapabilityRank(formula.ModelCapability(c.Model)) < formula.CapabilityRank(minCapability) {
+		return false
+	}
+	return true
+}
+
+// selectStepAgent picks the agent for a workflow step. An explicit step agent
+// always wins. Otherwise the role default (which follows the cost tier) is
+// kept when it satisfies the constraints, and the least capable satisfying
+// candidate is chosen when it does not. Returns the role default and false
+// when no candidate qualifies.
+func selectStepAgent(fields *beads.StepFields, candidates []stepAgentCandidate, roleDefault string) (string, bool) {
+	if fields.Agent != "" {
+		return fields.Agent, true
+	}
+	if fields.Model == "" && fields.MinCapability == "" {
+		return roleDefault, true
+	}
+	var matches []stepAgentCandidate
+	for _, c := range candidates {
+		if !stepAgentSatisfies(c, fields.Model, fields.MinCapability) {
+			continue
+		}
+		if c.Name == roleDefault {
+			return roleDefault, true
+		}
+		matches = append(matches, c)
+	}
+	if len(matches) == 0 {
+		return roleDefault, false
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		ri := formula.CapabilityRank(formula.ModelCapability(matches[i].Model))
+		rj := formula.CapabilityRank(formula.ModelCapability(matches[j].Model))
+		if ri != rj {
+			return ri < rj
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches[0].Name, true
+}
+
+// loadStepAgentCandidates lists the agents a step can be routed to: the
+// town's and rig's custom agents (including cost tier presets such as
+// claude-sonnet) plus the built-in claude preset.
+func loadStepAgentCandidates(townRoot, rigPath string) []stepAgentCandidate {
+	names := map[string]bool{"claude": true}
+	if ts, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot)); err == nil {
+		for name := range ts.Agents {
+			names[name] = true
+		}
+	}
+	if rigPath != "" {
+		if rs, err := config.LoadRigSettings(config.RigSettingsPath(rigPath)); err == nil {
+			for name := range rs.Agents {
+				names[name] = true
+			}
+		}
+	}
+	candidates := make([]stepAgentCandidate, 0, len(names))
+	for name := range names {
+		rc := config.ResolveAgentConfigByName(name, townRoot, rigPath)
+		candidates = append(candidates, stepAgentCandidate{Name: name, Model: runtimeConfigModel(rc)})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	return candidates
+}
+
+// routeWorkflowStepAgent applies a workflow step's agent constraints when it
+// is slung to a rig's polecat pool, and records the agent and model it runs
+// on (fields.RanAgent, fields.RanModel) for gt mol status. An explicit
+// --agent always wins. Returns the agent to dispatch with ("" = role
+// default). Non-rig targets are left alone.
+func routeWorkflowStepAgent(townRoot, target, agent string, fields *beads.StepFields) string {
+	rigName, isRig := IsRigName(target)
+	if !isRig {
+		return agent
+	}
+	rigPath := filepath.Join(townRoot, rigName)
+	roleDefault, _ := config.ResolveRoleAgentName("polecat", townRoot, rigPath)
+	ran := agent
+	if agent == "" {
+		chosen, ok := selectStepAgent(fields, loadStepAgentCandidates(townRoot, rigPath), roleDefault)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "  %s no configured agent satisfies step %s (%s); dispatching anyway with %s\n",
+				style.Warning.Render("⚠"), fields.Step, describeStepConstraints(fields), roleDefault)
+		}
+		if chosen != roleDefault {
+			agent = chosen
+		}
+		ran = chosen
+	}
+	if agent != "" {
+		fmt.Printf("  Step agent: %s%s\n", agent, style.Dim.Render(" ("+describeStepConstraints(fields)+")"))
+	}
+	fields.RanAgent = ran
+	fields.RanModel = runtimeConfigModel(config.ResolveAgentConfigByName(ran, townRoot, rigPath))
+	return agent
+}
+
+// describeStepConstraints renders a step's agent constraints for messages.
+func describeStepConstraints(fields *beads.StepFields) string {
+	var parts []string
+	if fields.Agent != "" {
+		parts = append(parts, "agent "+fields.Agent)
+	}
+	if fields.Model != "" {
+		parts = append(parts, "model "+fields.Model)
+	}
+	if fields.MinCapability != "" {
+		parts = append(parts, "min_capability "+fields.MinCapability)
+	}
+	if len(parts) == 0 {
+		return "no constraints"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestSelectStepAgent(t *testing.T) {
+	candidates := []stepAgentCandidate{
+		{Name: "claude", Model: "opus"},
+		{Name: "claude-haiku", Model: "haiku"},
+		{Name: "claude-sonnet", Model: "sonnet[1m]"},
+		{Name: "groq-compound", Model: "groq/compound-beta"},
+	}
+	tests := []struct {
+		name        string
+		fields      beads.StepFields
+		roleDefault string
+		want        string
+		wantOK      bool
+	}{
+		{"no constraints keeps default", beads.StepFields{}, "claude-sonnet", "claude-sonnet", true},
+		{"explicit agent wins", beads.StepFields{Agent: "codex"}, "claude", "codex", true},
+		{"model picks matching agent", beads.StepFields{Model: "haiku"}, "claude", "claude-haiku", true},
+		{"model matched by default", beads.StepFields{Model: "claude-opus-4-5"}, "claude", "claude", true},
+		{"default meets capability", beads.StepFields{MinCapability: "basic"}, "claude-sonnet", "claude-sonnet", true},
+		{"cheapest sufficient agent", beads.StepFields{MinCapability: "standard"}, "groq-compound", "claude-sonnet", true},
+		{"unknown model falls back", beads.StepFields{Model: "gpt-5"}, "claude-sonnet", "claude-sonnet", false},
+		{"unsatisfiable combination", beads.StepFields{Model: "haiku", MinCapability: "advanced"}, "claude", "claude", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := selectStepAgent(&tt.fields, candidates, tt.roleDefault)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("selectStepAgent = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRuntimeConfigModel(t *testing.T) {
+	config.ResetRegistryForTesting()
+	t.Cleanup(config.ResetRegistryForTesting)
+	config.RegisterAgentForTesting("claude-fast", config.AgentPresetInfo{
+		Name:         "claude-fast",
+		Command:      "claude",
+		DefaultModel: "haiku",
+	})
+
+	tests := []struct {
+		rc   *config.RuntimeConfig
+		want string
+	}{
+		{nil, ""},
+		{&config.RuntimeConfig{Command: "claude"}, "opus"},
+		{&config.RuntimeConfig{Command: "claude", Args: []string{"--model", "haiku"}}, "haiku"},
+		{&config.RuntimeConfig{Command: "gemini", Args: []string{"-m", "gemini-2.5-pro"}}, "gemini-2.5-pro"},
+		{&config.RuntimeConfig{Command: "codex"}, ""},
+		{&config.RuntimeConfig{Command: "claude", Args: []string{"--model=sonnet"}}, "sonnet"},
+		{&config.RuntimeConfig{Command: "gemini", Args: []string{"-m=gemini-2.5-flash"}}, "gemini-2.5-flash"},
+		{&config.RuntimeConfig{Command: "/usr/local/bin/claude"}, "opus"},
+		{&config.RuntimeConfig{Command: "claude", ResolvedAgent: "claude-fast"}, "haiku"},
+		{&config.RuntimeConfig{Command: "aider", Provider: "claude"}, ""},
+	}
+	for _, tt := range tests {
+		if got := runtimeConfigModel(tt.rc); got != tt.want {
+			t.Errorf("runtimeConfigModel(%+v) = %q, want %q", tt.rc, got, tt.want)
+		}
+	}
+}
@@ -7,11 +7,12 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // logUsagePath is the JSONL file where command usage is recorded.
 // Location: $GT_HOME/.gt when GT_HOME is set, otherwise ~/.gt.
-var logUsagePath = filepath.Join(gtDataDir(), "cmd-usage.jsonl")
+var logUsagePath = filepath.Join(util.GTDataDir(), "cmd-usage.jsonl")
 
 // noLogCommands are top-level commands excluded from telemetry.
 // These fire per-tool-use and would dominate the log.
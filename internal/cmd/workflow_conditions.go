@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// workflowStepFields builds the step metadata recorded on a workflow step
+// bead. Formula vars in when/repeat_until are bound to their values now, so
+// the stored conditions only depend on step outputs produced later.
+// The bool result is false when the step's when condition already evaluates
+// false from vars alone; the returned fields then carry the skip reason.
+func workflowStepFields(step formula.Step, vars map[string]string) (*beads.StepFields, bool, error) {
+	fields := &beads.StepFields{
+		Step:          step.ID,
+		Agent:         step.Agent,
+		Model:         step.Model,
+		MinCapability: step.MinCapability,
+		Timeout:       step.Timeout,
+		Retries:       step.Retries,
+		RetryBackoff:  step.RetryBackoff,
+		OnFailure:     step.OnFailure,
+	}
+	if len(step.Outputs) > 0 {
+		fields.OutputTypes = make(map[string]string, len(step.Outputs))
+		for name, out := range step.Outputs {
+			fields.OutputTypes[name] = string(out.Kind())
+		}
+	}
+	if step.When != "" {
+		cond, err := formula.ParseCondition(step.When)
+		if err != nil {
+			return nil, false, fmt.Errorf("step %q when: %w", step.ID, err)
+		}
+		fields.When = cond.Bind(vars)
+		if bound, _ := formula.ParseCondition(fields.When); bound != nil && len(bound.Refs()) == 0 {
+			run, err := bound.Eval(formula.Env{})
+			if err != nil {
+				return nil, false, fmt.Errorf("step %q when: %w", step.ID, err)
+			}
+			if !run {
+				fields.Skipped = "when " + step.When + " is false"
+				return fields, false, nil
+			}
+		}
+	}
+	if step.RepeatUntil != "" {
+		cond, err := formula.ParseCondition(step.RepeatUntil)
+		if err != nil {
+			return nil, false, fmt.Errorf("step %q repeat_until: %w", step.ID, err)
+		}
+		fields.RepeatUntil = cond.Bind(vars)
+		fields.MaxIterations = step.IterationLimit()
+		fields.Iteration = 1
+	}
+	return fields, true, nil
+}
+
+// skipWorkflowStepIfFalse closes a workflow step bead whose when condition
+// evaluates false, recording the reason. Closing unblocks its dependents the
+// same way completing it would. Returns true if the step was skipped.
+func skipWorkflowStepIfFalse(b *beads.Beads, issue *beads.Issue) (bool, error) {
+	fields := beads.ParseStepFields(issue)
+	if fields == nil || fields.When == "" || fields.Skipped != "" {
+		return false, nil
+	}
+	env, err := workflowStepEnv(b, issue, fields)
+	if err != nil {
+		return false, err
+	}
+	run, err := formula.EvalCondition(fields.When, env)
+	if err != nil {
+		return false, fmt.Errorf("evaluating when for %s: %w", issue.ID, err)
+	}
+	if run {
+		return false, nil
+	}
+
+	fields.Skipped = "when " + fields.When + " is false"
+	desc := beads.SetStepFields(issue, fields)
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return false, fmt.Errorf("recording skip on %s: %w", issue.ID, err)
+	}
+	if err := b.CloseWithReason("skipped: "+fields.Skipped, issue.ID); err != nil {
+		return false, fmt.Errorf("closing skipped step %s: %w", issue.ID, err)
+	}
+	fmt.Printf("%s Skipped step %s: %s\n", style.Dim.Render("⊘"), issue.ID, fields.Skipped)
+	return true, nil
+}
+
+// repeatWorkflowStep reopens a finished repeat_until step for another
+// iteration when its loop condition is still false and the bound has not
+// been reached. Returns true if the step was reopened instead of completing.
+func repeatWorkflowStep(b *beads.Beads, issue *beads.Issue) (bool, error) {
+	fields := beads.ParseStepFields(issue)
+	if fields == nil || fields.RepeatUntil == "" {
+		return false, nil
+	}
+	iteration := fields.Iteration
+	if iteration < 1 {
+		iteration = 1
+	}
+	env, err := workflowStepEnv(b, issue, fields)
+	if err != nil {
+		return false, err
+	}
+	step := formula.Step{ID: fields.Step, RepeatUntil: fields.RepeatUntil, MaxIterations: fields.MaxIterations}
+	again, err := step.RepeatAgain(iteration, env)
+	if err != nil {
+		return false, fmt.Errorf("evaluating repeat_until for %s: %w", issue.ID, err)
+	}
+	if !again {
+		return false, nil
+	}
+
+	fields.Iteration = iteration + 1
+	fields.Attempt = 0 // Retries are counted per iteration
+	desc := beads.SetStepFields(issue, fields)
+	status := "open"
+	assignee := ""
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc, Status: &status, Assignee: &assignee}); err != nil {
+		return false, fmt.Errorf("reopening %s for iteration %d: %w", issue.ID, fields.Iteration, err)
+	}
+	fmt.Printf("%s Step %s repeats (iteration %d/%d): %s is still false\n",
+		style.Bold.Render("↻"), issue.ID, fields.Iteration, step.IterationLimit(), fields.RepeatUntil)
+	return true, nil
+}
+
+// prepareWorkflowStep is the gt sling gate for workflow step beads: a step
+// whose when condition is false is closed as skipped instead of being
+// dispatched, and output placeholders in its title and description are filled
+// in from earlier steps. Beads that are not workflow steps are left alone.
+func prepareWorkflowStep(townRoot, beadID string) (bool, error) {
+	b := beads.New(townRoot)
+	issue, err := b.Show(beadID)
+	if err != nil {
+		return false, nil // Not a bead (e.g. a formula name); nothing to gate
+	}
+	if skipped, err := skipWorkflowStepIfFalse(b, issue); err != nil || skipped {
+		return skipped, err
+	}
+	return false, fillWorkflowStepOutputs(b, issue)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestWorkflowStepFields(t *testing.T) {
+	vars := map[string]string{"has_migrations": "false", "strict": "true"}
+	tests := []struct {
+		name        string
+		step        formula.Step
+		wantRun     bool
+		wantWhen    string
+		wantSkipped string
+		wantMax     int
+	}{
+		{
+			name:    "plain step",
+			step:    formula.Step{ID: "build"},
+			wantRun: true,
+		},
+		{
+			name:        "var-only condition decided now",
+			step:        formula.Step{ID: "migrate-db", When: `has_migrations == "true"`},
+			wantRun:     false,
+			wantWhen:    `"false" == "true"`,
+			wantSkipped: `when has_migrations == "true" is false`,
+		},
+		{
+			name:     "output condition deferred with vars bound",
+			step:     formula.Step{ID: "fix", When: `strict && steps.test.outputs.failures > 0`},
+			wantRun:  true,
+			wantWhen: `"true" && (steps.test.outputs.failures > "0")`,
+		},
+		{
+			name:    "loop gets default bound",
+			step:    formula.Step{ID: "review", RepeatUntil: `steps.review.outputs.verdict == "approve"`},
+			wantRun: true,
+			wantMax: formula.DefaultMaxIterations,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, run, err := workflowStepFields(tt.step, vars)
+			if err != nil {
+				t.Fatalf("workflowStepFields: %v", err)
+			}
+			if run != tt.wantRun || fields.When != tt.wantWhen || fields.Skipped != tt.wantSkipped || fields.MaxIterations != tt.wantMax {
+				t.Errorf("got run=%v fields=%+v", run, fields)
+			}
+			if tt.wantMax > 0 && fields.Iteration != 1 {
+				t.Errorf("Iteration = %d, want 1", fields.Iteration)
+			}
+		})
+	}
+}
+
+func TestFormulaStepConditionNote(t *testing.T) {
+	vars := map[string]string{"has_migrations": "false"}
+	skip := formulaStepConditionNote(formula.Step{ID: "m", When: `has_migrations == "true"`}, vars)
+	if !strings.Contains(skip, "Skip this step") {
+		t.Errorf("skip note = %q", skip)
+	}
+	loop := formulaStepConditionNote(formula.Step{ID: "r", RepeatUntil: "done", MaxIterations: 4}, vars)
+	if !strings.Contains(loop, "at most 4 times") {
+		t.Errorf("loop note = %q", loop)
+	}
+	if note := formulaStepConditionNote(formula.Step{ID: "plain"}, vars); note != "" {
+		t.Errorf("plain note = %q, want empty", note)
+	}
+}
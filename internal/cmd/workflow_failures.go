@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Step failure outcomes that are not on_failure policies.
+const stepOutcomeRetry = "retry"
+
+// startWorkflowStep records the dispatch of a workflow step bead by gt sling:
+// it routes the step to an agent matching its constraints, stamps
+// workflow_started_at (the clock for timeout), and counts the attempt.
+// Returns the agent to dispatch with. Beads that are not workflow steps are
+// left alone.
+func startWorkflowStep(townRoot, beadID, target, agent string) string {
+	b := beads.New(townRoot)
+	issue, err := b.Show(beadID)
+	if err != nil {
+		return agent
+	}
+	fields := beads.ParseStepFields(issue)
+	if fields == nil || fields.Step == "" {
+		return agent
+	}
+	agent = routeWorkflowStepAgent(townRoot, target, agent, fields)
+	fields.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	if fields.Attempt < 1 {
+		fields.Attempt = 1
+	}
+	fields.RetryAt = ""
+	desc := beads.SetStepFields(issue, fields)
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		style.PrintWarning("could not record dispatch of step %s: %v", issue.ID, err)
+	}
+	return agent
+}
+
+// hasStepFailurePolicy reports whether a step bead opted into timeout,
+// retries, or on_failure handling.
+func hasStepFailurePolicy(fields *beads.StepFields) bool {
+	return fields.Timeout != "" || fields.Retries > 0 || fields.OnFailure != ""
+}
+
+// planStepFailure decides what happens to a failed workflow step and updates
+// its fields accordingly: another attempt after the retry backoff while
+// retries remain, then the on_failure policy. Returns the outcome (retry,
+// fail-molecule, skip, escalate, or goto:<step>) and the goto target.
+func planStepFailure(fields *beads.StepFields, reason string, now time.Time) (outcome, gotoStep string) {
+	policy := formula.Step{ID: fields.Step, Retries: fields.Retries, RetryBackoff: fields.RetryBackoff}
+	attempt := max(fields.Attempt, 1)
+	fields.StartedAt = ""
+	if attempt <= fields.Retries {
+		fields.Attempt = attempt + 1
+		fields.RetryAt = now.Add(policy.RetryDelay(attempt)).UTC().Format(time.RFC3339)
+		fields.Failure = fmt.Sprintf("attempt %d %s; retry %d/%d at %s", attempt, reason, attempt, fields.Retries, fields.RetryAt)
+		return stepOutcomeRetry, ""
+	}
+
+	fields.RetryAt = ""
+	action, target, err := formula.ParseOnFailure(fields.OnFailure)
+	if err != nil {
+		action = formula.OnFailureFailMolecule
+	}
+	switch action {
+	case formula.OnFailureSkip:
+		fields.Skipped = "failed: " + reason
+		fields.Failure = reason + "; skipped"
+		return formula.OnFailureSkip, ""
+	case "goto":
+		fields.Attempt = 0
+		fields.Failure = reason + "; goto " + target
+		return "goto:" + target, target
+	case formula.OnFailureEscalate:
+		fields.Failure = reason + "; escalated"
+		return formula.OnFailureEscalate, ""
+	default:
+		fields.Failure = reason + "; molecule failed"
+		return formula.OnFailureFailMolecule, ""
+	}
+}
+
+// failWorkflowStep applies a failed step's retry and on_failure policy.
+// Retried, failed, and escalated steps are parked as blocked and unassigned
+// (gt deacon step-timeouts re-dispatches retries when they are due); skipped
+// steps are closed so their dependents proceed; goto reopens the target step
+// and this one so the workflow resumes from the target. The outcome is
+// recorded on the step and posted to the feed.
+func failWorkflowStep(townRoot string, b *beads.Beads, issue *beads.Issue, fields *beads.StepFields, reason, actor string, now time.Time) (string, error) {
+	outcome, gotoStep := planStepFailure(fields, reason, now)
+	desc := beads.SetStepFields(issue, fields)
+	status := "blocked"
+	if gotoStep != "" {
+		status = "open"
+	}
+	unassigned := ""
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc, Status: &status, Assignee: &unassigned}); err != nil {
+		return "", fmt.Errorf("recording failure of %s: %w", issue.ID, err)
+	}
+
+	switch outcome {
+	case formula.OnFailureSkip:
+		if err := b.CloseWithReason("skipped: "+fields.Skipped, issue.ID); err != nil {
+			return "", fmt.Errorf("closing skipped step %s: %w", issue.ID, err)
+		}
+	case formula.OnFailureEscalate:
+		msg := fmt.Sprintf("Workflow step %s (%s) failed: %s", fields.Step, issue.ID, reason)
+		if err := exec.Command("gt", "escalate", "--severity", "high", "--reason", "step-failed", msg).Run(); err != nil {
+			style.PrintWarning("escalating failed step %s: %v", issue.ID, err)
+		}
+	case formula.OnFailureFailMolecule:
+		if fields.Workflow != "" {
+			_ = b.AddComment(fields.Workflow, fmt.Sprintf("Workflow failed: step %s (%s) %s", fields.Step, issue.ID, reason))
+		}
+	default:
+		if gotoStep != "" {
+			if err := rerunWorkflowStep(townRoot, b, fields.Workflow, gotoStep); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	_ = events.LogFeed(events.TypeStepFailed, actor,
+		events.StepFailedPayload(issue.ID, fields.Step, fields.Workflow, reason, outcome))
+	fmt.Printf("%s Step %s (%s) %s: %s\n", style.Bold.Render("✗"), fields.Step, issue.ID, reason, outcome)
+	return outcome, nil
+}
+
+// timeOutWorkflowStep fails a step that ran past its timeout. The agent
+// still holding the step is released first, so a hung polecat can neither
+// keep working the step nor close it with a late gt done once it is retried
+// or handed on.
+func timeOutWorkflowStep(townRoot string, b *beads.Beads, issue *beads.Issue, fields *beads.StepFields, reason string, now time.Time) (string, error) {
+	if issue.Assignee != "" {
+		releaseStepHolderFn(townRoot, issue.Assignee)
+	}
+	return failWorkflowStep(townRoot, b, issue, fields, reason, "deacon", now)
+}
+
+// releaseStepHolderFn is a seam for tests. Production uses releaseStepHolder.
+var releaseStepHolderFn = releaseStepHolder
+
+// releaseStepHolder stops the session of the polecat assigned to a step and
+// clears hook_bead on its agent bead. Crew sessions are persistent and are
+// left running; the caller unassigns the step from them.
+func releaseStepHolder(townRoot, assignee string) {
+	sessionName, persistent := assigneeToSessionName(assignee)
+	if sessionName == "" || persistent {
+		return
+	}
+	t := tmux.NewTmux()
+	if alive, err := t.HasSession(sessionName); err == nil && alive {
+		if err := t.KillSessionWithProcesses(sessionName); err != nil {
+			style.PrintWarning("stopping %s: %v", sessionName, err)
+		} else {
+			fmt.Printf("%s Stopped %s (%s)\n", style.Bold.Render("■"), sessionName, assignee)
+		}
+	}
+	if agentBeadID := agentIDToBeadID(assignee, townRoot); agentBeadID != "" {
+		emptyHook := ""
+		if err := beads.New(townRoot).ForAgentBead().UpdateAgentDescriptionFields(agentBeadID, beads.AgentFieldUpdates{HookBead: &emptyHook}); err != nil {
+			style.PrintWarning("clearing hook_bead on %s: %v", agentBeadID, err)
+		}
+	}
+}
+
+// failWorkflowStepOnEscalate treats gt done --status ESCALATED on a workflow
+// step with a failure policy as a failure of that step. Returns true when the
+// policy handled the step, in which case gt done must not close it.
+func failWorkflowStepOnEscalate(townRoot string, b *beads.Beads, issue *beads.Issue, actor string) (bool, error) {
+	fields := beads.ParseStepFields(issue)
+	if fields == nil || !hasStepFailurePolicy(fields) {
+		return false, nil
+	}
+	if _, err := failWorkflowStep(townRoot, b, issue, fields, "escalated by "+actor, actor, time.Now()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// findWorkflowStepBead finds the bead for formula step stepID in a workflow.
+func findWorkflowStepBead(townRoot string, b *beads.Beads, workflowID, stepID string) (*beads.Issue, *beads.StepFields, error) {
+	tracked, err := getTrackedIssues(filepath.Join(townRoot, ".beads"), workflowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range tracked {
+		issue, err := b.Show(t.ID)
+		if err != nil {
+			continue
+		}
+		if fields := beads.ParseStepFields(issue); fields != nil && fields.Step == stepID {
+			return issue, fields, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("workflow %s has no step %q", workflowID, stepID)
+}
+
+// rerunWorkflowStep reopens a workflow step (for on_failure goto) with fresh
+// failure state and dispatches it again.
+func rerunWorkflowStep(townRoot string, b *beads.Beads, workflowID, stepID string) error {
+	issue, fields, err := findWorkflowStepBead(townRoot, b, workflowID, stepID)
+	if err != nil {
+		return err
+	}
+	fields.Skipped, fields.Failure, fields.RetryAt, fields.StartedAt = "", "", "", ""
+	fields.Attempt = 0
+	desc := beads.SetStepFields(issue, fields)
+	status := "open"
+	unassigned := ""
+	if err := b.Update(issue.ID, beads.UpdateOptions{Description: &desc, Status: &status, Assignee: &unassigned}); err != nil {
+		return fmt.Errorf("reopening step %s: %w", issue.ID, err)
+	}
+	return slingWorkflowStep(townRoot, issue.ID)
+}
+
+// slingWorkflowStep dispatches a step bead to its rig with gt sling; the
+// step's workflow_target, if any, is applied by gt sling itself.
+func slingWorkflowStep(townRoot, beadID string) error {
+	rig := beads.GetRigNameForPrefix(townRoot, beads.ExtractPrefix(beadID))
+	if rig == "" {
+		return fmt.Errorf("cannot resolve rig for step %s", beadID)
+	}
+	cmd := exec.Command("gt", "sling", beadID, rig, "--no-convoy")
+	cmd.Dir = townRoot
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("dispatching step %s: %w", beadID, err)
+	}
+	return nil
+}
+
+// Step policy checks made by gt deacon step-timeouts.
+const (
+	stepTimedOut = "timeout"
+	stepRetryDue = "retry"
+)
+
+// stepPolicyDue reports whether a workflow step needs attention: a running
+// step (hooked or in_progress) past its timeout, or a failed step whose retry
+// time has come. Returns the check that fired and, for timeouts, the failure
+// reason.
+func stepPolicyDue(fields *beads.StepFields, status string, now time.Time) (check, reason string) {
+	switch status {
+	case "hooked", "in_progress":
+		timeout := (&formula.Step{Timeout: fields.Timeout}).TimeoutDuration()
+		started, err := time.Parse(time.RFC3339, fields.StartedAt)
+		if timeout > 0 && err == nil && now.Sub(started) > timeout {
+			return stepTimedOut, "timed out after " + fields.Timeout
+		}
+	case "blocked":
+		retryAt, err := time.Parse(time.RFC3339, fields.RetryAt)
+		if err == nil && !now.Before(retryAt) {
+			return stepRetryDue, ""
+		}
+	}
+	return "", ""
+}
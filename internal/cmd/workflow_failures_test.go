@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestPlanStepFailure(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		fields      beads.StepFields
+		wantOutcome string
+		wantGoto    string
+		wantAttempt int
+		wantRetryAt string
+	}{
+		{"first retry", beads.StepFields{Retries: 2, RetryBackoff: "5m", Attempt: 1}, "retry", "", 2, "2026-03-01T12:05:00Z"},
+		{"backoff doubles", beads.StepFields{Retries: 2, RetryBackoff: "5m", Attempt: 2}, "retry", "", 3, "2026-03-01T12:10:00Z"},
+		{"retries exhausted", beads.StepFields{Retries: 2, Attempt: 3}, "fail-molecule", "", 3, ""},
+		{"skip", beads.StepFields{OnFailure: "skip", Attempt: 1}, "skip", "", 1, ""},
+		{"escalate", beads.StepFields{OnFailure: "escalate"}, "escalate", "", 0, ""},
+		{"goto", beads.StepFields{OnFailure: "goto:plan", Attempt: 1}, "goto:plan", "plan", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := tt.fields
+			fields.StartedAt = "2026-03-01T11:00:00Z"
+			outcome, gotoStep := planStepFailure(&fields, "timed out after 30m", now)
+			if outcome != tt.wantOutcome || gotoStep != tt.wantGoto {
+				t.Errorf("planStepFailure = %q, %q; want %q, %q", outcome, gotoStep, tt.wantOutcome, tt.wantGoto)
+			}
+			if fields.Attempt != tt.wantAttempt || fields.RetryAt != tt.wantRetryAt || fields.StartedAt != "" || fields.Failure == "" {
+				t.Errorf("fields = %+v", fields)
+			}
+			if (outcome == "skip") != (fields.Skipped != "") {
+				t.Errorf("Skipped = %q for outcome %s", fields.Skipped, outcome)
+			}
+		})
+	}
+}
+
+func TestStepPolicyDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		fields    beads.StepFields
+		status    string
+		wantCheck string
+	}{
+		{"running within timeout", beads.StepFields{Timeout: "30m", StartedAt: "2026-03-01T11:45:00Z"}, "hooked", ""},
+		{"running past timeout", beads.StepFields{Timeout: "30m", StartedAt: "2026-03-01T11:00:00Z"}, "in_progress", stepTimedOut},
+		{"no timeout", beads.StepFields{StartedAt: "2026-03-01T01:00:00Z"}, "hooked", ""},
+		{"open step not timed", beads.StepFields{Timeout: "30m", StartedAt: "2026-03-01T11:00:00Z"}, "open", ""},
+		{"retry not yet due", beads.StepFields{RetryAt: "2026-03-01T12:05:00Z"}, "blocked", ""},
+		{"retry due", beads.StepFields{RetryAt: "2026-03-01T11:59:00Z"}, "blocked", stepRetryDue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if check, _ := stepPolicyDue(&tt.fields, tt.status, now); check != tt.wantCheck {
+				t.Errorf("stepPolicyDue = %q, want %q", check, tt.wantCheck)
+			}
+		})
+	}
+}
+
+func TestTimeOutWorkflowStep_ReleasesHolderBeforeFailing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX bd stub")
+	}
+	townRoot := t.TempDir()
+	logPath := filepath.Join(townRoot, "calls.log")
+	binDir := t.TempDir()
+	writeBDStub(t, binDir, `#!/usr/bin/env sh
+echo "bd $*" >> "`+logPath+`"
+cat >/dev/null
+`, "")
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	prev := releaseStepHolderFn
+	t.Cleanup(func() { releaseStepHolderFn = prev })
+	releaseStepHolderFn = func(_, assignee string) {
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		fmt.Fprintf(f, "release %s\n", assignee)
+	}
+
+	issue := &beads.Issue{ID: "gt-step1", Status: "hooked", Assignee: "gastown/polecats/nux"}
+	fields := &beads.StepFields{Step: "build", Timeout: "30m", Retries: 1, Attempt: 1, StartedAt: "2026-03-01T11:00:00Z"}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	outcome, err := timeOutWorkflowStep(townRoot, beads.NewIsolated(townRoot), issue, fields, "timed out after 30m", now)
+	if err != nil {
+		t.Fatalf("timeOutWorkflowStep: %v", err)
+	}
+	if outcome != stepOutcomeRetry {
+		t.Errorf("outcome = %q, want %q", outcome, stepOutcomeRetry)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, update := -1, -1
+	for i, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		switch {
+		case line == "release gastown/polecats/nux":
+			release = i
+		case strings.Contains(line, "update gt-step1") && update < 0:
+			update = i
+			if !strings.Contains(line, "--status=blocked") || !strings.Contains(line, "--assignee=") {
+				t.Errorf("step not parked blocked and unassigned: %q", line)
+			}
+		}
+	}
+	if release < 0 || update < 0 || release > update {
+		t.Errorf("holder must be released before the step is updated, calls:\n%s", data)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// stepOutputs collects the outputs recorded on workflow step beads, keyed by
+// formula step ID. The first bead seen for a step ID wins.
+func stepOutputs(issues []*beads.Issue) map[string]map[string]string {
+	outputs := make(map[string]map[string]string)
+	for _, issue := range issues {
+		fields := beads.ParseStepFields(issue)
+		if fields == nil || fields.Step == "" || len(fields.Outputs) == 0 {
+			continue
+		}
+		if _, ok := outputs[fields.Step]; !ok {
+			outputs[fields.Step] = fields.Outputs
+		}
+	}
+	return outputs
+}
+
+// workflowStepEnv builds the condition environment for a workflow step bead:
+// its own outputs plus those recorded by the other steps its workflow root
+// tracks. Formula vars were bound when the step bead was created.
+func workflowStepEnv(b *beads.Beads, issue *beads.Issue, fields *beads.StepFields) (formula.Env, error) {
+	issues := []*beads.Issue{issue}
+	if fields.Workflow != "" {
+		townRoot, err := getTownBeadsDir()
+		if err != nil {
+			return formula.Env{}, err
+		}
+		tracked, err := convoyTrackedBeadIDs(townRoot, fields.Workflow)
+		if err != nil {
+			return formula.Env{}, fmt.Errorf("reading step outputs of %s: %w", fields.Workflow, err)
+		}
+		var ids []string
+		for id := range tracked {
+			if id != issue.ID {
+				ids = append(ids, id)
+			}
+		}
+		siblings, err := b.ShowMultiple(ids)
+		if err != nil {
+			return formula.Env{}, fmt.Errorf("reading step outputs of %s: %w", fields.Workflow, err)
+		}
+		for _, s := range siblings {
+			issues = append(issues, s)
+		}
+	}
+	return formula.Env{Outputs: stepOutputs(issues)}, nil
+}
+
+// fillWorkflowStepOutputs replaces {{steps.<id>.outputs.<name>}} placeholders
+// in a step bead's title and description with the values recorded by earlier
+// steps. Placeholders whose output was never recorded are left in place.
+func fillWorkflowStepOutputs(b *beads.Beads, issue *beads.Issue) error {
+	if len(formula.ExtractOutputRefs(issue.Title+"\n"+issue.Description)) == 0 {
+		return nil
+	}
+	fields := beads.ParseStepFields(issue)
+	if fields == nil {
+		return nil
+	}
+	env, err := workflowStepEnv(b, issue, fields)
+	if err != nil {
+		return err
+	}
+
+	opts := beads.UpdateOptions{}
+	if title := formula.SubstituteOutputs(issue.Title, env); title != issue.Title {
+		opts.Title = &title
+	}
+	if desc := formula.SubstituteOutputs(issue.Description, env); desc != issue.Description {
+		opts.Description = &desc
+	}
+	if opts.Title == nil && opts.Description == nil {
+		return nil
+	}
+	if err := b.Update(issue.ID, opts); err != nil {
+		return fmt.Errorf("filling step outputs on %s: %w", issue.ID, err)
+	}
+	return nil
+}
+
+// recordStepOutput validates value against the step's declared output type
+// and returns the step fields with the value recorded. Relative paths are
+// resolved against the current directory.
+func recordStepOutput(issue *beads.Issue, name, value string) (*beads.StepFields, error) {
+	fields := beads.ParseStepFields(issue)
+	if fields == nil || fields.Step == "" {
+		return nil, fmt.Errorf("%s is not a workflow step (no workflow_step field)", issue.ID)
+	}
+	typ, declared := fields.OutputTypes[name]
+	if !declared {
+		if len(fields.OutputTypes) == 0 {
+			return nil, fmt.Errorf("step %s declares no outputs", fields.Step)
+		}
+		names := make([]string, 0, len(fields.OutputTypes))
+		for n := range fields.OutputTypes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("step %s has no output %q (declared: %s)", fields.Step, name, strings.Join(names, ", "))
+	}
+	normalized, err := formula.OutputType(typ).Normalize(value)
+	if err != nil {
+		return nil, fmt.Errorf("output %q (%s): %w", name, typ, err)
+	}
+	// Paths are recorded absolute so later steps in other worktrees can
+	// tell where they came from.
+	if formula.OutputType(typ) == formula.OutputPath && !filepath.IsAbs(normalized) {
+		if normalized, err = filepath.Abs(normalized); err != nil {
+			return nil, fmt.Errorf("output %q: %w", name, err)
+		}
+	}
+	if fields.Outputs == nil {
+		fields.Outputs = make(map[string]string)
+	}
+	fields.Outputs[name] = normalized
+	return fields, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestRecordStepOutput(t *testing.T) {
+	step := formula.Step{ID: "open-pr", Outputs: map[string]formula.StepOutput{
+		"pr_url": {},
+		"report": {Type: formula.OutputJSON},
+		"notes":  {Type: formula.OutputPath},
+	}}
+	fields, _, err := workflowStepFields(step, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields.Workflow = "hq-wf-abc12"
+	issue := &beads.Issue{ID: "gt-wfs-1", Description: beads.SetStepFields(&beads.Issue{Description: "Open the PR."}, fields)}
+
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	tests := []struct {
+		name, value, want, wantErr string
+	}{
+		{name: "pr_url", value: "https://example.com/pull/7", want: "https://example.com/pull/7"},
+		{name: "report", value: "{ \"failed\": 0 }", want: `{"failed":0}`},
+		{name: "report", value: "{nope", wantErr: "invalid JSON"},
+		{name: "notes", value: "out/notes.md", want: filepath.Join(cwd, "out/notes.md")},
+		{name: "sha", value: "abc", wantErr: "declared: notes, pr_url, report"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			got, err := recordStepOutput(issue, tt.name, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("recordStepOutput error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("recordStepOutput: %v", err)
+			}
+			if got.Outputs[tt.name] != tt.want || got.Workflow != "hq-wf-abc12" {
+				t.Errorf("fields = %+v, want %s=%q", got, tt.name, tt.want)
+			}
+		})
+	}
+
+	if _, err := recordStepOutput(&beads.Issue{ID: "gt-1", Description: "plain"}, "x", "y"); err == nil {
+		t.Error("recording on a non-workflow bead should fail")
+	}
+}
+
+func TestStepOutputs(t *testing.T) {
+	withOutputs := func(id, step string, outputs map[string]string) *beads.Issue {
+		return &beads.Issue{ID: id, Description: beads.FormatStepFields(&beads.StepFields{Step: step, Outputs: outputs})}
+	}
+	issues := []*beads.Issue{
+		withOutputs("gt-wfs-2", "review", map[string]string{"verdict": "approve"}),
+		withOutputs("gt-wfs-1", "open-pr", map[string]string{"pr_url": "https://example.com/pull/7"}),
+		withOutputs("gt-wfs-9", "review", map[string]string{"verdict": "stale"}),
+		{ID: "gt-wfs-3", Description: "workflow_step: merge"},
+	}
+	want := map[string]map[string]string{
+		"review":  {"verdict": "approve"},
+		"open-pr": {"pr_url": "https://example.com/pull/7"},
+	}
+	got := stepOutputs(issues)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("stepOutputs = %v, want %v", got, want)
+	}
+
+	ok, err := formula.EvalCondition(`steps.review.outputs.verdict == "approve"`, formula.Env{Outputs: got})
+	if err != nil || !ok {
+		t.Errorf("condition over collected outputs = %v, %v; want true", ok, err)
+	}
+}
+
+func TestWorkflowStepOutputsNote(t *testing.T) {
+	step := formula.Step{ID: "open-pr", Outputs: map[string]formula.StepOutput{
+		"report": {Type: formula.OutputJSON, Description: "Test summary"},
+		"pr_url": {},
+	}}
+	got := workflowStepDescription(step, "Open the PR.")
+	want := "Open the PR.\n\n## Outputs\n\nRecord before finishing: gt mol step output set <name> <value>\n\n- pr_url (string)\n- report (json): Test summary"
+	if got != want {
+		t.Errorf("workflowStepDescription() = %q, want %q", got, want)
+	}
+	if got := workflowStepDescription(formula.Step{ID: "plain"}, "Do it."); got != "Do it." {
+		t.Errorf("step without outputs = %q", got)
+	}
+}
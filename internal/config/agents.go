@@ -68,6 +68,10 @@ type AgentPresetInfo struct {
 	// Args are the default command-line arguments for autonomous mode.
 	Args []string `json:"args"`
 
+	// DefaultModel is the model the CLI runs when no --model is passed, as a
+	// --model value (e.g., "opus" for claude). Empty means unknown.
+	DefaultModel string `json:"default_model,omitempty"`
+
 	// Env are environment variables to set when starting the agent.
 	// These are merged with the standard GT_* variables.
 	// Used for agent-specific configuration like OPENCODE_PERMISSION.
@@ -232,6 +236,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		Name:                AgentClaude,
 		Command:             "claude",
 		Args:                []string{"--dangerously-skip-permissions"},
+		DefaultModel:        "opus",
 		ProcessNames:        []string{"node", "claude"}, // Claude runs as Node.js
 		SessionIDEnv:        "CLAUDE_SESSION_ID",
 		ResumeFlag:          "--resume",
@@ -236,6 +236,45 @@ func validateRigSettings(c *RigSettings) error {
 			return err
 		}
 	}
+	if c.Sandbox != nil {
+		if err := validateSandboxConfig(c.Sandbox); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrInvalidSandbox indicates an invalid sandbox setting.
+var ErrInvalidSandbox = errors.New("invalid sandbox config")
+
+// validateSandboxConfig validates a SandboxConfig.
+func validateSandboxConfig(c *SandboxConfig) error {
+	switch c.Mode {
+	case "", SandboxModeNone, SandboxModeNamespace:
+	default:
+		return fmt.Errorf("%w: mode %q, want %q or %q", ErrInvalidSandbox, c.Mode, SandboxModeNone, SandboxModeNamespace)
+	}
+	switch c.Backend {
+	case "", SandboxBackendAuto, SandboxBackendBwrap, SandboxBackendNamespace:
+	default:
+		return fmt.Errorf("%w: backend %q, want %q, %q or %q", ErrInvalidSandbox, c.Backend,
+			SandboxBackendAuto, SandboxBackendBwrap, SandboxBackendNamespace)
+	}
+	switch c.Network {
+	case "", SandboxNetworkNone, SandboxNetworkHost:
+	default:
+		return fmt.Errorf("%w: network %q, want %q or %q", ErrInvalidSandbox, c.Network, SandboxNetworkNone, SandboxNetworkHost)
+	}
+	if c.CertTTL != "" {
+		if d, err := time.ParseDuration(c.CertTTL); err != nil || d <= 0 {
+			return fmt.Errorf("%w: cert_ttl %q", ErrInvalidSandbox, c.CertTTL)
+		}
+	}
+	for _, p := range append(append([]string(nil), c.ReadOnlyPaths...), c.WritablePaths...) {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("%w: path %q is not absolute", ErrInvalidSandbox, p)
+		}
+	}
 	return nil
 }
 
@@ -2256,6 +2295,9 @@ func BuildStartupCommand(envVars map[string]string, rigPath, prompt string) stri
 	if len(rc.ExecWrapper) == 0 {
 		rc.ExecWrapper = resolveExecWrapper(rigPath)
 	}
+	// A wrapper error leaves a wrapper that fails at startup; this path
+	// has no error return.
+	rc.ExecWrapper, _ = withSandboxWrapper(rc.ExecWrapper, envVars, rigPath)
 
 	// Copy env vars to avoid mutating caller map
 	resolvedEnv := make(map[string]string, len(envVars)+2)
@@ -2512,6 +2554,11 @@ func BuildStartupCommandWithAgentOverride(envVars map[string]string, rigPath, pr
 	if len(rc.ExecWrapper) == 0 {
 		rc.ExecWrapper = resolveExecWrapper(rigPath)
 	}
+	wrapper, err := withSandboxWrapper(rc.ExecWrapper, envVars, rigPath)
+	if err != nil {
+		return "", err
+	}
+	rc.ExecWrapper = wrapper
 
 	// Copy env vars to avoid mutating caller map
 	resolvedEnv := make(map[string]string, len(envVars)+2)
@@ -2729,6 +2776,38 @@ func resolveExecWrapper(rigPath string) []string {
 	return nil
 }
 
+// withSandboxWrapper prepends "gt sandbox exec" to wrapper when envVars start
+// a polecat in a rig whose settings enable the sandbox, so every polecat start
+// (spawn, restart, handoff) runs sandboxed. The sandbox encloses any
+// configured exec wrapper.
+//
+// A polecat without a name cannot be sandboxed. The error reports that, and
+// the returned wrapper omits the missing flag so gt sandbox exec refuses to
+// start rather than the polecat running unsandboxed.
+func withSandboxWrapper(wrapper []string, envVars map[string]string, rigPath string) ([]string, error) {
+	if rigPath == "" || ExtractSimpleRole(envVars["GT_ROLE"]) != constants.RolePolecat {
+		return wrapper, nil
+	}
+	settings, err := LoadRigSettings(RigSettingsPath(rigPath))
+	if err != nil || !settings.Sandbox.Enabled() {
+		return wrapper, nil
+	}
+	rig := envVars["GT_RIG"]
+	if rig == "" {
+		rig = filepath.Base(rigPath)
+	}
+	polecat := envVars["GT_POLECAT"]
+	sandbox := []string{"gt", "sandbox", "exec", "--rig", ShellQuote(rig)}
+	if polecat != "" {
+		sandbox = append(sandbox, "--polecat", ShellQuote(polecat))
+	}
+	sandbox = append(append(sandbox, "--"), wrapper...)
+	if polecat == "" {
+		return sandbox, fmt.Errorf("rig %s runs polecats sandboxed but GT_POLECAT is not set", rig)
+	}
+	return sandbox, nil
+}
+
 // ExpectedPaneCommands returns tmux pane command names that indicate the runtime is running.
 // Claude can report as "node" (older versions) or "claude" (newer versions).
 // Other runtimes typically report their executable name.
@@ -347,6 +347,39 @@ func TestRigSettingsValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid sandbox",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				Sandbox: &SandboxConfig{
+					Mode:          SandboxModeNamespace,
+					Backend:       SandboxBackendBwrap,
+					Network:       SandboxNetworkHost,
+					CertTTL:       "8h",
+					ReadOnlyPaths: []string{"/opt/claude"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid sandbox mode",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				Sandbox: &SandboxConfig{Mode: "docker"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative sandbox path",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				Sandbox: &SandboxConfig{Mode: SandboxModeNamespace, WritablePaths: []string{"cache"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid poll_interval",
 			settings: &RigSettings{
@@ -5666,6 +5699,53 @@ func TestBuildStartupCommandWithAgentOverride_ExecWrapper(t *testing.T) {
 	}
 }
 
+func TestBuildStartupCommand_SandboxWrapper(t *testing.T) {
+	t.Parallel()
+	townRoot := t.TempDir()
+	rigPath := filepath.Join(townRoot, "testrig")
+
+	rigSettings := NewRigSettings()
+	rigSettings.Sandbox = &SandboxConfig{Mode: SandboxModeNamespace}
+	rigSettings.Runtime = &RuntimeConfig{
+		Command:     "claude",
+		ExecWrapper: []string{"exitbox", "run", "--"},
+	}
+	if err := SaveRigSettings(RigSettingsPath(rigPath), rigSettings); err != nil {
+		t.Fatalf("SaveRigSettings: %v", err)
+	}
+
+	polecat := AgentEnv(AgentEnvConfig{Role: "polecat", Rig: "testrig", AgentName: "nux", TownRoot: townRoot})
+	cmd, err := BuildStartupCommandWithAgentOverride(polecat, rigPath, "hello", "")
+	if err != nil {
+		t.Fatalf("BuildStartupCommandWithAgentOverride: %v", err)
+	}
+	// The sandbox encloses the configured wrapper and the agent.
+	if !strings.Contains(cmd, "gt sandbox exec --rig testrig --polecat nux -- exitbox run -- claude") {
+		t.Errorf("expected sandbox wrapper before exec wrapper, got: %q", cmd)
+	}
+	if cmd := BuildStartupCommand(polecat, rigPath, "hello"); !strings.Contains(cmd, "gt sandbox exec --rig testrig --polecat nux --") {
+		t.Errorf("expected sandbox wrapper, got: %q", cmd)
+	}
+
+	// A polecat without a name is rejected rather than started unsandboxed
+	// or with an empty --polecat.
+	unnamed := AgentEnv(AgentEnvConfig{Role: "polecat", Rig: "testrig", TownRoot: townRoot})
+	delete(unnamed, "GT_POLECAT")
+	if _, err := BuildStartupCommandWithAgentOverride(unnamed, rigPath, "hello", ""); err == nil {
+		t.Error("expected error for a sandboxed polecat without GT_POLECAT")
+	}
+	cmd = BuildStartupCommand(unnamed, rigPath, "hello")
+	if !strings.Contains(cmd, "gt sandbox exec --rig testrig --") || strings.Contains(cmd, "--polecat") {
+		t.Errorf("expected sandbox wrapper without --polecat, got: %q", cmd)
+	}
+
+	// Other roles in the rig are not sandboxed.
+	witness := AgentEnv(AgentEnvConfig{Role: "witness", Rig: "testrig", TownRoot: townRoot})
+	if cmd := BuildStartupCommand(witness, rigPath, "hello"); strings.Contains(cmd, "gt sandbox") {
+		t.Errorf("witness must not be sandboxed, got: %q", cmd)
+	}
+}
+
 // --- Tests for GH#3153: --agent override skips --settings flag ---
 
 func TestWithRoleSettingsFlag_IdempotencyGuard(t *testing.T) {
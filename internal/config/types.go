@@ -82,6 +82,10 @@ type TownSettings struct {
 	// WebTimeouts configures command execution timeouts for the web dashboard.
 	WebTimeouts *WebTimeoutsConfig `json:"web_timeouts,omitempty"`
 
+	// WebAuth configures authentication and roles for the web dashboard.
+	// When nil or empty, the dashboard trusts every client (loopback use only).
+	WebAuth *WebAuthConfig `json:"web_auth,omitempty"`
+
 	// WorkerStatus configures activity-age thresholds for worker status classification.
 	WorkerStatus *WorkerStatusConfig `json:"worker_status,omitempty"`
 
@@ -165,6 +169,58 @@ func DefaultWebTimeoutsConfig() *WebTimeoutsConfig {
 	}
 }
 
+// WebAuthConfig configures authentication for the web dashboard.
+// Credentials are stored hashed; plaintext tokens and passwords never touch disk.
+type WebAuthConfig struct {
+	// Tokens are static bearer tokens for API clients and scripts.
+	Tokens []WebAuthToken `json:"tokens,omitempty"`
+	// Users are local accounts checked with HTTP basic auth.
+	Users []WebAuthUser `json:"users,omitempty"`
+	// TrustedProxy accepts an identity asserted by a reverse proxy.
+	TrustedProxy *WebAuthProxyConfig `json:"trusted_proxy,omitempty"`
+}
+
+// WebAuthToken is a named bearer token.
+type WebAuthToken struct {
+	// Name identifies the token in audit entries and `gt dashboard token list`.
+	Name string `json:"name"`
+	// Role is "viewer", "operator", or "admin".
+	Role string `json:"role"`
+	// Hash is "sha256:<hex>" of the token.
+	Hash string `json:"hash"`
+	// CreatedAt is when the token was issued (RFC3339).
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// WebAuthUser is a local dashboard account.
+type WebAuthUser struct {
+	// Name is the basic-auth username, also matched against the trusted proxy header.
+	Name string `json:"name"`
+	// Role is "viewer", "operator", or "admin".
+	Role string `json:"role"`
+	// PasswordHash is a bcrypt hash. Empty for proxy-only accounts.
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// WebAuthProxyConfig trusts a user header set by a reverse proxy.
+type WebAuthProxyConfig struct {
+	// Header carries the authenticated username (e.g. "X-Forwarded-User").
+	Header string `json:"header"`
+	// Sources are the CIDRs the proxy connects from. Default: loopback only.
+	Sources []string `json:"sources,omitempty"`
+	// DefaultRole applies to proxied users without a matching local account.
+	// Default: "viewer".
+	DefaultRole string `json:"default_role,omitempty"`
+}
+
+// Enabled reports whether any authentication method is configured.
+func (c *WebAuthConfig) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	return len(c.Tokens) > 0 || len(c.Users) > 0 || (c.TrustedProxy != nil && c.TrustedProxy.Header != "")
+}
+
 // WorkerStatusConfig configures activity-age thresholds for worker status classification.
 type WorkerStatusConfig struct {
 	// StaleThreshold is the activity age after which a worker is considered "stale".
@@ -676,6 +732,7 @@ type RigSettings struct {
 	Crew       *CrewConfig       `json:"crew,omitempty"`        // crew startup settings
 	Workflow   *WorkflowConfig   `json:"workflow,omitempty"`    // workflow settings
 	Runtime    *RuntimeConfig    `json:"runtime,omitempty"`     // LLM runtime settings (deprecated: use Agent)
+	Sandbox    *SandboxConfig    `json:"sandbox,omitempty"`     // polecat sandbox runtime (Linux)
 
 	// Agent selects which agent preset to use for this rig.
 	// Can be a built-in preset ("claude", "gemini", "codex", "cursor", "auggie", "amp", "opencode", "copilot")
@@ -709,6 +766,66 @@ type RigSettings struct {
 	RoleEffort map[string]string `json:"role_effort,omitempty"`
 }
 
+// Sandbox modes for SandboxConfig.Mode.
+const (
+	// SandboxModeNone runs polecats directly on the host (the default).
+	SandboxModeNone = "none"
+	// SandboxModeNamespace runs polecats in a Linux namespace sandbox that
+	// exposes only the worktree, a private home and the proxy socket.
+	SandboxModeNamespace = "namespace"
+)
+
+// Sandbox backends for SandboxConfig.Backend.
+const (
+	SandboxBackendAuto      = "auto"      // bwrap if usable, else built-in namespaces
+	SandboxBackendBwrap     = "bwrap"     // bubblewrap
+	SandboxBackendNamespace = "namespace" // built-in user/mount/pid/net namespaces
+)
+
+// Sandbox network policies for SandboxConfig.Network.
+const (
+	SandboxNetworkNone = "none" // private network namespace; only the proxy is reachable
+	SandboxNetworkHost = "host" // share the host network (agents that call a hosted model)
+)
+
+// SandboxConfig selects the sandbox runtime for a rig's polecats.
+// Polecats in a sandboxed rig reach gt, bd and git only through the mTLS
+// proxy (gt-proxy-server), which must be running on the same host.
+type SandboxConfig struct {
+	// Mode is "none" (default) or "namespace".
+	Mode string `json:"mode,omitempty"`
+
+	// Backend is "auto" (default), "bwrap" or "namespace".
+	Backend string `json:"backend,omitempty"`
+
+	// Network is "none" (default) or "host".
+	Network string `json:"network,omitempty"`
+
+	// ProxyClient is the gt-proxy-client binary installed in the sandbox as
+	// gt and bd. Default: gt-proxy-client next to the gt binary, else in PATH.
+	ProxyClient string `json:"proxy_client,omitempty"`
+
+	// ProxyURL is the proxy's TCP address, used when Network is "host".
+	// Default: https://127.0.0.1:9876.
+	ProxyURL string `json:"proxy_url,omitempty"`
+
+	// CertTTL is the lifetime of the client certificate issued on each spawn
+	// (Go duration). Default: 24h.
+	CertTTL string `json:"cert_ttl,omitempty"`
+
+	// ReadOnlyPaths are extra host paths visible read-only, e.g. the agent's
+	// install directory when it lives under $HOME.
+	ReadOnlyPaths []string `json:"ro_paths,omitempty"`
+
+	// WritablePaths are extra host paths visible read-write.
+	WritablePaths []string `json:"rw_paths,omitempty"`
+}
+
+// Enabled reports whether c selects a sandbox. A nil config is disabled.
+func (c *SandboxConfig) Enabled() bool {
+	return c != nil && c.Mode == SandboxModeNamespace
+}
+
 // CrewConfig represents crew workspace settings for a rig.
 type CrewConfig struct {
 	// Startup is a natural language instruction for which crew to start on boot.
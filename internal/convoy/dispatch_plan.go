@@ -0,0 +1,191 @@
+package convoy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DispatchPlan orders a batch of beads into dispatch waves by their blocking
+// dependencies. Wave 1 holds the roots — beads with no open blockers — which
+// can be slung immediately. Every later wave waits on an earlier one, or on
+// an open blocker outside the batch.
+type DispatchPlan struct {
+	// Waves lists bead IDs per wave, in input order within each wave.
+	// Waves[0] is wave 1 and may be empty when every bead waits on a
+	// blocker outside the batch.
+	Waves [][]string
+
+	// Blockers maps each bead to the open beads blocking it, in the batch
+	// or not. Beads without open blockers have no entry.
+	Blockers map[string][]string
+}
+
+// Roots returns the beads that can be dispatched immediately.
+func (p *DispatchPlan) Roots() []string {
+	if len(p.Waves) == 0 {
+		return nil
+	}
+	return p.Waves[0]
+}
+
+// Staged returns the beads that must wait for a blocker to close, in wave
+// order.
+func (p *DispatchPlan) Staged() []string {
+	var staged []string
+	for _, wave := range p.Waves[min(1, len(p.Waves)):] {
+		staged = append(staged, wave...)
+	}
+	return staged
+}
+
+// ExternalBlockers returns the open blockers of id that are not part of the
+// batch.
+func (p *DispatchPlan) ExternalBlockers(id string) []string {
+	inBatch := make(map[string]bool)
+	for _, wave := range p.Waves {
+		for _, w := range wave {
+			inBatch[w] = true
+		}
+	}
+	var external []string
+	for _, b := range p.Blockers[id] {
+		if !inBatch[b] {
+			external = append(external, b)
+		}
+	}
+	return external
+}
+
+// PlanDispatch computes the dispatch waves for a batch of beads. Blocking
+// deps are resolved through resolver so blockers in other rigs' databases
+// are seen with their current status. With no stores available every bead
+// is a root, which matches dispatching without ordering.
+func PlanDispatch(ctx context.Context, resolver *StoreResolver, ids []string) (*DispatchPlan, error) {
+	blockers := make(map[string][]string)
+	for _, id := range ids {
+		if open := openBlockers(ctx, resolver, id); len(open) > 0 {
+			blockers[id] = open
+		}
+	}
+	return planDispatch(ids, blockers)
+}
+
+// openBlockers returns the blocking deps of issueID that have not closed.
+// It applies the same rules as isIssueBlocked: tombstones never block,
+// merge-blocks needs a merged close, and non-closed snapshot statuses are
+// re-checked against the blocker's home store.
+func openBlockers(ctx context.Context, resolver *StoreResolver, issueID string) []string {
+	if resolver == nil {
+		return nil
+	}
+
+	var open, candidates, candidateTypes []string
+	for _, d := range resolver.ResolveDepsWithMetadata(ctx, issueID) {
+		depType := string(d.DependencyType)
+		if !blockingDepTypes[depType] {
+			continue
+		}
+		id := extractIssueID(d.ID)
+		switch string(d.Status) {
+		case "tombstone":
+		case "closed":
+			if depType == "merge-blocks" && !strings.HasPrefix(d.CloseReason, "Merged in ") {
+				open = append(open, id)
+			}
+		default:
+			candidates = append(candidates, id)
+			candidateTypes = append(candidateTypes, depType)
+		}
+	}
+
+	if len(candidates) > 0 {
+		fresh := resolver.ResolveIssues(ctx, candidates)
+		for i, id := range candidates {
+			issue, ok := fresh[id]
+			if !ok {
+				open = append(open, id) // can't resolve = assume blocked
+				continue
+			}
+			switch string(issue.Status) {
+			case "tombstone":
+			case "closed":
+				if candidateTypes[i] == "merge-blocks" && !strings.HasPrefix(issue.CloseReason, "Merged in ") {
+					open = append(open, id)
+				}
+			default:
+				open = append(open, id)
+			}
+		}
+	}
+	return open
+}
+
+// planDispatch assigns each bead the earliest wave after all of its blockers
+// in the batch. A bead with an open blocker outside the batch is at least in
+// wave 2: it is staged rather than dispatched. Returns an error naming the
+// cycle if beads in the batch block each other.
+func planDispatch(ids []string, blockers map[string][]string) (*DispatchPlan, error) {
+	plan := &DispatchPlan{Blockers: blockers}
+	inBatch := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inBatch[id] = true
+	}
+
+	const visiting = -1
+	wave := make(map[string]int, len(ids))
+	var path []string
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch wave[id] {
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if p == id {
+					start = i
+				}
+			}
+			cycle := append(append([]string(nil), path[start:]...), id)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+		case 0:
+		default:
+			return nil
+		}
+
+		wave[id] = visiting
+		path = append(path, id)
+		w := 1
+		for _, b := range blockers[id] {
+			if !inBatch[b] {
+				w = max(w, 2)
+				continue
+			}
+			if err := visit(b); err != nil {
+				return err
+			}
+			w = max(w, wave[b]+1)
+		}
+		path = path[:len(path)-1]
+		wave[id] = w
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+
+	placed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if placed[id] {
+			continue
+		}
+		placed[id] = true
+		for len(plan.Waves) < wave[id] {
+			plan.Waves = append(plan.Waves, nil)
+		}
+		plan.Waves[wave[id]-1] = append(plan.Waves[wave[id]-1], id)
+	}
+	return plan, nil
+}
@@ -0,0 +1,69 @@
+package convoy
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanDispatch_Waves(t *testing.T) {
+	ids := []string{"gt-d", "gt-a", "gt-b", "gt-c", "gt-e"}
+	blockers := map[string][]string{
+		"gt-b": {"gt-a"},
+		"gt-c": {"gt-a"},
+		"gt-d": {"gt-b", "gt-c"},
+		"gt-e": {"bd-x"}, // open blocker in another rig
+	}
+	plan, err := planDispatch(ids, blockers)
+	if err != nil {
+		t.Fatalf("planDispatch: %v", err)
+	}
+	want := [][]string{{"gt-a"}, {"gt-b", "gt-c", "gt-e"}, {"gt-d"}}
+	if !reflect.DeepEqual(plan.Waves, want) {
+		t.Errorf("Waves = %v, want %v", plan.Waves, want)
+	}
+	if got := plan.Roots(); !reflect.DeepEqual(got, []string{"gt-a"}) {
+		t.Errorf("Roots = %v", got)
+	}
+	if got := plan.Staged(); !reflect.DeepEqual(got, []string{"gt-b", "gt-c", "gt-e", "gt-d"}) {
+		t.Errorf("Staged = %v", got)
+	}
+	if got := plan.ExternalBlockers("gt-e"); !reflect.DeepEqual(got, []string{"bd-x"}) {
+		t.Errorf("ExternalBlockers(gt-e) = %v", got)
+	}
+	if got := plan.ExternalBlockers("gt-d"); got != nil {
+		t.Errorf("ExternalBlockers(gt-d) = %v, want none", got)
+	}
+}
+
+func TestPlanDispatch_AllExternallyBlocked(t *testing.T) {
+	plan, err := planDispatch([]string{"gt-a"}, map[string][]string{"gt-a": {"hq-x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Roots()) != 0 || !reflect.DeepEqual(plan.Staged(), []string{"gt-a"}) {
+		t.Errorf("Waves = %v, want gt-a staged with no roots", plan.Waves)
+	}
+}
+
+func TestPlanDispatch_Cycle(t *testing.T) {
+	_, err := planDispatch([]string{"gt-a", "gt-b", "gt-c"}, map[string][]string{
+		"gt-a": {"gt-b"},
+		"gt-b": {"gt-c"},
+		"gt-c": {"gt-b"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "gt-b → gt-c → gt-b") {
+		t.Errorf("err = %v, want cycle gt-b → gt-c → gt-b", err)
+	}
+}
+
+func TestPlanDispatch_NoStores(t *testing.T) {
+	plan, err := PlanDispatch(context.Background(), NewStoreResolver(t.TempDir(), nil), []string{"gt-a", "gt-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan.Waves, [][]string{{"gt-a", "gt-b"}}) {
+		t.Errorf("Waves = %v, want a single wave", plan.Waves)
+	}
+}
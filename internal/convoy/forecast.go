@@ -0,0 +1,442 @@
+package convoy
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Forecast tuning. The simulation is seeded so a forecast is stable between
+// refreshes of the same convoy state.
+const (
+	forecastTrials      = 400
+	forecastSeed        = 1
+	forecastTopBlockers = 3
+)
+
+// ForecastIssue is a convoy's tracked issue as seen by the forecaster.
+type ForecastIssue struct {
+	ID        string
+	Status    string    // Bead status; closed issues are finished
+	BlockedBy []string  // Issues that block this one (blocks dependencies)
+	StartedAt time.Time // Last sling, when the issue is being worked
+	DoneAt    time.Time // Last gt done, when the issue is waiting to merge
+}
+
+// ForecastBlocker is an open issue with the amount of convoy work waiting
+// on it.
+type ForecastBlocker struct {
+	ID         string `json:"id"`
+	Downstream int    `json:"downstream"` // Open issues transitively blocked by it
+}
+
+// Forecast is the expected completion of a convoy. Completion times are
+// zero when there is no history to estimate durations from.
+type Forecast struct {
+	Remaining    int               `json:"remaining"`
+	Capacity     int               `json:"capacity,omitempty"` // Concurrent polecats assumed; 0 = unbounded
+	Samples      int               `json:"samples"`            // Completed issues the estimate is based on
+	CriticalPath []string          `json:"critical_path,omitempty"`
+	PathDuration time.Duration     `json:"critical_path_duration,omitempty"`
+	ETA          time.Time         `json:"eta,omitzero"`       // Median completion (p50)
+	ETAEarly     time.Time         `json:"eta_early,omitzero"` // p10
+	ETALate      time.Time         `json:"eta_late,omitzero"`  // p90
+	Blockers     []ForecastBlocker `json:"blockers,omitempty"`
+}
+
+// Estimated reports whether the forecast has completion times.
+func (f *Forecast) Estimated() bool {
+	return f != nil && !f.ETA.IsZero()
+}
+
+// forecastNode is an open issue in the forecast graph.
+type forecastNode struct {
+	id         string
+	started    bool
+	elapsed    time.Duration // Time since sling, while working
+	merging    bool
+	sinceDone  time.Duration // Time since gt done, while merging
+	blockers   []int
+	dependents []int
+	weight     time.Duration // Point estimate of remaining time
+	tail       time.Duration // Longest remaining path from this node on
+}
+
+// ComputeForecast forecasts when a convoy's open issues will be done. Each
+// issue takes a work phase (sling to done) that occupies one of capacity
+// polecats, then a merge phase (done to merged) that does not; durations are
+// drawn from history, less the time already spent on issues underway.
+// Blocks dependencies between tracked issues order the work; dependencies on
+// closed or untracked issues are ignored. A capacity of 0 or less is
+// unbounded.
+//
+// The critical path is the longest chain of dependent open issues by median
+// remaining time (by issue count without history). The completion bands come
+// from simulating the schedule with durations resampled from history.
+func ComputeForecast(issues []ForecastIssue, history *ForecastHistory, capacity int, now time.Time) *Forecast {
+	if history == nil {
+		history = &ForecastHistory{}
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	work := sortedDurations(history.Work)
+	merge := sortedDurations(history.Merge)
+	nodes := buildForecastGraph(issues, now)
+
+	f := &Forecast{Remaining: len(nodes), Capacity: capacity, Samples: len(work)}
+	if len(nodes) == 0 {
+		return f
+	}
+	estimated := len(work) > 0
+
+	for _, n := range nodes {
+		if estimated {
+			w, m := n.remaining(work, merge, nil)
+			n.weight = w + m
+		} else {
+			n.weight = 1
+		}
+	}
+	order := topoOrder(nodes)
+
+	// Longest path ending at each node, for the critical path.
+	dist := make([]time.Duration, len(nodes))
+	pred := make([]int, len(nodes))
+	end := -1
+	for _, i := range order {
+		pred[i] = -1
+		for _, b := range nodes[i].blockers {
+			if dist[b] > dist[i] || (dist[b] == dist[i] && pred[i] >= 0 && nodes[b].id < nodes[pred[i]].id) {
+				dist[i], pred[i] = dist[b], b
+			}
+		}
+		dist[i] += nodes[i].weight
+		if end < 0 || dist[i] > dist[end] || (dist[i] == dist[end] && nodes[i].id < nodes[end].id) {
+			end = i
+		}
+	}
+	for i := end; i >= 0; i = pred[i] {
+		f.CriticalPath = append([]string{nodes[i].id}, f.CriticalPath...)
+	}
+	if estimated {
+		f.PathDuration = dist[end]
+	}
+
+	// Longest path starting at each node, to schedule the critical work first.
+	for k := len(order) - 1; k >= 0; k-- {
+		n := nodes[order[k]]
+		var longest time.Duration
+		for _, d := range n.dependents {
+			longest = max(longest, nodes[d].tail)
+		}
+		n.tail = n.weight + longest
+	}
+
+	f.Blockers = topBlockers(nodes)
+
+	if estimated {
+		rng := rand.New(rand.NewSource(forecastSeed))
+		spans := make([]time.Duration, forecastTrials)
+		for t := range spans {
+			spans[t] = simulateForecast(nodes, work, merge, capacity, rng)
+		}
+		sort.Slice(spans, func(i, j int) bool { return spans[i] < spans[j] })
+		f.ETAEarly = now.Add(percentile(spans, 0.1))
+		f.ETA = now.Add(percentile(spans, 0.5))
+		f.ETALate = now.Add(percentile(spans, 0.9))
+	}
+	return f
+}
+
+// buildForecastGraph returns the open issues with their blocks edges to
+// other open issues.
+func buildForecastGraph(issues []ForecastIssue, now time.Time) []*forecastNode {
+	index := make(map[string]int)
+	var nodes []*forecastNode
+	var open []ForecastIssue
+	for _, issue := range issues {
+		if issue.Status == "closed" {
+			continue
+		}
+		if _, dup := index[issue.ID]; dup {
+			continue
+		}
+		index[issue.ID] = len(nodes)
+		n := &forecastNode{id: issue.ID}
+		switch {
+		case !issue.DoneAt.IsZero():
+			n.merging = true
+			n.sinceDone = max(now.Sub(issue.DoneAt), 0)
+		case !issue.StartedAt.IsZero():
+			n.started = true
+			n.elapsed = max(now.Sub(issue.StartedAt), 0)
+		case issue.Status == "hooked" || issue.Status == "in_progress":
+			n.started = true
+		}
+		nodes = append(nodes, n)
+		open = append(open, issue)
+	}
+	for i, issue := range open {
+		seen := make(map[int]bool)
+		for _, id := range issue.BlockedBy {
+			b, ok := index[id]
+			if !ok || b == i || seen[b] {
+				continue
+			}
+			seen[b] = true
+			nodes[i].blockers = append(nodes[i].blockers, b)
+			nodes[b].dependents = append(nodes[b].dependents, i)
+		}
+	}
+	return nodes
+}
+
+// topoOrder orders nodes so blockers come before their dependents. Edges
+// into dependency cycles are dropped so a cyclic convoy still forecasts.
+func topoOrder(nodes []*forecastNode) []int {
+	pending := make([]int, len(nodes))
+	var ready []int
+	for i, n := range nodes {
+		pending[i] = len(n.blockers)
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	order := make([]int, 0, len(nodes))
+	placed := make([]bool, len(nodes))
+	for len(order) < len(nodes) {
+		if len(ready) == 0 {
+			// Cycle: release the first unplaced node from its unplaced blockers.
+			for i, n := range nodes {
+				if placed[i] {
+					continue
+				}
+				kept := n.blockers[:0]
+				for _, b := range n.blockers {
+					if placed[b] {
+						kept = append(kept, b)
+					} else {
+						nodes[b].dependents = removeIndex(nodes[b].dependents, i)
+					}
+				}
+				n.blockers = kept
+				ready = append(ready, i)
+				break
+			}
+		}
+		i := ready[0]
+		ready = ready[1:]
+		placed[i] = true
+		order = append(order, i)
+		for _, d := range nodes[i].dependents {
+			pending[d]--
+			if pending[d] == 0 && !placed[d] {
+				ready = append(ready, d)
+			}
+		}
+	}
+	return order
+}
+
+func removeIndex(list []int, v int) []int {
+	out := list[:0]
+	for _, x := range list {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// remaining returns a node's remaining work and merge time: the median (or,
+// with rng, a random draw) of the historical durations that outlast the time
+// already spent.
+func (n *forecastNode) remaining(work, merge []time.Duration, rng *rand.Rand) (time.Duration, time.Duration) {
+	switch {
+	case n.merging:
+		return 0, residual(merge, n.sinceDone, rng)
+	case n.started:
+		return residual(work, n.elapsed, rng), residual(merge, 0, rng)
+	default:
+		return residual(work, 0, rng), residual(merge, 0, rng)
+	}
+}
+
+// residual draws from the sorted samples longer than elapsed and returns the
+// time left. Issues running longer than any sample are treated as about to
+// finish.
+func residual(samples []time.Duration, elapsed time.Duration, rng *rand.Rand) time.Duration {
+	i := sort.Search(len(samples), func(i int) bool { return samples[i] > elapsed })
+	longer := samples[i:]
+	if len(longer) == 0 {
+		return 0
+	}
+	if rng == nil {
+		return percentile(longer, 0.5) - elapsed
+	}
+	return longer[rng.Intn(len(longer))] - elapsed
+}
+
+// simulateForecast plays out one schedule of the open issues with durations
+// drawn from history and returns how long it takes. Ready issues start in
+// order of the longest remaining path behind them while polecats are free.
+func simulateForecast(nodes []*forecastNode, work, merge []time.Duration, capacity int, rng *rand.Rand) time.Duration {
+	const (
+		waiting = iota
+		working
+		merging
+		finished
+	)
+	phase := make([]int, len(nodes))
+	endAt := make([]time.Duration, len(nodes))
+	mergeFor := make([]time.Duration, len(nodes))
+	pending := make([]int, len(nodes))
+	busy := 0
+	for i, n := range nodes {
+		w, m := n.remaining(work, merge, rng)
+		pending[i] = len(n.blockers)
+		mergeFor[i] = m
+		switch {
+		case n.merging:
+			phase[i], endAt[i] = merging, m
+		case n.started:
+			phase[i], endAt[i] = working, w
+			busy++
+		default:
+			endAt[i] = w // Work time, until started
+		}
+	}
+
+	var now time.Duration
+	for {
+		if capacity == 0 || busy < capacity {
+			var ready []int
+			for i := range nodes {
+				if phase[i] == waiting && pending[i] == 0 {
+					ready = append(ready, i)
+				}
+			}
+			sort.Slice(ready, func(a, b int) bool {
+				na, nb := nodes[ready[a]], nodes[ready[b]]
+				if na.tail != nb.tail {
+					return na.tail > nb.tail
+				}
+				return na.id < nb.id
+			})
+			for _, i := range ready {
+				if capacity > 0 && busy >= capacity {
+					break
+				}
+				phase[i], endAt[i] = working, now+endAt[i]
+				busy++
+			}
+		}
+
+		next := -1
+		for i := range nodes {
+			if (phase[i] == working || phase[i] == merging) && (next < 0 || endAt[i] < endAt[next]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return now
+		}
+		now = endAt[next]
+		if phase[next] == working {
+			busy--
+			phase[next], endAt[next] = merging, now+mergeFor[next]
+			continue
+		}
+		phase[next] = finished
+		for _, d := range nodes[next].dependents {
+			pending[d]--
+		}
+	}
+}
+
+// topBlockers returns the open issues with the most open work transitively
+// blocked behind them.
+func topBlockers(nodes []*forecastNode) []ForecastBlocker {
+	var blockers []ForecastBlocker
+	for i := range nodes {
+		seen := make(map[int]bool)
+		stack := append([]int(nil), nodes[i].dependents...)
+		for len(stack) > 0 {
+			d := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			stack = append(stack, nodes[d].dependents...)
+		}
+		if len(seen) > 0 {
+			blockers = append(blockers, ForecastBlocker{ID: nodes[i].id, Downstream: len(seen)})
+		}
+	}
+	sort.Slice(blockers, func(i, j int) bool {
+		if blockers[i].Downstream != blockers[j].Downstream {
+			return blockers[i].Downstream > blockers[j].Downstream
+		}
+		return blockers[i].ID < blockers[j].ID
+	})
+	if len(blockers) > forecastTopBlockers {
+		blockers = blockers[:forecastTopBlockers]
+	}
+	return blockers
+}
+
+func sortedDurations(in []time.Duration) []time.Duration {
+	out := make([]time.Duration, 0, len(in))
+	for _, d := range in {
+		if d > 0 {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// percentile returns the q-th quantile of sorted durations.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(q*float64(len(sorted)-1)+0.5)]
+}
+
+// FormatForecastETA renders a forecast's expected completion relative to
+// now with its p10–p90 band, e.g. "~3h (2h–5h)", or "" without an estimate.
+func FormatForecastETA(f *Forecast, now time.Time) string {
+	if !f.Estimated() {
+		return ""
+	}
+	return fmt.Sprintf("~%s (%s–%s)",
+		FormatForecastDuration(f.ETA.Sub(now)),
+		FormatForecastDuration(f.ETAEarly.Sub(now)),
+		FormatForecastDuration(f.ETALate.Sub(now)))
+}
+
+// FormatForecastDuration renders a forecast duration coarsely: minutes under
+// an hour, hours and minutes under two days, then days and hours.
+func FormatForecastDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		h := int(d.Hours())
+		if m := int(d.Minutes()) % 60; m != 0 {
+			return fmt.Sprintf("%dh%dm", h, m)
+		}
+		return fmt.Sprintf("%dh", h)
+	default:
+		days := int(d.Hours()) / 24
+		if h := int(d.Hours()) % 24; h != 0 {
+			return fmt.Sprintf("%dd%dh", days, h)
+		}
+		return fmt.Sprintf("%dd", days)
+	}
+}
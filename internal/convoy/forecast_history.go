@@ -0,0 +1,139 @@
+package convoy
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// forecastHistoryWindow bounds how far back durations are sampled from, so
+// forecasts follow the town's current pace.
+const forecastHistoryWindow = 30 * 24 * time.Hour
+
+// ForecastHistory holds the durations the forecaster samples from and the
+// latest dispatch and completion of each bead.
+type ForecastHistory struct {
+	Work  []time.Duration      // Sling to done, per completed issue
+	Merge []time.Duration      // Done to merged, per merged MR
+	Slung map[string]time.Time // Latest sling per bead
+	Done  map[string]time.Time // Latest gt done per bead
+}
+
+// LoadForecastHistory collects forecast history for a town: sling-to-done
+// durations from the event log and done-to-merged durations from the rigs'
+// merged MR beads, over the last 30 days. Sources that cannot be read are
+// skipped.
+func LoadForecastHistory(townRoot string, now time.Time) *ForecastHistory {
+	since := now.Add(-forecastHistoryWindow)
+	h := &ForecastHistory{Slung: make(map[string]time.Time), Done: make(map[string]time.Time)}
+	h.readEvents(filepath.Join(townRoot, events.EventsFile), since)
+
+	rigsConfig, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json"))
+	if err != nil {
+		return h
+	}
+	for rigName := range rigsConfig.Rigs {
+		rigPath := filepath.Join(townRoot, rigName)
+		if _, err := os.Stat(filepath.Join(rigPath, ".beads")); err != nil {
+			continue
+		}
+		mrs, err := beads.New(rigPath).List(beads.ListOptions{
+			Status:   "closed",
+			Label:    "gt:merge-request",
+			Priority: -1,
+		})
+		if err != nil {
+			continue
+		}
+		h.addMerges(mrs, since)
+	}
+	return h
+}
+
+// readEvents pairs each done event with the bead's latest earlier sling.
+// The event log is append-only, so events are read in time order.
+func (h *ForecastHistory) readEvents(path string, since time.Time) {
+	file, err := os.Open(path) //nolint:gosec // G304: path is the town's event log
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if event.Type != events.TypeSling && event.Type != events.TypeDone {
+			continue
+		}
+		bead, _ := event.Payload["bead"].(string)
+		ts, err := time.Parse(time.RFC3339, event.Timestamp)
+		if bead == "" || err != nil {
+			continue
+		}
+		if event.Type == events.TypeSling {
+			h.Slung[bead] = ts
+			continue
+		}
+		h.Done[bead] = ts
+		if slung, ok := h.Slung[bead]; ok && ts.After(slung) && !ts.Before(since) {
+			h.Work = append(h.Work, ts.Sub(slung))
+		}
+	}
+}
+
+// addMerges records how long merged MRs waited from submission (gt done)
+// to merge.
+func (h *ForecastHistory) addMerges(mrs []*beads.Issue, since time.Time) {
+	for _, mr := range mrs {
+		fields := beads.ParseMRFields(mr)
+		if fields == nil || fields.CloseReason != "merged" {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, mr.CreatedAt)
+		if err != nil {
+			continue
+		}
+		closed, err := time.Parse(time.RFC3339, mr.ClosedAt)
+		if err != nil || closed.Before(since) || !closed.After(created) {
+			continue
+		}
+		h.Merge = append(h.Merge, closed.Sub(created))
+	}
+}
+
+// Annotate fills in when an open issue was slung or finished its work, from
+// the event log: an issue whose latest gt done follows its latest sling is
+// waiting to merge, and a hooked or in-progress issue has been worked on
+// since its latest sling.
+func (h *ForecastHistory) Annotate(issue *ForecastIssue) {
+	if h == nil || issue.Status == "closed" {
+		return
+	}
+	slung, done := h.Slung[issue.ID], h.Done[issue.ID]
+	switch {
+	case !done.IsZero() && done.After(slung):
+		issue.DoneAt = done
+	case !slung.IsZero() && (issue.Status == "hooked" || issue.Status == "in_progress"):
+		issue.StartedAt = slung
+	}
+}
+
+// ForecastCapacity returns the number of polecats the scheduler runs at once
+// (max_polecats), or 0 when dispatch is not capacity-limited.
+func ForecastCapacity(townRoot string) int {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.Scheduler == nil {
+		return 0
+	}
+	return max(settings.Scheduler.GetMaxPolecats(), 0)
+}
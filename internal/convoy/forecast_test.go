@@ -0,0 +1,223 @@
+package convoy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+var forecastNow = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+func hours(h ...float64) []time.Duration {
+	out := make([]time.Duration, len(h))
+	for i, v := range h {
+		out[i] = time.Duration(v * float64(time.Hour))
+	}
+	return out
+}
+
+func TestComputeForecast_CriticalPathAndBlockers(t *testing.T) {
+	issues := []ForecastIssue{
+		{ID: "gt-a", Status: "open"},
+		{ID: "gt-b", Status: "open", BlockedBy: []string{"gt-a"}},
+		{ID: "gt-c", Status: "open", BlockedBy: []string{"gt-b"}},
+		{ID: "gt-d", Status: "open"},
+		{ID: "gt-e", Status: "closed"},
+		{ID: "gt-f", Status: "open", BlockedBy: []string{"gt-e", "gt-x"}},
+	}
+	f := ComputeForecast(issues, &ForecastHistory{Work: hours(1)}, 0, forecastNow)
+
+	if f.Remaining != 5 {
+		t.Errorf("Remaining = %d, want 5", f.Remaining)
+	}
+	if want := []string{"gt-a", "gt-b", "gt-c"}; !reflect.DeepEqual(f.CriticalPath, want) {
+		t.Errorf("CriticalPath = %v, want %v", f.CriticalPath, want)
+	}
+	if f.PathDuration != 3*time.Hour {
+		t.Errorf("PathDuration = %v, want 3h", f.PathDuration)
+	}
+	want := []ForecastBlocker{{ID: "gt-a", Downstream: 2}, {ID: "gt-b", Downstream: 1}}
+	if !reflect.DeepEqual(f.Blockers, want) {
+		t.Errorf("Blockers = %v, want %v", f.Blockers, want)
+	}
+	if !f.ETA.Equal(forecastNow.Add(3 * time.Hour)) {
+		t.Errorf("ETA = %v, want now+3h", f.ETA)
+	}
+}
+
+func TestComputeForecast_Capacity(t *testing.T) {
+	issues := []ForecastIssue{
+		{ID: "gt-a", Status: "open"},
+		{ID: "gt-b", Status: "open"},
+		{ID: "gt-c", Status: "open"},
+		{ID: "gt-d", Status: "open"},
+	}
+	history := &ForecastHistory{Work: hours(1), Merge: hours(0.5)}
+
+	tests := []struct {
+		capacity int
+		want     time.Duration
+	}{
+		{0, 90 * time.Minute},
+		{-1, 90 * time.Minute},
+		{2, 150 * time.Minute},
+		{1, 270 * time.Minute}, // Merges overlap the next issue's work
+	}
+	for _, tt := range tests {
+		f := ComputeForecast(issues, history, tt.capacity, forecastNow)
+		if got := f.ETA.Sub(forecastNow); got != tt.want {
+			t.Errorf("capacity %d: ETA in %v, want %v", tt.capacity, got, tt.want)
+		}
+	}
+}
+
+func TestComputeForecast_IssuesUnderway(t *testing.T) {
+	history := &ForecastHistory{Work: hours(2), Merge: hours(1)}
+	tests := []struct {
+		name  string
+		issue ForecastIssue
+		want  time.Duration
+	}{
+		{"not started", ForecastIssue{ID: "gt-a", Status: "open"}, 3 * time.Hour},
+		{"working", ForecastIssue{ID: "gt-a", Status: "hooked", StartedAt: forecastNow.Add(-30 * time.Minute)}, 150 * time.Minute},
+		{"merging", ForecastIssue{ID: "gt-a", Status: "hooked", DoneAt: forecastNow.Add(-15 * time.Minute)}, 45 * time.Minute},
+		{"overdue", ForecastIssue{ID: "gt-a", Status: "hooked", DoneAt: forecastNow.Add(-5 * time.Hour)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := ComputeForecast([]ForecastIssue{tt.issue}, history, 0, forecastNow)
+			if got := f.ETA.Sub(forecastNow); got != tt.want {
+				t.Errorf("ETA in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeForecast_Bands(t *testing.T) {
+	issues := []ForecastIssue{{ID: "gt-a", Status: "open"}, {ID: "gt-b", Status: "open", BlockedBy: []string{"gt-a"}}}
+	f := ComputeForecast(issues, &ForecastHistory{Work: hours(1, 2, 3, 4, 8)}, 0, forecastNow)
+	if !f.Estimated() {
+		t.Fatal("expected an estimate")
+	}
+	if f.ETAEarly.After(f.ETA) || f.ETA.After(f.ETALate) || !f.ETAEarly.Before(f.ETALate) {
+		t.Errorf("bands out of order: %v %v %v", f.ETAEarly, f.ETA, f.ETALate)
+	}
+	again := ComputeForecast(issues, &ForecastHistory{Work: hours(1, 2, 3, 4, 8)}, 0, forecastNow)
+	if !reflect.DeepEqual(f, again) {
+		t.Error("forecast is not deterministic")
+	}
+}
+
+func TestComputeForecast_NoHistory(t *testing.T) {
+	issues := []ForecastIssue{
+		{ID: "gt-a", Status: "open"},
+		{ID: "gt-b", Status: "open", BlockedBy: []string{"gt-a"}},
+		{ID: "gt-c", Status: "open"},
+	}
+	f := ComputeForecast(issues, nil, 0, forecastNow)
+	if f.Estimated() || f.PathDuration != 0 {
+		t.Errorf("expected no estimate, got ETA %v path %v", f.ETA, f.PathDuration)
+	}
+	if want := []string{"gt-a", "gt-b"}; !reflect.DeepEqual(f.CriticalPath, want) {
+		t.Errorf("CriticalPath = %v, want %v", f.CriticalPath, want)
+	}
+}
+
+func TestComputeForecast_Cycle(t *testing.T) {
+	issues := []ForecastIssue{
+		{ID: "gt-a", Status: "open", BlockedBy: []string{"gt-b"}},
+		{ID: "gt-b", Status: "open", BlockedBy: []string{"gt-a"}},
+		{ID: "gt-c", Status: "open", BlockedBy: []string{"gt-b"}},
+	}
+	f := ComputeForecast(issues, &ForecastHistory{Work: hours(1)}, 1, forecastNow)
+	if !f.Estimated() || len(f.CriticalPath) == 0 {
+		t.Errorf("cyclic convoy not forecast: %+v", f)
+	}
+}
+
+func TestComputeForecast_Complete(t *testing.T) {
+	f := ComputeForecast([]ForecastIssue{{ID: "gt-a", Status: "closed"}}, &ForecastHistory{Work: hours(1)}, 0, forecastNow)
+	if f.Remaining != 0 || f.Estimated() || f.CriticalPath != nil {
+		t.Errorf("completed convoy forecast = %+v", f)
+	}
+}
+
+func TestForecastHistory_ReadEventsAndAnnotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	lines := []string{
+		`{"ts":"2026-03-01T08:00:00Z","type":"sling","payload":{"bead":"gt-a"}}`,
+		`{"ts":"2026-03-01T10:00:00Z","type":"done","payload":{"bead":"gt-a"}}`,
+		`{"ts":"2026-03-02T09:00:00Z","type":"sling","payload":{"bead":"gt-b"}}`,
+		`{"ts":"2026-03-02T09:30:00Z","type":"hook","payload":{"bead":"gt-b"}}`,
+		`not json`,
+		`{"ts":"2026-03-02T11:00:00Z","type":"done","payload":{"bead":"gt-c"}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := &ForecastHistory{Slung: map[string]time.Time{}, Done: map[string]time.Time{}}
+	h.readEvents(path, forecastNow.Add(-forecastHistoryWindow))
+
+	if !reflect.DeepEqual(h.Work, hours(2)) {
+		t.Errorf("Work = %v, want [2h]", h.Work)
+	}
+
+	a := ForecastIssue{ID: "gt-a", Status: "hooked"}
+	h.Annotate(&a)
+	if a.DoneAt.IsZero() || !a.StartedAt.IsZero() {
+		t.Errorf("gt-a should be merging: %+v", a)
+	}
+	b := ForecastIssue{ID: "gt-b", Status: "in_progress"}
+	h.Annotate(&b)
+	if !b.StartedAt.Equal(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)) || !b.DoneAt.IsZero() {
+		t.Errorf("gt-b should be working since its sling: %+v", b)
+	}
+	open := ForecastIssue{ID: "gt-b", Status: "open"}
+	h.Annotate(&open)
+	if !open.StartedAt.IsZero() {
+		t.Errorf("unhooked issue should not be working: %+v", open)
+	}
+}
+
+func TestForecastHistory_AddMerges(t *testing.T) {
+	mr := func(reason, created, closed string) *beads.Issue {
+		return &beads.Issue{
+			Description: "branch: polecat/nux\nclose_reason: " + reason,
+			CreatedAt:   created,
+			ClosedAt:    closed,
+		}
+	}
+	h := &ForecastHistory{}
+	h.addMerges([]*beads.Issue{
+		mr("merged", "2026-03-01T10:00:00Z", "2026-03-01T10:45:00Z"),
+		mr("rejected", "2026-03-01T10:00:00Z", "2026-03-01T11:00:00Z"),
+		mr("merged", "2026-01-01T10:00:00Z", "2026-01-01T11:00:00Z"), // Outside the window
+	}, forecastNow.Add(-forecastHistoryWindow))
+	if !reflect.DeepEqual(h.Merge, []time.Duration{45 * time.Minute}) {
+		t.Errorf("Merge = %v, want [45m]", h.Merge)
+	}
+}
+
+func TestFormatForecastDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{42 * time.Minute, "42m"},
+		{2 * time.Hour, "2h"},
+		{26*time.Hour + 20*time.Minute, "26h20m"},
+		{3 * 24 * time.Hour, "3d"},
+		{50 * time.Hour, "2d2h"},
+	}
+	for _, tt := range tests {
+		if got := FormatForecastDuration(tt.in); got != tt.want {
+			t.Errorf("FormatForecastDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package convoy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/steveyegge/gastown/internal/atomicfile"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/scheduler/cron"
+)
+
+// Template is a convoy skeleton that can be instantiated on demand or on a
+// cron schedule. Templates live in <townRoot>/settings/convoy-templates/<name>.toml.
+//
+//	name = "deps-weekly"
+//	title = "Dependency updates {{week}}"
+//	schedule = "0 9 * * 1"
+//
+//	[vars.ecosystem]
+//	default = "go"
+//
+//	[[issues]]
+//	id = "bump"
+//	rig = "gastown"
+//	title = "Bump {{ecosystem}} dependencies"
+//	formula = "mol-dep-update"
+//
+//	[[issues]]
+//	id = "verify"
+//	rig = "gastown"
+//	title = "Run the full test matrix"
+//	needs = ["bump"]
+type Template struct {
+	Name        string `toml:"name"`
+	Title       string `toml:"title"`
+	Description string `toml:"description"`
+
+	// Schedule is a five-field cron expression (or @daily, @weekly, ...)
+	// on which the daemon instantiates the template. Empty = manual only.
+	Schedule string `toml:"schedule"`
+	// Timezone is the IANA zone Schedule is evaluated in. Default: local time.
+	Timezone string `toml:"timezone"`
+
+	Owner string `toml:"owner"` // Receives the completion notification
+	Merge string `toml:"merge"` // Merge strategy: direct, mr, local
+
+	Labels []string               `toml:"labels"` // Added to every issue
+	Vars   map[string]TemplateVar `toml:"vars"`
+	Issues []TemplateIssue        `toml:"issues"`
+}
+
+// TemplateVar declares a variable used in {{name}} placeholders.
+type TemplateVar struct {
+	Description string `toml:"description"`
+	Default     string `toml:"default"`
+	Required    bool   `toml:"required"`
+}
+
+// TemplateIssue is one issue a template instantiates.
+type TemplateIssue struct {
+	ID          string            `toml:"id"` // Local key referenced by needs
+	Rig         string            `toml:"rig"`
+	Title       string            `toml:"title"`
+	Description string            `toml:"description"`
+	Type        string            `toml:"type"`
+	Priority    *int              `toml:"priority"`
+	Labels      []string          `toml:"labels"`
+	Formula     string            `toml:"formula"` // Formula gt sling applies
+	Vars        map[string]string `toml:"vars"`    // Formula variables
+	Needs       []string          `toml:"needs"`   // Local IDs this issue is blocked by
+}
+
+// RenderedIssue is a template issue with its variables substituted.
+type RenderedIssue struct {
+	ID          string
+	Rig         string
+	Title       string
+	Description string
+	Type        string
+	Priority    int
+	Labels      []string
+	Formula     string
+	FormulaVars []string // key=value, sorted by key
+	Needs       []string
+}
+
+// RenderedTemplate is a template ready to instantiate. Issues are in
+// dependency order: every issue follows the issues it needs.
+type RenderedTemplate struct {
+	Title       string
+	Description string
+	Issues      []RenderedIssue
+}
+
+const (
+	// TemplatesDir is the town-relative directory holding convoy templates.
+	TemplatesDir = "settings/convoy-templates"
+
+	defaultTemplatePriority = 2
+)
+
+var (
+	templateNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	templateVarPattern   = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+	templateBuiltinNames = []string{"date", "month", "template", "week", "year"}
+)
+
+// TemplatePath returns where the named template is stored.
+func TemplatePath(townRoot, name string) string {
+	return filepath.Join(townRoot, TemplatesDir, name+".toml")
+}
+
+// ParseTemplate parses and validates convoy template TOML.
+func ParseTemplate(data []byte) (*Template, error) {
+	var t Template
+	if _, err := toml.Decode(string(data), &t); err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// LoadTemplate reads the named template from the town.
+func LoadTemplate(townRoot, name string) (*Template, error) {
+	data, err := os.ReadFile(TemplatePath(townRoot, name)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("convoy template %q not found", name)
+		}
+		return nil, err
+	}
+	t, err := ParseTemplate(data)
+	if err != nil {
+		return nil, fmt.Errorf("convoy template %q: %w", name, err)
+	}
+	if t.Name != name {
+		return nil, fmt.Errorf("convoy template %q: file declares name %q", name, t.Name)
+	}
+	return t, nil
+}
+
+// ListTemplates loads every template in the town, sorted by name. Templates
+// that fail to load are reported in errs rather than aborting the listing.
+func ListTemplates(townRoot string) (templates []*Template, errs []error) {
+	entries, err := os.ReadDir(filepath.Join(townRoot, TemplatesDir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+		return nil, errs
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".toml")
+		if e.IsDir() || !ok {
+			continue
+		}
+		t, err := LoadTemplate(townRoot, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, errs
+}
+
+// Validate checks the template's name, schedule, issues and dependency graph,
+// and that every placeholder refers to a declared or built-in variable.
+func (t *Template) Validate() error {
+	if !templateNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits, '-' or '_'", t.Name)
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	if _, _, err := t.schedule(); err != nil {
+		return err
+	}
+	switch t.Merge {
+	case "", "direct", "mr", "local":
+	default:
+		return fmt.Errorf("merge %q must be direct, mr, or local", t.Merge)
+	}
+	if len(t.Issues) == 0 {
+		return fmt.Errorf("at least one [[issues]] entry is required")
+	}
+
+	for name, v := range t.Vars {
+		if isTemplateBuiltin(name) {
+			return fmt.Errorf("var %q shadows a built-in variable", name)
+		}
+		if t.Schedule != "" && v.Required && v.Default == "" {
+			return fmt.Errorf("var %q is required without a default, so the template cannot run on a schedule", name)
+		}
+	}
+
+	ids := make(map[string]bool, len(t.Issues))
+	for i, issue := range t.Issues {
+		switch {
+		case issue.ID == "":
+			return fmt.Errorf("issue %d: id is required", i+1)
+		case ids[issue.ID]:
+			return fmt.Errorf("issue %q: duplicate id", issue.ID)
+		case strings.TrimSpace(issue.Title) == "":
+			return fmt.Errorf("issue %q: title is required", issue.ID)
+		case issue.Rig == "":
+			return fmt.Errorf("issue %q: rig is required", issue.ID)
+		case issue.Priority != nil && (*issue.Priority < 0 || *issue.Priority > 4):
+			return fmt.Errorf("issue %q: priority must be 0-4", issue.ID)
+		}
+		ids[issue.ID] = true
+	}
+	for _, issue := range t.Issues {
+		for _, need := range issue.Needs {
+			if !ids[need] {
+				return fmt.Errorf("issue %q needs unknown issue %q", issue.ID, need)
+			}
+			if need == issue.ID {
+				return fmt.Errorf("issue %q needs itself", issue.ID)
+			}
+		}
+	}
+	if _, err := t.dependencyOrder(); err != nil {
+		return err
+	}
+
+	for _, name := range t.placeholders() {
+		if _, ok := t.Vars[name]; !ok && !isTemplateBuiltin(name) {
+			return fmt.Errorf("placeholder {{%s}} is not a declared var", name)
+		}
+	}
+	return nil
+}
+
+// schedule parses the template's cron schedule and timezone. A template
+// without a schedule returns a nil schedule.
+func (t *Template) schedule() (*cron.Schedule, *time.Location, error) {
+	loc := time.Local
+	if t.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(t.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("timezone %q: %w", t.Timezone, err)
+		}
+	}
+	if t.Schedule == "" {
+		return nil, loc, nil
+	}
+	sched, err := cron.Parse(t.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("schedule: %w", err)
+	}
+	return sched, loc, nil
+}
+
+// NextRun returns the template's next scheduled run after now, or the zero
+// time for templates without a schedule.
+func (t *Template) NextRun(now time.Time) time.Time {
+	sched, loc, err := t.schedule()
+	if err != nil || sched == nil {
+		return time.Time{}
+	}
+	return sched.Next(now.In(loc))
+}
+
+// Due reports whether a scheduled run has come up since lastCheck, the time
+// the template was last run or skipped. Missed runs collapse into one, so a
+// daemon that was down over several runs instantiates a single convoy.
+func (t *Template) Due(lastCheck, now time.Time) bool {
+	sched, loc, err := t.schedule()
+	if err != nil || sched == nil {
+		return false
+	}
+	prev := sched.Prev(now.In(loc))
+	return !prev.IsZero() && prev.After(lastCheck)
+}
+
+// dependencyOrder returns the issue indexes ordered so every issue follows
+// the issues it needs, or an error naming an issue on a cycle.
+func (t *Template) dependencyOrder() ([]int, error) {
+	index := make(map[string]int, len(t.Issues))
+	for i, issue := range t.Issues {
+		index[issue.ID] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(t.Issues))
+	order := make([]int, 0, len(t.Issues))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("issue %q is part of a dependency cycle", t.Issues[i].ID)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, need := range t.Issues[i].Needs {
+			if j, ok := index[need]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+	for i := range t.Issues {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// placeholders returns the variable names used across the template's text.
+func (t *Template) placeholders() []string {
+	texts := []string{t.Title, t.Description}
+	for _, issue := range t.Issues {
+		texts = append(texts, issue.Title, issue.Description, issue.Formula)
+		texts = append(texts, issue.Labels...)
+		for _, v := range issue.Vars {
+			texts = append(texts, v)
+		}
+	}
+	texts = append(texts, t.Labels...)
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, text := range texts {
+		for _, m := range templateVarPattern.FindAllStringSubmatch(text, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveVars merges overrides onto the template's defaults and built-ins
+// (date, week, month, year, template), evaluated at now in the template's
+// timezone. Unknown overrides and missing required variables are errors.
+func (t *Template) ResolveVars(overrides map[string]string, now time.Time) (map[string]string, error) {
+	_, loc, err := t.schedule()
+	if err != nil {
+		return nil, err
+	}
+	local := now.In(loc)
+	year, week := local.ISOWeek()
+	vars := map[string]string{
+		"date":     local.Format("2006-01-02"),
+		"month":    local.Format("2006-01"),
+		"year":     local.Format("2006"),
+		"week":     fmt.Sprintf("%d-W%02d", year, week),
+		"template": t.Name,
+	}
+	for name, v := range t.Vars {
+		if v.Default != "" {
+			vars[name] = v.Default
+		}
+	}
+
+	var unknown []string
+	for name, value := range overrides {
+		if _, ok := t.Vars[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		vars[name] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown template vars: %s", strings.Join(unknown, ", "))
+	}
+
+	var missing []string
+	for name, v := range t.Vars {
+		if _, ok := vars[name]; !ok && v.Required {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required template vars: %s", strings.Join(missing, ", "))
+	}
+	return vars, nil
+}
+
+// Render substitutes vars into the template. Placeholders for optional
+// variables without a value render empty.
+func (t *Template) Render(vars map[string]string) (*RenderedTemplate, error) {
+	order, err := t.dependencyOrder()
+	if err != nil {
+		return nil, err
+	}
+	sub := func(s string) string {
+		return templateVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+			return vars[templateVarPattern.FindStringSubmatch(m)[1]]
+		})
+	}
+	subAll := func(in []string) []string {
+		var out []string
+		for _, s := range in {
+			if s = strings.TrimSpace(sub(s)); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	r := &RenderedTemplate{Title: sub(t.Title), Description: sub(t.Description)}
+	for _, i := range order {
+		issue := t.Issues[i]
+		ri := RenderedIssue{
+			ID:          issue.ID,
+			Rig:         issue.Rig,
+			Title:       sub(issue.Title),
+			Description: sub(issue.Description),
+			Type:        issue.Type,
+			Priority:    defaultTemplatePriority,
+			Labels:      subAll(append(append([]string(nil), t.Labels...), issue.Labels...)),
+			Formula:     sub(issue.Formula),
+			Needs:       issue.Needs,
+		}
+		if ri.Type == "" {
+			ri.Type = "task"
+		}
+		if issue.Priority != nil {
+			ri.Priority = *issue.Priority
+		}
+		keys := make([]string, 0, len(issue.Vars))
+		for k := range issue.Vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ri.FormulaVars = append(ri.FormulaVars, k+"="+sub(issue.Vars[k]))
+		}
+		r.Issues = append(r.Issues, ri)
+	}
+	return r, nil
+}
+
+func isTemplateBuiltin(name string) bool {
+	i := sort.SearchStrings(templateBuiltinNames, name)
+	return i < len(templateBuiltinNames) && templateBuiltinNames[i] == name
+}
+
+// TemplateRun records a template's latest instantiation.
+type TemplateRun struct {
+	// LastCheck is when the schedule was last acted on: the template was run,
+	// skipped because its previous convoy was still open, or first seen.
+	LastCheck time.Time `json:"last_check"`
+	// LastRun is when the template last created a convoy.
+	LastRun    time.Time `json:"last_run,omitzero"`
+	LastConvoy string    `json:"last_convoy,omitempty"`
+	// LastSkip explains why the latest scheduled run was skipped.
+	LastSkip string `json:"last_skip,omitempty"`
+}
+
+// TemplateState is the runtime state of a town's convoy templates, keyed by
+// template name. Stored at <townRoot>/.runtime/convoy-templates.json.
+type TemplateState map[string]*TemplateRun
+
+func templateStatePath(townRoot string) string {
+	return filepath.Join(townRoot, constants.DirRuntime, "convoy-templates.json")
+}
+
+// LoadTemplateState loads the template run state. A missing file is an
+// empty state.
+func LoadTemplateState(townRoot string) (TemplateState, error) {
+	state := make(TemplateState)
+	data, err := os.ReadFile(templateStatePath(townRoot)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing convoy template state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveTemplateState writes the template run state.
+func SaveTemplateState(townRoot string, state TemplateState) error {
+	return atomicfile.EnsureDirAndWriteJSON(templateStatePath(townRoot), state)
+}
+
+// DueTemplates returns the scheduled templates with a run due at now.
+// Templates seen for the first time start their schedule from now rather
+// than running at once; their state is recorded before returning. Templates
+// that fail to load are reported in the error alongside the due list.
+func DueTemplates(townRoot string, now time.Time) ([]*Template, error) {
+	templates, errs := ListTemplates(townRoot)
+	state, err := LoadTemplateState(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	var due []*Template
+	seen := false
+	for _, t := range templates {
+		if t.Schedule == "" {
+			continue
+		}
+		run := state[t.Name]
+		if run == nil {
+			state[t.Name] = &TemplateRun{LastCheck: now}
+			seen = true
+			continue
+		}
+		if t.Due(run.LastCheck, now) {
+			due = append(due, t)
+		}
+	}
+	if seen {
+		if err := SaveTemplateState(townRoot, state); err != nil {
+			return nil, err
+		}
+	}
+	return due, errors.Join(errs...)
+}
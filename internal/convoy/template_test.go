@@ -0,0 +1,227 @@
+package convoy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const depsWeeklyTemplate = `
+name = "deps-weekly"
+title = "Dependency updates {{week}}"
+schedule = "0 9 * * 1"
+timezone = "UTC"
+labels = ["maintenance"]
+
+[vars.ecosystem]
+default = "go"
+
+[vars.reviewer]
+
+[[issues]]
+id = "verify"
+rig = "gastown"
+title = "Verify {{ecosystem}} updates"
+description = "Reviewer: {{reviewer}}"
+needs = ["bump", "audit"]
+
+[[issues]]
+id = "bump"
+rig = "gastown"
+title = "Bump {{ecosystem}} dependencies"
+formula = "mol-dep-update"
+priority = 1
+vars = { ecosystem = "{{ecosystem}}", since = "{{date}}" }
+
+[[issues]]
+id = "audit"
+rig = "beads"
+title = "Audit licenses"
+labels = ["legal"]
+`
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(depsWeeklyTemplate))
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	if tmpl.Name != "deps-weekly" || len(tmpl.Issues) != 3 {
+		t.Errorf("parsed %q with %d issues", tmpl.Name, len(tmpl.Issues))
+	}
+}
+
+func TestTemplateValidate_Errors(t *testing.T) {
+	base := func() *Template {
+		return &Template{
+			Name:  "nightly",
+			Title: "Nightly triage",
+			Issues: []TemplateIssue{
+				{ID: "a", Rig: "gastown", Title: "A"},
+				{ID: "b", Rig: "gastown", Title: "B", Needs: []string{"a"}},
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		mutate func(*Template)
+		want   string
+	}{
+		{"bad name", func(t *Template) { t.Name = "Nightly Triage" }, "name"},
+		{"bad schedule", func(t *Template) { t.Schedule = "every night" }, "schedule"},
+		{"bad timezone", func(t *Template) { t.Timezone = "Mars/Olympus" }, "timezone"},
+		{"bad merge", func(t *Template) { t.Merge = "squash" }, "merge"},
+		{"no issues", func(t *Template) { t.Issues = nil }, "at least one"},
+		{"duplicate id", func(t *Template) { t.Issues[1].ID = "a" }, "duplicate"},
+		{"no rig", func(t *Template) { t.Issues[0].Rig = "" }, "rig is required"},
+		{"unknown need", func(t *Template) { t.Issues[1].Needs = []string{"c"} }, "unknown issue"},
+		{"cycle", func(t *Template) { t.Issues[0].Needs = []string{"b"} }, "cycle"},
+		{"undeclared var", func(t *Template) { t.Title = "Triage {{night}}" }, "{{night}}"},
+		{"shadowed builtin", func(t *Template) { t.Vars = map[string]TemplateVar{"date": {}} }, "built-in"},
+		{"scheduled required var", func(t *Template) {
+			t.Schedule = "@daily"
+			t.Vars = map[string]TemplateVar{"owner": {Required: true}}
+		}, "cannot run on a schedule"},
+	}
+	if err := base().Validate(); err != nil {
+		t.Fatalf("base template invalid: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := base()
+			tt.mutate(tmpl)
+			err := tmpl.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(depsWeeklyTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	vars, err := tmpl.ResolveVars(map[string]string{"ecosystem": "npm"}, now)
+	if err != nil {
+		t.Fatalf("ResolveVars: %v", err)
+	}
+	r, err := tmpl.Render(vars)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if r.Title != "Dependency updates 2026-W43" {
+		t.Errorf("Title = %q", r.Title)
+	}
+	var order []string
+	for _, issue := range r.Issues {
+		order = append(order, issue.ID)
+	}
+	if want := []string{"bump", "audit", "verify"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("issue order = %v, want %v", order, want)
+	}
+
+	bump := r.Issues[0]
+	if bump.Title != "Bump npm dependencies" || bump.Priority != 1 || bump.Type != "task" {
+		t.Errorf("bump = %+v", bump)
+	}
+	if want := []string{"ecosystem=npm", "since=2026-10-19"}; !reflect.DeepEqual(bump.FormulaVars, want) {
+		t.Errorf("FormulaVars = %v, want %v", bump.FormulaVars, want)
+	}
+	audit := r.Issues[1]
+	if want := []string{"maintenance", "legal"}; !reflect.DeepEqual(audit.Labels, want) {
+		t.Errorf("audit labels = %v, want %v", audit.Labels, want)
+	}
+	if audit.Priority != defaultTemplatePriority {
+		t.Errorf("audit priority = %d, want default", audit.Priority)
+	}
+	if verify := r.Issues[2]; verify.Description != "Reviewer: " {
+		t.Errorf("optional var without value should render empty, got %q", verify.Description)
+	}
+}
+
+func TestTemplateResolveVars_Errors(t *testing.T) {
+	tmpl := &Template{Name: "audit", Vars: map[string]TemplateVar{"scope": {Required: true}}}
+	if _, err := tmpl.ResolveVars(nil, time.Now()); err == nil || !strings.Contains(err.Error(), "scope") {
+		t.Errorf("missing required var: err = %v", err)
+	}
+	if _, err := tmpl.ResolveVars(map[string]string{"scope": "all", "extra": "x"}, time.Now()); err == nil || !strings.Contains(err.Error(), "extra") {
+		t.Errorf("unknown var: err = %v", err)
+	}
+}
+
+func TestTemplateDue(t *testing.T) {
+	tmpl := &Template{Name: "nightly", Schedule: "0 2 * * *", Timezone: "UTC"}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		lastCheck time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"before first run", at(18, 1, 0), at(18, 1, 59), false},
+		{"at run time", at(18, 1, 0), at(18, 2, 0), true},
+		{"already handled", at(18, 2, 0), at(18, 14, 0), false},
+		{"missed runs collapse", at(15, 3, 0), at(18, 14, 0), true},
+	}
+	for _, tt := range tests {
+		if got := tmpl.Due(tt.lastCheck, tt.now); got != tt.want {
+			t.Errorf("%s: Due = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (&Template{Name: "manual"}).Due(time.Time{}, at(18, 2, 0)) {
+		t.Error("template without schedule should never be due")
+	}
+	if next := tmpl.NextRun(at(18, 2, 0)); !next.Equal(at(19, 2, 0)) {
+		t.Errorf("NextRun = %v, want the next night", next)
+	}
+}
+
+func TestDueTemplates(t *testing.T) {
+	townRoot := t.TempDir()
+	dir := filepath.Join(townRoot, TemplatesDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	issue := "\n[[issues]]\nid = \"a\"\nrig = \"gastown\"\ntitle = \"A\"\n"
+	write("nightly.toml", "name = \"nightly\"\ntitle = \"Nightly\"\nschedule = \"0 2 * * *\"\ntimezone = \"UTC\"\n"+issue)
+	write("manual.toml", "name = \"manual\"\ntitle = \"Manual\"\n"+issue)
+	write("broken.toml", "name = \"broken\"\n")
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	due, err := DueTemplates(townRoot, now)
+	if len(due) != 0 {
+		t.Errorf("first sight should not run, got %d due", len(due))
+	}
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("broken template not reported: %v", err)
+	}
+
+	state, err := LoadTemplateState(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run := state["nightly"]; run == nil || !run.LastCheck.Equal(now) {
+		t.Fatalf("nightly state = %+v, want LastCheck now", run)
+	}
+	if _, ok := state["manual"]; ok {
+		t.Error("manual template should not get schedule state")
+	}
+
+	due, _ = DueTemplates(townRoot, now.Add(15*time.Hour))
+	if len(due) != 1 || due[0].Name != "nightly" {
+		t.Errorf("due after 02:00 = %v, want nightly", due)
+	}
+}
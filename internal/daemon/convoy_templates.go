@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+const defaultConvoyTemplatesInterval = time.Minute
+
+// ConvoyTemplatesConfig holds configuration for the convoy_templates patrol.
+// This patrol instantiates convoy templates (settings/convoy-templates/*.toml)
+// on their cron schedules by running `gt convoy template run --due`.
+type ConvoyTemplatesConfig struct {
+	// Enabled controls whether scheduled convoy templates run.
+	Enabled bool `json:"enabled"`
+
+	// IntervalStr is how often schedules are checked, as a string (e.g., "1m").
+	// Schedules have minute granularity, so longer intervals delay runs.
+	IntervalStr string `json:"interval,omitempty"`
+}
+
+// convoyTemplatesInterval returns the configured interval, or the default (1m).
+func convoyTemplatesInterval(config *DaemonPatrolConfig) time.Duration {
+	if config != nil && config.Patrols != nil && config.Patrols.ConvoyTemplates != nil {
+		if config.Patrols.ConvoyTemplates.IntervalStr != "" {
+			if d, err := time.ParseDuration(config.Patrols.ConvoyTemplates.IntervalStr); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return defaultConvoyTemplatesInterval
+}
+
+// runConvoyTemplates instantiates any convoy templates whose schedule has
+// come up. The due check runs in-process so idle ticks don't spawn gt.
+func (d *Daemon) runConvoyTemplates() {
+	if !d.isPatrolActive("convoy_templates") {
+		return
+	}
+
+	due, err := convoy.DueTemplates(d.config.TownRoot, time.Now())
+	if err != nil {
+		d.logger.Printf("convoy_templates: %v", err)
+	}
+	if len(due) == 0 {
+		return
+	}
+	names := make([]string, len(due))
+	for i, t := range due {
+		names[i] = t.Name
+	}
+	d.logger.Printf("convoy_templates: due: %s", strings.Join(names, ", "))
+
+	cmd := exec.CommandContext(d.ctx, d.gtPath, "convoy", "template", "run", "--due")
+	cmd.Dir = d.config.TownRoot
+	util.SetDetachedProcessGroup(cmd)
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			d.logger.Printf("convoy_templates: %s", line)
+		}
+	}
+	if err != nil {
+		d.logger.Printf("convoy_templates: gt convoy template run failed: %v", err)
+		d.escalate("convoy_templates", fmt.Sprintf("gt convoy template run --due failed: %v", err))
+	}
+}
@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConvoyTemplatesInterval(t *testing.T) {
+	if got := convoyTemplatesInterval(nil); got != defaultConvoyTemplatesInterval {
+		t.Errorf("expected default %v, got %v", defaultConvoyTemplatesInterval, got)
+	}
+
+	config := &DaemonPatrolConfig{
+		Patrols: &PatrolsConfig{
+			ConvoyTemplates: &ConvoyTemplatesConfig{Enabled: true, IntervalStr: "5m"},
+		},
+	}
+	if got := convoyTemplatesInterval(config); got != 5*time.Minute {
+		t.Errorf("expected 5m, got %v", got)
+	}
+
+	config.Patrols.ConvoyTemplates.IntervalStr = "bad"
+	if got := convoyTemplatesInterval(config); got != defaultConvoyTemplatesInterval {
+		t.Errorf("expected default for invalid interval, got %v", got)
+	}
+}
+
+func TestIsPatrolEnabledConvoyTemplates(t *testing.T) {
+	// Opt-in: disabled without explicit config
+	if IsPatrolEnabled(nil, "convoy_templates") {
+		t.Error("expected convoy_templates disabled with nil config")
+	}
+	if IsPatrolEnabled(&DaemonPatrolConfig{Patrols: &PatrolsConfig{}}, "convoy_templates") {
+		t.Error("expected convoy_templates disabled without patrol config")
+	}
+
+	config := &DaemonPatrolConfig{
+		Patrols: &PatrolsConfig{ConvoyTemplates: &ConvoyTemplatesConfig{Enabled: true}},
+	}
+	if !IsPatrolEnabled(config, "convoy_templates") {
+		t.Error("expected convoy_templates enabled")
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -107,6 +108,11 @@ type Daemon struct {
 	// Only accessed from heartbeat loop goroutine - no sync needed.
 	lastDoctorMolTime time.Time
 
+	// lastDoltHealth is the Dolt health snapshot from the current heartbeat,
+	// sampled into the metrics history. Nil when the Dolt server isn't managed.
+	// Only accessed from heartbeat loop goroutine - no sync needed.
+	lastDoltHealth *doltserver.HealthMetrics
+
 	// lastMaintenanceRun tracks when scheduled maintenance last ran.
 	// Only accessed from heartbeat loop goroutine - no sync needed.
 	lastMaintenanceRun time.Time
@@ -717,6 +723,18 @@ func (d *Daemon) Run() (err error) {
 		d.logger.Printf("Quota dog ticker started (interval %v)", interval)
 	}
 
+	// Start convoy templates ticker if configured.
+	// Instantiates convoy templates whose cron schedule has come up.
+	var convoyTemplatesTicker *time.Ticker
+	var convoyTemplatesChan <-chan time.Time
+	if d.isPatrolActive("convoy_templates") {
+		interval := convoyTemplatesInterval(d.patrolConfig)
+		convoyTemplatesTicker = time.NewTicker(interval)
+		convoyTemplatesChan = convoyTemplatesTicker.C
+		defer convoyTemplatesTicker.Stop()
+		d.logger.Printf("Convoy templates ticker started (interval %v)", interval)
+	}
+
 	// Note: PATCH-010 uses per-session hooks in deacon/manager.go (SetAutoRespawnHook).
 	// Global pane-died hooks don't fire reliably in tmux 3.2a, so we rely on the
 	// per-session approach which has been tested to work for continuous recovery.
@@ -826,6 +844,13 @@ func (d *Daemon) Run() (err error) {
 				d.runQuotaDog()
 			}
 
+		case <-convoyTemplatesChan:
+			// Convoy templates — instantiates recurring convoys (weekly
+			// dependency updates, nightly triage, ...) on their schedules.
+			if !d.isShutdownInProgress() {
+				d.runConvoyTemplates()
+			}
+
 		case <-timer.C:
 			d.heartbeat(state)
 
@@ -1003,6 +1028,10 @@ func (d *Daemon) heartbeat(state *State) {
 	// daemon.log uses lumberjack for automatic rotation; this handles Dolt server logs.
 	d.rotateOversizedLogs()
 
+	// 16. Sample key gauges into the town's metrics history (dashboard trends,
+	// gt metrics history).
+	d.recordMetricsHistory()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
@@ -1047,9 +1076,11 @@ func (d *Daemon) ensureDoltServerRunning() {
 		}
 	}
 
-	// Update OTel gauges with the latest Dolt health snapshot.
+	// Keep the latest Dolt health snapshot for the metrics history and
+	// update OTel gauges with it.
+	h := doltserver.GetHealthMetrics(d.config.TownRoot)
+	d.lastDoltHealth = h
 	if d.metrics != nil {
-		h := doltserver.GetHealthMetrics(d.config.TownRoot)
 		d.metrics.updateDoltHealth(
 			int64(h.Connections),
 			int64(h.MaxConnections),
@@ -3071,6 +3102,30 @@ func (d *Daemon) pruneStaleBranches() {
 	pruneInDir(d.config.TownRoot, "town-root")
 }
 
+// townDispatchWindowClosed reports whether the town-wide dispatch window
+// (scheduler.windows in settings/config.json) is closed at now, and when it
+// next opens. Per-rig windows are enforced by `gt scheduler run` itself; the
+// town window lets the daemon skip the subprocess entirely. Missing or invalid
+// config returns false so `gt scheduler run` can report the problem.
+func townDispatchWindowClosed(townRoot string, now time.Time) (bool, time.Time) {
+	data, err := os.ReadFile(filepath.Join(townRoot, "settings", "config.json")) //nolint:gosec // G304: path constructed internally
+	if err != nil {
+		return false, time.Time{}
+	}
+	var raw struct {
+		Scheduler *capacity.SchedulerConfig `json:"scheduler"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil || raw.Scheduler == nil || raw.Scheduler.Windows == nil {
+		return false, time.Time{}
+	}
+	windows, err := capacity.CompileDispatchWindows(&capacity.SchedulerConfig{Windows: raw.Scheduler.Windows})
+	if err != nil || windows.IsOpen("", now) {
+		return false, time.Time{}
+	}
+	next, _ := windows.NextOpen("", now)
+	return true, next
+}
+
 // dispatchQueuedWork shells out to `gt scheduler run` to dispatch scheduled beads.
 // This avoids circular import between the daemon and cmd packages.
 // Uses a 5m timeout to allow multi-bead dispatch with formula cooking and hook retries.
@@ -3081,6 +3136,14 @@ func (d *Daemon) pruneStaleBranches() {
 // prevents double-dispatch on the next cycle. The batch_size config (default: 1)
 // limits how many beads are in-flight per heartbeat, reducing the timeout window.
 func (d *Daemon) dispatchQueuedWork() {
+	if closed, next := townDispatchWindowClosed(d.config.TownRoot, time.Now()); closed {
+		if next.IsZero() {
+			d.logger.Printf("Scheduler dispatch skipped: outside town dispatch window (no upcoming window)")
+		} else {
+			d.logger.Printf("Scheduler dispatch skipped: outside town dispatch window (next opening %s)", next.Format(time.RFC3339))
+		}
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, "gt", "scheduler", "run")
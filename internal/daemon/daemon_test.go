@@ -986,3 +986,46 @@ func TestIsRigOperational_DockedRig(t *testing.T) {
 	}
 	t.Logf("Docked rig check returned: operational=%v, reason=%q", operational, reason)
 }
+
+func TestTownDispatchWindowClosed(t *testing.T) {
+	townRoot := t.TempDir()
+	settingsDir := filepath.Join(townRoot, "settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeSettings := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(settingsDir, "config.json"), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	monday := func(hour int) time.Time { return time.Date(2026, 3, 2, hour, 0, 0, 0, time.UTC) }
+
+	if closed, _ := townDispatchWindowClosed(townRoot, monday(3)); closed {
+		t.Error("missing settings must not close the window")
+	}
+
+	writeSettings(`{"scheduler":{"windows":{"timezone":"UTC","windows":[{"cron":"0 9 * * 1-5","duration":"8h"}]}}}`)
+	closed, next := townDispatchWindowClosed(townRoot, monday(3))
+	if !closed {
+		t.Fatal("03:00 Monday should be outside the 09:00-17:00 window")
+	}
+	if !next.Equal(monday(9)) {
+		t.Errorf("next opening = %v, want %v", next, monday(9))
+	}
+	if closed, _ := townDispatchWindowClosed(townRoot, monday(10)); closed {
+		t.Error("10:00 Monday should be inside the window")
+	}
+
+	// Rig-only windows are enforced by gt scheduler run, not the daemon.
+	writeSettings(`{"scheduler":{"rig_windows":{"gastown":{"windows":[{"cron":"0 9 * * 1-5","duration":"1h"}]}}}}`)
+	if closed, _ := townDispatchWindowClosed(townRoot, monday(3)); closed {
+		t.Error("rig-only windows must not skip town dispatch")
+	}
+
+	// Invalid config defers to gt scheduler run for reporting.
+	writeSettings(`{"scheduler":{"windows":{"timezone":"Mars/Olympus"}}}`)
+	if closed, _ := townDispatchWindowClosed(townRoot, monday(3)); closed {
+		t.Error("invalid window config must not skip dispatch")
+	}
+}
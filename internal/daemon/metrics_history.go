@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/trends"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// recordMetricsHistory samples the town's key gauges into the metrics history
+// store (<town>/.runtime/metrics). Each source is best-effort: a gauge that
+// can't be read is left out of the sample rather than recorded as zero, so a
+// transient bd or tmux failure doesn't draw a false dip on the trend charts.
+func (d *Daemon) recordMetricsHistory() {
+	townRoot := d.config.TownRoot
+	values := make(map[string]float64)
+
+	if sessions, err := tmux.NewTmux().ListSessions(); err == nil {
+		agents, polecats := 0, 0
+		for _, name := range sessions {
+			if !isAgentSession(name) {
+				continue
+			}
+			agents++
+			if id, err := session.ParseSessionName(name); err == nil && id.Role == session.RolePolecat {
+				polecats++
+			}
+		}
+		values[trends.AgentSessions] = float64(agents)
+		values[trends.ActivePolecats] = float64(polecats)
+	}
+
+	townBeads := beads.NewWithBeadsDir(townRoot, beads.ResolveBeadsDir(townRoot))
+	if contexts, err := townBeads.ListOpenSlingContexts(); err == nil {
+		values[trends.QueueDepth] = float64(len(contexts))
+	}
+	if escalations, err := townBeads.ListEscalations(); err == nil {
+		values[trends.OpenEscalations] = float64(len(escalations))
+	}
+
+	depth, oldest, queuesRead := 0, time.Duration(0), false
+	for _, rigName := range d.getKnownRigs() {
+		queue, err := refinery.NewManager(&rig.Rig{
+			Name: rigName,
+			Path: filepath.Join(townRoot, rigName),
+		}).Queue()
+		if err != nil {
+			continue
+		}
+		queuesRead = true
+		depth += len(queue)
+		for _, item := range queue {
+			if item.MR != nil && !item.MR.CreatedAt.IsZero() {
+				oldest = max(oldest, time.Since(item.MR.CreatedAt))
+			}
+		}
+	}
+	if queuesRead {
+		values[trends.MergeQueueDepth] = float64(depth)
+		values[trends.MRWaitSeconds] = oldest.Seconds()
+	}
+
+	// The Dolt snapshot is taken once per heartbeat in ensureDoltServerRunning.
+	if h := d.lastDoltHealth; h != nil {
+		values[trends.DoltLatencyMs] = float64(h.QueryLatency.Milliseconds())
+		values[trends.DoltConnections] = float64(h.Connections)
+		d.lastDoltHealth = nil
+	}
+
+	if spent, ok := costsToday(time.Now()); ok {
+		values[trends.CostTodayUSD] = spent
+	}
+
+	if len(values) == 0 {
+		return
+	}
+	if err := trends.Open(townRoot).Record(time.Now(), values); err != nil {
+		d.logger.Printf("metrics_history: failed to record sample: %v", err)
+	}
+}
+
+// costsToday sums the session costs logged since local midnight.
+// Reports false when the cost log doesn't exist or can't be read.
+func costsToday(now time.Time) (float64, bool) {
+	f, err := os.Open(util.CostsLogPath())
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var total float64
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			CostUSD float64   `json:"cost_usd"`
+			EndedAt time.Time `json:"ended_at"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if !entry.EndedAt.Before(midnight) {
+			total += entry.CostUSD
+		}
+	}
+	return total, scanner.Err() == nil
+}
@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCostsToday(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("GT_HOME", home)
+
+	if _, ok := costsToday(time.Now()); ok {
+		t.Fatal("costsToday reported a total without a cost log")
+	}
+
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	log := `{"session_id":"a","role":"polecat","cost_usd":1.25,"ended_at":"2026-03-02T01:00:00Z"}
+{"session_id":"b","role":"witness","cost_usd":4,"ended_at":"2026-03-01T23:59:00Z"}
+not json
+{"session_id":"c","role":"mayor","cost_usd":0.5,"ended_at":"2026-03-02T14:30:00Z"}
+`
+	if err := os.MkdirAll(filepath.Join(home, ".gt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".gt", "costs.jsonl"), []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := costsToday(now)
+	if !ok || got != 1.75 {
+		t.Errorf("costsToday = %v, %v; want 1.75, true", got, ok)
+	}
+}
@@ -131,6 +131,7 @@ type PatrolsConfig struct {
 	MainBranchTest         *MainBranchTestConfig          `json:"main_branch_test,omitempty"`
 	QuotaDog               *QuotaDogConfig                `json:"quota_dog,omitempty"`
 	RestartTracker         *RestartTrackerConfig          `json:"restart_tracker,omitempty"`
+	ConvoyTemplates        *ConvoyTemplatesConfig         `json:"convoy_templates,omitempty"`
 }
 
 // DoltRemotesConfig holds configuration for the dolt_remotes patrol.
@@ -308,6 +309,12 @@ func IsPatrolEnabled(config *DaemonPatrolConfig, patrol string) bool {
 		}
 		return config.Patrols.QuotaDog.Enabled
 	}
+	if patrol == "convoy_templates" {
+		if config == nil || config.Patrols == nil || config.Patrols.ConvoyTemplates == nil {
+			return false
+		}
+		return config.Patrols.ConvoyTemplates.Enabled
+	}
 
 	if config == nil || config.Patrols == nil {
 		return true // Default: enabled
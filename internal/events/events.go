@@ -74,6 +74,13 @@ const (
 	TypeSchedulerDispatch       = "scheduler_dispatch"        // Bead dispatched from scheduler
 	TypeSchedulerDispatchFailed = "scheduler_dispatch_failed" // Bead dispatch failed (requeued)
 	TypeSchedulerCloseRetry     = "scheduler_close_retry"     // Context close needed last-resort attempt
+	TypeSchedulerSLARisk        = "scheduler_sla_risk"        // Queued bead may miss its deadline (capacity exhausted)
+
+	// Workflow step events
+	TypeStepFailed = "step_failed" // Workflow step failed or timed out; payload carries the policy outcome
+
+	// Dashboard events
+	TypeDashboardAPI = "dashboard_api" // Mutating dashboard API call with the authenticated principal
 )
 
 // EventsFile is the name of the raw events log.
@@ -352,11 +359,14 @@ func SchedulerEnqueuePayload(beadID, rig string) map[string]interface{} {
 }
 
 // SchedulerDispatchPayload creates a payload for scheduler dispatch events.
-func SchedulerDispatchPayload(beadID, rig, polecat string) map[string]interface{} {
+// maxPolecats is the scheduler.max_polecats in effect for the dispatch
+// (<= 0 = unlimited), so history can be compared against its own capacity.
+func SchedulerDispatchPayload(beadID, rig, polecat string, maxPolecats int) map[string]interface{} {
 	return map[string]interface{}{
-		"bead":    beadID,
-		"rig":     rig,
-		"polecat": polecat,
+		"bead":         beadID,
+		"rig":          rig,
+		"polecat":      polecat,
+		"max_polecats": maxPolecats,
 	}
 }
 
@@ -368,3 +378,28 @@ func SchedulerDispatchFailedPayload(beadID, rig, errMsg string) map[string]inter
 		"error": errMsg,
 	}
 }
+
+// SchedulerSLARiskPayload creates a payload for scheduler SLA risk events.
+// dueAt is RFC3339; slack is a Go duration string (negative when overdue).
+func SchedulerSLARiskPayload(beadID, rig, dueAt, slack string) map[string]interface{} {
+	return map[string]interface{}{
+		"bead":   beadID,
+		"rig":    rig,
+		"due_at": dueAt,
+		"slack":  slack,
+	}
+}
+
+// StepFailedPayload creates a payload for workflow step failure events.
+// outcome is the applied policy: retry, fail-molecule, skip, escalate, or
+// goto:<step>.
+func StepFailedPayload(beadID, step, workflow, reason, outcome string) map[string]interface{} {
+	return map[string]interface{}{
+		"bead":     beadID,
+		"step":     step,
+		"workflow": workflow,
+		"reason":   reason,
+		"outcome":  outcome,
+		"message":  fmt.Sprintf("step %s %s: %s", step, reason, outcome),
+	}
+}
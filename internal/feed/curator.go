@@ -516,6 +516,12 @@ func (c *Curator) generateSummary(event *events.Event) string {
 		}
 		return "Merge failed"
 
+	case events.TypeStepFailed:
+		if msg, ok := event.Payload["message"].(string); ok {
+			return "Workflow " + msg
+		}
+		return "Workflow step failed"
+
 	case events.TypeSessionDeath:
 		session, _ := event.Payload["session"].(string)
 		reason, _ := event.Payload["reason"].(string)
@@ -0,0 +1,301 @@
+package formula
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// AdviceRule weaves extra steps around every step it matches (a join point).
+// A step matches when its ID matches Target and, if Tag is set, the step
+// carries that tag.
+//
+//	[[advice]]
+//	target = "implement"        # step ID glob; "*" or "" matches every step
+//	tag = "touches-auth"        # optional: only steps tagged touches-auth
+//	[advice.after]
+//	id = "{step.id}-security-audit"
+//	title = "Security audit for {step.title}"
+type AdviceRule struct {
+	// Target is a step ID glob (path.Match syntax). Empty matches any step.
+	Target string `toml:"target"`
+
+	// Tag restricts the rule to steps whose tags include this value.
+	Tag string `toml:"tag"`
+
+	// Before inserts one step that the join point waits on.
+	Before *AdviceStep `toml:"before"`
+
+	// After inserts one step that waits on the join point; steps that
+	// depended on the join point wait on it instead.
+	After *AdviceStep `toml:"after"`
+
+	// Around inserts step chains on both sides of the join point.
+	Around *AroundAdvice `toml:"around"`
+}
+
+// AroundAdvice lists step chains woven before and after a join point.
+type AroundAdvice struct {
+	Before []AdviceStep `toml:"before"`
+	After  []AdviceStep `toml:"after"`
+}
+
+// AdviceStep is a step template inserted by advice. ID, Title, Description,
+// and Acceptance may use {step.id}, {step.title}, and {step.description},
+// which expand to the join point's values.
+type AdviceStep struct {
+	ID          string `toml:"id"`
+	Title       string `toml:"title"`
+	Description string `toml:"description"`
+	Acceptance  string `toml:"acceptance"`
+}
+
+// Pointcut limits where an aspect formula's advice may apply. When an aspect
+// declares pointcuts, a step must match at least one of them (in addition to
+// the advice rule's own target) to be advised.
+type Pointcut struct {
+	Glob string `toml:"glob"` // Step ID glob (path.Match syntax)
+	Tag  string `toml:"tag"`  // Step tag
+}
+
+// matches reports whether a step is selected by the glob and tag filters.
+// Empty filters match everything.
+func stepMatches(step Step, glob, tag string) bool {
+	if glob != "" && glob != "*" {
+		if ok, _ := path.Match(glob, step.ID); !ok {
+			return false
+		}
+	}
+	if tag != "" {
+		for _, t := range step.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (p Pointcut) matches(step Step) bool {
+	return stepMatches(step, p.Glob, p.Tag)
+}
+
+func (r *AdviceRule) matches(step Step) bool {
+	return stepMatches(step, r.Target, r.Tag)
+}
+
+// beforeSteps returns the steps woven before the join point, in run order.
+func (r *AdviceRule) beforeSteps() []AdviceStep {
+	var steps []AdviceStep
+	if r.Before != nil {
+		steps = append(steps, *r.Before)
+	}
+	if r.Around != nil {
+		steps = append(steps, r.Around.Before...)
+	}
+	return steps
+}
+
+// afterSteps returns the steps woven after the join point, in run order.
+func (r *AdviceRule) afterSteps() []AdviceStep {
+	var steps []AdviceStep
+	if r.Around != nil {
+		steps = append(steps, r.Around.After...)
+	}
+	if r.After != nil {
+		steps = append(steps, *r.After)
+	}
+	return steps
+}
+
+func (f *Formula) validateAdvice() error {
+	for i, rule := range f.Advice {
+		if rule == nil {
+			return fmt.Errorf("advice %d is empty", i)
+		}
+		if _, err := path.Match(rule.Target, ""); err != nil {
+			return fmt.Errorf("advice %d: invalid target glob %q: %w", i, rule.Target, err)
+		}
+		before, after := rule.beforeSteps(), rule.afterSteps()
+		if len(before) == 0 && len(after) == 0 {
+			return fmt.Errorf("advice %d (target %q) has no before, after, or around steps", i, rule.Target)
+		}
+		for _, s := range append(before, after...) {
+			if s.ID == "" {
+				return fmt.Errorf("advice %d (target %q): step missing required id field", i, rule.Target)
+			}
+		}
+	}
+	for i, pc := range f.Pointcuts {
+		if pc.Glob == "" && pc.Tag == "" {
+			return fmt.Errorf("pointcut %d needs a glob or tag", i)
+		}
+		if _, err := path.Match(pc.Glob, ""); err != nil {
+			return fmt.Errorf("pointcut %d: invalid glob %q: %w", i, pc.Glob, err)
+		}
+	}
+	return nil
+}
+
+// weaveAspects applies the advice of each named aspect formula to steps, in
+// order. Join points are always the steps present before weaving began, so
+// advice never matches steps inserted by other advice. Aspect formulas may
+// list further aspects in their own compose.aspects; chain tracks that nesting
+// for cycle detection.
+func weaveAspects(steps []Step, aspectNames []string, searchPaths []string, chain []string) ([]Step, error) {
+	joinPoints := make(map[string]bool, len(steps))
+	for _, s := range steps {
+		joinPoints[s.ID] = true
+	}
+	rules, err := collectAdvice(aspectNames, searchPaths, chain)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		// Iterate over a snapshot of the join point IDs in current order,
+		// since weaving inserts steps into the slice.
+		var targets []string
+		for _, s := range steps {
+			if joinPoints[s.ID] && r.rule.matches(s) && r.pointcutsAllow(s) {
+				targets = append(targets, s.ID)
+			}
+		}
+		for _, id := range targets {
+			steps = weaveRule(steps, id, r.rule)
+		}
+	}
+	return steps, nil
+}
+
+// boundAdvice is an advice rule together with its aspect's pointcuts.
+type boundAdvice struct {
+	aspect    string
+	rule      *AdviceRule
+	pointcuts []Pointcut
+}
+
+func (b boundAdvice) pointcutsAllow(step Step) bool {
+	if len(b.pointcuts) == 0 {
+		return true
+	}
+	for _, pc := range b.pointcuts {
+		if pc.matches(step) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectAdvice loads aspect formulas by name and flattens their advice,
+// including aspects they compose themselves.
+func collectAdvice(aspectNames []string, searchPaths []string, chain []string) ([]boundAdvice, error) {
+	var rules []boundAdvice
+	for _, name := range aspectNames {
+		for _, seen := range chain {
+			if seen == name {
+				return nil, fmt.Errorf("circular aspects detected: %s", strings.Join(append(chain, name), " -> "))
+			}
+		}
+		aspect, err := loadFormulaByName(name, searchPaths)
+		if err != nil {
+			return nil, fmt.Errorf("aspect %q: %w", name, err)
+		}
+		if aspect.Type != TypeAspect {
+			return nil, fmt.Errorf("aspect %q is type %q, want %q", name, aspect.Type, TypeAspect)
+		}
+		if aspect.Compose != nil && len(aspect.Compose.Aspects) > 0 {
+			nested, err := collectAdvice(aspect.Compose.Aspects, searchPaths, append(chain, name))
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, nested...)
+		}
+		for _, rule := range aspect.Advice {
+			rules = append(rules, boundAdvice{aspect: name, rule: rule, pointcuts: aspect.Pointcuts})
+		}
+	}
+	return rules, nil
+}
+
+// weaveRule inserts a rule's before and after chains around the step with
+// the given ID. Before steps take over the join point's needs and the join
+// point waits on the last of them; after steps wait on the join point and
+// every former dependent of the join point waits on the last after step.
+func weaveRule(steps []Step, targetID string, rule *AdviceRule) []Step {
+	idx := -1
+	for i, s := range steps {
+		if s.ID == targetID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return steps
+	}
+	target := steps[idx]
+
+	var before []Step
+	prevNeeds := append([]string(nil), target.Needs...)
+	for _, a := range rule.beforeSteps() {
+		s := a.instantiate(target)
+		s.Needs = prevNeeds
+		before = append(before, s)
+		prevNeeds = []string{s.ID}
+	}
+	if len(before) > 0 {
+		target.Needs = prevNeeds
+	}
+
+	var after []Step
+	prev := target.ID
+	for _, a := range rule.afterSteps() {
+		s := a.instantiate(target)
+		s.Needs = []string{prev}
+		after = append(after, s)
+		prev = s.ID
+	}
+
+	result := make([]Step, 0, len(steps)+len(before)+len(after))
+	for i, s := range steps {
+		if i == idx {
+			result = append(result, before...)
+			result = append(result, target)
+			result = append(result, after...)
+			continue
+		}
+		if len(after) > 0 {
+			s = replaceNeed(s, target.ID, prev)
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// replaceNeed returns step with every need on from rewritten to to.
+func replaceNeed(step Step, from, to string) Step {
+	for j, need := range step.Needs {
+		if need == from {
+			step.Needs = append([]string(nil), step.Needs...)
+			step.Needs[j] = to
+		}
+	}
+	return step
+}
+
+// instantiate builds a Step from an advice template for a join point.
+func (a AdviceStep) instantiate(joinPoint Step) Step {
+	expand := func(s string) string {
+		s = strings.ReplaceAll(s, "{step.id}", joinPoint.ID)
+		s = strings.ReplaceAll(s, "{step.title}", joinPoint.Title)
+		s = strings.ReplaceAll(s, "{step.description}", joinPoint.Description)
+		return s
+	}
+	return Step{
+		ID:          expand(a.ID),
+		Title:       expand(a.Title),
+		Description: expand(a.Description),
+		Acceptance:  expand(a.Acceptance),
+		Target:      joinPoint.Target,
+	}
+}
@@ -0,0 +1,183 @@
+package formula
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFormulas(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+".formula.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const aspectBaseWorkflow = `formula = "app"
+type = "workflow"
+version = 1
+
+[[steps]]
+id = "login"
+title = "Build login"
+tags = ["touches-auth"]
+
+[[steps]]
+id = "docs"
+title = "Write docs"
+needs = ["login"]
+
+[[steps]]
+id = "submit"
+title = "Submit"
+needs = ["docs"]
+`
+
+func TestResolve_AspectWeaving(t *testing.T) {
+	tests := []struct {
+		name      string
+		aspect    string
+		wantIDs   []string
+		wantNeeds map[string][]string
+	}{
+		{
+			name: "after by tag",
+			aspect: `formula = "audit"
+[[advice]]
+tag = "touches-auth"
+[advice.after]
+id = "{step.id}-audit"
+title = "Audit {step.title}"
+`,
+			wantIDs: []string{"login", "login-audit", "docs", "submit"},
+			wantNeeds: map[string][]string{
+				"login-audit": {"login"},
+				"docs":        {"login-audit"},
+			},
+		},
+		{
+			name: "before by id",
+			aspect: `formula = "audit"
+[[advice]]
+target = "submit"
+[advice.before]
+id = "rule-of-five"
+`,
+			wantIDs: []string{"login", "docs", "rule-of-five", "submit"},
+			wantNeeds: map[string][]string{
+				"rule-of-five": {"docs"},
+				"submit":       {"rule-of-five"},
+			},
+		},
+		{
+			name: "around chains",
+			aspect: `formula = "audit"
+[[advice]]
+target = "docs"
+[advice.around]
+[[advice.around.before]]
+id = "{step.id}-pre1"
+[[advice.around.before]]
+id = "{step.id}-pre2"
+[[advice.around.after]]
+id = "{step.id}-post"
+`,
+			wantIDs: []string{"login", "docs-pre1", "docs-pre2", "docs", "docs-post", "submit"},
+			wantNeeds: map[string][]string{
+				"docs-pre1": {"login"},
+				"docs-pre2": {"docs-pre1"},
+				"docs":      {"docs-pre2"},
+				"submit":    {"docs-post"},
+			},
+		},
+		{
+			name: "pointcuts restrict glob",
+			aspect: `formula = "audit"
+[[advice]]
+target = "*"
+[advice.after]
+id = "{step.id}-check"
+
+[[pointcuts]]
+glob = "s*"
+`,
+			wantIDs: []string{"login", "docs", "submit", "submit-check"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFormulas(t, map[string]string{"audit": tt.aspect})
+			f, err := Parse([]byte(aspectBaseWorkflow + "\n[compose]\naspects = [\"audit\"]\n"))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			resolved, err := Resolve(f, []string{dir})
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got := stepIDs(resolved); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Fatalf("steps = %v, want %v", got, tt.wantIDs)
+			}
+			for id, want := range tt.wantNeeds {
+				if got := resolved.GetStep(id).Needs; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s needs = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve_AspectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"audit": "formula = \"audit\"\ntype = \"aspect\"\n[compose]\naspects = [\"lint\"]\n[[advice]]\ntarget = \"docs\"\n[advice.after]\nid = \"a\"\n",
+				"lint":  "formula = \"lint\"\ntype = \"aspect\"\n[compose]\naspects = [\"audit\"]\n[[advice]]\ntarget = \"docs\"\n[advice.after]\nid = \"b\"\n",
+			},
+			wantErr: "circular aspects detected: audit -> lint -> audit",
+		},
+		{
+			name:    "not an aspect",
+			files:   map[string]string{"audit": aspectBaseWorkflow},
+			wantErr: `is type "workflow"`,
+		},
+		{
+			name: "duplicate woven id",
+			files: map[string]string{
+				"audit": "formula = \"audit\"\n[[advice]]\ntarget = \"*\"\n[advice.after]\nid = \"same\"\n",
+			},
+			wantErr: "duplicate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFormulas(t, tt.files)
+			f, err := Parse([]byte(aspectBaseWorkflow + "\n[compose]\naspects = [\"audit\"]\n"))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			_, err = Resolve(f, []string{dir})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Resolve error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_AdviceValidation(t *testing.T) {
+	_, err := Parse([]byte("formula = \"audit\"\n[[advice]]\ntarget = \"x\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "no before, after, or around") {
+		t.Errorf("Parse error = %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultMaxIterations bounds repeat_until loops that do not set max_iterations.
+const DefaultMaxIterations = 3
+
+// Env supplies values for identifiers in step conditions.
+//
+// A bare identifier (has_migrations) names a formula var. A dotted reference
+// of the form steps.<id>.outputs.<name> names an output recorded by an
+// earlier step. Unknown identifiers evaluate to the empty string.
+type Env struct {
+	Vars    map[string]string
+	Outputs map[string]map[string]string // step ID -> output name -> value
+}
+
+// Lookup returns the value of an identifier.
+func (e Env) Lookup(name string) string {
+	if stepID, output, ok := splitOutputRef(name); ok {
+		return e.Outputs[stepID][output]
+	}
+	return e.Vars[name]
+}
+
+// splitOutputRef splits steps.<id>.outputs.<name> into its step ID and
+// output name.
+func splitOutputRef(name string) (stepID, output string, ok bool) {
+	rest, found := strings.CutPrefix(name, "steps.")
+	if !found {
+		return "", "", false
+	}
+	idx := strings.LastIndex(rest, ".outputs.")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return rest[:idx], rest[idx+len(".outputs."):], true
+}
+
+// Condition is a parsed step condition (when / repeat_until).
+//
+// Grammar:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand ]
+//	operand = "(" expr ")" | string | number | "true" | "false" | identifier
+//
+// Values are strings. == and != compare numerically when both sides are
+// numbers; ordering operators require numbers. A lone operand is true unless
+// it is "", "false", or "0".
+type Condition struct {
+	src  string
+	root condNode
+}
+
+// ParseCondition parses a condition expression.
+func ParseCondition(src string) (*Condition, error) {
+	p := &condParser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, fmt.Errorf("condition %q: %w", src, err)
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", src, err)
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("condition %q: unexpected %q", src, p.toks[p.pos].text)
+	}
+	return &Condition{src: src, root: root}, nil
+}
+
+// String returns the source text of the condition.
+func (c *Condition) String() string { return c.src }
+
+// Eval evaluates the condition.
+func (c *Condition) Eval(env Env) (bool, error) {
+	v, err := c.root.eval(env)
+	if err != nil {
+		return false, fmt.Errorf("condition %q: %w", c.src, err)
+	}
+	return truthy(v), nil
+}
+
+// Refs returns the identifiers referenced by the condition, in order of
+// first appearance.
+func (c *Condition) Refs() []string {
+	var refs []string
+	seen := make(map[string]bool)
+	c.root.walk(func(n condNode) {
+		if r, ok := n.(refNode); ok && !seen[string(r)] {
+			seen[string(r)] = true
+			refs = append(refs, string(r))
+		}
+	})
+	return refs
+}
+
+// Bind returns an equivalent condition source with every formula var
+// replaced by its literal value from vars. Step output references are kept,
+// so the result can be stored and evaluated later once outputs exist.
+func (c *Condition) Bind(vars map[string]string) string {
+	return c.root.format(vars)
+}
+
+// EvalCondition parses and evaluates src. An empty src is true.
+func EvalCondition(src string, env Env) (bool, error) {
+	if strings.TrimSpace(src) == "" {
+		return true, nil
+	}
+	c, err := ParseCondition(src)
+	if err != nil {
+		return false, err
+	}
+	return c.Eval(env)
+}
+
+func truthy(v string) bool {
+	switch strings.TrimSpace(strings.ToLower(v)) {
+	case "", "false", "0":
+		return false
+	}
+	return true
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// --- AST ---
+
+type condNode interface {
+	eval(env Env) (string, error)
+	walk(fn func(condNode))
+	format(vars map[string]string) string
+}
+
+type litNode string
+type refNode string
+type notNode struct{ x condNode }
+type binNode struct {
+	op   string
+	l, r condNode
+}
+
+func (n litNode) eval(Env) (string, error)        { return string(n), nil }
+func (n litNode) walk(fn func(condNode))          { fn(n) }
+func (n litNode) format(map[string]string) string { return strconv.Quote(string(n)) }
+
+func (n refNode) eval(env Env) (string, error) { return env.Lookup(string(n)), nil }
+func (n refNode) walk(fn func(condNode))       { fn(n) }
+func (n refNode) format(vars map[string]string) string {
+	if _, _, ok := splitOutputRef(string(n)); !ok {
+		if v, bound := vars[string(n)]; bound {
+			return strconv.Quote(v)
+		}
+	}
+	return string(n)
+}
+
+func (n notNode) eval(env Env) (string, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return "", err
+	}
+	return boolString(!truthy(v)), nil
+}
+func (n notNode) walk(fn func(condNode)) { fn(n); n.x.walk(fn) }
+func (n notNode) format(vars map[string]string) string {
+	return "!" + wrapFormat(n.x, vars)
+}
+
+func (n binNode) eval(env Env) (string, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return "", err
+	}
+	switch n.op {
+	case "&&":
+		if !truthy(l) {
+			return "false", nil
+		}
+		r, err := n.r.eval(env)
+		return boolString(truthy(r)), err
+	case "||":
+		if truthy(l) {
+			return "true", nil
+		}
+		r, err := n.r.eval(env)
+		return boolString(truthy(r)), err
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return "", err
+	}
+	lf, lerr := strconv.ParseFloat(strings.TrimSpace(l), 64)
+	rf, rerr := strconv.ParseFloat(strings.TrimSpace(r), 64)
+	numeric := lerr == nil && rerr == nil
+	switch n.op {
+	case "==":
+		if numeric {
+			return boolString(lf == rf), nil
+		}
+		return boolString(l == r), nil
+	case "!=":
+		if numeric {
+			return boolString(lf != rf), nil
+		}
+		return boolString(l != r), nil
+	}
+	if !numeric {
+		return "", fmt.Errorf("%s needs numbers, got %q and %q", n.op, l, r)
+	}
+	switch n.op {
+	case "<":
+		return boolString(lf < rf), nil
+	case "<=":
+		return boolString(lf <= rf), nil
+	case ">":
+		return boolString(lf > rf), nil
+	default: // ">="
+		return boolString(lf >= rf), nil
+	}
+}
+func (n binNode) walk(fn func(condNode)) { fn(n); n.l.walk(fn); n.r.walk(fn) }
+func (n binNode) format(vars map[string]string) string {
+	return wrapFormat(n.l, vars) + " " + n.op + " " + wrapFormat(n.r, vars)
+}
+
+// wrapFormat parenthesizes binary subexpressions so the formatted text keeps
+// the original grouping.
+func wrapFormat(n condNode, vars map[string]string) string {
+	if _, ok := n.(binNode); ok {
+		return "(" + n.format(vars) + ")"
+	}
+	return n.format(vars)
+}
+
+// --- Parser ---
+
+type condTokKind int
+
+const (
+	tokOp condTokKind = iota
+	tokString
+	tokNumber
+	tokIdent
+)
+
+type condTok struct {
+	kind condTokKind
+	text string
+}
+
+type condParser struct {
+	src  string
+	toks []condTok
+	pos  int
+}
+
+func isIdentStart(r rune) bool { return r == '_' || unicode.IsLetter(r) }
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (p *condParser) lex() error {
+	rs := []rune(p.src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(rs) && rs[j] != r {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+				j++
+			}
+			if j >= len(rs) {
+				return fmt.Errorf("unterminated string")
+			}
+			p.toks = append(p.toks, condTok{tokString, sb.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			p.toks = append(p.toks, condTok{tokNumber, string(rs[i:j])})
+			i = j
+		case isIdentStart(r):
+			j := i + 1
+			for j < len(rs) && isIdentPart(rs[j]) {
+				j++
+			}
+			p.toks = append(p.toks, condTok{tokIdent, string(rs[i:j])})
+			i = j
+		default:
+			two := ""
+			if i+1 < len(rs) {
+				two = string(rs[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				p.toks = append(p.toks, condTok{tokOp, two})
+				i += 2
+				continue
+			}
+			switch r {
+			case '!', '<', '>', '(', ')':
+				p.toks = append(p.toks, condTok{tokOp, string(r)})
+				i++
+			default:
+				return fmt.Errorf("unexpected character %q", r)
+			}
+		}
+	}
+	if len(p.toks) == 0 {
+		return fmt.Errorf("empty expression")
+	}
+	return nil
+}
+
+func (p *condParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.toks) || p.toks[p.pos].kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.toks[p.pos].text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("||"); !ok {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{"||", l, r}
+	}
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("&&"); !ok {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{"&&", l, r}
+	}
+}
+
+func (p *condParser) parseUnary() (condNode, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *condParser) parseCompare() (condNode, error) {
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.peekOp("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return l, nil
+	}
+	p.pos++
+	r, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return binNode{op, l, r}, nil
+}
+
+func (p *condParser) parseOperand() (condNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.toks[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokString, tokNumber:
+		return litNode(tok.text), nil
+	case tokIdent:
+		if tok.text == "true" || tok.text == "false" {
+			return litNode(tok.text), nil
+		}
+		return refNode(tok.text), nil
+	}
+	if tok.text == "(" {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
@@ -0,0 +1,175 @@
+package formula
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	env := Env{
+		Vars: map[string]string{"has_migrations": "false", "retries": "2", "mode": "fast"},
+		Outputs: map[string]map[string]string{
+			"review": {"verdict": "approve"},
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`has_migrations == "false"`, true},
+		{`has_migrations`, false},
+		{`!has_migrations`, true},
+		{`retries >= 2 && mode != 'slow'`, true},
+		{`retries < 2 || unknown`, false},
+		{`retries == 2.0`, true},
+		{`steps.review.outputs.verdict == "approve"`, true},
+		{`(mode == "fast" || mode == "slow") && !(retries > 5)`, true},
+		{`steps.review.outputs.missing`, false},
+		{`true`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalCondition(tt.expr, env)
+			if err != nil {
+				t.Fatalf("EvalCondition: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalCondition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, expr := range []string{``, `a ==`, `(a`, `"open`, `a = b`, `a b`} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q) succeeded, want error", expr)
+		}
+	}
+	if _, err := EvalCondition(`mode > 1`, Env{Vars: map[string]string{"mode": "fast"}}); err == nil {
+		t.Error("ordering non-numbers should fail")
+	}
+}
+
+func TestConditionBindKeepsOutputs(t *testing.T) {
+	c, err := ParseCondition(`has_migrations == "true" && steps.test.outputs.failures > 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound := c.Bind(map[string]string{"has_migrations": "true"})
+	if want := `("true" == "true") && (steps.test.outputs.failures > "0")`; bound != want {
+		t.Errorf("Bind = %s, want %s", bound, want)
+	}
+	if got := c.Refs(); !reflect.DeepEqual(got, []string{"has_migrations", "steps.test.outputs.failures"}) {
+		t.Errorf("Refs = %v", got)
+	}
+	ok, err := EvalCondition(bound, Env{Outputs: map[string]map[string]string{"test": {"failures": "3"}}})
+	if err != nil || !ok {
+		t.Errorf("bound condition = %v, %v; want true", ok, err)
+	}
+}
+
+const conditionalWorkflow = `formula = "ship"
+type = "workflow"
+
+[[steps]]
+id = "plan"
+
+[[steps]]
+id = "migrate-db"
+needs = ["plan"]
+when = 'has_migrations == "true"'
+
+[[steps]]
+id = "verify-db"
+needs = ["migrate-db"]
+when = 'has_migrations == "true"'
+
+[[steps]]
+id = "review"
+needs = ["plan"]
+repeat_until = 'steps.review.outputs.verdict == "approve"'
+max_iterations = 2
+
+[[steps]]
+id = "deploy"
+needs = ["verify-db", "review"]
+`
+
+func TestReadyStepsEnvSkipsConditionalSteps(t *testing.T) {
+	f, err := Parse([]byte(conditionalWorkflow))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	env := Env{Vars: map[string]string{"has_migrations": "false"}}
+
+	ready, skipped, err := f.ReadyStepsEnv(map[string]bool{"plan": true}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ready, []string{"review"}) || !reflect.DeepEqual(skipped, []string{"migrate-db", "verify-db"}) {
+		t.Fatalf("ready=%v skipped=%v", ready, skipped)
+	}
+
+	completed := map[string]bool{"plan": true, "migrate-db": true, "verify-db": true, "review": true}
+	ready, skipped, err = f.ReadyStepsEnv(completed, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ready, []string{"deploy"}) || len(skipped) != 0 {
+		t.Errorf("ready=%v skipped=%v, want [deploy] []", ready, skipped)
+	}
+}
+
+func TestStepRepeatAgain(t *testing.T) {
+	f, err := Parse([]byte(conditionalWorkflow))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	review := f.GetStep("review")
+	rejected := Env{Outputs: map[string]map[string]string{"review": {"verdict": "changes"}}}
+	approved := Env{Outputs: map[string]map[string]string{"review": {"verdict": "approve"}}}
+
+	tests := []struct {
+		name      string
+		iteration int
+		env       Env
+		want      bool
+	}{
+		{"condition unmet", 1, rejected, true},
+		{"condition met", 1, approved, false},
+		{"limit reached", 2, rejected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := review.RepeatAgain(tt.iteration, tt.env)
+			if err != nil || got != tt.want {
+				t.Errorf("RepeatAgain(%d) = %v, %v; want %v", tt.iteration, got, err, tt.want)
+			}
+		})
+	}
+	if plan := f.GetStep("plan"); plan.IterationLimit() != 1 {
+		t.Errorf("plain step IterationLimit = %d, want 1", plan.IterationLimit())
+	}
+}
+
+func TestValidateStepConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    string
+		wantErr string
+	}{
+		{"bad syntax", "when = 'a =='", "when"},
+		{"unknown step output", "when = 'steps.nope.outputs.x'", "unknown step: nope"},
+		{"bound without loop", "max_iterations = 2", "requires repeat_until"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte("formula = \"x\"\n[[steps]]\nid = \"a\"\n" + tt.step + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -10,7 +10,8 @@
 //   - convoy: Parallel execution of independent legs with synthesis
 //   - workflow: Sequential steps with explicit dependencies
 //   - expansion: Template-based step generation
-//   - aspect: Multi-aspect parallel analysis
+//   - aspect: Multi-aspect parallel analysis, or advice woven into workflows
+//     that list the aspect in compose.aspects (see Resolve)
 //
 // # Quick Start
 //
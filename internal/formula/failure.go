@@ -0,0 +1,95 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Failure policies for Step.OnFailure.
+const (
+	OnFailureFailMolecule = "fail-molecule"
+	OnFailureSkip         = "skip"
+	OnFailureEscalate     = "escalate"
+	onFailureGotoPrefix   = "goto:"
+)
+
+// DefaultRetryBackoff is the wait before the first retry of a failed step
+// that does not set retry_backoff. Each later retry waits twice as long.
+const DefaultRetryBackoff = time.Minute
+
+// maxRetryBackoff caps the exponential retry delay.
+const maxRetryBackoff = time.Hour
+
+// ParseOnFailure splits an on_failure policy into its action and, for
+// goto:<step>, the target step ID. An empty policy means fail-molecule.
+func ParseOnFailure(policy string) (action, target string, err error) {
+	policy = strings.TrimSpace(policy)
+	switch policy {
+	case "", OnFailureFailMolecule:
+		return OnFailureFailMolecule, "", nil
+	case OnFailureSkip, OnFailureEscalate:
+		return policy, "", nil
+	}
+	if target, ok := strings.CutPrefix(policy, onFailureGotoPrefix); ok && strings.TrimSpace(target) != "" {
+		return "goto", strings.TrimSpace(target), nil
+	}
+	return "", "", fmt.Errorf("invalid on_failure %q (want %s, %s, %s, or goto:<step>)",
+		policy, OnFailureFailMolecule, OnFailureSkip, OnFailureEscalate)
+}
+
+// TimeoutDuration returns the step's timeout, or 0 when it has none.
+func (s *Step) TimeoutDuration() time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(s.Timeout))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// RetryDelay returns how long to wait before retry number attempt (1-based):
+// retry_backoff (or DefaultRetryBackoff) doubled for each earlier retry,
+// capped at one hour.
+func (s *Step) RetryDelay(attempt int) time.Duration {
+	base, err := time.ParseDuration(strings.TrimSpace(s.RetryBackoff))
+	if err != nil || base <= 0 {
+		base = DefaultRetryBackoff
+	}
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
+
+// validateFailurePolicy checks timeout, retries, retry_backoff, and
+// on_failure. A goto target must be another step of the formula.
+func (s *Step) validateFailurePolicy(stepIDs map[string]bool) error {
+	for field, value := range map[string]string{"timeout": s.Timeout, "retry_backoff": s.RetryBackoff} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("step %q: %s must be a positive duration such as \"30m\", got %q", s.ID, field, value)
+		}
+	}
+	if s.Retries < 0 {
+		return fmt.Errorf("step %q: retries must not be negative", s.ID)
+	}
+	if s.RetryBackoff != "" && s.Retries == 0 {
+		return fmt.Errorf("step %q: retry_backoff requires retries", s.ID)
+	}
+	action, target, err := ParseOnFailure(s.OnFailure)
+	if err != nil {
+		return fmt.Errorf("step %q: %w", s.ID, err)
+	}
+	if action == "goto" {
+		if target == s.ID {
+			return fmt.Errorf("step %q: on_failure cannot goto itself; use retries", s.ID)
+		}
+		if !stepIDs[target] {
+			return fmt.Errorf("step %q: on_failure goto unknown step: %s", s.ID, target)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOnFailure(t *testing.T) {
+	tests := []struct {
+		policy, action, target string
+		wantErr                bool
+	}{
+		{"", OnFailureFailMolecule, "", false},
+		{"skip", OnFailureSkip, "", false},
+		{"escalate", OnFailureEscalate, "", false},
+		{"goto:plan", "goto", "plan", false},
+		{"goto:", "", "", true},
+		{"retry", "", "", true},
+	}
+	for _, tt := range tests {
+		action, target, err := ParseOnFailure(tt.policy)
+		if action != tt.action || target != tt.target || (err != nil) != tt.wantErr {
+			t.Errorf("ParseOnFailure(%q) = %q, %q, %v", tt.policy, action, target, err)
+		}
+	}
+}
+
+func TestStepRetryDelay(t *testing.T) {
+	s := &Step{RetryBackoff: "10m"}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Minute, 2: 20 * time.Minute, 3: 40 * time.Minute, 5: time.Hour} {
+		if got := s.RetryDelay(attempt); got != want {
+			t.Errorf("RetryDelay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+	if got := (&Step{}).RetryDelay(1); got != DefaultRetryBackoff {
+		t.Errorf("default RetryDelay = %s", got)
+	}
+	if got := (&Step{Timeout: "bogus"}).TimeoutDuration(); got != 0 {
+		t.Errorf("invalid TimeoutDuration = %s", got)
+	}
+}
+
+func TestParse_StepFailurePolicy(t *testing.T) {
+	f, err := Parse([]byte(`formula = "policies"
+[[steps]]
+id = "plan"
+[[steps]]
+id = "build"
+needs = ["plan"]
+timeout = "30m"
+retries = 2
+retry_backoff = "5m"
+on_failure = "goto:plan"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if build := f.GetStep("build"); build.TimeoutDuration() != 30*time.Minute || build.Retries != 2 || build.OnFailure != "goto:plan" {
+		t.Errorf("build = %+v", build)
+	}
+
+	tests := map[string]string{
+		"timeout = \"soon\"":       "timeout must be a positive duration",
+		"retries = -1":             "retries must not be negative",
+		"retry_backoff = \"1m\"":   "retry_backoff requires retries",
+		"on_failure = \"ignore\"":  `invalid on_failure "ignore"`,
+		"on_failure = \"goto:a\"":  "cannot goto itself",
+		"on_failure = \"goto:zz\"": "goto unknown step: zz",
+	}
+	for field, want := range tests {
+		_, err := Parse([]byte("formula = \"bad\"\n[[steps]]\nid = \"a\"\n" + field + "\n"))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want containing %q", field, err, want)
+		}
+	}
+}
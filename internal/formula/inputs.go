@@ -0,0 +1,313 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types for formula [vars] and [inputs].
+const (
+	ParamString   = "string"
+	ParamInt      = "int"
+	ParamNumber   = "number"
+	ParamBool     = "bool"
+	ParamEnum     = "enum"      // One of Values
+	ParamRegex    = "regex"     // String matching Pattern (required)
+	ParamBeadID   = "bead-id"   // Issue ID such as gt-abc12
+	ParamRigName  = "rig-name"  // Registered rig
+	ParamFilePath = "file-path" // Existing file or directory
+)
+
+var paramTypes = map[string]bool{
+	ParamString: true, ParamInt: true, ParamNumber: true, ParamBool: true, ParamEnum: true,
+	ParamRegex: true, ParamBeadID: true, ParamRigName: true, ParamFilePath: true,
+}
+
+// beadIDPattern matches issue IDs: a lowercase prefix, a dash, then the
+// hash and any hierarchical suffixes (gt-abc12, hq-wf-x1y2z, gt-abc12.3).
+var beadIDPattern = regexp.MustCompile(`^[a-z][a-z0-9]*-[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// rigNamePattern matches rig names (alphanumeric and underscore, as enforced
+// by gt rig add).
+var rigNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Param is a typed formula parameter, from either [vars] or [inputs].
+type Param struct {
+	Name           string
+	Description    string
+	Type           string // One of the Param* types; empty means string
+	Required       bool
+	RequiredUnless []string
+	Default        string
+	Values         []string
+	Pattern        string
+}
+
+// Kind returns the parameter type, defaulting to ParamString.
+func (p Param) Kind() string {
+	if p.Type == "" {
+		return ParamString
+	}
+	return p.Type
+}
+
+// Params returns the formula's [vars] and [inputs] as typed parameters,
+// sorted by name. An input shadows a var of the same name.
+func (f *Formula) Params() []Param {
+	byName := make(map[string]Param, len(f.Vars)+len(f.Inputs))
+	for name, v := range f.Vars {
+		byName[name] = Param{Name: name, Description: v.Description, Type: v.Type, Required: v.Required,
+			Default: v.Default, Values: v.Values, Pattern: v.Pattern}
+	}
+	for name, in := range f.Inputs {
+		byName[name] = Param{Name: name, Description: in.Description, Type: in.Type, Required: in.Required,
+			RequiredUnless: in.RequiredUnless, Default: in.Default, Values: in.Values, Pattern: in.Pattern}
+	}
+	params := make([]Param, 0, len(byName))
+	for _, p := range byName {
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+// validateParams checks parameter declarations: known types, enum values,
+// compilable patterns, and defaults that satisfy their own type.
+func (f *Formula) validateParams() error {
+	for _, p := range f.Params() {
+		if !paramTypes[p.Kind()] {
+			return fmt.Errorf("%s: unknown type %q", p.Name, p.Type)
+		}
+		if p.Kind() == ParamEnum && len(p.Values) == 0 {
+			return fmt.Errorf("%s: enum requires values", p.Name)
+		}
+		if p.Kind() == ParamRegex && p.Pattern == "" {
+			return fmt.Errorf("%s: regex requires pattern", p.Name)
+		}
+		if p.Pattern != "" {
+			if _, err := regexp.Compile(p.Pattern); err != nil {
+				return fmt.Errorf("%s: invalid pattern: %w", p.Name, err)
+			}
+		}
+		// Defaults may be templates filled in at cook time; check literals only.
+		if p.Default != "" && !strings.Contains(p.Default, "{{") {
+			if err := (InputChecker{}).CheckValue(p, p.Default); err != nil {
+				return fmt.Errorf("default: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// InputChecker validates values supplied for a formula's parameters.
+// The lookup funcs check that rigs, beads, and files exist; a nil func
+// skips that check (syntax is still checked).
+type InputChecker struct {
+	// Supplied names parameters that will be filled in by the caller later
+	// (e.g. issue when slinging a formula onto a bead). They count as
+	// present for required checks.
+	Supplied []string
+
+	RigExists  func(name string) bool
+	BeadExists func(id string) bool
+	FileExists func(path string) bool
+}
+
+// CheckValue validates a single value against a parameter's type.
+func (c InputChecker) CheckValue(p Param, value string) error {
+	switch p.Kind() {
+	case ParamInt:
+		if _, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s: %q is not an integer", p.Name, value)
+		}
+	case ParamNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return fmt.Errorf("%s: %q is not a number", p.Name, value)
+		}
+	case ParamBool:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s: %q is not a bool (use true or false)", p.Name, value)
+		}
+	case ParamEnum:
+		found := false
+		for _, allowed := range p.Values {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %q is not one of %s", p.Name, value, strings.Join(p.Values, ", "))
+		}
+	case ParamBeadID:
+		if !beadIDPattern.MatchString(value) {
+			return fmt.Errorf("%s: %q is not a bead ID (e.g. gt-abc12)", p.Name, value)
+		}
+		if c.BeadExists != nil && !c.BeadExists(value) {
+			return fmt.Errorf("%s: bead %s not found", p.Name, value)
+		}
+	case ParamRigName:
+		if !rigNamePattern.MatchString(value) {
+			return fmt.Errorf("%s: %q is not a rig name", p.Name, value)
+		}
+		if c.RigExists != nil && !c.RigExists(value) {
+			return fmt.Errorf("%s: unknown rig %q", p.Name, value)
+		}
+	case ParamFilePath:
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s: path is empty", p.Name)
+		}
+		if c.FileExists != nil && !c.FileExists(value) {
+			return fmt.Errorf("%s: %s does not exist", p.Name, value)
+		}
+	}
+	if p.Pattern != "" {
+		re, err := regexp.Compile(`^(?:` + p.Pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", p.Name, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s: %q does not match %s", p.Name, value, p.Pattern)
+		}
+	}
+	return nil
+}
+
+// Missing returns the required parameters with no value, no default, and
+// no supplied alternative (required_unless).
+func (c InputChecker) Missing(f *Formula, values map[string]string) []Param {
+	present := func(name string) bool {
+		if _, ok := values[name]; ok {
+			return true
+		}
+		for _, s := range c.Supplied {
+			if s == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var missing []Param
+	for _, p := range f.Params() {
+		if !p.Required || p.Default != "" || present(p.Name) {
+			continue
+		}
+		satisfied := false
+		for _, alt := range p.RequiredUnless {
+			if present(alt) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// Check validates values against the formula's parameters and reports
+// every problem at once. Values for undeclared names are passed through
+// unchecked.
+func (c InputChecker) Check(f *Formula, values map[string]string) error {
+	var problems []string
+	for _, p := range c.Missing(f, values) {
+		msg := p.Name + ": required"
+		if len(p.RequiredUnless) > 0 {
+			msg += " (unless " + strings.Join(p.RequiredUnless, " or ") + " is set)"
+		}
+		problems = append(problems, msg)
+	}
+	for _, p := range f.Params() {
+		value, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		if err := c.CheckValue(p, value); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid inputs for formula %s:\n  %s", f.Name, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// InputSchema returns a JSON Schema (draft 2020-12) describing the formula's
+// parameters, suitable for rendering an input form. Bead, rig, and path
+// parameters carry a "format" of bead-id, rig-name, or file-path;
+// required_unless is reported as the x-required-unless extension.
+func (f *Formula) InputSchema() map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for _, p := range f.Params() {
+		prop := map[string]any{"type": "string"}
+		if p.Description != "" {
+			prop["description"] = strings.TrimSpace(p.Description)
+		}
+		switch p.Kind() {
+		case ParamInt:
+			prop["type"] = "integer"
+		case ParamNumber:
+			prop["type"] = "number"
+		case ParamBool:
+			prop["type"] = "boolean"
+		case ParamEnum:
+			prop["enum"] = p.Values
+		case ParamBeadID:
+			prop["format"] = ParamBeadID
+			prop["pattern"] = beadIDPattern.String()
+		case ParamRigName, ParamFilePath:
+			prop["format"] = p.Kind()
+		}
+		if p.Pattern != "" {
+			prop["pattern"] = `^(?:` + p.Pattern + `)$`
+		}
+		if p.Default != "" {
+			prop["default"] = schemaDefault(p)
+		}
+		if len(p.RequiredUnless) > 0 {
+			prop["x-required-unless"] = p.RequiredUnless
+		}
+		properties[p.Name] = prop
+
+		if p.Required && p.Default == "" && len(p.RequiredUnless) == 0 {
+			required = append(required, p.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      f.Name,
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		schema["description"] = desc
+	}
+	return schema
+}
+
+// schemaDefault converts a default to its JSON type where possible.
+func schemaDefault(p Param) any {
+	switch p.Kind() {
+	case ParamInt:
+		if n, err := strconv.Atoi(p.Default); err == nil {
+			return n
+		}
+	case ParamNumber:
+		if n, err := strconv.ParseFloat(p.Default, 64); err == nil {
+			return n
+		}
+	case ParamBool:
+		if b, err := strconv.ParseBool(p.Default); err == nil {
+			return b
+		}
+	}
+	return p.Default
+}
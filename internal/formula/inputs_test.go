@@ -0,0 +1,171 @@
+package formula
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const typedInputsFormula = `formula = "deploy"
+type = "workflow"
+description = "Deploy a service"
+
+[vars.retries]
+type = "int"
+default = "3"
+
+[vars.env]
+type = "enum"
+values = ["staging", "prod"]
+required = true
+
+[vars.dry_run]
+type = "bool"
+default = "false"
+
+[vars.ticket]
+type = "bead-id"
+description = "Tracking issue"
+
+[vars.rig]
+type = "rig-name"
+
+[vars.version]
+type = "regex"
+pattern = 'v\d+\.\d+\.\d+'
+required = true
+
+[vars.manifest]
+type = "file-path"
+
+[inputs.pr]
+type = "int"
+required = true
+required_unless = ["branch"]
+
+[inputs.branch]
+description = "Branch to deploy"
+
+[[steps]]
+id = "ship"
+`
+
+func TestInputChecker_Check(t *testing.T) {
+	f, err := Parse([]byte(typedInputsFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	c := InputChecker{
+		RigExists:  func(name string) bool { return name == "gastown" },
+		BeadExists: func(id string) bool { return id == "gt-abc12" },
+		FileExists: func(path string) bool { return path == "deploy.yaml" },
+	}
+
+	valid := map[string]string{"env": "prod", "version": "v1.2.3", "branch": "main"}
+	if err := c.Check(f, valid); err != nil {
+		t.Fatalf("Check(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string // Merged over the valid values; "" deletes
+		wantErr string
+	}{
+		{"missing required", map[string]string{"env": ""}, "env: required"},
+		{"required_unless unmet", map[string]string{"branch": ""}, "pr: required (unless branch is set)"},
+		{"bad int", map[string]string{"retries": "three"}, `retries: "three" is not an integer`},
+		{"bad enum", map[string]string{"env": "dev"}, `env: "dev" is not one of staging, prod`},
+		{"bad bool", map[string]string{"dry_run": "yes please"}, "is not a bool"},
+		{"bad regex", map[string]string{"version": "1.2"}, `version: "1.2" does not match`},
+		{"bad bead id", map[string]string{"ticket": "not a bead"}, "is not a bead ID"},
+		{"unknown bead", map[string]string{"ticket": "gt-zzz99"}, "bead gt-zzz99 not found"},
+		{"unknown rig", map[string]string{"rig": "beads"}, `unknown rig "beads"`},
+		{"missing file", map[string]string{"manifest": "nope.yaml"}, "nope.yaml does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]string{"env": "prod", "version": "v1.2.3", "branch": "main"}
+			for k, v := range tt.values {
+				if v == "" {
+					delete(values, k)
+					continue
+				}
+				values[k] = v
+			}
+			err := c.Check(f, values)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	supplied := InputChecker{Supplied: []string{"env", "branch"}}
+	if missing := supplied.Missing(f, map[string]string{}); len(missing) != 1 || missing[0].Name != "version" {
+		t.Errorf("Missing with supplied = %+v, want [version]", missing)
+	}
+}
+
+func TestParse_InvalidParams(t *testing.T) {
+	tests := []struct {
+		decl    string
+		wantErr string
+	}{
+		{"[vars.n]\ntype = \"integer\"\n", `n: unknown type "integer"`},
+		{"[vars.n]\ntype = \"enum\"\n", "enum requires values"},
+		{"[vars.n]\ntype = \"regex\"\n", "regex requires pattern"},
+		{"[vars.n]\npattern = \"(\"\n", "invalid pattern"},
+		{"[vars.n]\ntype = \"int\"\ndefault = \"many\"\n", "default: n"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte("formula = \"x\"\n" + tt.decl + "[[steps]]\nid = \"a\"\n"))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Parse(%q) error = %v, want containing %q", tt.decl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInputSchema(t *testing.T) {
+	f, err := Parse([]byte(typedInputsFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	data, err := json.Marshal(f.InputSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Title       string                    `json:"title"`
+		Description string                    `json:"description"`
+		Required    []string                  `json:"required"`
+		Properties  map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Title != "deploy" || schema.Description != "Deploy a service" {
+		t.Errorf("title/description = %q/%q", schema.Title, schema.Description)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"env", "version"}) {
+		t.Errorf("required = %v, want [env version]", schema.Required)
+	}
+
+	want := map[string]map[string]any{
+		"retries":  {"type": "integer", "default": float64(3)},
+		"env":      {"type": "string", "enum": []any{"staging", "prod"}},
+		"dry_run":  {"type": "boolean", "default": false},
+		"rig":      {"type": "string", "format": "rig-name"},
+		"version":  {"type": "string", "pattern": `^(?:v\d+\.\d+\.\d+)$`},
+		"manifest": {"type": "string", "format": "file-path"},
+		"pr":       {"type": "integer", "x-required-unless": []any{"branch"}},
+		"branch":   {"type": "string", "description": "Branch to deploy"},
+	}
+	for name, props := range want {
+		if got := schema.Properties[name]; !reflect.DeepEqual(got, props) {
+			t.Errorf("properties[%s] = %v, want %v", name, got, props)
+		}
+	}
+	if got := schema.Properties["ticket"]["format"]; got != "bead-id" {
+		t.Errorf("ticket format = %v, want bead-id", got)
+	}
+}
@@ -9,10 +9,8 @@ import (
 // TestParseRealFormulas tests parsing all embedded formula files.
 // Composition formulas (extends/compose) are now also resolved and validated.
 func TestParseRealFormulas(t *testing.T) {
-	// Formulas that use aspect-oriented features not yet implemented.
-	skipFormulas := map[string]string{
-		"security-audit.formula.toml": "uses aspect-oriented features (advice/pointcuts)",
-	}
+	// Formulas that use features not yet implemented.
+	skipFormulas := map[string]string{}
 
 	entries, err := fs.ReadDir(formulasFS, "formulas")
 	if err != nil {
@@ -0,0 +1,79 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capability levels for Step.MinCapability, ordered from least to most
+// capable. They follow the Claude model families: basic is haiku-class,
+// standard is sonnet-class, and advanced is opus-class.
+const (
+	CapabilityBasic    = "basic"
+	CapabilityStandard = "standard"
+	CapabilityAdvanced = "advanced"
+)
+
+// CapabilityRank orders capability levels: basic < standard < advanced.
+// Unknown levels rank 0.
+func CapabilityRank(level string) int {
+	switch level {
+	case CapabilityBasic:
+		return 1
+	case CapabilityStandard:
+		return 2
+	case CapabilityAdvanced:
+		return 3
+	}
+	return 0
+}
+
+// ModelFamily normalizes a model name to its family ("opus", "sonnet",
+// "haiku"), dropping provider prefixes, versions, and context suffixes such
+// as "[1m]". Names outside the known families are returned lowercased.
+func ModelFamily(model string) string {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.Index(m, "["); i >= 0 {
+		m = m[:i]
+	}
+	for _, family := range []string{"opus", "sonnet", "haiku"} {
+		if strings.Contains(m, family) {
+			return family
+		}
+	}
+	return m
+}
+
+// ModelCapability returns the capability level of a model, or "" when the
+// model's family is not known.
+func ModelCapability(model string) string {
+	switch ModelFamily(model) {
+	case "haiku":
+		return CapabilityBasic
+	case "sonnet":
+		return CapabilityStandard
+	case "opus":
+		return CapabilityAdvanced
+	}
+	return ""
+}
+
+// validateAgentConstraints checks a step's or template's agent, model, and
+// min_capability fields. An explicit agent already fixes the model, so it
+// cannot be combined with the other two.
+func validateAgentConstraints(kind, id, agent, model, minCapability string) error {
+	if minCapability != "" && CapabilityRank(minCapability) == 0 {
+		return fmt.Errorf("%s %q: invalid min_capability %q (want %s, %s, or %s)",
+			kind, id, minCapability, CapabilityBasic, CapabilityStandard, CapabilityAdvanced)
+	}
+	if agent != "" && (model != "" || minCapability != "") {
+		return fmt.Errorf("%s %q: agent cannot be combined with model or min_capability", kind, id)
+	}
+	return nil
+}
+
+// HasAgentConstraints reports whether the step sets agent, model, or
+// min_capability.
+func (s *Step) HasAgentConstraints() bool {
+	return s.Agent != "" || s.Model != "" || s.MinCapability != ""
+}
@@ -0,0 +1,89 @@
+package formula
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModelCapability(t *testing.T) {
+	tests := map[string]string{
+		"opus":              CapabilityAdvanced,
+		"claude-opus-4-5":   CapabilityAdvanced,
+		"sonnet[1m]":        CapabilityStandard,
+		"Claude-Sonnet-4-5": CapabilityStandard,
+		"haiku":             CapabilityBasic,
+		"groq/compound":     "",
+		"":                  "",
+	}
+	for model, want := range tests {
+		if got := ModelCapability(model); got != want {
+			t.Errorf("ModelCapability(%q) = %q, want %q", model, got, want)
+		}
+	}
+	if CapabilityRank(CapabilityBasic) >= CapabilityRank(CapabilityStandard) || CapabilityRank("expert") != 0 {
+		t.Error("CapabilityRank ordering is wrong")
+	}
+}
+
+func TestParse_StepAgentConstraints(t *testing.T) {
+	f, err := Parse([]byte(`formula = "models"
+[[steps]]
+id = "design"
+model = "opus"
+[[steps]]
+id = "rename"
+needs = ["design"]
+min_capability = "basic"
+[[steps]]
+id = "review"
+needs = ["rename"]
+agent = "codex"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Steps[0].Model != "opus" || f.Steps[1].MinCapability != CapabilityBasic || f.Steps[2].Agent != "codex" {
+		t.Errorf("constraints not parsed: %+v", f.Steps)
+	}
+
+	tests := map[string]string{
+		"[[steps]]\nid = \"a\"\nmin_capability = \"expert\"\n":                                                 `invalid min_capability "expert"`,
+		"[[steps]]\nid = \"a\"\nagent = \"codex\"\nmodel = \"opus\"\n":                                         "agent cannot be combined",
+		"type = \"expansion\"\n[[template]]\nid = \"{target}.a\"\nagent = \"x\"\nmin_capability = \"basic\"\n": `template "{target}.a"`,
+	}
+	for body, want := range tests {
+		if _, err := Parse([]byte("formula = \"bad\"\n" + body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want containing %q", body, err, want)
+		}
+	}
+}
+
+func TestApplyExpandRule_AgentConstraints(t *testing.T) {
+	dir := t.TempDir()
+	expansion := `formula = "split"
+type = "expansion"
+[[template]]
+id = "{target}.plan"
+model = "opus"
+[[template]]
+id = "{target}.do"
+needs = ["{target}.plan"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "split.formula.toml"), []byte(expansion), 0644); err != nil {
+		t.Fatal(err)
+	}
+	steps := []Step{{ID: "work", MinCapability: CapabilityStandard}}
+	expanded, err := applyExpandRule(steps, &ExpandRule{Target: "work", With: "split"}, []string{dir})
+	if err != nil {
+		t.Fatalf("applyExpandRule: %v", err)
+	}
+	// A template's own constraints win; an unconstrained template inherits the target's.
+	if got := expanded[0]; got.Model != "opus" || got.MinCapability != "" {
+		t.Errorf("work.plan = %+v", got)
+	}
+	if got := expanded[1]; got.Model != "" || got.MinCapability != CapabilityStandard {
+		t.Errorf("work.do = %+v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package formula
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputType is the declared type of a step output.
+type OutputType string
+
+const (
+	// OutputString is free text (the default).
+	OutputString OutputType = "string"
+
+	// OutputJSON is a JSON document, stored compacted.
+	OutputJSON OutputType = "json"
+
+	// OutputPath is a file path produced by the step.
+	OutputPath OutputType = "path"
+)
+
+// StepOutput declares a named value a workflow step records when it runs
+// (gt mol step output set). Later steps reference it as
+// {{steps.<id>.outputs.<name>}} in descriptions, or steps.<id>.outputs.<name>
+// in when/repeat_until conditions.
+//
+// Supports both shorthand string syntax (pr_url = "string") and full table
+// syntax ([steps.outputs.pr_url] with type/description).
+type StepOutput struct {
+	Type        OutputType `toml:"type"`
+	Description string     `toml:"description"`
+}
+
+// UnmarshalTOML allows StepOutput to be decoded from either a plain type
+// string or a table with type/description keys.
+func (o *StepOutput) UnmarshalTOML(data any) error {
+	switch val := data.(type) {
+	case string:
+		o.Type = OutputType(val)
+		return nil
+	case map[string]any:
+		if t, ok := val["type"]; ok {
+			if s, ok := t.(string); ok {
+				o.Type = OutputType(s)
+			}
+		}
+		if d, ok := val["description"]; ok {
+			if s, ok := d.(string); ok {
+				o.Description = s
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("expected string or table for step output, got %T", data)
+	}
+}
+
+// Kind returns the output type, defaulting to OutputString.
+func (o StepOutput) Kind() OutputType {
+	if o.Type == "" {
+		return OutputString
+	}
+	return o.Type
+}
+
+// IsValid returns true if the output type is recognized.
+func (t OutputType) IsValid() bool {
+	switch t {
+	case "", OutputString, OutputJSON, OutputPath:
+		return true
+	}
+	return false
+}
+
+// Normalize checks value against the output type and returns the form to
+// store: JSON is compacted onto one line, paths are trimmed.
+func (t OutputType) Normalize(value string) (string, error) {
+	switch t {
+	case OutputJSON:
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(value)); err != nil {
+			return "", fmt.Errorf("invalid JSON: %w", err)
+		}
+		return buf.String(), nil
+	case OutputPath:
+		p := strings.TrimSpace(value)
+		if p == "" || strings.ContainsAny(p, "\n\x00") {
+			return "", fmt.Errorf("invalid path %q", value)
+		}
+		return p, nil
+	}
+	return value, nil
+}
+
+// outputNamePattern matches valid output names.
+var outputNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// outputRefPattern matches {{steps.<id>.outputs.<name>}} placeholders.
+var outputRefPattern = regexp.MustCompile(`\{\{\s*steps\.([a-zA-Z0-9_.-]+?)\.outputs\.([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+
+// OutputRef is a reference to another step's output.
+type OutputRef struct {
+	Step string
+	Name string
+}
+
+// String returns the reference in condition syntax.
+func (r OutputRef) String() string {
+	return "steps." + r.Step + ".outputs." + r.Name
+}
+
+// ExtractOutputRefs finds all {{steps.<id>.outputs.<name>}} placeholders in
+// text. Returns a deduplicated list sorted by step, then name.
+func ExtractOutputRefs(text string) []OutputRef {
+	seen := make(map[OutputRef]bool)
+	var refs []OutputRef
+	for _, m := range outputRefPattern.FindAllStringSubmatch(text, -1) {
+		ref := OutputRef{Step: m[1], Name: m[2]}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Step != refs[j].Step {
+			return refs[i].Step < refs[j].Step
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+// SubstituteOutputs replaces {{steps.<id>.outputs.<name>}} placeholders with
+// values recorded in env. Placeholders for outputs not yet recorded are left
+// as-is.
+func SubstituteOutputs(text string, env Env) string {
+	return outputRefPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := outputRefPattern.FindStringSubmatch(match)
+		v, ok := env.Outputs[m[1]][m[2]]
+		if !ok {
+			return match
+		}
+		return v
+	})
+}
+
+// validateStepOutputs checks output names and types on every step.
+func (f *Formula) validateStepOutputs() error {
+	for _, step := range f.Steps {
+		for name, out := range step.Outputs {
+			if !outputNamePattern.MatchString(name) {
+				return fmt.Errorf("step %q: invalid output name %q", step.ID, name)
+			}
+			if !out.Type.IsValid() {
+				return fmt.Errorf("step %q output %q: unknown type %q (want string, json, or path)", step.ID, name, out.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOutputRefs checks that every step output referenced from a step's
+// title, description, or conditions is declared, and that the producing step
+// runs first: descriptions and when conditions may only reference upstream
+// steps (via needs), while repeat_until may also reference the step itself.
+func (f *Formula) validateOutputRefs() error {
+	var problems []string
+	check := func(stepID, where string, ref OutputRef, allowSelf bool) {
+		producer := f.GetStep(ref.Step)
+		switch {
+		case producer == nil:
+			problems = append(problems, fmt.Sprintf("%s (%s of %s): unknown step", ref, where, stepID))
+		case !hasOutput(producer, ref.Name):
+			problems = append(problems, fmt.Sprintf("%s (%s of %s): output not declared", ref, where, stepID))
+		case ref.Step == stepID && !allowSelf:
+			problems = append(problems, fmt.Sprintf("%s (%s of %s): step cannot use its own output", ref, where, stepID))
+		case ref.Step != stepID && !f.upstreamOf(stepID)[ref.Step]:
+			problems = append(problems, fmt.Sprintf("%s (%s of %s): %s is not upstream of %s", ref, where, stepID, ref.Step, stepID))
+		}
+	}
+
+	for _, step := range f.Steps {
+		for _, ref := range ExtractOutputRefs(step.Title + "\n" + step.Description) {
+			check(step.ID, "description", ref, false)
+		}
+		for _, c := range []struct {
+			field, src string
+			allowSelf  bool
+		}{{"when", step.When, false}, {"repeat_until", step.RepeatUntil, true}} {
+			if strings.TrimSpace(c.src) == "" {
+				continue
+			}
+			cond, err := ParseCondition(c.src)
+			if err != nil {
+				continue // Reported by Validate
+			}
+			for _, r := range cond.Refs() {
+				if id, name, ok := splitOutputRef(r); ok {
+					check(step.ID, c.field, OutputRef{Step: id, Name: name}, c.allowSelf)
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid step output references: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func hasOutput(step *Step, name string) bool {
+	_, ok := step.Outputs[name]
+	return ok
+}
+
+// upstreamOf returns the IDs of all steps that stepID transitively needs.
+func (f *Formula) upstreamOf(stepID string) map[string]bool {
+	upstream := make(map[string]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		step := f.GetStep(id)
+		if step == nil {
+			return
+		}
+		for _, need := range step.Needs {
+			if !upstream[need] {
+				upstream[need] = true
+				visit(need)
+			}
+		}
+	}
+	visit(stepID)
+	return upstream
+}
@@ -0,0 +1,120 @@
+package formula
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const outputsWorkflow = `formula = "ship"
+type = "workflow"
+
+[[steps]]
+id = "open-pr"
+title = "Open PR"
+[steps.outputs]
+pr_url = "string"
+report = { type = "json", description = "Test summary" }
+
+[[steps]]
+id = "review"
+needs = ["open-pr"]
+description = "Review {{steps.open-pr.outputs.pr_url}}"
+repeat_until = 'steps.review.outputs.verdict == "approve"'
+[steps.outputs.verdict]
+description = "approve or changes"
+
+[[steps]]
+id = "merge"
+needs = ["review"]
+when = 'steps.open-pr.outputs.pr_url != ""'
+`
+
+func TestParse_StepOutputs(t *testing.T) {
+	f, err := Parse([]byte(outputsWorkflow))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]StepOutput{
+		"pr_url": {Type: OutputString},
+		"report": {Type: OutputJSON, Description: "Test summary"},
+	}
+	if got := f.GetStep("open-pr").Outputs; !reflect.DeepEqual(got, want) {
+		t.Errorf("open-pr outputs = %+v, want %+v", got, want)
+	}
+	if kind := f.GetStep("review").Outputs["verdict"].Kind(); kind != OutputString {
+		t.Errorf("untyped output Kind = %q, want string", kind)
+	}
+	if err := f.ValidateTemplateVariables(); err != nil {
+		t.Errorf("ValidateTemplateVariables: %v", err)
+	}
+
+	if _, err := Parse([]byte(strings.Replace(outputsWorkflow, `pr_url = "string"`, `pr_url = "url"`, 1))); err == nil ||
+		!strings.Contains(err.Error(), `unknown type "url"`) {
+		t.Errorf("unknown output type error = %v", err)
+	}
+}
+
+func TestValidateTemplateVariables_OutputRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{"undeclared output", "outputs.pr_url}}", "outputs.pr_link}}", "steps.open-pr.outputs.pr_link (description of review): output not declared"},
+		{"unknown step", "steps.open-pr.outputs.pr_url}}", "steps.nope.outputs.x}}", "unknown step"},
+		{"not upstream", `needs = ["open-pr"]`, "", "open-pr is not upstream of review"},
+		{"own output in description", "steps.open-pr.outputs.pr_url}}", "steps.review.outputs.verdict}}", "cannot use its own output"},
+		{"undeclared output in when", `outputs.pr_url != ""`, `outputs.sha != ""`, "(when of merge): output not declared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(strings.Replace(outputsWorkflow, tt.from, tt.to, 1)))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			err = f.ValidateTemplateVariables()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplateVariables() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubstituteOutputs(t *testing.T) {
+	env := Env{Outputs: map[string]map[string]string{"open-pr": {"pr_url": "https://example.com/pull/7"}}}
+	text := "Review {{ steps.open-pr.outputs.pr_url }} and {{steps.open-pr.outputs.report}} for {{issue}}"
+	want := "Review https://example.com/pull/7 and {{steps.open-pr.outputs.report}} for {{issue}}"
+	if got := SubstituteOutputs(text, env); got != want {
+		t.Errorf("SubstituteOutputs = %q, want %q", got, want)
+	}
+	wantRefs := []OutputRef{{"open-pr", "pr_url"}, {"open-pr", "report"}}
+	if got := ExtractOutputRefs(text); !reflect.DeepEqual(got, wantRefs) {
+		t.Errorf("ExtractOutputRefs = %v, want %v", got, wantRefs)
+	}
+	if got := ExtractTemplateVariables(text); !reflect.DeepEqual(got, []string{"issue"}) {
+		t.Errorf("ExtractTemplateVariables = %v, want [issue]", got)
+	}
+}
+
+func TestOutputTypeNormalize(t *testing.T) {
+	tests := []struct {
+		typ     OutputType
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{OutputString, " keep  spacing\n", " keep  spacing\n", false},
+		{OutputJSON, "{\n  \"failed\": 0\n}", `{"failed":0}`, false},
+		{OutputJSON, "{nope", "", true},
+		{OutputPath, " out/report.md ", "out/report.md", false},
+		{OutputPath, "  ", "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.typ.Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s.Normalize(%q) = %q, %v; want %q, err=%v", tt.typ, tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
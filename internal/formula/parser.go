@@ -51,7 +51,7 @@ func (f *Formula) inferType() {
 		f.Type = TypeConvoy
 	} else if len(f.Template) > 0 {
 		f.Type = TypeExpansion
-	} else if len(f.Aspects) > 0 {
+	} else if len(f.Aspects) > 0 || len(f.Advice) > 0 {
 		f.Type = TypeAspect
 	}
 }
@@ -67,6 +67,10 @@ func (f *Formula) Validate() error {
 		return fmt.Errorf("invalid formula type %q (must be convoy, workflow, expansion, or aspect)", f.Type)
 	}
 
+	if err := f.validateParams(); err != nil {
+		return fmt.Errorf("invalid input %w", err)
+	}
+
 	// Type-specific validation
 	switch f.Type {
 	case TypeConvoy:
@@ -147,6 +151,21 @@ func (f *Formula) validateWorkflow() error {
 		}
 	}
 
+	if err := f.validateStepConditions(seen); err != nil {
+		return err
+	}
+	if err := f.validateStepOutputs(); err != nil {
+		return err
+	}
+	for _, step := range f.Steps {
+		if err := validateAgentConstraints("step", step.ID, step.Agent, step.Model, step.MinCapability); err != nil {
+			return err
+		}
+		if err := step.validateFailurePolicy(seen); err != nil {
+			return err
+		}
+	}
+
 	// Check for cycles
 	if err := f.checkCycles(); err != nil {
 		return err
@@ -155,6 +174,35 @@ func (f *Formula) validateWorkflow() error {
 	return nil
 }
 
+// validateStepConditions checks when/repeat_until syntax, loop bounds, and
+// that step output references name known steps.
+func (f *Formula) validateStepConditions(stepIDs map[string]bool) error {
+	for _, step := range f.Steps {
+		for _, c := range []struct{ field, src string }{{"when", step.When}, {"repeat_until", step.RepeatUntil}} {
+			field, src := c.field, c.src
+			if strings.TrimSpace(src) == "" {
+				continue
+			}
+			cond, err := ParseCondition(src)
+			if err != nil {
+				return fmt.Errorf("step %q %s: %w", step.ID, field, err)
+			}
+			for _, ref := range cond.Refs() {
+				if id, _, ok := splitOutputRef(ref); ok && !stepIDs[id] {
+					return fmt.Errorf("step %q %s references unknown step: %s", step.ID, field, id)
+				}
+			}
+		}
+		if step.MaxIterations < 0 {
+			return fmt.Errorf("step %q: max_iterations must be positive", step.ID)
+		}
+		if step.MaxIterations > 0 && strings.TrimSpace(step.RepeatUntil) == "" {
+			return fmt.Errorf("step %q: max_iterations requires repeat_until", step.ID)
+		}
+	}
+	return nil
+}
+
 func (f *Formula) validateExpansion() error {
 	if len(f.Template) == 0 {
 		return fmt.Errorf("expansion formula requires at least one template")
@@ -179,6 +227,9 @@ func (f *Formula) validateExpansion() error {
 				return fmt.Errorf("template %q needs unknown template: %s", tmpl.ID, need)
 			}
 		}
+		if err := validateAgentConstraints("template", tmpl.ID, tmpl.Agent, tmpl.Model, tmpl.MinCapability); err != nil {
+			return err
+		}
 	}
 
 	// Check for cycles
@@ -190,8 +241,11 @@ func (f *Formula) validateExpansion() error {
 }
 
 func (f *Formula) validateAspect() error {
-	if len(f.Aspects) == 0 {
-		return fmt.Errorf("aspect formula requires at least one aspect")
+	if len(f.Aspects) == 0 && len(f.Advice) == 0 {
+		return fmt.Errorf("aspect formula requires at least one aspect or advice rule")
+	}
+	if err := f.validateAdvice(); err != nil {
+		return err
 	}
 
 	// Check aspect IDs are unique
@@ -417,6 +471,81 @@ func (f *Formula) ReadySteps(completed map[string]bool) []string {
 	return ready
 }
 
+// ReadyStepsEnv is ReadySteps for workflows with conditional steps.
+// completed holds steps that finished or were skipped earlier. A step whose
+// needs are all met is ready unless its when condition is false under env,
+// in which case it is skipped; skipping propagates, so a step behind a
+// skipped step may become ready (or skipped) in the same call.
+// Returns the ready steps and the steps newly skipped by this call.
+func (f *Formula) ReadyStepsEnv(completed map[string]bool, env Env) (ready, skipped []string, err error) {
+	if f.Type != TypeWorkflow {
+		return f.ReadySteps(completed), nil, nil
+	}
+	done := make(map[string]bool, len(completed))
+	for id, ok := range completed {
+		done[id] = ok
+	}
+	for {
+		progressed := false
+		ready = ready[:0]
+		for _, step := range f.Steps {
+			if done[step.ID] {
+				continue
+			}
+			allMet := true
+			for _, need := range step.Needs {
+				if !done[need] {
+					allMet = false
+					break
+				}
+			}
+			if !allMet {
+				continue
+			}
+			run, err := EvalCondition(step.When, env)
+			if err != nil {
+				return nil, nil, fmt.Errorf("step %q when: %w", step.ID, err)
+			}
+			if run {
+				ready = append(ready, step.ID)
+				continue
+			}
+			done[step.ID] = true
+			skipped = append(skipped, step.ID)
+			progressed = true
+		}
+		if !progressed {
+			return ready, skipped, nil
+		}
+	}
+}
+
+// IterationLimit returns how many times the step may run: 1 for ordinary
+// steps, max_iterations (or DefaultMaxIterations) for repeat_until loops.
+func (s *Step) IterationLimit() int {
+	if strings.TrimSpace(s.RepeatUntil) == "" {
+		return 1
+	}
+	if s.MaxIterations > 0 {
+		return s.MaxIterations
+	}
+	return DefaultMaxIterations
+}
+
+// RepeatAgain reports whether a step that has just finished its
+// iteration'th run (1-based) should run again: its repeat_until condition
+// is still false and the iteration limit has not been reached.
+func (s *Step) RepeatAgain(iteration int, env Env) (bool, error) {
+	if strings.TrimSpace(s.RepeatUntil) == "" || iteration >= s.IterationLimit() {
+		return false, nil
+	}
+	done, err := EvalCondition(s.RepeatUntil, env)
+	if err != nil {
+		return false, fmt.Errorf("step %q repeat_until: %w", s.ID, err)
+	}
+	return !done, nil
+}
+
 // GetStep returns a step by ID, or nil if not found.
 func (f *Formula) GetStep(id string) *Step {
 	for i := range f.Steps {
@@ -589,7 +718,13 @@ func resolveChain(formula *Formula, searchPaths []string, chain []string) (*Form
 			}
 			merged.Steps = expanded
 		}
-		// compose.aspects is recorded but not yet acted upon (future work).
+		if len(formula.Compose.Aspects) > 0 {
+			woven, err := weaveAspects(merged.Steps, formula.Compose.Aspects, searchPaths, nil)
+			if err != nil {
+				return nil, fmt.Errorf("compose aspects: %w", err)
+			}
+			merged.Steps = woven
+		}
 	}
 
 	if err := merged.Validate(); err != nil {
@@ -598,9 +733,23 @@ func resolveChain(formula *Formula, searchPaths []string, chain []string) (*Form
 	return merged, nil
 }
 
-// loadFormulaByName loads a formula by name: embedded FS first, then searchPaths.
+// loadFormulaByName loads a formula by name: a version installed from a
+// registry (recorded in a search path's lockfile) first, then the embedded
+// FS, then plain files in searchPaths.
 func loadFormulaByName(name string, searchPaths []string) (*Formula, error) {
-	// Try the embedded formula filesystem first.
+	// An installed version was chosen explicitly, so it overrides the
+	// embedded copy.
+	for _, dir := range searchPaths {
+		path, err := InstalledFormulaPath(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			return ParseFile(path)
+		}
+	}
+
+	// Try the embedded formula filesystem.
 	data, err := GetEmbeddedFormulaContent(name)
 	if err == nil {
 		return Parse(data)
@@ -655,6 +804,13 @@ func applyExpandRule(steps []Step, rule *ExpandRule, searchPaths []string) ([]St
 			Title:       expandPlaceholders(tmpl.Title, rule.Target, targetStep),
 			Description: expandPlaceholders(tmpl.Description, rule.Target, targetStep),
 			Acceptance:  expandPlaceholders(tmpl.Acceptance, rule.Target, targetStep),
+
+			Agent:         tmpl.Agent,
+			Model:         tmpl.Model,
+			MinCapability: tmpl.MinCapability,
+		}
+		if !newStep.HasAgentConstraints() {
+			newStep.Agent, newStep.Model, newStep.MinCapability = targetStep.Agent, targetStep.Model, targetStep.MinCapability
 		}
 		if len(tmpl.Needs) == 0 {
 			// First expanded step inherits the target's own needs.
@@ -605,8 +605,13 @@ func TestResolve_ShinySecure(t *testing.T) {
 		t.Fatalf("Resolve: %v", err)
 	}
 
-	// shiny-secure has no expand rules; it should produce the same 5 steps as shiny.
-	wantIDs := []string{"design", "implement", "review", "test", "submit"}
+	// shiny-secure weaves security-audit scans around implement and submit.
+	wantIDs := []string{
+		"design",
+		"implement-security-prescan", "implement", "implement-security-postscan",
+		"review", "test",
+		"submit-security-prescan", "submit", "submit-security-postscan",
+	}
 	if len(resolved.Steps) != len(wantIDs) {
 		t.Fatalf("got %d steps, want %d: %v", len(resolved.Steps), len(wantIDs), stepIDs(resolved))
 	}
@@ -615,6 +620,9 @@ func TestResolve_ShinySecure(t *testing.T) {
 			t.Errorf("step[%d] = %q, want %q", i, got, want)
 		}
 	}
+	if got := resolved.Steps[4].Needs; len(got) != 1 || got[0] != "implement-security-postscan" {
+		t.Errorf("review needs = %v, want [implement-security-postscan]", got)
+	}
 }
 
 // TestResolve_CycleDetection verifies that circular extends chains are rejected.
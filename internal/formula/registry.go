@@ -0,0 +1,552 @@
+package formula
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/atomicfile"
+)
+
+// A formula registry is a directory or a static HTTP site laid out as:
+//
+//	index.json                              - RegistryIndex
+//	<name>/<version>/<name>.formula.toml    - published formula packages
+//
+// The index lists every published version with its SHA-256 checksum, so a
+// plain file server (or a git checkout served over HTTPS) is a registry.
+const (
+	// RegistryIndexFile is the index at the root of a registry.
+	RegistryIndexFile = "index.json"
+
+	// LockFileName is the per-directory record of installed formulas.
+	LockFileName = ".lock.json"
+
+	// InstalledDir holds installed formula packages, one file per version.
+	InstalledDir = ".installed"
+
+	// maxFormulaPackageSize bounds a downloaded formula package.
+	maxFormulaPackageSize = 4 << 20
+)
+
+// RegistryIndex is the registry's index.json.
+type RegistryIndex struct {
+	Version  int                       `json:"version"`
+	Formulas map[string]*RegistryEntry `json:"formulas"`
+}
+
+// RegistryEntry lists the published versions of one formula.
+type RegistryEntry struct {
+	Description string            `json:"description,omitempty"`
+	Versions    []RegistryVersion `json:"versions"`
+}
+
+// RegistryVersion is one published formula package.
+type RegistryVersion struct {
+	Version     string    `json:"version"`
+	SHA256      string    `json:"sha256"`
+	Path        string    `json:"path"` // Relative to the registry root
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Registry serves a formula index and its packages.
+type Registry interface {
+	// Location is the directory or URL the registry was opened from.
+	Location() string
+	// Index fetches the registry index.
+	Index(ctx context.Context) (*RegistryIndex, error)
+	// Fetch downloads a package and verifies its checksum.
+	Fetch(ctx context.Context, v RegistryVersion) ([]byte, error)
+}
+
+// OpenRegistry opens an http(s) URL as a static HTTP registry and anything
+// else (a path or file:// URL) as a directory registry.
+func OpenRegistry(location string) (Registry, error) {
+	if location == "" {
+		return nil, errors.New("no formula registry configured (use --registry or set GT_FORMULA_REGISTRY)")
+	}
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(strings.TrimSuffix(location, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry URL %q: %w", location, err)
+		}
+		return &httpRegistry{base: u, client: &http.Client{Timeout: 30 * time.Second}}, nil
+	}
+	dir := strings.TrimPrefix(location, "file://")
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &dirRegistry{root: abs}, nil
+}
+
+type dirRegistry struct {
+	root string
+}
+
+func (r *dirRegistry) Location() string { return r.root }
+
+func (r *dirRegistry) Index(ctx context.Context) (*RegistryIndex, error) {
+	data, err := os.ReadFile(filepath.Join(r.root, RegistryIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &RegistryIndex{Version: 1, Formulas: map[string]*RegistryEntry{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading registry index: %w", err)
+	}
+	return parseRegistryIndex(data)
+}
+
+func (r *dirRegistry) Fetch(ctx context.Context, v RegistryVersion) ([]byte, error) {
+	rel, err := cleanPackagePath(v.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, fmt.Errorf("reading package: %w", err)
+	}
+	return data, verifyChecksum(data, v.SHA256)
+}
+
+type httpRegistry struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func (r *httpRegistry) Location() string { return r.base.String() }
+
+func (r *httpRegistry) Index(ctx context.Context) (*RegistryIndex, error) {
+	data, err := r.get(ctx, RegistryIndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("fetching registry index: %w", err)
+	}
+	return parseRegistryIndex(data)
+}
+
+func (r *httpRegistry) Fetch(ctx context.Context, v RegistryVersion) ([]byte, error) {
+	rel, err := cleanPackagePath(v.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.get(ctx, rel)
+	if err != nil {
+		return nil, fmt.Errorf("downloading package: %w", err)
+	}
+	return data, verifyChecksum(data, v.SHA256)
+}
+
+func (r *httpRegistry) get(ctx context.Context, rel string) ([]byte, error) {
+	ref, err := url.Parse(rel)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", req.URL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFormulaPackageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFormulaPackageSize {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", req.URL, maxFormulaPackageSize)
+	}
+	return data, nil
+}
+
+func parseRegistryIndex(data []byte) (*RegistryIndex, error) {
+	var idx RegistryIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("parsing registry index: %w", err)
+	}
+	if idx.Version != 1 {
+		return nil, fmt.Errorf("unsupported registry index version %d", idx.Version)
+	}
+	if idx.Formulas == nil {
+		idx.Formulas = map[string]*RegistryEntry{}
+	}
+	for name, entry := range idx.Formulas {
+		if err := validateRegistryName(name); err != nil {
+			return nil, fmt.Errorf("registry index: %w", err)
+		}
+		if entry == nil {
+			continue
+		}
+		for _, v := range entry.Versions {
+			if err := validateRegistryVersion(name, v.Version); err != nil {
+				return nil, fmt.Errorf("registry index: %w", err)
+			}
+		}
+	}
+	return &idx, nil
+}
+
+// registryNamePattern is what a registry formula name may look like. Names
+// become file names under the installed/ directory and the registry root, so
+// separators, dots and leading dashes are refused.
+var registryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func validateRegistryName(name string) error {
+	if !registryNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid formula name %q: must be lowercase letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// validateRegistryVersion refuses versions that are not plain semver, since
+// they are joined into package file names too.
+func validateRegistryVersion(name, version string) error {
+	if _, ok := parseSemver(version); !ok || strings.HasPrefix(version, "v") {
+		return fmt.Errorf("invalid version %q for formula %s", version, name)
+	}
+	return nil
+}
+
+// cleanPackagePath rejects package paths that escape the registry root.
+func cleanPackagePath(p string) (string, error) {
+	clean := path.Clean(p)
+	if p == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(p, "://") {
+		return "", fmt.Errorf("invalid package path %q", p)
+	}
+	return clean, nil
+}
+
+// Checksum returns the hex SHA-256 of a formula package.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func verifyChecksum(data []byte, want string) error {
+	if got := Checksum(data); got != strings.TrimPrefix(want, "sha256:") {
+		return fmt.Errorf("checksum mismatch: got sha256:%s, want sha256:%s", got, strings.TrimPrefix(want, "sha256:"))
+	}
+	return nil
+}
+
+// semverPattern matches MAJOR.MINOR.PATCH with an optional pre-release.
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?$`)
+
+type semver struct {
+	parts [3]int
+	pre   string
+}
+
+func parseSemver(v string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(v)
+	if m == nil {
+		return semver{}, false
+	}
+	var s semver
+	for i := 0; i < 3; i++ {
+		s.parts[i], _ = strconv.Atoi(m[i+1])
+	}
+	s.pre = m[4]
+	return s, true
+}
+
+// CompareVersions compares two semantic versions, returning -1, 0, or 1.
+// A pre-release sorts before its release; invalid versions sort first.
+func CompareVersions(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if va.parts[i] != vb.parts[i] {
+			if va.parts[i] < vb.parts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case va.pre == vb.pre:
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	}
+	return strings.Compare(va.pre, vb.pre)
+}
+
+// ParseFormulaRef splits "name@constraint" (constraint may be empty).
+func ParseFormulaRef(ref string) (name, constraint string) {
+	name, constraint, _ = strings.Cut(ref, "@")
+	return name, constraint
+}
+
+// matchesConstraint reports whether version satisfies a constraint of
+// "" (any release), "4" (major), "4.1" (major.minor), or "4.1.2" (exact).
+func matchesConstraint(version, constraint string) bool {
+	v, ok := parseSemver(version)
+	if !ok {
+		return false
+	}
+	if constraint == "" || constraint == "latest" {
+		return v.pre == ""
+	}
+	if _, exact := parseSemver(constraint); exact {
+		return CompareVersions(version, constraint) == 0
+	}
+	fields := strings.Split(strings.TrimPrefix(constraint, "v"), ".")
+	if len(fields) > 2 {
+		return false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || v.parts[i] != n {
+			return false
+		}
+	}
+	return v.pre == ""
+}
+
+// Resolve returns the highest published version of name satisfying
+// constraint (see ParseFormulaRef).
+func (idx *RegistryIndex) Resolve(name, constraint string) (RegistryVersion, error) {
+	entry, ok := idx.Formulas[name]
+	if !ok || len(entry.Versions) == 0 {
+		return RegistryVersion{}, fmt.Errorf("formula %q not found in registry", name)
+	}
+	var best *RegistryVersion
+	for i := range entry.Versions {
+		v := &entry.Versions[i]
+		if matchesConstraint(v.Version, constraint) && (best == nil || CompareVersions(v.Version, best.Version) > 0) {
+			best = v
+		}
+	}
+	if best == nil {
+		return RegistryVersion{}, fmt.Errorf("no version of %q matches %q", name, constraint)
+	}
+	return *best, nil
+}
+
+// RegistrySearchResult is one formula matched by Search.
+type RegistrySearchResult struct {
+	Name        string `json:"name"`
+	Latest      string `json:"latest"`
+	Description string `json:"description,omitempty"`
+	Versions    int    `json:"versions"`
+}
+
+// Search returns formulas whose name or description contains query
+// (case-insensitive), sorted by name. An empty query matches everything.
+func (idx *RegistryIndex) Search(query string) []RegistrySearchResult {
+	query = strings.ToLower(query)
+	var results []RegistrySearchResult
+	for name, entry := range idx.Formulas {
+		if query != "" && !strings.Contains(strings.ToLower(name), query) &&
+			!strings.Contains(strings.ToLower(entry.Description), query) {
+			continue
+		}
+		r := RegistrySearchResult{Name: name, Description: entry.Description, Versions: len(entry.Versions)}
+		if latest, err := idx.Resolve(name, ""); err == nil {
+			r.Latest = latest.Version
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// PublishToDir adds a formula package to a directory registry. The content
+// must parse as a formula named name, and version must be new.
+func PublishToDir(root, name, version string, content []byte, now time.Time) (RegistryVersion, error) {
+	if err := validateRegistryName(name); err != nil {
+		return RegistryVersion{}, err
+	}
+	if _, ok := parseSemver(version); !ok {
+		return RegistryVersion{}, fmt.Errorf("invalid version %q (want MAJOR.MINOR.PATCH)", version)
+	}
+	version = strings.TrimPrefix(version, "v")
+	f, err := Parse(content)
+	if err != nil {
+		return RegistryVersion{}, fmt.Errorf("invalid formula: %w", err)
+	}
+	if f.Name != name {
+		return RegistryVersion{}, fmt.Errorf("formula declares name %q, publishing as %q", f.Name, name)
+	}
+
+	reg := &dirRegistry{root: root}
+	idx, err := reg.Index(context.Background())
+	if err != nil {
+		return RegistryVersion{}, err
+	}
+	entry := idx.Formulas[name]
+	if entry == nil {
+		entry = &RegistryEntry{}
+		idx.Formulas[name] = entry
+	}
+	for _, v := range entry.Versions {
+		if CompareVersions(v.Version, version) == 0 {
+			return RegistryVersion{}, fmt.Errorf("%s@%s is already published", name, version)
+		}
+	}
+
+	v := RegistryVersion{
+		Version:     version,
+		SHA256:      Checksum(content),
+		Path:        path.Join(name, version, name+".formula.toml"),
+		PublishedAt: now.UTC(),
+	}
+	pkgPath := filepath.Join(root, filepath.FromSlash(v.Path))
+	if err := os.MkdirAll(filepath.Dir(pkgPath), 0755); err != nil {
+		return RegistryVersion{}, err
+	}
+	if err := atomicfile.WriteFile(pkgPath, content, 0644); err != nil {
+		return RegistryVersion{}, fmt.Errorf("writing package: %w", err)
+	}
+	entry.Versions = append(entry.Versions, v)
+	sort.Slice(entry.Versions, func(i, j int) bool {
+		return CompareVersions(entry.Versions[i].Version, entry.Versions[j].Version) < 0
+	})
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		if first, _, _ := strings.Cut(desc, "\n"); first != "" {
+			entry.Description = first
+		}
+	}
+	if err := atomicfile.WriteJSON(filepath.Join(root, RegistryIndexFile), idx); err != nil {
+		return RegistryVersion{}, fmt.Errorf("writing registry index: %w", err)
+	}
+	return v, nil
+}
+
+// Lockfile records the formulas installed into a formulas directory
+// (normally the town's .beads/formulas/).
+type Lockfile struct {
+	Version  int                      `json:"version"`
+	Formulas map[string]LockedFormula `json:"formulas"`
+}
+
+// LockedFormula is one installed formula.
+type LockedFormula struct {
+	Version     string    `json:"version"`
+	Pinned      bool      `json:"pinned"` // Installed at an explicit version; update leaves it alone
+	Checksum    string    `json:"checksum"`
+	InstalledAt time.Time `json:"installed_at"`
+	Registry    string    `json:"registry"`
+}
+
+// ReadLockfile reads dir's lockfile; a missing lockfile is empty.
+func ReadLockfile(dir string) (*Lockfile, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lockfile{Version: 1, Formulas: map[string]LockedFormula{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Join(dir, LockFileName), err)
+	}
+	if lock.Formulas == nil {
+		lock.Formulas = map[string]LockedFormula{}
+	}
+	return &lock, nil
+}
+
+// InstalledFormulaFile returns where an installed package lives in dir.
+// name and version must already have passed registry validation.
+func InstalledFormulaFile(dir, name, version string) string {
+	return filepath.Join(dir, InstalledDir, name+"@"+version+".formula.toml")
+}
+
+// InstallFormula writes a fetched package into dir and records it in the
+// lockfile, replacing any previously installed version.
+func InstallFormula(dir, name string, v RegistryVersion, content []byte, registry string, pinned bool, now time.Time) error {
+	if err := validateRegistryName(name); err != nil {
+		return err
+	}
+	if err := validateRegistryVersion(name, v.Version); err != nil {
+		return err
+	}
+	if err := verifyChecksum(content, v.SHA256); err != nil {
+		return err
+	}
+	lock, err := ReadLockfile(dir)
+	if err != nil {
+		return err
+	}
+	file := InstalledFormulaFile(dir, name, v.Version)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(file, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", file, err)
+	}
+	if prev, ok := lock.Formulas[name]; ok && prev.Version != v.Version {
+		_ = os.Remove(InstalledFormulaFile(dir, name, prev.Version))
+	}
+	lock.Formulas[name] = LockedFormula{
+		Version:     v.Version,
+		Pinned:      pinned,
+		Checksum:    "sha256:" + Checksum(content),
+		InstalledAt: now.UTC(),
+		Registry:    registry,
+	}
+	return atomicfile.WriteJSON(filepath.Join(dir, LockFileName), lock)
+}
+
+// InstalledFormulaPath returns the package installed for name in dir, or ""
+// if dir's lockfile does not list it. A package whose checksum no longer
+// matches the lockfile is an error rather than a silent fallback.
+func InstalledFormulaPath(dir, name string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, LockFileName)); err != nil {
+		return "", nil
+	}
+	lock, err := ReadLockfile(dir)
+	if err != nil {
+		return "", err
+	}
+	locked, ok := lock.Formulas[name]
+	if !ok {
+		return "", nil
+	}
+	if err := validateRegistryName(name); err != nil {
+		return "", err
+	}
+	if err := validateRegistryVersion(name, locked.Version); err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Join(dir, LockFileName), err)
+	}
+	file := InstalledFormulaFile(dir, name, locked.Version)
+	data, err := os.ReadFile(file) //nolint:gosec // G304: path built from the lockfile in a search path
+	if err != nil {
+		return "", fmt.Errorf("installed formula %s@%s: %w", name, locked.Version, err)
+	}
+	if err := verifyChecksum(data, locked.Checksum); err != nil {
+		return "", fmt.Errorf("installed formula %s@%s: %w", name, locked.Version, err)
+	}
+	return file, nil
+}
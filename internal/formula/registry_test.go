@@ -0,0 +1,184 @@
+package formula
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func registryFormula(name, desc string) []byte {
+	return []byte("formula = \"" + name + "\"\ndescription = \"" + desc + "\"\n[[steps]]\nid = \"a\"\n")
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "v1.2.3", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0-rc.1", "2.0.0", -1},
+		{"2.0.0-alpha", "2.0.0-beta", -1},
+		{"bogus", "0.0.1", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry_PublishResolveInstall(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, v := range []string{"1.0.0", "1.2.0", "2.0.0-rc.1", "1.10.1"} {
+		if _, err := PublishToDir(root, "review", v, registryFormula("review", "Review v"+v), now); err != nil {
+			t.Fatalf("publish %s: %v", v, err)
+		}
+	}
+	if _, err := PublishToDir(root, "review", "1.2.0", registryFormula("review", "again"), now); err == nil || !strings.Contains(err.Error(), "already published") {
+		t.Errorf("republish error = %v", err)
+	}
+	if _, err := PublishToDir(root, "review", "1.3", registryFormula("review", "x"), now); err == nil {
+		t.Error("non-semver version should be rejected")
+	}
+	if _, err := PublishToDir(root, "other", "1.0.0", registryFormula("review", "x"), now); err == nil {
+		t.Error("name mismatch should be rejected")
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(root)))
+	defer srv.Close()
+
+	for _, location := range []string{root, srv.URL} {
+		t.Run(location, func(t *testing.T) {
+			reg, err := OpenRegistry(location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			idx, err := reg.Index(context.Background())
+			if err != nil {
+				t.Fatalf("Index: %v", err)
+			}
+			for constraint, want := range map[string]string{
+				"":           "1.10.1",
+				"1":          "1.10.1",
+				"1.2":        "1.2.0",
+				"1.0.0":      "1.0.0",
+				"2.0.0-rc.1": "2.0.0-rc.1",
+			} {
+				v, err := idx.Resolve("review", constraint)
+				if err != nil || v.Version != want {
+					t.Errorf("Resolve(%q) = %s, %v; want %s", constraint, v.Version, err, want)
+				}
+			}
+			if _, err := idx.Resolve("review", "3"); err == nil {
+				t.Error("Resolve(3) should fail")
+			}
+			if got := idx.Search("REVIEW"); len(got) != 1 || got[0].Latest != "1.10.1" || got[0].Versions != 4 {
+				t.Errorf("Search = %+v", got)
+			}
+
+			v, _ := idx.Resolve("review", "1.2")
+			content, err := reg.Fetch(context.Background(), v)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			bad := v
+			bad.SHA256 = strings.Repeat("0", 64)
+			if _, err := reg.Fetch(context.Background(), bad); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+				t.Errorf("Fetch with bad checksum = %v", err)
+			}
+			escape := v
+			escape.Path = "../secret"
+			if _, err := reg.Fetch(context.Background(), escape); err == nil {
+				t.Error("Fetch outside the registry root should fail")
+			}
+
+			dir := t.TempDir()
+			if err := InstallFormula(dir, "review", v, content, reg.Location(), true, now); err != nil {
+				t.Fatalf("InstallFormula: %v", err)
+			}
+			lock, err := ReadLockfile(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lock.Formulas["review"]; got.Version != "1.2.0" || !got.Pinned || got.Registry != reg.Location() {
+				t.Errorf("lock entry = %+v", got)
+			}
+		})
+	}
+}
+
+func TestLoadFormulaByName_Installed(t *testing.T) {
+	dir := t.TempDir()
+	v := RegistryVersion{Version: "1.0.0"}
+	content := registryFormula("shiny", "Installed shiny")
+	v.SHA256 = Checksum(content)
+	if err := InstallFormula(dir, "shiny", v, content, "test", true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The installed version overrides the embedded shiny formula.
+	f, err := loadFormulaByName("shiny", []string{dir})
+	if err != nil {
+		t.Fatalf("loadFormulaByName: %v", err)
+	}
+	if f.Description != "Installed shiny" {
+		t.Errorf("description = %q, want the installed version", f.Description)
+	}
+
+	if err := os.WriteFile(InstalledFormulaFile(dir, "shiny", "1.0.0"), registryFormula("shiny", "tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadFormulaByName("shiny", []string{dir}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered install error = %v", err)
+	}
+
+	// Upgrading replaces the old package.
+	next := registryFormula("shiny", "v2")
+	if err := InstallFormula(dir, "shiny", RegistryVersion{Version: "2.0.0", SHA256: Checksum(next)}, next, "test", false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(InstalledFormulaFile(dir, "shiny", "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("old package still present: %v", err)
+	}
+	if path, err := InstalledFormulaPath(dir, "shiny"); err != nil || filepath.Base(path) != "shiny@2.0.0.formula.toml" {
+		t.Errorf("InstalledFormulaPath = %q, %v", path, err)
+	}
+}
+
+func TestRegistry_RejectsPathLikeNames(t *testing.T) {
+	for _, index := range []string{
+		`{"version":1,"formulas":{"../../x":{"versions":[{"version":"1.0.0","path":"x.formula.toml"}]}}}`,
+		`{"version":1,"formulas":{"shiny":{"versions":[{"version":"1.0.0/../../x","path":"x.formula.toml"}]}}}`,
+	} {
+		if _, err := parseRegistryIndex([]byte(index)); err == nil {
+			t.Errorf("parseRegistryIndex(%s) succeeded, want error", index)
+		}
+	}
+
+	dir := t.TempDir()
+	content := registryFormula("x", "escape")
+	v := RegistryVersion{Version: "1.0.0", SHA256: Checksum(content)}
+	if err := InstallFormula(dir, "../../x", v, content, "test", true, time.Now()); err == nil {
+		t.Error("InstallFormula with a path-like name succeeded, want error")
+	}
+	if _, err := PublishToDir(dir, "../x", "1.0.0", content, time.Now()); err == nil {
+		t.Error("PublishToDir with a path-like name succeeded, want error")
+	}
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "x") {
+			t.Errorf("file %s written outside the formulas dir", e.Name())
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package formula
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Simulation results.
+const (
+	SimCompleted = "completed" // Every step finished or was skipped
+	SimFailed    = "failed"    // A step failed
+	SimStalled   = "stalled"   // The script ran out of events with steps still running
+)
+
+// Scenario is a scripted run of a formula, checked by gt formula test
+// without agents or beads. Steps are dispatched in waves chosen by
+// ParallelReadySteps; each event finishes one running step.
+//
+//	formula = "mol-polecat-work"      # name, or a path to a .formula.toml
+//	overlay = "overlay.toml"          # optional, relative to the scenario
+//
+//	[vars]
+//	issue = "gt-abc12"
+//
+//	[[events]]
+//	step = "implement"
+//	outputs = { pr_url = "https://example.com/pull/7" }
+//
+//	[[events]]
+//	step = "review"
+//	result = "fail"
+//
+//	[expect]
+//	waves = [["implement"], ["review"]]
+//	status = "failed"
+//	[expect.descriptions]
+//	review = "https://example.com/pull/7"
+type Scenario struct {
+	Formula string            `toml:"formula"`
+	Overlay string            `toml:"overlay"`
+	Vars    map[string]string `toml:"vars"`
+	Events  []ScenarioEvent   `toml:"events"`
+	Expect  ScenarioExpect    `toml:"expect"`
+
+	// Path is the file the scenario was read from.
+	Path string `toml:"-"`
+}
+
+// ScenarioEvent finishes one running step.
+type ScenarioEvent struct {
+	Step    string            `toml:"step"`
+	Result  string            `toml:"result"` // "complete" (default) or "fail"
+	Outputs map[string]string `toml:"outputs"`
+}
+
+// ScenarioExpect holds a scenario's assertions. Unset fields are not
+// checked, except Status, which defaults to "completed".
+type ScenarioExpect struct {
+	Steps        []string          `toml:"steps"`        // Step IDs after resolve and overlays, in formula order
+	Order        []string          `toml:"order"`        // Steps in dispatch order; looping steps appear once per run
+	Waves        [][]string        `toml:"waves"`        // Groups dispatched together
+	Skipped      []string          `toml:"skipped"`      // Steps skipped by when conditions
+	Failed       []string          `toml:"failed"`       // Steps that failed
+	Status       string            `toml:"status"`       // completed, failed, or stalled
+	Titles       map[string]string `toml:"titles"`       // Step ID -> exact rendered title
+	Descriptions map[string]string `toml:"descriptions"` // Step ID -> text the rendered description contains
+
+	// AllowUnresolved permits {{...}} placeholders left in rendered steps.
+	AllowUnresolved bool `toml:"allow_unresolved"`
+}
+
+// ParseScenarioFile reads a scenario from a TOML file.
+func ParseScenarioFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: user-specified scenario file
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario: %w", err)
+	}
+	var s Scenario
+	if _, err := toml.Decode(string(data), &s); err != nil {
+		return nil, fmt.Errorf("parsing scenario %s: %w", path, err)
+	}
+	if s.Formula == "" {
+		return nil, fmt.Errorf("scenario %s: formula is required", path)
+	}
+	for i, ev := range s.Events {
+		if ev.Step == "" {
+			return nil, fmt.Errorf("scenario %s: events[%d]: step is required", path, i)
+		}
+		switch ev.Result {
+		case "", "complete", "fail":
+		default:
+			return nil, fmt.Errorf("scenario %s: events[%d]: invalid result %q (want complete or fail)", path, i, ev.Result)
+		}
+	}
+	s.Path = path
+	return &s, nil
+}
+
+// relPath resolves a path named in the scenario against its directory.
+func (s *Scenario) relPath(p string) string {
+	if filepath.IsAbs(p) || s.Path == "" {
+		return p
+	}
+	return filepath.Join(filepath.Dir(s.Path), p)
+}
+
+// FormulaFile returns the formula file the scenario names by path, or ""
+// if it names a formula to look up by name.
+func (s *Scenario) FormulaFile() string {
+	if strings.HasSuffix(s.Formula, ".toml") || strings.HasSuffix(s.Formula, ".json") {
+		return s.relPath(s.Formula)
+	}
+	return ""
+}
+
+// LoadOverlay returns the scenario's overlay file, or nil if it has none.
+func (s *Scenario) LoadOverlay() (*FormulaOverlay, error) {
+	if s.Overlay == "" {
+		return nil, nil
+	}
+	path := s.relPath(s.Overlay)
+	overlay, err := loadOverlayFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading overlay %s: %w", path, err)
+	}
+	if overlay == nil {
+		return nil, fmt.Errorf("overlay %s does not exist", path)
+	}
+	return overlay, nil
+}
+
+// RenderedStep is a step's title and description as dispatched, with vars
+// and earlier outputs substituted.
+type RenderedStep struct {
+	Title       string
+	Description string
+	Unresolved  []string // Placeholders left unsubstituted
+}
+
+// Simulation is the result of running a formula against scripted events.
+type Simulation struct {
+	Steps    []string
+	Waves    [][]string
+	Order    []string
+	Skipped  []string
+	Failed   []string
+	Status   string
+	Pending  []string                // Steps still running when a run stalls
+	Rendered map[string]RenderedStep // Last dispatch of each step
+}
+
+// Simulate runs a resolved formula against scripted events using the same
+// scheduling as real runs: ReadyStepsEnv skips steps whose when condition is
+// false, ParallelReadySteps picks each wave, and repeat_until steps are
+// dispatched again until their condition holds. vars are checked against the
+// formula's typed inputs, with defaults filled in.
+//
+// Errors are problems with the script itself: an event for a step that is
+// not running, an undeclared output, or events left after the run ends.
+func Simulate(f *Formula, vars map[string]string, events []ScenarioEvent) (*Simulation, error) {
+	values := make(map[string]string, len(vars))
+	for _, p := range f.Params() {
+		if p.Default != "" {
+			values[p.Name] = p.Default
+		}
+	}
+	for k, v := range vars {
+		values[k] = v
+	}
+	if err := (InputChecker{}).Check(f, vars); err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{Steps: f.stepIDs(), Rendered: map[string]RenderedStep{}}
+	env := Env{Vars: values, Outputs: map[string]map[string]string{}}
+	done := map[string]bool{}
+	iterations := map[string]int{}
+	next := 0
+
+	for {
+		_, skipped, err := f.ReadyStepsEnv(done, env)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range skipped {
+			done[id] = true
+			sim.Skipped = append(sim.Skipped, id)
+		}
+
+		wave, sequential := f.ParallelReadySteps(done)
+		if sequential != "" {
+			wave = []string{sequential}
+		}
+		if len(wave) == 0 {
+			break
+		}
+		sim.Waves = append(sim.Waves, wave)
+		sim.Order = append(sim.Order, wave...)
+		running := make(map[string]bool, len(wave))
+		for _, id := range wave {
+			running[id] = true
+			sim.Rendered[id] = f.renderStep(id, env)
+		}
+
+		for len(running) > 0 {
+			if next >= len(events) {
+				sim.Status = SimStalled
+				sim.Pending = sortedSet(running)
+				return sim, nil
+			}
+			ev := events[next]
+			next++
+			if !running[ev.Step] {
+				return nil, fmt.Errorf("event %d: step %q is not running (running: %s)",
+					next, ev.Step, strings.Join(sortedSet(running), ", "))
+			}
+			delete(running, ev.Step)
+
+			if ev.Result == "fail" {
+				sim.Failed = append(sim.Failed, ev.Step)
+				sim.Status = SimFailed
+				if next < len(events) {
+					return nil, fmt.Errorf("event %d: %d event(s) after %s failed the run", next+1, len(events)-next, ev.Step)
+				}
+				return sim, nil
+			}
+			if err := f.recordOutputs(ev, env); err != nil {
+				return nil, fmt.Errorf("event %d: %w", next, err)
+			}
+			iterations[ev.Step]++
+			if step := f.GetStep(ev.Step); step != nil {
+				again, err := step.RepeatAgain(iterations[ev.Step], env)
+				if err != nil {
+					return nil, err
+				}
+				if again {
+					continue
+				}
+			}
+			done[ev.Step] = true
+		}
+	}
+
+	if next < len(events) {
+		return nil, fmt.Errorf("event %d: step %q never runs again; the run finished with %d event(s) left",
+			next+1, events[next].Step, len(events)-next)
+	}
+	sim.Status = SimCompleted
+	return sim, nil
+}
+
+// stepIDs returns the IDs of the formula's schedulable units in order.
+func (f *Formula) stepIDs() []string {
+	var ids []string
+	switch f.Type {
+	case TypeWorkflow:
+		for _, s := range f.Steps {
+			ids = append(ids, s.ID)
+		}
+	case TypeExpansion:
+		for _, t := range f.Template {
+			ids = append(ids, t.ID)
+		}
+	case TypeConvoy:
+		for _, l := range f.Legs {
+			ids = append(ids, l.ID)
+		}
+	case TypeAspect:
+		for _, a := range f.Aspects {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
+
+// recordOutputs validates an event's outputs against the step's declared
+// outputs and adds them to env.
+func (f *Formula) recordOutputs(ev ScenarioEvent, env Env) error {
+	if len(ev.Outputs) == 0 {
+		return nil
+	}
+	step := f.GetStep(ev.Step)
+	for name, value := range ev.Outputs {
+		var decl StepOutput
+		ok := false
+		if step != nil {
+			decl, ok = step.Outputs[name]
+		}
+		if !ok {
+			return fmt.Errorf("step %q does not declare output %q", ev.Step, name)
+		}
+		normalized, err := decl.Kind().Normalize(value)
+		if err != nil {
+			return fmt.Errorf("step %q output %q: %w", ev.Step, name, err)
+		}
+		if env.Outputs[ev.Step] == nil {
+			env.Outputs[ev.Step] = map[string]string{}
+		}
+		env.Outputs[ev.Step][name] = normalized
+	}
+	return nil
+}
+
+// renderStep substitutes vars and recorded outputs into a step's title and
+// description, as gt does when it dispatches the step.
+func (f *Formula) renderStep(id string, env Env) RenderedStep {
+	var title, desc string
+	switch {
+	case f.GetStep(id) != nil:
+		title, desc = f.GetStep(id).Title, f.GetStep(id).Description
+	case f.GetTemplate(id) != nil:
+		title, desc = f.GetTemplate(id).Title, f.GetTemplate(id).Description
+	case f.GetLeg(id) != nil:
+		title, desc = f.GetLeg(id).Title, f.GetLeg(id).Description
+	case f.GetAspect(id) != nil:
+		title, desc = f.GetAspect(id).Title, f.GetAspect(id).Description
+	}
+	render := func(text string) string {
+		text = SubstituteOutputs(text, env)
+		return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+			if v, ok := env.Vars[variablePattern.FindStringSubmatch(match)[1]]; ok {
+				return v
+			}
+			return match
+		})
+	}
+	r := RenderedStep{Title: render(title), Description: render(desc)}
+	text := r.Title + "\n" + r.Description
+	r.Unresolved = ExtractTemplateVariables(text)
+	for _, ref := range ExtractOutputRefs(text) {
+		r.Unresolved = append(r.Unresolved, ref.String())
+	}
+	return r
+}
+
+// Check compares a simulation against the scenario's expectations and
+// returns one message per mismatch.
+func (s *Scenario) Check(sim *Simulation) []string {
+	var failures []string
+	mismatch := func(what string, got, want any) {
+		failures = append(failures, fmt.Sprintf("%s: got %v, want %v", what, got, want))
+	}
+	e := s.Expect
+
+	status := e.Status
+	if status == "" {
+		status = SimCompleted
+	}
+	if sim.Status != status {
+		msg := fmt.Sprintf("status: got %s, want %s", sim.Status, status)
+		if len(sim.Pending) > 0 {
+			msg += " (still running: " + strings.Join(sim.Pending, ", ") + ")"
+		}
+		failures = append(failures, msg)
+	}
+	if e.Steps != nil && !reflect.DeepEqual(sim.Steps, e.Steps) {
+		mismatch("steps", sim.Steps, e.Steps)
+	}
+	if e.Order != nil && !reflect.DeepEqual(sim.Order, e.Order) {
+		mismatch("order", sim.Order, e.Order)
+	}
+	if e.Waves != nil && !reflect.DeepEqual(sim.Waves, e.Waves) {
+		mismatch("waves", sim.Waves, e.Waves)
+	}
+	if e.Skipped != nil && !sameSet(sim.Skipped, e.Skipped) {
+		mismatch("skipped", sim.Skipped, e.Skipped)
+	}
+	if e.Failed != nil && !sameSet(sim.Failed, e.Failed) {
+		mismatch("failed", sim.Failed, e.Failed)
+	}
+
+	for _, id := range sortedKeys(e.Titles) {
+		r, ok := sim.Rendered[id]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("title of %s: step was never dispatched", id))
+		case r.Title != e.Titles[id]:
+			mismatch("title of "+id, fmt.Sprintf("%q", r.Title), fmt.Sprintf("%q", e.Titles[id]))
+		}
+	}
+	for _, id := range sortedKeys(e.Descriptions) {
+		r, ok := sim.Rendered[id]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("description of %s: step was never dispatched", id))
+		case !strings.Contains(r.Description, e.Descriptions[id]):
+			failures = append(failures, fmt.Sprintf("description of %s does not contain %q", id, e.Descriptions[id]))
+		}
+	}
+	if !e.AllowUnresolved {
+		for _, id := range sortedKeys(sim.Rendered) {
+			if u := sim.Rendered[id].Unresolved; len(u) > 0 {
+				failures = append(failures, fmt.Sprintf("%s: unresolved placeholders: %s", id, strings.Join(u, ", ")))
+			}
+		}
+	}
+	return failures
+}
+
+func sortedSet(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,245 @@
+package formula
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const simExpansion = `formula = "sim-review"
+type = "expansion"
+
+[[template]]
+id = "{target}.draft"
+title = "Draft {target.title}"
+
+[[template]]
+id = "{target}.refine"
+title = "Refine"
+needs = ["{target}.draft"]
+`
+
+const simWorkflow = `formula = "sim-ship"
+type = "workflow"
+
+[vars.issue]
+required = true
+
+[vars.mode]
+default = "full"
+
+[[steps]]
+id = "plan"
+title = "Plan {{issue}}"
+
+[[steps]]
+id = "write"
+title = "Write"
+needs = ["plan"]
+
+[[steps]]
+id = "lint"
+title = "Lint"
+needs = ["write"]
+parallel = true
+
+[[steps]]
+id = "test"
+title = "Test"
+needs = ["write"]
+parallel = true
+repeat_until = 'steps.test.outputs.result == "pass"'
+[steps.outputs]
+result = "string"
+
+[[steps]]
+id = "docs"
+title = "Docs"
+needs = ["lint", "test"]
+when = 'mode == "full"'
+
+[[steps]]
+id = "merge"
+title = "Merge"
+description = "Tests: {{steps.test.outputs.result}}"
+needs = ["docs"]
+
+[compose]
+[[compose.expand]]
+target = "write"
+with = "sim-review"
+`
+
+func writeSimFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func loadSimWorkflow(t *testing.T, dir string) *Formula {
+	t.Helper()
+	f, err := ParseFile(filepath.Join(dir, "sim-ship.formula.toml"))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	resolved, err := Resolve(f, []string{dir})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	return resolved
+}
+
+func TestSimulate_Scenario(t *testing.T) {
+	dir := writeSimFiles(t, map[string]string{
+		"sim-ship.formula.toml":   simWorkflow,
+		"sim-review.formula.toml": simExpansion,
+		"ship.scenario.toml": `formula = "sim-ship.formula.toml"
+
+[vars]
+issue = "gt-abc12"
+
+[[events]]
+step = "plan"
+[[events]]
+step = "write.draft"
+[[events]]
+step = "write.refine"
+[[events]]
+step = "test"
+outputs = { result = "fail" }
+[[events]]
+step = "lint"
+[[events]]
+step = "test"
+outputs = { result = "pass" }
+[[events]]
+step = "docs"
+[[events]]
+step = "merge"
+
+[expect]
+steps = ["plan", "write.draft", "write.refine", "lint", "test", "docs", "merge"]
+order = ["plan", "write.draft", "write.refine", "lint", "test", "test", "docs", "merge"]
+waves = [["plan"], ["write.draft"], ["write.refine"], ["lint", "test"], ["test"], ["docs"], ["merge"]]
+skipped = []
+[expect.titles]
+plan = "Plan gt-abc12"
+"write.draft" = "Draft Write"
+[expect.descriptions]
+merge = "Tests: pass"
+`,
+	})
+
+	s, err := ParseScenarioFile(filepath.Join(dir, "ship.scenario.toml"))
+	if err != nil {
+		t.Fatalf("ParseScenarioFile: %v", err)
+	}
+	if got := s.FormulaFile(); got != filepath.Join(dir, "sim-ship.formula.toml") {
+		t.Errorf("FormulaFile = %q", got)
+	}
+	f := loadSimWorkflow(t, dir)
+	sim, err := Simulate(f, s.Vars, s.Events)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if failures := s.Check(sim); len(failures) > 0 {
+		t.Errorf("Check failures:\n%s", strings.Join(failures, "\n"))
+	}
+
+	// The same run with a mistaken expectation reports each mismatch.
+	s.Expect.Waves = [][]string{{"plan"}}
+	s.Expect.Descriptions["merge"] = "Tests: fail"
+	if failures := s.Check(sim); len(failures) != 2 {
+		t.Errorf("Check with wrong expectations = %v, want 2 failures", failures)
+	}
+}
+
+func TestSimulate_OverlayAndConditions(t *testing.T) {
+	dir := writeSimFiles(t, map[string]string{
+		"sim-ship.formula.toml":   simWorkflow,
+		"sim-review.formula.toml": simExpansion,
+	})
+	f := loadSimWorkflow(t, dir)
+	ApplyOverlays(f, &FormulaOverlay{StepOverrides: []StepOverride{{StepID: "lint", Mode: ModeSkip}}})
+
+	sim, err := Simulate(f, map[string]string{"issue": "gt-1", "mode": "quick"}, []ScenarioEvent{
+		{Step: "plan"}, {Step: "write.draft"}, {Step: "write.refine"},
+		{Step: "test", Outputs: map[string]string{"result": "pass"}},
+		{Step: "merge"},
+	})
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if want := []string{"docs"}; !reflect.DeepEqual(sim.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", sim.Skipped, want)
+	}
+	if want := [][]string{{"plan"}, {"write.draft"}, {"write.refine"}, {"test"}, {"merge"}}; !reflect.DeepEqual(sim.Waves, want) {
+		t.Errorf("Waves = %v, want %v", sim.Waves, want)
+	}
+	if sim.Status != SimCompleted {
+		t.Errorf("Status = %s", sim.Status)
+	}
+}
+
+func TestSimulate_FailStallAndScriptErrors(t *testing.T) {
+	dir := writeSimFiles(t, map[string]string{
+		"sim-ship.formula.toml":   simWorkflow,
+		"sim-review.formula.toml": simExpansion,
+	})
+	f := loadSimWorkflow(t, dir)
+	vars := map[string]string{"issue": "gt-1"}
+
+	sim, err := Simulate(f, vars, []ScenarioEvent{{Step: "plan"}, {Step: "write.draft", Result: "fail"}})
+	if err != nil || sim.Status != SimFailed || !reflect.DeepEqual(sim.Failed, []string{"write.draft"}) {
+		t.Errorf("failed run = %+v, %v", sim, err)
+	}
+
+	sim, err = Simulate(f, vars, []ScenarioEvent{{Step: "plan"}})
+	if err != nil || sim.Status != SimStalled || !reflect.DeepEqual(sim.Pending, []string{"write.draft"}) {
+		t.Errorf("stalled run = %+v, %v", sim, err)
+	}
+	if failures := (&Scenario{}).Check(sim); len(failures) != 1 || !strings.Contains(failures[0], "still running: write.draft") {
+		t.Errorf("stalled Check = %v", failures)
+	}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		events  []ScenarioEvent
+		wantErr string
+	}{
+		{"missing var", nil, nil, "issue: required"},
+		{"not running", vars, []ScenarioEvent{{Step: "merge"}}, `step "merge" is not running (running: plan)`},
+		{"undeclared output", vars, []ScenarioEvent{{Step: "plan", Outputs: map[string]string{"x": "1"}}}, `does not declare output "x"`},
+		{"after failure", vars, []ScenarioEvent{{Step: "plan", Result: "fail"}, {Step: "write.draft"}}, "after plan failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Simulate(f, tt.vars, tt.events); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Simulate error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScenarioFile_Invalid(t *testing.T) {
+	dir := writeSimFiles(t, map[string]string{
+		"no-formula.toml": "[[events]]\nstep = \"a\"\n",
+		"bad-result.toml": "formula = \"x\"\n[[events]]\nstep = \"a\"\nresult = \"skip\"\n",
+	})
+	for name, want := range map[string]string{
+		"no-formula.toml": "formula is required",
+		"bad-result.toml": `invalid result "skip"`,
+	} {
+		if _, err := ParseScenarioFile(filepath.Join(dir, name)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want containing %q", name, err, want)
+		}
+	}
+}
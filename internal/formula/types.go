@@ -55,6 +55,11 @@ type Formula struct {
 
 	// Aspect-specific (similar to convoy but for analysis)
 	Aspects []Aspect `toml:"aspects"`
+
+	// Aspect weaving: advice woven into workflows that list this formula
+	// in compose.aspects. Pointcuts restrict which steps the advice may touch.
+	Advice    []*AdviceRule `toml:"advice"`
+	Pointcuts []Pointcut    `toml:"pointcuts"`
 }
 
 // ComposeRules defines how a formula can be composed with others.
@@ -62,8 +67,8 @@ type ComposeRules struct {
 	// Expand replaces a single target step with an expansion formula's template steps.
 	Expand []*ExpandRule `toml:"expand"`
 
-	// Aspects lists aspect formula names to apply to this formula.
-	// (Reserved for future implementation.)
+	// Aspects lists aspect formula names whose advice is woven into the
+	// resolved steps, in order, after expand rules have been applied.
 	Aspects []string `toml:"aspects"`
 }
 
@@ -85,12 +90,15 @@ type Aspect struct {
 }
 
 // Input represents an input parameter for a formula.
+// Type is one of the Param* types (default string); see Param.
 type Input struct {
 	Description    string   `toml:"description"`
 	Type           string   `toml:"type"`
 	Required       bool     `toml:"required"`
 	RequiredUnless []string `toml:"required_unless"`
 	Default        string   `toml:"default"`
+	Values         []string `toml:"values"`  // Allowed values for type "enum"
+	Pattern        string   `toml:"pattern"` // Regular expression the whole value must match
 }
 
 // Output configures where formula outputs are written.
@@ -127,6 +135,39 @@ type Step struct {
 	Parallel    bool     `toml:"parallel"`    // If true, this step can run concurrently with other parallel steps that share the same needs
 	Interactive bool     `toml:"interactive"` // If true, this step requires user dialog and runs in the current session instead of being dispatched to a polecat
 	Acceptance  string   `toml:"acceptance"`  // Exit criteria for this step (used by Ralph loop mode)
+	Tags        []string `toml:"tags"`        // Labels that aspect advice and pointcuts can match (e.g. "touches-auth")
+
+	// When is a condition over formula vars and earlier step outputs
+	// (see Condition). The step is skipped when it evaluates false; skipped
+	// steps count as done for their dependents.
+	When string `toml:"when"`
+
+	// RepeatUntil is a condition checked after each run of the step. While it
+	// is false the step runs again, up to MaxIterations runs in total.
+	RepeatUntil   string `toml:"repeat_until"`
+	MaxIterations int    `toml:"max_iterations"` // Loop bound for RepeatUntil (default DefaultMaxIterations)
+
+	// Outputs declares the named values this step records with
+	// gt mol step output set, keyed by output name.
+	Outputs map[string]StepOutput `toml:"outputs"`
+
+	// Agent pins the step to an agent preset (like Leg.Agent). Model asks
+	// for an agent running that model (e.g. "opus", "haiku"), and
+	// MinCapability for one at least that capable (basic, standard,
+	// advanced). Unset means the polecat role default.
+	Agent         string `toml:"agent"`
+	Model         string `toml:"model"`
+	MinCapability string `toml:"min_capability"`
+
+	// Timeout bounds how long a dispatched step may run (Go duration, e.g.
+	// "30m"). A step that times out or ends with gt done --status ESCALATED
+	// is retried up to Retries times, waiting RetryBackoff (default 1m,
+	// doubling per attempt), and then handled by OnFailure: fail-molecule
+	// (default), skip, escalate, or goto:<step>.
+	Timeout      string `toml:"timeout"`
+	Retries      int    `toml:"retries"`
+	RetryBackoff string `toml:"retry_backoff"`
+	OnFailure    string `toml:"on_failure"`
 }
 
 // Template represents a template step in an expansion formula.
@@ -136,15 +177,24 @@ type Template struct {
 	Description string   `toml:"description"`
 	Needs       []string `toml:"needs"`
 	Acceptance  string   `toml:"acceptance"` // Exit criteria for this expanded step (propagated to generated Step)
+
+	// Agent constraints for the generated step (see Step); unset fields
+	// inherit the expansion target's.
+	Agent         string `toml:"agent"`
+	Model         string `toml:"model"`
+	MinCapability string `toml:"min_capability"`
 }
 
 // Var represents a variable definition for formulas.
 // Supports both shorthand string syntax (wisp_type = "gc_report")
 // and full table syntax ([vars.wisp_type] with description/required/default).
 type Var struct {
-	Description string `toml:"description"`
-	Required    bool   `toml:"required"`
-	Default     string `toml:"default"`
+	Description string   `toml:"description"`
+	Required    bool     `toml:"required"`
+	Default     string   `toml:"default"`
+	Type        string   `toml:"type"`    // One of the Param* types (default string)
+	Values      []string `toml:"values"`  // Allowed values for type "enum"
+	Pattern     string   `toml:"pattern"` // Regular expression the whole value must match
 }
 
 // UnmarshalTOML allows Var to be decoded from either a plain string
@@ -170,6 +220,25 @@ func (v *Var) UnmarshalTOML(data any) error {
 				v.Default = s
 			}
 		}
+		if t, ok := val["type"]; ok {
+			if s, ok := t.(string); ok {
+				v.Type = s
+			}
+		}
+		if vs, ok := val["values"]; ok {
+			if list, ok := vs.([]any); ok {
+				for _, item := range list {
+					if s, ok := item.(string); ok {
+						v.Values = append(v.Values, s)
+					}
+				}
+			}
+		}
+		if p, ok := val["pattern"]; ok {
+			if s, ok := p.(string); ok {
+				v.Pattern = s
+			}
+		}
 		return nil
 	default:
 		return fmt.Errorf("expected string or table for Var, got %T", data)
@@ -60,6 +60,10 @@ func isHandlebarsKeyword(name string) bool {
 // with "missing required variables" error.
 //
 // Variables with any definition in [vars] (even with default="") are considered valid.
+//
+// Step output references ({{steps.<id>.outputs.<name>}} in step text, and
+// steps.<id>.outputs.<name> in when/repeat_until) must name an output the
+// producing step declares, and that step must run before the reference.
 func (f *Formula) ValidateTemplateVariables() error {
 	// Collect all text that might contain variables
 	var allText strings.Builder
@@ -156,6 +160,6 @@ func (f *Formula) ValidateTemplateVariables() error {
 			strings.Join(undefined, ", "))
 	}
 
-	return nil
+	return f.validateOutputRefs()
 }
 
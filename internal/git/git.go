@@ -94,6 +94,20 @@ func (g *Git) IsRepo() bool {
 	return err == nil
 }
 
+// CommonDir returns the absolute path of the repository's common git
+// directory. For a linked worktree this is the main repository's git dir
+// (e.g. a rig's .repo.git), which holds the shared objects and refs.
+func (g *Git) CommonDir() (string, error) {
+	dir, err := g.run("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.workDir, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
 // run executes a git command and returns stdout.
 func (g *Git) run(args ...string) (string, error) {
 	if err := g.guardUnsafeTownRootMutation(args); err != nil {
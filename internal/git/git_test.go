@@ -306,6 +306,28 @@ func TestIsRepo(t *testing.T) {
 	}
 }
 
+func TestCommonDir(t *testing.T) {
+	repo := initTestRepo(t)
+	wt := filepath.Join(t.TempDir(), "wt")
+	if err := NewGit(repo).WorktreeAdd(wt, "feature"); err != nil {
+		t.Fatalf("worktree add: %v", err)
+	}
+
+	want, err := filepath.EvalSymlinks(filepath.Join(repo, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{repo, wt} {
+		got, err := NewGit(dir).CommonDir()
+		if err != nil {
+			t.Fatalf("CommonDir(%s): %v", dir, err)
+		}
+		if got, _ = filepath.EvalSymlinks(got); got != want {
+			t.Errorf("CommonDir(%s) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
 func TestCloneWithReferenceCreatesAlternates(t *testing.T) {
 	tmp := t.TempDir()
 	src := filepath.Join(tmp, "src")
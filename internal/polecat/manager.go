@@ -2296,6 +2296,23 @@ func (m *Manager) FindIdlePolecat() (*Polecat, error) {
 	return nil, nil
 }
 
+// FindIdlePolecats returns every reusable idle polecat in the rig, in List order.
+// Used by capability routing to choose among idle polecats instead of taking
+// the first one.
+func (m *Manager) FindIdlePolecats() ([]*Polecat, error) {
+	polecats, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var idle []*Polecat
+	for _, p := range polecats {
+		if p.State == StateIdle && m.reuseDecisionForPolecat(p.Name, p.State).Reusable {
+			idle = append(idle, p)
+		}
+	}
+	return idle, nil
+}
+
 // ReuseDecisionForPolecat exposes the same reuse verdict used by FindIdlePolecat
 // so admission planning cannot drift from the destructive reuse gate.
 func (m *Manager) ReuseDecisionForPolecat(name string, state State) SlotReuseDecision {
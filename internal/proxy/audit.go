@@ -0,0 +1,518 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditJournalPath returns the location of the proxy audit journal for a town.
+func AuditJournalPath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "proxy", "audit.jsonl")
+}
+
+// AuditCheckpointPath returns the location of the checkpoint copies that
+// accompany the journal at journalPath.
+func AuditCheckpointPath(journalPath string) string {
+	return filepath.Join(filepath.Dir(journalPath), "audit-checkpoints.jsonl")
+}
+
+// Audit entry kinds.
+const (
+	AuditKindExec        = "exec"
+	AuditKindReceivePack = "receive-pack"
+	AuditKindCheckpoint  = "checkpoint"
+)
+
+// auditCheckpointEvery is how many entries the journal writes between
+// checkpoints; the server also checkpoints on an interval and on shutdown.
+const auditCheckpointEvery = 100
+
+// auditCheckpointInterval is how often a running server checkpoints entries
+// written since the last checkpoint.
+const auditCheckpointInterval = 10 * time.Minute
+
+// AuditEntry is one line of the audit journal.
+//
+// Entries form a hash chain: Hash is the SHA-256 of the entry's JSON encoding
+// with Hash empty, and Prev is the previous entry's Hash, so editing,
+// removing or reordering any entry breaks every hash after it. Seq numbers
+// entries from 1 without gaps.
+type AuditEntry struct {
+	Seq        uint64           `json:"seq"`
+	Time       time.Time        `json:"time"`
+	Kind       string           `json:"kind"`
+	Identity   string           `json:"identity,omitempty"`
+	Exec       *AuditExec       `json:"exec,omitempty"`
+	Push       *AuditPush       `json:"push,omitempty"`
+	Checkpoint *AuditCheckpoint `json:"checkpoint,omitempty"`
+	Prev       string           `json:"prev"`
+	Hash       string           `json:"hash,omitempty"`
+}
+
+// AuditExec records one exec request that ran a subprocess.
+type AuditExec struct {
+	// Argv is the command as the client requested it. Unlike the slog audit
+	// line it is complete, which is why the journal is readable only by the
+	// proxy's user.
+	Argv         []string `json:"argv"`
+	Stream       bool     `json:"stream,omitempty"`
+	ExitCode     int      `json:"exit_code"`
+	DurationMS   int64    `json:"duration_ms"`
+	StdoutSHA256 string   `json:"stdout_sha256"`
+	StdoutBytes  int64    `json:"stdout_bytes"`
+	StderrSHA256 string   `json:"stderr_sha256"`
+	StderrBytes  int64    `json:"stderr_bytes"`
+}
+
+// AuditPush records one git-receive-pack request.
+type AuditPush struct {
+	Rig     string      `json:"rig"`
+	Updates []RefUpdate `json:"updates"`
+	// Denied is set when the proxy rejected the push before running git.
+	Denied     bool  `json:"denied,omitempty"`
+	ExitCode   int   `json:"exit_code"`
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// RefUpdate is one ref update command of a push.
+type RefUpdate struct {
+	Ref string `json:"ref"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// outputDigest is an io.Writer that hashes and counts what is written to it.
+// The zero value is ready to use.
+type outputDigest struct {
+	h hash.Hash
+	n int64
+}
+
+func (d *outputDigest) Write(p []byte) (int, error) {
+	if d.h == nil {
+		d.h = sha256.New()
+	}
+	d.n += int64(len(p))
+	return d.h.Write(p)
+}
+
+// Sum returns the hex SHA-256 of everything written.
+func (d *outputDigest) Sum() string {
+	if d.h == nil {
+		d.h = sha256.New()
+	}
+	return hex.EncodeToString(d.h.Sum(nil))
+}
+
+// AuditCheckpoint commits to the journal up to and including entry Seq,
+// whose hash is Hash. It is signed with the proxy CA key when the server has
+// one, so that a rewritten chain can't carry valid checkpoints. Each
+// checkpoint is written both into the chain and to the separate checkpoint
+// file, so truncating the journal is detectable as well.
+type AuditCheckpoint struct {
+	Seq       uint64    `json:"seq"`
+	Hash      string    `json:"hash"`
+	Time      time.Time `json:"time"`
+	Signature []byte    `json:"signature,omitempty"` // ECDSA ASN.1 over checkpointDigest
+}
+
+// checkpointDigest is the message a checkpoint signature covers.
+func checkpointDigest(seq uint64, hash string) []byte {
+	sum := sha256.Sum256([]byte("gt-proxy-audit-checkpoint\x00" + strconv.FormatUint(seq, 10) + "\x00" + hash))
+	return sum[:]
+}
+
+// verifySignature reports whether cp is signed by the key of caCert.
+func (cp *AuditCheckpoint) verifySignature(caCert *x509.Certificate) bool {
+	pub, ok := caCert.PublicKey.(*ecdsa.PublicKey)
+	return ok && ecdsa.VerifyASN1(pub, checkpointDigest(cp.Seq, cp.Hash), cp.Signature)
+}
+
+// entryHash computes the chain hash of e (ignoring e.Hash).
+func entryHash(e AuditEntry) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditJournal appends entries to the hash-chained audit journal. It is safe
+// for concurrent use; only one process should append to a journal.
+type AuditJournal struct {
+	path    string
+	cpPath  string
+	signKey *ecdsa.PrivateKey
+
+	mu           sync.Mutex
+	seq          uint64
+	last         string // hash of the last entry
+	sinceCheckpt int
+}
+
+// OpenAuditJournal opens the journal at path, creating its directory if
+// needed, and resumes the chain from its last entry. signKey (may be nil)
+// signs checkpoints. It fails if the last entry is unreadable: appending
+// would chain onto an unknown hash.
+func OpenAuditJournal(path string, signKey *ecdsa.PrivateKey) (*AuditJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create audit journal dir: %w", err)
+	}
+	j := &AuditJournal{path: path, cpPath: AuditCheckpointPath(path), signKey: signKey}
+	line, err := lastLine(path)
+	if err != nil {
+		return nil, fmt.Errorf("read audit journal: %w", err)
+	}
+	if line != nil {
+		var e AuditEntry
+		if err := json.Unmarshal(line, &e); err != nil || e.Hash == "" {
+			return nil, fmt.Errorf("audit journal %s: last entry is unreadable (run gt proxy audit verify)", path)
+		}
+		j.seq, j.last = e.Seq, e.Hash
+		if e.Kind != AuditKindCheckpoint {
+			// Unknown how many entries follow the last checkpoint; make sure
+			// the next checkpoint isn't far away.
+			j.sinceCheckpt = auditCheckpointEvery - 1
+		}
+	}
+	return j, nil
+}
+
+// Path returns the journal's file path.
+func (j *AuditJournal) Path() string { return j.path }
+
+// AppendExec records an exec.
+func (j *AuditJournal) AppendExec(identity string, rec AuditExec) error {
+	return j.append(AuditEntry{Kind: AuditKindExec, Identity: identity, Exec: &rec})
+}
+
+// AppendPush records a receive-pack.
+func (j *AuditJournal) AppendPush(identity string, rec AuditPush) error {
+	return j.append(AuditEntry{Kind: AuditKindReceivePack, Identity: identity, Push: &rec})
+}
+
+// Checkpoint writes a checkpoint if any entries were appended since the last
+// one.
+func (j *AuditJournal) Checkpoint() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.sinceCheckpt == 0 {
+		return nil
+	}
+	return j.checkpointLocked(time.Now())
+}
+
+func (j *AuditJournal) append(e AuditEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	if err := j.appendLocked(e, now); err != nil {
+		return err
+	}
+	j.sinceCheckpt++
+	if j.sinceCheckpt >= auditCheckpointEvery {
+		return j.checkpointLocked(now)
+	}
+	return nil
+}
+
+func (j *AuditJournal) appendLocked(e AuditEntry, now time.Time) error {
+	e.Seq = j.seq + 1
+	e.Time = now.UTC()
+	e.Prev = j.last
+	hash, err := entryHash(e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	if err := appendJSONLine(j.path, e, e.Kind == AuditKindCheckpoint); err != nil {
+		return fmt.Errorf("append audit entry: %w", err)
+	}
+	j.seq, j.last = e.Seq, e.Hash
+	return nil
+}
+
+func (j *AuditJournal) checkpointLocked(now time.Time) error {
+	cp := AuditCheckpoint{Seq: j.seq, Hash: j.last, Time: now.UTC()}
+	if j.signKey != nil {
+		sig, err := ecdsa.SignASN1(rand.Reader, j.signKey, checkpointDigest(cp.Seq, cp.Hash))
+		if err != nil {
+			return fmt.Errorf("sign audit checkpoint: %w", err)
+		}
+		cp.Signature = sig
+	}
+	if err := j.appendLocked(AuditEntry{Kind: AuditKindCheckpoint, Checkpoint: &cp}, now); err != nil {
+		return err
+	}
+	if err := appendJSONLine(j.cpPath, cp, true); err != nil {
+		return fmt.Errorf("append audit checkpoint: %w", err)
+	}
+	j.sinceCheckpt = 0
+	return nil
+}
+
+// appendJSONLine appends v as one JSON line with a single write, so
+// concurrent readers never see a partial entry from a well-behaved writer.
+func appendJSONLine(path string, v any, sync bool) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gosec // path is under the town's .runtime
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if sync {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// lastLine returns the last non-empty line of the file at path, or nil if
+// the file is missing or empty.
+func lastLine(path string) ([]byte, error) {
+	f, err := os.Open(path) //nolint:gosec // path is under the town's .runtime
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const block = 64 << 10
+	var tail []byte
+	for end := fi.Size(); end > 0; {
+		start := max(end-block, 0)
+		buf := make([]byte, end-start)
+		if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if start == 0 && len(trimmed) > 0 {
+			return trimmed, nil
+		}
+		end = start
+	}
+	return nil, nil
+}
+
+// AuditProblem is one inconsistency found by VerifyAuditJournal.
+type AuditProblem struct {
+	Line    int    `json:"line,omitempty"` // 1-based journal line; 0 for checkpoint-file problems
+	Seq     uint64 `json:"seq,omitempty"`
+	Message string `json:"message"`
+}
+
+// AuditReport is the result of VerifyAuditJournal.
+type AuditReport struct {
+	Journal string `json:"journal"`
+	Entries int    `json:"entries"`
+	LastSeq uint64 `json:"last_seq"`
+	// Checkpoints counts checkpoints in the chain; Signed those whose
+	// signature verified against the CA.
+	Checkpoints int `json:"checkpoints"`
+	Signed      int `json:"signed"`
+	// ExternalCheckpoints counts entries of the separate checkpoint file.
+	ExternalCheckpoints int `json:"external_checkpoints"`
+	// LastCheckpointSeq is the highest seq covered by a valid checkpoint.
+	LastCheckpointSeq uint64 `json:"last_checkpoint_seq"`
+	// Unsealed counts the entries after the last valid checkpoint; they are
+	// protected only by the hash chain.
+	Unsealed int            `json:"unsealed"`
+	Problems []AuditProblem `json:"problems,omitempty"`
+}
+
+// OK reports whether verification found no problems.
+func (r *AuditReport) OK() bool { return len(r.Problems) == 0 }
+
+func (r *AuditReport) problem(line int, seq uint64, format string, args ...any) {
+	r.Problems = append(r.Problems, AuditProblem{Line: line, Seq: seq, Message: fmt.Sprintf(format, args...)})
+}
+
+// VerifyAuditJournal checks the journal at path: every entry's hash, the
+// chain links and sequence numbers (gaps, reordering, removal), each
+// checkpoint against the entry it covers, and the separate checkpoint file
+// against the journal (truncation). If caCert is non-nil, checkpoint
+// signatures must verify against it. It returns an error only if the files
+// can't be read.
+func VerifyAuditJournal(path string, caCert *x509.Certificate) (*AuditReport, error) {
+	rep := &AuditReport{Journal: path}
+
+	// External checkpoints: seq → hash that entry must have.
+	external := make(map[uint64]string)
+	if err := readJSONLines(AuditCheckpointPath(path), func(n int, line []byte) {
+		rep.ExternalCheckpoints++
+		var cp AuditCheckpoint
+		if err := json.Unmarshal(line, &cp); err != nil {
+			rep.problem(0, 0, "checkpoint file line %d: unreadable: %v", n, err)
+			return
+		}
+		if caCert != nil && len(cp.Signature) == 0 {
+			rep.problem(0, cp.Seq, "checkpoint file line %d: checkpoint is unsigned", n)
+			return
+		}
+		if caCert != nil && !cp.verifySignature(caCert) {
+			rep.problem(0, cp.Seq, "checkpoint file line %d: signature does not verify", n)
+			return
+		}
+		external[cp.Seq] = cp.Hash
+	}); err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(AuditCheckpointPath(path))
+	checkpointFileMissing := errors.Is(statErr, os.ErrNotExist)
+
+	var (
+		prevHash  string
+		prevSeq   uint64
+		resync    bool // previous line was unreadable; accept the next link
+		seenByseq = make(map[uint64]string)
+	)
+	err := readJSONLines(path, func(n int, line []byte) {
+		var e AuditEntry
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&e); err != nil {
+			rep.problem(n, 0, "unreadable entry: %v", err)
+			resync = true
+			return
+		}
+		rep.Entries++
+		rep.Unsealed++
+		if want, err := entryHash(e); err != nil || want != e.Hash {
+			rep.problem(n, e.Seq, "entry hash mismatch: entry was modified")
+		}
+		if !resync {
+			if e.Seq != prevSeq+1 {
+				rep.problem(n, e.Seq, "sequence gap: seq %d follows %d", e.Seq, prevSeq)
+			}
+			if e.Prev != prevHash {
+				rep.problem(n, e.Seq, "chain broken: prev hash does not match entry %d", prevSeq)
+			}
+		}
+		resync = false
+		if cp := e.Checkpoint; e.Kind == AuditKindCheckpoint && cp != nil {
+			rep.Checkpoints++
+			valid := true
+			if cp.Seq != e.Seq-1 || cp.Hash != e.Prev {
+				rep.problem(n, e.Seq, "checkpoint covers seq %d but follows seq %d", cp.Seq, e.Seq-1)
+				valid = false
+			}
+			if caCert != nil {
+				switch {
+				case len(cp.Signature) == 0:
+					rep.problem(n, e.Seq, "checkpoint is unsigned")
+					valid = false
+				case cp.verifySignature(caCert):
+					rep.Signed++
+				default:
+					rep.problem(n, e.Seq, "checkpoint signature does not verify")
+					valid = false
+				}
+			}
+			if valid && cp.Seq > rep.LastCheckpointSeq {
+				rep.LastCheckpointSeq = cp.Seq
+				rep.Unsealed = 0
+			}
+		}
+		seenByseq[e.Seq] = e.Hash
+		prevHash, prevSeq = e.Hash, e.Seq
+	})
+	if err != nil {
+		return nil, err
+	}
+	rep.LastSeq = prevSeq
+	if checkpointFileMissing && rep.Checkpoints > 0 {
+		rep.problem(0, 0, "checkpoint file %s is missing but the journal has %d checkpoints", AuditCheckpointPath(path), rep.Checkpoints)
+	}
+
+	for seq, hash := range external {
+		got, ok := seenByseq[seq]
+		switch {
+		case !ok && seq > prevSeq:
+			rep.problem(0, seq, "journal truncated: checkpoint covers seq %d but the journal ends at %d", seq, prevSeq)
+		case !ok:
+			rep.problem(0, seq, "entry %d covered by a checkpoint is missing", seq)
+		case got != hash:
+			rep.problem(0, seq, "entry %d does not match its checkpoint", seq)
+		}
+	}
+	sortAuditProblems(rep.Problems)
+	return rep, nil
+}
+
+// readJSONLines calls fn for each non-empty line of the file at path with its
+// 1-based line number. A missing file has no lines.
+func readJSONLines(path string, fn func(n int, line []byte)) error {
+	f, err := os.Open(path) //nolint:gosec // path is the operator-supplied journal
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimRight(line, "\n"); len(line) > 0 {
+			fn(n, line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sortAuditProblems orders problems by journal line, checkpoint-file problems
+// (line 0) last, then by seq.
+func sortAuditProblems(ps []AuditProblem) {
+	line := func(p AuditProblem) int {
+		if p.Line == 0 {
+			return int(^uint(0) >> 1)
+		}
+		return p.Line
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		if li, lj := line(ps[i]), line(ps[j]); li != lj {
+			return li < lj
+		}
+		return ps[i].Seq < ps[j].Seq
+	})
+}
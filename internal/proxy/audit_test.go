@@ -0,0 +1,267 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestJournal appends n exec entries and a push, then checkpoints,
+// signing with ca's key if ca is non-nil.
+func writeTestJournal(t *testing.T, path string, ca *CA, n int) {
+	t.Helper()
+	var key *ecdsa.PrivateKey
+	if ca != nil {
+		key = ca.Key
+	}
+	j, err := OpenAuditJournal(path, key)
+	require.NoError(t, err)
+	for i := range n {
+		require.NoError(t, j.AppendExec("gastown/nux", AuditExec{Argv: []string{"gt", "prime", strings.Repeat("x", i)}}))
+	}
+	require.NoError(t, j.AppendPush("gastown/nux", AuditPush{Rig: "gastown", Updates: []RefUpdate{
+		{Ref: "refs/heads/polecat/nux-1", Old: strings.Repeat("0", 40), New: strings.Repeat("a", 40)},
+	}}))
+	require.NoError(t, j.Checkpoint())
+}
+
+func readJournalLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeJournalLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+}
+
+func TestAuditJournalChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeTestJournal(t, path, nil, 3)
+
+	rep, err := VerifyAuditJournal(path, nil)
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), "problems: %+v", rep.Problems)
+	assert.Equal(t, 5, rep.Entries) // 3 execs, 1 push, 1 checkpoint
+	assert.Equal(t, uint64(5), rep.LastSeq)
+	assert.Equal(t, 1, rep.Checkpoints)
+	assert.Equal(t, 1, rep.ExternalCheckpoints)
+	assert.Equal(t, uint64(4), rep.LastCheckpointSeq)
+	assert.Zero(t, rep.Unsealed)
+
+	// Reopening resumes the chain rather than starting a new one.
+	writeTestJournal(t, path, nil, 1)
+	rep, err = VerifyAuditJournal(path, nil)
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), "problems: %+v", rep.Problems)
+	assert.Equal(t, uint64(8), rep.LastSeq)
+
+	// A checkpoint with nothing new to cover is skipped.
+	j, err := OpenAuditJournal(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, j.Checkpoint())
+	assert.Len(t, readJournalLines(t, path), 8)
+}
+
+func TestAuditJournalCheckpointsEveryN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	j, err := OpenAuditJournal(path, nil)
+	require.NoError(t, err)
+	for range auditCheckpointEvery {
+		require.NoError(t, j.AppendExec("gastown/nux", AuditExec{Argv: []string{"gt", "prime"}}))
+	}
+	lines := readJournalLines(t, path)
+	require.Len(t, lines, auditCheckpointEvery+1)
+	assert.Contains(t, lines[auditCheckpointEvery], `"kind":"checkpoint"`)
+}
+
+func TestVerifyAuditJournalDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   string
+	}{
+		{"modified entry", func(l []string) []string {
+			l[1] = strings.Replace(l[1], `"exit_code":0`, `"exit_code":1`, 1)
+			return l
+		}, "entry hash mismatch"},
+		{"removed entry", func(l []string) []string {
+			return append(l[:1:1], l[2:]...)
+		}, "sequence gap"},
+		{"reordered entries", func(l []string) []string {
+			l[1], l[2] = l[2], l[1]
+			return l
+		}, "chain broken"},
+		{"truncated journal", func(l []string) []string {
+			return l[:3]
+		}, "journal truncated"},
+		{"added field", func(l []string) []string {
+			l[0] = strings.Replace(l[0], `{"seq"`, `{"note":"x","seq"`, 1)
+			return l
+		}, "unreadable entry"},
+		{"rehashed rewrite", func(l []string) []string {
+			// A forger who recomputes the hashes still can't match the
+			// external checkpoint.
+			var e AuditEntry
+			_ = json.Unmarshal([]byte(l[0]), &e)
+			e.Exec.Argv = []string{"gt", "innocent"}
+			prev := ""
+			out := make([]string, 0, len(l))
+			for i, line := range l {
+				if i > 0 {
+					e = AuditEntry{}
+					_ = json.Unmarshal([]byte(line), &e)
+				}
+				e.Prev = prev
+				if e.Checkpoint != nil {
+					e.Checkpoint.Hash = prev
+				}
+				e.Hash, _ = entryHash(e)
+				prev = e.Hash
+				data, _ := json.Marshal(e)
+				out = append(out, string(data))
+			}
+			return out
+		}, "does not match its checkpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			writeTestJournal(t, path, nil, 3)
+			writeJournalLines(t, path, tt.tamper(readJournalLines(t, path)))
+
+			rep, err := VerifyAuditJournal(path, nil)
+			require.NoError(t, err)
+			require.False(t, rep.OK())
+			var msgs []string
+			for _, p := range rep.Problems {
+				msgs = append(msgs, p.Message)
+			}
+			assert.Contains(t, strings.Join(msgs, "\n"), tt.want)
+		})
+	}
+}
+
+func TestAuditCheckpointSignature(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+	other, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeTestJournal(t, path, ca, 2)
+
+	rep, err := VerifyAuditJournal(path, ca.Cert)
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), "problems: %+v", rep.Problems)
+	assert.Equal(t, 1, rep.Signed)
+
+	rep, err = VerifyAuditJournal(path, other.Cert)
+	require.NoError(t, err)
+	assert.False(t, rep.OK())
+	assert.Zero(t, rep.Signed)
+}
+
+func TestAuditCheckpointUnsigned(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+
+	problems := func(rep *AuditReport) string {
+		var msgs []string
+		for _, p := range rep.Problems {
+			msgs = append(msgs, p.Message)
+		}
+		return strings.Join(msgs, "\n")
+	}
+
+	// A journal written without a signing key verifies only without a CA.
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeTestJournal(t, path, nil, 2)
+	rep, err := VerifyAuditJournal(path, nil)
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), "problems: %+v", rep.Problems)
+
+	rep, err = VerifyAuditJournal(path, ca.Cert)
+	require.NoError(t, err)
+	assert.Zero(t, rep.Signed)
+	assert.Zero(t, rep.LastCheckpointSeq)
+	assert.Contains(t, problems(rep), "checkpoint is unsigned")
+	assert.Contains(t, problems(rep), "checkpoint file line 1: checkpoint is unsigned")
+
+	// Stripping the signatures from a signed journal (and dropping the
+	// checkpoint file) does not verify clean either.
+	path = filepath.Join(t.TempDir(), "audit.jsonl")
+	writeTestJournal(t, path, ca, 2)
+	lines := readJournalLines(t, path)
+	for i, line := range lines {
+		var e AuditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		if e.Checkpoint != nil {
+			e.Checkpoint.Signature = nil
+			e.Hash, err = entryHash(e)
+			require.NoError(t, err)
+			data, err := json.Marshal(e)
+			require.NoError(t, err)
+			lines[i] = string(data)
+		}
+	}
+	writeJournalLines(t, path, lines)
+	require.NoError(t, os.Remove(AuditCheckpointPath(path)))
+
+	rep, err = VerifyAuditJournal(path, ca.Cert)
+	require.NoError(t, err)
+	assert.False(t, rep.OK())
+	assert.Contains(t, problems(rep), "checkpoint is unsigned")
+	assert.Contains(t, problems(rep), "checkpoint file")
+	assert.Contains(t, problems(rep), "is missing")
+}
+
+func TestOpenAuditJournalRejectsBrokenTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeTestJournal(t, path, nil, 1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":9,"kind":"ex`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = OpenAuditJournal(path, nil)
+	assert.Error(t, err)
+}
+
+func TestExecIsAudited(t *testing.T) {
+	srv := newExecTestServer(t, Config{AllowedCommands: []string{"echo"}})
+	exec := func(body string) int {
+		rec := httptest.NewRecorder()
+		srv.handleExec(rec, makeFakeRequest("POST", "/v1/exec", body, "gt-gastown-nux"))
+		return rec.Code
+	}
+	require.Equal(t, 200, exec(`{"argv":["echo","hello"]}`))
+
+	lines := readJournalLines(t, AuditJournalPath(srv.cfg.TownRoot))
+	require.Len(t, lines, 1)
+	var e AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, AuditKindExec, e.Kind)
+	assert.Equal(t, "gastown/nux", e.Identity)
+	require.NotNil(t, e.Exec)
+	assert.Equal(t, []string{"echo", "hello"}, e.Exec.Argv)
+	sum := sha256.Sum256([]byte("hello\n"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), e.Exec.StdoutSHA256)
+	assert.Equal(t, int64(6), e.Exec.StdoutBytes)
+
+	// Rejected requests never ran, so they are not journaled.
+	require.Equal(t, 403, exec(`{"argv":["curl","x"]}`))
+	assert.Len(t, readJournalLines(t, AuditJournalPath(srv.cfg.TownRoot)), 1)
+}
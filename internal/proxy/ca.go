@@ -91,13 +91,21 @@ func GenerateCA(dir string) (*CA, error) {
 
 // LoadOrGenerateCA loads the CA from dir if present, otherwise generates and saves it.
 func LoadOrGenerateCA(dir string) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, "ca.crt")); errors.Is(err, os.ErrNotExist) {
+		return GenerateCA(dir)
+	}
+	return LoadCA(dir)
+}
+
+// LoadCA loads an existing CA from dir/ca.crt and dir/ca.key. Unlike
+// LoadOrGenerateCA it never creates one: callers that issue certs for a
+// running proxy must use the proxy's CA, and a missing file is an error that
+// wraps os.ErrNotExist.
+func LoadCA(dir string) (*CA, error) {
 	certPath := filepath.Join(dir, "ca.crt")
 	keyPath := filepath.Join(dir, "ca.key")
 
 	certPEM, err := os.ReadFile(certPath)
-	if errors.Is(err, os.ErrNotExist) {
-		return GenerateCA(dir)
-	}
 	if err != nil {
 		return nil, fmt.Errorf("read ca.crt: %w", err)
 	}
@@ -0,0 +1,241 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/steveyegge/gastown/internal/atomicfile"
+)
+
+// CertRecord is one polecat client certificate known to the proxy: issued by
+// it, revoked through it, or both.
+type CertRecord struct {
+	// Serial is the certificate serial number in lowercase hex.
+	Serial string `json:"serial"`
+	// CN is the certificate common name (gt-<rig>-<name>). Empty for a cert
+	// revoked by serial alone that the proxy never issued.
+	CN string `json:"cn,omitempty"`
+	// IssuedAt is when the proxy issued the cert. Zero if it was issued elsewhere.
+	IssuedAt time.Time `json:"issued_at,omitzero"`
+	// NotAfter is the cert's expiry. The record is pruned once it passes.
+	NotAfter time.Time `json:"not_after"`
+	// Revoked is set once the cert has been revoked.
+	Revoked *Revocation `json:"revoked,omitempty"`
+}
+
+// Revocation records who revoked a certificate, when, and why.
+type Revocation struct {
+	At     time.Time `json:"at"`
+	By     string    `json:"by,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// State reports "revoked", "expired", or "active" as of now.
+func (r CertRecord) State(now time.Time) string {
+	switch {
+	case r.Revoked != nil:
+		return "revoked"
+	case r.NotAfter.Before(now):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+// certLedgerFile is the on-disk shape of the ledger.
+type certLedgerFile struct {
+	Certs []CertRecord `json:"certs"`
+}
+
+// CertLedger is the town's persistent record of issued and revoked polecat
+// certificates. It lives in a JSON file guarded by a sibling flock, so the
+// proxy server and gt proxy certs can both update it. Records are dropped once
+// the certificate's NotAfter passes: an expired cert fails the TLS handshake
+// regardless, so its revocation no longer needs to be remembered.
+type CertLedger struct {
+	path string
+}
+
+// CertLedgerPath returns the ledger location for a town.
+func CertLedgerPath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "proxy", "certs.json")
+}
+
+// OpenCertLedger returns the ledger stored at path. The file is created on
+// the first write.
+func OpenCertLedger(path string) *CertLedger {
+	return &CertLedger{path: path}
+}
+
+// Path returns the ledger file path.
+func (l *CertLedger) Path() string {
+	return l.path
+}
+
+// Records returns the unexpired records, oldest first.
+func (l *CertLedger) Records() ([]CertRecord, error) {
+	f, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := f.Certs[:0]
+	for _, r := range f.Certs {
+		if !r.NotAfter.Before(now) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// Lookup returns the record for serial (lowercase hex).
+func (l *CertLedger) Lookup(serial string) (CertRecord, bool, error) {
+	records, err := l.Records()
+	if err != nil {
+		return CertRecord{}, false, err
+	}
+	serial = normalizeSerial(serial)
+	for _, r := range records {
+		if r.Serial == serial {
+			return r, true, nil
+		}
+	}
+	return CertRecord{}, false, nil
+}
+
+// RecordIssued adds a newly issued certificate to the ledger.
+func (l *CertLedger) RecordIssued(cert *x509.Certificate, issuedAt time.Time) error {
+	return l.update(time.Now(), func(f *certLedgerFile) error {
+		f.Certs = append(f.Certs, CertRecord{
+			Serial:   cert.SerialNumber.Text(16),
+			CN:       cert.Subject.CommonName,
+			IssuedAt: issuedAt.UTC(),
+			NotAfter: cert.NotAfter.UTC(),
+		})
+		return nil
+	})
+}
+
+// Revoke marks serial revoked and returns the updated record. A serial the
+// ledger has no record of is added, kept until notAfter, which must then be
+// set. Revoking an already-revoked cert keeps the original revocation.
+func (l *CertLedger) Revoke(serial string, rev Revocation, notAfter time.Time) (CertRecord, error) {
+	serial = normalizeSerial(serial)
+	var out CertRecord
+	err := l.update(time.Now(), func(f *certLedgerFile) error {
+		for i := range f.Certs {
+			if f.Certs[i].Serial != serial {
+				continue
+			}
+			if f.Certs[i].Revoked == nil {
+				rev.At = rev.At.UTC()
+				f.Certs[i].Revoked = &rev
+			}
+			out = f.Certs[i]
+			return nil
+		}
+		if notAfter.IsZero() {
+			return fmt.Errorf("serial %s is not in the ledger; its expiry is required", serial)
+		}
+		rev.At = rev.At.UTC()
+		out = CertRecord{Serial: serial, NotAfter: notAfter.UTC(), Revoked: &rev}
+		f.Certs = append(f.Certs, out)
+		return nil
+	})
+	return out, err
+}
+
+// Prune drops records for certificates that expired before now and returns
+// how many were removed. The file is only rewritten when something expired.
+func (l *CertLedger) Prune(now time.Time) (int, error) {
+	f, err := l.read()
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, r := range f.Certs {
+		if r.NotAfter.Before(now) {
+			expired++
+		}
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+	return expired, l.update(now, func(*certLedgerFile) error { return nil })
+}
+
+// update applies fn to the ledger under the file lock, drops records that
+// expired before now, and writes the result atomically.
+func (l *CertLedger) update(now time.Time, fn func(*certLedgerFile) error) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("create ledger dir: %w", err)
+	}
+	lock := flock.New(l.path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("lock cert ledger: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	f, err := l.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		return err
+	}
+	kept := f.Certs[:0]
+	for _, r := range f.Certs {
+		if !r.NotAfter.Before(now) {
+			kept = append(kept, r)
+		}
+	}
+	f.Certs = kept
+	sort.SliceStable(f.Certs, func(i, j int) bool { return f.Certs[i].IssuedAt.Before(f.Certs[j].IssuedAt) })
+	return atomicfile.WriteJSONWithPerm(l.path, f, 0600)
+}
+
+func (l *CertLedger) read() (*certLedgerFile, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &certLedgerFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cert ledger: %w", err)
+	}
+	var f certLedgerFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse cert ledger %s: %w", l.path, err)
+	}
+	return &f, nil
+}
+
+// ParseSerial parses a certificate serial in hex, tolerating a 0x prefix,
+// colon separators and upper case, as printed by openssl and other tools.
+func ParseSerial(s string) (*big.Int, bool) {
+	n, ok := new(big.Int).SetString(normalizeSerial(s), 16)
+	if !ok || n.Sign() <= 0 {
+		return nil, false
+	}
+	return n, true
+}
+
+// normalizeSerial returns the canonical ledger form of a hex serial.
+func normalizeSerial(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "0x")
+	s = strings.ReplaceAll(s, ":", "")
+	if n, ok := new(big.Int).SetString(s, 16); ok {
+		return n.Text(16)
+	}
+	return s
+}
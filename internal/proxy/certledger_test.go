@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueLeaf(t *testing.T, ca *CA, cn string, ttl time.Duration) *x509.Certificate {
+	t.Helper()
+	certPEM, _, err := ca.IssuePolecat(cn, ttl)
+	require.NoError(t, err)
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return leaf
+}
+
+func TestCertLedger(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+	town := t.TempDir()
+	l := OpenCertLedger(CertLedgerPath(town))
+
+	t.Run("missing file is an empty ledger", func(t *testing.T) {
+		records, err := l.Records()
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	alice := issueLeaf(t, ca, "gt-gastown-alice", time.Hour)
+	bob := issueLeaf(t, ca, "gt-gastown-bob", time.Hour)
+	require.NoError(t, l.RecordIssued(alice, time.Now()))
+	require.NoError(t, l.RecordIssued(bob, time.Now()))
+
+	t.Run("revoke an issued cert keeps its expiry", func(t *testing.T) {
+		rec, err := l.Revoke(bob.SerialNumber.Text(16), Revocation{At: time.Now(), By: "mayor", Reason: "leaked"}, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, "gt-gastown-bob", rec.CN)
+		assert.True(t, rec.NotAfter.Equal(bob.NotAfter.UTC()))
+		require.NotNil(t, rec.Revoked)
+		assert.Equal(t, "leaked", rec.Revoked.Reason)
+
+		// Revoking again keeps the first revocation.
+		again, err := l.Revoke(bob.SerialNumber.Text(16), Revocation{At: time.Now(), By: "witness", Reason: "dup"}, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, "mayor", again.Revoked.By)
+	})
+
+	t.Run("unknown serial needs an expiry", func(t *testing.T) {
+		_, err := l.Revoke("abc123", Revocation{At: time.Now()}, time.Time{})
+		assert.Error(t, err)
+		rec, err := l.Revoke("0xABC123", Revocation{At: time.Now()}, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", rec.Serial)
+	})
+
+	t.Run("state and lookup", func(t *testing.T) {
+		rec, found, err := l.Lookup(alice.SerialNumber.Text(16))
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, "active", rec.State(time.Now()))
+		rec, _, _ = l.Lookup(bob.SerialNumber.Text(16))
+		assert.Equal(t, "revoked", rec.State(time.Now()))
+	})
+
+	t.Run("expired records are pruned", func(t *testing.T) {
+		_, err := l.Revoke("dead", Revocation{At: time.Now()}, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		_, found, err := l.Lookup("dead")
+		require.NoError(t, err)
+		assert.False(t, found, "expired revocation should not be kept")
+
+		n, err := l.Prune(time.Now().Add(2 * time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		records, err := l.Records()
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("ledger file is private", func(t *testing.T) {
+		info, err := os.Stat(l.Path())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+}
+
+func TestCertLedger_ConcurrentWriters(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "certs.json")
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Separate ledger values model separate processes sharing the file.
+			leaf := issueLeaf(t, ca, "gt-gastown-p"+big.NewInt(int64(i)).String(), time.Hour)
+			assert.NoError(t, OpenCertLedger(path).RecordIssued(leaf, time.Now()))
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := OpenCertLedger(path).Records()
+	require.NoError(t, err)
+	assert.Len(t, records, n)
+}
+
+func TestParseSerial(t *testing.T) {
+	for _, in := range []string{"1a2b", "0x1A2B", "1A:2B", " 1a2b "} {
+		n, ok := ParseSerial(in)
+		if assert.True(t, ok, in) {
+			assert.Equal(t, "1a2b", n.Text(16), in)
+		}
+	}
+	for _, bad := range []string{"", "xyz", "-5", "0", "not-hex-!@#"} {
+		_, ok := ParseSerial(bad)
+		assert.False(t, ok, bad)
+	}
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"math/big"
 	"sync"
+	"time"
 )
 
 // DenyList is a thread-safe in-memory set of revoked certificate serial numbers.
@@ -10,31 +11,60 @@ import (
 // which is unique per RFC 5280 within a single CA's issued certificates.
 //
 // The deny list is checked during the TLS handshake via VerifyPeerCertificate.
-// Entries are never removed (TTLs are short; the whole CA rotates periodically).
+// Each entry may carry the revoked certificate's NotAfter: once that passes,
+// the handshake rejects the expired cert anyway, so Prune drops the entry.
+// The server rebuilds the list from the cert ledger on start (see CertLedger).
 type DenyList struct {
 	mu     sync.RWMutex
-	denied map[string]bool
+	denied map[string]time.Time // serial → NotAfter; zero means never expires
 }
 
 // NewDenyList returns an empty deny list.
 func NewDenyList() *DenyList {
-	return &DenyList{denied: make(map[string]bool)}
+	return &DenyList{denied: make(map[string]time.Time)}
 }
 
-// Deny adds a certificate serial number to the deny list.
+// Deny adds a certificate serial number to the deny list with no expiry.
 // Subsequent IsDenied calls for the same serial return true.
 // Calling Deny on an already-denied serial is a no-op.
 func (d *DenyList) Deny(serial *big.Int) {
+	d.DenyUntil(serial, time.Time{})
+}
+
+// DenyUntil adds a certificate serial number to the deny list until notAfter,
+// the revoked certificate's own expiry. A zero notAfter never expires. If the
+// serial is already denied, the later expiry wins.
+func (d *DenyList) DenyUntil(serial *big.Int, notAfter time.Time) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.denied[serial.Text(16)] = true
+	key := serial.Text(16)
+	if cur, ok := d.denied[key]; ok && (cur.IsZero() || (!notAfter.IsZero() && cur.After(notAfter))) {
+		return
+	}
+	d.denied[key] = notAfter
 }
 
 // IsDenied reports whether the given serial number is on the deny list.
 func (d *DenyList) IsDenied(serial *big.Int) bool {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return d.denied[serial.Text(16)]
+	_, ok := d.denied[serial.Text(16)]
+	return ok
+}
+
+// Prune removes entries whose certificate expired before now and returns how
+// many were removed.
+func (d *DenyList) Prune(now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for key, notAfter := range d.denied {
+		if !notAfter.IsZero() && notAfter.Before(now) {
+			delete(d.denied, key)
+			n++
+		}
+	}
+	return n
 }
 
 // Len returns the number of entries currently in the deny list.
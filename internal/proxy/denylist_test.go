@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -50,6 +51,28 @@ func TestDenyList(t *testing.T) {
 		assert.False(t, d.IsDenied(other))
 	})
 
+	t.Run("DenyUntil entries are pruned after the cert expires", func(t *testing.T) {
+		d := NewDenyList()
+		now := time.Now()
+		d.DenyUntil(big.NewInt(1), now.Add(-time.Minute))
+		d.DenyUntil(big.NewInt(2), now.Add(time.Hour))
+		d.Deny(big.NewInt(3))
+		assert.Equal(t, 1, d.Prune(now))
+		assert.False(t, d.IsDenied(big.NewInt(1)))
+		assert.True(t, d.IsDenied(big.NewInt(2)))
+		assert.True(t, d.IsDenied(big.NewInt(3)), "entries without expiry are kept")
+	})
+
+	t.Run("DenyUntil keeps the later expiry", func(t *testing.T) {
+		d := NewDenyList()
+		now := time.Now()
+		d.DenyUntil(big.NewInt(1), now.Add(time.Hour))
+		d.DenyUntil(big.NewInt(1), now.Add(time.Minute))
+		assert.Equal(t, 0, d.Prune(now.Add(30*time.Minute)))
+		d.Deny(big.NewInt(1))
+		assert.Equal(t, 0, d.Prune(now.Add(2*time.Hour)), "a permanent deny overrides the expiry")
+	})
+
 	t.Run("concurrent Deny and IsDenied", func(t *testing.T) {
 		d := NewDenyList()
 		const n = 100
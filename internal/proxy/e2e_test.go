@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,7 +30,7 @@ func TestE2EEchoThroughProxy(t *testing.T) {
 	// --- Start proxy server with echo allowed ---
 	srv, err := New(Config{
 		ListenAddr:      "127.0.0.1:0",
-		AllowedCommands: []string{"echo"},
+		AllowedCommands: []string{"echo", "cat", "sh"},
 		TownRoot:        t.TempDir(),
 		Logger:          discardLogger(),
 	}, ca)
@@ -110,6 +111,39 @@ func TestE2EEchoThroughProxy(t *testing.T) {
 		assert.Equal(t, "a b c d\n", string(output))
 	})
 
+	t.Run("piped stdin streams through cat", func(t *testing.T) {
+		catLink := filepath.Join(binDir, "cat")
+		require.NoError(t, os.Symlink(clientBin, catLink))
+		cmd := exec.Command(catLink)
+		cmd.Env = proxyEnv
+		cmd.Stdin = strings.NewReader("from stdin\n")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "cat through proxy failed: %s", output)
+		assert.Equal(t, "from stdin\n", string(output))
+	})
+
+	t.Run("exit code and stderr propagate", func(t *testing.T) {
+		shLink := filepath.Join(binDir, "sh")
+		require.NoError(t, os.Symlink(clientBin, shLink))
+		cmd := exec.Command(shLink, "-c", "echo oops >&2; exit 4")
+		cmd.Env = proxyEnv
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 4, exitErr.ExitCode())
+		assert.Equal(t, "oops\n", stderr.String())
+	})
+
+	t.Run("buffered mode uses /v1/exec", func(t *testing.T) {
+		cmd := exec.Command(echoLink, "buffered")
+		cmd.Env = append(proxyEnv, "GT_PROXY_MODE=buffered")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "echo through proxy failed: %s", output)
+		assert.Equal(t, "buffered\n", string(output))
+	})
+
 	t.Run("wrong CA cert is rejected at TLS handshake", func(t *testing.T) {
 		// Issue a cert from a completely different CA.
 		otherCA, err := GenerateCA(t.TempDir())
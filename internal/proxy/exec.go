@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"golang.org/x/time/rate"
@@ -42,39 +44,95 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Argv) == 0 {
-		http.Error(w, "argv is empty", http.StatusBadRequest)
+	plan, release, ok := s.admitExec(w, identity, req.Argv)
+	if !ok {
 		return
 	}
+	defer release()
+
+	execCtx := r.Context()
+	if s.execTimeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(execCtx, s.execTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	out, errOut, exitCode := runCommand(execCtx, plan.argv, identity, plan.env)
+	s.logExec(identity, plan, exitCode)
+	var stdout, stderr outputDigest
+	_, _ = io.WriteString(&stdout, out)
+	_, _ = io.WriteString(&stderr, errOut)
+	s.auditExec(identity, plan, false, exitCode, time.Since(start), &stdout, &stderr)
+
+	// The handler always returns HTTP 200 even when the subprocess exits
+	// non-zero. This is intentional: the RPC call itself succeeded (the request was
+	// well-formed, the command was allowed, and the subprocess ran). The subprocess's
+	// outcome is reported in the JSON body via exitCode. Callers must inspect exitCode
+	// rather than the HTTP status to determine whether the command succeeded.
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(execResponse{
+		Stdout:   out,
+		Stderr:   errOut,
+		ExitCode: exitCode,
+	})
+}
+
+// execPlan is an exec request that passed admitExec, ready to run.
+type execPlan struct {
+	cmd0    string   // tool name as requested ("gt", "bd")
+	sub     string   // truncated argv[1], for logs
+	request []string // argv as the client sent it, for the audit journal
+	argv    []string // argv with argv[0] resolved to the binary path
+	env     []string // subprocess environment; nil means minimalEnv()
+}
+
+// admitExec applies the checks shared by /v1/exec and /v1/exec/stream:
+// the command and subcommand allowlists, the per-client rate limit and the
+// global concurrency cap. On rejection it writes the HTTP error and returns
+// ok == false; otherwise the caller must call release when the subprocess
+// is done.
+func (s *Server) admitExec(w http.ResponseWriter, identity string, reqArgv []string) (plan execPlan, release func(), ok bool) {
+	if len(reqArgv) == 0 {
+		http.Error(w, "argv is empty", http.StatusBadRequest)
+		return execPlan{}, nil, false
+	}
 
 	// Validate argv[0] is in the allowlist.
-	cmd0 := req.Argv[0]
+	cmd0 := reqArgv[0]
 	if !s.isAllowed(cmd0) {
 		http.Error(w, fmt.Sprintf("command not allowed: %q", cmd0), http.StatusForbidden)
-		return
+		return execPlan{}, nil, false
 	}
 
 	// Validate argv[1] (subcommand) if this command has a subcommand allowlist.
 	if subs, ok := s.allowedSubs[cmd0]; ok {
-		sub, ok := allowedSubcommand(cmd0, req.Argv)
+		sub, ok := allowedSubcommand(cmd0, reqArgv)
 		if !ok {
 			http.Error(w, "subcommand required", http.StatusForbidden)
-			return
+			return execPlan{}, nil, false
 		}
-		if cmd0 == "bd" && beads.HasBDTargetSelectorFlag(req.Argv) {
+		if cmd0 == "bd" && beads.HasBDTargetSelectorFlag(reqArgv) {
 			http.Error(w, "bd target-selector global flags are not allowed", http.StatusForbidden)
-			return
+			return execPlan{}, nil, false
 		}
 		if !subs[sub] {
 			http.Error(w, fmt.Sprintf("subcommand not allowed: %q %q", cmd0, sub), http.StatusForbidden)
-			return
+			return execPlan{}, nil, false
 		}
 	}
 
-	// Build argv as a copy of req.Argv to avoid mutating the decoded request.
-	argv := append([]string(nil), req.Argv...)
-	envOverride := []string(nil)
-	argv, envOverride = s.rewriteBDCreateRepo(argv)
+	// Per-identity policy: may deny with a reason or assign a rate class.
+	decision, rateClass := s.evaluatePolicy(identity, reqArgv)
+	if !decision.Allow {
+		s.log.Warn("exec denied by policy", "identity", identity, "cmd", cmd0,
+			"sub", subForLog(reqArgv), "rule", decision.Rule, "reason", decision.Reason)
+		http.Error(w, "denied by policy: "+decision.Reason, http.StatusForbidden)
+		return execPlan{}, nil, false
+	}
+
+	// Build argv as a copy of reqArgv to avoid mutating the decoded request.
+	argv := append([]string(nil), reqArgv...)
+	argv, envOverride := s.rewriteBDCreateRepo(argv)
 	// Use the resolved absolute binary path to prevent PATH hijacking after startup.
 	if resolved, ok := s.resolvedPaths[cmd0]; ok {
 		argv[0] = resolved
@@ -85,50 +143,56 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	if rateKey == "" {
 		rateKey = "unknown"
 	}
-	if !s.limiterFor(rateKey).Allow() {
-		s.log.Warn("exec rate limit exceeded", "identity", identity)
+	limiter := s.limiterFor(rateKey)
+	if decision.RateClass != "" {
+		limiter = s.classLimiterFor(rateKey, decision.RateClass, rateClass)
+	}
+	if !limiter.Allow() {
+		s.log.Warn("exec rate limit exceeded", "identity", identity, "rate_class", decision.RateClass)
 		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
-		return
+		return execPlan{}, nil, false
 	}
 
 	// Global concurrency cap: reject immediately if all slots are busy.
 	select {
 	case s.execSem <- struct{}{}:
-		defer func() { <-s.execSem }()
 	default:
 		s.log.Warn("exec concurrency limit exceeded", "identity", identity)
 		http.Error(w, "server busy", http.StatusServiceUnavailable)
-		return
+		return execPlan{}, nil, false
 	}
 
-	execCtx := r.Context()
-	if s.execTimeout > 0 {
-		var cancel context.CancelFunc
-		execCtx, cancel = context.WithTimeout(execCtx, s.execTimeout)
-		defer cancel()
-	}
-	out, errOut, exitCode := runCommand(execCtx, argv, identity, envOverride)
+	plan = execPlan{cmd0: cmd0, sub: subForLog(reqArgv), request: reqArgv, argv: argv, env: envOverride}
+	return plan, func() { <-s.execSem }, true
+}
 
-	// Audit log (do not log full argv — it may contain tokens or secrets).
+// logExec writes the exec audit log line.
+// The full argv is not logged — it may contain tokens or secrets.
+func (s *Server) logExec(identity string, plan execPlan, exitCode int, attrs ...any) {
+	attrs = append([]any{"identity", identity, "cmd", plan.cmd0, "sub", plan.sub, "exit", exitCode}, attrs...)
 	if exitCode == 0 {
-		s.log.Info("exec", "identity", identity, "cmd", cmd0,
-			"sub", subForLog(req.Argv), "exit", exitCode)
+		s.log.Info("exec", attrs...)
 	} else {
-		s.log.Warn("exec failed", "identity", identity, "cmd", cmd0,
-			"sub", subForLog(req.Argv), "exit", exitCode)
+		s.log.Warn("exec failed", attrs...)
 	}
+}
 
-	// The handler always returns HTTP 200 even when the subprocess exits
-	// non-zero. This is intentional: the RPC call itself succeeded (the request was
-	// well-formed, the command was allowed, and the subprocess ran). The subprocess's
-	// outcome is reported in the JSON body via exitCode. Callers must inspect exitCode
-	// rather than the HTTP status to determine whether the command succeeded.
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(execResponse{
-		Stdout:   out,
-		Stderr:   errOut,
-		ExitCode: exitCode,
+// auditExec appends an exec to the audit journal. A failed append is logged
+// but doesn't fail the request: the command has already run.
+func (s *Server) auditExec(identity string, plan execPlan, stream bool, exitCode int, d time.Duration, stdout, stderr *outputDigest) {
+	err := s.audit.AppendExec(identity, AuditExec{
+		Argv:         plan.request,
+		Stream:       stream,
+		ExitCode:     exitCode,
+		DurationMS:   d.Milliseconds(),
+		StdoutSHA256: stdout.Sum(),
+		StdoutBytes:  stdout.n,
+		StderrSHA256: stderr.Sum(),
+		StderrBytes:  stderr.n,
 	})
+	if err != nil {
+		s.log.Error("audit journal append failed", "identity", identity, "cmd", plan.cmd0, "err", err)
+	}
 }
 
 // subForLog returns a truncated argv[1] if present, otherwise "".
@@ -189,6 +253,12 @@ func cnToIdentity(cn string) string {
 	return rig + "/" + name
 }
 
+// IdentityFromCN returns the "<rig>/<name>" identity the proxy derives from a
+// polecat cert CN ("gt-<rig>-<name>"), or "" if the CN is not in that format.
+func IdentityFromCN(cn string) string {
+	return cnToIdentity(cn)
+}
+
 // isAllowed reports whether cmd is in the allowlist.
 func (s *Server) isAllowed(cmd string) bool {
 	return s.allowed[cmd]
@@ -210,32 +280,74 @@ func (s *Server) limiterFor(identity string) *rate.Limiter {
 	return v.(*rate.Limiter)
 }
 
+// classLimiterFor returns the rate.Limiter for identity within a policy rate
+// class. The key includes the class parameters, so editing a class in the
+// policy takes effect without a restart.
+func (s *Server) classLimiterFor(identity, class string, rc RateClass) *rate.Limiter {
+	key := fmt.Sprintf("%s\x00%s\x00%g\x00%d", identity, class, rc.Rate, rc.Burst)
+	if v, ok := s.rateLimiters.Load(key); ok {
+		return v.(*rate.Limiter)
+	}
+	l := rate.NewLimiter(rate.Limit(rc.Rate), rc.Burst)
+	v, _ := s.rateLimiters.LoadOrStore(key, l)
+	return v.(*rate.Limiter)
+}
+
+// evaluatePolicy evaluates the current exec policy for a request and returns
+// the decision with its rate class, if any.
+func (s *Server) evaluatePolicy(identity string, argv []string) (PolicyDecision, RateClass) {
+	p, err := s.policy.current()
+	if err != nil {
+		s.log.Error("exec policy reload failed — keeping previous policy", "path", s.policy.path, "err", err)
+	}
+	d := p.Evaluate(s.cfg.TownRoot, identity, argv)
+	if d.RateClass == "" {
+		return d, RateClass{}
+	}
+	return d, p.RateClasses[d.RateClass]
+}
+
 func runCommand(ctx context.Context, argv []string, identity string, envOverride ...[]string) (stdout, stderr string, exitCode int) {
-	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	var env []string
+	if len(envOverride) > 0 {
+		env = envOverride[0]
+	}
+	cmd := newCommand(ctx, argv, identity, env)
 	var outBuf, errBuf strings.Builder
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
+	exitCode = exitCodeOf(cmd.Run())
+	return outBuf.String(), errBuf.String(), exitCode
+}
+
+// newCommand builds the subprocess for an exec request. env nil means
+// minimalEnv().
+func newCommand(ctx context.Context, argv []string, identity string, env []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
 	// Restrict the subprocess environment to prevent server credentials from
 	// leaking into gt/bd calls. Pass identity via env var so commands can
 	// optionally use it without requiring a --identity CLI flag on every command.
-	env := minimalEnv()
-	if len(envOverride) > 0 && envOverride[0] != nil {
-		env = envOverride[0]
+	if env == nil {
+		env = minimalEnv()
 	}
 	if identity != "" {
 		env = stripEnvKey(env, "GT_PROXY_IDENTITY")
 		env = append(env, "GT_PROXY_IDENTITY="+identity)
 	}
 	cmd.Env = env
-	err := cmd.Run()
-	if err != nil {
-		if exit, ok := err.(*exec.ExitError); ok {
-			exitCode = exit.ExitCode()
-		} else {
-			exitCode = 1
-		}
+	return cmd
+}
+
+// exitCodeOf maps the error from exec.Cmd.Run or Wait to an exit code:
+// the subprocess's own status, or 1 if it could not be run at all.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
 	}
-	return outBuf.String(), errBuf.String(), exitCode
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.ExitCode()
+	}
+	return 1
 }
 
 func (s *Server) rewriteBDCreateRepo(argv []string) ([]string, []string) {
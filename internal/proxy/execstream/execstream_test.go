@@ -0,0 +1,99 @@
+package execstream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Start, StartRequest{Argv: []string{"gt", "mail"}, Stdin: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFrame(&buf, Stdin, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFrame(&buf, StdinEOF, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		t       Type
+		payload string
+	}{
+		{Start, `{"argv":["gt","mail"],"stdin":true}`},
+		{Stdin, "hello"},
+		{StdinEOF, ""},
+	}
+	for _, w := range want {
+		typ, payload, err := ReadFrame(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != w.t || string(payload) != w.payload {
+			t.Errorf("frame = %q %q, want %q %q", byte(typ), payload, byte(w.t), w.payload)
+		}
+	}
+	if _, _, err := ReadFrame(&buf); err != io.EOF {
+		t.Errorf("ReadFrame at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFrame(&buf, Stdout, []byte("truncated")); err != nil {
+		t.Fatal(err)
+	}
+	short := buf.Bytes()[:buf.Len()-2]
+	if _, _, err := ReadFrame(bytes.NewReader(short)); err == nil || err == io.EOF {
+		t.Errorf("truncated payload: err = %v", err)
+	}
+	if _, _, err := ReadFrame(bytes.NewReader(short[:3])); err == nil || err == io.EOF {
+		t.Errorf("truncated header: err = %v", err)
+	}
+
+	huge := []byte{byte(Stdin), 0xff, 0xff, 0xff, 0xff}
+	if _, _, err := ReadFrame(bytes.NewReader(huge)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("oversized frame: err = %v, want ErrPayloadTooLarge", err)
+	}
+	if err := WriteFrame(io.Discard, Stdout, make([]byte, MaxPayload+1)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("WriteFrame oversized: err = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+func TestWriterStreamChunks(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	fw := NewWriter(&buf, func() { flushes++ })
+
+	data := bytes.Repeat([]byte("x"), chunkSize*2+10)
+	n, err := fw.Stream(Stderr).Write(data)
+	if err != nil || n != len(data) {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+
+	var got []byte
+	frames := 0
+	for {
+		typ, payload, err := ReadFrame(&buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != Stderr {
+			t.Errorf("frame type = %q, want Stderr", byte(typ))
+		}
+		got = append(got, payload...)
+		frames++
+	}
+	if frames != 3 || flushes != 3 {
+		t.Errorf("frames = %d, flushes = %d, want 3 each", frames, flushes)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("reassembled payload differs from input")
+	}
+}
@@ -60,6 +60,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // rigNameRe matches valid rig names: alphanumeric, hyphens, and underscores only.
@@ -173,12 +174,14 @@ func (s *Server) handlePack(w http.ResponseWriter, r *http.Request, repoPath, se
 	identity := cnToIdentity(clientCN)
 
 	// For receive-pack: enforce CN-scoped branch authorization.
-	var refs []string
+	var updates []RefUpdate
+	start := time.Now()
 	if service == "git-receive-pack" {
 		var ok bool
-		ok, refs = s.authorizeReceivePack(w, r, clientCN)
+		ok, updates = s.authorizeReceivePack(w, r, clientCN)
 		if !ok {
-			s.log.Warn("git push denied", "identity", identity, "rig", rig, "refs", refs)
+			s.log.Warn("git push denied", "identity", identity, "rig", rig, "refs", refNames(updates))
+			s.auditPush(identity, AuditPush{Rig: rig, Updates: updates, Denied: true})
 			return
 		}
 	}
@@ -192,23 +195,32 @@ func (s *Server) handlePack(w http.ResponseWriter, r *http.Request, repoPath, se
 	cmd.Stdout = w
 	cmd.Stderr = &errBuf
 	cmd.Env = minimalEnv()
-	if err := cmd.Run(); err != nil {
+	err := cmd.Run()
+	if err != nil {
 		s.log.Error("git pack failed", "service", service, "rig", rig, "err", err, "stderr", errBuf.String())
 	}
 
 	// Audit log: record who performed the operation regardless of git subprocess outcome.
 	if service == "git-receive-pack" {
-		s.log.Info("git push", "identity", identity, "rig", rig, "refs", refs)
+		s.log.Info("git push", "identity", identity, "rig", rig, "refs", refNames(updates))
+		s.auditPush(identity, AuditPush{Rig: rig, Updates: updates, ExitCode: exitCodeOf(err), DurationMS: time.Since(start).Milliseconds()})
 	} else {
 		s.log.Info("git fetch", "identity", identity, "rig", rig)
 	}
 }
 
+// auditPush appends a receive-pack to the audit journal, logging failures.
+func (s *Server) auditPush(identity string, rec AuditPush) {
+	if err := s.audit.AppendPush(identity, rec); err != nil {
+		s.log.Error("audit journal append failed", "identity", identity, "rig", rec.Rig, "err", err)
+	}
+}
+
 // authorizeReceivePack checks that the push only touches refs/heads/polecat/<cn-name>-*.
-// It reads the pkt-line stream to extract ref names, then rewinds the body.
-// It returns (true, refs) on success, or (false, refs) on failure; refs may be
-// non-nil on failure when the body was read but contained a disallowed ref.
-func (s *Server) authorizeReceivePack(w http.ResponseWriter, r *http.Request, clientCN string) (bool, []string) {
+// It reads the pkt-line stream to extract the ref updates, then rewinds the body.
+// It returns (true, updates) on success, or (false, updates) on failure; updates
+// may be non-nil on failure when the body was read but contained a disallowed ref.
+func (s *Server) authorizeReceivePack(w http.ResponseWriter, r *http.Request, clientCN string) (bool, []RefUpdate) {
 	// Issue 8: Use the shared polecatName helper instead of reimplementing CN parsing.
 	cnName := polecatName(clientCN)
 	if cnName == "" {
@@ -253,25 +265,25 @@ func (s *Server) authorizeReceivePack(w http.ResponseWriter, r *http.Request, cl
 
 	pktBytes := pktBuf.Bytes()
 
-	// Collect refs before validation so they are available for audit logging even
-	// when authorization is denied.
-	refs := collectReceivePackRefs(pktBytes)
+	// Collect updates before validation so they are available for audit logging
+	// even when authorization is denied.
+	updates := collectReceivePackUpdates(pktBytes)
 
 	if err := validateReceivePackRefs(pktBytes, cnName); err != nil {
 		http.Error(w, err.Error(), http.StatusForbidden)
-		return false, refs
+		return false, updates
 	}
 
 	// Reconstruct body: pkt-line prefix + remaining pack data (streamed).
 	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(pktBytes), r.Body))
-	return true, refs
+	return true, updates
 }
 
-// collectReceivePackRefs parses the git-receive-pack pkt-line stream and returns
-// all ref names found, without validating whether they are authorized.
+// collectReceivePackUpdates parses the git-receive-pack pkt-line stream and
+// returns all ref updates found, without validating whether they are authorized.
 // Used solely for audit logging; validation is handled by validateReceivePackRefs.
-func collectReceivePackRefs(body []byte) []string {
-	var refs []string
+func collectReceivePackUpdates(body []byte) []RefUpdate {
+	var updates []RefUpdate
 	offset := 0
 	for offset < len(body) {
 		if offset+4 > len(body) {
@@ -301,7 +313,16 @@ func collectReceivePackRefs(body []byte) []string {
 		if len(parts) < 3 {
 			continue
 		}
-		refs = append(refs, string(parts[2]))
+		updates = append(updates, RefUpdate{Ref: string(parts[2]), Old: string(parts[0]), New: string(parts[1])})
+	}
+	return updates
+}
+
+// refNames returns the ref names of updates, for log lines.
+func refNames(updates []RefUpdate) []string {
+	var refs []string
+	for _, u := range updates {
+		refs = append(refs, u.Ref)
 	}
 	return refs
 }
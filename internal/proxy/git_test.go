@@ -200,10 +200,14 @@ func TestAuthorizeReceivePack(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest("POST", "/v1/git/rig/git-receive-pack",
 			bytes.NewReader(body))
-		ok, refs := srv.authorizeReceivePack(rec, req, cn)
+		ok, updates := srv.authorizeReceivePack(rec, req, cn)
 
 		require.True(t, ok)
-		assert.Equal(t, []string{"refs/heads/polecat/furiosa-abc123"}, refs)
+		assert.Equal(t, []RefUpdate{{
+			Ref: "refs/heads/polecat/furiosa-abc123",
+			Old: strings.Repeat("0", 40),
+			New: strings.Repeat("a", 40),
+		}}, updates)
 		// Verify body was rewound so git can re-read it.
 		rewound, err := io.ReadAll(req.Body)
 		require.NoError(t, err)
@@ -217,12 +221,12 @@ func TestAuthorizeReceivePack(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest("POST", "/v1/git/rig/git-receive-pack",
 			bytes.NewReader(body))
-		ok, refs := srv.authorizeReceivePack(rec, req, cn)
+		ok, updates := srv.authorizeReceivePack(rec, req, cn)
 
 		assert.False(t, ok)
 		assert.Equal(t, http.StatusForbidden, rec.Code)
 		// Refs are still returned even on denial (for audit logging).
-		assert.Equal(t, []string{"refs/heads/main"}, refs)
+		assert.Equal(t, []string{"refs/heads/main"}, refNames(updates))
 	})
 
 	t.Run("body read error returns 400", func(t *testing.T) {
@@ -231,9 +235,9 @@ func TestAuthorizeReceivePack(t *testing.T) {
 		req := httptest.NewRequest("POST", "/v1/git/rig/git-receive-pack", nil)
 		req.Body = errReadCloser{err: fmt.Errorf("simulated read error")}
 
-		ok, refs := srv.authorizeReceivePack(rec, req, cn)
+		ok, updates := srv.authorizeReceivePack(rec, req, cn)
 		assert.False(t, ok)
-		assert.Nil(t, refs)
+		assert.Nil(t, updates)
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
@@ -0,0 +1,433 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// PolicyPath returns the default location of the exec policy file for a town.
+func PolicyPath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "proxy", "policy.json")
+}
+
+// Policy effects.
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+)
+
+// Policy is a per-identity exec policy, evaluated for every /v1/exec and
+// /v1/exec/stream request after the command and subcommand allowlists pass.
+// It can only narrow what the allowlists permit.
+//
+// Rules are tried in order and the first one that matches decides. A rule
+// matches when the caller's identity matches one of Identities, argv matches
+// Argv, and all of the rule's argument constraints hold; a rule whose
+// constraints fail is skipped, so an allow rule with constraints followed by
+// a broader deny rule expresses "only ...".
+type Policy struct {
+	// Default is the effect when no rule matches: "allow" (the default) or "deny".
+	Default string `json:"default,omitempty"`
+	// DefaultReason is reported when Default is "deny".
+	DefaultReason string `json:"default_reason,omitempty"`
+	// RateClasses are named per-identity rate limits that rules can assign.
+	RateClasses map[string]RateClass `json:"rate_classes,omitempty"`
+	Rules       []PolicyRule         `json:"rules"`
+}
+
+// RateClass is a per-identity token bucket. Requests assigned to a class are
+// limited by it instead of the server-wide exec rate limit.
+type RateClass struct {
+	Rate  float64 `json:"rate"`  // sustained requests per second
+	Burst int     `json:"burst"` // bucket size
+}
+
+// PolicyRule is one policy rule.
+//
+// Patterns are globs where "*" matches any run of characters (including "/")
+// and "?" any one character. They may use the placeholders {identity}
+// ("<rig>/<name>"), {rig}, {name}, and {prefix} (the bead prefix of the
+// caller's rig, without the trailing "-"); a rule using a placeholder never
+// matches a caller without an identity.
+type PolicyRule struct {
+	Name string `json:"name"`
+	// Identities are patterns for the caller's "<rig>/<name>" identity.
+	// Empty matches every caller, including one without an identity.
+	Identities []string `json:"identities,omitempty"`
+	// Argv is a pattern per argument; a final "**" matches any remaining
+	// arguments (the rule's tail). Without "**" the argument count must match.
+	Argv []string `json:"argv"`
+	// Operands, when set, must match every non-flag argument in the tail.
+	Operands []string `json:"operands,omitempty"`
+	// Flags constrains flag values in the tail: each occurrence of the flag
+	// ("--to x" or "--to=x") must have a value matching one of the patterns.
+	Flags map[string][]string `json:"flags,omitempty"`
+	// ValueFlags lists other flags that take a value, so that value is not
+	// mistaken for an operand.
+	ValueFlags []string `json:"value_flags,omitempty"`
+	// BeadPrefixes, when set, restricts bead IDs in the tail (operands and
+	// flag values whose prefix is a routed bead prefix in the town) to these
+	// prefixes, e.g. ["{prefix}", "hq"] for "only beads in my rig".
+	BeadPrefixes []string `json:"bead_prefixes,omitempty"`
+	// Effect is "allow" or "deny".
+	Effect string `json:"effect"`
+	// Reason is returned to the caller when the rule denies.
+	Reason string `json:"reason,omitempty"`
+	// RateClass names an entry of Policy.RateClasses to rate limit allowed
+	// requests with.
+	RateClass string `json:"rate_class,omitempty"`
+}
+
+// PolicyDecision is the outcome of evaluating a request against a Policy.
+type PolicyDecision struct {
+	Allow bool `json:"allow"`
+	// Rule is the name of the deciding rule; empty when the default applied.
+	Rule      string `json:"rule,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	RateClass string `json:"rate_class,omitempty"`
+}
+
+// LoadPolicy reads and validates the policy file at path. A missing file
+// returns (nil, nil): no policy, every allowlisted request is allowed.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is the operator-configured policy file
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy decodes and validates a policy document.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	switch p.Default {
+	case "", PolicyAllow, PolicyDeny:
+	default:
+		return fmt.Errorf("default: want %q or %q, got %q", PolicyAllow, PolicyDeny, p.Default)
+	}
+	for name, rc := range p.RateClasses {
+		if rc.Rate <= 0 || rc.Burst <= 0 {
+			return fmt.Errorf("rate class %q: rate and burst must be positive", name)
+		}
+	}
+	for i, r := range p.Rules {
+		where := fmt.Sprintf("rule %d", i+1)
+		if r.Name != "" {
+			where = fmt.Sprintf("rule %q", r.Name)
+		}
+		switch r.Effect {
+		case PolicyAllow, PolicyDeny:
+		default:
+			return fmt.Errorf("%s: effect: want %q or %q, got %q", where, PolicyAllow, PolicyDeny, r.Effect)
+		}
+		if len(r.Argv) == 0 {
+			return fmt.Errorf("%s: argv is empty", where)
+		}
+		for j, tok := range r.Argv {
+			if tok == "**" && j != len(r.Argv)-1 {
+				return fmt.Errorf("%s: \"**\" must be the last argv pattern", where)
+			}
+		}
+		hasTail := r.Argv[len(r.Argv)-1] == "**"
+		if !hasTail && (r.Operands != nil || r.Flags != nil || r.ValueFlags != nil || r.BeadPrefixes != nil) {
+			return fmt.Errorf("%s: operands, flags, value_flags and bead_prefixes need argv ending in \"**\"", where)
+		}
+		if r.RateClass != "" {
+			if r.Effect != PolicyAllow {
+				return fmt.Errorf("%s: rate_class only applies to allow rules", where)
+			}
+			if _, ok := p.RateClasses[r.RateClass]; !ok {
+				return fmt.Errorf("%s: unknown rate class %q", where, r.RateClass)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate decides whether identity ("<rig>/<name>", or "" when the client
+// cert has none) may run argv. townRoot locates the bead routes used by
+// {prefix} and bead_prefixes.
+func (p *Policy) Evaluate(townRoot, identity string, argv []string) PolicyDecision {
+	if p == nil {
+		return PolicyDecision{Allow: true}
+	}
+	subj := newPolicySubject(townRoot, identity)
+	for _, r := range p.Rules {
+		if !r.matches(subj, argv) {
+			continue
+		}
+		if r.Effect == PolicyDeny {
+			reason := r.Reason
+			if reason == "" && r.Name != "" {
+				reason = "denied by rule " + r.Name
+			} else if reason == "" {
+				reason = "denied by policy rule"
+			}
+			return PolicyDecision{Rule: r.Name, Reason: reason}
+		}
+		return PolicyDecision{Allow: true, Rule: r.Name, RateClass: r.RateClass}
+	}
+	if p.Default == PolicyDeny {
+		reason := p.DefaultReason
+		if reason == "" {
+			reason = "no policy rule allows this command"
+		}
+		return PolicyDecision{Reason: reason}
+	}
+	return PolicyDecision{Allow: true}
+}
+
+// policySubject is the caller a policy is evaluated for. Bead routes are
+// loaded lazily, only for rules that need them.
+type policySubject struct {
+	townRoot       string
+	identity       string
+	rig, name      string
+	prefix         *string
+	routedPrefixes map[string]bool
+}
+
+func newPolicySubject(townRoot, identity string) *policySubject {
+	s := &policySubject{townRoot: townRoot, identity: identity}
+	if rig, name, ok := strings.Cut(identity, "/"); ok {
+		s.rig, s.name = rig, name
+	}
+	return s
+}
+
+// expand substitutes placeholders in pattern. It reports false if the
+// pattern uses a placeholder the subject can't fill.
+func (s *policySubject) expand(pattern string) (string, bool) {
+	if !strings.Contains(pattern, "{") {
+		return pattern, true
+	}
+	if s.identity == "" {
+		return "", false
+	}
+	if strings.Contains(pattern, "{prefix}") {
+		if s.prefix == nil {
+			prefix := beads.GetPrefixForRig(s.townRoot, s.rig)
+			s.prefix = &prefix
+		}
+		pattern = strings.ReplaceAll(pattern, "{prefix}", *s.prefix)
+	}
+	return strings.NewReplacer(
+		"{identity}", s.identity,
+		"{rig}", s.rig,
+		"{name}", s.name,
+	).Replace(pattern), true
+}
+
+// match reports whether value matches any of patterns after expansion.
+func (s *policySubject) match(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if p, ok := s.expand(p); ok && globMatch(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRoutedBead reports whether tok looks like a bead ID whose prefix is
+// routed in the town, and returns that prefix without the trailing "-".
+func (s *policySubject) isRoutedBead(tok string) (string, bool) {
+	prefix := strings.TrimSuffix(beads.ExtractPrefix(tok), "-")
+	if prefix == "" || len(tok) == len(prefix)+1 {
+		return "", false
+	}
+	if s.routedPrefixes == nil {
+		s.routedPrefixes = make(map[string]bool)
+		routes, _ := beads.LoadRoutes(filepath.Join(s.townRoot, ".beads"))
+		for _, r := range routes {
+			s.routedPrefixes[strings.TrimSuffix(r.Prefix, "-")] = true
+		}
+	}
+	return prefix, s.routedPrefixes[prefix]
+}
+
+func (r *PolicyRule) matches(s *policySubject, argv []string) bool {
+	if len(r.Identities) > 0 && (s.identity == "" || !s.match(r.Identities, s.identity)) {
+		return false
+	}
+	fixed := r.Argv
+	hasTail := fixed[len(fixed)-1] == "**"
+	if hasTail {
+		fixed = fixed[:len(fixed)-1]
+	}
+	if len(argv) < len(fixed) || (!hasTail && len(argv) != len(fixed)) {
+		return false
+	}
+	for i, pat := range fixed {
+		if !s.match([]string{pat}, argv[i]) {
+			return false
+		}
+	}
+	if !hasTail {
+		return true
+	}
+	return r.tailAllowed(s, argv[len(fixed):])
+}
+
+// tailAllowed checks the rule's argument constraints against the arguments
+// matched by "**".
+func (r *PolicyRule) tailAllowed(s *policySubject, tail []string) bool {
+	beadOK := func(tok string) bool {
+		if r.BeadPrefixes == nil {
+			return true
+		}
+		prefix, ok := s.isRoutedBead(tok)
+		return !ok || s.match(r.BeadPrefixes, prefix)
+	}
+	flagsDone := false
+	for i := 0; i < len(tail); i++ {
+		arg := tail[i]
+		if !flagsDone && arg == "--" {
+			flagsDone = true
+			continue
+		}
+		if !flagsDone && len(arg) > 1 && arg[0] == '-' {
+			name, value, hasValue := strings.Cut(arg, "=")
+			allowed, constrained := r.Flags[name]
+			if !hasValue && (constrained || containsString(r.ValueFlags, name)) && i+1 < len(tail) {
+				i++
+				value, hasValue = tail[i], true
+			}
+			if constrained && (!hasValue || !s.match(allowed, value)) {
+				return false
+			}
+			if hasValue && !beadOK(value) {
+				return false
+			}
+			continue
+		}
+		if r.Operands != nil && !s.match(r.Operands, arg) {
+			return false
+		}
+		if !beadOK(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch reports whether s matches pattern, where "*" matches any run of
+// characters (including "/") and "?" matches exactly one.
+func globMatch(pattern, s string) bool {
+	px, sx := 0, 0
+	starP, starS := -1, 0
+	for sx < len(s) {
+		switch {
+		case px < len(pattern) && pattern[px] == '*':
+			starP, starS = px, sx
+			px++
+		case px < len(pattern) && (pattern[px] == '?' || pattern[px] == s[sx]):
+			px++
+			sx++
+		case starP >= 0:
+			starS++
+			px, sx = starP+1, starS
+		default:
+			return false
+		}
+	}
+	for px < len(pattern) && pattern[px] == '*' {
+		px++
+	}
+	return px == len(pattern)
+}
+
+// policyStore holds the server's policy, reloading it when the file changes
+// so operators can edit it without restarting the proxy.
+type policyStore struct {
+	path string
+
+	mu      sync.Mutex
+	policy  *Policy
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// newPolicyStore loads the policy at path. An invalid policy is an error:
+// starting without it would allow everything it denies.
+func newPolicyStore(path string) (*policyStore, error) {
+	ps := &policyStore{path: path}
+	if _, err := ps.reload(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// current returns the policy in effect, reloading it if the file changed.
+// If the changed file is invalid, the previous policy stays in effect and
+// the error is returned alongside it.
+func (ps *policyStore) current() (*Policy, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	fi, err := os.Stat(ps.path)
+	exists := err == nil
+	if exists == ps.exists && (!exists || (fi.ModTime().Equal(ps.modTime) && fi.Size() == ps.size)) {
+		return ps.policy, nil
+	}
+	_, err = ps.reloadLocked()
+	return ps.policy, err
+}
+
+func (ps *policyStore) reload() (*Policy, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.reloadLocked()
+}
+
+func (ps *policyStore) reloadLocked() (*Policy, error) {
+	// Stat before reading so a write racing the read is picked up next time.
+	fi, statErr := os.Stat(ps.path)
+	p, err := LoadPolicy(ps.path)
+	if statErr == nil {
+		ps.modTime, ps.size, ps.exists = fi.ModTime(), fi.Size(), true
+	} else {
+		ps.modTime, ps.size, ps.exists = time.Time{}, 0, false
+	}
+	if err != nil {
+		return ps.policy, err
+	}
+	ps.policy = p
+	return p, nil
+}
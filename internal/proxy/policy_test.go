@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPolicy restricts mail to the caller's witness, beads to its own rig
+// (plus town beads), and puts "echo status" in a slow rate class.
+const testPolicy = `{
+  "rate_classes": {"slow": {"rate": 0.001, "burst": 1}},
+  "rules": [
+    {
+      "name": "mail-own-witness",
+      "identities": ["*/*"],
+      "argv": ["gt", "mail", "send", "**"],
+      "operands": ["{rig}/witness"],
+      "flags": {"--to": ["{rig}/witness"], "--cc": ["{rig}/witness"]},
+      "value_flags": ["-s", "--subject", "-m", "--message"],
+      "effect": "allow"
+    },
+    {
+      "name": "mail-other",
+      "argv": ["gt", "mail", "send", "**"],
+      "effect": "deny",
+      "reason": "polecats may only mail their witness"
+    },
+    {
+      "name": "beads-own-rig",
+      "argv": ["bd", "*", "**"],
+      "bead_prefixes": ["{prefix}", "hq"],
+      "value_flags": ["--status", "--parent"],
+      "effect": "allow"
+    },
+    {
+      "name": "beads-other-rig",
+      "argv": ["bd", "**"],
+      "effect": "deny",
+      "reason": "only beads in your rig"
+    },
+    {
+      "name": "status-slow",
+      "argv": ["echo", "status"],
+      "effect": "allow",
+      "rate_class": "slow"
+    },
+    {
+      "name": "no-nuke",
+      "identities": ["gastown/*"],
+      "argv": ["gt", "nuke*", "**"],
+      "effect": "deny"
+    }
+  ]
+}`
+
+// writeTestTown writes bead routes for two rigs and returns the town root.
+func writeTestTown(t *testing.T) string {
+	t.Helper()
+	town := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(town, ".beads"), 0o755))
+	routes := `{"prefix":"hq-","path":"."}
+{"prefix":"gt-","path":"gastown/mayor/rig"}
+{"prefix":"bd-","path":"beads/mayor/rig"}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(town, ".beads", "routes.jsonl"), []byte(routes), 0o644))
+	return town
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	town := writeTestTown(t)
+	p, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		identity string
+		argv     string
+		allow    bool
+		rule     string
+	}{
+		{"mail to own witness", "gastown/nux", "gt mail send gastown/witness -s hi -m body", true, "mail-own-witness"},
+		{"mail to own witness via --to", "gastown/nux", "gt mail send --to=gastown/witness -s hi", true, "mail-own-witness"},
+		{"subject is not an operand", "gastown/nux", "gt mail send gastown/witness --subject mayor/", true, "mail-own-witness"},
+		{"mail to mayor", "gastown/nux", "gt mail send mayor/ -s hi", false, "mail-other"},
+		{"mail to other rig's witness", "gastown/nux", "gt mail send beads/witness", false, "mail-other"},
+		{"cc outside rig", "gastown/nux", "gt mail send gastown/witness --cc mayor/", false, "mail-other"},
+		{"mail without identity", "", "gt mail send gastown/witness", false, "mail-other"},
+		{"own rig bead", "gastown/nux", "bd update gt-abc --status in_progress", true, "beads-own-rig"},
+		{"town bead", "gastown/nux", "bd show hq-xyz", true, "beads-own-rig"},
+		{"other rig bead", "gastown/nux", "bd close bd-123", false, "beads-other-rig"},
+		{"other rig bead as flag value", "gastown/nux", "bd update gt-abc --parent=bd-9", false, "beads-other-rig"},
+		{"unrouted prefix is not a bead", "gastown/nux", "bd list --status in-progress", true, "beads-own-rig"},
+		{"other rig's own beads", "beads/max", "bd close bd-123", true, "beads-own-rig"},
+		{"identity-scoped deny", "gastown/nux", "gt nuke --force", false, "no-nuke"},
+		{"identity-scoped deny skips others", "beads/max", "gt nuke --force", true, ""},
+		{"no rule matches", "gastown/nux", "gt prime", true, ""},
+		{"rate class", "gastown/nux", "echo status", true, "status-slow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(town, tt.identity, strings.Fields(tt.argv))
+			assert.Equal(t, tt.allow, d.Allow, "decision %+v", d)
+			assert.Equal(t, tt.rule, d.Rule)
+			if !tt.allow {
+				assert.NotEmpty(t, d.Reason)
+			}
+		})
+	}
+
+	d := p.Evaluate(town, "gastown/nux", []string{"gt", "mail", "send", "mayor/"})
+	assert.Equal(t, "polecats may only mail their witness", d.Reason)
+	d = p.Evaluate(town, "gastown/nux", []string{"echo", "status"})
+	assert.Equal(t, "slow", d.RateClass)
+}
+
+func TestPolicyDefaultDeny(t *testing.T) {
+	p, err := ParsePolicy([]byte(`{"default":"deny","default_reason":"not on the list","rules":[
+		{"name":"prime","argv":["gt","prime"],"effect":"allow"}]}`))
+	require.NoError(t, err)
+	assert.True(t, p.Evaluate("", "gastown/nux", []string{"gt", "prime"}).Allow)
+	assert.False(t, p.Evaluate("", "gastown/nux", []string{"gt", "prime", "--extra"}).Allow)
+	d := p.Evaluate("", "gastown/nux", []string{"gt", "done"})
+	assert.False(t, d.Allow)
+	assert.Equal(t, "not on the list", d.Reason)
+
+	var nilPolicy *Policy
+	assert.True(t, nilPolicy.Evaluate("", "", []string{"gt", "done"}).Allow)
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown field":       `{"rulez":[]}`,
+		"bad default":         `{"default":"maybe","rules":[]}`,
+		"bad effect":          `{"rules":[{"argv":["gt"],"effect":"permit"}]}`,
+		"empty argv":          `{"rules":[{"argv":[],"effect":"allow"}]}`,
+		"** not last":         `{"rules":[{"argv":["gt","**","x"],"effect":"allow"}]}`,
+		"operands without **": `{"rules":[{"argv":["gt","mail"],"operands":["x"],"effect":"allow"}]}`,
+		"unknown rate class":  `{"rules":[{"argv":["gt"],"effect":"allow","rate_class":"fast"}]}`,
+		"rate class on deny":  `{"rate_classes":{"c":{"rate":1,"burst":1}},"rules":[{"argv":["gt"],"effect":"deny","rate_class":"c"}]}`,
+		"non-positive rate":   `{"rate_classes":{"c":{"rate":0,"burst":1}},"rules":[]}`,
+		"truncated":           `{"rules":[`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(doc))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "gastown/witness", true},
+		{"gastown/*", "gastown/nux", true},
+		{"gastown/*", "beads/nux", false},
+		{"nuke*", "nuke", true},
+		{"gt-?", "gt-a", true},
+		{"gt-?", "gt-ab", false},
+		{"*-*-x", "a-b-c-x", true},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	} {
+		assert.Equal(t, tt.want, globMatch(tt.pattern, tt.s), "globMatch(%q, %q)", tt.pattern, tt.s)
+	}
+}
+
+func TestPolicyEnforcedByExec(t *testing.T) {
+	town := writeTestTown(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(town, ".runtime", "proxy"), 0o755))
+	policyPath := PolicyPath(town)
+	require.NoError(t, os.WriteFile(policyPath, []byte(testPolicy), 0o644))
+
+	srv, err := New(Config{AllowedCommands: []string{"echo"}, TownRoot: town, Logger: discardLogger()}, nil)
+	require.NoError(t, err)
+
+	exec := func(argv, cn string) *httptest.ResponseRecorder {
+		body := `{"argv":["` + strings.Join(strings.Fields(argv), `","`) + `"]}`
+		rec := httptest.NewRecorder()
+		srv.handleExec(rec, makeFakeRequest("POST", "/v1/exec", body, cn))
+		return rec
+	}
+
+	// echo status is in a class with burst 1: the second call is limited even
+	// though the server-wide limit would allow it.
+	assert.Equal(t, http.StatusOK, exec("echo status", "gt-gastown-nux").Code)
+	assert.Equal(t, http.StatusTooManyRequests, exec("echo status", "gt-gastown-nux").Code)
+	assert.Equal(t, http.StatusOK, exec("echo other", "gt-gastown-nux").Code)
+
+	// Editing the policy takes effect without a restart.
+	denyEcho := `{"rules":[{"name":"no-echo","argv":["echo","**"],"effect":"deny","reason":"echo is retired"}]}`
+	require.NoError(t, os.WriteFile(policyPath, []byte(denyEcho), 0o644))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(policyPath, future, future))
+	rec := exec("echo other", "gt-gastown-nux")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "echo is retired")
+
+	// A broken edit keeps the previous policy in force.
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{"rules":[`), 0o644))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(policyPath, future, future))
+	assert.Equal(t, http.StatusForbidden, exec("echo other", "gt-gastown-nux").Code)
+
+	// An invalid policy at startup is fatal rather than silently allowing all.
+	_, err = New(Config{AllowedCommands: []string{"echo"}, TownRoot: town, Logger: discardLogger()}, nil)
+	assert.Error(t, err)
+}
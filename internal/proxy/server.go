@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -58,6 +59,22 @@ type Config struct {
 	// ExecTimeout is the maximum duration a single exec subprocess may run.
 	// 0 uses the default (60s). Use a negative value to disable the timeout.
 	ExecTimeout time.Duration
+	// PolicyPath is the per-identity exec policy file (see Policy). Empty uses
+	// PolicyPath(TownRoot); a missing file means no policy.
+	PolicyPath string
+	// AuditPath is the hash-chained audit journal of execs and pushes (see
+	// AuditJournal). Empty uses AuditJournalPath(TownRoot).
+	AuditPath string
+	// SocketPath, if set, is a unix socket served alongside ListenAddr with
+	// the same mTLS handler. Sandboxed polecats without a network namespace
+	// of their own reach the proxy through it. The socket is created mode
+	// 0600; a stale socket file is replaced.
+	SocketPath string
+}
+
+// SocketPath returns the default location of the proxy's unix socket.
+func SocketPath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "proxy", "proxy.sock")
 }
 
 // Server is an mTLS HTTP proxy server.
@@ -69,6 +86,13 @@ type Server struct {
 	resolvedPaths map[string]string
 	log           *slog.Logger
 	denyList      *DenyList
+	// ledger persists issued and revoked certs under the town root; denyList
+	// is rebuilt from it on start and kept in sync with it while running.
+	ledger *CertLedger
+	// policy is the per-identity exec policy, reloaded when its file changes.
+	policy *policyStore
+	// audit is the tamper-evident journal of execs and pushes.
+	audit *AuditJournal
 
 	// execSem is a semaphore limiting global concurrent exec subprocesses.
 	execSem chan struct{}
@@ -82,6 +106,7 @@ type Server struct {
 	lnMu    sync.Mutex
 	ln      net.Listener
 	adminLn net.Listener
+	sockLn  net.Listener
 }
 
 // New creates a new Server with the given config and CA.
@@ -155,7 +180,29 @@ func New(cfg Config, ca *CA) (*Server, error) {
 		et = 60 * time.Second
 	}
 
-	return &Server{
+	policyPath := cfg.PolicyPath
+	if policyPath == "" {
+		policyPath = PolicyPath(cfg.TownRoot)
+	}
+	policy, err := newPolicyStore(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load exec policy: %w", err)
+	}
+
+	auditPath := cfg.AuditPath
+	if auditPath == "" {
+		auditPath = AuditJournalPath(cfg.TownRoot)
+	}
+	var signKey *ecdsa.PrivateKey
+	if ca != nil {
+		signKey = ca.Key
+	}
+	audit, err := OpenAuditJournal(auditPath, signKey)
+	if err != nil {
+		return nil, fmt.Errorf("open audit journal: %w", err)
+	}
+
+	s := &Server{
 		cfg:           cfg,
 		ca:            ca,
 		allowed:       allowed,
@@ -163,11 +210,92 @@ func New(cfg Config, ca *CA) (*Server, error) {
 		resolvedPaths: resolvedPaths,
 		log:           l,
 		denyList:      NewDenyList(),
+		ledger:        OpenCertLedger(CertLedgerPath(cfg.TownRoot)),
+		policy:        policy,
+		audit:         audit,
 		execSem:       make(chan struct{}, maxConcurrent),
 		execTimeout:   et,
 		rateLimit:     rate.Limit(rl),
 		rateBurst:     rb,
-	}, nil
+	}
+
+	// Fail closed: starting without the persisted revocations would accept
+	// every revoked cert again until it expires.
+	n, err := s.loadRevocations()
+	if err != nil {
+		return nil, fmt.Errorf("load revoked certs: %w", err)
+	}
+	if n > 0 {
+		l.Info("loaded revoked certs", "count", n, "ledger", s.ledger.Path())
+	}
+	return s, nil
+}
+
+// revocationSyncInterval is how often a running server re-reads the cert
+// ledger (picking up revocations written by gt proxy certs while the admin
+// API was unreachable) and prunes expired entries.
+const revocationSyncInterval = time.Minute
+
+// loadRevocations adds every revoked cert in the ledger to the deny list and
+// returns how many the ledger holds.
+func (s *Server) loadRevocations() (int, error) {
+	records, err := s.ledger.Records()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, r := range records {
+		if r.Revoked == nil {
+			continue
+		}
+		serial, ok := ParseSerial(r.Serial)
+		if !ok {
+			s.log.Warn("cert ledger: skipping malformed serial", "serial", r.Serial)
+			continue
+		}
+		s.denyList.DenyUntil(serial, r.NotAfter)
+		n++
+	}
+	return n, nil
+}
+
+// checkpointAudit checkpoints the audit journal every auditCheckpointInterval
+// until ctx ends, so that a quiet proxy still seals its recent entries.
+func (s *Server) checkpointAudit(ctx context.Context) {
+	t := time.NewTicker(auditCheckpointInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.audit.Checkpoint(); err != nil {
+				s.log.Error("audit checkpoint failed", "err", err)
+			}
+		}
+	}
+}
+
+// syncRevocations keeps the deny list in step with the ledger until ctx ends.
+func (s *Server) syncRevocations(ctx context.Context) {
+	ticker := time.NewTicker(revocationSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.loadRevocations(); err != nil {
+				s.log.Error("cert ledger: reload failed; keeping current deny list", "err", err)
+			}
+			s.denyList.Prune(now)
+			if n, err := s.ledger.Prune(now); err != nil {
+				s.log.Error("cert ledger: prune failed", "err", err)
+			} else if n > 0 {
+				s.log.Info("cert ledger: pruned expired certs", "count", n)
+			}
+		}
+	}
 }
 
 // Addr returns the address the server is listening on.
@@ -182,6 +310,17 @@ func (s *Server) Addr() net.Addr {
 	return s.ln.Addr()
 }
 
+// SocketAddr returns the unix socket address the server is listening on.
+// Returns nil if no socket was configured or if Start() has not yet bound it.
+func (s *Server) SocketAddr() net.Addr {
+	s.lnMu.Lock()
+	defer s.lnMu.Unlock()
+	if s.sockLn == nil {
+		return nil
+	}
+	return s.sockLn.Addr()
+}
+
 // AdminAddr returns the address the admin server is listening on.
 // Returns nil if no admin server was configured or if Start() has not yet bound
 // the admin listener.
@@ -197,10 +336,33 @@ func (s *Server) AdminAddr() net.Addr {
 // DenyCert adds a certificate serial number to the server's deny list.
 // Any active or future TLS connection presenting a cert with this serial will be
 // rejected at the TLS handshake. This method is safe for concurrent use.
+// The denial lasts for this process only; use RevokeCert to persist it.
 func (s *Server) DenyCert(serial *big.Int) {
 	s.denyList.Deny(serial)
 }
 
+// RevokeCert denies serial and records the revocation in the town's cert
+// ledger, so it survives restarts until the cert's NotAfter. notAfter is only
+// consulted for certs the ledger has no record of; when it is zero as well,
+// the revocation is kept until the CA itself expires.
+func (s *Server) RevokeCert(serial *big.Int, rev Revocation, notAfter time.Time) (CertRecord, error) {
+	if _, known, err := s.ledger.Lookup(serial.Text(16)); err == nil && !known && notAfter.IsZero() {
+		notAfter = s.ca.Cert.NotAfter
+	}
+	if rev.At.IsZero() {
+		rev.At = time.Now()
+	}
+	rec, err := s.ledger.Revoke(serial.Text(16), rev, notAfter)
+	if err != nil {
+		// Deny for this process regardless; the caller reports the
+		// persistence failure.
+		s.denyList.Deny(serial)
+		return CertRecord{}, err
+	}
+	s.denyList.DenyUntil(serial, rec.NotAfter)
+	return rec, nil
+}
+
 // Start begins listening and serving. Blocks until ctx is canceled.
 func (s *Server) Start(ctx context.Context) error {
 	pool := x509.NewCertPool()
@@ -231,6 +393,7 @@ func (s *Server) Start(ctx context.Context) error {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/exec", s.handleExec)
+	mux.HandleFunc("/v1/exec/stream", s.handleExecStream)
 	mux.HandleFunc("/v1/git/", s.handleGit)
 
 	srv := &http.Server{
@@ -271,13 +434,35 @@ func (s *Server) Start(ctx context.Context) error {
 	s.ln = ln
 	s.lnMu.Unlock()
 
-	errCh := make(chan error, 1)
+	var sockLn net.Listener
+	if s.cfg.SocketPath != "" {
+		if sockLn, err = listenUnix(s.cfg.SocketPath); err != nil {
+			_ = ln.Close()
+			return err
+		}
+		s.lnMu.Lock()
+		s.sockLn = sockLn
+		s.lnMu.Unlock()
+	}
+
+	go s.syncRevocations(ctx)
+	go s.checkpointAudit(ctx)
+
+	errCh := make(chan error, 2)
 	go func() {
 		s.log.Info("gt-proxy-server: listening", "addr", ln.Addr(), "tls", "mTLS")
 		if err := srv.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 	}()
+	if sockLn != nil {
+		go func() {
+			s.log.Info("gt-proxy-server: listening", "socket", sockLn.Addr(), "tls", "mTLS")
+			if err := srv.ServeTLS(sockLn, "", ""); err != nil && err != http.ErrServerClosed {
+				errCh <- err
+			}
+		}()
+	}
 
 	// Start the local admin HTTP server if configured. The admin server does not
 	// use TLS because it is intended only for same-host operator tools (witness,
@@ -288,6 +473,8 @@ func (s *Server) Start(ctx context.Context) error {
 		adminMux := http.NewServeMux()
 		adminMux.HandleFunc("/v1/admin/deny-cert", s.handleDenyCert)
 		adminMux.HandleFunc("/v1/admin/issue-cert", s.handleIssueCert)
+		adminMux.HandleFunc("/v1/admin/certs", s.handleListCerts)
+		adminMux.HandleFunc("/v1/admin/certs/", s.handleShowCert)
 
 		adminSrv = &http.Server{
 			Addr:         s.cfg.AdminListenAddr,
@@ -321,12 +508,43 @@ func (s *Server) Start(ctx context.Context) error {
 		if adminSrv != nil {
 			_ = adminSrv.Shutdown(shutCtx)
 		}
-		return srv.Shutdown(shutCtx)
+		err := srv.Shutdown(shutCtx)
+		// Seal whatever the drained requests appended.
+		if cerr := s.audit.Checkpoint(); cerr != nil {
+			s.log.Error("audit checkpoint failed", "err", cerr)
+		}
+		return err
 	case err := <-errCh:
 		return err
 	}
 }
 
+// listenUnix listens on the unix socket at path with mode 0600, replacing a
+// stale socket left by an earlier run. Anything other than a socket at path
+// is left alone and reported as an error.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("socket path %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("socket dir: %w", err)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen unix: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return ln, nil
+}
+
 // serverListenIPs returns the IP addresses that should be included as IP SANs in the
 // server certificate. It parses the host portion of listenAddr and:
 //   - If it is a specific non-loopback IP, returns [that IP, 127.0.0.1, ::1].
@@ -466,6 +684,10 @@ func (s *Server) handleIssueCert(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.log.Info("cert issued via admin API", "cn", cn, "serial", leaf.SerialNumber.Text(16))
+	if err := s.ledger.RecordIssued(leaf, time.Now()); err != nil {
+		// The cert is valid either way; it just won't show in gt proxy certs list.
+		s.log.Warn("cert ledger: failed to record issued cert", "serial", leaf.SerialNumber.Text(16), "err", err)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(issueCertResponse{
 		CN:        cn,
@@ -479,8 +701,21 @@ func (s *Server) handleIssueCert(w http.ResponseWriter, r *http.Request) {
 
 // denyCertRequest is the JSON body for POST /v1/admin/deny-cert.
 type denyCertRequest struct {
-	// Serial is the certificate serial number in lowercase hexadecimal (no "0x" prefix).
+	// Serial is the certificate serial number in hexadecimal.
 	Serial string `json:"serial"`
+	// Reason is a free-form note recorded with the revocation.
+	Reason string `json:"reason,omitempty"`
+	// RevokedBy identifies the operator or agent revoking the cert.
+	RevokedBy string `json:"revoked_by,omitempty"`
+	// NotAfter is the cert's expiry (RFC 3339), for certs the proxy did not
+	// issue. The revocation is kept until then; if omitted for such a cert,
+	// it is kept until the CA expires.
+	NotAfter string `json:"not_after,omitempty"`
+}
+
+// certListResponse is the JSON response for GET /v1/admin/certs.
+type certListResponse struct {
+	Certs []CertRecord `json:"certs"`
 }
 
 // handleDenyCert handles POST /v1/admin/deny-cert on the local admin server.
@@ -506,17 +741,75 @@ func (s *Server) handleDenyCert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serial := new(big.Int)
-	if _, ok := serial.SetString(req.Serial, 16); !ok {
-		http.Error(w, "bad request: serial must be lowercase hex (no 0x prefix)", http.StatusBadRequest)
+	serial, ok := ParseSerial(req.Serial)
+	if !ok {
+		http.Error(w, "bad request: serial must be a positive hex number", http.StatusBadRequest)
 		return
 	}
+	var notAfter time.Time
+	if req.NotAfter != "" {
+		t, err := time.Parse(time.RFC3339, req.NotAfter)
+		if err != nil {
+			http.Error(w, "bad request: invalid not_after: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		notAfter = t
+	}
 
-	s.denyList.Deny(serial)
-	s.log.Info("cert revoked via admin API", "serial", req.Serial)
+	rec, err := s.RevokeCert(serial, Revocation{By: req.RevokedBy, Reason: req.Reason}, notAfter)
+	if err != nil {
+		s.log.Error("cert revoked for this process only: ledger write failed", "serial", serial.Text(16), "err", err)
+		http.Error(w, "cert denied until restart, but persisting the revocation failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.log.Info("cert revoked via admin API", "serial", rec.Serial, "cn", rec.CN,
+		"by", req.RevokedBy, "reason", req.Reason, "until", rec.NotAfter.Format(time.RFC3339))
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleListCerts handles GET /v1/admin/certs on the local admin server.
+// It returns the unexpired issued and revoked certs from the ledger.
+func (s *Server) handleListCerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	records, err := s.ledger.Records()
+	if err != nil {
+		http.Error(w, "internal error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []CertRecord{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(certListResponse{Certs: records})
+}
+
+// handleShowCert handles GET /v1/admin/certs/<serial> on the local admin server.
+func (s *Server) handleShowCert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	serial, ok := ParseSerial(strings.TrimPrefix(r.URL.Path, "/v1/admin/certs/"))
+	if !ok {
+		http.Error(w, "bad request: serial must be a positive hex number", http.StatusBadRequest)
+		return
+	}
+	rec, found, err := s.ledger.Lookup(serial.Text(16))
+	if err != nil {
+		http.Error(w, "internal error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "not found: no unexpired cert with that serial in the ledger", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rec)
+}
+
 // minimalEnv returns a minimal environment for git and gt/bd subprocesses,
 // containing only HOME and PATH to avoid leaking server credentials.
 // GIT_EXEC_PATH is intentionally omitted: the git binary resolves it
@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -834,3 +835,156 @@ func TestAdminIssueCertEndpoint(t *testing.T) {
 		assert.WithinDuration(t, expectedExpiry, expiry, 5*time.Minute)
 	})
 }
+
+// TestRevocationPersistsAcrossRestart verifies that certs issued and revoked
+// through the admin API are recorded in the town ledger and that a new server
+// for the same town denies the revoked cert without being told again.
+func TestRevocationPersistsAcrossRestart(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+	town := t.TempDir()
+	cfg := Config{
+		ListenAddr:      "127.0.0.1:0",
+		AdminListenAddr: "127.0.0.1:0",
+		AllowedCommands: []string{"echo"},
+		TownRoot:        town,
+		Logger:          discardLogger(),
+	}
+
+	srv, err := New(cfg, ca)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { srv.Start(ctx) }() //nolint:errcheck
+
+	var adminAddr string
+	require.Eventually(t, func() bool {
+		if a := srv.AdminAddr(); a != nil {
+			adminAddr = a.String()
+		}
+		return adminAddr != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	waitForServer(t, adminAddr, 5*time.Second)
+	admin := "http://" + adminAddr
+
+	resp, err := http.Post(admin+"/v1/admin/issue-cert", "application/json",
+		strings.NewReader(`{"rig":"gastown","name":"nux","ttl":"2h"}`))
+	require.NoError(t, err)
+	var issued issueCertResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	resp.Body.Close()
+
+	resp, err = http.Post(admin+"/v1/admin/deny-cert", "application/json",
+		strings.NewReader(`{"serial":"`+issued.Serial+`","reason":"prompt injection","revoked_by":"gastown/witness"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	t.Run("list shows the revocation", func(t *testing.T) {
+		resp, err := http.Get(admin + "/v1/admin/certs")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var list certListResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		require.Len(t, list.Certs, 1)
+		rec := list.Certs[0]
+		assert.Equal(t, "gt-gastown-nux", rec.CN)
+		require.NotNil(t, rec.Revoked)
+		assert.Equal(t, "prompt injection", rec.Revoked.Reason)
+		assert.Equal(t, "gastown/witness", rec.Revoked.By)
+		assert.Equal(t, issued.ExpiresAt, rec.NotAfter.Format(time.RFC3339))
+	})
+
+	t.Run("show by serial", func(t *testing.T) {
+		resp, err := http.Get(admin + "/v1/admin/certs/" + issued.Serial)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp2, err := http.Get(admin + "/v1/admin/certs/abcdef")
+		require.NoError(t, err)
+		defer resp2.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp2.StatusCode)
+	})
+
+	t.Run("a restarted server still denies the cert", func(t *testing.T) {
+		restarted, err := New(cfg, ca)
+		require.NoError(t, err)
+		serial, ok := ParseSerial(issued.Serial)
+		require.True(t, ok)
+		assert.True(t, restarted.denyList.IsDenied(serial))
+	})
+
+	t.Run("a corrupt ledger fails closed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(CertLedgerPath(town), []byte("{not json"), 0600))
+		_, err := New(cfg, ca)
+		assert.Error(t, err)
+	})
+}
+
+func TestStartUnixSocket(t *testing.T) {
+	ca, err := GenerateCA(t.TempDir())
+	require.NoError(t, err)
+
+	// t.TempDir paths can exceed the unix socket path limit.
+	sockDir, err := os.MkdirTemp("", "gtsock")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(sockDir) })
+	sock := filepath.Join(sockDir, "proxy.sock")
+	require.NoError(t, os.WriteFile(sock+".txt", nil, 0o600))
+
+	// A regular file in the socket's place is not clobbered.
+	srv, err := New(Config{
+		ListenAddr:      "127.0.0.1:0",
+		AllowedCommands: []string{"echo"},
+		TownRoot:        t.TempDir(),
+		Logger:          discardLogger(),
+		SocketPath:      sock + ".txt",
+	}, ca)
+	require.NoError(t, err)
+	assert.ErrorContains(t, srv.Start(context.Background()), "not a socket")
+
+	// A stale socket from an earlier run is replaced.
+	stale, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	srv, err = New(Config{
+		ListenAddr:      "127.0.0.1:0",
+		AllowedCommands: []string{"echo"},
+		TownRoot:        t.TempDir(),
+		Logger:          discardLogger(),
+		SocketPath:      sock,
+	}, ca)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = srv.Start(ctx) }()
+	require.Eventually(t, func() bool { return srv.SocketAddr() != nil }, 5*time.Second, 10*time.Millisecond)
+
+	fi, err := os.Stat(sock)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	certPEM, keyPEM, err := ca.IssuePolecat("gt-gastown-nux", time.Hour)
+	require.NoError(t, err)
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{clientCert}, RootCAs: pool},
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+
+	resp, err := client.Post("https://127.0.0.1/v1/exec", "application/json", strings.NewReader(`{"argv":["echo","over the socket"]}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result execResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "over the socket\n", result.Stdout)
+}
@@ -0,0 +1,112 @@
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// bwrapOverlayVersion is the first bubblewrap release with --overlay.
+var bwrapOverlayVersion = [2]int{0, 8}
+
+// resolveBackend returns the backend to run: the configured one, or for
+// "auto" (and "") bwrap when a usable version is installed and the built-in
+// namespace runtime otherwise. needOverlay requires bwrap support for the
+// git overlay.
+func resolveBackend(backend string, needOverlay bool) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("sandbox mode requires Linux")
+	}
+	switch backend {
+	case config.SandboxBackendNamespace:
+		return backend, nil
+	case config.SandboxBackendBwrap:
+		if err := bwrapUsable(needOverlay); err != nil {
+			return "", err
+		}
+		return backend, nil
+	case "", config.SandboxBackendAuto:
+		if bwrapUsable(needOverlay) == nil {
+			return config.SandboxBackendBwrap, nil
+		}
+		return config.SandboxBackendNamespace, nil
+	default:
+		return "", fmt.Errorf("unknown sandbox backend %q", backend)
+	}
+}
+
+// bwrapUsable reports why bwrap cannot run the sandbox, or nil if it can.
+func bwrapUsable(needOverlay bool) error {
+	path, err := exec.LookPath("bwrap")
+	if err != nil {
+		return fmt.Errorf("bwrap not found in PATH")
+	}
+	if !needOverlay {
+		return nil
+	}
+	out, err := exec.Command(path, "--version").Output() //nolint:gosec // bwrap resolved from PATH
+	if err != nil {
+		return fmt.Errorf("bwrap --version: %w", err)
+	}
+	if v, ok := parseBwrapVersion(string(out)); !ok || v[0] < bwrapOverlayVersion[0] ||
+		(v[0] == bwrapOverlayVersion[0] && v[1] < bwrapOverlayVersion[1]) {
+		return fmt.Errorf("bwrap %s lacks --overlay (need %d.%d or later)",
+			strings.TrimSpace(string(out)), bwrapOverlayVersion[0], bwrapOverlayVersion[1])
+	}
+	return nil
+}
+
+// parseBwrapVersion parses "bubblewrap 0.8.0" into {0, 8}.
+func parseBwrapVersion(s string) ([2]int, bool) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return [2]int{}, false
+	}
+	parts := strings.SplitN(fields[len(fields)-1], ".", 3)
+	if len(parts) < 2 {
+		return [2]int{}, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return [2]int{}, false
+	}
+	return [2]int{major, minor}, true
+}
+
+// BwrapArgs returns the bwrap arguments (without argv[0]) that build spec's
+// sandbox and run argv under the sandbox init.
+func BwrapArgs(spec *Spec, argv []string) []string {
+	args := []string{
+		"--die-with-parent",
+		"--unshare-user", "--unshare-ipc", "--unshare-pid", "--unshare-uts", "--unshare-cgroup-try",
+	}
+	if spec.Network != config.SandboxNetworkHost {
+		args = append(args, "--unshare-net")
+	}
+	args = append(args, "--hostname", spec.Hostname)
+	for _, m := range spec.Mounts {
+		switch m.Kind {
+		case MountBind:
+			args = append(args, "--bind", m.Source, m.Target)
+		case MountROBind:
+			args = append(args, "--ro-bind", m.Source, m.Target)
+		case MountOverlay:
+			args = append(args, "--overlay-src", m.Source, "--overlay", m.Upper, m.Work, m.Target)
+		case MountTmpfs:
+			args = append(args, "--tmpfs", m.Target)
+		case MountProc:
+			args = append(args, "--proc", m.Target)
+		case MountDev:
+			args = append(args, "--dev", m.Target)
+		case MountSymlink:
+			args = append(args, "--symlink", m.Source, m.Target)
+		}
+	}
+	args = append(args, "--chdir", spec.WorkDir, "--", BinDir+"/"+InitName)
+	return append(args, argv...)
+}
@@ -0,0 +1,368 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Run starts argv inside spec's sandbox. With bwrap the current process is
+// replaced and Run returns only on error. With the built-in runtime Run
+// waits for the sandbox and returns the agent's exit code.
+func Run(spec *Spec, argv []string) (int, error) {
+	backend, err := resolveBackend(spec.Backend, spec.Overlay)
+	if err != nil {
+		return 0, err
+	}
+	env := spec.Environ(os.Environ())
+	if backend == config.SandboxBackendBwrap {
+		path, err := exec.LookPath("bwrap")
+		if err != nil {
+			return 0, err
+		}
+		args := append([]string{"bwrap"}, BwrapArgs(spec, argv)...)
+		return 0, fmt.Errorf("exec bwrap: %w", syscall.Exec(path, args, env)) //nolint:gosec // bwrap resolved from PATH
+	}
+	return runNamespace(spec, argv, env)
+}
+
+// runNamespace re-executes gt as "gt sandbox init" in new user, mount, PID,
+// IPC and UTS namespaces (plus network, unless the sandbox shares the host
+// network). The process keeps the caller's uid and gid, mapped to themselves,
+// with just enough capabilities in its own user namespace to build the
+// mounts; Init drops them before starting the agent.
+func runNamespace(spec *Spec, argv, env []string) (int, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(self, append([]string{"sandbox", "init", "--spec", spec.SpecPath(), "--"}, argv...)...) //nolint:gosec // re-exec of gt itself
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+
+	flags := uintptr(unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS)
+	if spec.Network != config.SandboxNetworkHost {
+		flags |= unix.CLONE_NEWNET
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:                 flags,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		AmbientCaps:                []uintptr{unix.CAP_SYS_ADMIN, unix.CAP_NET_ADMIN, unix.CAP_SETPCAP},
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+
+	// Interrupts typed at the terminal reach the whole foreground process
+	// group, sandbox included; only relay what is sent to us directly.
+	sigs := make(chan os.Signal, 4)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOSPC) {
+			return 0, fmt.Errorf("creating namespaces: %w (unprivileged user namespaces may be disabled; install bubblewrap)", err)
+		}
+		return 0, fmt.Errorf("creating namespaces: %w", err)
+	}
+	go func() {
+		for sig := range sigs {
+			if sig == syscall.SIGTERM || sig == syscall.SIGHUP {
+				_ = cmd.Process.Signal(sig)
+			}
+		}
+	}()
+	return exitCode(cmd.Wait())
+}
+
+// probeNamespaces reports whether this process may create the built-in
+// runtime's namespaces, by starting "true" in them.
+func probeNamespaces() error {
+	path, err := exec.LookPath("true")
+	if err != nil {
+		return nil // nothing to probe with; Run reports the real error
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	cmd := exec.Command(path) //nolint:gosec // coreutils true resolved from PATH
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cannot create user namespaces: %w (they may be disabled for unprivileged users; install bubblewrap)", err)
+	}
+	return nil
+}
+
+// exitCode converts a Wait error into a shell-style exit code.
+func exitCode(err error) (int, error) {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal()), nil
+		}
+		return ee.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// Init runs as "gt sandbox init", PID 1 of the namespaces runNamespace
+// created. It mounts spec's filesystem on a fresh tmpfs root, brings up
+// loopback, pivots into the new root, drops every capability and execs the
+// sandbox init (gt-proxy-client) with argv. It returns only on error.
+func Init(specPath string, argv []string) error {
+	// Capability and no_new_privs changes are per thread; exec from the
+	// thread that made them.
+	runtime.LockOSThread()
+
+	spec, err := LoadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	root := filepath.Join(spec.Dir, "root")
+
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("making mounts private: %w", err)
+	}
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("mounting root: %w", err)
+	}
+	for _, m := range spec.Mounts {
+		if err := applyMount(root, m); err != nil {
+			return fmt.Errorf("%s %s: %w", m.Kind, m.Target, err)
+		}
+	}
+	if spec.Network != config.SandboxNetworkHost {
+		if err := loopbackUp(); err != nil {
+			return fmt.Errorf("loopback: %w", err)
+		}
+	}
+	if err := unix.Sethostname([]byte(spec.Hostname)); err != nil {
+		return fmt.Errorf("hostname: %w", err)
+	}
+	if err := pivotRoot(root); err != nil {
+		return err
+	}
+	if err := os.Chdir(spec.WorkDir); err != nil {
+		return err
+	}
+	if err := dropPrivileges(); err != nil {
+		return err
+	}
+	initPath := BinDir + "/" + InitName
+	return fmt.Errorf("exec %s: %w", initPath, unix.Exec(initPath, append([]string{InitName}, argv...), os.Environ()))
+}
+
+// applyMount creates m's target under root and mounts it.
+func applyMount(root string, m Mount) error {
+	dst := filepath.Join(root, m.Target)
+	switch m.Kind {
+	case MountBind, MountROBind:
+		fi, err := os.Stat(m.Source)
+		if err != nil {
+			return err
+		}
+		if err := mkTarget(dst, fi.IsDir()); err != nil {
+			return err
+		}
+		if err := unix.Mount(m.Source, dst, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return err
+		}
+		if m.Kind == MountROBind {
+			return remountReadOnly(dst)
+		}
+		return nil
+	case MountOverlay:
+		for _, p := range []string{m.Source, m.Upper, m.Work} {
+			if strings.ContainsAny(p, ",:") {
+				return fmt.Errorf("overlay path %q contains ',' or ':'", p)
+			}
+		}
+		if err := mkTarget(dst, true); err != nil {
+			return err
+		}
+		opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s,userxattr", m.Source, m.Upper, m.Work)
+		return unix.Mount("overlay", dst, "overlay", 0, opts)
+	case MountTmpfs:
+		if err := mkTarget(dst, true); err != nil {
+			return err
+		}
+		return unix.Mount("tmpfs", dst, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777")
+	case MountProc:
+		if err := mkTarget(dst, true); err != nil {
+			return err
+		}
+		return unix.Mount("proc", dst, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	case MountDev:
+		return mountDev(dst)
+	case MountSymlink:
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(m.Source, dst)
+	default:
+		return fmt.Errorf("unknown mount kind")
+	}
+}
+
+// mkTarget creates a mount point: a directory, or an empty file to bind a
+// file (or socket) onto.
+func mkTarget(path string, dir bool) error {
+	if dir {
+		return os.MkdirAll(path, 0o755)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// remountReadOnly makes the bind mount at path (and its submounts)
+// read-only. Kernels before 5.12 lack mount_setattr; there the top mount is
+// remounted, keeping the flags the kernel refuses to clear in a user
+// namespace.
+func remountReadOnly(path string) error {
+	err := unix.MountSetattr(unix.AT_FDCWD, path, unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY})
+	if !errors.Is(err, unix.ENOSYS) {
+		return err
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+	for stFlag, msFlag := range map[int64]uintptr{
+		unix.ST_NOSUID:     unix.MS_NOSUID,
+		unix.ST_NODEV:      unix.MS_NODEV,
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if int64(st.Flags)&stFlag != 0 {
+			flags |= msFlag
+		}
+	}
+	return unix.Mount("", path, "", flags, "")
+}
+
+// mountDev builds a minimal /dev at dst: the host's safe character devices,
+// a private devpts and a tmpfs /dev/shm.
+func mountDev(dst string) error {
+	if err := mkTarget(dst, true); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", dst, "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "mode=0755"); err != nil {
+		return err
+	}
+	for _, name := range []string{"null", "zero", "full", "random", "urandom", "tty"} {
+		host := "/dev/" + name
+		if _, err := os.Stat(host); err != nil {
+			continue
+		}
+		node := filepath.Join(dst, name)
+		if err := mkTarget(node, false); err != nil {
+			return err
+		}
+		if err := unix.Mount(host, node, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	for name, target := range map[string]string{
+		"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2", "ptmx": "pts/ptmx",
+	} {
+		if err := os.Symlink(target, filepath.Join(dst, name)); err != nil {
+			return err
+		}
+	}
+	pts := filepath.Join(dst, "pts")
+	if err := mkTarget(pts, true); err != nil {
+		return err
+	}
+	if err := unix.Mount("devpts", pts, "devpts", unix.MS_NOSUID|unix.MS_NOEXEC, "newinstance,ptmxmode=0666,mode=0620"); err != nil {
+		return fmt.Errorf("devpts: %w", err)
+	}
+	shm := filepath.Join(dst, "shm")
+	if err := mkTarget(shm, true); err != nil {
+		return err
+	}
+	return unix.Mount("tmpfs", shm, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777")
+}
+
+// loopbackUp brings up lo in the sandbox's new network namespace, where it
+// starts down, so the init can serve the proxy on 127.0.0.1.
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// pivotRoot makes root the filesystem root and detaches the host's.
+func pivotRoot(root string) error {
+	old := filepath.Join(root, ".oldroot")
+	if err := os.Mkdir(old, 0o700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount("/.oldroot", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detaching host root: %w", err)
+	}
+	return os.Remove("/.oldroot")
+}
+
+// dropPrivileges empties the bounding, ambient, effective, permitted and
+// inheritable capability sets and sets no_new_privs, so the agent holds no
+// capabilities even where the caller's uid is 0.
+func dropPrivileges() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("dropping bounding capabilities: %w", err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("clearing ambient capabilities: %w", err)
+	}
+	var data [2]unix.CapUserData
+	if err := unix.Capset(&unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}, &data[0]); err != nil {
+		return fmt.Errorf("clearing capabilities: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("no_new_privs: %w", err)
+	}
+	return nil
+}
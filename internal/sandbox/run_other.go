@@ -0,0 +1,21 @@
+//go:build !linux
+
+package sandbox
+
+import "errors"
+
+var errNotLinux = errors.New("sandbox mode requires Linux")
+
+// Run is only supported on Linux.
+func Run(*Spec, []string) (int, error) {
+	return 0, errNotLinux
+}
+
+// Init is only supported on Linux.
+func Init(string, []string) error {
+	return errNotLinux
+}
+
+func probeNamespaces() error {
+	return errNotLinux
+}
@@ -0,0 +1,647 @@
+// Package sandbox runs polecats in a Linux namespace sandbox.
+//
+// A sandboxed polecat sees the host's system directories read-only, its own
+// worktree read-write, a private home and /tmp, and nothing else of the town.
+// gt and bd inside the sandbox are gt-proxy-client, which forwards every
+// command to gt-proxy-server over mTLS using a certificate issued from the
+// proxy CA when the polecat spawns. Git fetch and push go through the proxy's
+// git relay, which enforces the polecat's branch scope.
+//
+// The sandbox is built either by bubblewrap (bwrap) or, when bwrap is not
+// installed, by gt itself using user, mount, PID, IPC, UTS and network
+// namespaces. Both backends consume the same Spec.
+package sandbox
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/atomicfile"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/proxy"
+)
+
+// Paths inside the sandbox.
+const (
+	RunDir     = "/run/gt-sandbox"
+	BinDir     = RunDir + "/bin"
+	CertDir    = RunDir + "/certs"
+	SocketPath = RunDir + "/proxy.sock"
+	HomeDir    = "/home/polecat"
+
+	// InitName is the name gt-proxy-client answers to as the sandbox's
+	// init process: it relays the proxy socket to ForwardAddr, runs the
+	// agent and reaps orphans.
+	InitName = "gt-sandbox-init"
+
+	// ForwardAddr is where the init listens for the proxy inside a private
+	// network namespace.
+	ForwardAddr = "127.0.0.1:9876"
+)
+
+// DefaultProxyURL is the proxy's TCP address for sandboxes that share the
+// host network.
+const DefaultProxyURL = "https://127.0.0.1:9876"
+
+// DefaultCertTTL is the lifetime of the client certificate issued on spawn.
+const DefaultCertTTL = 24 * time.Hour
+
+// ErrNoCA is returned when the town has no proxy CA to issue certificates from.
+var ErrNoCA = errors.New("proxy CA not found (start gt-proxy-server once to create it)")
+
+// Mount kinds.
+const (
+	MountBind    = "bind"    // read-write bind of Source at Target
+	MountROBind  = "ro-bind" // read-only bind of Source at Target
+	MountOverlay = "overlay" // Source overlaid by Upper (with scratch Work) at Target
+	MountTmpfs   = "tmpfs"
+	MountProc    = "proc"
+	MountDev     = "dev"     // minimal /dev: null, zero, random, tty, pts, shm
+	MountSymlink = "symlink" // symlink at Target pointing to Source
+)
+
+// Mount is one entry of the sandbox filesystem, applied in order.
+type Mount struct {
+	Kind   string `json:"kind"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target"`
+	Upper  string `json:"upper,omitempty"`
+	Work   string `json:"work,omitempty"`
+}
+
+// Spec describes a prepared sandbox. Prepare writes it to spec.json in the
+// sandbox directory, where the built-in runtime's init process reads it.
+type Spec struct {
+	Rig     string `json:"rig"`
+	Polecat string `json:"polecat"`
+	// Dir is the sandbox's state directory on the host (see Dir).
+	Dir string `json:"dir"`
+	// Backend is the configured backend; "auto" is resolved at run time.
+	Backend  string  `json:"backend"`
+	Network  string  `json:"network"`
+	Hostname string  `json:"hostname"`
+	WorkDir  string  `json:"workdir"`
+	Mounts   []Mount `json:"mounts"`
+	// Env is set (KEY=VALUE) on top of the caller's environment.
+	Env []string `json:"env"`
+	// Unset lists caller environment variables not passed in.
+	Unset []string `json:"unset"`
+	// Overlay reports whether Mounts use an overlay (bwrap 0.8 or later).
+	Overlay bool `json:"overlay"`
+}
+
+// SpecPath returns the spec.json location for a spec.
+func (s *Spec) SpecPath() string {
+	return filepath.Join(s.Dir, "spec.json")
+}
+
+// Dir returns a polecat's sandbox state directory.
+func Dir(townRoot, rig, polecat string) string {
+	return filepath.Join(townRoot, ".runtime", "sandbox", rig, polecat)
+}
+
+// checkName rejects a rig or polecat name that is empty or would escape its
+// directory under .runtime/sandbox; the certificate CN is built from both.
+func checkName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("sandbox %s name is empty", kind)
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("sandbox %s name %q is not a valid name", kind, name)
+	}
+	return nil
+}
+
+// CADir returns the directory holding the town's proxy CA.
+func CADir(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "ca")
+}
+
+// Options are the inputs to Prepare.
+type Options struct {
+	TownRoot string
+	Rig      string
+	Polecat  string
+	// WorkDir is the polecat's worktree, mounted read-write at the same path.
+	WorkDir string
+	Config  *config.SandboxConfig
+	// Agent is the agent command (argv[0]) the sandbox will run. Its
+	// install directory is made visible read-only.
+	Agent string
+	// ConfigDir is the agent's config dir (e.g. CLAUDE_CONFIG_DIR), mounted
+	// read-write when set.
+	ConfigDir string
+	// PATH is the caller's search path, used to find the agent and filtered
+	// down to the directories visible in the sandbox.
+	PATH string
+}
+
+// hostSystemDirs are made visible read-only (or recreated as the symlinks
+// they are on merged-/usr systems).
+var hostSystemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/etc", "/opt"}
+
+// instructionFiles are agent instruction files picked up from the town root
+// and rig above the worktree.
+var instructionFiles = []string{"CLAUDE.md", "AGENTS.md", ".claude"}
+
+// unsetEnv are host session variables that would point the agent at host
+// services (agents, keyrings) outside the sandbox.
+var unsetEnv = []string{
+	"SSH_AUTH_SOCK", "SSH_ASKPASS", "GIT_ASKPASS", "GPG_AGENT_INFO",
+	"DBUS_SESSION_BUS_ADDRESS", "XDG_RUNTIME_DIR", "TMPDIR",
+}
+
+// Check reports configuration problems that would stop a polecat's sandbox
+// from starting — a missing CA, proxy socket, proxy client or backend —
+// without preparing anything.
+func Check(townRoot string, cfg *config.SandboxConfig) error {
+	if _, err := os.Stat(filepath.Join(CADir(townRoot), "ca.crt")); err != nil {
+		return ErrNoCA
+	}
+	if network(cfg) == config.SandboxNetworkNone {
+		if _, err := os.Stat(proxy.SocketPath(townRoot)); err != nil {
+			return fmt.Errorf("proxy socket %s not found; is gt-proxy-server running?", proxy.SocketPath(townRoot))
+		}
+	}
+	if _, err := proxyClient(cfg); err != nil {
+		return err
+	}
+	backend := ""
+	if cfg != nil {
+		backend = cfg.Backend
+	}
+	resolved, err := resolveBackend(backend, true)
+	if err != nil {
+		return err
+	}
+	if resolved == config.SandboxBackendNamespace {
+		return probeNamespaces()
+	}
+	return nil
+}
+
+// Prepare builds a polecat's sandbox: it creates the state directory,
+// installs gt-proxy-client as gt and bd, issues a client certificate from
+// the proxy CA (recording it in the cert ledger), lays out the mounts and
+// environment, and writes spec.json.
+func Prepare(opts Options) (*Spec, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.SandboxConfig{}
+	}
+	if err := checkName("rig", opts.Rig); err != nil {
+		return nil, err
+	}
+	if err := checkName("polecat", opts.Polecat); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(opts.WorkDir) {
+		return nil, fmt.Errorf("sandbox workdir %q is not absolute", opts.WorkDir)
+	}
+	dir := Dir(opts.TownRoot, opts.Rig, opts.Polecat)
+	for _, sub := range []string{"home", "bin", "certs", "root", "git/upper", "git/work"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("creating sandbox dir: %w", err)
+		}
+	}
+
+	client, err := proxyClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := installClient(client, filepath.Join(dir, "bin")); err != nil {
+		return nil, err
+	}
+	if err := issueCert(opts.TownRoot, opts.Rig, opts.Polecat, cfg, filepath.Join(dir, "certs")); err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{
+		Rig:      opts.Rig,
+		Polecat:  opts.Polecat,
+		Dir:      dir,
+		Backend:  cfg.Backend,
+		Network:  network(cfg),
+		Hostname: opts.Polecat,
+		WorkDir:  opts.WorkDir,
+		Unset:    unsetEnv,
+	}
+	proxyURL := "https://" + ForwardAddr
+	if spec.Network == config.SandboxNetworkHost {
+		proxyURL = DefaultProxyURL
+		if cfg.ProxyURL != "" {
+			proxyURL = strings.TrimSuffix(cfg.ProxyURL, "/")
+		}
+	} else if _, err := os.Stat(proxy.SocketPath(opts.TownRoot)); err != nil {
+		return nil, fmt.Errorf("proxy socket %s not found; is gt-proxy-server running?", proxy.SocketPath(opts.TownRoot))
+	}
+
+	var visible []string // host paths the sandbox can read
+	targets := make(map[string]bool)
+	add := func(m Mount) {
+		if targets[m.Target] {
+			return
+		}
+		targets[m.Target] = true
+		spec.Mounts = append(spec.Mounts, m)
+		if m.Kind != MountSymlink && m.Source != "" {
+			visible = append(visible, m.Target)
+		}
+	}
+	for _, d := range hostSystemDirs {
+		fi, err := os.Lstat(d)
+		switch {
+		case err != nil:
+		case fi.Mode()&os.ModeSymlink != 0:
+			if target, err := os.Readlink(d); err == nil {
+				add(Mount{Kind: MountSymlink, Source: target, Target: d})
+			}
+		case fi.IsDir():
+			add(Mount{Kind: MountROBind, Source: d, Target: d})
+		}
+	}
+	add(Mount{Kind: MountProc, Target: "/proc"})
+	add(Mount{Kind: MountDev, Target: "/dev"})
+	add(Mount{Kind: MountTmpfs, Target: "/tmp"})
+	add(Mount{Kind: MountBind, Source: filepath.Join(dir, "home"), Target: HomeDir})
+	add(Mount{Kind: MountROBind, Source: filepath.Join(dir, "bin"), Target: BinDir})
+	add(Mount{Kind: MountROBind, Source: filepath.Join(dir, "certs"), Target: CertDir})
+	if spec.Network == config.SandboxNetworkNone {
+		add(Mount{Kind: MountBind, Source: proxy.SocketPath(opts.TownRoot), Target: SocketPath})
+	}
+
+	// Instruction files (CLAUDE.md, .claude/) from the town root down to
+	// the worktree's parent, and the shared polecat settings.
+	rigPath := filepath.Join(opts.TownRoot, opts.Rig)
+	for _, d := range ancestors(opts.TownRoot, opts.WorkDir) {
+		for _, name := range instructionFiles {
+			if p := filepath.Join(d, name); exists(p) {
+				add(Mount{Kind: MountROBind, Source: p, Target: p})
+			}
+		}
+	}
+	if settings := config.RoleSettingsDir("polecat", rigPath); settings != "" {
+		entries, _ := os.ReadDir(settings)
+		for _, e := range entries {
+			p := filepath.Join(settings, e.Name())
+			if strings.HasPrefix(e.Name(), ".") && !under(opts.WorkDir, p) && !under(p, opts.WorkDir) {
+				add(Mount{Kind: MountROBind, Source: p, Target: p})
+			}
+		}
+	}
+
+	var agentDirs []string
+	if opts.Agent != "" {
+		agentDirs = agentPaths(opts.Agent, opts.PATH)
+		for _, d := range agentDirs {
+			if !coveredBy(d, visible) {
+				add(Mount{Kind: MountROBind, Source: d, Target: d})
+			}
+		}
+	}
+	if opts.ConfigDir != "" {
+		if err := os.MkdirAll(opts.ConfigDir, 0o700); err != nil {
+			return nil, fmt.Errorf("creating agent config dir: %w", err)
+		}
+		add(Mount{Kind: MountBind, Source: opts.ConfigDir, Target: opts.ConfigDir})
+	}
+	for _, p := range cfg.ReadOnlyPaths {
+		if exists(p) {
+			add(Mount{Kind: MountROBind, Source: p, Target: p})
+		}
+	}
+	for _, p := range cfg.WritablePaths {
+		if exists(p) {
+			add(Mount{Kind: MountBind, Source: p, Target: p})
+		}
+	}
+
+	// A linked worktree keeps its objects and refs in the rig's shared git
+	// dir. The polecat gets a private copy-on-write view of it, so commits
+	// work but only reach the shared repo by pushing through the proxy.
+	if common, err := git.NewGit(opts.WorkDir).CommonDir(); err == nil && !under(common, opts.WorkDir) {
+		add(Mount{
+			Kind:   MountOverlay,
+			Source: common,
+			Target: common,
+			Upper:  filepath.Join(dir, "git", "upper"),
+			Work:   filepath.Join(dir, "git", "work"),
+		})
+		spec.Overlay = true
+	}
+	add(Mount{Kind: MountBind, Source: opts.WorkDir, Target: opts.WorkDir})
+
+	path := []string{BinDir}
+	path = append(path, agentDirs...)
+	for _, d := range filepath.SplitList(opts.PATH) {
+		if filepath.IsAbs(d) && coveredBy(d, visible) && !slices.Contains(path, d) {
+			path = append(path, d)
+		}
+	}
+	spec.Env = []string{
+		"HOME=" + HomeDir,
+		"TMPDIR=/tmp",
+		"PATH=" + strings.Join(path, string(os.PathListSeparator)),
+		"GT_SANDBOX=" + spec.Network,
+		"GT_PROXY_URL=" + proxyURL,
+		"GT_PROXY_CERT=" + CertDir + "/client.crt",
+		"GT_PROXY_KEY=" + CertDir + "/client.key",
+		"GT_PROXY_CA=" + CertDir + "/ca.crt",
+		"GIT_SSL_CERT=" + CertDir + "/client.crt",
+		"GIT_SSL_KEY=" + CertDir + "/client.key",
+		"GIT_SSL_CAINFO=" + CertDir + "/ca.crt",
+	}
+	if spec.Network == config.SandboxNetworkNone {
+		spec.Env = append(spec.Env, "GT_SANDBOX_SOCKET="+SocketPath)
+	}
+	spec.Env = append(spec.Env, gitRelayEnv(rigPath, proxyURL+"/v1/git/"+opts.Rig)...)
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := atomicfile.WriteFile(spec.SpecPath(), data, 0o600); err != nil {
+		return nil, fmt.Errorf("writing sandbox spec: %w", err)
+	}
+	return spec, nil
+}
+
+// LoadSpec reads a spec written by Prepare.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is the sandbox spec written by Prepare
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parsing sandbox spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// Environ returns base with the spec's Unset variables removed and its Env
+// applied.
+func (s *Spec) Environ(base []string) []string {
+	drop := make(map[string]bool, len(s.Unset)+len(s.Env))
+	for _, k := range s.Unset {
+		drop[k] = true
+	}
+	for _, kv := range s.Env {
+		k, _, _ := strings.Cut(kv, "=")
+		drop[k] = true
+	}
+	out := make([]string, 0, len(base)+len(s.Env))
+	for _, kv := range base {
+		k, _, _ := strings.Cut(kv, "=")
+		if !drop[k] {
+			out = append(out, kv)
+		}
+	}
+	return append(out, s.Env...)
+}
+
+// network returns the configured network policy, defaulting to none.
+func network(cfg *config.SandboxConfig) string {
+	if cfg != nil && cfg.Network != "" {
+		return cfg.Network
+	}
+	return config.SandboxNetworkNone
+}
+
+// proxyClient locates the gt-proxy-client binary: the configured path, else
+// next to the running gt, else in PATH.
+func proxyClient(cfg *config.SandboxConfig) (string, error) {
+	if cfg != nil && cfg.ProxyClient != "" {
+		if _, err := os.Stat(cfg.ProxyClient); err != nil {
+			return "", fmt.Errorf("sandbox proxy_client: %w", err)
+		}
+		return cfg.ProxyClient, nil
+	}
+	if self, err := os.Executable(); err == nil {
+		if p := filepath.Join(filepath.Dir(self), "gt-proxy-client"); exists(p) {
+			return p, nil
+		}
+	}
+	p, err := exec.LookPath("gt-proxy-client")
+	if err != nil {
+		return "", fmt.Errorf("gt-proxy-client not found next to gt or in PATH (set sandbox.proxy_client)")
+	}
+	return p, nil
+}
+
+// installClient copies the proxy client into bin as gt, with bd and the
+// init name as symlinks to it. A copy (not a bind of the original) keeps
+// the sandbox working if the host binary is replaced mid-session.
+func installClient(src, bin string) error {
+	in, err := os.Open(src) //nolint:gosec // operator-configured proxy client
+	if err != nil {
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(bin, ".gt-*")
+	if err != nil {
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	if err := tmp.Chmod(0o755); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(bin, "gt")); err != nil {
+		return fmt.Errorf("installing gt-proxy-client: %w", err)
+	}
+	for _, name := range []string{"bd", InitName} {
+		link := filepath.Join(bin, name)
+		if target, err := os.Readlink(link); err == nil && target == "gt" {
+			continue
+		}
+		_ = os.Remove(link)
+		if err := os.Symlink("gt", link); err != nil {
+			return fmt.Errorf("installing %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// issueCert issues the polecat's client certificate (CN gt-<rig>-<polecat>)
+// from the town's proxy CA into certDir and records it in the cert ledger,
+// so gt proxy certs lists and can revoke it.
+func issueCert(townRoot, rig, polecat string, cfg *config.SandboxConfig, certDir string) error {
+	ca, err := proxy.LoadCA(CADir(townRoot))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoCA
+	}
+	if err != nil {
+		return fmt.Errorf("loading proxy CA: %w", err)
+	}
+	ttl := DefaultCertTTL
+	if cfg.CertTTL != "" {
+		if ttl, err = time.ParseDuration(cfg.CertTTL); err != nil {
+			return fmt.Errorf("sandbox cert_ttl: %w", err)
+		}
+	}
+	certPEM, keyPEM, err := ca.IssuePolecat("gt-"+rig+"-"+polecat, ttl)
+	if err != nil {
+		return fmt.Errorf("issuing polecat cert: %w", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return fmt.Errorf("issuing polecat cert: bad PEM")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("issuing polecat cert: %w", err)
+	}
+	for name, data := range map[string][]byte{"client.crt": certPEM, "client.key": keyPEM, "ca.crt": ca.CertPEM} {
+		if err := atomicfile.WriteFile(filepath.Join(certDir, name), data, 0o600); err != nil {
+			return err
+		}
+	}
+	if err := proxy.OpenCertLedger(proxy.CertLedgerPath(townRoot)).RecordIssued(leaf, time.Now()); err != nil {
+		return fmt.Errorf("recording polecat cert: %w", err)
+	}
+	return nil
+}
+
+// gitRelayEnv returns GIT_CONFIG_* variables that rewrite the rig's remote
+// URLs to the proxy's git relay for fetch and push.
+func gitRelayEnv(rigPath, relay string) []string {
+	rc, err := config.LoadRigConfig(filepath.Join(rigPath, "config.json"))
+	if err != nil {
+		return nil
+	}
+	var kv [][2]string
+	for _, u := range []string{rc.GitURL, rc.PushURL} {
+		if u != "" {
+			kv = append(kv, [2]string{"url." + relay + ".insteadOf", u}, [2]string{"url." + relay + ".pushInsteadOf", u})
+		}
+	}
+	if len(kv) == 0 {
+		return nil
+	}
+	env := []string{"GIT_CONFIG_COUNT=" + strconv.Itoa(len(kv))}
+	for i, p := range kv {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, p[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, p[1]))
+	}
+	return env
+}
+
+// agentPaths returns the directories the agent command needs: the one it
+// is found in, the one its symlinks resolve to, and for a "#!/usr/bin/env
+// interp" script the interpreter's.
+func agentPaths(agent, pathEnv string) []string {
+	bin := lookPath(agent, pathEnv)
+	if bin == "" {
+		return nil
+	}
+	dirs := []string{filepath.Dir(bin)}
+	if real, err := filepath.EvalSymlinks(bin); err == nil && !slices.Contains(dirs, filepath.Dir(real)) {
+		dirs = append(dirs, filepath.Dir(real))
+		bin = real
+	}
+	if interp := envInterpreter(bin); interp != "" {
+		if p := lookPath(interp, pathEnv); p != "" {
+			for _, d := range agentPaths(p, pathEnv) {
+				if !slices.Contains(dirs, d) {
+					dirs = append(dirs, d)
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// lookPath finds name in pathEnv, returning "" if it is not there.
+func lookPath(name, pathEnv string) string {
+	if strings.Contains(name, "/") {
+		if abs, err := filepath.Abs(name); err == nil && exists(abs) {
+			return abs
+		}
+		return ""
+	}
+	for _, d := range filepath.SplitList(pathEnv) {
+		if p := filepath.Join(d, name); filepath.IsAbs(p) {
+			if fi, err := os.Stat(p); err == nil && !fi.IsDir() && fi.Mode()&0o111 != 0 {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// envInterpreter returns interp for a script starting "#!/usr/bin/env interp".
+func envInterpreter(path string) string {
+	f, err := os.Open(path) //nolint:gosec // agent binary resolved from PATH
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, 128)
+	n, _ := f.Read(buf)
+	line, _, _ := bytes.Cut(buf[:n], []byte("\n"))
+	fields := strings.Fields(strings.TrimPrefix(string(line), "#!"))
+	if !bytes.HasPrefix(line, []byte("#!")) || len(fields) < 2 || filepath.Base(fields[0]) != "env" {
+		return ""
+	}
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "-") {
+			return f
+		}
+	}
+	return ""
+}
+
+// ancestors returns root and each directory below it down to (excluding) path.
+func ancestors(root, path string) []string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	dirs := []string{root}
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, filepath.Join(root, filepath.Join(parts[:i]...)))
+	}
+	return dirs
+}
+
+// under reports whether path is dir or inside it.
+func under(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// coveredBy reports whether path is under any of dirs.
+func coveredBy(path string, dirs []string) bool {
+	for _, d := range dirs {
+		if under(path, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,214 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/proxy"
+)
+
+// fakeClient writes an executable standing in for gt-proxy-client.
+func fakeClient(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "gt-proxy-client")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPrepare(t *testing.T) {
+	townRoot := t.TempDir()
+	if _, err := proxy.GenerateCA(CADir(townRoot)); err != nil {
+		t.Fatalf("GenerateCA: %v", err)
+	}
+	workDir := filepath.Join(townRoot, "gastown", "polecats", "nux", "gastown")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(townRoot, "CLAUDE.md"), []byte("town"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	extra := t.TempDir()
+
+	spec, err := Prepare(Options{
+		TownRoot: townRoot,
+		Rig:      "gastown",
+		Polecat:  "nux",
+		WorkDir:  workDir,
+		Config: &config.SandboxConfig{
+			Mode:          config.SandboxModeNamespace,
+			Network:       config.SandboxNetworkHost,
+			ProxyClient:   fakeClient(t),
+			ReadOnlyPaths: []string{extra},
+		},
+		PATH: "/usr/bin:relative:/nonexistent-dir",
+	})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	dir := Dir(townRoot, "gastown", "nux")
+	for _, name := range []string{"bin/gt", "bin/bd", "bin/" + InitName, "certs/client.crt", "certs/client.key", "certs/ca.crt", "spec.json"} {
+		if !exists(filepath.Join(dir, name)) {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "bin", "bd")); err != nil || target != "gt" {
+		t.Errorf("bd -> %q (%v), want gt", target, err)
+	}
+
+	mounts := make(map[string]Mount)
+	for _, m := range spec.Mounts {
+		mounts[m.Target] = m
+	}
+	for target, kind := range map[string]string{
+		workDir:                              MountBind,
+		HomeDir:                              MountBind,
+		CertDir:                              MountROBind,
+		"/proc":                              MountProc,
+		"/tmp":                               MountTmpfs,
+		extra:                                MountROBind,
+		filepath.Join(townRoot, "CLAUDE.md"): MountROBind,
+	} {
+		if got := mounts[target].Kind; got != kind {
+			t.Errorf("mount %s = %q, want %q", target, got, kind)
+		}
+	}
+	if _, ok := mounts[SocketPath]; ok {
+		t.Error("host-network sandbox should not mount the proxy socket")
+	}
+	if _, ok := mounts[townRoot]; ok {
+		t.Error("town root must not be visible")
+	}
+
+	env := strings.Join(spec.Env, "\n")
+	for _, want := range []string{"HOME=" + HomeDir, "GT_PROXY_URL=" + DefaultProxyURL, "GT_PROXY_CERT=" + CertDir + "/client.crt"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("env missing %q", want)
+		}
+	}
+	if strings.Contains(env, "relative") || strings.Contains(env, "nonexistent-dir") {
+		t.Errorf("PATH keeps directories not visible in the sandbox: %s", env)
+	}
+
+	// The spawn certificate is recorded in the cert ledger.
+	entries, err := proxy.OpenCertLedger(proxy.CertLedgerPath(townRoot)).Records()
+	if err != nil {
+		t.Fatalf("ledger: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CN != "gt-gastown-nux" {
+		t.Errorf("ledger = %+v, want one gt-gastown-nux entry", entries)
+	}
+
+	loaded, err := LoadSpec(spec.SpecPath())
+	if err != nil {
+		t.Fatalf("LoadSpec: %v", err)
+	}
+	if loaded.WorkDir != workDir || len(loaded.Mounts) != len(spec.Mounts) {
+		t.Errorf("LoadSpec round trip mismatch: %+v", loaded)
+	}
+}
+
+func TestPrepareErrors(t *testing.T) {
+	townRoot := t.TempDir()
+	workDir := t.TempDir()
+	cfg := &config.SandboxConfig{Mode: config.SandboxModeNamespace, ProxyClient: fakeClient(t)}
+
+	_, err := Prepare(Options{TownRoot: townRoot, Rig: "gastown", Polecat: "nux", WorkDir: workDir, Config: cfg})
+	if !errors.Is(err, ErrNoCA) {
+		t.Errorf("without CA: err = %v, want ErrNoCA", err)
+	}
+
+	if _, err := proxy.GenerateCA(CADir(townRoot)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Prepare(Options{TownRoot: townRoot, Rig: "gastown", Polecat: "nux", WorkDir: workDir, Config: cfg})
+	if err == nil || !strings.Contains(err.Error(), "proxy socket") {
+		t.Errorf("without socket: err = %v, want proxy socket error", err)
+	}
+
+	_, err = Prepare(Options{TownRoot: townRoot, Rig: "gastown", Polecat: "nux", WorkDir: "relative", Config: cfg})
+	if err == nil {
+		t.Error("relative workdir: expected error")
+	}
+
+	for _, opts := range []Options{
+		{TownRoot: townRoot, Rig: "gastown", Polecat: "", WorkDir: workDir, Config: cfg},
+		{TownRoot: townRoot, Rig: "", Polecat: "nux", WorkDir: workDir, Config: cfg},
+		{TownRoot: townRoot, Rig: "gastown", Polecat: "../nux", WorkDir: workDir, Config: cfg},
+	} {
+		if _, err := Prepare(opts); err == nil || !strings.Contains(err.Error(), "name") {
+			t.Errorf("rig %q polecat %q: err = %v, want name error", opts.Rig, opts.Polecat, err)
+		}
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	spec := &Spec{
+		Env:   []string{"HOME=/home/polecat", "PATH=/run/gt-sandbox/bin"},
+		Unset: []string{"SSH_AUTH_SOCK"},
+	}
+	got := spec.Environ([]string{"HOME=/root", "SSH_AUTH_SOCK=/tmp/agent", "GT_RIG=gastown", "PATH=/usr/bin"})
+	want := []string{"GT_RIG=gastown", "HOME=/home/polecat", "PATH=/run/gt-sandbox/bin"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Environ = %v, want %v", got, want)
+	}
+}
+
+func TestBwrapArgs(t *testing.T) {
+	spec := &Spec{
+		Network:  config.SandboxNetworkNone,
+		Hostname: "nux",
+		WorkDir:  "/town/rig/polecats/nux",
+		Mounts: []Mount{
+			{Kind: MountROBind, Source: "/usr", Target: "/usr"},
+			{Kind: MountSymlink, Source: "usr/lib", Target: "/lib"},
+			{Kind: MountOverlay, Source: "/town/rig/.repo.git", Target: "/town/rig/.repo.git", Upper: "/s/upper", Work: "/s/work"},
+			{Kind: MountBind, Source: "/town/rig/polecats/nux", Target: "/town/rig/polecats/nux"},
+		},
+	}
+	got := strings.Join(BwrapArgs(spec, []string{"claude", "--resume"}), " ")
+	for _, want := range []string{
+		"--unshare-net",
+		"--hostname nux",
+		"--ro-bind /usr /usr",
+		"--symlink usr/lib /lib",
+		"--overlay-src /town/rig/.repo.git --overlay /s/upper /s/work /town/rig/.repo.git",
+		"--bind /town/rig/polecats/nux /town/rig/polecats/nux",
+		"--chdir /town/rig/polecats/nux -- " + BinDir + "/" + InitName + " claude --resume",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("bwrap args missing %q:\n%s", want, got)
+		}
+	}
+
+	spec.Network = config.SandboxNetworkHost
+	if got := BwrapArgs(spec, []string{"claude"}); slices.Contains(got, "--unshare-net") {
+		t.Error("host network must not unshare the network namespace")
+	}
+}
+
+func TestParseBwrapVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [2]int
+		ok   bool
+	}{
+		{"bubblewrap 0.8.0\n", [2]int{0, 8}, true},
+		{"bubblewrap 0.11.1", [2]int{0, 11}, true},
+		{"bubblewrap", [2]int{}, false},
+		{"bubblewrap x.y", [2]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseBwrapVersion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseBwrapVersion(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
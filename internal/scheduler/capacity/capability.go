@@ -0,0 +1,359 @@
+package capacity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/flock"
+)
+
+// Capability labels on work beads. A bead labeled cap:<name> requires a polecat
+// (or agent preset) with that skill; cap-pref:<name> only prefers one.
+const (
+	LabelCapabilityPrefix          = "cap:"
+	LabelCapabilityPreferredPrefix = "cap-pref:"
+)
+
+// DefaultCapabilityMinCompletions is how many completed beads with a capability
+// label a polecat or agent needs before that capability is learned.
+const DefaultCapabilityMinCompletions = 2
+
+// CapabilityConfig declares skills for capability-based routing.
+// Declared skills are combined with skills learned from completed work.
+type CapabilityConfig struct {
+	// Polecats maps a polecat identity to its skills. Keys are "<rig>/<name>"
+	// for one rig, or "<name>" for that name in every rig.
+	// Example: {"gastown/Toast": ["frontend"], "nux": ["migrations"]}.
+	Polecats map[string][]string `json:"polecats,omitempty"`
+
+	// Agents maps an agent preset (e.g., "claude", "codex", or a custom agent
+	// from settings) to its skills.
+	Agents map[string][]string `json:"agents,omitempty"`
+
+	// MinCompletions is the number of completed beads carrying a capability
+	// label before the capability is learned. nil = default (2). 0 disables
+	// learning from history.
+	MinCompletions *int `json:"min_completions,omitempty"`
+}
+
+// CapabilityNeeds are the capabilities a bead asks for.
+type CapabilityNeeds struct {
+	Required  []string `json:"required,omitempty"`
+	Preferred []string `json:"preferred,omitempty"`
+}
+
+// CapabilityNeedsFromLabels extracts cap: and cap-pref: labels.
+func CapabilityNeedsFromLabels(labels []string) CapabilityNeeds {
+	var n CapabilityNeeds
+	for _, l := range labels {
+		switch {
+		case strings.HasPrefix(l, LabelCapabilityPreferredPrefix):
+			if c := normalizeCapability(strings.TrimPrefix(l, LabelCapabilityPreferredPrefix)); c != "" {
+				n.Preferred = append(n.Preferred, c)
+			}
+		case strings.HasPrefix(l, LabelCapabilityPrefix):
+			if c := normalizeCapability(strings.TrimPrefix(l, LabelCapabilityPrefix)); c != "" {
+				n.Required = append(n.Required, c)
+			}
+		}
+	}
+	return n
+}
+
+// Empty reports whether the bead asks for no capabilities.
+func (n CapabilityNeeds) Empty() bool {
+	return len(n.Required) == 0 && len(n.Preferred) == 0
+}
+
+// All returns required then preferred capabilities.
+func (n CapabilityNeeds) All() []string {
+	return append(append([]string(nil), n.Required...), n.Preferred...)
+}
+
+// Missing returns the required capabilities not present in skills.
+func (n CapabilityNeeds) Missing(skills []string) []string {
+	have := skillSet(skills)
+	var missing []string
+	for _, c := range n.Required {
+		if !have[c] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// Score rates how well skills fit the needs: satisfied is true when every
+// required capability is present, and score counts matched capabilities with
+// required matches weighted above preferred ones.
+func (n CapabilityNeeds) Score(skills []string) (score int, satisfied bool) {
+	have := skillSet(skills)
+	satisfied = true
+	for _, c := range n.Required {
+		if have[c] {
+			score += 2
+		} else {
+			satisfied = false
+		}
+	}
+	for _, c := range n.Preferred {
+		if have[c] {
+			score++
+		}
+	}
+	return score, satisfied
+}
+
+// CapabilityCandidate is a polecat or agent preset considered for a bead.
+type CapabilityCandidate struct {
+	Name   string
+	Skills []string
+}
+
+// BestCandidate returns the index of the candidate that best fits needs:
+// candidates satisfying every required capability win, then the highest
+// score, then input order. satisfied reports whether the winner has every
+// required capability; when none does, the best partial match is returned so
+// callers can fall back instead of refusing work. Returns -1 for no candidates.
+func BestCandidate(needs CapabilityNeeds, candidates []CapabilityCandidate) (best int, satisfied bool) {
+	best = -1
+	bestScore := -1
+	for i, c := range candidates {
+		score, ok := needs.Score(c.Skills)
+		if best < 0 || (ok && !satisfied) || (ok == satisfied && score > bestScore) {
+			best, bestScore, satisfied = i, score, ok
+		}
+	}
+	return best, satisfied
+}
+
+// CapabilityHistory counts completed beads per capability label, keyed by
+// polecat identity ("<rig>/<name>") and by agent preset.
+// Stored at <townRoot>/.runtime/capability-history.json.
+type CapabilityHistory struct {
+	Polecats map[string]map[string]int `json:"polecats,omitempty"`
+	Agents   map[string]map[string]int `json:"agents,omitempty"`
+}
+
+func capabilityHistoryFile(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "capability-history.json")
+}
+
+// LoadCapabilityHistory loads the completion history, returning an empty
+// history if the file doesn't exist.
+func LoadCapabilityHistory(townRoot string) (*CapabilityHistory, error) {
+	data, err := os.ReadFile(capabilityHistoryFile(townRoot)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &CapabilityHistory{}, nil
+		}
+		return nil, err
+	}
+	var h CapabilityHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// RecordCapabilityCompletion adds one completion of each capability to the
+// history of polecat ("<rig>/<name>") and agent. Either key may be empty.
+// Uses flock because polecats in different processes finish concurrently.
+func RecordCapabilityCompletion(townRoot, polecat, agent string, capabilities []string) error {
+	if len(capabilities) == 0 || (polecat == "" && agent == "") {
+		return nil
+	}
+	path := capabilityHistoryFile(townRoot)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	fl := flock.New(path + ".lock")
+	if err := fl.Lock(); err != nil {
+		return err
+	}
+	defer fl.Unlock() //nolint:errcheck // best-effort unlock
+
+	h, err := LoadCapabilityHistory(townRoot)
+	if err != nil {
+		return err
+	}
+	add := func(m *map[string]map[string]int, key string) {
+		if key == "" {
+			return
+		}
+		if *m == nil {
+			*m = make(map[string]map[string]int)
+		}
+		if (*m)[key] == nil {
+			(*m)[key] = make(map[string]int)
+		}
+		for _, c := range capabilities {
+			(*m)[key][c]++
+		}
+	}
+	add(&h.Polecats, polecat)
+	add(&h.Agents, agent)
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".capability-history-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// CapabilityProfiles resolves the skills of polecats and agent presets from
+// declared config plus learned history.
+type CapabilityProfiles struct {
+	cfg     *CapabilityConfig
+	history *CapabilityHistory
+	min     int
+}
+
+// NewCapabilityProfiles combines the scheduler's declared capabilities with
+// the completion history. Either argument may be nil.
+func NewCapabilityProfiles(c *SchedulerConfig, history *CapabilityHistory) *CapabilityProfiles {
+	p := &CapabilityProfiles{history: history, min: DefaultCapabilityMinCompletions}
+	if c != nil && c.Capabilities != nil {
+		p.cfg = c.Capabilities
+		if c.Capabilities.MinCompletions != nil {
+			p.min = *c.Capabilities.MinCompletions
+		}
+	}
+	if p.history == nil {
+		p.history = &CapabilityHistory{}
+	}
+	return p
+}
+
+// Polecat returns the skills of the polecat <rig>/<name>.
+func (p *CapabilityProfiles) Polecat(rig, name string) []string {
+	var skills []string
+	if p.cfg != nil {
+		skills = append(skills, p.cfg.Polecats[rig+"/"+name]...)
+		skills = append(skills, p.cfg.Polecats[name]...)
+	}
+	skills = append(skills, p.learned(p.history.Polecats[rig+"/"+name])...)
+	return dedupeSkills(skills)
+}
+
+// Agent returns the skills of an agent preset.
+func (p *CapabilityProfiles) Agent(agent string) []string {
+	var skills []string
+	if p.cfg != nil {
+		skills = append(skills, p.cfg.Agents[agent]...)
+	}
+	skills = append(skills, p.learned(p.history.Agents[agent])...)
+	return dedupeSkills(skills)
+}
+
+// Agents returns every agent preset with a declared or learned profile, sorted.
+func (p *CapabilityProfiles) Agents() []string {
+	seen := make(map[string]bool)
+	if p.cfg != nil {
+		for a := range p.cfg.Agents {
+			seen[a] = true
+		}
+	}
+	for a := range p.history.Agents {
+		if len(p.Agent(a)) > 0 {
+			seen[a] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for a := range seen {
+		names = append(names, a)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Polecats returns every polecat identity ("<rig>/<name>" or bare "<name>")
+// with a declared or learned profile, sorted.
+func (p *CapabilityProfiles) Polecats() []string {
+	seen := make(map[string]bool)
+	if p.cfg != nil {
+		for id := range p.cfg.Polecats {
+			seen[id] = true
+		}
+	}
+	for id, counts := range p.history.Polecats {
+		if len(p.learned(counts)) > 0 {
+			seen[id] = true
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Profile returns the skills for an identity as listed by Polecats.
+func (p *CapabilityProfiles) Profile(identity string) []string {
+	if rig, name, ok := strings.Cut(identity, "/"); ok {
+		return p.Polecat(rig, name)
+	}
+	if p.cfg == nil {
+		return nil
+	}
+	return dedupeSkills(p.cfg.Polecats[identity])
+}
+
+func (p *CapabilityProfiles) learned(counts map[string]int) []string {
+	if p.min <= 0 {
+		return nil
+	}
+	var skills []string
+	for c, n := range counts {
+		if n >= p.min {
+			skills = append(skills, c)
+		}
+	}
+	return skills
+}
+
+func normalizeCapability(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+func skillSet(skills []string) map[string]bool {
+	set := make(map[string]bool, len(skills))
+	for _, s := range skills {
+		set[normalizeCapability(s)] = true
+	}
+	return set
+}
+
+func dedupeSkills(skills []string) []string {
+	set := skillSet(skills)
+	out := make([]string, 0, len(set))
+	for s := range set {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,104 @@
+package capacity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCapabilityNeedsFromLabels(t *testing.T) {
+	n := CapabilityNeedsFromLabels([]string{"gt:task", "cap:Frontend", "cap-pref:go-concurrency", "cap:", "cap: migrations "})
+	if !reflect.DeepEqual(n.Required, []string{"frontend", "migrations"}) {
+		t.Errorf("Required = %v", n.Required)
+	}
+	if !reflect.DeepEqual(n.Preferred, []string{"go-concurrency"}) {
+		t.Errorf("Preferred = %v", n.Preferred)
+	}
+	if CapabilityNeedsFromLabels([]string{"gt:task"}).Empty() != true {
+		t.Error("labels without cap: should yield empty needs")
+	}
+}
+
+func TestBestCandidate(t *testing.T) {
+	needs := CapabilityNeeds{Required: []string{"frontend"}, Preferred: []string{"css"}}
+	tests := []struct {
+		name          string
+		candidates    []CapabilityCandidate
+		wantBest      int
+		wantSatisfied bool
+	}{
+		{"none", nil, -1, false},
+		{
+			"required beats preferred-only",
+			[]CapabilityCandidate{{Name: "a", Skills: []string{"css"}}, {Name: "b", Skills: []string{"frontend"}}},
+			1, true,
+		},
+		{
+			"preferred breaks ties among satisfying",
+			[]CapabilityCandidate{{Name: "a", Skills: []string{"frontend"}}, {Name: "b", Skills: []string{"frontend", "css"}}},
+			1, true,
+		},
+		{
+			"input order breaks remaining ties",
+			[]CapabilityCandidate{{Name: "a", Skills: []string{"frontend"}}, {Name: "b", Skills: []string{"FRONTEND"}}},
+			0, true,
+		},
+		{
+			"fallback to best partial match",
+			[]CapabilityCandidate{{Name: "a"}, {Name: "b", Skills: []string{"css"}}},
+			1, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, ok := BestCandidate(needs, tt.candidates)
+			if best != tt.wantBest || ok != tt.wantSatisfied {
+				t.Errorf("BestCandidate = (%d, %v), want (%d, %v)", best, ok, tt.wantBest, tt.wantSatisfied)
+			}
+		})
+	}
+}
+
+func TestCapabilityProfilesCombineDeclaredAndLearned(t *testing.T) {
+	townRoot := t.TempDir()
+	for i := 0; i < 2; i++ {
+		if err := RecordCapabilityCompletion(townRoot, "gastown/Toast", "codex", []string{"migrations"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordCapabilityCompletion(townRoot, "gastown/Toast", "", []string{"frontend"}); err != nil {
+		t.Fatal(err)
+	}
+	history, err := LoadCapabilityHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Polecats["gastown/Toast"]["migrations"] != 2 || history.Agents["codex"]["migrations"] != 2 {
+		t.Fatalf("history = %+v", history)
+	}
+
+	cfg := &SchedulerConfig{Capabilities: &CapabilityConfig{
+		Polecats: map[string][]string{"Toast": {"go"}, "beads/Nux": {"docs"}},
+		Agents:   map[string][]string{"gemini": {"frontend"}},
+	}}
+	p := NewCapabilityProfiles(cfg, history)
+
+	// Declared bare name + learned (2 completions); frontend has only 1.
+	if got := p.Polecat("gastown", "Toast"); !reflect.DeepEqual(got, []string{"go", "migrations"}) {
+		t.Errorf("Polecat(gastown, Toast) = %v", got)
+	}
+	if got := p.Polecat("beads", "Toast"); !reflect.DeepEqual(got, []string{"go"}) {
+		t.Errorf("Polecat(beads, Toast) = %v, want declared only", got)
+	}
+	if got := p.Agents(); !reflect.DeepEqual(got, []string{"codex", "gemini"}) {
+		t.Errorf("Agents = %v", got)
+	}
+	if got := p.Polecats(); !reflect.DeepEqual(got, []string{"Toast", "beads/Nux", "gastown/Toast"}) {
+		t.Errorf("Polecats = %v", got)
+	}
+
+	zero := 0
+	cfg.Capabilities.MinCompletions = &zero
+	if got := NewCapabilityProfiles(cfg, history).Agent("codex"); len(got) != 0 {
+		t.Errorf("learning disabled: Agent(codex) = %v, want none", got)
+	}
+}
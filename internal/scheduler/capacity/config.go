@@ -3,7 +3,10 @@
 // resolution) stays in cmd but uses types and pure functions from this package.
 package capacity
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // SchedulerConfig configures the capacity scheduler for polecat dispatch.
 // This is a town-wide setting (not per-rig) because capacity control is host-wide:
@@ -28,8 +31,35 @@ type SchedulerConfig struct {
 	// SpawnDelay is the delay between spawns to prevent Dolt lock contention.
 	// Default: "0s".
 	SpawnDelay string `json:"spawn_delay,omitempty"`
+
+	// SLAs maps bead priority ("P0".."P4") to a default dispatch SLA, as a Go
+	// duration measured from enqueue time. Applies only to scheduled beads that
+	// carry no explicit deadline or SLA of their own.
+	// Example: {"P0": "15m", "P1": "2h"}.
+	SLAs map[string]string `json:"slas,omitempty"`
+
+	// SLARiskWindow is the slack below which a bead held back by capacity is
+	// reported as at risk of missing its deadline. Default: "5m".
+	SLARiskWindow string `json:"sla_risk_window,omitempty"`
+
+	// Windows restricts when the scheduler may start new polecats town-wide
+	// (cron-style windows, timezone, blackout dates). nil = always open.
+	Windows *DispatchSchedule `json:"windows,omitempty"`
+
+	// RigWindows adds per-rig dispatch windows, keyed by rig name. A rig may
+	// receive new polecats only while both its own schedule and the town
+	// schedule are open.
+	RigWindows map[string]*DispatchSchedule `json:"rig_windows,omitempty"`
+
+	// Capabilities declares polecat and agent preset skills for routing beads
+	// labeled cap:<name> / cap-pref:<name>. Learned skills from completed work
+	// are added on top. nil = learned skills only.
+	Capabilities *CapabilityConfig `json:"capabilities,omitempty"`
 }
 
+// DefaultSLARiskWindow is the slack threshold used when SLARiskWindow is unset.
+const DefaultSLARiskWindow = 5 * time.Minute
+
 // DefaultSchedulerConfig returns a SchedulerConfig with sensible defaults.
 // MaxPolecats=-1 means direct dispatch (no scheduler overhead).
 func DefaultSchedulerConfig() *SchedulerConfig {
@@ -66,12 +96,36 @@ func (c *SchedulerConfig) GetSpawnDelay() time.Duration {
 	return ParseDurationOrDefault(c.SpawnDelay, 0)
 }
 
+// GetSLAForPriority returns the configured SLA for a bead priority (0-4),
+// or 0 when no SLA is configured for that priority.
+func (c *SchedulerConfig) GetSLAForPriority(priority int) time.Duration {
+	if c == nil || len(c.SLAs) == 0 {
+		return 0
+	}
+	return ParseDurationOrDefault(c.SLAs[fmt.Sprintf("P%d", priority)], 0)
+}
+
+// GetSLARiskWindow returns SLARiskWindow as a duration, defaulting to 5m.
+func (c *SchedulerConfig) GetSLARiskWindow() time.Duration {
+	if c == nil || c.SLARiskWindow == "" {
+		return DefaultSLARiskWindow
+	}
+	return ParseDurationOrDefault(c.SLARiskWindow, DefaultSLARiskWindow)
+}
+
 // IsDeferred returns true when the scheduler is configured for deferred dispatch
 // (max_polecats > 0). Returns false for direct dispatch (-1) and disabled (0).
 func (c *SchedulerConfig) IsDeferred() bool {
 	return c.GetMaxPolecats() > 0
 }
 
+// HasDispatchWindows reports whether any town or rig dispatch window is configured.
+// With windows set, direct-dispatch towns still queue beads slung outside a
+// window; the scheduler dispatches them once the window opens.
+func (c *SchedulerConfig) HasDispatchWindows() bool {
+	return c != nil && (c.Windows != nil || len(c.RigWindows) > 0)
+}
+
 // ParseDurationOrDefault parses a Go duration string, returning fallback on error or empty input.
 func ParseDurationOrDefault(s string, fallback time.Duration) time.Duration {
 	if s == "" {
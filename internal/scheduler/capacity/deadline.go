@@ -0,0 +1,107 @@
+package capacity
+
+import (
+	"sort"
+	"time"
+)
+
+// DueAt resolves the dispatch deadline for a pending bead.
+// Resolution order:
+//  1. Explicit Deadline on the sling context (RFC3339)
+//  2. Explicit SLA on the sling context, measured from EnqueuedAt
+//  3. Per-priority SLA from the scheduler config, measured from EnqueuedAt
+//
+// Returns false when no deadline applies (the bead is best-effort FIFO).
+func DueAt(b PendingBead, cfg *SchedulerConfig) (time.Time, bool) {
+	ctx := b.Context
+	if ctx == nil {
+		return time.Time{}, false
+	}
+	if ctx.Deadline != "" {
+		if t, err := time.Parse(time.RFC3339, ctx.Deadline); err == nil {
+			return t, true
+		}
+	}
+	enqueued, err := time.Parse(time.RFC3339, ctx.EnqueuedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if sla := ParseDurationOrDefault(ctx.SLA, 0); sla > 0 {
+		return enqueued.Add(sla), true
+	}
+	if sla := cfg.GetSLAForPriority(b.Priority); sla > 0 {
+		return enqueued.Add(sla), true
+	}
+	return time.Time{}, false
+}
+
+// Slack returns the time remaining before a bead's dispatch deadline.
+// Negative slack means the deadline has already passed.
+// Returns false when the bead has no deadline.
+func Slack(b PendingBead, cfg *SchedulerConfig, now time.Time) (time.Duration, bool) {
+	due, ok := DueAt(b, cfg)
+	if !ok {
+		return 0, false
+	}
+	return due.Sub(now), true
+}
+
+// OrderBySlack returns ready beads in dispatch order: least slack first, with
+// beads that have no deadline treated as having unlimited slack. Ties (including
+// all deadline-free beads) are broken by priority, so a P0 preempts lower-priority
+// work that was queued earlier. Remaining ties keep their input (FIFO) order.
+// The input slice is not modified.
+func OrderBySlack(ready []PendingBead, cfg *SchedulerConfig, now time.Time) []PendingBead {
+	type keyed struct {
+		bead     PendingBead
+		slack    time.Duration
+		hasSlack bool
+	}
+	items := make([]keyed, len(ready))
+	for i, b := range ready {
+		slack, ok := Slack(b, cfg, now)
+		items[i] = keyed{bead: b, slack: slack, hasSlack: ok}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.hasSlack != b.hasSlack {
+			return a.hasSlack
+		}
+		if a.hasSlack && a.slack != b.slack {
+			return a.slack < b.slack
+		}
+		return a.bead.Priority < b.bead.Priority
+	})
+	result := make([]PendingBead, len(items))
+	for i, it := range items {
+		result[i] = it.bead
+	}
+	return result
+}
+
+// SLARisk describes a queued bead that is at risk of missing its deadline.
+type SLARisk struct {
+	Bead  PendingBead
+	DueAt time.Time
+	Slack time.Duration
+}
+
+// FindSLARisks returns the deferred beads whose slack is at or below the
+// configured risk window. Callers pass DispatchPlan.Deferred — beads that were
+// ready but held back this cycle — so the result is exactly the work whose
+// deadline is threatened by lack of capacity.
+func FindSLARisks(deferred []PendingBead, cfg *SchedulerConfig, now time.Time) []SLARisk {
+	window := cfg.GetSLARiskWindow()
+	var risks []SLARisk
+	for _, b := range deferred {
+		due, ok := DueAt(b, cfg)
+		if !ok {
+			continue
+		}
+		slack := due.Sub(now)
+		if slack <= window {
+			risks = append(risks, SLARisk{Bead: b, DueAt: due, Slack: slack})
+		}
+	}
+	return risks
+}
@@ -0,0 +1,146 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueAt(t *testing.T) {
+	enqueued := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	cfg := &SchedulerConfig{SLAs: map[string]string{"P0": "15m"}}
+
+	tests := []struct {
+		name     string
+		bead     PendingBead
+		wantDue  time.Time
+		wantHave bool
+	}{
+		{"nil context", PendingBead{ID: "a"}, time.Time{}, false},
+		{
+			"explicit deadline wins",
+			PendingBead{Context: &SlingContextFields{
+				EnqueuedAt: enqueued.Format(time.RFC3339),
+				Deadline:   "2026-01-02T12:00:00Z",
+				SLA:        "1m",
+			}},
+			time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC), true,
+		},
+		{
+			"explicit SLA from enqueue",
+			PendingBead{Context: &SlingContextFields{EnqueuedAt: enqueued.Format(time.RFC3339), SLA: "1h"}},
+			enqueued.Add(time.Hour), true,
+		},
+		{
+			"priority SLA from config",
+			PendingBead{Priority: 0, Context: &SlingContextFields{EnqueuedAt: enqueued.Format(time.RFC3339)}},
+			enqueued.Add(15 * time.Minute), true,
+		},
+		{
+			"no SLA for priority",
+			PendingBead{Priority: 2, Context: &SlingContextFields{EnqueuedAt: enqueued.Format(time.RFC3339)}},
+			time.Time{}, false,
+		},
+		{
+			"invalid deadline falls through to SLA",
+			PendingBead{Context: &SlingContextFields{EnqueuedAt: enqueued.Format(time.RFC3339), Deadline: "soon", SLA: "2h"}},
+			enqueued.Add(2 * time.Hour), true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, ok := DueAt(tt.bead, cfg)
+			if ok != tt.wantHave {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantHave)
+			}
+			if ok && !due.Equal(tt.wantDue) {
+				t.Errorf("due = %v, want %v", due, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestOrderBySlack(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	enq := now.Add(-time.Hour).Format(time.RFC3339)
+	withSLA := func(id string, priority int, sla string) PendingBead {
+		return PendingBead{ID: id, Priority: priority, Context: &SlingContextFields{EnqueuedAt: enq, SLA: sla}}
+	}
+
+	ready := []PendingBead{
+		withSLA("fifo-p2", 2, ""),
+		withSLA("loose", 3, "4h"),
+		withSLA("fifo-p1", 1, ""),
+		withSLA("tight", 3, "70m"),
+		withSLA("overdue", 4, "30m"),
+	}
+	got := OrderBySlack(ready, nil, now)
+
+	want := []string{"overdue", "tight", "loose", "fifo-p1", "fifo-p2"}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("position %d: got %s, want %s", i, got[i].ID, id)
+		}
+	}
+	if ready[0].ID != "fifo-p2" {
+		t.Error("OrderBySlack modified its input")
+	}
+}
+
+func TestOrderBySlackPreservesFIFOForTies(t *testing.T) {
+	ready := []PendingBead{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	got := OrderBySlack(ready, nil, time.Now())
+	for i, id := range []string{"a", "b", "c"} {
+		if got[i].ID != id {
+			t.Errorf("position %d: got %s, want %s", i, got[i].ID, id)
+		}
+	}
+}
+
+func TestFindSLARisks(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	enq := now.Add(-10 * time.Minute).Format(time.RFC3339)
+	deferred := []PendingBead{
+		{ID: "safe", Context: &SlingContextFields{EnqueuedAt: enq, SLA: "1h"}},
+		{ID: "close", Context: &SlingContextFields{EnqueuedAt: enq, SLA: "13m"}},
+		{ID: "late", Context: &SlingContextFields{EnqueuedAt: enq, SLA: "5m"}},
+		{ID: "none", Context: &SlingContextFields{EnqueuedAt: enq}},
+	}
+
+	risks := FindSLARisks(deferred, nil, now)
+	if len(risks) != 2 {
+		t.Fatalf("got %d risks, want 2", len(risks))
+	}
+	if risks[0].Bead.ID != "close" || risks[0].Slack != 3*time.Minute {
+		t.Errorf("risk[0] = %s slack %v, want close slack 3m", risks[0].Bead.ID, risks[0].Slack)
+	}
+	if risks[1].Bead.ID != "late" || risks[1].Slack >= 0 {
+		t.Errorf("risk[1] = %s slack %v, want late with negative slack", risks[1].Bead.ID, risks[1].Slack)
+	}
+
+	wide := &SchedulerConfig{SLARiskWindow: "2h"}
+	if got := FindSLARisks(deferred, wide, now); len(got) != 3 {
+		t.Errorf("with 2h window got %d risks, want 3", len(got))
+	}
+}
+
+func TestPlanDispatchDeferred(t *testing.T) {
+	ready := []PendingBead{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	plan := PlanDispatch(1, 3, ready)
+	if len(plan.Deferred) != 2 || plan.Deferred[0].ID != "b" {
+		t.Errorf("capacity-constrained Deferred = %v, want [b c]", plan.Deferred)
+	}
+
+	plan = PlanDispatch(0, 3, ready)
+	if len(plan.Deferred) != 3 {
+		t.Errorf("no-capacity Deferred len = %d, want 3", len(plan.Deferred))
+	}
+
+	plan = PlanDispatch(5, 5, ready)
+	if len(plan.Deferred) != 0 {
+		t.Errorf("unconstrained Deferred len = %d, want 0", len(plan.Deferred))
+	}
+}
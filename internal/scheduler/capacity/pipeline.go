@@ -10,6 +10,7 @@ type PendingBead struct {
 	TargetRig       string
 	Description     string
 	Labels          []string
+	Priority        int                 // Work bead priority (0 = P0, highest)
 	Context         *SlingContextFields // Parsed sling params from context bead
 	ContextWorkDir  string              // Work dir for the DB where the context was discovered.
 	ContextBeadsDir string              // Resolved .beads dir where the context was discovered.
@@ -38,6 +39,8 @@ type SlingContextFields struct {
 	Mode             string `json:"mode,omitempty"`
 	DispatchFailures int    `json:"dispatch_failures,omitempty"`
 	LastFailure      string `json:"last_failure,omitempty"`
+	Deadline         string `json:"deadline,omitempty"` // RFC3339 time by which the bead must be dispatched
+	SLA              string `json:"sla,omitempty"`      // Go duration from EnqueuedAt (e.g. "15m")
 }
 
 // LabelSlingContext is the label used to identify sling context beads.
@@ -84,6 +87,7 @@ func FilterMessagingBeads(beads []PendingBead) ([]PendingBead, int) {
 // DispatchPlan is the output of PlanDispatch — what to dispatch and why.
 type DispatchPlan struct {
 	ToDispatch []PendingBead
+	Deferred   []PendingBead // Ready beads left queued this cycle (capacity/batch)
 	Skipped    int
 	Reason     string // "capacity" | "batch" | "ready" | "none"
 }
@@ -144,8 +148,9 @@ func PlanDispatch(availableCapacity, batchSize int, ready []PendingBead) Dispatc
 
 	if availableCapacity <= 0 {
 		return DispatchPlan{
-			Skipped: len(ready) + msgSkipped,
-			Reason:  "capacity",
+			Deferred: ready,
+			Skipped:  len(ready) + msgSkipped,
+			Reason:   "capacity",
 		}
 	}
 
@@ -173,6 +178,7 @@ func PlanDispatch(availableCapacity, batchSize int, ready []PendingBead) Dispatc
 
 	return DispatchPlan{
 		ToDispatch: ready[:toDispatch],
+		Deferred:   ready[toDispatch:],
 		Skipped:    skipped,
 		Reason:     reason,
 	}
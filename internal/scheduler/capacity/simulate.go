@@ -0,0 +1,384 @@
+package capacity
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultSimServiceTime is the polecat run time assumed for simulated jobs when
+// the history contains no completed job to estimate it from.
+const DefaultSimServiceTime = 30 * time.Minute
+
+// simHorizon bounds how long past the last enqueue the simulator keeps ticking,
+// so a config that can never drain the queue (e.g., windows that never open)
+// terminates and reports the remainder as undispatched.
+const simHorizon = 30 * 24 * time.Hour
+
+// SimJob is one scheduled bead reconstructed from the event log.
+// Zero DispatchedAt/DoneAt mean the event was not observed.
+type SimJob struct {
+	ID           string
+	Rig          string
+	EnqueuedAt   time.Time
+	DispatchedAt time.Time
+	DoneAt       time.Time
+	// MaxPolecats is the scheduler.max_polecats recorded with the dispatch
+	// event; nil when the event predates recording it.
+	MaxPolecats *int
+}
+
+// serviceTime returns how long the job's polecat actually ran.
+func (j SimJob) serviceTime() (time.Duration, bool) {
+	if j.DispatchedAt.IsZero() || j.DoneAt.IsZero() || j.DoneAt.Before(j.DispatchedAt) {
+		return 0, false
+	}
+	return j.DoneAt.Sub(j.DispatchedAt), true
+}
+
+// SimStats summarizes queue wait, concurrency, and throughput for one run
+// (either the recorded history or a simulation of it).
+type SimStats struct {
+	Jobs            int           `json:"jobs"`
+	Dispatched      int           `json:"dispatched"`
+	Completed       int           `json:"completed"`
+	MeanWait        time.Duration `json:"mean_wait"`
+	P50Wait         time.Duration `json:"p50_wait"`
+	P90Wait         time.Duration `json:"p90_wait"`
+	MaxWait         time.Duration `json:"max_wait"`
+	Span            time.Duration `json:"span"`
+	ThroughputPerHr float64       `json:"throughput_per_hour"`
+	AvgConcurrency  float64       `json:"avg_concurrency"`
+	PeakConcurrency int           `json:"peak_concurrency"`
+	// Utilization is AvgConcurrency / max_polecats; 0 when capacity is unlimited.
+	Utilization float64 `json:"utilization"`
+	// UtilizationBasis says which capacity ActualStats divided by: the
+	// max_polecats recorded at dispatch, or the current config when the
+	// history does not record it. Empty for simulated stats.
+	UtilizationBasis string `json:"utilization_basis,omitempty"`
+}
+
+// Utilization bases for ActualStats.
+const (
+	UtilizationRecordedCapacity = "recorded_capacity"
+	UtilizationCurrentCapacity  = "current_capacity"
+)
+
+// ActualStats computes SimStats for the jobs as they were recorded. Jobs
+// still running at the end of the log are counted as busy until the last
+// observed event.
+//
+// Utilization is measured against the max_polecats recorded with each
+// dispatch, each value holding until the next dispatch. When any dispatched
+// job lacks one, it falls back to currentMaxPolecats (<= 0 = unlimited) and
+// UtilizationBasis says so.
+func ActualStats(jobs []SimJob, currentMaxPolecats int) SimStats {
+	runs := make([]simRun, 0, len(jobs))
+	for _, j := range jobs {
+		runs = append(runs, simRun{
+			enqueued:   j.EnqueuedAt,
+			dispatched: j.DispatchedAt,
+			done:       j.DoneAt,
+		})
+	}
+	stats := summarize(runs, currentMaxPolecats)
+	stats.UtilizationBasis = UtilizationCurrentCapacity
+	if capacityHours, ok := recordedCapacityHours(jobs); ok {
+		stats.UtilizationBasis = UtilizationRecordedCapacity
+		stats.Utilization = 0
+		if capacityHours > 0 {
+			stats.Utilization = stats.AvgConcurrency * stats.Span.Hours() / capacityHours
+		}
+	}
+	return stats
+}
+
+// recordedCapacityHours integrates the recorded max_polecats over the span
+// summarize uses (first to last observed event). Each dispatch's value holds
+// until the next dispatch; the first also covers the time before it. Returns
+// false when some dispatched job has no recorded value or nothing was
+// dispatched, and 0 when any recorded capacity was unlimited.
+func recordedCapacityHours(jobs []SimJob) (float64, bool) {
+	var start, end time.Time
+	var dispatched []SimJob
+	for _, j := range jobs {
+		for _, t := range []time.Time{j.EnqueuedAt, j.DispatchedAt, j.DoneAt} {
+			if t.IsZero() {
+				continue
+			}
+			if start.IsZero() || t.Before(start) {
+				start = t
+			}
+			if t.After(end) {
+				end = t
+			}
+		}
+		if j.DispatchedAt.IsZero() {
+			continue
+		}
+		if j.MaxPolecats == nil {
+			return 0, false
+		}
+		if *j.MaxPolecats <= 0 {
+			return 0, true
+		}
+		dispatched = append(dispatched, j)
+	}
+	if len(dispatched) == 0 {
+		return 0, false
+	}
+	sort.SliceStable(dispatched, func(i, k int) bool {
+		return dispatched[i].DispatchedAt.Before(dispatched[k].DispatchedAt)
+	})
+
+	var hours float64
+	from := start
+	for i, j := range dispatched {
+		until := end
+		if i+1 < len(dispatched) {
+			until = dispatched[i+1].DispatchedAt
+		}
+		hours += float64(*j.MaxPolecats) * until.Sub(from).Hours()
+		from = until
+	}
+	return hours, true
+}
+
+// Simulate replays the enqueue times of jobs against cfg and returns the
+// resulting stats. Each tick mirrors one daemon heartbeat running
+// gt scheduler run: dispatch windows are applied with FilterOpen, and the
+// beads to start are chosen by PlanDispatch using the free capacity and batch
+// size from cfg, spaced by the configured spawn delay. Polecats run for the
+// job's recorded service time, or the median recorded service time when the
+// job never finished in the log.
+//
+// Beads dispatch in FIFO order: the event log does not carry priorities or
+// deadlines, so slack ordering has nothing to reorder.
+//
+// With max_polecats <= 0 (direct dispatch), beads start at enqueue time, or
+// at the next window opening when slung outside a dispatch window.
+func Simulate(jobs []SimJob, cfg *SchedulerConfig, tick time.Duration) (SimStats, error) {
+	windows, err := CompileDispatchWindows(cfg)
+	if err != nil {
+		return SimStats{}, err
+	}
+	if tick <= 0 {
+		tick = 3 * time.Minute
+	}
+
+	arrivals := make([]SimJob, len(jobs))
+	copy(arrivals, jobs)
+	sort.SliceStable(arrivals, func(i, k int) bool {
+		return arrivals[i].EnqueuedAt.Before(arrivals[k].EnqueuedAt)
+	})
+	service := serviceTimes(arrivals)
+
+	maxPolecats := cfg.GetMaxPolecats()
+	runs := make([]simRun, len(arrivals))
+	for i, j := range arrivals {
+		runs[i] = simRun{enqueued: j.EnqueuedAt}
+	}
+	if len(arrivals) == 0 {
+		return summarize(runs, maxPolecats), nil
+	}
+
+	if maxPolecats <= 0 {
+		for i, j := range arrivals {
+			start, ok := windows.NextOpen(j.Rig, j.EnqueuedAt)
+			if !ok {
+				continue
+			}
+			runs[i].dispatched = start
+			runs[i].done = start.Add(service[i])
+		}
+		return summarize(runs, maxPolecats), nil
+	}
+
+	batchSize := cfg.GetBatchSize()
+	spawnDelay := cfg.GetSpawnDelay()
+	horizon := arrivals[len(arrivals)-1].EnqueuedAt.Add(simHorizon)
+
+	var queue []int // indexes into arrivals, FIFO
+	next := 0
+	for now := arrivals[0].EnqueuedAt; !now.After(horizon); now = now.Add(tick) {
+		for next < len(arrivals) && !arrivals[next].EnqueuedAt.After(now) {
+			queue = append(queue, next)
+			next++
+		}
+		if len(queue) == 0 {
+			if next == len(arrivals) {
+				break
+			}
+			continue
+		}
+
+		running := 0
+		for _, r := range runs {
+			if !r.dispatched.IsZero() && r.done.After(now) {
+				running++
+			}
+		}
+
+		ready := make([]PendingBead, len(queue))
+		for k, idx := range queue {
+			ready[k] = PendingBead{ID: arrivals[idx].ID, TargetRig: arrivals[idx].Rig}
+		}
+		open, _ := windows.FilterOpen(ready, now)
+		plan := PlanDispatch(maxPolecats-running, batchSize, open)
+
+		for k, b := range plan.ToDispatch {
+			for _, idx := range queue {
+				if arrivals[idx].ID == b.ID && runs[idx].dispatched.IsZero() {
+					at := now.Add(time.Duration(k) * spawnDelay)
+					runs[idx].dispatched = at
+					runs[idx].done = at.Add(service[idx])
+					break
+				}
+			}
+		}
+		remaining := queue[:0]
+		for _, idx := range queue {
+			if runs[idx].dispatched.IsZero() {
+				remaining = append(remaining, idx)
+			}
+		}
+		queue = remaining
+	}
+	return summarize(runs, maxPolecats), nil
+}
+
+// serviceTimes returns the run time to simulate for each job: its recorded
+// service time, or the median of all recorded service times.
+func serviceTimes(jobs []SimJob) []time.Duration {
+	var known []time.Duration
+	for _, j := range jobs {
+		if d, ok := j.serviceTime(); ok {
+			known = append(known, d)
+		}
+	}
+	fallback := DefaultSimServiceTime
+	if len(known) > 0 {
+		fallback = percentile(known, 50)
+	}
+	out := make([]time.Duration, len(jobs))
+	for i, j := range jobs {
+		if d, ok := j.serviceTime(); ok {
+			out[i] = d
+		} else {
+			out[i] = fallback
+		}
+	}
+	return out
+}
+
+// simRun is the timeline of one job within a run.
+type simRun struct {
+	enqueued, dispatched, done time.Time
+}
+
+func summarize(runs []simRun, maxPolecats int) SimStats {
+	stats := SimStats{Jobs: len(runs)}
+	if len(runs) == 0 {
+		return stats
+	}
+
+	var start, end time.Time
+	extend := func(t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if start.IsZero() || t.Before(start) {
+			start = t
+		}
+		if t.After(end) {
+			end = t
+		}
+	}
+	var waits []time.Duration
+	for _, r := range runs {
+		extend(r.enqueued)
+		extend(r.dispatched)
+		extend(r.done)
+		if r.dispatched.IsZero() {
+			continue
+		}
+		stats.Dispatched++
+		if !r.done.IsZero() {
+			stats.Completed++
+		}
+		wait := r.dispatched.Sub(r.enqueued)
+		if wait < 0 {
+			wait = 0
+		}
+		waits = append(waits, wait)
+	}
+
+	if len(waits) > 0 {
+		var total time.Duration
+		for _, w := range waits {
+			total += w
+		}
+		stats.MeanWait = total / time.Duration(len(waits))
+		stats.P50Wait = percentile(waits, 50)
+		stats.P90Wait = percentile(waits, 90)
+		stats.MaxWait = percentile(waits, 100)
+	}
+
+	stats.Span = end.Sub(start)
+	if stats.Span <= 0 {
+		return stats
+	}
+	stats.ThroughputPerHr = float64(stats.Completed) / stats.Span.Hours()
+
+	// Sweep dispatch/done edges for average and peak concurrency.
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	var busy time.Duration
+	for _, r := range runs {
+		if r.dispatched.IsZero() {
+			continue
+		}
+		stop := r.done
+		if stop.IsZero() {
+			stop = end
+		}
+		busy += stop.Sub(r.dispatched)
+		edges = append(edges, edge{r.dispatched, 1}, edge{stop, -1})
+	}
+	sort.Slice(edges, func(i, k int) bool {
+		if edges[i].at.Equal(edges[k].at) {
+			return edges[i].delta < edges[k].delta // release before acquire
+		}
+		return edges[i].at.Before(edges[k].at)
+	})
+	level := 0
+	for _, e := range edges {
+		level += e.delta
+		if level > stats.PeakConcurrency {
+			stats.PeakConcurrency = level
+		}
+	}
+	stats.AvgConcurrency = busy.Hours() / stats.Span.Hours()
+	if maxPolecats > 0 {
+		stats.Utilization = stats.AvgConcurrency / float64(maxPolecats)
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile (nearest rank) of ds without
+// modifying it.
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(ds))
+	copy(sorted, ds)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i] < sorted[k] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,159 @@
+package capacity
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func simJobs(t0 time.Time) []SimJob {
+	// Four beads enqueued together; historically all ran in parallel for 1h.
+	var jobs []SimJob
+	for _, id := range []string{"a", "b", "c", "d"} {
+		jobs = append(jobs, SimJob{
+			ID:           id,
+			Rig:          "gastown",
+			EnqueuedAt:   t0,
+			DispatchedAt: t0,
+			DoneAt:       t0.Add(time.Hour),
+		})
+	}
+	return jobs
+}
+
+func TestActualStats(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	jobs := simJobs(t0)
+	jobs[3].DispatchedAt = t0.Add(30 * time.Minute)
+	jobs[3].DoneAt = t0.Add(2 * time.Hour)
+
+	st := ActualStats(jobs, 4)
+	if st.Jobs != 4 || st.Dispatched != 4 || st.Completed != 4 {
+		t.Fatalf("counts = %d/%d/%d, want 4/4/4", st.Jobs, st.Dispatched, st.Completed)
+	}
+	if st.MaxWait != 30*time.Minute || st.P50Wait != 0 {
+		t.Errorf("waits p50=%v max=%v, want 0 and 30m", st.P50Wait, st.MaxWait)
+	}
+	if st.PeakConcurrency != 4 {
+		t.Errorf("peak = %d, want 4", st.PeakConcurrency)
+	}
+	if st.Span != 2*time.Hour || st.ThroughputPerHr != 2 {
+		t.Errorf("span=%v throughput=%v, want 2h and 2/h", st.Span, st.ThroughputPerHr)
+	}
+	// Busy time 1h*3 + 1.5h = 4.5h over 2h.
+	if st.AvgConcurrency != 2.25 || st.Utilization != 2.25/4 {
+		t.Errorf("avg=%v util=%v, want 2.25 and 0.5625", st.AvgConcurrency, st.Utilization)
+	}
+	if st.UtilizationBasis != UtilizationCurrentCapacity {
+		t.Errorf("basis = %q, want %q for unrecorded capacity", st.UtilizationBasis, UtilizationCurrentCapacity)
+	}
+}
+
+func TestActualStatsRecordedCapacity(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	jobs := simJobs(t0)
+	jobs[3].DispatchedAt = t0.Add(30 * time.Minute)
+	jobs[3].DoneAt = t0.Add(2 * time.Hour)
+	wide, narrow := 8, 4
+	for i := range jobs {
+		jobs[i].MaxPolecats = &wide
+	}
+	jobs[3].MaxPolecats = &narrow
+
+	// The current config must not matter once capacity is recorded.
+	st := ActualStats(jobs, 1)
+	if st.UtilizationBasis != UtilizationRecordedCapacity {
+		t.Fatalf("basis = %q, want %q", st.UtilizationBasis, UtilizationRecordedCapacity)
+	}
+	// Capacity 8 for the first 30m and 4 for the last 1.5h: 10 polecat-hours.
+	// Busy time is 4.5h.
+	if want := 4.5 / 10; math.Abs(st.Utilization-want) > 1e-9 {
+		t.Errorf("util = %v, want %v", st.Utilization, want)
+	}
+
+	unlimited := 0
+	jobs[3].MaxPolecats = &unlimited
+	if st := ActualStats(jobs, 4); st.Utilization != 0 || st.UtilizationBasis != UtilizationRecordedCapacity {
+		t.Errorf("unlimited recorded capacity: util=%v basis=%q, want 0 and recorded", st.Utilization, st.UtilizationBasis)
+	}
+}
+
+func TestSimulateCapacityLimit(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	maxPolecats, batch := 2, 2
+	cfg := &SchedulerConfig{MaxPolecats: &maxPolecats, BatchSize: &batch}
+
+	st, err := Simulate(simJobs(t0), cfg, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Dispatched != 4 || st.PeakConcurrency != 2 {
+		t.Fatalf("dispatched=%d peak=%d, want 4 and 2", st.Dispatched, st.PeakConcurrency)
+	}
+	// Second pair waits for the first to finish at the 1h tick.
+	if st.MaxWait != time.Hour {
+		t.Errorf("max wait = %v, want 1h", st.MaxWait)
+	}
+	if st.Span != 2*time.Hour || st.Utilization != 1 {
+		t.Errorf("span=%v util=%v, want 2h and 1", st.Span, st.Utilization)
+	}
+}
+
+func TestSimulateBatchAndSpawnDelay(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	maxPolecats, batch := 10, 1
+	cfg := &SchedulerConfig{MaxPolecats: &maxPolecats, BatchSize: &batch, SpawnDelay: "1m"}
+
+	st, err := Simulate(simJobs(t0), cfg, 3*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One bead per 3m tick: waits 0, 3m, 6m, 9m.
+	if st.MaxWait != 9*time.Minute || st.MeanWait != 4*time.Minute+30*time.Second {
+		t.Errorf("max=%v mean=%v, want 9m and 4m30s", st.MaxWait, st.MeanWait)
+	}
+
+	batch = 4
+	st, err = Simulate(simJobs(t0), cfg, 3*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One tick, spawn delay staggers starts by 1m.
+	if st.MaxWait != 3*time.Minute {
+		t.Errorf("with batch 4 max wait = %v, want 3m", st.MaxWait)
+	}
+}
+
+func TestSimulateDirectModeWithWindows(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC) // Monday, before window
+	cfg := &SchedulerConfig{Windows: businessHours()}
+
+	st, err := Simulate(simJobs(t0), cfg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Dispatched != 4 || st.MaxWait != 2*time.Hour {
+		t.Errorf("dispatched=%d max wait=%v, want 4 and 2h", st.Dispatched, st.MaxWait)
+	}
+}
+
+func TestSimulateUsesMedianServiceForUnfinishedJobs(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	jobs := simJobs(t0)
+	jobs[3].DoneAt = time.Time{}
+
+	st, err := Simulate(jobs, &SchedulerConfig{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Completed != 4 || st.Span != time.Hour {
+		t.Errorf("completed=%d span=%v, want 4 and 1h", st.Completed, st.Span)
+	}
+}
+
+func TestSimulateInvalidWindows(t *testing.T) {
+	cfg := &SchedulerConfig{Windows: &DispatchSchedule{Timezone: "Mars/Olympus"}}
+	if _, err := Simulate(nil, cfg, 0); err == nil {
+		t.Error("expected error for invalid windows")
+	}
+}
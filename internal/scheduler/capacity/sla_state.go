@@ -0,0 +1,52 @@
+package capacity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/atomicfile"
+)
+
+// SLARiskState records which SLA risks have been reported and escalated.
+// Stored at <townRoot>/.runtime/scheduler-sla-risk.json. The daemon starts a
+// new `gt scheduler run` on every heartbeat, so this is what keeps an
+// unchanged risk from being reported and escalated on every tick.
+type SLARiskState struct {
+	// Escalated maps work bead IDs to their last SLA-risk escalation.
+	Escalated map[string]time.Time `json:"escalated,omitempty"`
+
+	// Reported is the sorted set of at-risk work bead IDs last reported.
+	Reported []string `json:"reported,omitempty"`
+}
+
+// slaRiskStateFile returns the path to the SLA-risk state file.
+func slaRiskStateFile(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "scheduler-sla-risk.json")
+}
+
+// LoadSLARiskState loads the SLA-risk state, returning an empty state if the
+// file doesn't exist.
+func LoadSLARiskState(townRoot string) (*SLARiskState, error) {
+	data, err := os.ReadFile(slaRiskStateFile(townRoot)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &SLARiskState{Escalated: map[string]time.Time{}}, nil
+		}
+		return nil, err
+	}
+	var state SLARiskState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Escalated == nil {
+		state.Escalated = map[string]time.Time{}
+	}
+	return &state, nil
+}
+
+// SaveSLARiskState writes the SLA-risk state to disk atomically.
+func SaveSLARiskState(townRoot string, state *SLARiskState) error {
+	return atomicfile.EnsureDirAndWriteJSON(slaRiskStateFile(townRoot), state)
+}
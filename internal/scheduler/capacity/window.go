@@ -0,0 +1,232 @@
+package capacity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/scheduler/cron"
+)
+
+// DispatchWindow is a recurring period during which the scheduler may start
+// new polecats. The window opens at each Cron match and stays open for Duration.
+type DispatchWindow struct {
+	// Cron is a five-field cron expression for when the window opens
+	// (e.g., "0 9 * * 1-5" for weekdays at 09:00).
+	Cron string `json:"cron"`
+
+	// Duration is how long the window stays open after each opening
+	// (Go duration, e.g., "8h").
+	Duration string `json:"duration"`
+}
+
+// DispatchSchedule restricts when the scheduler may dispatch.
+// A schedule with no windows is always open except on blackout dates.
+type DispatchSchedule struct {
+	// Timezone is the IANA zone used to evaluate cron expressions and
+	// blackout dates (e.g., "America/Los_Angeles"). Default: local time.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Windows lists the dispatch windows. Dispatch is allowed while any
+	// window is open. Empty = no time restriction.
+	Windows []DispatchWindow `json:"windows,omitempty"`
+
+	// Blackouts lists dates (YYYY-MM-DD, in Timezone) with no dispatch at all,
+	// even inside a window.
+	Blackouts []string `json:"blackouts,omitempty"`
+}
+
+// nextOpenSearchLimit bounds NextOpen iteration so a schedule that never
+// opens (e.g., every window falls on a blackout) returns instead of spinning.
+const nextOpenSearchLimit = 1000
+
+// compiledWindow is a DispatchWindow with its expression parsed.
+type compiledWindow struct {
+	schedule *cron.Schedule
+	duration time.Duration
+}
+
+// compiledSchedule is a DispatchSchedule ready for evaluation.
+type compiledSchedule struct {
+	loc       *time.Location
+	windows   []compiledWindow
+	blackouts map[string]bool
+}
+
+// Validate reports configuration errors: unknown timezone, bad cron
+// expressions, non-positive durations, or malformed blackout dates.
+func (s *DispatchSchedule) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+func (s *DispatchSchedule) compile() (*compiledSchedule, error) {
+	if s == nil {
+		return nil, nil
+	}
+	c := &compiledSchedule{loc: time.Local, blackouts: make(map[string]bool)}
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("dispatch schedule timezone %q: %w", s.Timezone, err)
+		}
+		c.loc = loc
+	}
+	for i, w := range s.Windows {
+		sched, err := cron.Parse(w.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("dispatch window %d: %w", i, err)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("dispatch window %d: duration %q: expected positive Go duration (e.g. 8h)", i, w.Duration)
+		}
+		c.windows = append(c.windows, compiledWindow{schedule: sched, duration: d})
+	}
+	for _, date := range s.Blackouts {
+		if _, err := time.ParseInLocation("2006-01-02", date, c.loc); err != nil {
+			return nil, fmt.Errorf("dispatch blackout %q: expected YYYY-MM-DD", date)
+		}
+		c.blackouts[date] = true
+	}
+	return c, nil
+}
+
+// isOpen reports whether dispatch is allowed at t. A nil schedule is always open.
+func (c *compiledSchedule) isOpen(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	local := t.In(c.loc)
+	if c.blackouts[local.Format("2006-01-02")] {
+		return false
+	}
+	if len(c.windows) == 0 {
+		return true
+	}
+	for _, w := range c.windows {
+		opened := w.schedule.Prev(local)
+		if !opened.IsZero() && local.Before(opened.Add(w.duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextCandidate returns the next instant after t at which the schedule could
+// change from closed to open: midnight after a blackout day, or the next
+// window opening.
+func (c *compiledSchedule) nextCandidate(t time.Time) time.Time {
+	local := t.In(c.loc)
+	if c.blackouts[local.Format("2006-01-02")] {
+		return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, c.loc)
+	}
+	var next time.Time
+	for _, w := range c.windows {
+		n := w.schedule.Next(local)
+		if n.IsZero() {
+			continue
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
+
+// nextOpen returns the earliest time at or after t when the schedule is open.
+func (c *compiledSchedule) nextOpen(t time.Time) (time.Time, bool) {
+	cur := t
+	for i := 0; i < nextOpenSearchLimit; i++ {
+		if c.isOpen(cur) {
+			return cur, true
+		}
+		cur = c.nextCandidate(cur)
+		if cur.IsZero() {
+			return time.Time{}, false
+		}
+	}
+	return time.Time{}, false
+}
+
+// DispatchWindows evaluates the town schedule together with per-rig schedules.
+// A rig may receive new polecats only while both its own schedule (if any)
+// and the town schedule are open.
+type DispatchWindows struct {
+	town *compiledSchedule
+	rigs map[string]*compiledSchedule
+}
+
+// CompileDispatchWindows validates and compiles the windows configured on c.
+func CompileDispatchWindows(c *SchedulerConfig) (*DispatchWindows, error) {
+	w := &DispatchWindows{rigs: make(map[string]*compiledSchedule)}
+	if c == nil {
+		return w, nil
+	}
+	town, err := c.Windows.compile()
+	if err != nil {
+		return nil, fmt.Errorf("scheduler.windows: %w", err)
+	}
+	w.town = town
+	for rig, s := range c.RigWindows {
+		compiled, err := s.compile()
+		if err != nil {
+			return nil, fmt.Errorf("scheduler.rig_windows[%s]: %w", rig, err)
+		}
+		if compiled != nil {
+			w.rigs[rig] = compiled
+		}
+	}
+	return w, nil
+}
+
+// Configured reports whether any town or rig schedule is set.
+func (w *DispatchWindows) Configured() bool {
+	return w != nil && (w.town != nil || len(w.rigs) > 0)
+}
+
+// IsOpen reports whether the scheduler may dispatch to rig at t.
+// Pass an empty rig to check only the town schedule.
+func (w *DispatchWindows) IsOpen(rig string, t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	return w.town.isOpen(t) && w.rigs[rig].isOpen(t)
+}
+
+// NextOpen returns the earliest time at or after t when dispatch to rig is
+// allowed. Returns false if no opening exists within the search horizon.
+func (w *DispatchWindows) NextOpen(rig string, t time.Time) (time.Time, bool) {
+	if w == nil {
+		return t, true
+	}
+	cur := t
+	for i := 0; i < nextOpenSearchLimit; i++ {
+		townOpen, ok := w.town.nextOpen(cur)
+		if !ok {
+			return time.Time{}, false
+		}
+		rigOpen, ok := w.rigs[rig].nextOpen(townOpen)
+		if !ok {
+			return time.Time{}, false
+		}
+		if rigOpen.Equal(townOpen) {
+			return rigOpen, true
+		}
+		cur = rigOpen
+	}
+	return time.Time{}, false
+}
+
+// FilterOpen splits ready beads into those whose target rig is inside its
+// dispatch window at now and those held until the window opens. Order is
+// preserved in both slices.
+func (w *DispatchWindows) FilterOpen(ready []PendingBead, now time.Time) (open, held []PendingBead) {
+	for _, b := range ready {
+		if w.IsOpen(b.TargetRig, now) {
+			open = append(open, b)
+		} else {
+			held = append(held, b)
+		}
+	}
+	return open, held
+}
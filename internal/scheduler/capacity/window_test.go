@@ -0,0 +1,137 @@
+package capacity
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func businessHours() *DispatchSchedule {
+	return &DispatchSchedule{
+		Timezone:  "UTC",
+		Windows:   []DispatchWindow{{Cron: "0 9 * * 1-5", Duration: "8h"}},
+		Blackouts: []string{"2026-03-04"},
+	}
+}
+
+func TestCompileDispatchWindowsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *SchedulerConfig
+		wantErr string
+	}{
+		{"bad timezone", &SchedulerConfig{Windows: &DispatchSchedule{Timezone: "Mars/Olympus"}}, "timezone"},
+		{"bad cron", &SchedulerConfig{Windows: &DispatchSchedule{Windows: []DispatchWindow{{Cron: "0 25 * * *", Duration: "1h"}}}}, "hour"},
+		{"bad duration", &SchedulerConfig{Windows: &DispatchSchedule{Windows: []DispatchWindow{{Cron: "0 9 * * *", Duration: "0s"}}}}, "duration"},
+		{"bad blackout", &SchedulerConfig{Windows: &DispatchSchedule{Blackouts: []string{"03/04/2026"}}}, "blackout"},
+		{"bad rig window", &SchedulerConfig{RigWindows: map[string]*DispatchSchedule{"gastown": {Windows: []DispatchWindow{{Cron: "x", Duration: "1h"}}}}}, "rig_windows[gastown]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileDispatchWindows(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatchWindowsIsOpen(t *testing.T) {
+	w, err := CompileDispatchWindows(&SchedulerConfig{
+		Windows: businessHours(),
+		RigWindows: map[string]*DispatchSchedule{
+			"nightly": {Timezone: "UTC", Windows: []DispatchWindow{{Cron: "0 13 * * *", Duration: "2h"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 3, day, hour, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		rig  string
+		t    time.Time
+		want bool
+	}{
+		{"weekday inside window", "gastown", at(2, 10, 0), true},
+		{"weekday before window", "gastown", at(2, 8, 59), false},
+		{"weekday at window close", "gastown", at(2, 17, 0), false},
+		{"weekend", "gastown", at(7, 10, 0), false},
+		{"blackout date", "gastown", at(4, 10, 0), false},
+		{"rig window open and town open", "nightly", at(2, 14, 0), true},
+		{"town open but rig closed", "nightly", at(2, 10, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.IsOpen(tt.rig, tt.t); got != tt.want {
+				t.Errorf("IsOpen(%s, %v) = %v, want %v", tt.rig, tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchWindowsNextOpen(t *testing.T) {
+	w, err := CompileDispatchWindows(&SchedulerConfig{
+		Windows: businessHours(),
+		RigWindows: map[string]*DispatchSchedule{
+			"nightly": {Timezone: "UTC", Windows: []DispatchWindow{{Cron: "0 20 * * *", Duration: "2h"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tuesday 18:00 → Wednesday is a blackout → Thursday 09:00.
+	got, ok := w.NextOpen("gastown", time.Date(2026, 3, 3, 18, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("NextOpen = %v (%v), want %v", got, ok, want)
+	}
+
+	// Already open → now.
+	now := time.Date(2026, 3, 2, 11, 30, 0, 0, time.UTC)
+	if got, ok := w.NextOpen("gastown", now); !ok || !got.Equal(now) {
+		t.Errorf("NextOpen while open = %v, want %v", got, now)
+	}
+
+	// Rig and town windows never overlap → no opening.
+	if _, ok := w.NextOpen("nightly", now); ok {
+		t.Error("NextOpen for disjoint rig/town windows reported an opening")
+	}
+}
+
+func TestDispatchWindowsUnconfigured(t *testing.T) {
+	w, err := CompileDispatchWindows(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Configured() {
+		t.Error("nil config reported windows configured")
+	}
+	if !w.IsOpen("any", time.Now()) {
+		t.Error("unconfigured windows must always be open")
+	}
+}
+
+func TestFilterOpen(t *testing.T) {
+	w, err := CompileDispatchWindows(&SchedulerConfig{
+		RigWindows: map[string]*DispatchSchedule{
+			"night": {Timezone: "UTC", Windows: []DispatchWindow{{Cron: "0 22 * * *", Duration: "6h"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := []PendingBead{
+		{ID: "a", TargetRig: "day"},
+		{ID: "b", TargetRig: "night"},
+		{ID: "c", TargetRig: "day"},
+	}
+	open, held := w.FilterOpen(ready, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	if len(open) != 2 || open[0].ID != "a" || open[1].ID != "c" {
+		t.Errorf("open = %v, want [a c]", open)
+	}
+	if len(held) != 1 || held[0].ID != "b" {
+		t.Errorf("held = %v, want [b]", held)
+	}
+}
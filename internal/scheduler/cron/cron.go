@@ -0,0 +1,212 @@
+// Package cron parses standard five-field cron expressions and computes
+// matching times. It is intentionally small: minute granularity, no seconds
+// field, no @-macros beyond the common aliases, no external dependencies.
+//
+// Field order: minute hour day-of-month month day-of-week.
+// Each field accepts "*", single values, ranges ("1-5"), steps ("*/15",
+// "9-17/2"), and comma-separated lists. Day-of-week uses 0-6 with 0 = Sunday
+// (7 is also accepted as Sunday). When both day-of-month and day-of-week are
+// restricted, a time matches if either does (traditional cron semantics).
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr   string
+	minute uint64 // bits 0-59
+	hour   uint64 // bits 0-23
+	dom    uint64 // bits 1-31
+	month  uint64 // bits 1-12
+	dow    uint64 // bits 0-6
+
+	domStar bool
+	dowStar bool
+}
+
+// aliases maps the common @-macros to their five-field equivalents.
+var aliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// searchLimit bounds Next so an expression that can never match (e.g. Feb 30)
+// returns instead of looping forever.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Parse parses a five-field cron expression.
+func Parse(expr string) (*Schedule, error) {
+	trimmed := strings.TrimSpace(expr)
+	if alias, ok := aliases[trimmed]; ok {
+		trimmed = alias
+	}
+	fields := strings.Fields(trimmed)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron expression %q: minute: %w", expr, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron expression %q: hour: %w", expr, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day-of-month: %w", expr, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron expression %q: month: %w", expr, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day-of-week: %w", expr, err)
+	}
+	// Fold 7 (Sunday) onto 0.
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow &^ (1 << 7)) | 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the original expression.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Matches reports whether t (truncated to the minute) satisfies the schedule.
+// t is evaluated in its own location.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute&(1<<uint(t.Minute())) != 0 &&
+		s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.month&(1<<uint(t.Month())) != 0 &&
+		s.dayMatches(t)
+}
+
+// Next returns the first matching minute strictly after t, in t's location.
+// Returns the zero time if nothing matches within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	cur := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for cur.Before(limit) {
+		if s.month&(1<<uint(cur.Month())) == 0 {
+			cur = time.Date(cur.Year(), cur.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(cur) {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(cur.Hour())) == 0 {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day(), cur.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(cur.Minute())) == 0 {
+			cur = cur.Add(time.Minute)
+			continue
+		}
+		return cur
+	}
+	return time.Time{}
+}
+
+// Prev returns the most recent matching minute at or before t, in t's location.
+// Returns the zero time if nothing matched within the previous five years.
+func (s *Schedule) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	cur := t.Truncate(time.Minute)
+	limit := t.Add(-searchLimit)
+
+	for cur.After(limit) {
+		if s.month&(1<<uint(cur.Month())) == 0 {
+			// Last minute of the previous month.
+			cur = time.Date(cur.Year(), cur.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if !s.dayMatches(cur) {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if s.hour&(1<<uint(cur.Hour())) == 0 {
+			cur = time.Date(cur.Year(), cur.Month(), cur.Day(), cur.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(cur.Minute())) == 0 {
+			cur = cur.Add(-time.Minute)
+			continue
+		}
+		return cur
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// parseField parses one cron field into a bitset over [lo, hi].
+func parseField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty list element in %q", field)
+		}
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:idx], n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+			start, end = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start, end = v, v
+			if step > 1 {
+				// "5/15" means "starting at 5, every 15".
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
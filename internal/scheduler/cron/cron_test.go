@@ -0,0 +1,105 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 5, 6, 0, 0, time.UTC), true},
+		{"0 9 * * 1-5", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), true},   // Monday
+		{"0 9 * * 1-5", time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), false},  // Sunday
+		{"*/15 * * * *", time.Date(2026, 3, 2, 9, 45, 0, 0, time.UTC), true}, // step
+		{"*/15 * * * *", time.Date(2026, 3, 2, 9, 46, 0, 0, time.UTC), false},
+		{"0 0 * * 7", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), true}, // 7 = Sunday
+		// Both day fields restricted: OR semantics.
+		{"0 0 15 * 1", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 15 * 1", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 15 * 1", time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC), false},
+		{"@daily", time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := s.Matches(tt.at); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.expr, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestNextAndPrev(t *testing.T) {
+	s, err := Parse("30 9 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Friday 10:00 → next is Monday 09:30.
+	fri := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
+	if got, want := s.Next(fri), time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+	// Monday 09:00 → previous is Friday 09:30.
+	mon := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	if got, want := s.Prev(mon), time.Date(2026, 3, 6, 9, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Prev = %v, want %v", got, want)
+	}
+	// Prev is inclusive of the current minute, Next is exclusive.
+	at := time.Date(2026, 3, 9, 9, 30, 20, 0, time.UTC)
+	if got := s.Prev(at); !got.Equal(time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Prev inclusive = %v", got)
+	}
+	if got := s.Next(at); !got.Equal(time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Next exclusive = %v", got)
+	}
+}
+
+func TestNextRespectsLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC).In(loc))
+	if got.Hour() != 9 || got.Location() != loc {
+		t.Errorf("Next = %v, want 09:00 New York", got)
+	}
+}
+
+func TestNextImpossible(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next for Feb 30 = %v, want zero", got)
+	}
+}
@@ -0,0 +1,221 @@
+package tmux
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ControlStream follows a session's pane output through a read-only
+// control-mode client (tmux -C attach -r). Control clients don't resize the
+// session or accept keystrokes, so any number can watch the same pane.
+type ControlStream struct {
+	stdin     io.WriteCloser
+	output    chan []byte
+	done      chan struct{}
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// controlOutputBuffer is how many %output chunks may queue before the reader
+// blocks on a slow consumer.
+const controlOutputBuffer = 256
+
+// AttachControl starts a read-only control-mode client on the session and
+// streams the output of its active pane. The stream ends when ctx is
+// cancelled, Close is called, or the session exits.
+func (t *Tmux) AttachControl(ctx context.Context, session string) (*ControlStream, error) {
+	pane, err := t.run("display-message", "-p", "-t", session, "#{pane_id}")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := t.commandContext(ctx, "-C", "attach-session", "-r", "-t", session)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("tmux control stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("tmux control stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting tmux control client: %w", err)
+	}
+
+	s := &ControlStream{
+		stdin:  stdin,
+		output: make(chan []byte, controlOutputBuffer),
+		done:   make(chan struct{}),
+		ready:  make(chan struct{}),
+	}
+	go func() {
+		defer close(s.output)
+		defer s.markReady()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	read:
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "%exit") {
+				break
+			}
+			// tmux answers the attach with a %begin/%end block and reports
+			// the session; after either the client receives pane output.
+			if strings.HasPrefix(line, "%end") || strings.HasPrefix(line, "%error") || strings.HasPrefix(line, "%session-changed") {
+				s.markReady()
+			}
+			id, data, ok := parseControlOutput(line)
+			if !ok || id != pane {
+				continue
+			}
+			select {
+			case s.output <- data:
+			case <-s.done:
+				break read
+			}
+		}
+		_ = stdin.Close()
+		_, _ = io.Copy(io.Discard, stdout) // drain so tmux can exit before Wait
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil && !s.closed() {
+			s.setErr(fmt.Errorf("tmux control client: %w", err))
+		}
+	}()
+	return s, nil
+}
+
+// Ready is closed once the control client is attached and receiving pane
+// output, or when the stream ends. Output produced before then is not seen.
+func (s *ControlStream) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func (s *ControlStream) markReady() {
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+// Output returns the pane output. The channel is closed when the stream ends.
+func (s *ControlStream) Output() <-chan []byte {
+	return s.output
+}
+
+// Close detaches the control client. Closing stdin makes tmux exit cleanly.
+func (s *ControlStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	_ = s.stdin.Close()
+	return nil
+}
+
+func (s *ControlStream) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns the error that ended the stream, if any.
+func (s *ControlStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *ControlStream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// parseControlOutput decodes a "%output %<pane> <data>" notification.
+func parseControlOutput(line string) (pane string, data []byte, ok bool) {
+	rest, ok := strings.CutPrefix(line, "%output ")
+	if !ok {
+		return "", nil, false
+	}
+	pane, escaped, ok := strings.Cut(rest, " ")
+	if !ok {
+		return "", nil, false
+	}
+	return pane, unescapeControlOutput(escaped), true
+}
+
+// unescapeControlOutput reverses tmux's control-mode escaping, which writes
+// bytes below 0x20 and backslash as three-digit octal (\015, \134).
+func unescapeControlOutput(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(n))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// CaptureScreen returns the visible screen of a session's active pane with
+// colors, followed by an escape sequence restoring the cursor position, so a
+// terminal emulator can paint it before following live output.
+func (t *Tmux) CaptureScreen(session string) ([]byte, error) {
+	// Not t.run: its TrimSpace would eat leading indentation on the first row.
+	var stderr strings.Builder
+	cmd := t.commandContext(context.Background(), "capture-pane", "-p", "-e", "-t", session)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, t.wrapError(err, stderr.String(), []string{"capture-pane"})
+	}
+	content := strings.TrimRight(string(out), "\n")
+	cursor, err := t.run("display-message", "-p", "-t", session, "#{cursor_x},#{cursor_y}")
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(strings.ReplaceAll(content, "\n", "\r\n"))
+	if x, y, ok := strings.Cut(cursor, ","); ok {
+		col, errX := strconv.Atoi(x)
+		row, errY := strconv.Atoi(y)
+		if errX == nil && errY == nil {
+			fmt.Fprintf(&b, "\x1b[%d;%dH", row+1, col+1)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// SendBytes writes raw input bytes to a session's active pane. Unlike
+// SendKeys it performs no key-name lookup, so escape sequences from a
+// terminal emulator (arrows, function keys) arrive unchanged.
+func (t *Tmux) SendBytes(session string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(data)+4)
+	args = append(args, "send-keys", "-t", session, "-H")
+	for _, b := range data {
+		args = append(args, hex.EncodeToString([]byte{b}))
+	}
+	_, err := t.run(args...)
+	return err
+}
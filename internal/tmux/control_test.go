@@ -0,0 +1,85 @@
+package tmux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseControlOutput(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantPane string
+		wantData string
+		wantOK   bool
+	}{
+		{`%output %0 hi\015\012`, "%0", "hi\r\n", true},
+		{`%output %3 \033[?2004h`, "%3", "\x1b[?2004h", true},
+		{`%output %1 back\134slash`, "%1", `back\slash`, true},
+		{`%output %1 trailing\01`, "%1", `trailing\01`, true},
+		{`%begin 1 2 0`, "", "", false},
+		{`%output %1`, "", "", false},
+	}
+	for _, tt := range tests {
+		pane, data, ok := parseControlOutput(tt.line)
+		if ok != tt.wantOK || pane != tt.wantPane || string(data) != tt.wantData {
+			t.Errorf("parseControlOutput(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.line, pane, data, ok, tt.wantPane, tt.wantData, tt.wantOK)
+		}
+	}
+}
+
+func TestAttachControl_StreamsAndSendsBytes(t *testing.T) {
+	tm := newTestTmux(t)
+	name := "gt-test-control-stream"
+	_ = tm.KillSession(name)
+	if err := tm.NewSessionWithCommand(name, t.TempDir(), "cat"); err != nil {
+		t.Fatalf("NewSessionWithCommand: %v", err)
+	}
+	defer func() { _ = tm.KillSession(name) }()
+
+	// Generous bound: under a full parallel test run tmux can be slow to
+	// start, but readiness, not the deadline, paces the test.
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	stream, err := tm.AttachControl(ctx, name)
+	if err != nil {
+		t.Fatalf("AttachControl: %v", err)
+	}
+	defer stream.Close()
+
+	// Input sent before the client is attached would echo unseen.
+	select {
+	case <-stream.Ready():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the control client to attach")
+	}
+	if err := tm.SendBytes(name, []byte("ping\r")); err != nil {
+		t.Fatalf("SendBytes: %v", err)
+	}
+	var got bytes.Buffer
+	for !bytes.Contains(got.Bytes(), []byte("ping")) {
+		select {
+		case data, ok := <-stream.Output():
+			if !ok {
+				t.Fatalf("stream ended early (err=%v), got %q", stream.Err(), got.String())
+			}
+			got.Write(data)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for output, got %q", got.String())
+		}
+	}
+
+	screen, err := tm.CaptureScreen(name)
+	if err != nil {
+		t.Fatalf("CaptureScreen: %v", err)
+	}
+	if !bytes.HasPrefix(screen, []byte("\x1b[H\x1b[2J")) || !bytes.Contains(screen, []byte("ping")) {
+		t.Errorf("CaptureScreen = %q", screen)
+	}
+
+	_ = stream.Close()
+	for range stream.Output() {
+	}
+}
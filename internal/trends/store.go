@@ -0,0 +1,486 @@
+// Package trends keeps a small embedded time-series history of town gauges.
+//
+// The daemon records one sample per heartbeat. Samples land in a raw tier
+// that is rolled up into hourly and then daily buckets (min/max/sum/count),
+// and each tier is pruned to its own retention, so the store stays a few
+// hundred kilobytes no matter how long the town runs. Everything lives in
+// JSONL files under <town>/.runtime/metrics.
+package trends
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/steveyegge/gastown/internal/atomicfile"
+)
+
+// Series sampled by the daemon.
+const (
+	AgentSessions   = "sessions.active"       // running agent tmux sessions
+	ActivePolecats  = "polecats.active"       // running polecat sessions
+	QueueDepth      = "scheduler.queue_depth" // beads waiting for scheduled dispatch
+	MergeQueueDepth = "mq.depth"              // open merge requests across rigs
+	MRWaitSeconds   = "mq.wait_max_seconds"   // age of the oldest open merge request
+	DoltLatencyMs   = "dolt.latency_ms"       // Dolt health probe round trip
+	DoltConnections = "dolt.connections"      // active Dolt server connections
+	CostTodayUSD    = "costs.today_usd"       // session costs recorded today
+	OpenEscalations = "escalations.open"      // open escalation beads
+)
+
+// Names lists the sampled series in display order.
+var Names = []string{
+	AgentSessions, ActivePolecats, QueueDepth, MergeQueueDepth, MRWaitSeconds,
+	DoltLatencyMs, DoltConnections, CostTodayUSD, OpenEscalations,
+}
+
+// Retention is how long each tier is kept.
+type Retention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// DefaultRetention keeps two days at heartbeat resolution, a month of hourly
+// buckets, and a year of daily buckets.
+var DefaultRetention = Retention{
+	Raw:    48 * time.Hour,
+	Hourly: 30 * 24 * time.Hour,
+	Daily:  365 * 24 * time.Hour,
+}
+
+const (
+	rawFile    = "raw.jsonl"
+	hourlyFile = "hourly.jsonl"
+	dailyFile  = "daily.jsonl"
+	stateFile  = "state.json"
+	lockFile   = ".lock"
+)
+
+// Dir returns the metrics directory for a town.
+func Dir(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "metrics")
+}
+
+// Store is a town's metrics history.
+type Store struct {
+	dir       string
+	retention Retention
+}
+
+// Open returns the store for a town. Files are created on first Record.
+func Open(townRoot string) *Store {
+	return &Store{dir: Dir(townRoot), retention: DefaultRetention}
+}
+
+// WithRetention returns a copy of the store using r.
+func (s *Store) WithRetention(r Retention) *Store {
+	c := *s
+	c.retention = r
+	return &c
+}
+
+// rawPoint is one line of the raw tier.
+type rawPoint struct {
+	T int64              `json:"t"`
+	V map[string]float64 `json:"v"`
+}
+
+// Agg summarizes the samples that fell into one bucket.
+type Agg struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Sum float64 `json:"sum"`
+	N   int     `json:"n"`
+}
+
+func (a *Agg) add(b Agg) {
+	if a.N == 0 {
+		*a = b
+		return
+	}
+	a.Min = math.Min(a.Min, b.Min)
+	a.Max = math.Max(a.Max, b.Max)
+	a.Sum += b.Sum
+	a.N += b.N
+}
+
+func single(v float64) Agg { return Agg{Min: v, Max: v, Sum: v, N: 1} }
+
+// bucket is one line of a rollup tier; T is the bucket start.
+type bucket struct {
+	T int64          `json:"t"`
+	V map[string]Agg `json:"v"`
+}
+
+// rollupState records how far each rollup has been computed.
+type rollupState struct {
+	HourlyThrough int64 `json:"hourly_through"` // unix start of the first hour not yet rolled up
+	DailyThrough  int64 `json:"daily_through"`  // unix start of the first day not yet rolled up
+}
+
+// Record appends a sample taken at t, then rolls up completed hours and days
+// and prunes expired data.
+func (s *Store) Record(t time.Time, values map[string]float64) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating metrics dir: %w", err)
+	}
+	fl := flock.New(filepath.Join(s.dir, lockFile))
+	if err := fl.Lock(); err != nil {
+		return fmt.Errorf("locking metrics store: %w", err)
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	line, err := json.Marshal(rawPoint{T: t.Unix(), V: values})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, rawFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening raw metrics: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("appending sample: %w", err)
+	}
+	return s.compact(t)
+}
+
+// compact rolls raw samples into hourly buckets and hourly into daily once
+// their period has ended, then drops data past retention. Caller holds the lock.
+func (s *Store) compact(now time.Time) error {
+	var state rollupState
+	if data, err := os.ReadFile(filepath.Join(s.dir, stateFile)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	raw, err := readLines[rawPoint](filepath.Join(s.dir, rawFile))
+	if err != nil {
+		return err
+	}
+	hourly, err := readLines[bucket](filepath.Join(s.dir, hourlyFile))
+	if err != nil {
+		return err
+	}
+	daily, err := readLines[bucket](filepath.Join(s.dir, dailyFile))
+	if err != nil {
+		return err
+	}
+	changed := false
+
+	hourNow := now.UTC().Truncate(time.Hour).Unix()
+	if state.HourlyThrough == 0 && len(raw) > 0 {
+		state.HourlyThrough = time.Unix(raw[0].T, 0).UTC().Truncate(time.Hour).Unix()
+	}
+	if state.HourlyThrough < hourNow {
+		rolled := rollup(raw, state.HourlyThrough, hourNow, time.Hour)
+		hourly = append(hourly, rolled...)
+		state.HourlyThrough = hourNow
+		changed = true
+	}
+
+	dayNow := truncateDay(now)
+	if state.DailyThrough == 0 && len(hourly) > 0 {
+		state.DailyThrough = truncateDay(time.Unix(hourly[0].T, 0))
+	}
+	if state.DailyThrough < dayNow && state.DailyThrough != 0 {
+		daily = append(daily, rerollup(hourly, state.DailyThrough, dayNow, 24*time.Hour)...)
+		state.DailyThrough = dayNow
+		changed = true
+	}
+
+	rawKept := keepSince(raw, now.Add(-s.retention.Raw).Unix(), func(p rawPoint) int64 { return p.T })
+	hourlyKept := keepSince(hourly, now.Add(-s.retention.Hourly).Unix(), func(b bucket) int64 { return b.T })
+	dailyKept := keepSince(daily, now.Add(-s.retention.Daily).Unix(), func(b bucket) int64 { return b.T })
+
+	if len(rawKept) != len(raw) {
+		if err := writeLines(filepath.Join(s.dir, rawFile), rawKept); err != nil {
+			return err
+		}
+	}
+	if changed || len(hourlyKept) != len(hourly) {
+		if err := writeLines(filepath.Join(s.dir, hourlyFile), hourlyKept); err != nil {
+			return err
+		}
+	}
+	if changed || len(dailyKept) != len(daily) {
+		if err := writeLines(filepath.Join(s.dir, dailyFile), dailyKept); err != nil {
+			return err
+		}
+	}
+	if changed {
+		return atomicfile.WriteJSON(filepath.Join(s.dir, stateFile), state)
+	}
+	return nil
+}
+
+// rollup aggregates raw samples in [from, to) into buckets of size step.
+func rollup(raw []rawPoint, from, to int64, step time.Duration) []bucket {
+	byStart := make(map[int64]map[string]Agg)
+	for _, p := range raw {
+		if p.T < from || p.T >= to {
+			continue
+		}
+		start := alignUnix(p.T, step)
+		m := byStart[start]
+		if m == nil {
+			m = make(map[string]Agg)
+			byStart[start] = m
+		}
+		for name, v := range p.V {
+			a := m[name]
+			a.add(single(v))
+			m[name] = a
+		}
+	}
+	return sortedBuckets(byStart)
+}
+
+// rerollup merges buckets in [from, to) into coarser buckets of size step.
+func rerollup(in []bucket, from, to int64, step time.Duration) []bucket {
+	byStart := make(map[int64]map[string]Agg)
+	for _, b := range in {
+		if b.T < from || b.T >= to {
+			continue
+		}
+		start := alignUnix(b.T, step)
+		m := byStart[start]
+		if m == nil {
+			m = make(map[string]Agg)
+			byStart[start] = m
+		}
+		for name, v := range b.V {
+			a := m[name]
+			a.add(v)
+			m[name] = a
+		}
+	}
+	return sortedBuckets(byStart)
+}
+
+func sortedBuckets(byStart map[int64]map[string]Agg) []bucket {
+	out := make([]bucket, 0, len(byStart))
+	for t, v := range byStart {
+		out = append(out, bucket{T: t, V: v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].T < out[j].T })
+	return out
+}
+
+// alignUnix truncates a unix time to a multiple of step (UTC).
+func alignUnix(t int64, step time.Duration) int64 {
+	s := int64(step / time.Second)
+	if s <= 0 {
+		return t
+	}
+	return t - ((t%s)+s)%s
+}
+
+func truncateDay(t time.Time) int64 {
+	return alignUnix(t.Unix(), 24*time.Hour)
+}
+
+func keepSince[T any](in []T, cutoff int64, at func(T) int64) []T {
+	i := sort.Search(len(in), func(i int) bool { return at(in[i]) >= cutoff })
+	return in[i:]
+}
+
+// readLines reads a JSONL file, skipping lines that don't parse (such as a
+// torn final line from an interrupted append). A missing file is empty.
+func readLines[T any](path string) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	var out []T
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var v T
+		if json.Unmarshal(sc.Bytes(), &v) == nil {
+			out = append(out, v)
+		}
+	}
+	return out, sc.Err()
+}
+
+func writeLines[T any](path string, lines []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, l := range lines {
+		if err := enc.Encode(l); err != nil {
+			return err
+		}
+	}
+	return atomicfile.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Point is one bucket of a queried series.
+type Point struct {
+	Time time.Time `json:"t"`
+	Avg  float64   `json:"avg"`
+	Min  float64   `json:"min"`
+	Max  float64   `json:"max"`
+	N    int       `json:"n"`
+}
+
+// Series is a named run of points, oldest first.
+type Series struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
+}
+
+// AutoStep picks a bucket size giving at most a few hundred points for span.
+func AutoStep(span time.Duration) time.Duration {
+	for _, step := range []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour} {
+		if span/step <= 400 {
+			return step
+		}
+	}
+	return 7 * 24 * time.Hour
+}
+
+// ParseSpan parses a lookback like "7d", "36h", or "90m". Days are accepted
+// in addition to the units time.ParseDuration understands.
+func ParseSpan(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid span %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid span %q", s)
+	}
+	return d, nil
+}
+
+// Query returns the named series (all series when names is empty) between
+// from and to, bucketed by step (AutoStep when zero). Each period is read from
+// the finest tier that still covers it.
+func (s *Store) Query(from, to time.Time, step time.Duration, names ...string) ([]Series, error) {
+	if step <= 0 {
+		step = AutoStep(to.Sub(from))
+	}
+	raw, err := readLines[rawPoint](filepath.Join(s.dir, rawFile))
+	if err != nil {
+		return nil, err
+	}
+	hourly, err := readLines[bucket](filepath.Join(s.dir, hourlyFile))
+	if err != nil {
+		return nil, err
+	}
+	daily, err := readLines[bucket](filepath.Join(s.dir, dailyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var state rollupState
+	if data, err := os.ReadFile(filepath.Join(s.dir, stateFile)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	// Raw samples are read for the whole raw retention window. The first
+	// retained hour is read from its hourly bucket when one exists, since
+	// pruning may have dropped part of that hour's samples. Hourly is read
+	// from its first complete day if daily buckets cover what came before,
+	// and from its first bucket if not; daily covers whole days before that.
+	const day = int64(24 * time.Hour / time.Second)
+	rawCut := state.HourlyThrough
+	if len(raw) > 0 {
+		rawCut = alignUnix(raw[0].T, time.Hour)
+		for _, b := range hourly {
+			if b.T == rawCut {
+				rawCut += int64(time.Hour / time.Second)
+				break
+			}
+		}
+	}
+	hourlyCut := rawCut
+	if len(hourly) > 0 {
+		hourlyCut = hourly[0].T
+		if first := alignUnix(hourly[0].T+day-1, 24*time.Hour); first <= state.DailyThrough && first <= rawCut {
+			hourlyCut = first
+		}
+	}
+
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	lo, hi := from.Unix(), to.Unix()
+	acc := make(map[string]map[int64]Agg)
+	addAgg := func(t int64, name string, a Agg) {
+		if t < lo || t > hi || (len(want) > 0 && !want[name]) {
+			return
+		}
+		m := acc[name]
+		if m == nil {
+			m = make(map[int64]Agg)
+			acc[name] = m
+		}
+		start := alignUnix(t, step)
+		cur := m[start]
+		cur.add(a)
+		m[start] = cur
+	}
+	for _, b := range daily {
+		if b.T+day <= hourlyCut {
+			for name, a := range b.V {
+				addAgg(b.T, name, a)
+			}
+		}
+	}
+	for _, b := range hourly {
+		if b.T >= hourlyCut && b.T < rawCut {
+			for name, a := range b.V {
+				addAgg(b.T, name, a)
+			}
+		}
+	}
+	for _, p := range raw {
+		if p.T >= rawCut {
+			for name, v := range p.V {
+				addAgg(p.T, name, single(v))
+			}
+		}
+	}
+
+	out := make([]Series, 0, len(acc))
+	for name, byStart := range acc {
+		sr := Series{Name: name}
+		for t, a := range byStart {
+			sr.Points = append(sr.Points, Point{
+				Time: time.Unix(t, 0).UTC(),
+				Avg:  a.Sum / float64(a.N),
+				Min:  a.Min,
+				Max:  a.Max,
+				N:    a.N,
+			})
+		}
+		sort.Slice(sr.Points, func(i, j int) bool { return sr.Points[i].Time.Before(sr.Points[j].Time) })
+		out = append(out, sr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
@@ -0,0 +1,150 @@
+package trends
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_RecordAndQueryRaw(t *testing.T) {
+	s := Open(t.TempDir())
+	base := time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		at := base.Add(time.Duration(i) * 3 * time.Minute)
+		if err := s.Record(at, map[string]float64{ActivePolecats: float64(i), QueueDepth: 7}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	series, err := s.Query(base.Add(-time.Hour), base.Add(time.Hour), 5*time.Minute, ActivePolecats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].Name != ActivePolecats {
+		t.Fatalf("series = %+v", series)
+	}
+	// Samples at :05 :08 | :11 :14 fall into the :05 and :10 buckets.
+	pts := series[0].Points
+	if len(pts) != 2 || pts[0].Avg != 0.5 || pts[1].Min != 2 || pts[1].Max != 3 || pts[1].N != 2 {
+		t.Errorf("points = %+v", pts)
+	}
+}
+
+func TestStore_QueryEarlierHourInRawWindow(t *testing.T) {
+	s := Open(t.TempDir())
+	// Three hours of heartbeat samples; the first two are already rolled up.
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 36; i++ {
+		if err := s.Record(base.Add(time.Duration(i)*5*time.Minute), map[string]float64{QueueDepth: float64(i)}); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	// 11:00-11:55 is inside the raw window but before the current hour.
+	from := base.Add(time.Hour)
+	series, err := s.Query(from, from.Add(55*time.Minute), 5*time.Minute, QueueDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("series = %+v", series)
+	}
+	pts := series[0].Points
+	if len(pts) != 12 {
+		t.Fatalf("got %d points, want 12 at heartbeat resolution: %+v", len(pts), pts)
+	}
+	for i, p := range pts {
+		if p.N != 1 || p.Avg != float64(12+i) || !p.Time.Equal(from.Add(time.Duration(i)*5*time.Minute)) {
+			t.Errorf("point %d = %+v", i, p)
+		}
+	}
+}
+
+func TestStore_RollupAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	s := Open(dir).WithRetention(Retention{Raw: 3 * time.Hour, Hourly: 3 * 24 * time.Hour, Daily: 30 * 24 * time.Hour})
+
+	// One sample every 30 minutes for five days; the value is the day number.
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var last time.Time
+	for at := start; at.Before(start.Add(5 * 24 * time.Hour)); at = at.Add(30 * time.Minute) {
+		day := float64(at.Sub(start) / (24 * time.Hour))
+		if err := s.Record(at, map[string]float64{MRWaitSeconds: day}); err != nil {
+			t.Fatalf("Record(%v): %v", at, err)
+		}
+		last = at
+	}
+
+	raw, _ := readLines[rawPoint](filepath.Join(s.dir, rawFile))
+	if len(raw) == 0 || time.Unix(raw[0].T, 0).Before(last.Add(-3*time.Hour)) {
+		t.Errorf("raw tier not pruned: %d points, first %v", len(raw), time.Unix(raw[0].T, 0).UTC())
+	}
+	hourly, _ := readLines[bucket](filepath.Join(s.dir, hourlyFile))
+	if len(hourly) == 0 || time.Unix(hourly[0].T, 0).Before(last.Add(-3*24*time.Hour)) {
+		t.Errorf("hourly tier not pruned: first %v", time.Unix(hourly[0].T, 0).UTC())
+	}
+	if a := hourly[0].V[MRWaitSeconds]; a.N != 2 {
+		t.Errorf("hourly bucket = %+v, want 2 samples", a)
+	}
+
+	// Daily buckets still cover the first day, which raw and hourly dropped.
+	series, err := s.Query(start, last, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("series = %+v", series)
+	}
+	pts := series[0].Points
+	if len(pts) != 5 {
+		t.Fatalf("got %d daily points, want 5: %+v", len(pts), pts)
+	}
+	for i, p := range pts {
+		if p.Avg != float64(i) || p.N != 48 {
+			t.Errorf("day %d = %+v", i, p)
+		}
+	}
+
+	// The store stays small.
+	var size int64
+	entries, _ := os.ReadDir(Dir(dir))
+	for _, e := range entries {
+		info, _ := e.Info()
+		size += info.Size()
+	}
+	if size > 64*1024 {
+		t.Errorf("store is %d bytes", size)
+	}
+}
+
+func TestAutoStep(t *testing.T) {
+	tests := []struct {
+		span time.Duration
+		want time.Duration
+	}{
+		{6 * time.Hour, 5 * time.Minute},
+		{48 * time.Hour, 15 * time.Minute},
+		{7 * 24 * time.Hour, time.Hour},
+		{30 * 24 * time.Hour, 6 * time.Hour},
+		{365 * 24 * time.Hour, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := AutoStep(tt.span); got != tt.want {
+			t.Errorf("AutoStep(%v) = %v, want %v", tt.span, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	for in, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "36h": 36 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := ParseSpan(in); err != nil || got != want {
+			t.Errorf("ParseSpan(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "d", "-3d", "0h", "week"} {
+		if _, err := ParseSpan(bad); err == nil {
+			t.Errorf("ParseSpan(%q) accepted", bad)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/constants"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
 	"github.com/steveyegge/gastown/internal/util"
 )
 
@@ -24,9 +26,10 @@ var convoyIDPattern = regexp.MustCompile(`^hq-[a-zA-Z0-9-]+$`)
 
 // IssueItem represents a tracked issue within a convoy.
 type IssueItem struct {
-	ID     string
-	Title  string
-	Status string
+	ID        string
+	Title     string
+	Status    string
+	BlockedBy []string // Issues this one waits on (blocking dependencies)
 }
 
 // ConvoyItem represents a convoy with its tracked issues.
@@ -35,7 +38,8 @@ type ConvoyItem struct {
 	Title    string
 	Status   string
 	Issues   []IssueItem
-	Progress string // e.g., "2/5"
+	Progress string              // e.g., "2/5"
+	Forecast *convoyops.Forecast // Expected completion; nil for closed convoys
 	Expanded bool
 }
 
@@ -114,25 +118,49 @@ func loadConvoys(townBeads string) ([]ConvoyItem, error) {
 		return nil, fmt.Errorf("parsing convoy list: %w", err)
 	}
 
+	// Forecast history and capacity are town-wide; load them once.
+	now := time.Now()
+	var history *convoyops.ForecastHistory
+	capacity := 0
+
 	convoys := make([]ConvoyItem, 0, len(rawConvoys))
 	for _, rc := range rawConvoys {
 		if rc.IssueType != "convoy" && !tuiConvoyHasLabel(rc.Labels, "gt:convoy") {
 			continue
 		}
 		issues, completed, total := loadTrackedIssues(townBeads, rc.ID)
-		convoys = append(convoys, ConvoyItem{
+		item := ConvoyItem{
 			ID:       rc.ID,
 			Title:    rc.Title,
 			Status:   rc.Status,
 			Issues:   issues,
 			Progress: fmt.Sprintf("%d/%d", completed, total),
 			Expanded: false,
-		})
+		}
+		if rc.Status != "closed" && total > 0 {
+			if history == nil {
+				history = convoyops.LoadForecastHistory(townBeads, now)
+				capacity = convoyops.ForecastCapacity(townBeads)
+			}
+			item.Forecast = forecastConvoy(issues, history, capacity, now)
+		}
+		convoys = append(convoys, item)
 	}
 
 	return convoys, nil
 }
 
+// forecastConvoy forecasts the completion of a convoy's tracked issues.
+func forecastConvoy(issues []IssueItem, history *convoyops.ForecastHistory, capacity int, now time.Time) *convoyops.Forecast {
+	input := make([]convoyops.ForecastIssue, 0, len(issues))
+	for _, issue := range issues {
+		fi := convoyops.ForecastIssue{ID: issue.ID, Status: issue.Status, BlockedBy: issue.BlockedBy}
+		history.Annotate(&fi)
+		input = append(input, fi)
+	}
+	return convoyops.ComputeForecast(input, history, capacity, now)
+}
+
 func tuiConvoyHasLabel(labels []string, target string) bool {
 	for _, label := range labels {
 		if label == target {
@@ -178,19 +206,22 @@ func loadTrackedIssues(townBeads, convoyID string) ([]IssueItem, int, int) {
 	for i := range tracked {
 		tracked[i].ID = beads.ExtractIssueID(tracked[i].ID)
 	}
-	freshStatus := refreshIssueStatus(ctx, tracked)
+	fresh := refreshIssueStatus(ctx, tracked)
 
 	issues := make([]IssueItem, 0, len(tracked))
 	completed := 0
 	for _, t := range tracked {
 		status := t.Status
-		if fresh, ok := freshStatus[t.ID]; ok {
-			status = fresh
+		var blockedBy []string
+		if f, ok := fresh[t.ID]; ok {
+			status = f.Status
+			blockedBy = f.BlockedBy
 		}
 		issues = append(issues, IssueItem{
-			ID:     t.ID,
-			Title:  t.Title,
-			Status: status,
+			ID:        t.ID,
+			Title:     t.Title,
+			Status:    status,
+			BlockedBy: blockedBy,
 		})
 		if status == "closed" {
 			completed++
@@ -208,13 +239,19 @@ func loadTrackedIssues(townBeads, convoyID string) ([]IssueItem, int, int) {
 	return issues, completed, len(issues)
 }
 
-// refreshIssueStatus does a batch bd show to get current status for tracked issues.
-// Returns a map from issue ID to current status.
+// issueState is the current status and blocking dependencies of an issue.
+type issueState struct {
+	Status    string
+	BlockedBy []string
+}
+
+// refreshIssueStatus does a batch bd show to get current state for tracked issues.
+// Returns a map from issue ID to current status and blockers.
 func refreshIssueStatus(ctx context.Context, tracked []struct {
 	ID     string `json:"id"`
 	Title  string `json:"title"`
 	Status string `json:"status"`
-}) map[string]string {
+}) map[string]issueState {
 	if len(tracked) == 0 {
 		return nil
 	}
@@ -235,16 +272,26 @@ func refreshIssueStatus(ctx context.Context, tracked []struct {
 	}
 
 	var issues []struct {
-		ID     string `json:"id"`
-		Status string `json:"status"`
+		ID           string `json:"id"`
+		Status       string `json:"status"`
+		Dependencies []struct {
+			ID             string `json:"id"`
+			DependencyType string `json:"dependency_type"`
+		} `json:"dependencies"`
 	}
 	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
 		return nil
 	}
 
-	result := make(map[string]string, len(issues))
+	result := make(map[string]issueState, len(issues))
 	for _, issue := range issues {
-		result[issue.ID] = issue.Status
+		state := issueState{Status: issue.Status}
+		for _, dep := range issue.Dependencies {
+			if convoyops.IsBlockingDepType(dep.DependencyType) {
+				state.BlockedBy = append(state.BlockedBy, beads.ExtractIssueID(dep.ID))
+			}
+		}
+		result[issue.ID] = state
 	}
 	return result
 }
@@ -3,9 +3,12 @@ package convoy
 import (
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
 )
 
 // Styles for the convoy TUI
@@ -59,6 +62,7 @@ func (m *Model) renderView() string {
 	}
 
 	// Render convoys
+	now := time.Now()
 	pos := 0
 	for ci, c := range m.convoys {
 		isSelected := pos == m.cursor
@@ -78,6 +82,9 @@ func (m *Model) renderView() string {
 			c.Title,
 			progressStyle.Render(fmt.Sprintf("(%s)", c.Progress)),
 		)
+		if eta := convoyops.FormatForecastETA(c.Forecast, now); eta != "" {
+			line += " " + progressStyle.Render("ETA "+eta)
+		}
 
 		if isSelected {
 			b.WriteString(selectedStyle.Render(line))
@@ -120,6 +127,7 @@ func (m *Model) renderView() string {
 				b.WriteString("\n")
 				pos++
 			}
+			b.WriteString(renderForecastDetails(c.Forecast))
 		}
 	}
 
@@ -134,6 +142,28 @@ func (m *Model) renderView() string {
 	return b.String()
 }
 
+// renderForecastDetails renders the critical path and top blockers shown
+// under an expanded convoy. These rows are not selectable.
+func renderForecastDetails(f *convoyops.Forecast) string {
+	if f == nil || f.Remaining == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if len(f.CriticalPath) > 1 {
+		b.WriteString(progressStyle.Render("     critical path: " + strings.Join(f.CriticalPath, " → ")))
+		b.WriteString("\n")
+	}
+	if len(f.Blockers) > 0 {
+		parts := make([]string, 0, len(f.Blockers))
+		for _, blocker := range f.Blockers {
+			parts = append(parts, fmt.Sprintf("%s (%d waiting)", blocker.ID, blocker.Downstream))
+		}
+		b.WriteString(progressStyle.Render("     top blockers:  " + strings.Join(parts, ", ")))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 // statusToIcon converts a status string to an icon.
 func statusToIcon(status string) string {
 	switch status {
@@ -181,6 +181,8 @@ var (
 		"merged":        "✓",
 		"merge_failed":  "✗",
 		"merge_skipped": "⊘",
+		// Workflow step events
+		"step_failed": "✗",
 		// General gt events
 		"sling":   "🎯",
 		"hook":    "🪝",
@@ -2,6 +2,7 @@ package util
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 )
@@ -32,3 +33,26 @@ func ExpandHome(path string) string {
 	}
 	return home + path[1:]
 }
+
+// GTDataDir returns the directory used for GT's runtime data files
+// (logs, telemetry, cost records, etc.).
+//
+// Resolution order:
+//  1. $GT_HOME/.gt  — when GT_HOME is set, data is kept alongside the GT
+//     workspace rather than in the user's home directory.
+//  2. ~/.gt         — default location when GT_HOME is not set.
+func GTDataDir() string {
+	if h := os.Getenv("GT_HOME"); h != "" {
+		return filepath.Join(h, ".gt")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), ".gt")
+	}
+	return filepath.Join(home, ".gt")
+}
+
+// CostsLogPath returns the session cost log written by `gt costs record`.
+func CostsLogPath() string {
+	return filepath.Join(GTDataDir(), "costs.jsonl")
+}
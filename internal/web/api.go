@@ -3,9 +3,10 @@ package web
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -18,6 +19,9 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -64,12 +68,21 @@ type APIHandler struct {
 	cmdSem chan struct{}
 	// csrfToken is validated on POST requests to prevent cross-site request forgery.
 	csrfToken string
+	// stores caches the in-process beads stores behind /api/v1.
+	stores beadsStores
+	// v1Mux routes /api/v1, built on first use.
+	v1Once sync.Once
+	v1Mux  *http.ServeMux
+	// terminals shares live pane streams among websocket viewers.
+	terminals *terminalHub
+	// liveBeads reports bead changes to /api/events. Nil reads the stores.
+	liveBeads beadChangeSource
 }
 
 const optionsCacheTTL = 30 * time.Second
 
-// maxConcurrentCommands limits how many gt subprocesses can run at once.
-// handleOptions alone spawns 7; allow headroom for other concurrent handlers.
+// maxConcurrentCommands limits how many gt/gh subprocesses can run at once.
+// /api/v1 is served in-process; /api/run, PR lookups, and agent status remain.
 const maxConcurrentCommands = 12
 
 // NewAPIHandler creates a new API handler with the given run timeouts and CSRF token.
@@ -101,7 +114,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate CSRF token on all POST requests.
-	if r.Method == http.MethodPost && h.csrfToken != "" {
+	// Bearer-token clients are exempt: browsers never send those ambiently.
+	if r.Method == http.MethodPost && h.csrfToken != "" && !csrfExempt(r) {
 		if r.Header.Get("X-Dashboard-Token") != h.csrfToken {
 			h.sendError(w, "Invalid or missing dashboard token", http.StatusForbidden)
 			return
@@ -114,41 +128,41 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleRun(w, r)
 	case path == "/commands" && r.Method == http.MethodGet:
 		h.handleCommands(w, r)
-	case path == "/options" && r.Method == http.MethodGet:
-		h.handleOptions(w, r)
-	case path == "/mail/inbox" && r.Method == http.MethodGet:
-		h.handleMailInbox(w, r)
-	case path == "/mail/threads" && r.Method == http.MethodGet:
-		h.handleMailThreads(w, r)
-	case path == "/mail/read" && r.Method == http.MethodGet:
-		h.handleMailRead(w, r)
-	case path == "/mail/send" && r.Method == http.MethodPost:
-		h.handleMailSend(w, r)
-	case path == "/issues/show" && r.Method == http.MethodGet:
-		h.handleIssueShow(w, r)
-	case path == "/issues/create" && r.Method == http.MethodPost:
-		h.handleIssueCreate(w, r)
-	case path == "/issues/close" && r.Method == http.MethodPost:
-		h.handleIssueClose(w, r)
-	case path == "/issues/update" && r.Method == http.MethodPost:
-		h.handleIssueUpdate(w, r)
 	case path == "/pr/show" && r.Method == http.MethodGet:
 		h.handlePRShow(w, r)
 	case path == "/rig/add" && r.Method == http.MethodPost:
 		h.handleRigAdd(w, r)
-	case path == "/crew" && r.Method == http.MethodGet:
-		h.handleCrew(w, r)
-	case path == "/ready" && r.Method == http.MethodGet:
-		h.handleReady(w, r)
 	case path == "/events" && r.Method == http.MethodGet:
 		h.handleSSE(w, r)
 	case path == "/session/preview" && r.Method == http.MethodGet:
 		h.handleSessionPreview(w, r)
+	case strings.HasPrefix(path, "/v1/"):
+		h.serveV1(w, r)
+	case legacyAPIRoutes[path] != "":
+		h.sendError(w, "Endpoint moved to "+legacyAPIRoutes[path], http.StatusGone)
 	default:
 		http.Error(w, "Not found", http.StatusNotFound)
 	}
 }
 
+// legacyAPIRoutes maps the pre-v1 dashboard endpoints to their /api/v1
+// replacements. They answer 410 Gone with the new path for one release so
+// external scripts fail loudly instead of with a bare 404.
+var legacyAPIRoutes = map[string]string{
+	"/options":        "/api/v1/options",
+	"/mail/inbox":     "/api/v1/mail/inbox",
+	"/mail/threads":   "/api/v1/mail/threads",
+	"/mail/read":      "/api/v1/mail/messages/{id}",
+	"/mail/send":      "/api/v1/mail/send",
+	"/issues/show":    "/api/v1/issues/{id}",
+	"/issues/create":  "/api/v1/issues",
+	"/issues/close":   "/api/v1/issues/{id}/close",
+	"/issues/update":  "/api/v1/issues/{id}/update",
+	"/crew":           "/api/v1/crew",
+	"/ready":          "/api/v1/ready",
+	"/formula/schema": "/api/v1/formulas/{name}/schema",
+}
+
 // handleRun executes a gt command and returns the result.
 func (h *APIHandler) handleRun(w http.ResponseWriter, r *http.Request) {
 	var req CommandRequest
@@ -157,6 +171,8 @@ func (h *APIHandler) handleRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	noteAudit(r.Context(), "command", req.Command)
+
 	// Validate command against whitelist
 	meta, err := ValidateCommand(req.Command)
 	if err != nil {
@@ -164,6 +180,11 @@ func (h *APIHandler) handleRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if need := commandRole(meta); principalFromContext(r.Context()).Role < need {
+		h.sendError(w, fmt.Sprintf("Forbidden: command requires %s role", need), http.StatusForbidden)
+		return
+	}
+
 	// Enforce server-side confirmation for dangerous commands
 	if meta.Confirm && !req.Confirmed {
 		h.sendError(w, "This command requires confirmation (set confirmed: true)", http.StatusForbidden)
@@ -208,20 +229,22 @@ func (h *APIHandler) handleRun(w http.ResponseWriter, r *http.Request) {
 		resp.Output = output
 	}
 
-	// Log command execution (but not for safe read-only commands to reduce noise)
-	if !meta.Safe || !resp.Success {
-		// Could add structured logging here
-		_ = meta // silence unused warning for now
+	if !resp.Success {
+		noteAudit(r.Context(), "error", resp.Error)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// handleCommands returns the list of available commands for the palette.
-func (h *APIHandler) handleCommands(w http.ResponseWriter, _ *http.Request) {
-	resp := CommandListResponse{
-		Commands: GetCommandList(),
+// handleCommands returns the commands the caller's role may run, for the palette.
+func (h *APIHandler) handleCommands(w http.ResponseWriter, r *http.Request) {
+	role := principalFromContext(r.Context()).Role
+	resp := CommandListResponse{Commands: []CommandInfo{}}
+	for _, c := range GetCommandList() {
+		if need, _ := ParseRole(c.Role); need <= role {
+			resp.Commands = append(resp.Commands, c)
+		}
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -298,7 +321,7 @@ type MailMessage struct {
 	ReplyTo   string `json:"reply_to,omitempty"`
 }
 
-// MailInboxResponse is the response for /api/mail/inbox.
+// MailInboxResponse is the response for /api/v1/mail/inbox.
 type MailInboxResponse struct {
 	Messages    []MailMessage `json:"messages"`
 	UnreadCount int           `json:"unread_count"`
@@ -315,44 +338,17 @@ type MailThread struct {
 	UnreadCount int           `json:"unread_count"`
 }
 
-// MailThreadsResponse is the response for /api/mail/threads.
+// MailThreadsResponse is the response for /api/v1/mail/threads.
 type MailThreadsResponse struct {
 	Threads     []MailThread `json:"threads"`
 	UnreadCount int          `json:"unread_count"`
 	Total       int          `json:"total"`
 }
 
-// handleMailInbox returns the user's inbox.
+// handleMailInbox returns the dashboard's inbox, or the inbox of ?address=.
 func (h *APIHandler) handleMailInbox(w http.ResponseWriter, r *http.Request) {
-	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "inbox", "--json"})
-	if err != nil {
-		// Try without --json flag
-		output, err = h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "inbox"})
-		if err != nil {
-			h.sendError(w, "Failed to fetch inbox: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		// Parse text output
-		messages := parseMailInboxText(output)
-		unread := 0
-		for _, m := range messages {
-			if !m.Read {
-				unread++
-			}
-		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(MailInboxResponse{
-			Messages:    messages,
-			UnreadCount: unread,
-			Total:       len(messages),
-		})
-		return
-	}
-
-	// Parse JSON output
-	var messages []MailMessage
-	if err := json.Unmarshal([]byte(output), &messages); err != nil {
-		h.sendError(w, "Failed to parse inbox: "+err.Error(), http.StatusInternalServerError)
+	messages, ok := h.loadInbox(w, r)
+	if !ok {
 		return
 	}
 
@@ -373,36 +369,15 @@ func (h *APIHandler) handleMailInbox(w http.ResponseWriter, r *http.Request) {
 
 // handleMailThreads returns the inbox grouped by conversation threads.
 func (h *APIHandler) handleMailThreads(w http.ResponseWriter, r *http.Request) {
-	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "inbox", "--json"})
-	if err != nil {
-		// Fall back to text parsing
-		output, err = h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "inbox"})
-		if err != nil {
-			h.sendError(w, "Failed to fetch inbox: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		messages := parseMailInboxText(output)
-		threads := groupIntoThreads(messages)
-		unread := 0
-		for _, t := range threads {
-			unread += t.UnreadCount
-		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(MailThreadsResponse{
-			Threads:     threads,
-			UnreadCount: unread,
-			Total:       len(messages),
-		})
-		return
-	}
-
-	var messages []MailMessage
-	if err := json.Unmarshal([]byte(output), &messages); err != nil {
-		h.sendError(w, "Failed to parse inbox: "+err.Error(), http.StatusInternalServerError)
+	messages, ok := h.loadInbox(w, r)
+	if !ok {
 		return
 	}
 
 	threads := groupIntoThreads(messages)
+	if threads == nil {
+		threads = make([]MailThread, 0)
+	}
 	unread := 0
 	for _, t := range threads {
 		unread += t.UnreadCount
@@ -416,6 +391,35 @@ func (h *APIHandler) handleMailThreads(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// loadInbox lists the mailbox named by ?address=, defaulting to the
+// dashboard's own. On failure it writes the error response and returns false.
+func (h *APIHandler) loadInbox(w http.ResponseWriter, r *http.Request) ([]MailMessage, bool) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		address = dashboardMailIdentity
+	} else if !isValidMailAddress(address) {
+		h.sendError(w, "Invalid address format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
+		return nil, false
+	}
+
+	list, err := h.mailbox(townRoot, address).List()
+	if err != nil {
+		h.sendError(w, "Failed to fetch inbox: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	messages := make([]MailMessage, 0, len(list))
+	for _, m := range list {
+		messages = append(messages, toMailMessage(m))
+	}
+	return messages, true
+}
+
 // groupIntoThreads groups messages into conversation threads.
 // Messages are grouped by ThreadID when available, otherwise by ReplyTo chain,
 // and finally by subject similarity as a fallback.
@@ -497,32 +501,62 @@ func groupIntoThreads(messages []MailMessage) []MailThread {
 	return threads
 }
 
-// handleMailRead reads a specific message by ID.
+// handleMailRead returns a message from the dashboard's mailbox. Reading
+// does not mark the message read; see handleMailMarkRead.
 func (h *APIHandler) handleMailRead(w http.ResponseWriter, r *http.Request) {
-	msgID := r.URL.Query().Get("id")
-	if msgID == "" {
-		h.sendError(w, "Missing message ID", http.StatusBadRequest)
-		return
-	}
+	msgID := r.PathValue("id")
 	if !isValidID(msgID) {
 		h.sendError(w, "Invalid message ID format", http.StatusBadRequest)
 		return
 	}
 
-	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "read", msgID})
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
+		return
+	}
+
+	msg, err := h.mailbox(townRoot, dashboardMailIdentity).Get(msgID)
 	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			h.sendError(w, "Message not found: "+msgID, http.StatusNotFound)
+			return
+		}
 		h.sendError(w, "Failed to read message: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Parse the message output
-	msg := parseMailReadOutput(output, msgID)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toMailMessage(msg))
+}
+
+// handleMailMarkRead marks a message read without archiving it, as
+// "gt mail read" does when a message is opened.
+func (h *APIHandler) handleMailMarkRead(w http.ResponseWriter, r *http.Request) {
+	msgID := r.PathValue("id")
+	if !isValidID(msgID) {
+		h.sendError(w, "Invalid message ID format", http.StatusBadRequest)
+		return
+	}
+
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
+		return
+	}
+
+	if err := h.mailbox(townRoot, dashboardMailIdentity).MarkReadOnly(msgID); err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			h.sendError(w, "Message not found: "+msgID, http.StatusNotFound)
+			return
+		}
+		h.sendError(w, "Failed to mark message read: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(msg)
+	_ = json.NewEncoder(w).Encode(ActionResponse{Success: true, ID: msgID, Message: "Message marked read"})
 }
 
-// MailSendRequest is the request body for /api/mail/send.
+// MailSendRequest is the request body for /api/v1/mail/send.
 type MailSendRequest struct {
 	To      string `json:"to"`
 	Subject string `json:"subject"`
@@ -530,7 +564,8 @@ type MailSendRequest struct {
 	ReplyTo string `json:"reply_to,omitempty"`
 }
 
-// handleMailSend sends a new message.
+// handleMailSend sends a message from the dashboard's identity. A reply
+// joins the thread of the message it answers.
 func (h *APIHandler) handleMailSend(w http.ResponseWriter, r *http.Request) {
 	var req MailSendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -567,113 +602,28 @@ func (h *APIHandler) handleMailSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build mail send command. Flags go first, then -- to end flag parsing,
-	// then the positional recipient (consistent with handleIssueCreate/handleInstall).
-	args := []string{"mail", "send"}
-	args = append(args, "-s", req.Subject)
-	if req.Body != "" {
-		args = append(args, "-m", req.Body)
-	}
-	if req.ReplyTo != "" {
-		args = append(args, "--reply-to", req.ReplyTo)
-	}
-	args = append(args, "--", req.To)
-
-	output, err := h.runGtCommand(r.Context(), 30*time.Second, args)
-	if err != nil {
-		h.sendError(w, "Failed to send message: "+err.Error()+"\n"+output, http.StatusInternalServerError)
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Message sent",
-		"output":  output,
-	})
-}
-
-// parseMailInboxText parses text output from "gt mail inbox".
-func parseMailInboxText(output string) []MailMessage {
-	var messages []MailMessage
-	lines := strings.Split(output, "\n")
-
-	// Format: "  1. ● subject" or "  1. subject" (● = unread)
-	// followed by "      id from sender"
-	// followed by "      timestamp"
-	var current *MailMessage
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "📬") || strings.HasPrefix(trimmed, "(no messages)") {
-			continue
-		}
-
-		// Check for numbered message line
-		if len(trimmed) > 2 && trimmed[0] >= '1' && trimmed[0] <= '9' && trimmed[1] == '.' {
-			// Save previous message
-			if current != nil {
-				messages = append(messages, *current)
-			}
-			current = &MailMessage{}
-			// Parse "1. ● subject" or "1. subject"
-			rest := strings.TrimSpace(trimmed[2:])
-			if strings.HasPrefix(rest, "●") {
-				current.Read = false
-				current.Subject = strings.TrimSpace(strings.TrimPrefix(rest, "●"))
-			} else {
-				current.Read = true
-				current.Subject = rest
-			}
-		} else if current != nil && current.ID == "" && strings.Contains(trimmed, " from ") {
-			// Parse "id from sender"
-			parts := strings.SplitN(trimmed, " from ", 2)
-			if len(parts) == 2 {
-				current.ID = strings.TrimSpace(parts[0])
-				current.From = strings.TrimSpace(parts[1])
-			}
-		} else if current != nil && current.Timestamp == "" && (strings.Contains(trimmed, "-") || strings.Contains(trimmed, ":")) {
-			current.Timestamp = trimmed
+	msg := mail.NewMessage(dashboardMailIdentity, req.To, req.Subject, req.Body)
+	if req.ReplyTo != "" {
+		original, err := h.mailbox(townRoot, dashboardMailIdentity).Get(req.ReplyTo)
+		if err != nil {
+			h.sendError(w, "Reply-to message not found: "+req.ReplyTo, http.StatusBadRequest)
+			return
 		}
+		msg = mail.NewReplyMessage(dashboardMailIdentity, req.To, req.Subject, req.Body, original)
 	}
-	// Don't forget the last one
-	if current != nil && current.ID != "" {
-		messages = append(messages, *current)
-	}
-
-	return messages
-}
-
-// parseMailReadOutput parses the output from "gt mail read <id>".
-func parseMailReadOutput(output string, msgID string) MailMessage {
-	msg := MailMessage{ID: msgID}
-	lines := strings.Split(output, "\n")
-
-	inBody := false
-	var bodyLines []string
 
-	for _, line := range lines {
-		if strings.HasPrefix(line, "📬 ") || strings.HasPrefix(line, "Subject: ") {
-			msg.Subject = strings.TrimPrefix(strings.TrimPrefix(line, "📬 "), "Subject: ")
-			msg.Subject = strings.TrimSpace(msg.Subject)
-		} else if strings.HasPrefix(line, "From: ") {
-			msg.From = strings.TrimPrefix(line, "From: ")
-		} else if strings.HasPrefix(line, "To: ") {
-			msg.To = strings.TrimPrefix(line, "To: ")
-		} else if strings.HasPrefix(line, "ID: ") {
-			msg.ID = strings.TrimPrefix(line, "ID: ")
-		} else if strings.HasPrefix(line, "Thread: ") {
-			msg.ThreadID = strings.TrimSpace(strings.TrimPrefix(line, "Thread: "))
-		} else if strings.HasPrefix(line, "Reply-To: ") {
-			msg.ReplyTo = strings.TrimSpace(strings.TrimPrefix(line, "Reply-To: "))
-		} else if line == "" && msg.From != "" && !inBody {
-			inBody = true
-		} else if inBody {
-			bodyLines = append(bodyLines, line)
-		}
+	if err := mail.NewRouterWithTownRoot(townRoot, townRoot).Send(msg); err != nil {
+		h.sendError(w, "Failed to send message: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	msg.Body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
-	return msg
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ActionResponse{Success: true, ID: msg.ID, Message: "Message sent"})
 }
 
 // OptionItem represents an option with name and status.
@@ -683,7 +633,7 @@ type OptionItem struct {
 	Running bool   `json:"running,omitempty"` // convenience field
 }
 
-// OptionsResponse is the JSON response from /api/options.
+// OptionsResponse is the JSON response from /api/v1/options.
 type OptionsResponse struct {
 	Rigs        []string     `json:"rigs,omitempty"`
 	Polecats    []string     `json:"polecats,omitempty"`
@@ -727,93 +677,116 @@ func (h *APIHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
 		h.optionsCacheMu.RUnlock()
 	}
 
-	// Cache miss - fetch fresh data
+	// Cache miss - fetch fresh data. Rigs come from config; everything else
+	// is read in-process from the town, except agent status, which only
+	// "gt status" computes.
 	resp := &OptionsResponse{}
+	townRoot := h.townRoot()
+	var rigs []*rig.Rig
+	if townRoot != "" {
+		rigs = discoverRigs(townRoot)
+	}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	// Run all fetches in parallel with shorter timeouts
-	wg.Add(7)
+	wg.Add(2)
 
 	// Fetch rigs
 	go func() {
 		defer wg.Done()
+		rigNames := h.loadRigOptions(r.Context())
 		mu.Lock()
-		resp.Rigs = h.loadRigOptions(r.Context())
+		resp.Rigs = rigNames
 		mu.Unlock()
 	}()
 
-	// Fetch polecats
+	// Fetch agents - shorter timeout, skip if slow
 	go func() {
 		defer wg.Done()
-		if output, err := h.runGtCommand(r.Context(), 3*time.Second, []string{"polecat", "list", "--all", "--json"}); err == nil {
+		if output, err := h.runGtCommand(r.Context(), 5*time.Second, []string{"status", "--json"}); err == nil {
+			agents := parseAgentsFromStatus(output)
 			mu.Lock()
-			resp.Polecats = parseJSONPaths(output)
+			resp.Agents = agents
 			mu.Unlock()
 		} else {
-			log.Printf("warning: handleOptions: polecat list: %v", err)
+			log.Printf("warning: handleOptions: status: %v", err)
 		}
 	}()
 
-	// Fetch convoys
-	go func() {
-		defer wg.Done()
-		if output, err := h.runBdCommand(r.Context(), 3*time.Second, []string{"list", "--json", "--limit=0"}); err == nil {
+	if townRoot != "" {
+		wg.Add(5)
+
+		// Fetch polecats
+		go func() {
+			defer wg.Done()
+			var names []string
+			for _, p := range listPolecats(rigs) {
+				names = append(names, p.Rig+"/"+p.Name)
+			}
 			mu.Lock()
-			resp.Convoys = parseConvoyListJSON(output)
+			resp.Polecats = names
 			mu.Unlock()
-		} else {
-			log.Printf("warning: handleOptions: convoy list: %v", err)
-		}
-	}()
+		}()
 
-	// Fetch hooks
-	go func() {
-		defer wg.Done()
-		if output, err := h.runGtCommand(r.Context(), 3*time.Second, []string{"hooks", "list"}); err == nil {
+		// Fetch convoys
+		go func() {
+			defer wg.Done()
+			convoys, err := h.beadsForDir(beads.ResolveBeadsDir(townRoot)).List(beads.ListOptions{Label: "gt:convoy", Priority: -1})
+			if err != nil {
+				log.Printf("warning: handleOptions: convoy list: %v", err)
+				return
+			}
+			ids := make([]string, 0, len(convoys))
+			for _, c := range convoys {
+				ids = append(ids, c.ID)
+			}
 			mu.Lock()
-			resp.Hooks = parseHooksListOutput(output)
+			resp.Convoys = ids
 			mu.Unlock()
-		} else {
-			log.Printf("warning: handleOptions: hooks list: %v", err)
-		}
-	}()
+		}()
 
-	// Fetch mail messages
-	go func() {
-		defer wg.Done()
-		if output, err := h.runGtCommand(r.Context(), 3*time.Second, []string{"mail", "inbox"}); err == nil {
+		// Fetch hooks
+		go func() {
+			defer wg.Done()
+			var ids []string
+			for _, issue := range h.hookedIssues(townRoot, rigs) {
+				ids = append(ids, issue.ID)
+			}
+			sort.Strings(ids)
 			mu.Lock()
-			resp.Messages = parseMailInboxOutput(output)
+			resp.Hooks = ids
 			mu.Unlock()
-		} else {
-			log.Printf("warning: handleOptions: mail inbox: %v", err)
-		}
-	}()
+		}()
 
-	// Fetch crew members
-	go func() {
-		defer wg.Done()
-		if output, err := h.runGtCommand(r.Context(), 3*time.Second, []string{"crew", "list", "--all"}); err == nil {
+		// Fetch mail messages
+		go func() {
+			defer wg.Done()
+			messages, err := h.mailbox(townRoot, dashboardMailIdentity).List()
+			if err != nil {
+				log.Printf("warning: handleOptions: mail inbox: %v", err)
+				return
+			}
+			ids := make([]string, 0, len(messages))
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
 			mu.Lock()
-			resp.Crew = parseCrewListOutput(output)
+			resp.Messages = ids
 			mu.Unlock()
-		} else {
-			log.Printf("warning: handleOptions: crew list: %v", err)
-		}
-	}()
+		}()
 
-	// Fetch agents - shorter timeout, skip if slow
-	go func() {
-		defer wg.Done()
-		if output, err := h.runGtCommand(r.Context(), 5*time.Second, []string{"status", "--json"}); err == nil {
+		// Fetch crew members
+		go func() {
+			defer wg.Done()
+			var names []string
+			for _, c := range listCrew(rigs) {
+				names = append(names, c.Rig+"/"+c.Name)
+			}
 			mu.Lock()
-			resp.Agents = parseAgentsFromStatus(output)
+			resp.Crew = names
 			mu.Unlock()
-		} else {
-			log.Printf("warning: handleOptions: status: %v", err)
-		}
-	}()
+		}()
+	}
 
 	wg.Wait()
 
@@ -839,12 +812,6 @@ func (h *APIHandler) loadRigOptions(ctx context.Context) []string {
 		log.Printf("warning: handleOptions: rig list --json: %v", err)
 	}
 
-	if output, err := h.runGtCommand(ctx, 3*time.Second, []string{"rig", "list"}); err == nil {
-		return parseRigListOutput(output)
-	} else {
-		log.Printf("warning: handleOptions: rig list fallback: %v", err)
-	}
-
 	return nil
 }
 
@@ -897,31 +864,6 @@ func findRigsConfigPath(startDir string) (string, error) {
 	}
 }
 
-// parseRigListOutput extracts rig names from the text output of "gt rig list".
-// Example output:
-//
-//	Rigs in /Users/foo/gt:
-//	  claycantrell
-//	    Polecats: 1  Crew: 2
-//	  gastown
-//	    Polecats: 1  Crew: 1
-func parseRigListOutput(output string) []string {
-	var rigs []string
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		// Rig names are indented with 2 spaces and no colon
-		trimmed := strings.TrimPrefix(line, "  ")
-		if trimmed != line && !strings.Contains(trimmed, ":") && strings.TrimSpace(trimmed) != "" {
-			// This is a rig name line
-			name := strings.TrimSpace(trimmed)
-			if name != "" && !strings.HasPrefix(name, "Rigs") {
-				rigs = append(rigs, name)
-			}
-		}
-	}
-	return rigs
-}
-
 // parseRigListJSON extracts rig names from JSON output of "gt rig list --json".
 func parseRigListJSON(jsonStr string) []string {
 	var rigList []struct {
@@ -940,99 +882,6 @@ func parseRigListJSON(jsonStr string) []string {
 	return rigs
 }
 
-// parseConvoyListJSON extracts convoy IDs from JSON output of "bd list --json".
-func parseConvoyListJSON(jsonStr string) []string {
-	var convoys []struct {
-		ID        string   `json:"id"`
-		IssueType string   `json:"issue_type"`
-		Labels    []string `json:"labels"`
-	}
-	if err := json.Unmarshal([]byte(jsonStr), &convoys); err != nil {
-		log.Printf("warning: parseConvoyListJSON: %v", err)
-		return nil
-	}
-	ids := make([]string, 0, len(convoys))
-	for _, c := range convoys {
-		if c.ID != "" && (c.IssueType == "convoy" || webAPIHasLabel(c.Labels, "gt:convoy")) {
-			ids = append(ids, c.ID)
-		}
-	}
-	return ids
-}
-
-func webAPIHasLabel(labels []string, target string) bool {
-	for _, label := range labels {
-		if label == target {
-			return true
-		}
-	}
-	return false
-}
-
-// parseHooksListOutput extracts bead names from hooks list output.
-func parseHooksListOutput(output string) []string {
-	var hooks []string
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Skip header lines and empty lines
-		if trimmed != "" && !strings.HasPrefix(trimmed, "Hook") && !strings.HasPrefix(trimmed, "No ") && !strings.HasPrefix(trimmed, "BEAD") {
-			parts := strings.Fields(trimmed)
-			if len(parts) > 0 {
-				hooks = append(hooks, parts[0])
-			}
-		}
-	}
-	return hooks
-}
-
-// parseMailInboxOutput extracts message IDs from mail inbox output.
-func parseMailInboxOutput(output string) []string {
-	var messages []string
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Skip header lines and empty lines
-		if trimmed != "" && !strings.HasPrefix(trimmed, "Mail") && !strings.HasPrefix(trimmed, "No ") && !strings.HasPrefix(trimmed, "ID") && !strings.HasPrefix(trimmed, "---") {
-			parts := strings.Fields(trimmed)
-			if len(parts) > 0 {
-				messages = append(messages, parts[0])
-			}
-		}
-	}
-	return messages
-}
-
-// parseCrewListOutput extracts crew member names (rig/name format) from crew list output.
-func parseCrewListOutput(output string) []string {
-	var crew []string
-	lines := strings.Split(output, "\n")
-	currentRig := ""
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			continue
-		}
-		// Check if this is a rig header (ends with :)
-		if strings.HasSuffix(trimmed, ":") && !strings.Contains(trimmed, " ") {
-			currentRig = strings.TrimSuffix(trimmed, ":")
-			continue
-		}
-		// Skip non-crew lines
-		if strings.HasPrefix(trimmed, "Crew") || strings.HasPrefix(trimmed, "No ") {
-			continue
-		}
-		// This should be a crew member name
-		if currentRig != "" {
-			parts := strings.Fields(trimmed)
-			if len(parts) > 0 {
-				crew = append(crew, currentRig+"/"+parts[0])
-			}
-		}
-	}
-	return crew
-}
-
 // parseAgentsFromStatus extracts agents with status from "gt status --json" output.
 func parseAgentsFromStatus(jsonStr string) []OptionItem {
 	var status struct {
@@ -1066,32 +915,7 @@ func parseAgentsFromStatus(jsonStr string) []OptionItem {
 	return agents
 }
 
-// parseJSONPaths extracts rig/name paths from polecat JSON output.
-func parseJSONPaths(jsonStr string) []string {
-	var items []map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &items); err != nil {
-		var wrapper map[string][]map[string]interface{}
-		if err := json.Unmarshal([]byte(jsonStr), &wrapper); err != nil {
-			return nil
-		}
-		for _, v := range wrapper {
-			items = v
-			break
-		}
-	}
-
-	var paths []string
-	for _, item := range items {
-		rig, _ := item["rig"].(string)
-		name, _ := item["name"].(string)
-		if rig != "" && name != "" {
-			paths = append(paths, rig+"/"+name)
-		}
-	}
-	return paths
-}
-
-// IssueShowResponse is the response for /api/issues/show.
+// IssueShowResponse is the response for /api/v1/issues/{id}.
 type IssueShowResponse struct {
 	ID          string   `json:"id"`
 	Title       string   `json:"title"`
@@ -1099,23 +923,21 @@ type IssueShowResponse struct {
 	Status      string   `json:"status,omitempty"`
 	Priority    string   `json:"priority,omitempty"`
 	Owner       string   `json:"owner,omitempty"`
+	Assignee    string   `json:"assignee,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Created     string   `json:"created,omitempty"`
 	Updated     string   `json:"updated,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
 	DependsOn   []string `json:"depends_on,omitempty"`
 	Blocks      []string `json:"blocks,omitempty"`
-	RawOutput   string   `json:"raw_output"`
 }
 
-// handleIssueShow returns details for a specific issue/bead.
+// handleIssueShow returns details for a specific issue/bead, read from the
+// database its ID routes to.
 func (h *APIHandler) handleIssueShow(w http.ResponseWriter, r *http.Request) {
-	issueID := r.URL.Query().Get("id")
-	if issueID == "" {
-		h.sendError(w, "Missing issue ID", http.StatusBadRequest)
-		return
-	}
+	issueID := r.PathValue("id")
 	// Issue IDs may use external:prefix:id format for cross-rig dependencies.
-	// Unwrap to the raw bead ID before validation and bd show.
+	// Unwrap to the raw bead ID before validation and lookup.
 	showID := beads.ExtractIssueID(issueID)
 	if strings.HasPrefix(issueID, "external:") && showID == issueID {
 		h.sendError(w, "Malformed external issue ID (expected external:prefix:id)", http.StatusBadRequest)
@@ -1126,29 +948,25 @@ func (h *APIHandler) handleIssueShow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Try structured JSON output first (preferred — no text parsing needed)
-	output, err := h.runBdCommand(r.Context(), 10*time.Second, []string{"show", showID, "--json"})
-	if err == nil {
-		if resp, ok := parseIssueShowJSON(output); ok {
-			// Preserve the original request ID in the response (may be external:prefix:id).
-			// Callers may store/compare the full prefixed form.
-			resp.ID = issueID
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(resp)
-			return
-		}
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
+		return
 	}
 
-	// Fall back to text parsing
-	output, err = h.runBdCommand(r.Context(), 10*time.Second, []string{"show", showID})
+	issue, err := h.beadsForID(townRoot, showID).Show(showID)
 	if err != nil {
+		if errors.Is(err, beads.ErrNotFound) {
+			h.sendError(w, "Issue not found: "+showID, http.StatusNotFound)
+			return
+		}
 		h.sendError(w, "Failed to fetch issue: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Pass issueID (not showID) to preserve the original ID in the API response.
-	// Callers may store/compare the full external:prefix:id form.
-	resp := parseIssueShowOutput(output, issueID)
+	resp := toIssueShowResponse(issue)
+	// Preserve the original request ID in the response (may be external:prefix:id).
+	// Callers may store/compare the full prefixed form.
+	resp.ID = issueID
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -1169,7 +987,7 @@ type IssueCreateResponse struct {
 	Error   string `json:"error,omitempty"`
 }
 
-// handleIssueCreate creates a new issue via bd create.
+// handleIssueCreate creates a new issue in the town database.
 func (h *APIHandler) handleIssueCreate(w http.ResponseWriter, r *http.Request) {
 	var req IssueCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1206,131 +1024,87 @@ func (h *APIHandler) handleIssueCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build bd create command. Flags go first, then -- to end flag parsing,
-	// then the positional title (prevents titles like "--help" being parsed as flags).
-	// bd uses cobra/pflag which respects -- natively (verified: bd --help shows cobra format).
-	args := []string{"create"}
-
-	// Add priority if specified (default is P2)
+	// Default is P2
+	priority := 2
 	if req.Priority >= 1 && req.Priority <= 4 {
-		args = append(args, fmt.Sprintf("--priority=%d", req.Priority))
+		priority = req.Priority
 	}
 
-	// Add description if provided
-	if req.Description != "" {
-		args = append(args, "--body", req.Description)
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
+		return
 	}
 
-	args = append(args, "--", req.Title)
-
-	// Run bd create
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-
-	output, err := h.runBdCommand(ctx, 12*time.Second, args)
-
-	resp := IssueCreateResponse{}
+	issue, err := h.beadsForDir(beads.ResolveBeadsDir(townRoot)).Create(beads.CreateOptions{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    priority,
+		// The title is a form field, not argv, so "--help" is intentional.
+		AllowFlagTitle: true,
+	})
 	if err != nil {
-		resp.Success = false
-		resp.Error = "Failed to create issue: " + err.Error()
-		if output != "" {
-			resp.Message = output
-		}
-	} else {
-		resp.Success = true
-		resp.Message = output
-
-		// Try to extract issue ID from output (e.g., "Created issue: abc123")
-		if strings.Contains(output, "Created") {
-			parts := strings.Fields(output)
-			for i, p := range parts {
-				if strings.HasSuffix(p, ":") && i+1 < len(parts) {
-					resp.ID = strings.TrimSpace(parts[i+1])
-					break
-				}
-			}
-		}
+		h.sendError(w, "Failed to create issue: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
-// IssueCloseRequest is the request body for closing an issue.
-type IssueCloseRequest struct {
-	ID string `json:"id"`
+	_ = json.NewEncoder(w).Encode(IssueCreateResponse{
+		Success: true,
+		ID:      issue.ID,
+		Message: "Created issue: " + issue.ID,
+	})
 }
 
-// handleIssueClose closes an issue via bd close.
+// handleIssueClose closes an issue.
 func (h *APIHandler) handleIssueClose(w http.ResponseWriter, r *http.Request) {
-	var req IssueCloseRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+	issueID := r.PathValue("id")
+	if !isValidID(issueID) {
+		h.sendError(w, "Invalid issue ID format", http.StatusBadRequest)
 		return
 	}
 
-	if req.ID == "" {
-		h.sendError(w, "Issue ID is required", http.StatusBadRequest)
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
 		return
 	}
-	if !isValidID(req.ID) {
-		h.sendError(w, "Invalid issue ID format", http.StatusBadRequest)
+
+	if err := h.beadsForID(townRoot, issueID).Close(issueID); err != nil {
+		h.sendError(w, "Failed to close issue: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	output, err := h.runBdCommand(r.Context(), 12*time.Second, []string{"close", req.ID})
-
 	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Failed to close issue: " + err.Error(),
-			"output":  output,
-		})
-		return
-	}
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Issue closed",
-		"output":  output,
-	})
+	_ = json.NewEncoder(w).Encode(ActionResponse{Success: true, ID: issueID, Message: "Issue closed"})
 }
 
-// IssueUpdateRequest is the request body for updating an issue.
+// IssueUpdateRequest is the request body for updating an issue. The issue
+// is named by the request path.
 type IssueUpdateRequest struct {
-	ID       string `json:"id"`
 	Status   string `json:"status,omitempty"`   // "open", "in_progress"
 	Priority int    `json:"priority,omitempty"` // 1-4
 	Assignee string `json:"assignee,omitempty"`
 }
 
-// handleIssueUpdate updates issue fields via bd update.
+// handleIssueUpdate updates issue status, priority, or assignee.
 func (h *APIHandler) handleIssueUpdate(w http.ResponseWriter, r *http.Request) {
-	var req IssueUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+	issueID := r.PathValue("id")
+	if !isValidID(issueID) {
+		h.sendError(w, "Invalid issue ID format", http.StatusBadRequest)
 		return
 	}
 
-	if req.ID == "" {
-		h.sendError(w, "Issue ID is required", http.StatusBadRequest)
-		return
-	}
-	if !isValidID(req.ID) {
-		h.sendError(w, "Invalid issue ID format", http.StatusBadRequest)
+	var req IssueUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Build bd update args
-	args := []string{"update", req.ID}
-	hasUpdate := false
-
+	var opts beads.UpdateOptions
 	if req.Status != "" {
 		// Validate allowed status values
 		switch req.Status {
 		case "open", "in_progress":
-			args = append(args, "--status="+req.Status)
-			hasUpdate = true
+			opts.Status = &req.Status
 		default:
 			h.sendError(w, "Invalid status (allowed: open, in_progress)", http.StatusBadRequest)
 			return
@@ -1338,8 +1112,7 @@ func (h *APIHandler) handleIssueUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Priority >= 1 && req.Priority <= 4 {
-		args = append(args, fmt.Sprintf("--priority=%d", req.Priority))
-		hasUpdate = true
+		opts.Priority = &req.Priority
 	}
 
 	if req.Assignee != "" {
@@ -1347,226 +1120,26 @@ func (h *APIHandler) handleIssueUpdate(w http.ResponseWriter, r *http.Request) {
 			h.sendError(w, "Invalid assignee format", http.StatusBadRequest)
 			return
 		}
-		args = append(args, "--assignee="+req.Assignee)
-		hasUpdate = true
+		opts.Assignee = &req.Assignee
 	}
 
-	if !hasUpdate {
+	if opts.Status == nil && opts.Priority == nil && opts.Assignee == nil {
 		h.sendError(w, "No update fields provided", http.StatusBadRequest)
 		return
 	}
 
-	output, err := h.runBdCommand(r.Context(), 12*time.Second, args)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Failed to update issue: " + err.Error(),
-			"output":  output,
-		})
+	townRoot := h.requireTownRoot(w)
+	if townRoot == "" {
 		return
 	}
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Issue updated",
-		"output":  output,
-	})
-}
-
-// runBdCommand executes a bd command with the given args.
-func (h *APIHandler) runBdCommand(ctx context.Context, timeout time.Duration, args []string) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	// Acquire semaphore slot — shared with runGtCommand/runGhCommand.
-	select {
-	case h.cmdSem <- struct{}{}:
-		defer func() { <-h.cmdSem }()
-	case <-ctx.Done():
-		return "", fmt.Errorf("command slot unavailable: %w", ctx.Err())
-	}
-
-	cmd := exec.CommandContext(ctx, "bd", args...)
-	if h.workDir != "" {
-		cmd.Dir = h.workDir
-	}
-	cmd.Stdin = nil
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		if output != "" {
-			output += "\n"
-		}
-		output += stderr.String()
-	}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		return output, fmt.Errorf("command timed out after %v", timeout)
-	}
-
-	if err != nil {
-		return output, fmt.Errorf("command failed: %v", err)
-	}
-
-	return output, nil
-}
-
-// parseIssueShowJSON parses the JSON output from "bd show <id> --json".
-// Returns (response, true) on success, or (zero, false) if parsing fails.
-func parseIssueShowJSON(output string) (IssueShowResponse, bool) {
-	var items []struct {
-		ID          string   `json:"id"`
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Status      string   `json:"status"`
-		Priority    int      `json:"priority"`
-		Type        string   `json:"issue_type"`
-		Owner       string   `json:"owner"`
-		CreatedAt   string   `json:"created_at"`
-		UpdatedAt   string   `json:"updated_at"`
-		DependsOn   []string `json:"depends_on,omitempty"`
-		Blocks      []string `json:"blocks,omitempty"`
-	}
-	if err := json.Unmarshal([]byte(output), &items); err != nil || len(items) == 0 {
-		return IssueShowResponse{}, false
-	}
-	item := items[0]
-
-	priority := ""
-	if item.Priority > 0 {
-		priority = fmt.Sprintf("P%d", item.Priority)
-	}
-
-	return IssueShowResponse{
-		ID:          item.ID,
-		Title:       item.Title,
-		Type:        item.Type,
-		Status:      item.Status,
-		Priority:    priority,
-		Owner:       item.Owner,
-		Description: item.Description,
-		Created:     item.CreatedAt,
-		Updated:     item.UpdatedAt,
-		DependsOn:   item.DependsOn,
-		Blocks:      item.Blocks,
-		RawOutput:   output,
-	}, true
-}
-
-// parseIssueShowOutput parses the text output from "bd show <id>".
-// This is the fallback path when --json is unavailable.
-func parseIssueShowOutput(output string, issueID string) IssueShowResponse {
-	resp := IssueShowResponse{
-		ID:        issueID,
-		RawOutput: output,
-	}
-
-	lines := strings.Split(output, "\n")
-	inDescription := false
-	parsedFirstLine := false
-	var descLines []string
-	var dependsOn []string
-	var blocks []string
-
-	for _, line := range lines {
-		// First non-empty line usually has the format: "○ id · title   [● P2 · OPEN]"
-		if !parsedFirstLine && (strings.HasPrefix(line, "○") || strings.HasPrefix(line, "●")) {
-			parsedFirstLine = true
-			// Parse the first line for title and status
-			// Format: "○ id · title   [● P2 · OPEN]"
-			// Find the bracket first to isolate the status
-			if bracketIdx := strings.Index(line, "["); bracketIdx > 0 {
-				beforeBracket := line[:bracketIdx]
-				statusPart := line[bracketIdx:]
-
-				// Extract priority and status from [● P2 · OPEN]
-				statusPart = strings.Trim(statusPart, "[]●○ ")
-				statusParts := strings.Split(statusPart, "·")
-				if len(statusParts) >= 1 {
-					resp.Priority = strings.TrimSpace(statusParts[0])
-				}
-				if len(statusParts) >= 2 {
-					resp.Status = strings.TrimSpace(statusParts[1])
-				}
-
-				// Now parse the title from before the bracket
-				// Format: "○ id · title"
-				// Use strings.Cut for safe splitting on multi-byte "·" separator
-				if _, afterFirst, ok := strings.Cut(beforeBracket, "·"); ok {
-					if _, afterSecond, ok := strings.Cut(afterFirst, "·"); ok {
-						resp.Title = strings.TrimSpace(afterSecond)
-					} else {
-						// Only one dot - id is embedded in icon part
-						resp.Title = strings.TrimSpace(afterFirst)
-					}
-				}
-			}
-			continue
-		}
-
-		if strings.HasPrefix(line, "Owner:") {
-			// Format: "Owner: mayor · Type: task"
-			ownerLine := strings.TrimPrefix(line, "Owner:")
-			ownerParts := strings.Split(ownerLine, "·")
-			resp.Owner = strings.TrimSpace(ownerParts[0])
-			if len(ownerParts) >= 2 {
-				typePart := strings.TrimSpace(ownerParts[1])
-				resp.Type = strings.TrimSpace(strings.TrimPrefix(typePart, "Type:"))
-			}
-		} else if strings.HasPrefix(line, "Type:") {
-			resp.Type = strings.TrimSpace(strings.TrimPrefix(line, "Type:"))
-		} else if strings.HasPrefix(line, "Created:") {
-			// Split always returns >= 1 element; parts[0] is safe unconditionally
-			parts := strings.Split(line, "·")
-			resp.Created = strings.TrimSpace(strings.TrimPrefix(parts[0], "Created:"))
-			if len(parts) >= 2 {
-				resp.Updated = strings.TrimSpace(strings.TrimPrefix(parts[1], "Updated:"))
-			}
-		} else if line == "DESCRIPTION" {
-			inDescription = true
-		} else if line == "DEPENDS ON" || line == "BLOCKS" {
-			inDescription = false
-		} else if inDescription && strings.TrimSpace(line) != "" {
-			descLines = append(descLines, line)
-		} else if strings.HasPrefix(strings.TrimSpace(line), "→") {
-			// Dependency line
-			depLine := strings.TrimSpace(line)
-			depLine = strings.TrimPrefix(depLine, "→")
-			depLine = strings.TrimSpace(depLine)
-			// Extract just the bead ID
-			if colonIdx := strings.Index(depLine, ":"); colonIdx > 0 {
-				parts := strings.Fields(depLine[:colonIdx])
-				if len(parts) >= 2 {
-					dependsOn = append(dependsOn, parts[1])
-				}
-			}
-		} else if strings.HasPrefix(strings.TrimSpace(line), "←") {
-			// Blocks line
-			blockLine := strings.TrimSpace(line)
-			blockLine = strings.TrimPrefix(blockLine, "←")
-			blockLine = strings.TrimSpace(blockLine)
-			// Extract just the bead ID
-			if colonIdx := strings.Index(blockLine, ":"); colonIdx > 0 {
-				parts := strings.Fields(blockLine[:colonIdx])
-				if len(parts) >= 2 {
-					blocks = append(blocks, parts[1])
-				}
-			}
-		}
+	if err := h.beadsForID(townRoot, issueID).Update(issueID, opts); err != nil {
+		h.sendError(w, "Failed to update issue: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	resp.Description = strings.TrimSpace(strings.Join(descLines, "\n"))
-	resp.DependsOn = dependsOn
-	resp.Blocks = blocks
-
-	return resp
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ActionResponse{Success: true, ID: issueID, Message: "Issue updated"})
 }
 
 // PRShowResponse is the response for /api/pr/show.
@@ -1657,7 +1230,7 @@ func (h *APIHandler) runGhCommand(ctx context.Context, timeout time.Duration, ar
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Acquire semaphore slot — shared with runGtCommand/runBdCommand.
+	// Acquire semaphore slot — shared with runGtCommand.
 	select {
 	case h.cmdSem <- struct{}{}:
 		defer func() { <-h.cmdSem }()
@@ -1779,7 +1352,7 @@ type CrewMember struct {
 	LastActive string `json:"last_active"`
 }
 
-// CrewResponse is the response for /api/crew.
+// CrewResponse is the response for /api/v1/crew.
 type CrewResponse struct {
 	Crew  []CrewMember            `json:"crew"`
 	ByRig map[string][]CrewMember `json:"by_rig"`
@@ -1795,7 +1368,7 @@ type ReadyItem struct {
 	Type     string `json:"type"`   // issue, mr, etc.
 }
 
-// ReadyResponse is the response for /api/ready.
+// ReadyResponse is the response for /api/v1/ready.
 type ReadyResponse struct {
 	Items    []ReadyItem            `json:"items"`
 	BySource map[string][]ReadyItem `json:"by_source"`
@@ -1807,55 +1380,57 @@ type ReadyResponse struct {
 	} `json:"summary"`
 }
 
-// handleCrew returns crew status across all rigs with proper state detection.
-func (h *APIHandler) handleCrew(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-
-	// Run gt crew list --all --json to get crew across all rigs
-	output, err := h.runGtCommand(ctx, 10*time.Second, []string{"crew", "list", "--all", "--json"})
-
-	resp := CrewResponse{
-		Crew:  make([]CrewMember, 0),
-		ByRig: make(map[string][]CrewMember),
+// handleFormulaSchema returns the JSON Schema for a formula's inputs, for
+// rendering a run form.
+func (h *APIHandler) handleFormulaSchema(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !isValidID(name) {
+		h.sendError(w, "Invalid formula name", http.StatusBadRequest)
+		return
 	}
 
+	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"formula", "schema", name})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
+		h.sendError(w, "Failed to load formula schema: "+err.Error(), http.StatusNotFound)
 		return
 	}
-
-	// Parse the JSON output
-	var crewData []struct {
-		Name    string `json:"name"`
-		Rig     string `json:"rig"`
-		Branch  string `json:"branch"`
-		Session string `json:"session,omitempty"`
-		Hook    string `json:"hook,omitempty"`
+	if !json.Valid([]byte(output)) {
+		h.sendError(w, "Formula schema is not valid JSON", http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(output))
+}
 
-	if err := json.Unmarshal([]byte(output), &crewData); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
-		return
+// handleCrew returns the crew of every rig with each member's hooked work
+// and session state.
+func (h *APIHandler) handleCrew(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	resp := CrewResponse{
+		Crew:  make([]CrewMember, 0),
+		ByRig: make(map[string][]CrewMember),
 	}
 
-	// Convert to CrewMember format with state detection
-	for _, c := range crewData {
-		sessionName := session.CrewSessionName(session.PrefixFor(c.Rig), c.Name)
-		state, lastActive, sessionStatus := h.detectCrewState(ctx, sessionName, c.Hook)
+	if townRoot := h.townRoot(); townRoot != "" {
+		rigs := discoverRigs(townRoot)
+		hooked := h.hookedIssues(townRoot, rigs)
+		for _, c := range listCrew(rigs) {
+			member := CrewMember{
+				Name: c.Name,
+				Rig:  c.Rig,
+			}
+			if issue := hooked[c.Rig+"/crew/"+c.Name]; issue != nil {
+				member.Hook = issue.ID
+				member.HookTitle = issue.Title
+			}
+			sessionName := session.CrewSessionName(session.PrefixFor(c.Rig), c.Name)
+			member.State, member.LastActive, member.Session = h.detectCrewState(ctx, sessionName, member.Hook)
 
-		member := CrewMember{
-			Name:       c.Name,
-			Rig:        c.Rig,
-			State:      state,
-			Hook:       c.Hook,
-			Session:    sessionStatus,
-			LastActive: lastActive,
+			resp.Crew = append(resp.Crew, member)
+			resp.ByRig[c.Rig] = append(resp.ByRig[c.Rig], member)
 		}
-		resp.Crew = append(resp.Crew, member)
-		resp.ByRig[c.Rig] = append(resp.ByRig[c.Rig], member)
 	}
 	resp.Total = len(resp.Crew)
 
@@ -2012,71 +1587,44 @@ func determineCrewState(activityAge time.Duration, isClaudeRunning bool, hook st
 	}
 }
 
-// handleReady returns ready work items across town.
-func (h *APIHandler) handleReady(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-
-	// Run gt ready --json to get ready work
-	output, err := h.runGtCommand(ctx, 12*time.Second, []string{"ready", "--json"})
-
+// handleReady returns ready work across the town and its rigs, filtered the
+// way "gt ready" filters it.
+func (h *APIHandler) handleReady(w http.ResponseWriter, _ *http.Request) {
 	resp := ReadyResponse{
 		Items:    make([]ReadyItem, 0),
 		BySource: make(map[string][]ReadyItem),
 	}
 
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	// Parse the JSON output from gt ready
-	var readyData struct {
-		Sources []struct {
-			Name   string `json:"name"`
-			Issues []struct {
-				ID       string `json:"id"`
-				Title    string `json:"title"`
-				Priority int    `json:"priority"`
-				Type     string `json:"type"`
-			} `json:"issues"`
-		} `json:"sources"`
-		Summary struct {
-			Total   int `json:"total"`
-			P1Count int `json:"p1_count"`
-			P2Count int `json:"p2_count"`
-			P3Count int `json:"p3_count"`
-		} `json:"summary"`
-	}
-
-	if err := json.Unmarshal([]byte(output), &readyData); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	// Convert to ReadyItem format
-	for _, src := range readyData.Sources {
-		for _, issue := range src.Issues {
-			item := ReadyItem{
-				ID:       issue.ID,
-				Title:    issue.Title,
-				Priority: issue.Priority,
-				Source:   src.Name,
-				Type:     issue.Type,
+	if townRoot := h.townRoot(); townRoot != "" {
+		for _, src := range beadsSources(townRoot, discoverRigs(townRoot)) {
+			issues, err := h.beadsForDir(src.beadsDir).Ready()
+			if err != nil {
+				log.Printf("warning: handleReady: %s: %v", src.name, err)
+				continue
 			}
-			resp.Items = append(resp.Items, item)
-			resp.BySource[src.Name] = append(resp.BySource[src.Name], item)
-
-			// Count priorities
-			switch issue.Priority {
-			case 1:
-				resp.Summary.P1Count++
-			case 2:
-				resp.Summary.P2Count++
-			case 3:
-				resp.Summary.P3Count++
+			work := readyWork(townRoot, src, issues)
+			sort.SliceStable(work, func(i, j int) bool { return work[i].Priority < work[j].Priority })
+
+			for _, issue := range work {
+				item := ReadyItem{
+					ID:       issue.ID,
+					Title:    issue.Title,
+					Priority: issue.Priority,
+					Source:   src.name,
+					Type:     issue.Type,
+				}
+				resp.Items = append(resp.Items, item)
+				resp.BySource[src.name] = append(resp.BySource[src.name], item)
+
+				// Count priorities
+				switch issue.Priority {
+				case 1:
+					resp.Summary.P1Count++
+				case 2:
+					resp.Summary.P2Count++
+				case 3:
+					resp.Summary.P3Count++
+				}
 			}
 		}
 	}
@@ -2101,18 +1649,10 @@ func (h *APIHandler) handleSessionPreview(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Validate session name: must start with a known prefix and contain only safe characters
-	hasValidPrefix := session.HasKnownPrefix(sessionName)
-	if !hasValidPrefix {
-		h.sendError(w, "Invalid session name: must start with a known rig prefix", http.StatusBadRequest)
+	if err := validateSessionName(sessionName); err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	for _, c := range sessionName {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
-			h.sendError(w, "Invalid session name: contains invalid characters", http.StatusBadRequest)
-			return
-		}
-	}
 
 	// Run tmux capture-pane to get the last 30 lines
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
@@ -2177,10 +1717,23 @@ func parseCommandArgs(command string) []string {
 	return args
 }
 
-// handleSSE streams Server-Sent Events to the dashboard client.
-// It polls key dashboard state every 2 seconds and sends an event when
-// changes are detected, allowing the client to trigger a re-render.
-// Falls through gracefully if the client disconnects.
+// Poll intervals for the live event stream. The event log poll is a stat
+// call; the bead poll is one query per beads database.
+const (
+	liveLogPoll   = time.Second
+	liveBeadPoll  = 3 * time.Second
+	liveKeepalive = 15 * time.Second
+)
+
+// handleSSE streams typed dashboard updates as Server-Sent Events.
+// GET /api/events[?since=<cursor>]
+//
+// Updates come from the town event log (agent, merge, and mail events) and
+// from the beads databases' change records (bead and convoy events). The last
+// event of each batch carries a cursor id, so a reconnecting client resumes
+// from Last-Event-ID, or from ?since= for clients that can't set headers.
+// When a cursor can't be resumed (the log was pruned), the stream sends
+// "resync" and continues from now.
 func (h *APIHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -2194,17 +1747,59 @@ func (h *APIHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Accel-Buffering", "no")
 
 	ctx := r.Context()
+	townRoot := h.townRoot()
+	var logPath string
+	if townRoot != "" {
+		logPath = filepath.Join(townRoot, events.EventsFile)
+	}
+	beadSrc := h.liveBeads
+	if beadSrc == nil {
+		beadSrc = storeBeadChanges{stores: &h.stores}
+	}
+
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("since")
+	}
+	var tail *eventLogTail
+	var feed *beadFeed
+	resync := resume != ""
+	if c, ok := parseLiveCursor(resume); ok {
+		if t, err := tailFromCursor(logPath, c); err == nil {
+			tail, feed, resync = t, newBeadFeed(beadSrc, townRoot, c.beads), false
+		}
+	}
+	if tail == nil {
+		tail, feed = tailFromEnd(logPath), newBeadFeed(beadSrc, townRoot, time.Now())
+	}
+	cursor := func() string {
+		return liveCursor{offset: tail.offset, line: tail.line, beads: feed.since}.String()
+	}
 
-	// Send initial connection event
-	fmt.Fprintf(w, "event: connected\ndata: ok\n\n")
+	writeSSE(w, cursor(), "connected", "ok")
+	if resync {
+		writeSSE(w, cursor(), LiveResync, LiveEvent{Kind: LiveResync, Time: time.Now()})
+	}
 	flusher.Flush()
 
-	var lastHash string
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+	send := func(evs []LiveEvent) {
+		for i, ev := range evs {
+			id := ""
+			if i == len(evs)-1 {
+				id = cursor()
+			}
+			writeSSE(w, id, ev.Kind, ev)
+		}
+		if len(evs) > 0 {
+			flusher.Flush()
+		}
+	}
 
-	// Send keepalive comment every 15 seconds to prevent connection timeouts
-	keepalive := time.NewTicker(15 * time.Second)
+	logTicker := time.NewTicker(liveLogPoll)
+	defer logTicker.Stop()
+	beadTicker := time.NewTicker(liveBeadPoll)
+	defer beadTicker.Stop()
+	keepalive := time.NewTicker(liveKeepalive)
 	defer keepalive.Stop()
 
 	for {
@@ -2214,67 +1809,46 @@ func (h *APIHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
 		case <-keepalive.C:
 			fmt.Fprintf(w, ": keepalive\n\n")
 			flusher.Flush()
-		case <-ticker.C:
-			hash := h.computeDashboardHash(ctx)
-			if hash != "" && hash != lastHash {
-				lastHash = hash
-				fmt.Fprintf(w, "event: dashboard-update\ndata: %s\n\n", hash)
+		case <-logTicker.C:
+			if townRoot == "" {
+				continue // nothing to follow; keep the connection so the page stays live
+			}
+			evs, err := tail.next()
+			if errors.Is(err, errLogRewritten) {
+				tail = tailFromEnd(logPath)
+				writeSSE(w, cursor(), LiveResync, LiveEvent{Kind: LiveResync, Time: time.Now()})
 				flusher.Flush()
+				continue
+			}
+			send(evs)
+		case <-beadTicker.C:
+			if townRoot == "" {
+				continue
+			}
+			evs, err := feed.next(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("warning: live events: bead changes: %v", err)
 			}
+			send(evs)
 		}
 	}
 }
 
-// computeDashboardHash generates a lightweight hash of key dashboard state.
-// It runs quick commands in parallel and hashes their output to detect changes.
-func (h *APIHandler) computeDashboardHash(ctx context.Context) string {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	var mu sync.Mutex
-	var parts []string
-
-	var wg sync.WaitGroup
-	wg.Add(3)
-
-	// Check worker/polecat state
-	go func() {
-		defer wg.Done()
-		if out, err := h.runGtCommand(ctx, 3*time.Second, []string{"status", "--json"}); err == nil {
-			mu.Lock()
-			parts = append(parts, "status:"+out)
-			mu.Unlock()
-		}
-	}()
-
-	// Check hooks state
-	go func() {
-		defer wg.Done()
-		if out, err := h.runGtCommand(ctx, 3*time.Second, []string{"hooks", "list"}); err == nil {
-			mu.Lock()
-			parts = append(parts, "hooks:"+out)
-			mu.Unlock()
-		}
-	}()
-
-	// Check mail count
-	go func() {
-		defer wg.Done()
-		if out, err := h.runGtCommand(ctx, 3*time.Second, []string{"mail", "inbox"}); err == nil {
-			mu.Lock()
-			parts = append(parts, "mail:"+out)
-			mu.Unlock()
+// writeSSE writes one Server-Sent Event. Data that isn't a string is sent
+// as JSON. An empty id leaves the client's last event id unchanged.
+func writeSSE(w io.Writer, id, event string, data interface{}) {
+	payload, ok := data.(string)
+	if !ok {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return
 		}
-	}()
-
-	wg.Wait()
-
-	if len(parts) == 0 {
-		return ""
+		payload = string(b)
 	}
-
-	h256 := sha256.Sum256([]byte(strings.Join(parts, "|")))
-	return fmt.Sprintf("%x", h256[:8])
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
 }
 
 // handleRigAdd creates a new rig, optionally with a local bare repo.
@@ -2292,6 +1866,7 @@ func (h *APIHandler) handleRigAdd(w http.ResponseWriter, r *http.Request) {
 		h.sendError(w, "Rig name is required", http.StatusBadRequest)
 		return
 	}
+	noteAudit(r.Context(), "rig", req.Name)
 	if !isValidRigName(req.Name) {
 		h.sendError(w, "Invalid rig name: must be alphanumeric/underscore only", http.StatusBadRequest)
 		return
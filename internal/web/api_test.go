@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -458,7 +459,7 @@ func TestGetCommandList(t *testing.T) {
 
 func TestAPIHandler_Crew(t *testing.T) {
 	handler := &APIHandler{
-		gtPath:            "false", // fast-failing stub — crew handler gracefully returns empty on error
+		gtPath:            "false", // fast-failing stub — crew handler returns empty outside a town
 		workDir:           t.TempDir(),
 		defaultRunTimeout: 5 * time.Second,
 		maxRunTimeout:     10 * time.Second,
@@ -466,13 +467,13 @@ func TestAPIHandler_Crew(t *testing.T) {
 		csrfToken:         "test-token",
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/crew", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/crew", nil)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("GET /api/crew status = %d, want %d", w.Code, http.StatusOK)
+		t.Errorf("GET /api/v1/crew status = %d, want %d", w.Code, http.StatusOK)
 	}
 
 	var resp CrewResponse
@@ -491,7 +492,7 @@ func TestAPIHandler_Crew(t *testing.T) {
 
 func TestAPIHandler_Ready(t *testing.T) {
 	handler := &APIHandler{
-		gtPath:            "false", // fast-failing stub — ready handler gracefully returns empty on error
+		gtPath:            "false", // fast-failing stub — ready handler returns empty outside a town
 		workDir:           t.TempDir(),
 		defaultRunTimeout: 5 * time.Second,
 		maxRunTimeout:     10 * time.Second,
@@ -499,13 +500,13 @@ func TestAPIHandler_Ready(t *testing.T) {
 		csrfToken:         "test-token",
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/ready", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ready", nil)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("GET /api/ready status = %d, want %d", w.Code, http.StatusOK)
+		t.Errorf("GET /api/v1/ready status = %d, want %d", w.Code, http.StatusOK)
 	}
 
 	var resp ReadyResponse
@@ -526,7 +527,7 @@ func TestAPIHandler_IssueCreate_MissingTitle(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	body := `{"title": ""}`
-	req := httptest.NewRequest(http.MethodPost, "/api/issues/create", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/issues", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
@@ -534,7 +535,7 @@ func TestAPIHandler_IssueCreate_MissingTitle(t *testing.T) {
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/issues/create empty title status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/issues empty title status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -556,7 +557,7 @@ func TestAPIHandler_IssueCreate_InvalidTitle(t *testing.T) {
 				"title": tt.title,
 			}
 			body, _ := json.Marshal(payload)
-			req := httptest.NewRequest(http.MethodPost, "/api/issues/create", bytes.NewBuffer(body))
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/issues", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("X-Dashboard-Token", "test-token")
 			w := httptest.NewRecorder()
@@ -564,7 +565,7 @@ func TestAPIHandler_IssueCreate_InvalidTitle(t *testing.T) {
 			handler.ServeHTTP(w, req)
 
 			if w.Code != http.StatusBadRequest {
-				t.Errorf("POST /api/issues/create with %s: status = %d, want %d", tt.name, w.Code, http.StatusBadRequest)
+				t.Errorf("POST /api/v1/issues with %s: status = %d, want %d", tt.name, w.Code, http.StatusBadRequest)
 			}
 		})
 	}
@@ -578,7 +579,7 @@ func TestAPIHandler_IssueCreate_InvalidDescription(t *testing.T) {
 		"description": "desc with null\x00byte",
 	}
 	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/api/issues/create", bytes.NewBuffer(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/issues", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
@@ -586,7 +587,7 @@ func TestAPIHandler_IssueCreate_InvalidDescription(t *testing.T) {
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/issues/create with null in description: status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/issues with null in description: status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -594,7 +595,7 @@ func TestAPIHandler_IssueCreate_InvalidJSON(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	body := `{not valid json}`
-	req := httptest.NewRequest(http.MethodPost, "/api/issues/create", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/issues", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
@@ -602,101 +603,10 @@ func TestAPIHandler_IssueCreate_InvalidJSON(t *testing.T) {
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/issues/create invalid JSON status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/issues invalid JSON status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
-// --- parseIssueShowOutput edge-case tests (issue #1228: panic-safe string indexing) ---
-
-func TestParseIssueShowOutput_EmptyOutput(t *testing.T) {
-	resp := parseIssueShowOutput("", "gt-123")
-	if resp.ID != "gt-123" {
-		t.Errorf("ID = %q, want %q", resp.ID, "gt-123")
-	}
-	if resp.Title != "" {
-		t.Errorf("Title = %q, want empty", resp.Title)
-	}
-}
-
-func TestParseIssueShowOutput_NoBracket(t *testing.T) {
-	// First line without bracket section — title extraction is skipped
-	input := "○ gt-abc · My title without status\nType: issue\nCreated: 2025-01-01"
-	resp := parseIssueShowOutput(input, "gt-abc")
-	if resp.Title != "" {
-		t.Errorf("Title = %q, want empty (no bracket means no title extraction)", resp.Title)
-	}
-	if resp.Type != "issue" {
-		t.Errorf("Type = %q, want %q", resp.Type, "issue")
-	}
-	if resp.Created != "2025-01-01" {
-		t.Errorf("Created = %q, want %q", resp.Created, "2025-01-01")
-	}
-}
-
-func TestParseIssueShowOutput_NoDotSeparator(t *testing.T) {
-	// Created line without "·" separator — should not panic on parts[0]
-	input := "○ gt-abc · My title   [● P2 · OPEN]\nCreated: 2025-01-01"
-	resp := parseIssueShowOutput(input, "gt-abc")
-	if resp.Created != "2025-01-01" {
-		t.Errorf("Created = %q, want %q", resp.Created, "2025-01-01")
-	}
-	if resp.Updated != "" {
-		t.Errorf("Updated = %q, want empty", resp.Updated)
-	}
-}
-
-func TestParseIssueShowOutput_CreatedAndUpdated(t *testing.T) {
-	// No space around "·" here so TrimPrefix strips "Updated:" cleanly.
-	// Real bd output may have spaces around "·", but this test validates
-	// the bounds-check safety of the split, not the TrimPrefix edge case.
-	input := "○ gt-abc · My title   [● P2 · OPEN]\nCreated: 2025-01-01·Updated: 2025-06-15"
-	resp := parseIssueShowOutput(input, "gt-abc")
-	if resp.Created != "2025-01-01" {
-		t.Errorf("Created = %q, want %q", resp.Created, "2025-01-01")
-	}
-	if resp.Updated != "2025-06-15" {
-		t.Errorf("Updated = %q, want %q", resp.Updated, "2025-06-15")
-	}
-}
-
-func TestParseIssueShowOutput_TitleAndStatus(t *testing.T) {
-	input := "○ gt-abc · Deploy widget   [● P1 · IN PROGRESS]\nType: convoy"
-	resp := parseIssueShowOutput(input, "gt-abc")
-	if resp.Title != "Deploy widget" {
-		t.Errorf("Title = %q, want %q", resp.Title, "Deploy widget")
-	}
-	if resp.Priority != "P1" {
-		t.Errorf("Priority = %q, want %q", resp.Priority, "P1")
-	}
-	if resp.Status != "IN PROGRESS" {
-		t.Errorf("Status = %q, want %q", resp.Status, "IN PROGRESS")
-	}
-}
-
-func TestParseMailInboxText_EmptyOutput(t *testing.T) {
-	msgs := parseMailInboxText("")
-	if len(msgs) != 0 {
-		t.Errorf("got %d messages from empty output, want 0", len(msgs))
-	}
-}
-
-func TestParseMailInboxText_UnreadMarker(t *testing.T) {
-	// Verifies the TrimPrefix fix for "●" marker — should not panic
-	input := "📬 Inbox:\n1. ● Test subject\n      msg-1 from alice\n      2025-01-01"
-	msgs := parseMailInboxText(input)
-	if len(msgs) != 1 {
-		t.Fatalf("got %d messages, want 1", len(msgs))
-	}
-	if msgs[0].Subject != "Test subject" {
-		t.Errorf("Subject = %q, want %q", msgs[0].Subject, "Test subject")
-	}
-	if msgs[0].Read {
-		t.Error("expected unread message")
-	}
-}
-
-// --- groupIntoThreads tests ---
-
 func TestGroupIntoThreads_SingleMessages(t *testing.T) {
 	msgs := []MailMessage{
 		{ID: "msg-1", From: "alice", Subject: "Hello", Timestamp: "2026-01-01T10:00:00Z"},
@@ -782,81 +692,6 @@ func TestGroupIntoThreads_ReSubjectStrip(t *testing.T) {
 	}
 }
 
-// --- parseIssueShowJSON tests (issue #1228: prefer structured JSON over text parsing) ---
-
-func TestParseIssueShowJSON_ValidOutput(t *testing.T) {
-	input := `[{
-		"id": "gt-abc",
-		"title": "Deploy widget",
-		"description": "Detailed plan here",
-		"status": "open",
-		"priority": 1,
-		"issue_type": "convoy",
-		"created_at": "2025-01-01T00:00:00Z",
-		"updated_at": "2025-06-15T00:00:00Z",
-		"depends_on": ["gt-dep1"],
-		"blocks": ["gt-blk1", "gt-blk2"]
-	}]`
-	resp, ok := parseIssueShowJSON(input)
-	if !ok {
-		t.Fatal("parseIssueShowJSON returned ok=false for valid input")
-	}
-	if resp.ID != "gt-abc" {
-		t.Errorf("ID = %q, want %q", resp.ID, "gt-abc")
-	}
-	if resp.Title != "Deploy widget" {
-		t.Errorf("Title = %q, want %q", resp.Title, "Deploy widget")
-	}
-	if resp.Priority != "P1" {
-		t.Errorf("Priority = %q, want %q", resp.Priority, "P1")
-	}
-	if resp.Status != "open" {
-		t.Errorf("Status = %q, want %q", resp.Status, "open")
-	}
-	if resp.Type != "convoy" {
-		t.Errorf("Type = %q, want %q", resp.Type, "convoy")
-	}
-	if resp.Description != "Detailed plan here" {
-		t.Errorf("Description = %q, want %q", resp.Description, "Detailed plan here")
-	}
-	if len(resp.DependsOn) != 1 || resp.DependsOn[0] != "gt-dep1" {
-		t.Errorf("DependsOn = %v, want [gt-dep1]", resp.DependsOn)
-	}
-	if len(resp.Blocks) != 2 {
-		t.Errorf("Blocks = %v, want 2 elements", resp.Blocks)
-	}
-}
-
-func TestParseIssueShowJSON_ZeroPriority(t *testing.T) {
-	input := `[{"id": "gt-abc", "title": "No priority", "priority": 0}]`
-	resp, ok := parseIssueShowJSON(input)
-	if !ok {
-		t.Fatal("parseIssueShowJSON returned ok=false")
-	}
-	if resp.Priority != "" {
-		t.Errorf("Priority = %q, want empty for priority=0", resp.Priority)
-	}
-}
-
-func TestParseIssueShowJSON_InvalidInputs(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-	}{
-		{"empty string", ""},
-		{"malformed JSON", "{not valid"},
-		{"empty array", "[]"},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			_, ok := parseIssueShowJSON(tt.input)
-			if ok {
-				t.Errorf("parseIssueShowJSON(%q) returned ok=true, want false", tt.input)
-			}
-		})
-	}
-}
-
 func TestAPIHandler_SSE_ContentType(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
@@ -912,7 +747,7 @@ func TestOptionsCacheConcurrentAccess(t *testing.T) {
 		if i%2 == 0 {
 			go func() {
 				defer wg.Done()
-				req := httptest.NewRequest(http.MethodGet, "/api/options", nil)
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/options", nil)
 				w := httptest.NewRecorder()
 				h.handleOptions(w, req)
 				if w.Code != http.StatusOK {
@@ -1046,7 +881,7 @@ esac
 		cmdSem:            make(chan struct{}, maxConcurrentCommands),
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/options?type=rigs", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/options?type=rigs", nil)
 	w := httptest.NewRecorder()
 	h.handleOptions(w, req)
 
@@ -1098,7 +933,7 @@ esac
 		cmdSem:            make(chan struct{}, maxConcurrentCommands),
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/options?type=rigs", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/options?type=rigs", nil)
 	w := httptest.NewRecorder()
 	h.handleOptions(w, req)
 
@@ -1142,7 +977,7 @@ func TestHandleOptionsTypeRigsUsesConfigWithoutCommands(t *testing.T) {
 		cmdSem:            make(chan struct{}, maxConcurrentCommands),
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/options?type=rigs", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/options?type=rigs", nil)
 	w := httptest.NewRecorder()
 	h.handleOptions(w, req)
 
@@ -1193,7 +1028,7 @@ func TestHandleOptionsTypeRigsFindsConfigFromSubdir(t *testing.T) {
 		cmdSem:            make(chan struct{}, maxConcurrentCommands),
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/api/options?type=rigs", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/options?type=rigs", nil)
 	w := httptest.NewRecorder()
 	h.handleOptions(w, req)
 
@@ -1209,67 +1044,6 @@ func TestHandleOptionsTypeRigsFindsConfigFromSubdir(t *testing.T) {
 	}
 }
 
-func TestParseConvoyListJSON(t *testing.T) {
-	tests := []struct {
-		name string
-		json string
-		want []string
-	}{
-		{
-			name: "valid JSON with convoys",
-			json: `[{"id":"hq-cv-abc","title":"Deploy widgets","issue_type":"convoy"},{"id":"hq-cv-def","title":"Fix bugs","issue_type":"task","labels":["gt:convoy"]}]`,
-			want: []string{"hq-cv-abc", "hq-cv-def"},
-		},
-		{
-			name: "empty array",
-			json: `[]`,
-			want: []string{},
-		},
-		{
-			name: "invalid JSON",
-			json: `{not valid`,
-			want: nil,
-		},
-		{
-			name: "empty string",
-			json: ``,
-			want: nil,
-		},
-		{
-			name: "skips empty IDs",
-			json: `[{"id":"hq-cv-abc","issue_type":"convoy"},{"id":"","issue_type":"convoy"},{"id":"hq-cv-def","labels":["gt:convoy"]}]`,
-			want: []string{"hq-cv-abc", "hq-cv-def"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parseConvoyListJSON(tt.json)
-			if tt.want == nil {
-				if got != nil {
-					t.Errorf("parseConvoyListJSON(%q) = %v, want nil", tt.json, got)
-				}
-				return
-			}
-			if len(got) != len(tt.want) {
-				t.Errorf("parseConvoyListJSON(%q) = %v, want %v", tt.json, got, tt.want)
-				return
-			}
-			for i := range got {
-				if got[i] != tt.want[i] {
-					t.Errorf("parseConvoyListJSON(%q)[%d] = %q, want %q", tt.json, i, got[i], tt.want[i])
-				}
-			}
-		})
-	}
-}
-
-// TestRunGtCommandSemaphore verifies that runGtCommand limits concurrent
-// command execution via a semaphore. With a 1-slot semaphore and 3 commands
-// each sleeping 0.1s, total time must be >= 0.25s (serialized), proving the
-// semaphore prevents all 3 from running simultaneously (~0.1s).
-//
-// Regression test for steveyegge/gastown#1230 item 5.
 func TestRunGtCommandSemaphore(t *testing.T) {
 	// Create handler with a 1-slot semaphore — fully serialized execution.
 	h := &APIHandler{
@@ -1457,3 +1231,17 @@ func TestPaneCurrentCommandIsAgent(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIHandler_FormulaSchema_InvalidName(t *testing.T) {
+	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
+
+	for _, name := range []string{"--help", "a b", "x;y"} {
+		path := "/api/v1/formulas/" + url.PathEscape(name) + "/schema"
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want %d", path, w.Code, http.StatusBadRequest)
+		}
+	}
+}
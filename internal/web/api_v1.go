@@ -0,0 +1,461 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/crew"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// dashboardMailIdentity is the mailbox the dashboard reads and sends as.
+// The dashboard runs from the town root, where gt resolves the sender to the
+// human overseer.
+const dashboardMailIdentity = "overseer"
+
+// serveV1 routes /api/v1 requests. Every v1 endpoint is served in-process from
+// the gastown packages (mail, beads, crew, polecat, refinery) instead of by
+// running gt or bd and scraping their output. The JSON types it returns are
+// the v1 contract: fields may be added, but never renamed or removed.
+func (h *APIHandler) serveV1(w http.ResponseWriter, r *http.Request) {
+	h.v1Once.Do(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/v1/mail/inbox", h.handleMailInbox)
+		mux.HandleFunc("GET /api/v1/mail/threads", h.handleMailThreads)
+		mux.HandleFunc("GET /api/v1/mail/messages/{id}", h.handleMailRead)
+		mux.HandleFunc("POST /api/v1/mail/messages/{id}/read", h.handleMailMarkRead)
+		mux.HandleFunc("POST /api/v1/mail/send", h.handleMailSend)
+		mux.HandleFunc("POST /api/v1/issues", h.handleIssueCreate)
+		mux.HandleFunc("GET /api/v1/issues/{id}", h.handleIssueShow)
+		mux.HandleFunc("POST /api/v1/issues/{id}/close", h.handleIssueClose)
+		mux.HandleFunc("POST /api/v1/issues/{id}/update", h.handleIssueUpdate)
+		mux.HandleFunc("GET /api/v1/crew", h.handleCrew)
+		mux.HandleFunc("GET /api/v1/polecats", h.handlePolecats)
+		mux.HandleFunc("GET /api/v1/merge-queue", h.handleMergeQueue)
+		mux.HandleFunc("GET /api/v1/ready", h.handleReady)
+		mux.HandleFunc("GET /api/v1/options", h.handleOptions)
+		mux.HandleFunc("GET /api/v1/formulas/{name}/schema", h.handleFormulaSchema)
+		mux.HandleFunc("GET /api/v1/metrics/history", h.handleMetricsHistory)
+		mux.HandleFunc("GET /api/v1/sessions/{session}/terminal", h.handleTerminal)
+		h.v1Mux = mux
+		if h.terminals == nil {
+			h.terminals = newTerminalHub(tmuxPaneSource{t: tmux.NewTmux()})
+		}
+	})
+	h.v1Mux.ServeHTTP(w, r)
+}
+
+// ActionResponse is the response for v1 endpoints that change state without
+// returning a resource.
+type ActionResponse struct {
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// PolecatInfo is a polecat as reported by /api/v1/polecats.
+type PolecatInfo struct {
+	Name   string `json:"name"`
+	Rig    string `json:"rig"`
+	State  string `json:"state"`
+	Branch string `json:"branch,omitempty"`
+	Issue  string `json:"issue,omitempty"`
+}
+
+// PolecatsResponse is the response for /api/v1/polecats.
+type PolecatsResponse struct {
+	Polecats []PolecatInfo            `json:"polecats"`
+	ByRig    map[string][]PolecatInfo `json:"by_rig"`
+	Total    int                      `json:"total"`
+}
+
+// MergeQueueItem is a pending merge request in a rig's refinery queue.
+type MergeQueueItem struct {
+	Rig          string `json:"rig"`
+	Position     int    `json:"position"`
+	ID           string `json:"id"`
+	Branch       string `json:"branch"`
+	Worker       string `json:"worker,omitempty"`
+	IssueID      string `json:"issue_id,omitempty"`
+	TargetBranch string `json:"target_branch,omitempty"`
+	Age          string `json:"age,omitempty"`
+}
+
+// MergeQueueResponse is the response for /api/v1/merge-queue.
+type MergeQueueResponse struct {
+	Items []MergeQueueItem            `json:"items"`
+	ByRig map[string][]MergeQueueItem `json:"by_rig"`
+	Total int                         `json:"total"`
+}
+
+// beadsStores caches the in-process beads stores the v1 API reads through,
+// keyed by beads directory. Stores stay open for the life of the dashboard,
+// as the daemon's do. A directory whose store fails to open is not cached:
+// callers fall back to the bd subprocess and the next request retries.
+type beadsStores struct {
+	mu    sync.Mutex
+	byDir map[string]beadsdk.Storage
+}
+
+// get returns the store for beadsDir, opening it on first use. Returns nil
+// if the store cannot be opened.
+func (s *beadsStores) get(beadsDir string) beadsdk.Storage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if store, ok := s.byDir[beadsDir]; ok {
+		return store
+	}
+	store, err := beadsdk.OpenFromConfig(context.Background(), beadsDir)
+	if err != nil {
+		log.Printf("warning: api v1: beads store %s unavailable: %v", beadsDir, err)
+		return nil
+	}
+	if s.byDir == nil {
+		s.byDir = make(map[string]beadsdk.Storage)
+	}
+	s.byDir[beadsDir] = store
+	return store
+}
+
+// townRoot returns the town containing the dashboard's working directory, or
+// "" when the dashboard is not running inside a town.
+func (h *APIHandler) townRoot() string {
+	townRoot, err := workspace.Find(h.workDir)
+	if err != nil {
+		return ""
+	}
+	return townRoot
+}
+
+// requireTownRoot is townRoot for endpoints that cannot answer without a
+// town. It writes a 503 and returns "" when there is none.
+func (h *APIHandler) requireTownRoot(w http.ResponseWriter) string {
+	townRoot := h.townRoot()
+	if townRoot == "" {
+		h.sendError(w, "Dashboard is not running inside a Gas Town workspace", http.StatusServiceUnavailable)
+	}
+	return townRoot
+}
+
+// beadsForDir returns a beads client for beadsDir, backed by its cached
+// in-process store when one can be opened.
+func (h *APIHandler) beadsForDir(beadsDir string) *beads.Beads {
+	return beads.NewWithBeadsDirAndStore(filepath.Dir(beadsDir), beadsDir, h.stores.get(beadsDir))
+}
+
+// beadsForID returns the beads client for the database an issue ID routes to.
+func (h *APIHandler) beadsForID(townRoot, id string) *beads.Beads {
+	return h.beadsForDir(beads.ResolveBeadsDirForID(beads.ResolveBeadsDir(townRoot), id))
+}
+
+// mailbox returns the town-level mailbox for address.
+func (h *APIHandler) mailbox(townRoot, address string) *mail.Mailbox {
+	beadsDir := beads.ResolveBeadsDir(townRoot)
+	return mail.NewMailboxWithBeadsDirAndStore(address, townRoot, beadsDir, h.stores.get(beadsDir))
+}
+
+// beadsSource is one database the v1 API aggregates over: the town, named
+// "town", or a rig, named for the rig.
+type beadsSource struct {
+	name     string
+	beadsDir string
+}
+
+// beadsSources returns the town database followed by each rig's, in rig
+// order. Rigs without a beads database are skipped.
+func beadsSources(townRoot string, rigs []*rig.Rig) []beadsSource {
+	sources := []beadsSource{{name: "town", beadsDir: beads.ResolveBeadsDir(townRoot)}}
+	for _, r := range rigs {
+		if beadsDir := doltserver.FindRigBeadsDir(townRoot, r.Name); beadsDir != "" {
+			sources = append(sources, beadsSource{name: r.Name, beadsDir: beadsDir})
+		}
+	}
+	return sources
+}
+
+// discoverRigs returns the town's rigs sorted by name.
+func discoverRigs(townRoot string) []*rig.Rig {
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil {
+		return nil
+	}
+	rigs, err := rig.NewManager(townRoot, rigsConfig, git.NewGit(townRoot)).DiscoverRigs()
+	if err != nil {
+		log.Printf("warning: api v1: discovering rigs: %v", err)
+		return nil
+	}
+	sort.Slice(rigs, func(i, j int) bool { return rigs[i].Name < rigs[j].Name })
+	return rigs
+}
+
+// listCrew returns the crew workers of each rig, in rig order.
+func listCrew(rigs []*rig.Rig) []*crew.CrewWorker {
+	var workers []*crew.CrewWorker
+	for _, r := range rigs {
+		rigWorkers, err := crew.NewManager(r, git.NewGit(r.Path)).List()
+		if err != nil {
+			log.Printf("warning: api v1: crew list %s: %v", r.Name, err)
+			continue
+		}
+		workers = append(workers, rigWorkers...)
+	}
+	return workers
+}
+
+// listPolecats returns the polecats of each rig, in rig order.
+func listPolecats(rigs []*rig.Rig) []*polecat.Polecat {
+	t := tmux.NewTmux()
+	var polecats []*polecat.Polecat
+	for _, r := range rigs {
+		rigPolecats, err := polecat.NewManager(r, git.NewGit(r.Path), t).List()
+		if err != nil {
+			log.Printf("warning: api v1: polecat list %s: %v", r.Name, err)
+			continue
+		}
+		polecats = append(polecats, rigPolecats...)
+	}
+	return polecats
+}
+
+// hookedIssues returns the issues on an agent's hook across the town and its
+// rigs, keyed by assignee.
+func (h *APIHandler) hookedIssues(townRoot string, rigs []*rig.Rig) map[string]*beads.Issue {
+	hooked := make(map[string]*beads.Issue)
+	for _, src := range beadsSources(townRoot, rigs) {
+		issues, err := h.beadsForDir(src.beadsDir).List(beads.ListOptions{Status: beads.StatusHooked, Priority: -1})
+		if err != nil {
+			log.Printf("warning: api v1: hooked issues %s: %v", src.name, err)
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Assignee != "" {
+				hooked[issue.Assignee] = issue
+			}
+		}
+	}
+	return hooked
+}
+
+// handlePolecats returns the polecats of every rig.
+func (h *APIHandler) handlePolecats(w http.ResponseWriter, _ *http.Request) {
+	resp := PolecatsResponse{
+		Polecats: make([]PolecatInfo, 0),
+		ByRig:    make(map[string][]PolecatInfo),
+	}
+
+	if townRoot := h.townRoot(); townRoot != "" {
+		for _, p := range listPolecats(discoverRigs(townRoot)) {
+			info := PolecatInfo{
+				Name:   p.Name,
+				Rig:    p.Rig,
+				State:  string(p.State),
+				Branch: p.Branch,
+				Issue:  p.Issue,
+			}
+			resp.Polecats = append(resp.Polecats, info)
+			resp.ByRig[p.Rig] = append(resp.ByRig[p.Rig], info)
+		}
+	}
+	resp.Total = len(resp.Polecats)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleMergeQueue returns the refinery queue of every rig, or of ?rig=.
+func (h *APIHandler) handleMergeQueue(w http.ResponseWriter, r *http.Request) {
+	rigName := r.URL.Query().Get("rig")
+	if rigName != "" && !isValidRigName(rigName) {
+		h.sendError(w, "Invalid rig name", http.StatusBadRequest)
+		return
+	}
+
+	resp := MergeQueueResponse{
+		Items: make([]MergeQueueItem, 0),
+		ByRig: make(map[string][]MergeQueueItem),
+	}
+
+	if townRoot := h.townRoot(); townRoot != "" {
+		for _, rg := range discoverRigs(townRoot) {
+			if rigName != "" && rg.Name != rigName {
+				continue
+			}
+			queue, err := refinery.NewManager(rg).Queue()
+			if err != nil {
+				log.Printf("warning: api v1: merge queue %s: %v", rg.Name, err)
+				continue
+			}
+			for _, q := range queue {
+				if q.MR == nil {
+					continue
+				}
+				item := MergeQueueItem{
+					Rig:          rg.Name,
+					Position:     q.Position,
+					ID:           q.MR.ID,
+					Branch:       q.MR.Branch,
+					Worker:       q.MR.Worker,
+					IssueID:      q.MR.IssueID,
+					TargetBranch: q.MR.TargetBranch,
+					Age:          q.Age,
+				}
+				resp.Items = append(resp.Items, item)
+				resp.ByRig[rg.Name] = append(resp.ByRig[rg.Name], item)
+			}
+		}
+	}
+	resp.Total = len(resp.Items)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// toMailMessage converts a mail message to its API form.
+func toMailMessage(m *mail.Message) MailMessage {
+	msg := MailMessage{
+		ID:       m.ID,
+		From:     m.From,
+		To:       m.To,
+		Subject:  m.Subject,
+		Body:     m.Body,
+		Read:     m.Read,
+		Priority: string(m.Priority),
+		ThreadID: m.ThreadID,
+		ReplyTo:  m.ReplyTo,
+	}
+	if !m.Timestamp.IsZero() {
+		msg.Timestamp = m.Timestamp.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// toIssueShowResponse converts a beads issue to its API form. Parent links
+// are not dependencies for display, so they are left out of DependsOn and
+// Blocks.
+func toIssueShowResponse(issue *beads.Issue) IssueShowResponse {
+	resp := IssueShowResponse{
+		ID:          issue.ID,
+		Title:       issue.Title,
+		Type:        issue.Type,
+		Status:      issue.Status,
+		Priority:    fmt.Sprintf("P%d", issue.Priority),
+		Owner:       issue.CreatedBy,
+		Assignee:    issue.Assignee,
+		Description: issue.Description,
+		Created:     issue.CreatedAt,
+		Updated:     issue.UpdatedAt,
+		Labels:      issue.Labels,
+	}
+	for _, dep := range issue.Dependencies {
+		if dep.DependencyType != "parent-child" {
+			resp.DependsOn = append(resp.DependsOn, dep.ID)
+		}
+	}
+	if len(resp.DependsOn) == 0 {
+		resp.DependsOn = issue.DependsOn
+	}
+	for _, dep := range issue.Dependents {
+		if dep.DependencyType != "parent-child" {
+			resp.Blocks = append(resp.Blocks, dep.ID)
+		}
+	}
+	if len(resp.Blocks) == 0 {
+		resp.Blocks = issue.Blocks
+	}
+	return resp
+}
+
+// readyWork drops what gt ready hides from a source's ready list: wisps,
+// formula scaffolds, identity beads, and issues whose prefix routes to a
+// different source. Every remaining row must be usable by the dashboard's
+// Sling button, which resolves IDs through the same routes.
+func readyWork(townRoot string, src beadsSource, issues []*beads.Issue) []*beads.Issue {
+	formulas := formulaNames(src.beadsDir)
+	work := make([]*beads.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Ephemeral || isFormulaScaffold(issue.ID, formulas) || isIdentityBead(issue) {
+			continue
+		}
+		if !issueRoutesToSource(townRoot, src.name, issue.ID) {
+			continue
+		}
+		work = append(work, issue)
+	}
+	return work
+}
+
+// formulaNames returns the formulas defined in beadsDir/formulas.
+func formulaNames(beadsDir string) map[string]bool {
+	entries, err := os.ReadDir(filepath.Join(beadsDir, "formulas"))
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".formula.toml"); ok && !entry.IsDir() {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// isFormulaScaffold reports whether id is a formula scaffold: the formula
+// itself or one of its "<formula>.<step>" steps.
+func isFormulaScaffold(id string, formulas map[string]bool) bool {
+	if formulas[id] {
+		return true
+	}
+	prefix, _, ok := strings.Cut(id, ".")
+	return ok && formulas[prefix]
+}
+
+// isIdentityBead reports whether issue tracks an agent, role, or rig rather
+// than actionable work.
+func isIdentityBead(issue *beads.Issue) bool {
+	if beads.IsAgentBead(issue) {
+		return true
+	}
+	for _, label := range issue.Labels {
+		switch label {
+		case "gt:agent", "gt:role", "gt:rig":
+			return true
+		}
+	}
+	return strings.HasSuffix(issue.ID, "-role") || strings.Contains(issue.ID, "-rig-")
+}
+
+// issueRoutesToSource reports whether issueID's prefix routes to source.
+func issueRoutesToSource(townRoot, source, issueID string) bool {
+	prefix := beads.ExtractPrefix(issueID)
+	if prefix == "" {
+		return false
+	}
+	routePath := beads.GetRigPathForPrefix(townRoot, prefix)
+	if routePath == "" {
+		return false
+	}
+	if source == "town" {
+		return routePath == townRoot
+	}
+	return beads.GetRigNameForPrefix(townRoot, prefix) == source
+}
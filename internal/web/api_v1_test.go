@@ -0,0 +1,197 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestAPIHandlerV1_Routing(t *testing.T) {
+	h := newFastAPIHandler(t)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/nope", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/mail/send", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/v1/ready", http.StatusMethodNotAllowed},
+		// Legacy text-scraping endpoints answer 410 with their replacement.
+		{http.MethodGet, "/api/mail/inbox", http.StatusGone},
+		{http.MethodGet, "/api/issues/show?id=gt-abc", http.StatusGone},
+		{http.MethodGet, "/api/crew", http.StatusGone},
+		{http.MethodGet, "/api/formula/schema?name=shiny", http.StatusGone},
+		// Mail and issue endpoints need a town to read from.
+		{http.MethodGet, "/api/v1/mail/inbox", http.StatusServiceUnavailable},
+		{http.MethodGet, "/api/v1/issues/gt-abc", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Dashboard-Token", "test-token")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_LegacyRouteNamesReplacement(t *testing.T) {
+	h := newFastAPIHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/issues/close", bytes.NewBufferString(`{"id":"gt-abc"}`))
+	req.Header.Set("X-Dashboard-Token", "test-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusGone {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusGone)
+	}
+	var resp CommandResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.Contains(resp.Error, "/api/v1/issues/{id}/close") {
+		t.Errorf("error = %q, want it to name the /api/v1 replacement", resp.Error)
+	}
+}
+
+func TestAPIHandlerV1_Validation(t *testing.T) {
+	h := newFastAPIHandler(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"malformed external id", http.MethodGet, "/api/v1/issues/external:gt-abc", ""},
+		{"invalid message id", http.MethodGet, "/api/v1/mail/messages/bad%20id", ""},
+		{"invalid inbox address", http.MethodGet, "/api/v1/mail/inbox?address=-rf", ""},
+		{"invalid rig", http.MethodGet, "/api/v1/merge-queue?rig=bad-rig", ""},
+		{"update bad status", http.MethodPost, "/api/v1/issues/gt-abc/update", `{"status":"closed"}`},
+		{"update no fields", http.MethodPost, "/api/v1/issues/gt-abc/update", `{}`},
+		{"update bad assignee", http.MethodPost, "/api/v1/issues/gt-abc/update", `{"assignee":"a b"}`},
+		{"send missing subject", http.MethodPost, "/api/v1/mail/send", `{"to":"mayor/"}`},
+		{"send bad reply-to", http.MethodPost, "/api/v1/mail/send", `{"to":"mayor/","subject":"hi","reply_to":"a b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("X-Dashboard-Token", "test-token")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s %s status = %d, want %d: %s", tt.method, tt.path, w.Code, http.StatusBadRequest, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIHandlerV1_AggregatesEmptyOutsideTown(t *testing.T) {
+	h := newFastAPIHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/polecats", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var polecats PolecatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&polecats); err != nil {
+		t.Fatalf("decode polecats: %v", err)
+	}
+	if polecats.Polecats == nil || polecats.ByRig == nil {
+		t.Errorf("polecats response not initialized: %+v", polecats)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/merge-queue", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var queue MergeQueueResponse
+	if err := json.NewDecoder(w.Body).Decode(&queue); err != nil {
+		t.Fatalf("decode merge queue: %v", err)
+	}
+	if queue.Items == nil || queue.ByRig == nil {
+		t.Errorf("merge queue response not initialized: %+v", queue)
+	}
+}
+
+func TestToIssueShowResponse(t *testing.T) {
+	issue := &beads.Issue{
+		ID:        "gt-abc",
+		Title:     "Fix it",
+		Status:    "open",
+		Priority:  0,
+		Type:      "bug",
+		CreatedBy: "mayor",
+		Assignee:  "gastown/crew/max",
+		Dependencies: []beads.IssueDep{
+			{ID: "gt-epic", DependencyType: "parent-child"},
+			{ID: "gt-dep", DependencyType: "blocks"},
+		},
+		Dependents: []beads.IssueDep{
+			{ID: "gt-child", DependencyType: "parent-child"},
+			{ID: "gt-next", DependencyType: "blocks"},
+		},
+	}
+
+	resp := toIssueShowResponse(issue)
+	if resp.Priority != "P0" {
+		t.Errorf("Priority = %q, want P0", resp.Priority)
+	}
+	if resp.Owner != "mayor" || resp.Assignee != "gastown/crew/max" {
+		t.Errorf("Owner/Assignee = %q/%q", resp.Owner, resp.Assignee)
+	}
+	if len(resp.DependsOn) != 1 || resp.DependsOn[0] != "gt-dep" {
+		t.Errorf("DependsOn = %v, want [gt-dep]", resp.DependsOn)
+	}
+	if len(resp.Blocks) != 1 || resp.Blocks[0] != "gt-next" {
+		t.Errorf("Blocks = %v, want [gt-next]", resp.Blocks)
+	}
+}
+
+func TestReadyWork(t *testing.T) {
+	townRoot := t.TempDir()
+	townBeads := filepath.Join(townRoot, ".beads")
+	if err := os.MkdirAll(filepath.Join(townBeads, "formulas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	routes := `{"prefix":"hq-","path":"."}` + "\n" + `{"prefix":"gt-","path":"gastown/mayor/rig"}` + "\n"
+	if err := os.WriteFile(filepath.Join(townBeads, "routes.jsonl"), []byte(routes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(townBeads, "formulas", "hq-release.formula.toml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := []*beads.Issue{
+		{ID: "hq-work"},
+		{ID: "hq-release"},
+		{ID: "hq-release.build"},
+		{ID: "hq-wisp", Ephemeral: true},
+		{ID: "hq-agent", Labels: []string{"gt:agent"}},
+		{ID: "hq-crew-role"},
+		{ID: "gt-elsewhere"},
+	}
+
+	work := readyWork(townRoot, beadsSource{name: "town", beadsDir: townBeads}, issues)
+	if len(work) != 1 || work[0].ID != "hq-work" {
+		var ids []string
+		for _, w := range work {
+			ids = append(ids, w.ID)
+		}
+		t.Errorf("town ready work = %v, want [hq-work]", ids)
+	}
+
+	work = readyWork(townRoot, beadsSource{name: "gastown", beadsDir: t.TempDir()}, issues)
+	if len(work) != 1 || work[0].ID != "gt-elsewhere" {
+		t.Errorf("gastown ready work = %d issues, want [gt-elsewhere]", len(work))
+	}
+}
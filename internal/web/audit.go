@@ -0,0 +1,106 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// AuditEntry records one mutating dashboard API call.
+type AuditEntry struct {
+	Time       time.Time         `json:"time"`
+	Principal  string            `json:"principal,omitempty"` // empty when authentication failed
+	Role       string            `json:"role,omitempty"`
+	AuthMethod string            `json:"auth_method,omitempty"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Status     int               `json:"status"`
+	Remote     string            `json:"remote"`
+	Detail     map[string]string `json:"detail,omitempty"`
+}
+
+// AuditSink receives audit entries. It is called synchronously after the
+// handler returns, so implementations should be quick.
+type AuditSink func(AuditEntry)
+
+// EventsAuditSink writes audit entries to the town events log, where
+// `gt audit` picks them up. Actors are recorded as "dashboard/<principal>".
+func EventsAuditSink(e AuditEntry) {
+	actor := "dashboard/" + e.Principal
+	if e.Principal == "" {
+		actor = "dashboard/unauthenticated"
+	}
+	payload := map[string]interface{}{
+		"method": e.Method,
+		"path":   e.Path,
+		"status": e.Status,
+		"remote": e.Remote,
+	}
+	if e.Role != "" {
+		payload["role"] = e.Role
+		payload["auth_method"] = e.AuthMethod
+	}
+	for k, v := range e.Detail {
+		payload[k] = v
+	}
+	_ = events.LogAudit(events.TypeDashboardAPI, actor, payload)
+}
+
+type auditKey struct{}
+
+// auditRecord collects handler-supplied detail for the request's audit entry.
+type auditRecord struct {
+	mu     sync.Mutex
+	detail map[string]string
+
+	// principal and sink let long-lived handlers emit entries of their own.
+	principal *Principal
+	sink      AuditSink
+}
+
+// noteAudit attaches a key/value to the current request's audit entry.
+// It is a no-op outside the auth middleware.
+func noteAudit(ctx context.Context, key, value string) {
+	rec, ok := ctx.Value(auditKey{}).(*auditRecord)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.detail == nil {
+		rec.detail = make(map[string]string)
+	}
+	rec.detail[key] = value
+}
+
+// auditNow emits an audit entry immediately. Long-lived read requests such as
+// terminal takeover use it, since only mutating calls are audited on return.
+func auditNow(r *http.Request, status int, detail map[string]string) {
+	rec, ok := r.Context().Value(auditKey{}).(*auditRecord)
+	if !ok || rec.sink == nil {
+		return
+	}
+	rec.sink(newAuditEntry(r, rec.principal, status, &auditRecord{detail: detail}))
+}
+
+func newAuditEntry(r *http.Request, p *Principal, status int, rec *auditRecord) AuditEntry {
+	e := AuditEntry{
+		Time:   time.Now().UTC(),
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Status: status,
+		Remote: r.RemoteAddr,
+	}
+	if p != nil {
+		e.Principal = p.Name
+		e.Role = p.Role.String()
+		e.AuthMethod = p.Method
+	}
+	rec.mu.Lock()
+	e.Detail = rec.detail
+	rec.mu.Unlock()
+	return e
+}
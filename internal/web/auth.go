@@ -0,0 +1,399 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Role is a dashboard permission level. Higher roles include lower ones.
+type Role int
+
+const (
+	// RoleViewer can read every page and run Safe commands.
+	RoleViewer Role = iota + 1
+	// RoleOperator can also create/close issues, send mail, and run action commands.
+	RoleOperator
+	// RoleAdmin can also add rigs and run Admin commands (agent lifecycle, topology).
+	RoleAdmin
+)
+
+// String returns the config spelling of the role.
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseRole parses a role name from town settings.
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return 0, fmt.Errorf("invalid role %q (want viewer, operator, or admin)", s)
+	}
+}
+
+// Authentication methods recorded on a Principal.
+const (
+	AuthMethodLocal    = "local" // auth disabled; every client is trusted
+	AuthMethodToken    = "token"
+	AuthMethodPassword = "password"
+	AuthMethodProxy    = "proxy"
+)
+
+// Principal is the authenticated caller of a dashboard request.
+type Principal struct {
+	Name   string
+	Role   Role
+	Method string
+}
+
+// localPrincipal is used when no authentication is configured.
+var localPrincipal = &Principal{Name: "local", Role: RoleAdmin, Method: AuthMethodLocal}
+
+type principalKey struct{}
+
+// principalFromContext returns the request's principal. Handlers mounted
+// without the auth middleware (tests, setup mode) act as the local admin.
+func principalFromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok && p != nil {
+		return p
+	}
+	return localPrincipal
+}
+
+// csrfExempt reports whether the request authenticated with a bearer token.
+// Browsers never attach bearer tokens on their own, so those requests can't be forged.
+func csrfExempt(r *http.Request) bool {
+	return principalFromContext(r.Context()).Method == AuthMethodToken
+}
+
+// requiredRole maps an endpoint to the minimum role allowed to call it.
+// /api/run is admitted at viewer level and checked per command by handleRun.
+func requiredRole(r *http.Request) Role {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleViewer
+	}
+	switch r.URL.Path {
+	case "/api/run":
+		return RoleViewer
+	case "/api/rig/add":
+		return RoleAdmin
+	default:
+		return RoleOperator
+	}
+}
+
+// commandRole is the minimum role allowed to run a whitelisted command.
+func commandRole(meta *CommandMeta) Role {
+	switch {
+	case meta.Admin:
+		return RoleAdmin
+	case meta.Safe:
+		return RoleViewer
+	default:
+		return RoleOperator
+	}
+}
+
+// TokenPrefix marks dashboard bearer tokens so they are recognizable in logs and secrets scanners.
+const TokenPrefix = "gtd_"
+
+// GenerateToken returns a new random bearer token.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	return TokenPrefix + hex.EncodeToString(b), nil
+}
+
+// HashToken returns the stored form of a bearer token. Tokens carry 256 bits
+// of entropy, so a fast hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// HashPassword returns the bcrypt hash stored for a local account.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+type tokenCred struct {
+	name string
+	role Role
+	hash []byte
+}
+
+type userCred struct {
+	role Role
+	hash []byte
+}
+
+// authenticator resolves request credentials against WebAuthConfig.
+type authenticator struct {
+	tokens []tokenCred
+	users  map[string]userCred
+
+	proxyHeader  string
+	proxySources []*net.IPNet
+	proxyRole    Role
+
+	// verified caches successful basic-auth checks so htmx polling doesn't
+	// pay a bcrypt comparison per request. Keyed by sha256(user, password).
+	mu       sync.Mutex
+	verified map[[32]byte]*Principal
+}
+
+// newAuthenticator builds an authenticator from config. It returns nil when
+// no authentication method is configured.
+func newAuthenticator(cfg *config.WebAuthConfig) (*authenticator, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	a := &authenticator{
+		users:    make(map[string]userCred),
+		verified: make(map[[32]byte]*Principal),
+	}
+	for _, t := range cfg.Tokens {
+		role, err := ParseRole(t.Role)
+		if err != nil {
+			return nil, fmt.Errorf("token %q: %w", t.Name, err)
+		}
+		hexSum, ok := strings.CutPrefix(t.Hash, "sha256:")
+		sum, decErr := hex.DecodeString(hexSum)
+		if !ok || decErr != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("token %q: hash must be sha256:<hex>", t.Name)
+		}
+		a.tokens = append(a.tokens, tokenCred{name: t.Name, role: role, hash: sum})
+	}
+	for _, u := range cfg.Users {
+		role, err := ParseRole(u.Role)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: %w", u.Name, err)
+		}
+		a.users[u.Name] = userCred{role: role, hash: []byte(u.PasswordHash)}
+	}
+	if p := cfg.TrustedProxy; p != nil && p.Header != "" {
+		a.proxyHeader = p.Header
+		a.proxyRole = RoleViewer
+		if p.DefaultRole != "" {
+			role, err := ParseRole(p.DefaultRole)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy default role: %w", err)
+			}
+			a.proxyRole = role
+		}
+		sources := p.Sources
+		if len(sources) == 0 {
+			sources = []string{"127.0.0.0/8", "::1/128"}
+		}
+		for _, s := range sources {
+			_, n, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy source %q: %w", s, err)
+			}
+			a.proxySources = append(a.proxySources, n)
+		}
+	}
+	return a, nil
+}
+
+// authenticate returns the request's principal, or nil if the request
+// carries no valid credentials. Explicit Authorization headers take
+// precedence over the proxy header and never fall through on failure.
+func (a *authenticator) authenticate(r *http.Request) *Principal {
+	if authz := r.Header.Get("Authorization"); authz != "" {
+		if token, ok := strings.CutPrefix(authz, "Bearer "); ok {
+			return a.checkToken(strings.TrimSpace(token))
+		}
+		if user, pass, ok := r.BasicAuth(); ok {
+			return a.checkPassword(user, pass)
+		}
+		return nil
+	}
+	if a.proxyHeader != "" && a.fromTrustedProxy(r) {
+		if name := strings.TrimSpace(r.Header.Get(a.proxyHeader)); name != "" {
+			role := a.proxyRole
+			if u, ok := a.users[name]; ok {
+				role = u.role
+			}
+			return &Principal{Name: name, Role: role, Method: AuthMethodProxy}
+		}
+	}
+	return nil
+}
+
+func (a *authenticator) checkToken(token string) *Principal {
+	sum := sha256.Sum256([]byte(token))
+	var match *Principal
+	// Compare against every token so timing doesn't reveal which one matched.
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(sum[:], t.hash) == 1 {
+			match = &Principal{Name: t.name, Role: t.role, Method: AuthMethodToken}
+		}
+	}
+	return match
+}
+
+func (a *authenticator) checkPassword(user, pass string) *Principal {
+	u, ok := a.users[user]
+	if !ok || len(u.hash) == 0 {
+		return nil
+	}
+	key := sha256.Sum256([]byte(user + "\x00" + pass))
+	a.mu.Lock()
+	p, hit := a.verified[key]
+	a.mu.Unlock()
+	if hit {
+		return p
+	}
+	if bcrypt.CompareHashAndPassword(u.hash, []byte(pass)) != nil {
+		return nil
+	}
+	p = &Principal{Name: user, Role: u.role, Method: AuthMethodPassword}
+	a.mu.Lock()
+	a.verified[key] = p
+	a.mu.Unlock()
+	return p
+}
+
+func (a *authenticator) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range a.proxySources {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware authenticates every dashboard request, enforces endpoint
+// roles, and audits mutating calls.
+type authMiddleware struct {
+	next  http.Handler
+	auth  *authenticator // nil when auth is disabled
+	audit AuditSink
+}
+
+func (m *authMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := localPrincipal
+	if m.auth != nil {
+		p = m.auth.authenticate(r)
+	}
+
+	mutating := isMutating(r.Method)
+	rec := &auditRecord{principal: p, sink: m.audit}
+	ctx := context.WithValue(r.Context(), principalKey{}, p)
+	ctx = context.WithValue(ctx, auditKey{}, rec)
+	r = r.WithContext(ctx)
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	switch {
+	case p == nil:
+		if len(m.auth.users) > 0 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Gas Town dashboard", charset="UTF-8"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="Gas Town dashboard"`)
+		}
+		sw.status = http.StatusUnauthorized
+		writeAuthError(w, r, "Authentication required", http.StatusUnauthorized)
+	case p.Role < requiredRole(r):
+		sw.status = http.StatusForbidden
+		writeAuthError(w, r, fmt.Sprintf("Forbidden: requires %s role", requiredRole(r)), http.StatusForbidden)
+	case mutating:
+		m.next.ServeHTTP(sw, r)
+	default:
+		// Read-only requests are not audited; pass the raw writer so SSE
+		// flushing and connection upgrades keep working.
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	if mutating && m.audit != nil {
+		m.audit(newAuditEntry(r, p, sw.status, rec))
+	}
+}
+
+// writeAuthError answers API paths in the JSON error shape and pages in plain text.
+func writeAuthError(w http.ResponseWriter, r *http.Request, msg string, status int) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(CommandResponse{Success: false, Error: msg})
+		return
+	}
+	http.Error(w, msg, status)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WithAuth wraps a dashboard handler with authentication, role checks, and
+// auditing. A nil or empty cfg disables authentication; mutating calls are
+// still audited under the "local" principal.
+func WithAuth(next http.Handler, cfg *config.WebAuthConfig, audit AuditSink) (http.Handler, error) {
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard auth: %w", err)
+	}
+	return &authMiddleware{next: next, auth: auth, audit: audit}, nil
+}
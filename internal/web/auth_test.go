@@ -0,0 +1,242 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// newAuthTestHandler wraps a fast API handler with auth configured for
+// one token per role, a basic-auth user, and a trusted proxy header.
+func newAuthTestHandler(t *testing.T) (http.Handler, map[string]string, *[]AuditEntry) {
+	t.Helper()
+	tokens := map[string]string{}
+	cfg := &config.WebAuthConfig{
+		TrustedProxy: &config.WebAuthProxyConfig{Header: "X-Forwarded-User"},
+	}
+	for _, role := range []string{"viewer", "operator", "admin"} {
+		tok, err := GenerateToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[role] = tok
+		cfg.Tokens = append(cfg.Tokens, config.WebAuthToken{Name: role + "-bot", Role: role, Hash: HashToken(tok)})
+	}
+	hash, err := HashPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Users = []config.WebAuthUser{
+		{Name: "alice", Role: "operator", PasswordHash: hash},
+		{Name: "carol", Role: "admin"},
+	}
+
+	var audit []AuditEntry
+	h, err := WithAuth(newFastAPIHandler(t), cfg, func(e AuditEntry) { audit = append(audit, e) })
+	if err != nil {
+		t.Fatalf("WithAuth: %v", err)
+	}
+	return h, tokens, &audit
+}
+
+func TestAuth_RequiresCredentials(t *testing.T) {
+	h, tokens, _ := newAuthTestHandler(t)
+
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"none", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"bad token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer gtd_nope") }, http.StatusUnauthorized},
+		{"bad password", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }, http.StatusUnauthorized},
+		{"proxy-only user has no password", func(r *http.Request) { r.SetBasicAuth("carol", "") }, http.StatusUnauthorized},
+		{"untrusted proxy source", func(r *http.Request) {
+			r.RemoteAddr = "203.0.113.7:5555"
+			r.Header.Set("X-Forwarded-User", "carol")
+		}, http.StatusUnauthorized},
+		{"token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+tokens["viewer"]) }, http.StatusOK},
+		{"password", func(r *http.Request) { r.SetBasicAuth("alice", "hunter2") }, http.StatusOK},
+		{"trusted proxy", func(r *http.Request) {
+			r.RemoteAddr = "127.0.0.1:5555"
+			r.Header.Set("X-Forwarded-User", "someone")
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/commands", nil)
+			tt.setup(req)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestAuth_EndpointRoles(t *testing.T) {
+	h, tokens, _ := newAuthTestHandler(t)
+
+	tests := []struct {
+		role string
+		path string
+		body string
+		want int
+	}{
+		// Viewers can't reach mutating endpoints at all.
+		{"viewer", "/api/v1/issues/gt-abc/close", `{}`, http.StatusForbidden},
+		{"viewer", "/api/v1/mail/send", `{}`, http.StatusForbidden},
+		// Operators pass the role check and reach handler validation.
+		{"operator", "/api/v1/mail/send", `{}`, http.StatusBadRequest},
+		{"operator", "/api/rig/add", `{"name":"x"}`, http.StatusForbidden},
+		{"admin", "/api/rig/add", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tokens[tt.role])
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuth_CommandRoles(t *testing.T) {
+	h, tokens, _ := newAuthTestHandler(t)
+
+	tests := []struct {
+		role    string
+		command string
+		want    int
+	}{
+		{"viewer", "status", http.StatusOK},
+		{"viewer", "convoy refresh hq-cv-1", http.StatusForbidden},
+		{"operator", "convoy refresh hq-cv-1", http.StatusOK},
+		{"operator", "rig boot gastown", http.StatusForbidden},
+		{"admin", "rig boot gastown", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.command, func(t *testing.T) {
+			body, _ := json.Marshal(CommandRequest{Command: tt.command, Confirmed: true})
+			req := httptest.NewRequest(http.MethodPost, "/api/run", bytes.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+tokens[tt.role])
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuth_CommandListFilteredByRole(t *testing.T) {
+	h, tokens, _ := newAuthTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/commands", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens["viewer"])
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var resp CommandListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Commands) == 0 {
+		t.Fatal("viewer sees no commands")
+	}
+	for _, c := range resp.Commands {
+		if !c.Safe || c.Role != "viewer" {
+			t.Errorf("viewer sees %q (role %s)", c.Name, c.Role)
+		}
+	}
+}
+
+func TestAuth_CSRFStillAppliesToBrowserCredentials(t *testing.T) {
+	h, _, _ := newAuthTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", bytes.NewBufferString(`{}`))
+	req.SetBasicAuth("alice", "hunter2")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "dashboard token") {
+		t.Errorf("basic-auth POST without CSRF token: status = %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuth_AuditsMutatingCalls(t *testing.T) {
+	h, tokens, audit := newAuthTestHandler(t)
+
+	get := httptest.NewRequest(http.MethodGet, "/api/commands", nil)
+	get.Header.Set("Authorization", "Bearer "+tokens["viewer"])
+	h.ServeHTTP(httptest.NewRecorder(), get)
+
+	body, _ := json.Marshal(CommandRequest{Command: "rig boot gastown", Confirmed: true})
+	post := httptest.NewRequest(http.MethodPost, "/api/run", bytes.NewReader(body))
+	post.Header.Set("Authorization", "Bearer "+tokens["operator"])
+	h.ServeHTTP(httptest.NewRecorder(), post)
+
+	anon := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", nil)
+	h.ServeHTTP(httptest.NewRecorder(), anon)
+
+	if len(*audit) != 2 {
+		t.Fatalf("audit entries = %d, want 2 (GETs are not audited): %+v", len(*audit), *audit)
+	}
+	e := (*audit)[0]
+	if e.Principal != "operator-bot" || e.Role != "operator" || e.AuthMethod != AuthMethodToken {
+		t.Errorf("principal = %q/%q/%q", e.Principal, e.Role, e.AuthMethod)
+	}
+	if e.Status != http.StatusForbidden || e.Path != "/api/run" || e.Detail["command"] != "rig boot gastown" {
+		t.Errorf("entry = %+v", e)
+	}
+	if e := (*audit)[1]; e.Principal != "" || e.Status != http.StatusUnauthorized {
+		t.Errorf("unauthenticated entry = %+v", e)
+	}
+}
+
+func TestAuth_DisabledActsAsLocalAdmin(t *testing.T) {
+	var audit []AuditEntry
+	h, err := WithAuth(newFastAPIHandler(t), nil, func(e AuditEntry) { audit = append(audit, e) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/rig/add", bytes.NewBufferString(`{}`))
+	req.Header.Set("X-Dashboard-Token", "test-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 from handler validation", w.Code)
+	}
+	if len(audit) != 1 || audit[0].Principal != "local" || audit[0].Role != "admin" {
+		t.Errorf("audit = %+v", audit)
+	}
+}
+
+func TestNewAuthenticator_RejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.WebAuthConfig
+	}{
+		{"bad role", &config.WebAuthConfig{Users: []config.WebAuthUser{{Name: "a", Role: "root"}}}},
+		{"bad hash", &config.WebAuthConfig{Tokens: []config.WebAuthToken{{Name: "t", Role: "viewer", Hash: "md5:abc"}}}},
+		{"bad cidr", &config.WebAuthConfig{TrustedProxy: &config.WebAuthProxyConfig{Header: "X-User", Sources: []string{"nope"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newAuthenticator(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -12,6 +12,8 @@ type CommandMeta struct {
 	Safe bool
 	// Confirm commands require user confirmation before execution
 	Confirm bool
+	// Admin commands change agent lifecycle or town topology and need the admin role
+	Admin bool
 	// Desc is a short description shown in the command palette
 	Desc string
 	// Category groups commands in the palette UI
@@ -70,19 +72,19 @@ var AllowedCommands = map[string]CommandMeta{
 	"convoy add":     {Confirm: true, Desc: "Add issue to convoy", Category: "Convoys", Args: "<convoy-id> <issue>", ArgType: "convoys"},
 
 	// Rig actions
-	"rig add":   {Confirm: true, Desc: "Add rig", Category: "Rigs", Args: "<rig-name> <git-url>"},
-	"rig boot":  {Confirm: true, Desc: "Boot rig", Category: "Rigs", Args: "<rig-name>", ArgType: "rigs"},
-	"rig start": {Confirm: true, Desc: "Start rig", Category: "Rigs", Args: "<rig-name>", ArgType: "rigs"},
+	"rig add":   {Confirm: true, Admin: true, Desc: "Add rig", Category: "Rigs", Args: "<rig-name> <git-url>"},
+	"rig boot":  {Confirm: true, Admin: true, Desc: "Boot rig", Category: "Rigs", Args: "<rig-name>", ArgType: "rigs"},
+	"rig start": {Confirm: true, Admin: true, Desc: "Start rig", Category: "Rigs", Args: "<rig-name>", ArgType: "rigs"},
 
 	// Agent lifecycle (careful)
-	"witness start":  {Confirm: true, Desc: "Start witness", Category: "Agents", Args: "<rig-name>", ArgType: "rigs"},
-	"refinery start": {Confirm: true, Desc: "Start refinery", Category: "Agents", Args: "<rig-name>", ArgType: "rigs"},
-	"mayor attach":   {Confirm: true, Desc: "Attach mayor", Category: "Agents"},
-	"deacon start":   {Confirm: true, Desc: "Start deacon", Category: "Agents"},
+	"witness start":  {Confirm: true, Admin: true, Desc: "Start witness", Category: "Agents", Args: "<rig-name>", ArgType: "rigs"},
+	"refinery start": {Confirm: true, Admin: true, Desc: "Start refinery", Category: "Agents", Args: "<rig-name>", ArgType: "rigs"},
+	"mayor attach":   {Confirm: true, Admin: true, Desc: "Attach mayor", Category: "Agents"},
+	"deacon start":   {Confirm: true, Admin: true, Desc: "Start deacon", Category: "Agents"},
 
 	// Polecat actions
-	"polecat add":    {Confirm: true, Desc: "Add polecat", Category: "Polecats", Args: "<rig> <name>", ArgType: "rigs"},
-	"polecat remove": {Confirm: true, Desc: "Remove polecat", Category: "Polecats", Args: "<rig>/<name>", ArgType: "polecats"},
+	"polecat add":    {Confirm: true, Admin: true, Desc: "Add polecat", Category: "Polecats", Args: "<rig> <name>", ArgType: "rigs"},
+	"polecat remove": {Confirm: true, Admin: true, Desc: "Remove polecat", Category: "Polecats", Args: "<rig>/<name>", ArgType: "polecats"},
 
 	// Work assignment
 	"sling":       {Confirm: true, Desc: "Assign work to agent", Category: "Work", Args: "<bead> <rig>", ArgType: "hooks"},
@@ -196,6 +198,7 @@ func GetCommandList() []CommandInfo {
 			Category: meta.Category,
 			Safe:     meta.Safe,
 			Confirm:  meta.Confirm,
+			Role:     commandRole(&meta).String(),
 			Args:     meta.Args,
 			ArgType:  meta.ArgType,
 		})
@@ -210,6 +213,7 @@ type CommandInfo struct {
 	Category string `json:"category"`
 	Safe     bool   `json:"safe"`
 	Confirm  bool   `json:"confirm"`
+	Role     string `json:"role"`
 	Args     string `json:"args,omitempty"`
 	ArgType  string `json:"argType,omitempty"`
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -182,8 +183,18 @@ type LiveConvoyFetcher struct {
 	// Circuit breaker for FetchConvoys — prevents process storms when
 	// bd list by convoy label fails persistently (e.g., schema mismatch).
 	convoyBreaker fetchCircuitBreaker
+
+	// Forecast history is town-wide and costly to load (event log scan plus
+	// an MR query per rig), so it is cached across refreshes.
+	forecastMu       sync.Mutex
+	forecastHistory  *convoyops.ForecastHistory
+	forecastCapacity int
+	forecastLoadedAt time.Time
 }
 
+// forecastHistoryTTL is how long cached forecast history is reused.
+const forecastHistoryTTL = 2 * time.Minute
+
 // NewLiveConvoyFetcher creates a fetcher for the current workspace.
 // Loads timeout and threshold config from TownSettings; falls back to defaults if missing.
 func NewLiveConvoyFetcher() (*LiveConvoyFetcher, error) {
@@ -258,6 +269,7 @@ func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 	}
 
 	// Build convoy rows with activity data
+	now := time.Now()
 	rows := make([]ConvoyRow, 0, len(convoys))
 	for _, c := range convoys {
 		if c.IssueType != "convoy" && !webConvoyHasLabel(c.Labels, "gt:convoy") {
@@ -348,6 +360,10 @@ func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 		// Calculate work status based on progress and activity
 		row.WorkStatus = calculateWorkStatus(row.Completed, row.Total, row.LastActivity.ColorClass)
 
+		if row.Completed < row.Total {
+			f.applyForecast(&row, tracked, now)
+		}
+
 		// Get tracked issues for expandable view
 		row.TrackedIssues = make([]TrackedIssue, len(tracked))
 		for i, t := range tracked {
@@ -366,6 +382,37 @@ func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 	return rows, nil
 }
 
+// applyForecast fills in a convoy row's expected completion, critical path,
+// and top blockers.
+func (f *LiveConvoyFetcher) applyForecast(row *ConvoyRow, tracked []trackedIssueInfo, now time.Time) {
+	history, capacity := f.loadForecastHistory(now)
+	issues := make([]convoyops.ForecastIssue, 0, len(tracked))
+	for _, t := range tracked {
+		issue := convoyops.ForecastIssue{ID: t.ID, Status: t.Status, BlockedBy: t.BlockedBy}
+		history.Annotate(&issue)
+		issues = append(issues, issue)
+	}
+	forecast := convoyops.ComputeForecast(issues, history, capacity, now)
+	row.ETA = convoyops.FormatForecastETA(forecast, now)
+	if len(forecast.CriticalPath) > 1 {
+		row.CriticalPath = strings.Join(forecast.CriticalPath, " → ")
+	}
+	row.Blockers = forecast.Blockers
+}
+
+// loadForecastHistory returns the town's forecast history and scheduler
+// capacity, reloading them when the cache has expired.
+func (f *LiveConvoyFetcher) loadForecastHistory(now time.Time) (*convoyops.ForecastHistory, int) {
+	f.forecastMu.Lock()
+	defer f.forecastMu.Unlock()
+	if f.forecastHistory == nil || now.Sub(f.forecastLoadedAt) > forecastHistoryTTL {
+		f.forecastHistory = convoyops.LoadForecastHistory(f.townRoot, now)
+		f.forecastCapacity = convoyops.ForecastCapacity(f.townRoot)
+		f.forecastLoadedAt = now
+	}
+	return f.forecastHistory, f.forecastCapacity
+}
+
 func webConvoyHasLabel(labels []string, target string) bool {
 	for _, label := range labels {
 		if label == target {
@@ -383,6 +430,7 @@ type trackedIssueInfo struct {
 	Assignee     string
 	LastActivity time.Time
 	UpdatedAt    time.Time // Fallback for activity when no assignee
+	BlockedBy    []string  // Issues this one waits on (blocking dependencies)
 }
 
 // getTrackedIssues fetches tracked issues for a convoy.
@@ -425,6 +473,7 @@ func (f *LiveConvoyFetcher) getTrackedIssues(convoyID string) ([]trackedIssueInf
 			info.Status = d.Status
 			info.Assignee = d.Assignee
 			info.UpdatedAt = d.UpdatedAt
+			info.BlockedBy = d.BlockedBy
 		} else {
 			info.Title = "(external)"
 			info.Status = "unknown"
@@ -447,6 +496,7 @@ type issueDetail struct {
 	Status    string
 	Assignee  string
 	UpdatedAt time.Time
+	BlockedBy []string
 }
 
 // getIssueDetailsBatch fetches details for multiple issues.
@@ -470,6 +520,11 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) (map[string]
 		Status    string `json:"status"`
 		Assignee  string `json:"assignee"`
 		UpdatedAt string `json:"updated_at"`
+
+		Dependencies []struct {
+			ID             string `json:"id"`
+			DependencyType string `json:"dependency_type"`
+		} `json:"dependencies"`
 	}
 	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
 		return nil, fmt.Errorf("bd show returned invalid JSON (issue_count=%d): %w", len(issueIDs), err)
@@ -488,6 +543,11 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) (map[string]
 				detail.UpdatedAt = t
 			}
 		}
+		for _, dep := range issue.Dependencies {
+			if convoyops.IsBlockingDepType(dep.DependencyType) {
+				detail.BlockedBy = append(detail.BlockedBy, beads.ExtractIssueID(dep.ID))
+			}
+		}
 		result[issue.ID] = detail
 	}
 
@@ -455,8 +455,9 @@ func generateCSRFToken() string {
 }
 
 // NewDashboardMux creates an HTTP handler that serves both the dashboard and API.
-// webCfg may be nil, in which case defaults are used.
-func NewDashboardMux(fetcher ConvoyFetcher, webCfg *config.WebTimeoutsConfig) (http.Handler, error) {
+// webCfg may be nil, in which case defaults are used. authCfg may be nil to
+// disable authentication; mutating calls are audited to the events log either way.
+func NewDashboardMux(fetcher ConvoyFetcher, webCfg *config.WebTimeoutsConfig, authCfg *config.WebAuthConfig) (http.Handler, error) {
 	if webCfg == nil {
 		webCfg = config.DefaultWebTimeoutsConfig()
 	}
@@ -485,5 +486,5 @@ func NewDashboardMux(fetcher ConvoyFetcher, webCfg *config.WebTimeoutsConfig) (h
 	mux.Handle("/static/", http.StripPrefix("/static/", staticHandler))
 	mux.Handle("/", convoyHandler)
 
-	return mux, nil
+	return WithAuth(mux, authCfg, EventsAuditSink)
 }
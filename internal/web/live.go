@@ -0,0 +1,477 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// Live event kinds, sent as the SSE event name on /api/events.
+const (
+	LiveAgent  = "agent"  // agent state changed: sling, hook, done, spawn, session start/end...
+	LiveMerge  = "merge"  // merge queue activity: MR started, landed, failed
+	LiveMail   = "mail"   // mail was sent
+	LiveConvoy = "convoy" // a convoy's progress changed
+	LiveBead   = "bead"   // any other bead change
+	LiveResync = "resync" // the cursor can't be resumed; refetch everything
+)
+
+// LiveEvent is the JSON data of one typed event on the dashboard stream.
+type LiveEvent struct {
+	Kind      string                 `json:"kind"`
+	Type      string                 `json:"type"` // town event or bead event type
+	Time      time.Time              `json:"time"`
+	Actor     string                 `json:"actor,omitempty"`
+	Rig       string                 `json:"rig,omitempty"`
+	Subject   string                 `json:"subject,omitempty"` // bead, MR, or convoy the event is about
+	Summary   string                 `json:"summary,omitempty"`
+	Icon      string                 `json:"icon,omitempty"`
+	Category  string                 `json:"category,omitempty"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+	Completed int                    `json:"completed,omitempty"` // convoy progress
+	Total     int                    `json:"total,omitempty"`
+}
+
+// liveKind maps a town event type to the live event kind it is pushed as.
+// Types not listed (patrols, audit records...) are not pushed.
+func liveKind(eventType string) string {
+	switch eventType {
+	case "sling", "hook", "unhook", "handoff", "done", "spawn", "kill", "boot", "halt",
+		"session_start", "session_end", "session_death", "mass_death":
+		return LiveAgent
+	case "merge_started", "merged", "merge_failed", "merge_skipped":
+		return LiveMerge
+	case "mail":
+		return LiveMail
+	}
+	return ""
+}
+
+// liveCursor is a position in both live sources. It is the SSE event id, so
+// a reconnecting client resumes where it left off via Last-Event-ID.
+type liveCursor struct {
+	offset int64     // byte offset just past the last event log line delivered
+	line   uint32    // CRC of that line, to detect a rewritten (pruned) log
+	beads  time.Time // created_at of the newest bead change delivered
+}
+
+func (c liveCursor) String() string {
+	return fmt.Sprintf("%d-%08x-%d", c.offset, c.line, c.beads.UnixMilli())
+}
+
+func parseLiveCursor(s string) (liveCursor, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return liveCursor{}, false
+	}
+	offset, err1 := strconv.ParseInt(parts[0], 10, 64)
+	line, err2 := strconv.ParseUint(parts[1], 16, 32)
+	ms, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || offset < 0 {
+		return liveCursor{}, false
+	}
+	return liveCursor{offset: offset, line: uint32(line), beads: time.UnixMilli(ms)}, true
+}
+
+// errLogRewritten means the event log no longer holds the line the cursor
+// points after, usually because it was pruned.
+var errLogRewritten = errors.New("event log was rewritten")
+
+// maxLogLine bounds how far back lineBefore looks for the previous line.
+const maxLogLine = 64 * 1024
+
+// eventLogTail follows the town event log from a cursor.
+type eventLogTail struct {
+	path   string
+	offset int64
+	line   uint32
+}
+
+// tailFromEnd starts a tail at the current end of the log.
+func tailFromEnd(path string) *eventLogTail {
+	t := &eventLogTail{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return t // no log yet; follow it from the start once it appears
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		if line, err := lineBefore(f, info.Size()); err == nil {
+			t.offset, t.line = info.Size(), crc32.ChecksumIEEE(line)
+		}
+	}
+	return t
+}
+
+// tailFromCursor resumes a tail at c. It returns errLogRewritten if the log
+// no longer matches the cursor.
+func tailFromCursor(path string, c liveCursor) (*eventLogTail, error) {
+	t := &eventLogTail{path: path, offset: c.offset, line: c.line}
+	if err := t.verify(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *eventLogTail) verify() error {
+	if t.offset == 0 {
+		return nil
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		return errLogRewritten
+	}
+	defer f.Close()
+	return t.verifyFile(f)
+}
+
+func (t *eventLogTail) verifyFile(f *os.File) error {
+	line, err := lineBefore(f, t.offset)
+	if err != nil || crc32.ChecksumIEEE(line) != t.line {
+		return errLogRewritten
+	}
+	return nil
+}
+
+// next returns the events appended since the last call. Partial trailing
+// lines are left for the next call.
+func (t *eventLogTail) next() ([]LiveEvent, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) && t.offset == 0 {
+			return nil, nil
+		}
+		return nil, errLogRewritten
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+	if info.Size() < t.offset {
+		return nil, errLogRewritten
+	}
+	if t.offset > 0 {
+		if err := t.verifyFile(f); err != nil {
+			return nil, err
+		}
+	}
+
+	data := make([]byte, info.Size()-t.offset)
+	if _, err := f.ReadAt(data, t.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	var out []LiveEvent
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		data = data[i+1:]
+		t.offset += int64(i + 1)
+		t.line = crc32.ChecksumIEEE(line)
+		if ev, ok := parseTownEvent(line); ok {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+// lineBefore returns the newline-terminated line ending at offset, without
+// its newline.
+func lineBefore(f *os.File, offset int64) ([]byte, error) {
+	if offset == 0 {
+		return nil, nil
+	}
+	start := max(0, offset-maxLogLine)
+	buf := make([]byte, offset-start)
+	if _, err := f.ReadAt(buf, start); err != nil {
+		return nil, err
+	}
+	if buf[len(buf)-1] != '\n' {
+		return nil, errLogRewritten
+	}
+	buf = buf[:len(buf)-1]
+	i := bytes.LastIndexByte(buf, '\n')
+	if i < 0 && start > 0 {
+		return nil, errLogRewritten
+	}
+	return buf[i+1:], nil
+}
+
+// parseTownEvent converts an event log line to a live event. Audit-only
+// events and types the dashboard doesn't track are skipped.
+func parseTownEvent(line []byte) (LiveEvent, bool) {
+	var event struct {
+		Timestamp  string                 `json:"ts"`
+		Type       string                 `json:"type"`
+		Actor      string                 `json:"actor"`
+		Payload    map[string]interface{} `json:"payload"`
+		Visibility string                 `json:"visibility"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil || event.Visibility == "audit" {
+		return LiveEvent{}, false
+	}
+	kind := liveKind(event.Type)
+	if kind == "" {
+		return LiveEvent{}, false
+	}
+	ev := LiveEvent{
+		Kind:     kind,
+		Type:     event.Type,
+		Actor:    formatAgentAddress(event.Actor),
+		Rig:      extractRig(event.Actor),
+		Summary:  eventSummary(event.Type, event.Actor, event.Payload),
+		Icon:     eventIcon(event.Type),
+		Category: eventCategory(event.Type),
+		Payload:  event.Payload,
+	}
+	ev.Time, _ = time.Parse(time.RFC3339, event.Timestamp)
+	for _, key := range []string{"bead", "mr", "to"} {
+		if s, ok := event.Payload[key].(string); ok && s != "" {
+			ev.Subject = s
+			break
+		}
+	}
+	return ev, true
+}
+
+// beadChangeSource reports bead changes for the live stream. Tests
+// substitute a fake.
+type beadChangeSource interface {
+	// Changes returns bead events newer than since across the town and its
+	// rigs, oldest first.
+	Changes(ctx context.Context, townRoot string, since time.Time) ([]*beadsdk.Event, error)
+	// Convoy returns a convoy's completed and total tracked issue counts.
+	Convoy(ctx context.Context, townRoot, convoyID string) (completed, total int, err error)
+	// TrackingConvoys returns the convoys tracking an issue.
+	TrackingConvoys(ctx context.Context, townRoot, issueID string) []string
+}
+
+// storeBeadChanges reads bead changes from the handler's in-process stores.
+type storeBeadChanges struct {
+	stores *beadsStores
+}
+
+func (s storeBeadChanges) Changes(ctx context.Context, townRoot string, since time.Time) ([]*beadsdk.Event, error) {
+	var all []*beadsdk.Event
+	var errs []error
+	for _, src := range beadsSources(townRoot, discoverRigs(townRoot)) {
+		store := s.stores.get(src.beadsDir)
+		if store == nil {
+			continue
+		}
+		evs, err := store.GetAllEventsSince(ctx, since)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.name, err))
+			continue
+		}
+		all = append(all, evs...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	return all, errors.Join(errs...)
+}
+
+func (s storeBeadChanges) TrackingConvoys(ctx context.Context, townRoot, issueID string) []string {
+	store := s.stores.get(beads.ResolveBeadsDir(townRoot))
+	if store == nil {
+		return nil
+	}
+	// Cross-rig tracking deps are stored against the external-wrapped ID.
+	ids := []string{issueID}
+	if prefix := beads.ExtractPrefix(issueID); prefix != "" {
+		ids = append(ids, fmt.Sprintf("external:%s:%s", strings.TrimSuffix(prefix, "-"), issueID))
+	}
+	var convoys []string
+	for _, id := range ids {
+		dependents, err := store.GetDependentsWithMetadata(ctx, id)
+		if err != nil {
+			continue
+		}
+		for _, d := range dependents {
+			if d != nil && string(d.DependencyType) == "tracks" {
+				convoys = append(convoys, d.ID)
+			}
+		}
+	}
+	return convoys
+}
+
+func (s storeBeadChanges) Convoy(ctx context.Context, townRoot, convoyID string) (int, int, error) {
+	townBeads := beads.ResolveBeadsDir(townRoot)
+	store := s.stores.get(townBeads)
+	if store == nil {
+		return 0, 0, fmt.Errorf("town beads store unavailable")
+	}
+	deps, err := store.GetDependenciesWithMetadata(ctx, convoyID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Dependency rows carry a status snapshot; refresh it from each issue's
+	// own database so cross-rig issues are current.
+	status := make(map[string]string)
+	byDir := make(map[string][]string)
+	for _, d := range deps {
+		if d == nil || string(d.DependencyType) != "tracks" {
+			continue
+		}
+		id := d.ID
+		if strings.HasPrefix(id, "external:") {
+			if parts := strings.SplitN(id, ":", 3); len(parts) == 3 {
+				id = parts[2]
+			}
+		}
+		status[id] = string(d.Status)
+		dir := beads.ResolveBeadsDirForID(townBeads, id)
+		byDir[dir] = append(byDir[dir], id)
+	}
+	for dir, ids := range byDir {
+		rigStore := s.stores.get(dir)
+		if rigStore == nil {
+			continue
+		}
+		issues, err := rigStore.GetIssuesByIDs(ctx, ids)
+		if err != nil {
+			continue
+		}
+		for _, issue := range issues {
+			if issue != nil {
+				status[issue.ID] = string(issue.Status)
+			}
+		}
+	}
+
+	completed := 0
+	for _, st := range status {
+		if st == string(beadsdk.StatusClosed) {
+			completed++
+		}
+	}
+	return completed, len(status), nil
+}
+
+// isConvoyID reports whether id names a convoy. Convoys live in the town
+// database under the hq-cv- prefix.
+func isConvoyID(id string) bool {
+	return strings.HasPrefix(id, "hq-cv-")
+}
+
+// beadOverlap is how far back each bead poll reaches behind the cursor, so
+// changes committed with a slightly older created_at aren't missed. Repeats
+// are filtered by event ID.
+const beadOverlap = 10 * time.Second
+
+// beadFeed turns bead changes into live events, tracking its own cursor.
+type beadFeed struct {
+	src      beadChangeSource
+	townRoot string
+	since    time.Time
+	floor    time.Time // never reach back past the resume point
+	seen     map[string]time.Time
+}
+
+func newBeadFeed(src beadChangeSource, townRoot string, since time.Time) *beadFeed {
+	return &beadFeed{src: src, townRoot: townRoot, since: since, floor: since, seen: make(map[string]time.Time)}
+}
+
+// next returns live events for bead changes since the last call.
+func (f *beadFeed) next(ctx context.Context) ([]LiveEvent, error) {
+	from := f.since.Add(-beadOverlap)
+	if from.Before(f.floor) {
+		from = f.floor
+	}
+	changes, err := f.src.Changes(ctx, f.townRoot, from)
+
+	var out []LiveEvent
+	convoys := make(map[string]bool)
+	for _, c := range changes {
+		if c == nil {
+			continue
+		}
+		if _, dup := f.seen[c.ID]; dup {
+			continue
+		}
+		f.seen[c.ID] = c.CreatedAt
+		if c.CreatedAt.After(f.since) {
+			f.since = c.CreatedAt
+		}
+		out = append(out, beadLiveEvent(c))
+
+		switch c.EventType {
+		case beadsdk.EventClosed, beadsdk.EventReopened, beadsdk.EventStatusChanged:
+			for _, id := range f.src.TrackingConvoys(ctx, f.townRoot, c.IssueID) {
+				convoys[id] = true
+			}
+		case beadsdk.EventDependencyAdded, beadsdk.EventDependencyRemoved:
+			if isConvoyID(c.IssueID) {
+				convoys[c.IssueID] = true
+			}
+		}
+	}
+	for id := range convoys {
+		completed, total, err := f.src.Convoy(ctx, f.townRoot, id)
+		if err != nil {
+			log.Printf("warning: live events: convoy %s progress: %v", id, err)
+			continue
+		}
+		out = append(out, LiveEvent{
+			Kind:      LiveConvoy,
+			Type:      "progress",
+			Time:      f.since,
+			Subject:   id,
+			Summary:   fmt.Sprintf("%s %d/%d", id, completed, total),
+			Completed: completed,
+			Total:     total,
+		})
+	}
+
+	cutoff := f.since.Add(-2 * beadOverlap)
+	for id, at := range f.seen {
+		if at.Before(cutoff) {
+			delete(f.seen, id)
+		}
+	}
+	return out, err
+}
+
+func beadLiveEvent(c *beadsdk.Event) LiveEvent {
+	ev := LiveEvent{
+		Kind:    LiveBead,
+		Type:    string(c.EventType),
+		Time:    c.CreatedAt,
+		Actor:   formatAgentAddress(c.Actor),
+		Subject: c.IssueID,
+		Summary: fmt.Sprintf("%s %s", c.IssueID, strings.ReplaceAll(string(c.EventType), "_", " ")),
+		Icon:    "📋",
+	}
+	payload := make(map[string]interface{})
+	if c.OldValue != nil {
+		payload["old"] = *c.OldValue
+	}
+	if c.NewValue != nil {
+		payload["new"] = *c.NewValue
+	}
+	if len(payload) > 0 {
+		ev.Payload = payload
+	}
+	return ev
+}
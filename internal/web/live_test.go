@@ -0,0 +1,266 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func appendEvents(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLiveCursor_RoundTrip(t *testing.T) {
+	c := liveCursor{offset: 1234, line: 0xdeadbeef, beads: time.UnixMilli(1700000000123)}
+	got, ok := parseLiveCursor(c.String())
+	if !ok || got.offset != c.offset || got.line != c.line || !got.beads.Equal(c.beads) {
+		t.Errorf("round trip %q = %+v, %v", c.String(), got, ok)
+	}
+	for _, bad := range []string{"", "abc", "1-2", "-1-0-0", "1-zz-0"} {
+		if _, ok := parseLiveCursor(bad); ok {
+			t.Errorf("parseLiveCursor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestEventLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), events.EventsFile)
+	appendEvents(t, path, `{"ts":"2026-01-01T00:00:00Z","type":"sling","actor":"mayor"}`+"\n")
+
+	tail := tailFromEnd(path)
+	appendEvents(t, path,
+		`{"ts":"2026-01-01T00:00:01Z","type":"sling","actor":"mayor","payload":{"bead":"gt-1","target":"gastown/polecats/nux"},"visibility":"feed"}`+"\n",
+		`{"ts":"2026-01-01T00:00:02Z","type":"patrol_started","actor":"gastown/witness","visibility":"feed"}`+"\n",
+		`{"ts":"2026-01-01T00:00:03Z","type":"merged","actor":"gastown/refinery","payload":{"mr":"gt-mr1","branch":"polecat/nux"},"visibility":"both"}`+"\n",
+		`{"ts":"2026-01-01T00:00:04Z","type":"dashboard_api","actor":"dashboard/local","visibility":"audit"}`+"\n",
+		`{"ts":"2026-01-01T00:00:05Z","type":"mail","actor":"mayor","payload":{"to":"overseer","subject":"hi"}`, // partial
+	)
+
+	evs, err := tail.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || evs[0].Kind != LiveAgent || evs[0].Subject != "gt-1" || evs[1].Kind != LiveMerge || evs[1].Subject != "gt-mr1" {
+		t.Fatalf("events = %+v", evs)
+	}
+	if evs[1].Summary != "merged polecat/nux" || evs[1].Rig != "gastown" || evs[0].Category != "work" {
+		t.Errorf("activity fields = %+v", evs)
+	}
+
+	// The partial line completes and is picked up; a resumed tail sees only
+	// what came after its cursor.
+	cursor := liveCursor{offset: tail.offset, line: tail.line}
+	appendEvents(t, path, `,"visibility":"feed"}`+"\n")
+	evs, err = tail.next()
+	if err != nil || len(evs) != 1 || evs[0].Kind != LiveMail || evs[0].Subject != "overseer" {
+		t.Fatalf("after completing line: %+v, %v", evs, err)
+	}
+	resumed, err := tailFromCursor(path, cursor)
+	if err != nil {
+		t.Fatalf("tailFromCursor: %v", err)
+	}
+	if evs, _ := resumed.next(); len(evs) != 1 || evs[0].Kind != LiveMail {
+		t.Errorf("resumed events = %+v", evs)
+	}
+
+	// A pruned log no longer matches the cursor.
+	if err := os.WriteFile(path, []byte(`{"ts":"2026-01-02T00:00:00Z","type":"done","actor":"x","visibility":"feed"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tail.next(); err != errLogRewritten {
+		t.Errorf("next after prune: err = %v, want errLogRewritten", err)
+	}
+	if _, err := tailFromCursor(path, cursor); err != errLogRewritten {
+		t.Errorf("tailFromCursor after prune: err = %v, want errLogRewritten", err)
+	}
+}
+
+// fakeBeadChanges serves a fixed list of bead events.
+type fakeBeadChanges struct {
+	mu       sync.Mutex
+	events   []*beadsdk.Event
+	tracking map[string][]string
+	progress map[string][2]int
+}
+
+func (f *fakeBeadChanges) add(ev *beadsdk.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ev)
+}
+
+func (f *fakeBeadChanges) Changes(_ context.Context, _ string, since time.Time) ([]*beadsdk.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*beadsdk.Event
+	for _, ev := range f.events {
+		if ev.CreatedAt.After(since) {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBeadChanges) Convoy(_ context.Context, _, id string) (int, int, error) {
+	p := f.progress[id]
+	return p[0], p[1], nil
+}
+
+func (f *fakeBeadChanges) TrackingConvoys(_ context.Context, _, id string) []string {
+	return f.tracking[id]
+}
+
+func TestBeadFeed(t *testing.T) {
+	start := time.Now()
+	src := &fakeBeadChanges{
+		tracking: map[string][]string{"gt-1": {"hq-cv-a"}},
+		progress: map[string][2]int{"hq-cv-a": {1, 3}},
+	}
+	feed := newBeadFeed(src, "/town", start)
+
+	src.add(&beadsdk.Event{ID: "e1", IssueID: "gt-1", EventType: beadsdk.EventClosed, CreatedAt: start.Add(time.Second)})
+	evs, err := feed.next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || evs[0].Kind != LiveBead || evs[0].Subject != "gt-1" ||
+		evs[1].Kind != LiveConvoy || evs[1].Subject != "hq-cv-a" || evs[1].Completed != 1 || evs[1].Total != 3 {
+		t.Fatalf("events = %+v", evs)
+	}
+
+	// A late commit with an older timestamp is still caught by the overlap,
+	// and the first event isn't repeated.
+	src.add(&beadsdk.Event{ID: "e0", IssueID: "gt-2", EventType: beadsdk.EventUpdated, CreatedAt: start.Add(500 * time.Millisecond)})
+	evs, _ = feed.next(context.Background())
+	if len(evs) != 1 || evs[0].Subject != "gt-2" {
+		t.Errorf("second poll = %+v", evs)
+	}
+	if !feed.since.Equal(start.Add(time.Second)) {
+		t.Errorf("cursor = %v", feed.since)
+	}
+}
+
+// sseFrame is one parsed Server-Sent Event.
+type sseFrame struct {
+	id, event, data string
+}
+
+func readSSE(t *testing.T, srv *httptest.Server, path string, header http.Header) (<-chan sseFrame, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	frames := make(chan sseFrame, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(frames)
+		var f sseFrame
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if f.event != "" {
+					frames <- f
+				}
+				f = sseFrame{}
+			case strings.HasPrefix(line, "id: "):
+				f.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				f.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				f.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	t.Cleanup(cancel)
+	return frames, cancel
+}
+
+func nextFrame(t *testing.T, frames <-chan sseFrame) sseFrame {
+	t.Helper()
+	select {
+	case f, ok := <-frames:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return f
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for SSE frame")
+	}
+	return sseFrame{}
+}
+
+func TestHandleSSE_TypedEventsAndResume(t *testing.T) {
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(town, "mayor", "town.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(town, events.EventsFile)
+	appendEvents(t, logPath, `{"ts":"2026-01-01T00:00:00Z","type":"boot","actor":"mayor","visibility":"feed"}`+"\n")
+
+	h := newFastAPIHandler(t)
+	h.workDir = town
+	h.liveBeads = &fakeBeadChanges{}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	frames, cancel := readSSE(t, srv, "/api/events", nil)
+	if f := nextFrame(t, frames); f.event != "connected" || f.id == "" {
+		t.Fatalf("first frame = %+v", f)
+	}
+	appendEvents(t, logPath, `{"ts":"2026-01-01T00:00:01Z","type":"done","actor":"gastown/polecats/nux","payload":{"bead":"gt-1"},"visibility":"feed"}`+"\n")
+	f := nextFrame(t, frames)
+	if f.event != LiveAgent || !strings.Contains(f.data, `"subject":"gt-1"`) || f.id == "" {
+		t.Fatalf("agent frame = %+v", f)
+	}
+	cancel()
+
+	// Events written while disconnected are delivered on resume.
+	appendEvents(t, logPath, `{"ts":"2026-01-01T00:00:02Z","type":"mail","actor":"mayor","payload":{"to":"overseer","subject":"x"},"visibility":"feed"}`+"\n")
+	frames, _ = readSSE(t, srv, "/api/events", http.Header{"Last-Event-ID": {f.id}})
+	if c := nextFrame(t, frames); c.event != "connected" {
+		t.Fatalf("resume first frame = %+v", c)
+	}
+	if m := nextFrame(t, frames); m.event != LiveMail || !strings.Contains(m.data, `"subject":"overseer"`) {
+		t.Fatalf("resumed frame = %+v", m)
+	}
+
+	// An unusable cursor asks the client to resync.
+	frames, _ = readSSE(t, srv, "/api/events?since=bogus", nil)
+	nextFrame(t, frames)
+	if r := nextFrame(t, frames); r.event != LiveResync {
+		t.Errorf("bogus cursor frame = %+v, want resync", r)
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/trends"
+)
+
+// defaultHistorySpan is the lookback for /api/v1/metrics/history without ?since.
+const defaultHistorySpan = 24 * time.Hour
+
+// MetricsHistoryResponse is the response for /api/v1/metrics/history.
+type MetricsHistoryResponse struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	StepSeconds int64           `json:"step_seconds"`
+	Series      []trends.Series `json:"series"`
+}
+
+// handleMetricsHistory serves the daemon's sampled gauge history.
+//
+// Query parameters:
+//   - since: lookback such as 6h or 7d (default 24h)
+//   - step: bucket size such as 15m (default: chosen from since)
+//   - series: comma-separated series names (default: all)
+func (h *APIHandler) handleMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	span := defaultHistorySpan
+	if s := q.Get("since"); s != "" {
+		d, err := trends.ParseSpan(s)
+		if err != nil {
+			h.sendError(w, "Invalid since: use a span like 6h or 7d", http.StatusBadRequest)
+			return
+		}
+		span = d
+	}
+	var step time.Duration
+	if s := q.Get("step"); s != "" {
+		d, err := trends.ParseSpan(s)
+		if err != nil {
+			h.sendError(w, "Invalid step: use a span like 15m or 1h", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+	var names []string
+	if s := q.Get("series"); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-span)
+	if step <= 0 {
+		step = trends.AutoStep(span)
+	}
+	resp := MetricsHistoryResponse{
+		From:        from,
+		To:          to,
+		StepSeconds: int64(step / time.Second),
+		Series:      make([]trends.Series, 0),
+	}
+
+	if townRoot := h.townRoot(); townRoot != "" {
+		series, err := trends.Open(townRoot).Query(from, to, step, names...)
+		if err != nil {
+			log.Printf("warning: handleMetricsHistory: %v", err)
+			h.sendError(w, "Failed to read metrics history", http.StatusInternalServerError)
+			return
+		}
+		resp.Series = append(resp.Series, series...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
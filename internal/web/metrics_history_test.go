@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/trends"
+)
+
+func TestHandleMetricsHistory(t *testing.T) {
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(town, "mayor", "town.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := trends.Open(town)
+	now := time.Now()
+	for i := 3; i > 0; i-- {
+		at := now.Add(-time.Duration(i) * 20 * time.Minute)
+		if err := store.Record(at, map[string]float64{trends.ActivePolecats: float64(i), trends.QueueDepth: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := newFastAPIHandler(t)
+	h.workDir = town
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics/history?since=6h&series="+trends.ActivePolecats, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var resp MetricsHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StepSeconds != 300 {
+		t.Errorf("step = %ds, want 300", resp.StepSeconds)
+	}
+	if len(resp.Series) != 1 || resp.Series[0].Name != trends.ActivePolecats || len(resp.Series[0].Points) != 3 {
+		t.Fatalf("series = %+v", resp.Series)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/metrics/history?since=soon", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad since: status = %d, want 400", rec.Code)
+	}
+}
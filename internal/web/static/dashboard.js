@@ -20,31 +20,50 @@
     // ============================================
     // SSE (Server-Sent Events) CONNECTION
     // ============================================
+    // The server pushes typed events (agent, merge, mail, convoy, bead) that
+    // are applied to the page in place. "resync" means the server couldn't
+    // resume our cursor, so the whole dashboard is refetched.
     window.sseConnected = false;
     var evtSource = null;
     var sseReconnectDelay = 1000;
     var sseMaxReconnectDelay = 30000;
+    var sseLastEventId = '';
 
     function connectSSE() {
         if (evtSource) {
             evtSource.close();
         }
 
-        evtSource = new EventSource('/api/events');
+        // A fresh EventSource doesn't send Last-Event-ID, so resume explicitly.
+        var url = '/api/events';
+        if (sseLastEventId) {
+            url += '?since=' + encodeURIComponent(sseLastEventId);
+        }
+        evtSource = new EventSource(url);
 
-        evtSource.addEventListener('connected', function() {
+        evtSource.addEventListener('connected', function(e) {
             window.sseConnected = true;
             sseReconnectDelay = 1000;
+            trackEventId(e);
             updateConnectionStatus('live');
         });
 
-        evtSource.addEventListener('dashboard-update', function(e) {
-            if (window.pauseRefresh) return;
-            // Trigger HTMX to re-fetch the dashboard
-            var dashboard = document.getElementById('dashboard-main');
-            if (dashboard && typeof htmx !== 'undefined') {
-                htmx.trigger(dashboard, 'sse:dashboard-update');
-            }
+        evtSource.addEventListener('resync', function(e) {
+            trackEventId(e);
+            refreshDashboard();
+        });
+
+        ['agent', 'merge', 'mail', 'convoy', 'bead'].forEach(function(kind) {
+            evtSource.addEventListener(kind, function(e) {
+                trackEventId(e);
+                var ev;
+                try {
+                    ev = JSON.parse(e.data);
+                } catch (err) {
+                    return;
+                }
+                applyLiveEvent(ev);
+            });
         });
 
         evtSource.onerror = function() {
@@ -59,6 +78,109 @@
         };
     }
 
+    function trackEventId(e) {
+        if (e.lastEventId) sseLastEventId = e.lastEventId;
+    }
+
+    function refreshDashboard() {
+        if (window.pauseRefresh) return;
+        // Trigger HTMX to re-fetch the dashboard
+        var dashboard = document.getElementById('dashboard-main');
+        if (dashboard && typeof htmx !== 'undefined') {
+            htmx.trigger(dashboard, 'sse:dashboard-update');
+        }
+    }
+
+    // Panel reloads are debounced so a burst of events costs one fetch.
+    var liveReloadTimers = {};
+    function reloadSoon(name, fn) {
+        if (!fn) return;
+        clearTimeout(liveReloadTimers[name]);
+        liveReloadTimers[name] = setTimeout(fn, 500);
+    }
+
+    function applyLiveEvent(ev) {
+        switch (ev.kind) {
+            case 'agent':
+                prependActivity(ev);
+                reloadSoon('crew', window.refreshCrewPanel);
+                reloadSoon('ready', window.refreshReadyPanel);
+                break;
+            case 'merge':
+                prependActivity(ev);
+                if (ev.type === 'merged') {
+                    showToast('success', 'Merged', ev.summary);
+                } else if (ev.type === 'merge_failed') {
+                    showToast('error', 'Merge failed', ev.summary);
+                }
+                break;
+            case 'mail':
+                prependActivity(ev);
+                reloadSoon('mail', loadMailInbox);
+                if (ev.payload && ev.payload.to === 'overseer') {
+                    showToast('info', 'New mail from ' + (ev.actor || 'unknown'), ev.payload.subject || '');
+                }
+                break;
+            case 'convoy':
+                updateConvoyProgress(ev.subject, ev.completed, ev.total);
+                break;
+            case 'bead':
+                reloadSoon('ready', window.refreshReadyPanel);
+                break;
+        }
+    }
+
+    function prependActivity(ev) {
+        var timeline = document.getElementById('activity-timeline');
+        if (!timeline) return;
+        var entry = document.createElement('div');
+        entry.className = 'tl-entry tl-cat-' + (ev.category || 'system');
+        entry.setAttribute('data-category', ev.category || 'system');
+        entry.setAttribute('data-rig', ev.rig || '');
+        entry.setAttribute('data-agent', ev.actor || '');
+        entry.setAttribute('data-type', ev.type);
+        entry.setAttribute('data-ts', ev.time);
+        entry.innerHTML =
+            '<div class="tl-rail">' +
+                '<span class="tl-time">just now</span>' +
+                '<span class="tl-node"></span>' +
+            '</div>' +
+            '<div class="tl-content">' +
+                '<div class="tl-header">' +
+                    '<span class="tl-icon">' + escapeHtml(ev.icon || '') + '</span>' +
+                    '<span class="tl-summary">' + escapeHtml(ev.summary || ev.type) + '</span>' +
+                '</div>' +
+                '<div class="tl-meta">' +
+                    (ev.actor ? '<span class="tl-badge tl-badge-agent">' + escapeHtml(ev.actor) + '</span>' : '') +
+                    (ev.rig ? '<span class="tl-badge tl-badge-rig">' + escapeHtml(ev.rig) + '</span>' : '') +
+                    '<span class="tl-badge tl-badge-type">' + escapeHtml(ev.type) + '</span>' +
+                '</div>' +
+            '</div>';
+        timeline.insertBefore(entry, timeline.firstChild);
+        // Keep the timeline the size the server renders.
+        while (timeline.children.length > 50) {
+            timeline.removeChild(timeline.lastChild);
+        }
+    }
+
+    function updateConvoyProgress(convoyId, completed, total) {
+        var row = document.querySelector('.convoy-row[data-convoy-id="' + CSS.escape(convoyId) + '"]');
+        if (!row) {
+            // A convoy we aren't showing yet (new, or filtered out) — refetch.
+            refreshDashboard();
+            return;
+        }
+        completed = completed || 0;
+        total = total || 0;
+        var pct = total ? Math.floor(completed * 100 / total) : 0;
+        var fraction = row.querySelector('.convoy-progress-fraction');
+        if (fraction) fraction.textContent = completed + '/' + total;
+        var pctEl = row.querySelector('.convoy-progress-pct');
+        if (pctEl) pctEl.textContent = pct + '%';
+        var fill = row.querySelector('.progress-fill');
+        if (fill) fill.style.width = pct + '%';
+    }
+
     function updateConnectionStatus(state) {
         var el = document.getElementById('connection-status');
         if (!el) return;
@@ -148,6 +270,7 @@
         // Reload dynamic panels after swap (handled via window functions)
         if (window.refreshCrewPanel) window.refreshCrewPanel();
         if (window.refreshReadyPanel) window.refreshReadyPanel();
+        if (window.refreshTrendsPanel) window.refreshTrendsPanel();
         // Update connection status indicator after morph
         updateConnectionStatus(window.sseConnected ? 'live' : 'reconnecting');
     });
@@ -161,7 +284,7 @@
     var isPaletteOpen = false;
     var executionLock = false;
     var pendingCommand = null; // Command waiting for args
-    var cachedOptions = null;  // Cached options from /api/options
+    var cachedOptions = null;  // Cached options from /api/v1/options
     var recentCommands = [];   // Recently executed commands (from localStorage)
     var MAX_RECENT = 10;
     var RECENT_STORAGE_KEY = 'gt-palette-recent';
@@ -302,7 +425,7 @@
 
     // Fetch dynamic options (rigs, polecats, convoys, agents, hooks)
     function fetchOptions() {
-        return fetch('/api/options')
+        return fetch('/api/v1/options')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 cachedOptions = data;
@@ -895,7 +1018,7 @@
 
         if (!loading || !threadsContainer) return;
 
-        fetch('/api/mail/threads')
+        fetch('/api/v1/mail/threads')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 loading.style.display = 'none';
@@ -1031,7 +1154,7 @@
 
         if (!loading || !table || !tbody) return;
 
-        fetch('/api/crew')
+        fetch('/api/v1/crew')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 loading.style.display = 'none';
@@ -1414,7 +1537,7 @@
             payload.description = description;
         }
 
-        fetch('/api/issues/create', {
+        fetch('/api/v1/issues', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(payload)
@@ -1506,7 +1629,7 @@
 
         if (!loading || !table || !tbody) return;
 
-        fetch('/api/ready')
+        fetch('/api/v1/ready')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 loading.style.display = 'none';
@@ -1558,6 +1681,115 @@
     // Expose for refresh after HTMX swaps
     window.refreshReadyPanel = loadReady;
 
+    // ============================================
+    // TRENDS PANEL
+    // ============================================
+    var TREND_SERIES = [
+        { name: 'polecats.active', label: 'Active polecats' },
+        { name: 'sessions.active', label: 'Agent sessions' },
+        { name: 'scheduler.queue_depth', label: 'Scheduler queue' },
+        { name: 'mq.depth', label: 'Merge queue' },
+        { name: 'mq.wait_max_seconds', label: 'Oldest MR wait', format: formatTrendSeconds },
+        { name: 'dolt.latency_ms', label: 'Dolt latency', format: function(v) { return Math.round(v) + 'ms'; } },
+        { name: 'dolt.connections', label: 'Dolt connections' },
+        { name: 'costs.today_usd', label: 'Cost today', format: function(v) { return '$' + v.toFixed(2); } },
+        { name: 'escalations.open', label: 'Open escalations' }
+    ];
+    var trendsRange = '24h';
+    var trendsData = null;
+    var trendsFetchedAt = 0;
+
+    function formatTrendSeconds(v) {
+        if (v < 60) return Math.round(v) + 's';
+        if (v < 3600) return Math.round(v / 60) + 'm';
+        return (v / 3600).toFixed(1) + 'h';
+    }
+
+    function formatTrendValue(def, v) {
+        if (def.format) return def.format(v);
+        return Math.abs(v - Math.round(v)) < 0.05 ? String(Math.round(v)) : v.toFixed(1);
+    }
+
+    // Draws the avg line over a min–max band as an inline SVG in a 100x30 box.
+    function trendSparkline(points, from, to) {
+        var lo = Infinity, hi = -Infinity;
+        points.forEach(function(p) { lo = Math.min(lo, p.min); hi = Math.max(hi, p.max); });
+        if (hi === lo) { hi += 1; lo -= 1; }
+        var span = Math.max(to - from, 1);
+        function x(p) { return ((new Date(p.t).getTime() - from) / span * 100).toFixed(2); }
+        function y(v) { return (28 - (v - lo) / (hi - lo) * 26).toFixed(2); }
+        var line = points.map(function(p) { return x(p) + ',' + y(p.avg); }).join(' ');
+        var band = points.map(function(p) { return x(p) + ',' + y(p.max); })
+            .concat(points.slice().reverse().map(function(p) { return x(p) + ',' + y(p.min); })).join(' ');
+        return '<svg class="trend-spark" viewBox="0 0 100 30" preserveAspectRatio="none">' +
+            '<polygon class="band" points="' + band + '"></polygon>' +
+            '<polyline class="line" points="' + line + '"></polyline></svg>';
+    }
+
+    function renderTrends() {
+        var loading = document.getElementById('trends-loading');
+        var grid = document.getElementById('trends-grid');
+        var empty = document.getElementById('trends-empty');
+        var select = document.getElementById('trends-range');
+        if (!loading || !grid || !empty) return;
+        if (select) select.value = trendsRange;
+        if (!trendsData) return;
+
+        loading.style.display = 'none';
+        var byName = {};
+        (trendsData.series || []).forEach(function(s) { byName[s.name] = s.points || []; });
+        var from = new Date(trendsData.from).getTime();
+        var to = new Date(trendsData.to).getTime();
+
+        var html = '';
+        TREND_SERIES.forEach(function(def) {
+            var points = byName[def.name];
+            if (!points || points.length === 0) return;
+            var latest = points[points.length - 1];
+            var min = Infinity, max = -Infinity;
+            points.forEach(function(p) { min = Math.min(min, p.min); max = Math.max(max, p.max); });
+            html += '<div class="trend-card" title="' + escapeHtml(def.name) + '">' +
+                '<div class="trend-head"><span class="trend-label">' + escapeHtml(def.label) + '</span>' +
+                '<span class="trend-latest">' + escapeHtml(formatTrendValue(def, latest.avg)) + '</span></div>' +
+                trendSparkline(points, from, to) +
+                '<div class="trend-range-text">' + escapeHtml(formatTrendValue(def, min)) + ' – ' +
+                escapeHtml(formatTrendValue(def, max)) + '</div></div>';
+        });
+        grid.innerHTML = html;
+        grid.style.display = html ? 'grid' : 'none';
+        empty.style.display = html ? 'none' : 'block';
+    }
+
+    function loadTrends() {
+        fetch('/api/v1/metrics/history?since=' + encodeURIComponent(trendsRange))
+            .then(function(r) { return r.json(); })
+            .then(function(data) {
+                trendsData = data;
+                trendsFetchedAt = Date.now();
+                renderTrends();
+            })
+            .catch(function(err) {
+                var loading = document.getElementById('trends-loading');
+                if (loading) loading.textContent = 'Failed to load trends';
+                console.error('Trends load error:', err);
+            });
+    }
+
+    document.addEventListener('change', function(e) {
+        if (e.target && e.target.id === 'trends-range') {
+            trendsRange = e.target.value;
+            loadTrends();
+        }
+    });
+
+    loadTrends();
+    // Dashboard morphs replace the panel body: redraw from the cached history
+    // and refetch once it is a minute old (the daemon samples every few minutes).
+    window.refreshTrendsPanel = function() {
+        renderTrends();
+        if (Date.now() - trendsFetchedAt > 60000) loadTrends();
+    };
+
     // ============================================
     // CONVOY PANEL INTERACTIONS
     // ============================================
@@ -1587,6 +1819,7 @@
         document.getElementById('convoy-detail-title').textContent = 'Convoy: ' + convoyId;
         document.getElementById('convoy-detail-status').textContent = '';
         document.getElementById('convoy-detail-progress').textContent = '';
+        document.getElementById('convoy-detail-eta').textContent = '';
         document.getElementById('convoy-issues-loading').style.display = 'block';
         document.getElementById('convoy-issues-table').style.display = 'none';
         document.getElementById('convoy-issues-empty').style.display = 'none';
@@ -1664,7 +1897,8 @@
             // Skip header lines and convoy summary lines
             if (line.startsWith('Convoy') || line.startsWith('===') || line.startsWith('---') ||
                 line.startsWith('Status:') || line.startsWith('Progress:') || line.startsWith('Created:') ||
-                line.startsWith('Title:') || line.startsWith('Issues:') || line.startsWith('Name:')) {
+                line.startsWith('Title:') || line.startsWith('Issues:') || line.startsWith('Name:') ||
+                line.startsWith('ETA:')) {
                 // Extract convoy-level status/progress for the detail header
                 if (line.startsWith('Status:')) {
                     var statusEl = document.getElementById('convoy-detail-status');
@@ -1680,6 +1914,9 @@
                 if (line.startsWith('Progress:')) {
                     document.getElementById('convoy-detail-progress').textContent = line.replace('Progress:', '').trim();
                 }
+                if (line.startsWith('ETA:')) {
+                    document.getElementById('convoy-detail-eta').textContent = 'ETA ' + line.replace('ETA:', '').trim();
+                }
                 continue;
             }
             // Look for issue lines - typically formatted as:
@@ -1942,9 +2179,18 @@
         mailDetail.style.display = 'block';
 
         // Fetch message content
-        fetch('/api/mail/read?id=' + encodeURIComponent(msgId))
+        fetch('/api/v1/mail/messages/' + encodeURIComponent(msgId))
             .then(function(r) { return r.json(); })
             .then(function(msg) {
+                if (msg.error) {
+                    document.getElementById('mail-detail-body').textContent = 'Error loading message: ' + msg.error;
+                    return;
+                }
+                // Opening a message marks it read, as gt mail read does
+                if (!msg.read) {
+                    fetch('/api/v1/mail/messages/' + encodeURIComponent(msgId) + '/read', { method: 'POST' })
+                        .catch(function() {});
+                }
                 document.getElementById('mail-detail-subject').textContent = msg.subject || '(no subject)';
                 document.getElementById('mail-detail-from').textContent = msg.from || from;
                 document.getElementById('mail-detail-body').textContent = msg.body || '(no content)';
@@ -2052,7 +2298,7 @@
         btn.textContent = 'Sending...';
         btn.disabled = true;
 
-        fetch('/api/mail/send', {
+        fetch('/api/v1/mail/send', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -2107,7 +2353,7 @@
         }
 
         // Fetch agents from options API
-        return fetch('/api/options')
+        return fetch('/api/v1/options')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 // Clear loading state, rebuild options
@@ -2206,7 +2452,7 @@
         issueDetail.style.display = 'block';
 
         // Fetch issue details
-        fetch('/api/issues/show?id=' + encodeURIComponent(issueId))
+        fetch('/api/v1/issues/' + encodeURIComponent(issueId))
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 if (data.error) {
@@ -2217,7 +2463,7 @@
 
                 document.getElementById('issue-detail-id').textContent = data.id || issueId;
                 document.getElementById('issue-detail-title-text').textContent = data.title || '(no title)';
-                document.getElementById('issue-detail-description').textContent = data.description || data.raw_output || '(no description)';
+                document.getElementById('issue-detail-description').textContent = data.description || '(no description)';
 
                 // Priority badge
                 var priorityEl = document.getElementById('issue-detail-priority');
@@ -2344,7 +2590,7 @@
         var select = document.getElementById('issue-action-assignee');
         if (!select) return;
 
-        fetch('/api/options')
+        fetch('/api/v1/options')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 // Rebuild dropdown
@@ -2389,10 +2635,8 @@
 
         showToast('info', 'Closing...', issueId);
 
-        fetch('/api/issues/close', {
-            method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ id: issueId })
+        fetch('/api/v1/issues/' + encodeURIComponent(issueId) + '/close', {
+            method: 'POST'
         })
         .then(function(r) { return r.json(); })
         .then(function(data) {
@@ -2414,10 +2658,10 @@
     function reopenIssue(issueId) {
         showToast('info', 'Reopening...', issueId);
 
-        fetch('/api/issues/update', {
+        fetch('/api/v1/issues/' + encodeURIComponent(issueId) + '/update', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ id: issueId, status: 'open' })
+            body: JSON.stringify({ status: 'open' })
         })
         .then(function(r) { return r.json(); })
         .then(function(data) {
@@ -2441,10 +2685,10 @@
 
         showToast('info', 'Updating...', 'Setting priority to P' + priNum);
 
-        fetch('/api/issues/update', {
+        fetch('/api/v1/issues/' + encodeURIComponent(issueId) + '/update', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ id: issueId, priority: priNum })
+            body: JSON.stringify({ priority: priNum })
         })
         .then(function(r) { return r.json(); })
         .then(function(data) {
@@ -2467,10 +2711,10 @@
 
         showToast('info', 'Assigning...', 'Assigning to ' + assignee);
 
-        fetch('/api/issues/update', {
+        fetch('/api/v1/issues/' + encodeURIComponent(issueId) + '/update', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ id: issueId, assignee: assignee })
+            body: JSON.stringify({ assignee: assignee })
         })
         .then(function(r) { return r.json(); })
         .then(function(data) {
@@ -2649,7 +2893,7 @@
         activeSlingDropdown = dropdown;
 
         // Fetch rig options
-        fetch('/api/options?type=rigs')
+        fetch('/api/v1/options?type=rigs')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 var rigs = data.rigs || [];
@@ -2804,7 +3048,7 @@
         window.pauseRefresh = true;
 
         // Load agents
-        fetch('/api/options')
+        fetch('/api/v1/options')
             .then(function(r) { return r.json(); })
             .then(function(data) {
                 select.innerHTML = '<option value="">Select agent...</option>';
@@ -2990,8 +3234,14 @@
     // ============================================
     // SESSION TERMINAL PREVIEW
     // ============================================
+    // Streams the pane live over a websocket into xterm.js. Falls back to
+    // polling capture-pane snapshots when xterm.js or websockets are unavailable.
     var sessionPreviewInterval = null;
     var sessionsTable = null; // will be set when opening preview
+    var sessionSocket = null;
+    var sessionTerm = null;
+    var sessionPreviewName = null;
+    var sessionTakeover = false;
 
     // Click on session row to preview terminal output
     document.addEventListener('click', function(e) {
@@ -3025,6 +3275,19 @@
         contentEl.textContent = 'Loading...';
         statusEl.textContent = '';
         preview.style.display = 'block';
+        sessionPreviewName = sessionName;
+
+        if (window.Terminal && window.WebSocket) {
+            connectSessionTerminal(sessionName, false);
+            return;
+        }
+        startSessionPolling(sessionName, contentEl, statusEl);
+    }
+
+    function startSessionPolling(sessionName, contentEl, statusEl) {
+        var termEl = document.getElementById('session-preview-terminal');
+        if (termEl) termEl.style.display = 'none';
+        contentEl.style.display = '';
 
         // Fetch immediately
         fetchSessionPreview(sessionName, contentEl, statusEl);
@@ -3036,6 +3299,115 @@
         }, 3000);
     }
 
+    // Takeover is offered for crew and polecat sessions; the server enforces
+    // the operator role and rejects everything else.
+    function canTakeOver(sessionName) {
+        return !/^(hq|gthq)-/.test(sessionName) && !/-(witness|refinery)$/.test(sessionName);
+    }
+
+    function connectSessionTerminal(sessionName, takeover) {
+        closeSessionSocket();
+
+        var termEl = document.getElementById('session-preview-terminal');
+        var contentEl = document.getElementById('session-preview-content');
+        var statusEl = document.getElementById('session-preview-status');
+        var takeoverBtn = document.getElementById('session-takeover-btn');
+
+        contentEl.style.display = 'none';
+        termEl.style.display = 'block';
+        termEl.classList.toggle('takeover', takeover);
+        if (!sessionTerm) {
+            sessionTerm = new window.Terminal({
+                convertEol: false,
+                disableStdin: true,
+                fontSize: 12,
+                rows: 30,
+                theme: { background: '#000000' }
+            });
+            sessionTerm.open(termEl);
+            sessionTerm.onData(function(data) {
+                if (sessionTakeover && sessionSocket && sessionSocket.readyState === WebSocket.OPEN) {
+                    sessionSocket.send(data);
+                }
+            });
+        }
+        sessionTerm.reset();
+        sessionTerm.options.disableStdin = !takeover;
+        sessionTakeover = takeover;
+
+        if (takeoverBtn) {
+            takeoverBtn.style.display = canTakeOver(sessionName) ? '' : 'none';
+            takeoverBtn.textContent = takeover ? 'Release' : 'Take over';
+            takeoverBtn.classList.toggle('active', takeover);
+        }
+
+        var proto = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        var url = proto + '//' + window.location.host + '/api/v1/sessions/' + encodeURIComponent(sessionName) + '/terminal';
+        if (takeover) {
+            url += '?mode=takeover&token=' + encodeURIComponent(_csrfToken);
+        }
+        var ws = new WebSocket(url);
+        ws.binaryType = 'arraybuffer';
+        sessionSocket = ws;
+        statusEl.textContent = 'connecting...';
+
+        ws.onmessage = function(ev) {
+            if (typeof ev.data !== 'string') {
+                sessionTerm.write(new Uint8Array(ev.data));
+                return;
+            }
+            var msg;
+            try { msg = JSON.parse(ev.data); } catch (e) { return; }
+            if (msg.type === 'mode') {
+                statusEl.textContent = (msg.mode === 'takeover' ? 'typing into session' : 'live (read-only)') +
+                    (msg.viewers > 1 ? ' · ' + msg.viewers + ' viewers' : '');
+                if (msg.mode === 'takeover') sessionTerm.focus();
+            } else if (msg.type === 'error') {
+                statusEl.textContent = 'error: ' + msg.error;
+            } else if (msg.type === 'exit') {
+                statusEl.textContent = 'session ended';
+            }
+        };
+        ws.onerror = function() {
+            if (ws !== sessionSocket) return;
+            // Websocket refused (old server, proxy without upgrade support): poll instead.
+            if (!takeover) {
+                sessionSocket = null;
+                startSessionPolling(sessionName, contentEl, statusEl);
+            } else {
+                statusEl.textContent = 'take over refused';
+            }
+        };
+        ws.onclose = function() {
+            if (ws !== sessionSocket) return;
+            if (statusEl.textContent.indexOf('error') !== 0 && statusEl.textContent !== 'session ended') {
+                statusEl.textContent = 'disconnected';
+            }
+            sessionTakeover = false;
+            if (sessionTerm) sessionTerm.options.disableStdin = true;
+        };
+    }
+
+    function closeSessionSocket() {
+        if (sessionSocket) {
+            var ws = sessionSocket;
+            sessionSocket = null;
+            ws.close();
+        }
+        sessionTakeover = false;
+    }
+
+    var sessionTakeoverBtn = document.getElementById('session-takeover-btn');
+    if (sessionTakeoverBtn) {
+        sessionTakeoverBtn.addEventListener('click', function() {
+            if (!sessionPreviewName) return;
+            if (!sessionTakeover && !confirm('Take over ' + sessionPreviewName + '? Your keystrokes will be sent to the agent session and recorded in the audit log.')) {
+                return;
+            }
+            connectSessionTerminal(sessionPreviewName, !sessionTakeover);
+        });
+    }
+
     function fetchSessionPreview(sessionName, contentEl, statusEl) {
         fetch('/api/session/preview?session=' + encodeURIComponent(sessionName))
             .then(function(r) { return r.json(); })
@@ -3062,6 +3434,8 @@
             clearInterval(sessionPreviewInterval);
             sessionPreviewInterval = null;
         }
+        closeSessionSocket();
+        sessionPreviewName = null;
 
         var preview = document.getElementById('session-preview');
         if (preview) preview.style.display = 'none';
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/steveyegge/gastown/internal/activity"
+	convoyops "github.com/steveyegge/gastown/internal/convoy"
 )
 
 //go:embed templates/*.html
@@ -210,6 +211,11 @@ type ConvoyRow struct {
 	Assignees     []string // unique assignees across tracked issues
 	LastActivity  activity.Info
 	TrackedIssues []TrackedIssue
+
+	// Forecast (open convoys with history only)
+	ETA          string                      // e.g., "~3h (2h–5h)": median and p10–p90 band
+	CriticalPath string                      // e.g., "gt-a → gt-b → gt-c"
+	Blockers     []convoyops.ForecastBlocker // Issues blocking the most downstream work
 }
 
 // TrackedIssue represents an issue tracked by a convoy.
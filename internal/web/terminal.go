@@ -0,0 +1,375 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// paneSource is the tmux surface the terminal hub needs. Tests substitute a fake.
+type paneSource interface {
+	// Attach streams the session's pane output until ctx is cancelled or the
+	// session exits, at which point the channel is closed.
+	Attach(ctx context.Context, session string) (<-chan []byte, error)
+	// Snapshot returns the current screen, ready to paint into a terminal emulator.
+	Snapshot(session string) ([]byte, error)
+	// Send writes raw keystrokes to the session.
+	Send(session string, data []byte) error
+}
+
+// tmuxPaneSource streams panes through tmux control mode.
+type tmuxPaneSource struct {
+	t *tmux.Tmux
+}
+
+func (s tmuxPaneSource) Attach(ctx context.Context, name string) (<-chan []byte, error) {
+	stream, err := s.t.AttachControl(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = stream.Close()
+	}()
+	// Wait for the client to attach so output after the viewer's snapshot
+	// is not lost.
+	select {
+	case <-stream.Ready():
+	case <-ctx.Done():
+	}
+	return stream.Output(), nil
+}
+
+func (s tmuxPaneSource) Snapshot(name string) ([]byte, error) {
+	return s.t.CaptureScreen(name)
+}
+
+func (s tmuxPaneSource) Send(name string, data []byte) error {
+	return s.t.SendBytes(name, data)
+}
+
+// viewerBuffer is how many output chunks a viewer may fall behind before it
+// is disconnected, so one slow browser can't stall the shared stream.
+const viewerBuffer = 512
+
+// terminalAttachTimeout bounds how long join waits for tmux to attach.
+const terminalAttachTimeout = 10 * time.Second
+
+// errTerminalControlled is returned when another operator already holds takeover.
+var errTerminalControlled = errors.New("another operator has taken over this session")
+
+// terminalHub shares one pane stream per session among all connected viewers.
+type terminalHub struct {
+	src paneSource
+
+	mu      sync.Mutex
+	streams map[string]*terminalStream
+}
+
+type terminalStream struct {
+	cancel     context.CancelFunc
+	viewers    map[*terminalViewer]struct{}
+	controller *terminalViewer
+
+	// ready is closed once Attach returns; err is its failure, if any.
+	// Viewers that join a pending stream wait on ready outside the hub lock.
+	ready chan struct{}
+	err   error
+}
+
+// terminalViewer is one websocket's subscription. out is closed by the hub
+// when the stream ends or the viewer falls too far behind.
+type terminalViewer struct {
+	out chan []byte
+}
+
+func newTerminalHub(src paneSource) *terminalHub {
+	return &terminalHub{src: src, streams: make(map[string]*terminalStream)}
+}
+
+// join subscribes a viewer, starting the session's stream if it is the first.
+// The stream is recorded as pending under the lock and attached outside it,
+// so a slow tmux attach never blocks other sessions.
+func (h *terminalHub) join(name string) (*terminalViewer, error) {
+	v := &terminalViewer{out: make(chan []byte, viewerBuffer)}
+
+	h.mu.Lock()
+	if st, ok := h.streams[name]; ok {
+		st.viewers[v] = struct{}{}
+		h.mu.Unlock()
+		<-st.ready
+		if st.err != nil {
+			return nil, st.err
+		}
+		return v, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &terminalStream{
+		cancel:  cancel,
+		viewers: map[*terminalViewer]struct{}{v: {}},
+		ready:   make(chan struct{}),
+	}
+	h.streams[name] = st
+	h.mu.Unlock()
+
+	// ctx is the stream's lifetime, so the attach is bounded by cancelling
+	// it if tmux has not attached in time.
+	timer := time.AfterFunc(terminalAttachTimeout, cancel)
+	output, err := h.src.Attach(ctx, name)
+	if !timer.Stop() && err == nil {
+		err = fmt.Errorf("attaching to %s: timed out after %s", name, terminalAttachTimeout)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		cancel()
+		st.err = err
+		if h.streams[name] == st {
+			delete(h.streams, name)
+		}
+		close(st.ready)
+		return nil, err
+	}
+	close(st.ready)
+	go h.pump(name, st, output)
+	return v, nil
+}
+
+// pump fans pane output out to every viewer of a stream.
+func (h *terminalHub) pump(name string, st *terminalStream, output <-chan []byte) {
+	for data := range output {
+		h.mu.Lock()
+		for v := range st.viewers {
+			select {
+			case v.out <- data:
+			default:
+				h.dropLocked(st, v)
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for v := range st.viewers {
+		h.dropLocked(st, v)
+	}
+	if h.streams[name] == st {
+		delete(h.streams, name)
+	}
+	st.cancel()
+}
+
+// leave unsubscribes a viewer, stopping the stream when the last one leaves.
+func (h *terminalHub) leave(name string, v *terminalViewer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.streams[name]
+	if !ok {
+		return
+	}
+	h.dropLocked(st, v)
+	if len(st.viewers) == 0 {
+		delete(h.streams, name)
+		st.cancel()
+	}
+}
+
+func (h *terminalHub) dropLocked(st *terminalStream, v *terminalViewer) {
+	if _, ok := st.viewers[v]; !ok {
+		return
+	}
+	delete(st.viewers, v)
+	close(v.out)
+	if st.controller == v {
+		st.controller = nil
+	}
+}
+
+// claim gives a viewer exclusive input control of the session.
+func (h *terminalHub) claim(name string, v *terminalViewer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.streams[name]
+	if !ok {
+		return fmt.Errorf("session %s is not streaming", name)
+	}
+	if st.controller != nil && st.controller != v {
+		return errTerminalControlled
+	}
+	st.controller = v
+	return nil
+}
+
+// viewers reports how many clients are watching a session.
+func (h *terminalHub) viewers(name string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok := h.streams[name]; ok {
+		return len(st.viewers)
+	}
+	return 0
+}
+
+// TerminalControl is a JSON text frame on the terminal websocket. Pane output
+// travels in binary frames; keystrokes from the client travel in text frames.
+type TerminalControl struct {
+	Type    string `json:"type"` // "mode", "error", or "exit"
+	Mode    string `json:"mode,omitempty"`
+	Viewers int    `json:"viewers,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// maxTerminalInput bounds a single keystroke frame (pastes included).
+const maxTerminalInput = 64 * 1024
+
+// handleTerminal streams a session's pane over a websocket.
+// GET /api/v1/sessions/{session}/terminal[?mode=takeover&token=<csrf>]
+//
+// Watch mode is read-only. Takeover forwards keystrokes to the session; it
+// requires the operator role, is limited to crew and polecat sessions, and is
+// held by one client at a time. Takeover start and end are audited.
+func (h *APIHandler) handleTerminal(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("session")
+	if err := validateSessionName(name); err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	takeover := r.URL.Query().Get("mode") == "takeover"
+	if takeover {
+		if principalFromContext(r.Context()).Role < RoleOperator {
+			h.sendError(w, "Forbidden: take over requires operator role", http.StatusForbidden)
+			return
+		}
+		id, err := session.ParseSessionName(name)
+		if err != nil || (id.Role != session.RoleCrew && id.Role != session.RolePolecat) {
+			h.sendError(w, "Take over is limited to crew and polecat sessions", http.StatusForbidden)
+			return
+		}
+		// Websockets can't carry custom headers from a browser, so the CSRF
+		// token rides in the query string.
+		if h.csrfToken != "" && !csrfExempt(r) && r.URL.Query().Get("token") != h.csrfToken {
+			h.sendError(w, "Invalid or missing dashboard token", http.StatusForbidden)
+			return
+		}
+	}
+
+	srv := websocket.Server{
+		Handshake: sameOriginHandshake,
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxTerminalInput
+			h.streamTerminal(ws, r, name, takeover)
+		},
+	}
+	srv.ServeHTTP(w, r)
+}
+
+func (h *APIHandler) streamTerminal(ws *websocket.Conn, r *http.Request, name string, takeover bool) {
+	defer ws.Close()
+
+	v, err := h.terminals.join(name)
+	if err != nil {
+		_ = websocket.JSON.Send(ws, TerminalControl{Type: "error", Error: err.Error()})
+		return
+	}
+	defer h.terminals.leave(name, v)
+
+	mode := "watch"
+	var sent int
+	if takeover {
+		if err := h.terminals.claim(name, v); err != nil {
+			_ = websocket.JSON.Send(ws, TerminalControl{Type: "error", Error: err.Error()})
+			return
+		}
+		mode = "takeover"
+		start := time.Now()
+		auditNow(r, http.StatusSwitchingProtocols, map[string]string{"action": "terminal_takeover", "session": name})
+		defer func() {
+			auditNow(r, http.StatusOK, map[string]string{
+				"action":   "terminal_release",
+				"session":  name,
+				"bytes":    strconv.Itoa(sent),
+				"duration": time.Since(start).Round(time.Second).String(),
+			})
+		}()
+	}
+	done := h.readTerminalInput(ws, name, takeover, &sent)
+	defer func() {
+		_ = ws.Close() // unblocks the reader
+		<-done
+	}()
+
+	_ = websocket.JSON.Send(ws, TerminalControl{Type: "mode", Mode: mode, Viewers: h.terminals.viewers(name)})
+	if screen, err := h.terminals.src.Snapshot(name); err == nil {
+		if err := websocket.Message.Send(ws, screen); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case data, ok := <-v.out:
+			if !ok {
+				_ = websocket.JSON.Send(ws, TerminalControl{Type: "exit"})
+				return
+			}
+			if err := websocket.Message.Send(ws, data); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// readTerminalInput reads client frames until the client disconnects. In
+// takeover mode text frames are relayed to the session as keystrokes and
+// counted in sent; in watch mode they are dropped. The returned channel is
+// closed when the client goes away.
+func (h *APIHandler) readTerminalInput(ws *websocket.Conn, name string, takeover bool, sent *int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if !takeover || len(msg) == 0 {
+				continue
+			}
+			if err := h.terminals.src.Send(name, msg); err != nil {
+				_ = websocket.JSON.Send(ws, TerminalControl{Type: "error", Error: err.Error()})
+				continue
+			}
+			*sent += len(msg)
+		}
+	}()
+	return done
+}
+
+// sameOriginHandshake rejects cross-site websocket connections. Browsers send
+// ambient credentials on websocket upgrades without CORS checks, so Origin is
+// the only defence; non-browser clients send no Origin and are allowed.
+func sameOriginHandshake(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return fmt.Errorf("cross-origin websocket rejected: %s", origin)
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/session"
+)
+
+// fakePane is a paneSource whose output is driven by the test.
+type fakePane struct {
+	mu      sync.Mutex
+	attach  int
+	outputs []chan []byte
+	sent    []string
+}
+
+func (f *fakePane) Attach(ctx context.Context, _ string) (<-chan []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attach++
+	ch := make(chan []byte, 16)
+	f.outputs = append(f.outputs, ch)
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, c := range f.outputs {
+			if c == ch {
+				close(ch)
+				f.outputs = append(f.outputs[:i], f.outputs[i+1:]...)
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (f *fakePane) Snapshot(string) ([]byte, error) { return []byte("SCREEN"), nil }
+
+func (f *fakePane) Send(_ string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, string(data))
+	return nil
+}
+
+func (f *fakePane) emit(data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range f.outputs {
+		ch <- []byte(data)
+	}
+}
+
+func (f *fakePane) snapshot() (attach int, sent []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attach, append([]string(nil), f.sent...)
+}
+
+// newTerminalTestServer returns the server, its fake pane, and a func that
+// snapshots the audit entries recorded so far.
+func newTerminalTestServer(t *testing.T, auth *config.WebAuthConfig) (*httptest.Server, *fakePane, func() []AuditEntry) {
+	t.Helper()
+	original := session.DefaultRegistry()
+	t.Cleanup(func() { session.SetDefaultRegistry(original) })
+	reg := session.NewPrefixRegistry()
+	reg.Register("gt", "gastown")
+	session.SetDefaultRegistry(reg)
+
+	pane := &fakePane{}
+	api := newFastAPIHandler(t)
+	api.terminals = newTerminalHub(pane)
+
+	var mu sync.Mutex
+	var audit []AuditEntry
+	h, err := WithAuth(api, auth, func(e AuditEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		audit = append(audit, e)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, pane, func() []AuditEntry {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]AuditEntry(nil), audit...)
+	}
+}
+
+func dialTerminal(t *testing.T, srv *httptest.Server, path string, header http.Header) *websocket.Conn {
+	t.Helper()
+	cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(srv.URL, "http")+path, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		cfg.Header[k] = v
+	}
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		t.Fatalf("dial %s: %v", path, err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	_ = ws.SetDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func recvControl(t *testing.T, ws *websocket.Conn) TerminalControl {
+	t.Helper()
+	var c TerminalControl
+	if err := websocket.JSON.Receive(ws, &c); err != nil {
+		t.Fatalf("receive control frame: %v", err)
+	}
+	return c
+}
+
+func recvData(t *testing.T, ws *websocket.Conn) string {
+	t.Helper()
+	var b []byte
+	if err := websocket.Message.Receive(ws, &b); err != nil {
+		t.Fatalf("receive data frame: %v", err)
+	}
+	return string(b)
+}
+
+func TestTerminal_ViewersShareOneStream(t *testing.T) {
+	srv, pane, _ := newTerminalTestServer(t, nil)
+
+	a := dialTerminal(t, srv, "/api/v1/sessions/gt-crew-max/terminal", nil)
+	if c := recvControl(t, a); c.Mode != "watch" || c.Viewers != 1 {
+		t.Fatalf("first viewer control = %+v", c)
+	}
+	if got := recvData(t, a); got != "SCREEN" {
+		t.Fatalf("first frame = %q, want snapshot", got)
+	}
+	b := dialTerminal(t, srv, "/api/v1/sessions/gt-crew-max/terminal", nil)
+	if c := recvControl(t, b); c.Viewers != 2 {
+		t.Fatalf("second viewer control = %+v", c)
+	}
+	recvData(t, b)
+
+	pane.emit("hello")
+	if got := recvData(t, a); got != "hello" {
+		t.Errorf("viewer a got %q", got)
+	}
+	if got := recvData(t, b); got != "hello" {
+		t.Errorf("viewer b got %q", got)
+	}
+
+	// Watchers can't type.
+	_ = websocket.Message.Send(a, "rm -rf /\r")
+	pane.emit("tick")
+	recvData(t, a)
+	if attach, sent := pane.snapshot(); attach != 1 || len(sent) != 0 {
+		t.Errorf("attach = %d, sent = %q; want one shared stream and no input", attach, sent)
+	}
+}
+
+// slowPane blocks Attach for one session until release is closed.
+type slowPane struct {
+	fakePane
+	slow    string
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *slowPane) Attach(ctx context.Context, name string) (<-chan []byte, error) {
+	if name == p.slow {
+		close(p.entered)
+		<-p.release
+	}
+	return p.fakePane.Attach(ctx, name)
+}
+
+func TestTerminalHub_SlowAttachDoesNotBlockOtherSessions(t *testing.T) {
+	pane := &slowPane{slow: "gt-slow", entered: make(chan struct{}), release: make(chan struct{})}
+	hub := newTerminalHub(pane)
+
+	slowJoined := make(chan error, 2)
+	go func() {
+		_, err := hub.join("gt-slow")
+		slowJoined <- err
+	}()
+	<-pane.entered
+	// A second viewer of the pending session waits for the same attach.
+	go func() {
+		_, err := hub.join("gt-slow")
+		slowJoined <- err
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := hub.join("gt-fast")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("join fast session: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("join of another session blocked behind a pending attach")
+	}
+
+	close(pane.release)
+	for i := 0; i < 2; i++ {
+		if err := <-slowJoined; err != nil {
+			t.Errorf("join slow session: %v", err)
+		}
+	}
+	if got := hub.viewers("gt-slow"); got != 2 {
+		t.Errorf("viewers(gt-slow) = %d, want 2", got)
+	}
+	if attach, _ := pane.snapshot(); attach != 2 {
+		t.Errorf("attach = %d, want one per session", attach)
+	}
+}
+
+func TestTerminal_Takeover(t *testing.T) {
+	srv, pane, audit := newTerminalTestServer(t, nil)
+
+	ws := dialTerminal(t, srv, "/api/v1/sessions/gt-crew-max/terminal?mode=takeover&token=test-token", nil)
+	if c := recvControl(t, ws); c.Mode != "takeover" {
+		t.Fatalf("control = %+v", c)
+	}
+	recvData(t, ws)
+
+	// A second operator can watch but not take over.
+	other := dialTerminal(t, srv, "/api/v1/sessions/gt-crew-max/terminal?mode=takeover&token=test-token", nil)
+	if c := recvControl(t, other); c.Type != "error" {
+		t.Errorf("second takeover = %+v, want error", c)
+	}
+
+	if err := websocket.Message.Send(ws, "ls\r"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, sent := pane.snapshot(); len(sent) == 1 && sent[0] == "ls\r" {
+			break
+		}
+		if time.Now().After(deadline) {
+			_, sent := pane.snapshot()
+			t.Fatalf("sent = %q, want [ls\\r]", sent)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_ = ws.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		entries := audit()
+		var actions []string
+		for _, e := range entries {
+			actions = append(actions, e.Detail["action"])
+		}
+		if strings.Join(actions, ",") == "terminal_takeover,terminal_release" {
+			if entries[1].Detail["bytes"] != "3" {
+				t.Errorf("release detail = %v", entries[1].Detail)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("audit actions = %v", actions)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTerminal_TakeoverRejected(t *testing.T) {
+	tok, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &config.WebAuthConfig{Tokens: []config.WebAuthToken{{Name: "v", Role: "viewer", Hash: HashToken(tok)}}}
+	srv, _, _ := newTerminalTestServer(t, auth)
+	viewer := http.Header{"Authorization": {"Bearer " + tok}}
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		want   int
+	}{
+		{"viewer role", "/api/v1/sessions/gt-crew-max/terminal?mode=takeover", viewer, http.StatusForbidden},
+		{"bad session", "/api/v1/sessions/nope/terminal", viewer, http.StatusBadRequest},
+		{"unauthenticated", "/api/v1/sessions/gt-crew-max/terminal", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminal_TakeoverLimitedToWorkers(t *testing.T) {
+	srv, _, _ := newTerminalTestServer(t, nil)
+	for _, name := range []string{"hq-mayor", "gt-witness"} {
+		resp, err := http.Get(srv.URL + "/api/v1/sessions/" + name + "/terminal?mode=takeover&token=test-token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s takeover status = %d, want 403", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestSameOriginHandshake(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://dash.example:8080/api/v1/sessions/gt-crew-max/terminal", nil)
+	if err := sameOriginHandshake(nil, req); err != nil {
+		t.Errorf("no origin: %v", err)
+	}
+	req.Header.Set("Origin", "http://dash.example:8080")
+	if err := sameOriginHandshake(nil, req); err != nil {
+		t.Errorf("same origin: %v", err)
+	}
+	req.Header.Set("Origin", "https://evil.example")
+	if err := sameOriginHandshake(nil, req); err == nil {
+		t.Error("cross origin accepted")
+	}
+}
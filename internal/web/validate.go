@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/steveyegge/gastown/internal/session"
 )
 
 // Validation patterns for user input.
@@ -31,6 +33,20 @@ func isValidID(s string) bool {
 	return len(s) > 0 && len(s) <= 200 && idPattern.MatchString(s)
 }
 
+// validateSessionName checks that a tmux session name belongs to Gas Town:
+// it must start with a known prefix and contain only safe characters.
+func validateSessionName(name string) error {
+	if !session.HasKnownPrefix(name) {
+		return fmt.Errorf("Invalid session name: must start with a known rig prefix")
+	}
+	for _, c := range name {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
+			return fmt.Errorf("Invalid session name: contains invalid characters")
+		}
+	}
+	return nil
+}
+
 // isValidRigName checks if a string is a valid rig name.
 // Rig names allow only alphanumeric + underscore (no hyphens, dots, or spaces),
 // matching the constraint in internal/rig/manager.go:AddRig.
@@ -267,12 +267,12 @@ func TestExpandHomePath(t *testing.T) {
 func TestHandler_MailRead_InvalidID(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
-	req := httptest.NewRequest(http.MethodGet, "/api/mail/read?id=--inject", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/mail/messages/--inject", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("GET /api/mail/read?id=--inject status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("GET /api/v1/mail/messages/--inject status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -280,14 +280,14 @@ func TestHandler_MailSend_InvalidRecipient(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	body := `{"to": "--flag", "subject": "test"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/mail/send", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/mail/send flag recipient status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/mail/send flag recipient status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -295,23 +295,23 @@ func TestHandler_MailSend_ValidAgentPath(t *testing.T) {
 	handler := newFastAPIHandler(t)
 
 	// rig/agent is a valid mail address — should NOT be rejected by validation.
-	// gt isn't available in test, so expect 500 (command failed), NOT 400 (validation).
+	// The test handler is outside a town, so expect 503, NOT 400 (validation).
 	body := `{"to": "myrig/agent", "subject": "test"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/mail/send", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code == http.StatusBadRequest {
-		t.Errorf("POST /api/mail/send with rig/agent address rejected as bad request (should pass validation)")
+		t.Errorf("POST /api/v1/mail/send with rig/agent address rejected as bad request (should pass validation)")
 	}
 	// Verify the response doesn't mention validation failure
 	var resp map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err == nil {
 		if errMsg, ok := resp["error"].(string); ok {
 			if strings.Contains(errMsg, "Invalid recipient") {
-				t.Errorf("expected gt execution error, got validation error: %s", errMsg)
+				t.Errorf("expected no-town error, got validation error: %s", errMsg)
 			}
 		}
 	}
@@ -325,26 +325,26 @@ func TestHandler_MailSend_OversizedSubject(t *testing.T) {
 		"subject": strings.Repeat("x", 501),
 	}
 	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/api/mail/send", bytes.NewBuffer(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/mail/send oversized subject status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/mail/send oversized subject status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
 func TestHandler_IssueShow_InvalidID(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
-	req := httptest.NewRequest(http.MethodGet, "/api/issues/show?id=--help", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/issues/--help", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("GET /api/issues/show?id=--help status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("GET /api/v1/issues/--help status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -376,20 +376,20 @@ func TestHandler_IssueShow_ExternalPrefixID(t *testing.T) {
 	handler := newFastAPIHandler(t)
 
 	// external:prefix:id format should pass validation (unwrapped to raw ID).
-	// Expect 500 (bd not available), NOT 400 (validation failure).
-	req := httptest.NewRequest(http.MethodGet, "/api/issues/show?id=external:gt-mol:gt-mol-abc123", nil)
+	// The test handler is outside a town, so expect 503, NOT 400 (validation failure).
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/issues/external:gt-mol:gt-mol-abc123", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code == http.StatusBadRequest {
-		t.Errorf("GET /api/issues/show with external:prefix:id rejected as bad request (should unwrap)")
+		t.Errorf("GET /api/v1/issues/{id} with external:prefix:id rejected as bad request (should unwrap)")
 	}
-	// Verify it got past validation (error should be about bd execution, not ID format)
+	// Verify it got past validation (error should be about the missing town, not ID format)
 	var resp map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err == nil {
 		if errMsg, ok := resp["error"].(string); ok {
 			if strings.Contains(errMsg, "Invalid issue ID") {
-				t.Errorf("expected execution error, got validation error: %s", errMsg)
+				t.Errorf("expected no-town error, got validation error: %s", errMsg)
 			}
 		}
 	}
@@ -411,7 +411,7 @@ func TestHandler_PRShow_URLIgnoresRepoNumber(t *testing.T) {
 	if err := json.NewDecoder(w.Body).Decode(&resp); err == nil {
 		if errMsg, ok := resp["error"].(string); ok {
 			if strings.Contains(errMsg, "Invalid PR number") || strings.Contains(errMsg, "Invalid repo") {
-				t.Errorf("expected execution error, got validation error: %s", errMsg)
+				t.Errorf("expected no-town error, got validation error: %s", errMsg)
 			}
 		}
 	}
@@ -512,12 +512,12 @@ func TestHandler_IssueShow_MalformedExternalPrefix(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	// external:foo (only 2 parts) should get a specific error, not generic "Invalid issue ID".
-	req := httptest.NewRequest(http.MethodGet, "/api/issues/show?id=external:foo", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/issues/external:foo", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("GET /api/issues/show?id=external:foo status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("GET /api/v1/issues/external:foo status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 	var resp map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err == nil {
@@ -533,12 +533,12 @@ func TestHandler_IssueShow_ExternalWithExtraColons(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	// SplitN(":", 3) puts "id:with:colons" in parts[2]. isValidID rejects colons.
-	req := httptest.NewRequest(http.MethodGet, "/api/issues/show?id=external:prefix:id:with:colons", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/issues/external:prefix:id:with:colons", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("GET /api/issues/show external:prefix:id:with:colons status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("GET /api/v1/issues/{id} external:prefix:id:with:colons status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 	var resp map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err == nil {
@@ -554,14 +554,14 @@ func TestHandler_MailSend_NullByteSubject(t *testing.T) {
 	handler := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
 
 	body := `{"to": "alice", "subject": "test\u0000inject"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/mail/send", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mail/send", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("POST /api/mail/send null-byte subject status = %d, want %d", w.Code, http.StatusBadRequest)
+		t.Errorf("POST /api/v1/mail/send null-byte subject status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -569,17 +569,17 @@ func TestHandler_IssueCreate_FlagTitle(t *testing.T) {
 	handler := newFastAPIHandler(t)
 
 	// A title of "--help" should pass validation (no control chars, no newlines)
-	// and reach bd create. The -- sentinel ensures it's treated as positional.
-	// Expect 500 (bd not available), NOT 400.
+	// and reach beads create. Issues are created in-process with --title=, so
+	// there is no argv to inject into. Expect a non-400 failure (no town), NOT 400.
 	body := `{"title": "--help"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/issues/create", bytes.NewBufferString(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/issues", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Dashboard-Token", "test-token")
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
 	if w.Code == http.StatusBadRequest {
-		t.Errorf("POST /api/issues/create with title=--help rejected as bad request (-- sentinel should protect)")
+		t.Errorf("POST /api/v1/issues with title=--help rejected as bad request")
 	}
 }
 